@@ -1,11 +1,18 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/natevick/stui/internal/aws"
+	"github.com/natevick/stui/internal/cli"
+	"github.com/natevick/stui/internal/config"
 	"github.com/natevick/stui/internal/security"
 	"github.com/natevick/stui/internal/tui"
 )
@@ -20,6 +27,8 @@ func main() {
 	region := flag.String("region", os.Getenv("AWS_REGION"), "AWS region (can also use AWS_REGION env var)")
 	bucket := flag.String("bucket", "", "Start directly in this S3 bucket")
 	demo := flag.Bool("demo", false, "Run with mock data (no AWS credentials needed)")
+	readOnly := flag.Bool("read-only", false, "Disable every operation that changes S3")
+	noSign := flag.Bool("no-sign-request", false, "Browse public buckets without credentials (implies --read-only)")
 	showVersion := flag.Bool("version", false, "Show version and exit")
 	flag.Parse()
 
@@ -38,12 +47,50 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Load user settings
+	settings, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid config: %v\n", err)
+		os.Exit(1)
+	}
+	security.SetStrictness(settings.Strictness())
+
+	// Clear out part files left behind by a crash
+	if maxAge := settings.PartMaxAge(); maxAge > 0 {
+		if _, err := aws.SweepPartFiles(settings.ClientOptions().TempDir(), maxAge, time.Now()); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+
+	// Subcommands run without the TUI
+	if flag.NArg() > 0 {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		env := cli.Env{Stdin: os.Stdin, Stdout: os.Stdout, Stderr: os.Stderr}
+		code := cli.Run(ctx, flag.Args(), env, func(ctx context.Context, p string) (*aws.Client, error) {
+			if p == "" {
+				p = *profile
+			}
+			opts := settings.ClientOptions()
+			opts.ReadOnly = opts.ReadOnly || *readOnly
+			opts.Anonymous = *noSign
+			if dir, err := config.Dir(); err == nil {
+				opts.UploadJournal = aws.NewUploadJournal(filepath.Join(dir, "uploads"))
+			}
+			return aws.NewClient(ctx, p, *region, opts)
+		})
+		stop()
+		os.Exit(code)
+	}
+
 	// Create TUI model
 	cfg := tui.Config{
-		Profile:  *profile,
-		Region:   *region,
-		Bucket:   *bucket,
-		DemoMode: *demo,
+		Profile:   *profile,
+		Region:    *region,
+		Bucket:    *bucket,
+		DemoMode:  *demo,
+		ReadOnly:  *readOnly,
+		Anonymous: *noSign,
+		Settings:  settings,
 	}
 
 	model := tui.New(cfg)
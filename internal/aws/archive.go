@@ -0,0 +1,86 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/natevick/stui/internal/security"
+)
+
+// ErrUnverifiable is returned when an object's ETag can't be reproduced from
+// its bytes, so a local copy can't be proven to match it
+var ErrUnverifiable = errors.New("object can't be verified locally")
+
+// ArchiveToLocal moves an object off S3: it downloads key into localDir,
+// checks the file against the object's ETag, and deletes the source only
+// once the copy is verified and the object hasn't changed in the meantime.
+// Objects whose ETag isn't a content hash (KMS or customer-key encryption)
+// are refused before anything is downloaded. A copy that fails verification
+// is removed and the source is left alone. It returns the local path, which
+// is set whenever a verified copy exists, even if the delete then failed.
+// A file already at the local path is never replaced: keys sharing a base
+// name would otherwise leave only the last copy of objects already deleted.
+func (c *Client) ArchiveToLocal(ctx context.Context, bucket, key, localDir string) (string, error) {
+	if err := c.checkWritable(bucket); err != nil {
+		return "", err
+	}
+	localPath, err := security.SafePath(localDir, path.Base(key))
+	if err != nil {
+		return "", fmt.Errorf("unsafe path for key %s: %w", key, err)
+	}
+	if _, err := os.Lstat(localPath); err == nil {
+		return "", &OpError{Op: "Archiving", Bucket: bucket, Key: key,
+			Err: fmt.Errorf("%s: %w", localPath, os.ErrExist)}
+	} else if !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to check local file: %w", err)
+	}
+
+	obj, err := c.GetObjectMetadata(ctx, bucket, key)
+	if err != nil {
+		return "", err
+	}
+	if !reproducibleETag(obj) {
+		return "", &OpError{Op: "Archiving", Bucket: bucket, Key: key, Err: ErrUnverifiable}
+	}
+
+	if err := c.DownloadFile(ctx, bucket, key, localPath, nil); err != nil {
+		switch {
+		case errors.Is(err, ErrETagUnverifiable):
+			os.Remove(localPath)
+			return "", &OpError{Op: "Archiving", Bucket: bucket, Key: key, Err: ErrUnverifiable}
+		case errors.Is(err, ErrIntegrityCheckFailed):
+			os.Remove(localPath)
+		}
+		return "", err
+	}
+	if err := CompareETag(localPath, obj.ETag); err != nil {
+		os.Remove(localPath)
+		if errors.Is(err, ErrETagUnverifiable) {
+			return "", &OpError{Op: "Archiving", Bucket: bucket, Key: key, Err: ErrUnverifiable}
+		}
+		return "", err
+	}
+
+	// Don't delete a newer version than the one that was verified
+	current, err := c.GetObjectMetadata(ctx, bucket, key)
+	if err != nil {
+		return localPath, err
+	}
+	if current.ETag != obj.ETag {
+		return localPath, &OpError{Op: "Archiving", Bucket: bucket, Key: key,
+			Err: fmt.Errorf("object changed during download, source kept")}
+	}
+
+	failures, err := c.DeleteObjects(ctx, bucket, []string{key})
+	if err != nil {
+		return localPath, err
+	}
+	if len(failures) > 0 {
+		return localPath, &OpError{Op: "Deleting archived object", Bucket: bucket, Key: key,
+			Err: errors.New(failures[0].Message)}
+	}
+	return localPath, nil
+}
@@ -0,0 +1,104 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestArchiveToLocal(t *testing.T) {
+	tests := []struct {
+		name       string
+		etag       string
+		options    ClientOptions
+		wantErr    error
+		wantSource bool
+		wantLocal  bool
+	}{
+		{"verified copy deletes source", "", ClientOptions{}, nil, false, true},
+		{"failed verification keeps source", "0123456789abcdef0123456789abcdef", ClientOptions{}, ErrIntegrityCheckFailed, true, false},
+		{"failed download verification keeps source", "0123456789abcdef0123456789abcdef", ClientOptions{VerifyDownloads: true}, ErrIntegrityCheckFailed, true, false},
+		{"unverifiable ETag", "not-a-content-hash", ClientOptions{}, ErrUnverifiable, true, false},
+		{"read-only", "", ClientOptions{ReadOnly: true}, ErrReadOnlyMode, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeS3()
+			fake.put("b", "logs/2024.tar", fakeObject{body: []byte("archive payload"), etagOverride: tt.etag})
+			tt.options.DownloadTempDir = t.TempDir()
+			client := &Client{S3: fake, Options: tt.options}
+
+			dir := t.TempDir()
+			localPath, err := client.ArchiveToLocal(context.Background(), "b", "logs/2024.tar", dir)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("ArchiveToLocal() error = %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ArchiveToLocal() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if _, ok := fake.get("b", "logs/2024.tar"); ok != tt.wantSource {
+				t.Errorf("source exists = %v, want %v", ok, tt.wantSource)
+			}
+			_, statErr := os.Stat(filepath.Join(dir, "2024.tar"))
+			if exists := statErr == nil; exists != tt.wantLocal {
+				t.Errorf("local copy exists = %v, want %v", exists, tt.wantLocal)
+			}
+			if tt.wantLocal && localPath != filepath.Join(dir, "2024.tar") {
+				t.Errorf("local path = %q", localPath)
+			}
+			if !tt.wantLocal && fake.countCalls("DeleteObjects") != 0 {
+				t.Error("source delete attempted without a verified copy")
+			}
+		})
+	}
+}
+
+func TestArchiveToLocalReportsDeleteFailure(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("b", "a.bin", fakeObject{body: []byte("data")})
+	fake.errFor = func(op, key string) error {
+		if op == "DeleteObjects.Key" {
+			return errors.New("AccessDenied")
+		}
+		return nil
+	}
+	client := &Client{S3: fake, Options: ClientOptions{DownloadTempDir: t.TempDir()}}
+
+	localPath, err := client.ArchiveToLocal(context.Background(), "b", "a.bin", t.TempDir())
+	if err == nil {
+		t.Fatal("ArchiveToLocal() error = nil, want delete failure")
+	}
+	if localPath == "" {
+		t.Error("local path not reported for the verified copy")
+	}
+}
+
+func TestArchiveToLocalKeepsSourceWhenNameIsTaken(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("b", "a/report.csv", fakeObject{body: []byte("from a")})
+	fake.put("b", "b/report.csv", fakeObject{body: []byte("from b")})
+	client := &Client{S3: fake, Options: ClientOptions{DownloadTempDir: t.TempDir()}}
+	dir := t.TempDir()
+
+	if _, err := client.ArchiveToLocal(context.Background(), "b", "a/report.csv", dir); err != nil {
+		t.Fatalf("ArchiveToLocal(a) error = %v", err)
+	}
+	_, err := client.ArchiveToLocal(context.Background(), "b", "b/report.csv", dir)
+	if !errors.Is(err, os.ErrExist) {
+		t.Fatalf("ArchiveToLocal(b) error = %v, want the existing file refused", err)
+	}
+
+	if got, _ := os.ReadFile(filepath.Join(dir, "report.csv")); string(got) != "from a" {
+		t.Errorf("local copy = %q, want a's archive untouched", got)
+	}
+	if _, ok := fake.get("b", "b/report.csv"); !ok {
+		t.Error("b/report.csv deleted without an archived copy")
+	}
+	if fake.countCalls("GetObject") != 1 {
+		t.Error("b/report.csv downloaded despite the name being taken")
+	}
+}
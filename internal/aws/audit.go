@@ -0,0 +1,151 @@
+package aws
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/natevick/stui/internal/security"
+)
+
+// DefaultAuditConcurrency is the number of objects checked in parallel
+// when the caller doesn't specify a limit
+const DefaultAuditConcurrency = 8
+
+// AuditResult is the outcome of checking one object
+type AuditResult struct {
+	Key  string
+	Size int64
+	// Err is nil when the object checked out. It is an *OpError, so its
+	// message is sanitized.
+	Err error
+}
+
+// AuditOptions configures Audit
+type AuditOptions struct {
+	// Concurrency bounds the checks in flight; zero uses DefaultAuditConcurrency
+	Concurrency int
+	// ReadByte also fetches each object's first byte, which catches data that
+	// HEAD can't, such as a disabled KMS key. Objects in Glacier Flexible
+	// Retrieval and Deep Archive are only HEADed.
+	ReadByte bool
+}
+
+// AuditPrefix HEADs every object under prefix, streaming one result per
+// object. See Audit.
+func (c *Client) AuditPrefix(ctx context.Context, bucket, prefix string, concurrency int) (<-chan AuditResult, error) {
+	return c.Audit(ctx, bucket, prefix, AuditOptions{Concurrency: concurrency})
+}
+
+// Audit checks every object under prefix and streams one result per object,
+// in no particular order. A failed listing is reported as a result keyed by
+// the prefix. The channel is closed once every object has been checked or
+// ctx ends; callers should keep reading until then.
+func (c *Client) Audit(ctx context.Context, bucket, prefix string, opts AuditOptions) (<-chan AuditResult, error) {
+	if err := security.ValidBucketName(bucket); err != nil {
+		return nil, err
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultAuditConcurrency
+	}
+
+	objects := make(chan S3Object)
+	results := make(chan AuditResult)
+	send := func(r AuditResult) bool {
+		select {
+		case results <- r:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(objects)
+
+		lister := c.NewLister(bucket, prefix, "")
+		for lister.HasMorePages() {
+			page, err := lister.NextPage(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					send(AuditResult{Key: prefix, Err: err})
+				}
+				return
+			}
+			for _, obj := range page {
+				if obj.IsPrefix {
+					continue
+				}
+				select {
+				case objects <- obj:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for obj := range objects {
+				if ctx.Err() != nil {
+					return
+				}
+				if !send(c.auditObject(ctx, bucket, obj, opts.ReadByte)) {
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	return results, nil
+}
+
+// auditObject HEADs one object and optionally reads its first byte
+func (c *Client) auditObject(ctx context.Context, bucket string, obj S3Object, readByte bool) AuditResult {
+	result := AuditResult{Key: obj.Key, Size: obj.Size}
+
+	head, err := c.S3.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(obj.Key),
+	})
+	if err != nil {
+		result.Err = &OpError{Op: "Auditing object", Bucket: bucket, Key: obj.Key, Err: err}
+		return result
+	}
+	result.Size = aws.ToInt64(head.ContentLength)
+
+	archived := head.StorageClass == types.StorageClassGlacier || head.StorageClass == types.StorageClassDeepArchive
+	if !readByte || archived || result.Size == 0 {
+		return result
+	}
+
+	output, err := c.S3.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(obj.Key),
+		Range:  aws.String("bytes=0-0"),
+	})
+	if err == nil {
+		var n int64
+		n, err = io.Copy(io.Discard, output.Body)
+		output.Body.Close()
+		c.Options.Metrics.AddBytesDown(n)
+	}
+	if err != nil {
+		result.Err = &OpError{Op: "Reading object", Bucket: bucket, Key: obj.Key, Err: err}
+	}
+	return result
+}
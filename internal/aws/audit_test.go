@@ -0,0 +1,143 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func auditFixture(n int) *fakeS3 {
+	fake := newFakeS3()
+	for i := range n {
+		fake.put("archive", fmt.Sprintf("2024/file-%02d.bin", i), fakeObject{body: []byte("data")})
+	}
+	return fake
+}
+
+func TestAuditPrefixStreamsResults(t *testing.T) {
+	fake := auditFixture(5)
+	fake.put("archive", "2024/cold.bin", fakeObject{body: []byte("ice"), storageClass: types.StorageClassDeepArchive})
+	fake.errFor = func(op, key string) error {
+		if op == "HeadObject" && key == "2024/file-03.bin" {
+			return errors.New("AccessDenied: account 123456789012")
+		}
+		return nil
+	}
+	client := &Client{S3: fake}
+
+	results, err := client.Audit(context.Background(), "archive", "2024/", AuditOptions{Concurrency: 2, ReadByte: true})
+	if err != nil {
+		t.Fatalf("Audit() error = %v", err)
+	}
+
+	got := make(map[string]AuditResult)
+	for r := range results {
+		got[r.Key] = r
+	}
+	if len(got) != 6 {
+		t.Fatalf("got %d results, want 6: %v", len(got), got)
+	}
+	for key, r := range got {
+		if key == "2024/file-03.bin" {
+			var opErr *OpError
+			if !errors.As(r.Err, &opErr) {
+				t.Fatalf("%s error = %v, want an OpError", key, r.Err)
+			}
+			if strings.Contains(r.Err.Error(), "123456789012") {
+				t.Errorf("error message not sanitized: %q", r.Err.Error())
+			}
+			continue
+		}
+		if r.Err != nil || r.Size == 0 {
+			t.Errorf("%s = %+v, want a clean result with its size", key, r)
+		}
+	}
+
+	// The failed HEAD and the archived object are not read
+	if n := fake.countCalls("GetObject"); n != 4 {
+		t.Errorf("made %d ranged GETs, want 4", n)
+	}
+}
+
+func TestAuditPrefixBoundsConcurrency(t *testing.T) {
+	fake := auditFixture(30)
+	var inFlight, peak int32
+	fake.errFor = func(op, key string) error {
+		if op != "HeadObject" {
+			return nil
+		}
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		return nil
+	}
+	client := &Client{S3: fake}
+
+	results, err := client.AuditPrefix(context.Background(), "archive", "", 3)
+	if err != nil {
+		t.Fatalf("AuditPrefix() error = %v", err)
+	}
+	count := 0
+	for range results {
+		count++
+	}
+	if count != 30 {
+		t.Errorf("got %d results, want 30", count)
+	}
+	if peak > 3 {
+		t.Errorf("peak concurrent HEADs = %d, want <= 3", peak)
+	}
+	if peak < 2 {
+		t.Errorf("peak concurrent HEADs = %d, expected checks to run in parallel", peak)
+	}
+}
+
+func TestAuditPrefixCancellation(t *testing.T) {
+	fake := auditFixture(50)
+	client := &Client{S3: fake}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	results, err := client.AuditPrefix(ctx, "archive", "", 2)
+	if err != nil {
+		t.Fatalf("AuditPrefix() error = %v", err)
+	}
+	<-results
+	cancel()
+
+	done := make(chan int)
+	go func() {
+		n := 1
+		for range results {
+			n++
+		}
+		done <- n
+	}()
+	select {
+	case n := <-done:
+		if n >= 50 {
+			t.Errorf("audit checked all %d objects after cancellation", n)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("results channel not closed after cancellation")
+	}
+}
+
+func TestAuditPrefixRejectsBadBucket(t *testing.T) {
+	client := &Client{S3: newFakeS3()}
+	if _, err := client.AuditPrefix(context.Background(), "Bad_Bucket", "", 1); err == nil {
+		t.Error("expected error for invalid bucket name")
+	}
+}
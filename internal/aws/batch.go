@@ -0,0 +1,41 @@
+package aws
+
+import (
+	"fmt"
+)
+
+// ObjectResult is the outcome of a batch operation on one object
+type ObjectResult struct {
+	Key    string
+	NewKey string // destination key, for operations that write one
+	Err    error
+}
+
+// PartialFailureError reports a batch in which some objects failed.
+// S3 has no transactions, so objects that succeeded are left in place.
+type PartialFailureError struct {
+	Failed []ObjectResult
+	Total  int
+}
+
+func (e *PartialFailureError) Error() string {
+	if len(e.Failed) == 0 {
+		return fmt.Sprintf("0 of %d objects failed", e.Total)
+	}
+	first := e.Failed[0]
+	return fmt.Sprintf("%d of %d objects failed (first: %s: %v)", len(e.Failed), e.Total, first.Key, first.Err)
+}
+
+// batchError returns a *PartialFailureError if any result failed, or nil
+func batchError(results []ObjectResult) error {
+	var failed []ObjectResult
+	for _, r := range results {
+		if r.Err != nil {
+			failed = append(failed, r)
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return &PartialFailureError{Failed: failed, Total: len(results)}
+}
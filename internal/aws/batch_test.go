@@ -0,0 +1,200 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRenamePrefix(t *testing.T) {
+	fake := newFakeS3()
+	keys := []string{
+		"logs/2023/01/app.log",
+		"logs/2023/01/db.log",
+		"logs/2023/02/app.log",
+		"logs/2023/summary.txt",
+	}
+	for _, k := range keys {
+		fake.put("b", k, fakeObject{body: []byte(k)})
+	}
+	fake.put("b", "logs/2024/app.log", fakeObject{body: []byte("untouched")})
+	client := &Client{S3: fake}
+
+	results, err := client.RenamePrefix(context.Background(), "b", "logs/2023/", "archive/2023")
+	if err != nil {
+		t.Fatalf("RenamePrefix() error = %v", err)
+	}
+	if len(results) != len(keys) {
+		t.Fatalf("got %d results, want %d", len(results), len(keys))
+	}
+
+	for _, k := range keys {
+		if _, ok := fake.get("b", k); ok {
+			t.Errorf("%q should have been moved", k)
+		}
+		moved, ok := fake.get("b", "archive/2023/"+k[len("logs/2023/"):])
+		if !ok {
+			t.Errorf("%q missing under new prefix", k)
+		} else if string(moved.body) != k {
+			t.Errorf("moved %q has body %q", k, moved.body)
+		}
+	}
+	if _, ok := fake.get("b", "logs/2024/app.log"); !ok {
+		t.Error("object outside the prefix was moved")
+	}
+}
+
+func TestRenamePrefixPartialFailure(t *testing.T) {
+	fake := newFakeS3()
+	for _, k := range []string{"src/a.txt", "src/b.txt", "src/c.txt"} {
+		fake.put("b", k, fakeObject{body: []byte(k)})
+	}
+	fake.errFor = func(op, key string) error {
+		if op == "CopyObject" && key == "dst/b.txt" {
+			return errors.New("AccessDenied")
+		}
+		return nil
+	}
+	client := &Client{S3: fake}
+
+	results, err := client.RenamePrefix(context.Background(), "b", "src/", "dst/")
+
+	var partial *PartialFailureError
+	if !errors.As(err, &partial) {
+		t.Fatalf("RenamePrefix() error = %v, want *PartialFailureError", err)
+	}
+	if partial.Total != 3 || len(partial.Failed) != 1 || partial.Failed[0].Key != "src/b.txt" {
+		t.Errorf("unexpected partial failure %+v", partial)
+	}
+	if len(results) != 3 {
+		t.Errorf("got %d results, want every object reported", len(results))
+	}
+
+	// Objects after the failure are still attempted; nothing is rolled back
+	for _, k := range []string{"dst/a.txt", "dst/c.txt", "src/b.txt"} {
+		if _, ok := fake.get("b", k); !ok {
+			t.Errorf("expected %q to exist", k)
+		}
+	}
+	if _, ok := fake.get("b", "dst/b.txt"); ok {
+		t.Error("failed copy should not exist at destination")
+	}
+}
+
+func TestMoveSelection(t *testing.T) {
+	fake := newFakeS3()
+	keys := []string{"inbox/a.txt", "inbox/deep/b.csv", "c.json"}
+	for _, k := range keys {
+		fake.put("b", k, fakeObject{body: []byte(k)})
+	}
+	client := &Client{S3: fake}
+
+	results, err := client.MoveSelection(context.Background(), "b", keys, "archive", OnExistsFail)
+	if err != nil {
+		t.Fatalf("MoveSelection() error = %v", err)
+	}
+
+	want := map[string]string{
+		"inbox/a.txt":      "archive/a.txt",
+		"inbox/deep/b.csv": "archive/b.csv",
+		"c.json":           "archive/c.json",
+	}
+	if len(results) != len(keys) {
+		t.Fatalf("got %d results, want %d", len(results), len(keys))
+	}
+	for _, r := range results {
+		if r.Err != nil || r.NewKey != want[r.Key] {
+			t.Errorf("result %+v, want %s moved to %s", r, r.Key, want[r.Key])
+		}
+		if _, ok := fake.get("b", r.Key); ok {
+			t.Errorf("%q should have been moved", r.Key)
+		}
+		if moved, ok := fake.get("b", want[r.Key]); !ok || string(moved.body) != r.Key {
+			t.Errorf("%q missing or wrong at %s", r.Key, want[r.Key])
+		}
+	}
+}
+
+func TestMoveSelectionCollision(t *testing.T) {
+	tests := []struct {
+		name     string
+		onExists OnExists
+		wantKey  string // where a.txt ends up; "" when it stays put
+		wantErr  bool
+	}{
+		{"fail", OnExistsFail, "", true},
+		{"skip", OnExistsSkip, "", false},
+		{"rename", OnExistsRename, "dst/a (1).txt", false},
+		{"overwrite", OnExistsOverwrite, "dst/a.txt", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeS3()
+			fake.put("b", "src/a.txt", fakeObject{body: []byte("new")})
+			fake.put("b", "dst/a.txt", fakeObject{body: []byte("old")})
+			client := &Client{S3: fake}
+
+			results, err := client.MoveSelection(context.Background(), "b", []string{"src/a.txt"}, "dst/", tt.onExists)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("MoveSelection() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(results[0].Err, ErrObjectExists) {
+				t.Errorf("result error = %v, want ErrObjectExists", results[0].Err)
+			}
+			if results[0].NewKey != tt.wantKey {
+				t.Errorf("NewKey = %q, want %q", results[0].NewKey, tt.wantKey)
+			}
+
+			_, srcLeft := fake.get("b", "src/a.txt")
+			if srcLeft != (tt.wantKey == "") {
+				t.Errorf("source still present = %v, want %v", srcLeft, tt.wantKey == "")
+			}
+			if tt.wantKey != "" {
+				if moved, _ := fake.get("b", tt.wantKey); string(moved.body) != "new" {
+					t.Errorf("%s = %q, want the moved object", tt.wantKey, moved.body)
+				}
+			}
+			if tt.onExists != OnExistsOverwrite {
+				if kept, _ := fake.get("b", "dst/a.txt"); string(kept.body) != "old" {
+					t.Error("existing destination was replaced")
+				}
+			}
+		})
+	}
+}
+
+func TestMoveSelectionPartialFailure(t *testing.T) {
+	fake := newFakeS3()
+	for _, k := range []string{"a.txt", "b.txt", "c.txt"} {
+		fake.put("b", k, fakeObject{body: []byte(k)})
+	}
+	fake.errFor = func(op, key string) error {
+		if op == "CopyObject" && key == "dst/b.txt" {
+			return errors.New("AccessDenied")
+		}
+		return nil
+	}
+	client := &Client{S3: fake}
+
+	results, err := client.MoveSelection(context.Background(), "b", []string{"a.txt", "b.txt", "folder/", "c.txt"}, "dst", OnExistsFail)
+
+	var partial *PartialFailureError
+	if !errors.As(err, &partial) {
+		t.Fatalf("MoveSelection() error = %v, want *PartialFailureError", err)
+	}
+	if partial.Total != 4 || len(partial.Failed) != 2 {
+		t.Fatalf("unexpected partial failure %+v", partial)
+	}
+	if partial.Failed[0].Key != "b.txt" || partial.Failed[1].Key != "folder/" {
+		t.Errorf("failed keys = %s, %s, want b.txt and folder/", partial.Failed[0].Key, partial.Failed[1].Key)
+	}
+	if len(results) != 4 {
+		t.Errorf("got %d results, want every key reported", len(results))
+	}
+	for _, k := range []string{"dst/a.txt", "dst/c.txt", "b.txt"} {
+		if _, ok := fake.get("b", k); !ok {
+			t.Errorf("expected %q to exist", k)
+		}
+	}
+}
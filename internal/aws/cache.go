@@ -0,0 +1,99 @@
+package aws
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultListingCacheTTL is how long a cached listing is served before it's considered stale
+const DefaultListingCacheTTL = 5 * time.Minute
+
+// ListingCache holds recent single-level listings keyed by bucket and prefix.
+// It is safe for concurrent use.
+type ListingCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]cacheEntry
+	now     func() time.Time
+}
+
+type cacheEntry struct {
+	objects []S3Object
+	stored  time.Time
+}
+
+// NewListingCache creates a cache whose entries expire after ttl
+func NewListingCache(ttl time.Duration) *ListingCache {
+	if ttl <= 0 {
+		ttl = DefaultListingCacheTTL
+	}
+	return &ListingCache{
+		ttl:     ttl,
+		entries: make(map[string]cacheEntry),
+		now:     time.Now,
+	}
+}
+
+func cacheKey(bucket, prefix string) string {
+	return bucket + "/" + prefix
+}
+
+// Get returns a copy of the cached listing if present and not expired
+func (c *ListingCache) Get(bucket, prefix string) ([]S3Object, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[cacheKey(bucket, prefix)]
+	if !ok {
+		return nil, false
+	}
+	if c.now().Sub(entry.stored) > c.ttl {
+		delete(c.entries, cacheKey(bucket, prefix))
+		return nil, false
+	}
+	return append([]S3Object(nil), entry.objects...), true
+}
+
+// Put stores a listing
+func (c *ListingCache) Put(bucket, prefix string, objects []S3Object) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[cacheKey(bucket, prefix)] = cacheEntry{
+		objects: append([]S3Object(nil), objects...),
+		stored:  c.now(),
+	}
+}
+
+// Invalidate drops the cached listing for bucket/prefix
+func (c *ListingCache) Invalidate(bucket, prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, cacheKey(bucket, prefix))
+}
+
+// InvalidateKey drops the listings a change to key can alter: the folder it
+// is in and every folder above it, which may gain or lose a subfolder. A key
+// ending in "/" stands for a whole folder, so listings beneath it go too.
+func (c *ListingCache) InvalidateKey(bucket, key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for k := range c.entries {
+		prefix, ok := strings.CutPrefix(k, cacheKey(bucket, ""))
+		if !ok {
+			continue
+		}
+		if strings.HasPrefix(key, prefix) || (strings.HasSuffix(key, "/") && strings.HasPrefix(prefix, key)) {
+			delete(c.entries, k)
+		}
+	}
+}
+
+// Len returns the number of cached listings, including expired ones not yet evicted
+func (c *ListingCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
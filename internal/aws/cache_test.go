@@ -0,0 +1,75 @@
+package aws
+
+import (
+	"testing"
+	"time"
+)
+
+func TestListingCache(t *testing.T) {
+	now := time.Unix(1000, 0)
+	cache := NewListingCache(time.Minute)
+	cache.now = func() time.Time { return now }
+
+	if _, ok := cache.Get("b", "logs/"); ok {
+		t.Fatal("empty cache returned a hit")
+	}
+
+	cache.Put("b", "logs/", []S3Object{{Key: "logs/a.txt"}})
+	got, ok := cache.Get("b", "logs/")
+	if !ok || len(got) != 1 || got[0].Key != "logs/a.txt" {
+		t.Fatalf("Get() = %v, %v", got, ok)
+	}
+
+	// Callers can't corrupt the cached slice
+	got[0].Key = "changed"
+	if again, _ := cache.Get("b", "logs/"); again[0].Key != "logs/a.txt" {
+		t.Error("cached entry was modified through a returned slice")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := cache.Get("b", "logs/"); ok {
+		t.Error("expired entry returned a hit")
+	}
+
+	cache.Put("b", "", nil)
+	cache.Invalidate("b", "")
+	if _, ok := cache.Get("b", ""); ok {
+		t.Error("invalidated entry returned a hit")
+	}
+}
+
+func TestListingCacheInvalidateKey(t *testing.T) {
+	cache := NewListingCache(time.Minute)
+	prefixes := []string{"", "logs/", "logs/2024/", "logs/2024/01/", "other/"}
+	fill := func() {
+		for _, p := range prefixes {
+			cache.Put("b", p, nil)
+		}
+		cache.Put("b2", "logs/", nil)
+	}
+	cached := func(bucket, prefix string) bool {
+		_, ok := cache.Get(bucket, prefix)
+		return ok
+	}
+
+	// An object drops its folder and the folders above it
+	fill()
+	cache.InvalidateKey("b", "logs/2024/a.txt")
+	for p, want := range map[string]bool{"": false, "logs/": false, "logs/2024/": false, "logs/2024/01/": true, "other/": true} {
+		if cached("b", p) != want {
+			t.Errorf("after an object change, %q cached = %v, want %v", p, !want, want)
+		}
+	}
+	if !cached("b2", "logs/") {
+		t.Error("another bucket's listing was dropped")
+	}
+
+	// A folder drops everything beneath it too
+	fill()
+	cache.InvalidateKey("b", "logs/2024/")
+	for p, want := range map[string]bool{"": false, "logs/": false, "logs/2024/": false, "logs/2024/01/": false, "other/": true} {
+		if cached("b", p) != want {
+			t.Errorf("after a folder change, %q cached = %v, want %v", p, !want, want)
+		}
+	}
+}
@@ -7,51 +7,284 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/natevick/stui/internal/security"
 )
 
 // Client wraps the AWS S3 client with configuration
 type Client struct {
-	S3      *s3.Client
-	Config  aws.Config
-	Profile string
-	Region  string
+	S3        S3API
+	Presigner Presigner
+	Account   AccountSettingsAPI
+	Config    aws.Config
+	Profile   string
+	Region    string
+	Options   ClientOptions
 }
 
-// NewClient creates a new AWS client with the specified profile
-// Supports SSO profiles - user must run `aws sso login --profile <profile>` first
-func NewClient(ctx context.Context, profile, region string) (*Client, error) {
-	var opts []func(*config.LoadOptions) error
+// Retry attempt limits accepted by ClientOptions
+const (
+	MinMaxAttempts = 1
+	MaxMaxAttempts = 10
+)
 
-	if profile != "" {
-		opts = append(opts, config.WithSharedConfigProfile(profile))
+// ClientOptions configures optional client behavior
+type ClientOptions struct {
+	// RetryMode selects the SDK retryer ("standard" or "adaptive"); empty uses the SDK default
+	RetryMode aws.RetryMode
+	// MaxAttempts caps attempts per request, including the first; zero uses the SDK default
+	MaxAttempts int
+	// PageSize is the number of keys requested per listing page; zero uses DefaultPageSize
+	PageSize int
+	// VerifyDownloads checks each downloaded file against the object's ETag
+	VerifyDownloads bool
+	// VerifyMaxBytes skips verification for objects larger than this; zero means no limit
+	VerifyMaxBytes int64
+	// RemoveCorruptDownloads deletes files that fail verification
+	RemoveCorruptDownloads bool
+	// DownloadTempDir holds in-progress part files; empty uses the system temp dir
+	DownloadTempDir string
+	// PingTimeout bounds the connectivity check; zero uses DefaultPingTimeout
+	PingTimeout time.Duration
+	// ReadOnly makes every mutating operation fail with ErrReadOnlyMode
+	ReadOnly bool
+	// MaxRecursiveObjects stops recursive listings (prefix downloads, syncs,
+	// recursive deletes and renames) that find more objects; zero means no limit
+	MaxRecursiveObjects int
+	// ProtectedBuckets are bucket names or glob patterns that mutating operations refuse to touch
+	ProtectedBuckets []string
+	// Metrics collects request counts; NewClient creates one if nil so clients
+	// derived with WithRegion share it
+	Metrics *Metrics
+	// Journal records deletes so they can be restored; NewClient creates one
+	// if nil, shared the same way as Metrics
+	Journal *DeletionJournal
+	// UploadJournal records multipart uploads of local files so they can be
+	// resumed after a crash; nil disables resuming
+	UploadJournal *UploadJournal
+	// MaxConcurrentLists caps listing requests in flight; zero means no cap
+	MaxConcurrentLists int
+	// ListSemaphore enforces MaxConcurrentLists; NewClient creates one if
+	// nil, shared the same way as Metrics so the cap is app-wide
+	ListSemaphore *ListSemaphore
+	// ListAPIs remembers endpoints without ListObjectsV2; NewClient creates
+	// one if nil, shared the same way as Metrics
+	ListAPIs *ListAPICache
+	// BucketRegions caches GetBucketRegion; NewClient creates one if nil,
+	// shared the same way as Metrics
+	BucketRegions *BucketRegionCache
+	// Credentials replaces the SDK's default credential chain; nil uses the
+	// chain for the client's profile
+	Credentials CredentialSource
+	// BucketStorageClasses is the storage class uploads to each bucket get
+	// unless the upload sets its own; buckets not listed use S3's default
+	BucketStorageClasses map[string]types.StorageClass
+	// UploadKeyTemplate is the KeyTemplate folder uploads use; empty keys
+	// files by their relative path
+	UploadKeyTemplate string
+	// Anonymous sends unsigned requests without looking up credentials, for
+	// public buckets. Every mutating operation fails with ErrAnonymousMode.
+	Anonymous bool
+	// FallbackProfiles are tried in order when a read is denied with the
+	// client's own credentials; mutating requests never fall back. Ignored
+	// in Anonymous mode.
+	FallbackProfiles []string
+	// TrashRetention is how long soft-deleted objects stay under TrashPrefix
+	// before the empty-trash command deletes them; zero keeps them
+	TrashRetention time.Duration
+	// EgressWarnBytes is the smallest cross-region copy that needs
+	// confirming; zero never asks
+	EgressWarnBytes int64
+}
+
+// Validate checks the options are within supported ranges
+func (o ClientOptions) Validate() error {
+	switch o.RetryMode {
+	case "", aws.RetryModeStandard, aws.RetryModeAdaptive:
+	default:
+		return fmt.Errorf("unsupported retry mode %q", o.RetryMode)
+	}
+	if o.MaxAttempts != 0 && (o.MaxAttempts < MinMaxAttempts || o.MaxAttempts > MaxMaxAttempts) {
+		return fmt.Errorf("max attempts must be between %d and %d", MinMaxAttempts, MaxMaxAttempts)
+	}
+	if o.MaxConcurrentLists < 0 {
+		return fmt.Errorf("max concurrent lists must not be negative")
+	}
+	if o.TrashRetention < 0 {
+		return fmt.Errorf("trash retention must not be negative")
+	}
+	if o.Anonymous && o.Credentials != nil {
+		return fmt.Errorf("anonymous mode can't be combined with a credential source")
+	}
+	if err := validFallbackProfiles(o.FallbackProfiles); err != nil {
+		return err
+	}
+	for bucket, class := range o.BucketStorageClasses {
+		if err := ValidStorageClass(class); err != nil {
+			return fmt.Errorf("bucket %s: %w", bucket, err)
+		}
+	}
+	if o.UploadKeyTemplate != "" {
+		if _, err := ParseKeyTemplate(o.UploadKeyTemplate); err != nil {
+			return err
+		}
 	}
+	return validatePatterns(o.ProtectedBuckets)
+}
 
-	if region != "" {
-		opts = append(opts, config.WithRegion(region))
+// NewClient creates a new AWS client with the specified profile
+// Supports SSO profiles - user must run `aws sso login --profile <profile>` first
+func NewClient(ctx context.Context, profile, region string, options ClientOptions) (*Client, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+	if options.Credentials == nil {
+		if err := security.ValidProfileName(profile); err != nil {
+			return nil, err
+		}
 	}
 
-	cfg, err := config.LoadDefaultConfig(ctx, opts...)
+	cfg, err := config.LoadDefaultConfig(ctx, loadOptions(profile, region, options)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
 
-	s3Client := s3.NewFromConfig(cfg)
+	if options.Metrics == nil {
+		options.Metrics = NewMetrics()
+	}
+	if options.Journal == nil {
+		options.Journal = NewDeletionJournal()
+	}
+	if options.ListSemaphore == nil {
+		options.ListSemaphore = NewListSemaphore(options.MaxConcurrentLists)
+	}
+	if options.ListAPIs == nil {
+		options.ListAPIs = NewListAPICache()
+	}
+	if options.BucketRegions == nil {
+		options.BucketRegions = NewBucketRegionCache()
+	}
+	var s3Client S3API = newS3Client(cfg, options.Metrics)
+	if len(options.FallbackProfiles) > 0 && !options.Anonymous {
+		fallbacks, err := fallbackClients(ctx, cfg.Region, options)
+		if err != nil {
+			return nil, err
+		}
+		s3Client = newFallbackS3(s3Client, fallbacks)
+	}
 
 	return &Client{
-		S3:      s3Client,
+		S3: s3Client,
+		// Presigning sends nothing, so it stays out of the metrics
+		Presigner: s3.NewPresignClient(s3.NewFromConfig(cfg, func(o *s3.Options) {
+			o.UseARNRegion = true
+		})),
+		Account: &accountSettings{cfg: cfg},
 		Config:  cfg,
 		Profile: profile,
 		Region:  cfg.Region,
+		Options: options,
 	}, nil
 }
 
+// newS3Client creates the SDK client requests are sent with, counted in metrics
+func newS3Client(cfg aws.Config, metrics *Metrics) *s3.Client {
+	// The SDK's default S3 Express credentials provider calls CreateSession
+	// for directory buckets, so ExpressCredentials is deliberately left unset
+	return s3.NewFromConfig(cfg, func(o *s3.Options) {
+		o.APIOptions = append(o.APIOptions, metrics.AddMiddleware)
+		// Send Access Point ARN requests to the ARN's region, not the profile's
+		o.UseARNRegion = true
+	})
+}
+
+// fallbackClients creates an S3 client for each fallback profile, in the
+// primary client's region so a read reaches the same bucket endpoint
+func fallbackClients(ctx context.Context, region string, options ClientOptions) ([]ProfileS3, error) {
+	// Fallbacks sign with their own profile, never the primary's source
+	options.Credentials = nil
+	fallbacks := make([]ProfileS3, 0, len(options.FallbackProfiles))
+	for _, profile := range options.FallbackProfiles {
+		cfg, err := config.LoadDefaultConfig(ctx, loadOptions(profile, region, options)...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load AWS config for fallback profile %s: %w", profile, err)
+		}
+		fallbacks = append(fallbacks, ProfileS3{Profile: profile, S3: newS3Client(cfg, options.Metrics)})
+	}
+	return fallbacks, nil
+}
+
+// loadOptions builds the SDK config loading options for a client
+func loadOptions(profile, region string, options ClientOptions) []func(*config.LoadOptions) error {
+	var opts []func(*config.LoadOptions) error
+
+	if profile != "" {
+		opts = append(opts, config.WithSharedConfigProfile(profile))
+	}
+
+	if region != "" {
+		opts = append(opts, config.WithRegion(region))
+	}
+
+	if options.RetryMode != "" {
+		opts = append(opts, config.WithRetryMode(options.RetryMode))
+	}
+
+	if options.MaxAttempts != 0 {
+		opts = append(opts, config.WithRetryMaxAttempts(options.MaxAttempts))
+	}
+
+	// The SDK skips signing for AnonymousCredentials, and supplying any
+	// provider stops it resolving the default chain
+	if options.Anonymous {
+		opts = append(opts, config.WithCredentialsProvider(aws.AnonymousCredentials{}))
+	} else if options.Credentials != nil {
+		opts = append(opts, config.WithCredentialsProvider(credentialsProvider(options.Credentials)))
+	}
+
+	return opts
+}
+
 // WithRegion creates a new client with a different region
 func (c *Client) WithRegion(ctx context.Context, region string) (*Client, error) {
-	return NewClient(ctx, c.Profile, region)
+	return NewClient(ctx, c.Profile, region, c.Options)
+}
+
+// endpoint identifies the S3 service the client talks to: the custom
+// endpoint URL, or the region for AWS itself
+func (c *Client) endpoint() string {
+	if ep := aws.ToString(c.Config.BaseEndpoint); ep != "" {
+		return ep
+	}
+	return c.Region
+}
+
+// CredentialExpiry returns when the current credentials expire.
+// The second return value is false if the credentials don't expire or can't be retrieved.
+func (c *Client) CredentialExpiry(ctx context.Context) (time.Time, bool) {
+	if c.Config.Credentials == nil {
+		return time.Time{}, false
+	}
+	creds, err := c.Config.Credentials.Retrieve(ctx)
+	if err != nil || !creds.CanExpire {
+		return time.Time{}, false
+	}
+	return creds.Expires, true
+}
+
+// TimeUntilExpiry returns how long the current credentials remain valid.
+// The second return value is false if they don't expire or can't be retrieved.
+func (c *Client) TimeUntilExpiry(ctx context.Context) (time.Duration, bool) {
+	expires, ok := c.CredentialExpiry(ctx)
+	if !ok {
+		return 0, false
+	}
+	return time.Until(expires), true
 }
 
 // ProfileInfo contains information about an AWS profile
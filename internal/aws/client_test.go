@@ -0,0 +1,217 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+func applyLoadOptions(t *testing.T, opts []func(*config.LoadOptions) error) config.LoadOptions {
+	t.Helper()
+	var lo config.LoadOptions
+	for _, fn := range opts {
+		if err := fn(&lo); err != nil {
+			t.Fatalf("load option error: %v", err)
+		}
+	}
+	return lo
+}
+
+func TestLoadOptionsRetry(t *testing.T) {
+	lo := applyLoadOptions(t, loadOptions("dev", "eu-west-1", ClientOptions{
+		RetryMode:   aws.RetryModeAdaptive,
+		MaxAttempts: 7,
+	}))
+
+	if lo.RetryMode != aws.RetryModeAdaptive {
+		t.Errorf("RetryMode = %q, want %q", lo.RetryMode, aws.RetryModeAdaptive)
+	}
+	if lo.RetryMaxAttempts != 7 {
+		t.Errorf("RetryMaxAttempts = %d, want 7", lo.RetryMaxAttempts)
+	}
+	if lo.SharedConfigProfile != "dev" {
+		t.Errorf("SharedConfigProfile = %q, want dev", lo.SharedConfigProfile)
+	}
+	if lo.Region != "eu-west-1" {
+		t.Errorf("Region = %q, want eu-west-1", lo.Region)
+	}
+}
+
+func TestLoadOptionsDefaultsLeaveRetryerAlone(t *testing.T) {
+	lo := applyLoadOptions(t, loadOptions("", "", ClientOptions{}))
+
+	if lo.RetryMode != "" || lo.RetryMaxAttempts != 0 {
+		t.Errorf("expected SDK defaults, got mode %q attempts %d", lo.RetryMode, lo.RetryMaxAttempts)
+	}
+}
+
+func TestClientOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    ClientOptions
+		wantErr bool
+	}{
+		{"zero value", ClientOptions{}, false},
+		{"standard", ClientOptions{RetryMode: aws.RetryModeStandard, MaxAttempts: 3}, false},
+		{"adaptive max", ClientOptions{RetryMode: aws.RetryModeAdaptive, MaxAttempts: 10}, false},
+		{"too many attempts", ClientOptions{MaxAttempts: 11}, true},
+		{"negative attempts", ClientOptions{MaxAttempts: -1}, true},
+		{"unknown mode", ClientOptions{RetryMode: "yolo"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewClientRejectsOutOfRangeAttempts(t *testing.T) {
+	_, err := NewClient(context.Background(), "", "us-east-1", ClientOptions{MaxAttempts: 50})
+	if err == nil {
+		t.Error("expected error for out-of-range max attempts")
+	}
+}
+
+func TestRetry(t *testing.T) {
+	defer func(d time.Duration) { retryBaseDelay = d }(retryBaseDelay)
+	retryBaseDelay = 0
+
+	calls := 0
+	err := Retry(context.Background(), 3, func() error {
+		calls++
+		if calls < 3 {
+			return errors.New("transient")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Retry() error = %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 calls, got %d", calls)
+	}
+
+	calls = 0
+	err = Retry(context.Background(), 2, func() error {
+		calls++
+		return errors.New("permanent")
+	})
+	if err == nil || calls != 2 {
+		t.Errorf("expected failure after 2 calls, got err=%v calls=%d", err, calls)
+	}
+}
+
+func TestNewClientUsesCredentialSource(t *testing.T) {
+	calls := 0
+	source := aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+		calls++
+		return aws.Credentials{AccessKeyID: "AKIDFROMVAULT", SecretAccessKey: "vault-secret"}, nil
+	})
+
+	client, err := NewClient(context.Background(), "", "us-east-1", ClientOptions{Credentials: source})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	creds, err := client.Config.Credentials.Retrieve(context.Background())
+	if err != nil {
+		t.Fatalf("Retrieve() error = %v", err)
+	}
+	if creds.AccessKeyID != "AKIDFROMVAULT" || creds.SecretAccessKey != "vault-secret" {
+		t.Errorf("client credentials = %+v, want the source's", creds)
+	}
+
+	presigned, err := client.PresignGet(context.Background(), "b", "a.txt", time.Hour, time.Time{})
+	if err != nil {
+		t.Fatalf("PresignGet() error = %v", err)
+	}
+	if !strings.Contains(presigned.URL, "X-Amz-Credential=AKIDFROMVAULT") {
+		t.Errorf("URL %s wasn't signed with the source's key", presigned.URL)
+	}
+	if calls != 1 {
+		t.Errorf("source asked %d times, want 1 (cached)", calls)
+	}
+}
+
+func TestStaticCredentials(t *testing.T) {
+	creds, err := StaticCredentials("AKID", "secret", "token").Retrieve(context.Background())
+	if err != nil {
+		t.Fatalf("Retrieve() error = %v", err)
+	}
+	if creds.AccessKeyID != "AKID" || creds.SecretAccessKey != "secret" || creds.SessionToken != "token" {
+		t.Errorf("Retrieve() = %+v", creds)
+	}
+	if _, err := StaticCredentials("", "secret", "").Retrieve(context.Background()); err == nil {
+		t.Error("expected an error for a missing access key ID")
+	}
+}
+
+func TestDefaultCredentialsValidatesProfile(t *testing.T) {
+	if _, err := DefaultCredentials(context.Background(), "bad profile; rm -rf"); err == nil {
+		t.Error("DefaultCredentials() accepted an invalid profile name")
+	}
+	if _, err := NewClient(context.Background(), "bad profile", "us-east-1", ClientOptions{}); err == nil {
+		t.Error("NewClient() accepted an invalid profile name with the default chain")
+	}
+}
+
+func TestNewClientAnonymousSendsUnsignedRequests(t *testing.T) {
+	var authHeaders []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeaders = append(authHeaders, r.Header.Get("Authorization")+r.URL.Query().Get("X-Amz-Signature"))
+		w.Header().Set("Content-Type", "application/xml")
+		fmt.Fprint(w, `<ListBucketResult><Name>public-data</Name><KeyCount>1</KeyCount><IsTruncated>false</IsTruncated>`+
+			`<Contents><Key>readme.txt</Key><Size>5</Size></Contents></ListBucketResult>`)
+	}))
+	defer server.Close()
+
+	// Credentials in the environment must not be picked up
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDSHOULDNOTBEUSED")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+
+	client, err := NewClient(context.Background(), "", "us-east-1", ClientOptions{Anonymous: true})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	// Point a client built from the same config at the test server
+	client.S3 = s3.NewFromConfig(client.Config, func(o *s3.Options) {
+		o.BaseEndpoint = aws.String(server.URL)
+		o.UsePathStyle = true
+	})
+	objects, err := client.ListObjects(context.Background(), "public-data", "")
+	if err != nil {
+		t.Fatalf("ListObjects() error = %v", err)
+	}
+	if len(objects) != 1 || objects[0].Key != "readme.txt" {
+		t.Errorf("ListObjects() = %+v, want readme.txt", objects)
+	}
+	if len(authHeaders) == 0 {
+		t.Fatal("no request reached the server")
+	}
+	for _, h := range authHeaders {
+		if h != "" {
+			t.Errorf("request carried auth %q, want none", h)
+		}
+	}
+}
+
+func TestClientOptionsRejectAnonymousWithCredentials(t *testing.T) {
+	opts := ClientOptions{Anonymous: true, Credentials: StaticCredentials("AKID", "secret", "")}
+	if err := opts.Validate(); err == nil {
+		t.Error("Validate() accepted anonymous mode with a credential source")
+	}
+}
@@ -0,0 +1,250 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// compareChunkSize is how much of each object a byte comparison fetches per request
+const compareChunkSize = 8 * 1024 * 1024
+
+// ObjectRef names an object, optionally at a specific version
+type ObjectRef struct {
+	Bucket    string
+	Key       string
+	VersionID string
+}
+
+// String returns the ref as an s3:// URI, with "?versionId=" when it has one
+func (r ObjectRef) String() string {
+	uri := FormatS3URI(r.Bucket, r.Key)
+	if r.VersionID != "" {
+		uri += "?versionId=" + r.VersionID
+	}
+	return uri
+}
+
+// How CompareObjects decided whether two objects are identical
+const (
+	CompareBySize     = "size"
+	CompareByChecksum = "checksum"
+	CompareByETag     = "etag"
+	CompareByBytes    = "bytes"
+)
+
+// CompareResult describes how two objects differ
+type CompareResult struct {
+	A, B *S3Object
+
+	SameSize        bool
+	SameContentType bool
+	// ETagComparable is set when both ETags are digests of the content
+	// made the same way, so equal ETags mean equal bytes and vice versa
+	ETagComparable bool
+	SameETag       bool
+
+	// Decided reports whether Identical is known. It isn't when neither the
+	// checksums nor the ETags can be compared and no byte comparison ran.
+	Decided   bool
+	Identical bool
+	// Method is the Compare* constant that decided, empty when undecided
+	Method string
+	// FirstDifference is the offset of the first differing byte found by a
+	// byte comparison, or -1
+	FirstDifference int64
+}
+
+// byteCompareKey carries the byte comparison limit on the context
+type byteCompareKey struct{}
+
+// WithByteCompare lets CompareObjects run with the returned context fetch
+// and compare both objects' bytes, in ranged requests, when their metadata
+// can't decide. Objects larger than maxBytes aren't fetched.
+func WithByteCompare(ctx context.Context, maxBytes int64) context.Context {
+	return context.WithValue(ctx, byteCompareKey{}, maxBytes)
+}
+
+// CompareObjects reports whether a and b hold the same content, from HEAD
+// alone where possible: different sizes differ, and matching full-object
+// checksums or comparable ETags decide the rest. Multipart and single-part
+// uploads of the same bytes get different ETags, and KMS-encrypted objects
+// get ETags that aren't digests at all; such pairs are compared byte by byte
+// only with a context from WithByteCompare, and are otherwise undecided.
+func (c *Client) CompareObjects(ctx context.Context, a, b ObjectRef) (CompareResult, error) {
+	res := CompareResult{FirstDifference: -1}
+	var sumsA, sumsB map[string]string
+	var err error
+	if res.A, sumsA, err = c.headRef(ctx, a); err != nil {
+		return res, err
+	}
+	if res.B, sumsB, err = c.headRef(ctx, b); err != nil {
+		return res, err
+	}
+
+	res.SameSize = res.A.Size == res.B.Size
+	res.SameContentType = res.A.ContentType == res.B.ContentType
+	res.SameETag = res.A.ETag == res.B.ETag
+	res.ETagComparable = comparableETags(res.A, res.B)
+	sameSum := sameChecksum(sumsA, sumsB)
+
+	switch {
+	case !res.SameSize:
+		res.Decided, res.Identical, res.Method = true, false, CompareBySize
+		return res, nil
+	case sameSum != nil:
+		res.Decided, res.Identical, res.Method = true, *sameSum, CompareByChecksum
+		return res, nil
+	case res.ETagComparable:
+		res.Decided, res.Identical, res.Method = true, res.SameETag, CompareByETag
+		return res, nil
+	}
+
+	limit, ok := ctx.Value(byteCompareKey{}).(int64)
+	if !ok || res.A.Size > limit {
+		return res, nil
+	}
+	offset, err := c.compareBytes(ctx, a, b, res.A.Size)
+	if err != nil {
+		return res, err
+	}
+	res.Decided, res.Identical, res.Method = true, offset < 0, CompareByBytes
+	res.FirstDifference = offset
+	return res, nil
+}
+
+// headRef reads an object's metadata and full-object checksums, by algorithm
+func (c *Client) headRef(ctx context.Context, ref ObjectRef) (*S3Object, map[string]string, error) {
+	input := &s3.HeadObjectInput{
+		Bucket:       aws.String(ref.Bucket),
+		Key:          aws.String(ref.Key),
+		ChecksumMode: types.ChecksumModeEnabled,
+	}
+	if ref.VersionID != "" {
+		input.VersionId = aws.String(ref.VersionID)
+	}
+	output, err := c.S3.HeadObject(ctx, input)
+	if err != nil {
+		return nil, nil, &OpError{Op: "Comparing objects", Bucket: ref.Bucket, Key: ref.Key, Err: err}
+	}
+
+	obj := &S3Object{
+		Key:                  ref.Key,
+		Size:                 aws.ToInt64(output.ContentLength),
+		LastModified:         aws.ToTime(output.LastModified),
+		ETag:                 strings.Trim(aws.ToString(output.ETag), "\""),
+		ContentType:          aws.ToString(output.ContentType),
+		Metadata:             output.Metadata,
+		StorageClass:         GetStorageClass(output.StorageClass),
+		ServerSideEncryption: string(output.ServerSideEncryption),
+	}
+	if output.SSECustomerAlgorithm != nil {
+		obj.ServerSideEncryption = "SSE-C"
+	}
+
+	sums := make(map[string]string)
+	// Composite checksums of multipart uploads ("value-N") depend on the
+	// part sizes, like multipart ETags, so only full-object ones are kept
+	for algorithm, value := range map[string]*string{
+		"CRC64NVME": output.ChecksumCRC64NVME,
+		"SHA256":    output.ChecksumSHA256,
+		"SHA1":      output.ChecksumSHA1,
+		"CRC32C":    output.ChecksumCRC32C,
+		"CRC32":     output.ChecksumCRC32,
+	} {
+		if v := aws.ToString(value); v != "" && output.ChecksumType != types.ChecksumTypeComposite && !strings.Contains(v, "-") {
+			sums[algorithm] = v
+		}
+	}
+	return obj, sums, nil
+}
+
+// sameChecksum compares the full-object checksums the two objects share.
+// It returns nil when they have none in common.
+func sameChecksum(a, b map[string]string) *bool {
+	for algorithm, va := range a {
+		if vb, ok := b[algorithm]; ok {
+			same := va == vb
+			return &same
+		}
+	}
+	return nil
+}
+
+// comparableETags reports whether two ETags are content digests made the
+// same way: both plain MD5s, or multipart ETags that match exactly. Two
+// multipart ETags that differ may still be the same bytes in other parts.
+func comparableETags(a, b *S3Object) bool {
+	if !reproducibleETag(a) || !reproducibleETag(b) {
+		return false
+	}
+	aMultipart := strings.Contains(a.ETag, "-")
+	bMultipart := strings.Contains(b.ETag, "-")
+	switch {
+	case !aMultipart && !bMultipart:
+		return true
+	case aMultipart && bMultipart:
+		return a.ETag == b.ETag
+	default:
+		return false
+	}
+}
+
+// compareBytes reads both objects a chunk at a time and returns the offset
+// of the first differing byte, or -1 if all size bytes match
+func (c *Client) compareBytes(ctx context.Context, a, b ObjectRef, size int64) (int64, error) {
+	for offset := int64(0); offset < size; offset += compareChunkSize {
+		last := min(offset+compareChunkSize, size) - 1
+		chunkA, err := c.readRange(ctx, a, offset, last)
+		if err != nil {
+			return -1, err
+		}
+		chunkB, err := c.readRange(ctx, b, offset, last)
+		if err != nil {
+			return -1, err
+		}
+		if !bytes.Equal(chunkA, chunkB) {
+			i := 0
+			for i < len(chunkA) && i < len(chunkB) && chunkA[i] == chunkB[i] {
+				i++
+			}
+			return offset + int64(i), nil
+		}
+	}
+	return -1, nil
+}
+
+// readRange fetches bytes first through last of an object
+func (c *Client) readRange(ctx context.Context, ref ObjectRef, first, last int64) ([]byte, error) {
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(ref.Bucket),
+		Key:    aws.String(ref.Key),
+		Range:  aws.String(fmt.Sprintf("bytes=%d-%d", first, last)),
+	}
+	if ref.VersionID != "" {
+		input.VersionId = aws.String(ref.VersionID)
+	}
+	output, err := c.S3.GetObject(ctx, input)
+	if err != nil {
+		return nil, &OpError{Op: "Comparing objects", Bucket: ref.Bucket, Key: ref.Key, Err: err}
+	}
+	defer output.Body.Close()
+
+	want := last - first + 1
+	data, err := io.ReadAll(io.LimitReader(output.Body, want+1))
+	if err != nil {
+		return nil, &OpError{Op: "Comparing objects", Bucket: ref.Bucket, Key: ref.Key, Err: err}
+	}
+	c.Options.Metrics.AddBytesDown(int64(len(data)))
+	// An endpoint that ignores Range sends the whole object
+	if int64(len(data)) != want {
+		return nil, fmt.Errorf("comparing %s: got %d bytes for a %d-byte range", ref, len(data), want)
+	}
+	return data, nil
+}
@@ -0,0 +1,105 @@
+package aws
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCompareObjectsIdentical(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("a", "report.csv", fakeObject{body: []byte("same bytes"), contentType: "text/csv"})
+	fake.put("b", "copy.csv", fakeObject{body: []byte("same bytes"), contentType: "text/csv"})
+	client := &Client{S3: fake}
+
+	res, err := client.CompareObjects(context.Background(),
+		ObjectRef{Bucket: "a", Key: "report.csv"}, ObjectRef{Bucket: "b", Key: "copy.csv"})
+	if err != nil {
+		t.Fatalf("CompareObjects() error = %v", err)
+	}
+	if !res.Decided || !res.Identical || res.Method != CompareByETag {
+		t.Errorf("CompareObjects() decided=%v identical=%v method=%q, want identical by etag", res.Decided, res.Identical, res.Method)
+	}
+	if !res.SameSize || !res.SameContentType || !res.SameETag || !res.ETagComparable {
+		t.Errorf("CompareObjects() = %+v, want matching size, content type and ETag", res)
+	}
+	if n := fake.countCalls("GetObject"); n != 0 {
+		t.Errorf("GetObject called %d times, want 0", n)
+	}
+}
+
+func TestCompareObjectsDifferentSize(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("b", "short.txt", fakeObject{body: []byte("short"), contentType: "text/plain"})
+	fake.put("b", "long.txt", fakeObject{body: []byte("rather longer"), contentType: "application/octet-stream"})
+	client := &Client{S3: fake}
+
+	// Byte comparison is allowed but shouldn't be needed
+	ctx := WithByteCompare(context.Background(), 1<<20)
+	res, err := client.CompareObjects(ctx, ObjectRef{Bucket: "b", Key: "short.txt"}, ObjectRef{Bucket: "b", Key: "long.txt"})
+	if err != nil {
+		t.Fatalf("CompareObjects() error = %v", err)
+	}
+	if !res.Decided || res.Identical || res.Method != CompareBySize {
+		t.Errorf("CompareObjects() decided=%v identical=%v method=%q, want different by size", res.Decided, res.Identical, res.Method)
+	}
+	if res.SameSize || res.SameContentType {
+		t.Errorf("CompareObjects() = %+v, want size and content type to differ", res)
+	}
+	if n := fake.countCalls("GetObject"); n != 0 {
+		t.Errorf("GetObject called %d times, want 0", n)
+	}
+}
+
+func TestCompareObjectsFallsBackToBytes(t *testing.T) {
+	body := []byte("the same content, uploaded two ways")
+	fake := newFakeS3()
+	fake.put("b", "single.bin", fakeObject{body: body})
+	// A multipart upload of the same bytes has a composite ETag
+	fake.put("b", "multipart.bin", fakeObject{body: body, etagOverride: "0123456789abcdef0123456789abcdef-2"})
+	changed := append([]byte(nil), body...)
+	changed[9] = 'X'
+	fake.put("b", "changed.bin", fakeObject{body: changed, etagOverride: "fedcba9876543210fedcba9876543210-2"})
+	client := &Client{S3: fake}
+	single := ObjectRef{Bucket: "b", Key: "single.bin"}
+
+	res, err := client.CompareObjects(context.Background(), single, ObjectRef{Bucket: "b", Key: "multipart.bin"})
+	if err != nil {
+		t.Fatalf("CompareObjects() error = %v", err)
+	}
+	if res.Decided || res.ETagComparable || res.Method != "" {
+		t.Errorf("CompareObjects() without byte compare = %+v, want undecided", res)
+	}
+	if n := fake.countCalls("GetObject"); n != 0 {
+		t.Fatalf("GetObject called %d times without byte compare, want 0", n)
+	}
+
+	ctx := WithByteCompare(context.Background(), 1<<20)
+	res, err = client.CompareObjects(ctx, single, ObjectRef{Bucket: "b", Key: "multipart.bin"})
+	if err != nil {
+		t.Fatalf("CompareObjects() error = %v", err)
+	}
+	if !res.Decided || !res.Identical || res.Method != CompareByBytes || res.FirstDifference != -1 {
+		t.Errorf("CompareObjects() = %+v, want identical by bytes", res)
+	}
+
+	res, err = client.CompareObjects(ctx, single, ObjectRef{Bucket: "b", Key: "changed.bin"})
+	if err != nil {
+		t.Fatalf("CompareObjects() error = %v", err)
+	}
+	if !res.Decided || res.Identical || res.Method != CompareByBytes || res.FirstDifference != 9 {
+		t.Errorf("CompareObjects() = %+v, want different by bytes at offset 9", res)
+	}
+
+	// Objects over the limit aren't fetched
+	before := fake.countCalls("GetObject")
+	res, err = client.CompareObjects(WithByteCompare(context.Background(), 8), single, ObjectRef{Bucket: "b", Key: "multipart.bin"})
+	if err != nil {
+		t.Fatalf("CompareObjects() error = %v", err)
+	}
+	if res.Decided {
+		t.Errorf("CompareObjects() over the limit = %+v, want undecided", res)
+	}
+	if n := fake.countCalls("GetObject"); n != before {
+		t.Errorf("GetObject called %d more times over the limit, want 0", n-before)
+	}
+}
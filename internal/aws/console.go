@@ -0,0 +1,41 @@
+package aws
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// consoleHost returns the AWS console host for the partition a region belongs to
+func consoleHost(region string) string {
+	switch {
+	case strings.HasPrefix(region, "us-gov-"):
+		return "console.amazonaws-us-gov.com"
+	case strings.HasPrefix(region, "cn-"):
+		return "console.amazonaws.cn"
+	default:
+		return "s3.console.aws.amazon.com"
+	}
+}
+
+// ConsoleURL returns an S3 console deep link for an object, folder, or bucket.
+// Keys ending in "/" (and the empty key) open the folder view; anything else
+// opens the object overview. GovCloud and China regions use their partition's console.
+func ConsoleURL(region, bucket, key string) string {
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	query := url.Values{}
+	query.Set("region", region)
+
+	page := "object"
+	if key == "" || strings.HasSuffix(key, "/") {
+		page = "buckets"
+	}
+	if key != "" {
+		query.Set("prefix", key)
+	}
+
+	return fmt.Sprintf("https://%s/s3/%s/%s?%s", consoleHost(region), page, url.PathEscape(bucket), query.Encode())
+}
@@ -0,0 +1,52 @@
+package aws
+
+import "testing"
+
+func TestConsoleURL(t *testing.T) {
+	tests := []struct {
+		name   string
+		region string
+		bucket string
+		key    string
+		want   string
+	}{
+		{
+			"standard region object",
+			"eu-west-1", "my-bucket", "logs/2024/app log.txt",
+			"https://s3.console.aws.amazon.com/s3/object/my-bucket?prefix=logs%2F2024%2Fapp+log.txt&region=eu-west-1",
+		},
+		{
+			"folder",
+			"us-west-2", "my-bucket", "logs/",
+			"https://s3.console.aws.amazon.com/s3/buckets/my-bucket?prefix=logs%2F&region=us-west-2",
+		},
+		{
+			"bucket root",
+			"us-west-2", "my-bucket", "",
+			"https://s3.console.aws.amazon.com/s3/buckets/my-bucket?region=us-west-2",
+		},
+		{
+			"govcloud partition",
+			"us-gov-west-1", "gov-bucket", "data.csv",
+			"https://console.amazonaws-us-gov.com/s3/object/gov-bucket?prefix=data.csv&region=us-gov-west-1",
+		},
+		{
+			"china partition",
+			"cn-north-1", "cn-bucket", "data.csv",
+			"https://console.amazonaws.cn/s3/object/cn-bucket?prefix=data.csv&region=cn-north-1",
+		},
+		{
+			"default region",
+			"", "my-bucket", "a.txt",
+			"https://s3.console.aws.amazon.com/s3/object/my-bucket?prefix=a.txt&region=us-east-1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ConsoleURL(tt.region, tt.bucket, tt.key); got != tt.want {
+				t.Errorf("ConsoleURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package aws
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// CORSRule is one cross-origin rule of a bucket's CORS configuration
+type CORSRule struct {
+	ID             string
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+	ExposeHeaders  []string
+	// MaxAgeSeconds is how long browsers may cache the preflight response; zero if unset
+	MaxAgeSeconds int
+}
+
+// GetBucketCORS returns the bucket's CORS rules. A bucket without a CORS
+// configuration returns no rules.
+func (c *Client) GetBucketCORS(ctx context.Context, bucket string) ([]CORSRule, error) {
+	output, err := c.S3.GetBucketCors(ctx, &s3.GetBucketCorsInput{
+		Bucket: aws.String(bucket),
+	})
+	if err != nil {
+		if hasErrorCode(err, "NoSuchCORSConfiguration") {
+			return nil, nil
+		}
+		return nil, &OpError{Op: "Reading CORS configuration", Bucket: bucket, Err: err}
+	}
+
+	rules := make([]CORSRule, 0, len(output.CORSRules))
+	for _, r := range output.CORSRules {
+		rules = append(rules, CORSRule{
+			ID:             aws.ToString(r.ID),
+			AllowedOrigins: r.AllowedOrigins,
+			AllowedMethods: r.AllowedMethods,
+			AllowedHeaders: r.AllowedHeaders,
+			ExposeHeaders:  r.ExposeHeaders,
+			MaxAgeSeconds:  int(aws.ToInt32(r.MaxAgeSeconds)),
+		})
+	}
+	return rules, nil
+}
@@ -0,0 +1,63 @@
+package aws
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func TestGetBucketCORS(t *testing.T) {
+	fake := newFakeS3()
+	fake.cors["site"] = []types.CORSRule{
+		{
+			ID:             aws.String("public-read"),
+			AllowedOrigins: []string{"*"},
+			AllowedMethods: []string{"GET", "HEAD"},
+			MaxAgeSeconds:  aws.Int32(3000),
+		},
+		{
+			AllowedOrigins: []string{"https://app.example.com"},
+			AllowedMethods: []string{"PUT", "POST"},
+			AllowedHeaders: []string{"Content-Type", "x-amz-*"},
+			ExposeHeaders:  []string{"ETag"},
+		},
+	}
+	client := &Client{S3: fake}
+
+	got, err := client.GetBucketCORS(context.Background(), "site")
+	if err != nil {
+		t.Fatalf("GetBucketCORS() error = %v", err)
+	}
+	want := []CORSRule{
+		{
+			ID:             "public-read",
+			AllowedOrigins: []string{"*"},
+			AllowedMethods: []string{"GET", "HEAD"},
+			MaxAgeSeconds:  3000,
+		},
+		{
+			AllowedOrigins: []string{"https://app.example.com"},
+			AllowedMethods: []string{"PUT", "POST"},
+			AllowedHeaders: []string{"Content-Type", "x-amz-*"},
+			ExposeHeaders:  []string{"ETag"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetBucketCORS() = %+v, want %+v", got, want)
+	}
+}
+
+func TestGetBucketCORSNoConfiguration(t *testing.T) {
+	client := &Client{S3: newFakeS3()}
+
+	got, err := client.GetBucketCORS(context.Background(), "plain")
+	if err != nil {
+		t.Fatalf("GetBucketCORS() error = %v, want nil for a bucket without CORS", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("GetBucketCORS() = %+v, want no rules", got)
+	}
+}
@@ -0,0 +1,206 @@
+package aws
+
+import (
+	"context"
+	"math"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/natevick/stui/internal/security"
+)
+
+// countSamplePages is how many pages an approximate count reads before
+// extrapolating
+const countSamplePages = 3
+
+// countProbeDepth bounds how many characters of the last key an approximate
+// count locates; each character costs about nine single-key requests
+const countProbeDepth = 6
+
+// CountObjects counts the objects under prefix. Exact mode pages through the
+// whole listing. Approximate mode reads a few pages and, when the listing
+// goes on, estimates the total from how far through the prefix's key range
+// the sample reached. The bool reports whether the count is exact.
+func (c *Client) CountObjects(ctx context.Context, bucket, prefix string, approx bool) (int64, bool, error) {
+	lister := c.NewLister(bucket, prefix, "")
+	var count int64
+	var first, last string
+	for pages := 0; lister.HasMorePages(); pages++ {
+		if approx && pages == countSamplePages {
+			break
+		}
+		objects, err := lister.NextPage(ctx)
+		if err != nil {
+			return 0, false, err
+		}
+		for _, obj := range objects {
+			if first == "" {
+				first = obj.Key
+			}
+			last = obj.Key
+		}
+		count += int64(len(objects))
+	}
+	if !lister.HasMorePages() {
+		return count, true, nil
+	}
+
+	// Directory buckets don't list in key order, so the sample says nothing
+	// about how much is left; report it as a lower bound
+	if security.IsDirectoryBucket(bucket) {
+		return count, false, nil
+	}
+	end, err := c.lastKey(ctx, bucket, prefix, last)
+	if err != nil {
+		return 0, false, err
+	}
+	return extrapolateCount(count, first, last, end), false, nil
+}
+
+// lastKey locates (approximately) the greatest key under prefix without
+// listing it all. It walks the key one character at a time, binary searching
+// the printable range with StartAfter for the highest character any key has
+// at that position. after is a key already known to exist.
+func (c *Client) lastKey(ctx context.Context, bucket, prefix, after string) (string, error) {
+	best := after
+	cur := prefix
+	for depth := 0; depth < countProbeDepth; depth++ {
+		key, err := c.keyAfter(ctx, bucket, cur, cur)
+		if err != nil {
+			return "", err
+		}
+		if key == "" {
+			break
+		}
+		lo, hi := byte(' '), byte('~')
+		for lo <= hi {
+			mid := lo + (hi-lo)/2
+			k, err := c.keyAfter(ctx, bucket, cur, cur+string(mid))
+			if err != nil {
+				return "", err
+			}
+			if k == "" {
+				hi = mid - 1
+				continue
+			}
+			key = k
+			lo = mid + 1
+		}
+		if key > best {
+			best = key
+		}
+		next := key[len(cur)]
+		if next > '~' {
+			// Non-ASCII bytes can't be probed one at a time as valid UTF-8
+			break
+		}
+		cur += string(next)
+	}
+	return best, nil
+}
+
+// keyAfter returns the first key under prefix that sorts after startAfter,
+// or "" when there is none
+func (c *Client) keyAfter(ctx context.Context, bucket, prefix, startAfter string) (string, error) {
+	lists := c.Options.ListSemaphore
+	if err := lists.Acquire(ctx); err != nil {
+		return "", err
+	}
+	var contents []types.Object
+	var encoding types.EncodingType
+	var err error
+	// A V1 marker means the same as StartAfter
+	if c.Options.ListAPIs.V1Only(c.endpoint()) {
+		var output *s3.ListObjectsOutput
+		output, err = c.S3.ListObjects(ctx, &s3.ListObjectsInput{
+			Bucket:       aws.String(bucket),
+			Prefix:       aws.String(prefix),
+			Marker:       aws.String(startAfter),
+			MaxKeys:      aws.Int32(1),
+			EncodingType: types.EncodingTypeUrl,
+		})
+		if err == nil {
+			contents, encoding = output.Contents, output.EncodingType
+		}
+	} else {
+		var output *s3.ListObjectsV2Output
+		output, err = c.S3.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+			Bucket:       aws.String(bucket),
+			Prefix:       aws.String(prefix),
+			StartAfter:   aws.String(startAfter),
+			MaxKeys:      aws.Int32(1),
+			EncodingType: types.EncodingTypeUrl,
+		})
+		if err == nil {
+			contents, encoding = output.Contents, output.EncodingType
+		}
+	}
+	lists.Release()
+	if err != nil {
+		return "", &OpError{Op: "Counting objects", Bucket: bucket, Key: prefix, Err: err}
+	}
+	if len(contents) == 0 {
+		return "", nil
+	}
+	return decodeKey(aws.ToString(contents[0].Key), encoding == types.EncodingTypeUrl), nil
+}
+
+// extrapolateCount estimates how many keys lie between first and end given
+// that count of them run from first to last. Keys are read as numbers whose
+// digits are the characters after their common prefix. Each position counts
+// in the smallest character class (digits, lower or upper case letters)
+// covering what the three keys show there, so that padded numeric names like
+// "00042" interpolate linearly.
+func extrapolateCount(count int64, first, last, end string) int64 {
+	common := 0
+	for common < len(first) && common < len(end) && first[common] == end[common] {
+		common++
+	}
+	keys := []string{first[common:], last[common:], end[common:]}
+	width := 0
+	for _, k := range keys {
+		width = max(width, len(k))
+	}
+	width = min(width, countProbeDepth)
+
+	values := make([]float64, len(keys))
+	for i := 0; i < width; i++ {
+		lo, hi, short := 255, 0, 0
+		for _, k := range keys {
+			if i < len(k) {
+				lo, hi = min(lo, int(k[i])), max(hi, int(k[i]))
+			} else {
+				short = 1
+			}
+		}
+		lo, hi = charClass(lo, hi)
+		radix := float64(hi - lo + 1 + short)
+		for j, k := range keys {
+			digit := 0
+			if i < len(k) {
+				// A key that has ended sorts below every character
+				digit = int(k[i]) - lo + short
+			}
+			values[j] = values[j]*radix + float64(digit)
+		}
+	}
+
+	covered := values[1] - values[0]
+	total := values[2] - values[0]
+	if covered <= 0 || total <= covered {
+		return count
+	}
+	return max(count, 1+int64(math.Round(float64(count-1)*total/covered)))
+}
+
+// charClass widens the character range lo..hi to the digit or letter class
+// containing it, leaving other ranges as they are
+func charClass(lo, hi int) (int, int) {
+	for _, class := range [][2]int{{'0', '9'}, {'a', 'z'}, {'A', 'Z'}} {
+		if lo >= class[0] && hi <= class[1] {
+			return class[0], class[1]
+		}
+	}
+	return lo, hi
+}
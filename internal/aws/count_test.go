@@ -0,0 +1,128 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func newCountFake(n int) *fakeS3 {
+	fake := newFakeS3()
+	for i := 0; i < n; i++ {
+		fake.put("b", fmt.Sprintf("data/%05d.json", i), fakeObject{body: []byte("x")})
+	}
+	// Neighbours that must not be counted
+	fake.put("b", "datastore/a", fakeObject{body: []byte("x")})
+	fake.put("b", "other/a", fakeObject{body: []byte("x")})
+	return fake
+}
+
+func TestCountObjectsExact(t *testing.T) {
+	tests := []struct {
+		name     string
+		objects  int
+		pageSize int
+		wantReqs int
+	}{
+		{"empty", 0, 100, 1},
+		{"single page", 40, 100, 1},
+		{"paginated", 950, 100, 10},
+		{"exact page boundary", 300, 100, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newCountFake(tt.objects)
+			client := &Client{S3: fake, Options: ClientOptions{PageSize: tt.pageSize}}
+
+			count, exact, err := client.CountObjects(context.Background(), "b", "data/", false)
+			if err != nil {
+				t.Fatalf("CountObjects() error = %v", err)
+			}
+			if count != int64(tt.objects) || !exact {
+				t.Errorf("CountObjects() = %d, %v, want %d, true", count, exact, tt.objects)
+			}
+			if got := fake.countCalls("ListObjectsV2"); got != tt.wantReqs {
+				t.Errorf("made %d list requests, want %d", got, tt.wantReqs)
+			}
+		})
+	}
+}
+
+func TestCountObjectsApproximate(t *testing.T) {
+	fake := newCountFake(2500)
+	client := &Client{S3: fake, Options: ClientOptions{PageSize: 100}}
+
+	count, exact, err := client.CountObjects(context.Background(), "b", "data/", true)
+	if err != nil {
+		t.Fatalf("CountObjects() error = %v", err)
+	}
+	if exact {
+		t.Error("approximate count of a long listing reported exact")
+	}
+	if count < 2000 || count > 3000 {
+		t.Errorf("estimate = %d, want roughly 2500", count)
+	}
+	// A full listing would take 25 pages; the estimate should cost far less
+	// than that in page-sized requests
+	if got := len(fake.listInputs); got > 3+countProbeDepth*8 {
+		t.Errorf("made %d list requests", got)
+	}
+	pages := 0
+	for _, in := range fake.listInputs {
+		if in.MaxKeys != nil && *in.MaxKeys > 1 {
+			pages++
+		}
+	}
+	if pages != countSamplePages {
+		t.Errorf("read %d full pages, want %d", pages, countSamplePages)
+	}
+}
+
+func TestCountObjectsApproximateShortListingIsExact(t *testing.T) {
+	fake := newCountFake(150)
+	client := &Client{S3: fake, Options: ClientOptions{PageSize: 100}}
+
+	count, exact, err := client.CountObjects(context.Background(), "b", "data/", true)
+	if err != nil {
+		t.Fatalf("CountObjects() error = %v", err)
+	}
+	if count != 150 || !exact {
+		t.Errorf("CountObjects() = %d, %v, want 150, true", count, exact)
+	}
+}
+
+func TestCountObjectsError(t *testing.T) {
+	fake := newCountFake(10)
+	fake.errFor = func(op, key string) error {
+		if op == "ListObjectsV2" {
+			return fmt.Errorf("boom")
+		}
+		return nil
+	}
+	client := &Client{S3: fake}
+
+	if _, _, err := client.CountObjects(context.Background(), "b", "data/", false); err == nil {
+		t.Error("CountObjects() error = nil, want listing failure")
+	}
+}
+
+func TestExtrapolateCount(t *testing.T) {
+	tests := []struct {
+		name             string
+		count            int64
+		first, last, end string
+		want             int64
+	}{
+		{"padded numbers", 3000, "p/00000", "p/02999", "p/04999", 5000},
+		{"letters", 3, "a", "c", "z", 26},
+		{"sample reached the end", 10, "a", "z", "z", 10},
+		{"never below the sample", 10, "a", "m", "b", 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extrapolateCount(tt.count, tt.first, tt.last, tt.end); got != tt.want {
+				t.Errorf("extrapolateCount() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/natevick/stui/internal/security"
+)
+
+// CredentialSource supplies the credentials a Client signs with, e.g. from a
+// secrets manager or Vault. Its method matches aws.CredentialsProvider, so
+// any SDK provider is also a source.
+type CredentialSource interface {
+	Retrieve(ctx context.Context) (aws.Credentials, error)
+}
+
+// DefaultCredentials returns the SDK's default credential chain for profile:
+// environment variables, the shared config and credentials files (including
+// SSO), and instance or container roles. An empty profile uses the default.
+func DefaultCredentials(ctx context.Context, profile string) (CredentialSource, error) {
+	if err := security.ValidProfileName(profile); err != nil {
+		return nil, err
+	}
+	var opts []func(*config.LoadOptions) error
+	if profile != "" {
+		opts = append(opts, config.WithSharedConfigProfile(profile))
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	return cfg.Credentials, nil
+}
+
+// StaticCredentials returns a source that always supplies the same keys.
+// sessionToken may be empty for long-term access keys.
+func StaticCredentials(accessKeyID, secretAccessKey, sessionToken string) CredentialSource {
+	return aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+		if accessKeyID == "" || secretAccessKey == "" {
+			return aws.Credentials{}, fmt.Errorf("static credentials need an access key ID and secret")
+		}
+		return aws.Credentials{
+			AccessKeyID:     accessKeyID,
+			SecretAccessKey: secretAccessKey,
+			SessionToken:    sessionToken,
+			Source:          "StaticCredentials",
+		}, nil
+	})
+}
+
+// credentialsProvider adapts a source for the SDK, caching what it returns
+// until it expires so the source isn't asked on every request
+func credentialsProvider(src CredentialSource) aws.CredentialsProvider {
+	if cache, ok := src.(*aws.CredentialsCache); ok {
+		return cache
+	}
+	return aws.NewCredentialsCache(aws.CredentialsProviderFunc(src.Retrieve))
+}
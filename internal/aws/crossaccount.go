@@ -0,0 +1,104 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/natevick/stui/internal/security"
+)
+
+// DefaultCopyPartSize is the part size for streamed copies. Objects larger
+// than one part are uploaded with multipart upload.
+const DefaultCopyPartSize = 10 * 1024 * 1024
+
+// SrcLocation is the object a cross-account copy reads from
+type SrcLocation struct {
+	Client *Client
+	Bucket string
+	Key    string
+}
+
+// DstLocation is where a cross-account copy writes to
+type DstLocation struct {
+	Client *Client
+	Bucket string
+	Key    string
+	// PartSize is the multipart part size and threshold; zero uses DefaultCopyPartSize
+	PartSize int64
+	// OnExists decides what happens when Key is taken, as for an Uploader;
+	// the zero value refuses with ErrObjectExists
+	OnExists OnExists
+	// EgressWarnBytes, when positive, makes a cross-region copy of at least
+	// this many bytes fail with an *EgressError unless EgressConfirmed is set
+	EgressWarnBytes int64
+	EgressConfirmed bool
+}
+
+// CrossAccountCopy streams an object from one client's credentials to
+// another's. Server-side CopyObject needs one principal with access to both
+// buckets, which is often blocked across accounts, so the bytes are read with
+// GetObject and written with PutObject, switching to multipart upload when
+// the object is larger than one part. Content type and metadata are preserved.
+// An existing destination is handled by dst.OnExists; the result reports the
+// key written, or Skipped.
+func CrossAccountCopy(ctx context.Context, src SrcLocation, dst DstLocation) (UploadResult, error) {
+	if src.Client == nil || dst.Client == nil {
+		return UploadResult{}, fmt.Errorf("source and destination clients are required")
+	}
+	if err := dst.Client.checkWritable(dst.Bucket); err != nil {
+		return UploadResult{}, err
+	}
+	if err := security.ValidNewObjectKey(dst.Key); err != nil {
+		return UploadResult{}, err
+	}
+
+	// Settle the destination before reading anything from the source
+	target, skip, err := dst.Client.NewUploader(dst.OnExists).resolveKey(ctx, dst.Bucket, dst.Key)
+	if err != nil {
+		return UploadResult{}, err
+	}
+	if skip {
+		return UploadResult{Key: dst.Key, Skipped: true}, nil
+	}
+
+	output, err := src.Client.S3.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(src.Bucket),
+		Key:    aws.String(src.Key),
+	})
+	if err != nil {
+		return UploadResult{}, &OpError{Op: "Reading source object", Bucket: src.Bucket, Key: src.Key, Err: err}
+	}
+	defer output.Body.Close()
+
+	if !dst.EgressConfirmed {
+		if err := checkCopyEgress(ctx, src, dst, aws.ToInt64(output.ContentLength)); err != nil {
+			return UploadResult{}, err
+		}
+	}
+
+	partSize := dst.PartSize
+	if partSize <= 0 {
+		partSize = DefaultCopyPartSize
+	}
+
+	uploader := manager.NewUploader(dst.Client.S3, func(u *manager.Uploader) {
+		u.PartSize = partSize
+		u.Concurrency = 5
+	})
+
+	_, err = uploader.Upload(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(dst.Bucket),
+		Key:         aws.String(target),
+		Body:        output.Body,
+		ContentType: output.ContentType,
+		Metadata:    output.Metadata,
+	})
+	if err != nil {
+		return UploadResult{}, &OpError{Op: "Writing destination object", Bucket: dst.Bucket, Key: target, Err: err}
+	}
+
+	return UploadResult{Key: target}, nil
+}
@@ -0,0 +1,184 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func TestCrossAccountCopySinglePart(t *testing.T) {
+	srcFake, dstFake := newFakeS3(), newFakeS3()
+	srcFake.put("dev-bucket", "reports/q1.csv", fakeObject{
+		body:        []byte("a,b,c\n1,2,3\n"),
+		contentType: "text/csv",
+		metadata:    map[string]string{"team": "data"},
+	})
+
+	_, err := CrossAccountCopy(context.Background(),
+		SrcLocation{Client: &Client{S3: srcFake}, Bucket: "dev-bucket", Key: "reports/q1.csv"},
+		DstLocation{Client: &Client{S3: dstFake}, Bucket: "prod-bucket", Key: "reports/q1.csv"},
+	)
+	if err != nil {
+		t.Fatalf("CrossAccountCopy() error = %v", err)
+	}
+
+	got, ok := dstFake.get("prod-bucket", "reports/q1.csv")
+	if !ok {
+		t.Fatal("destination object not written")
+	}
+	if string(got.body) != "a,b,c\n1,2,3\n" {
+		t.Errorf("destination body = %q", got.body)
+	}
+	if got.contentType != "text/csv" || got.metadata["team"] != "data" {
+		t.Errorf("content type/metadata not preserved: %+v", got)
+	}
+	if dstFake.countCalls("PutObject") != 1 || dstFake.countCalls("CreateMultipartUpload") != 0 {
+		t.Errorf("expected a single PutObject, calls = %v", dstFake.calls)
+	}
+	if srcFake.countCalls("PutObject") != 0 {
+		t.Error("source client must not be written to")
+	}
+}
+
+func TestCrossAccountCopyMultipart(t *testing.T) {
+	const partSize = 5 * 1024 * 1024
+	body := bytes.Repeat([]byte("0123456789abcdef"), (2*partSize+4096)/16)
+
+	srcFake, dstFake := newFakeS3(), newFakeS3()
+	srcFake.put("dev-bucket", "big.bin", fakeObject{body: body, contentType: "application/octet-stream"})
+
+	_, err := CrossAccountCopy(context.Background(),
+		SrcLocation{Client: &Client{S3: srcFake}, Bucket: "dev-bucket", Key: "big.bin"},
+		DstLocation{Client: &Client{S3: dstFake}, Bucket: "prod-bucket", Key: "big.bin", PartSize: partSize},
+	)
+	if err != nil {
+		t.Fatalf("CrossAccountCopy() error = %v", err)
+	}
+
+	got, ok := dstFake.get("prod-bucket", "big.bin")
+	if !ok {
+		t.Fatal("destination object not written")
+	}
+	if !bytes.Equal(got.body, body) {
+		t.Errorf("destination body differs: got %d bytes, want %d", len(got.body), len(body))
+	}
+	if dstFake.countCalls("CreateMultipartUpload") != 1 {
+		t.Errorf("expected multipart upload, calls = %v", dstFake.calls)
+	}
+	if n := dstFake.countCalls("UploadPart"); n != 3 {
+		t.Errorf("UploadPart calls = %d, want 3", n)
+	}
+	if dstFake.countCalls("PutObject") != 0 {
+		t.Error("expected no single-part PutObject above the threshold")
+	}
+}
+
+func TestCrossAccountCopyProtectedDestination(t *testing.T) {
+	srcFake, dstFake := newFakeS3(), newFakeS3()
+	srcFake.put("dev-bucket", "a.txt", fakeObject{body: []byte("a")})
+
+	_, err := CrossAccountCopy(context.Background(),
+		SrcLocation{Client: &Client{S3: srcFake}, Bucket: "dev-bucket", Key: "a.txt"},
+		DstLocation{Client: &Client{S3: dstFake, Options: ClientOptions{ProtectedBuckets: []string{"prod-*"}}}, Bucket: "prod-bucket", Key: "a.txt"},
+	)
+	if !errors.Is(err, ErrProtectedBucket) {
+		t.Fatalf("CrossAccountCopy() error = %v, want ErrProtectedBucket", err)
+	}
+	if len(srcFake.calls) != 0 || len(dstFake.calls) != 0 {
+		t.Error("expected no requests for a protected destination")
+	}
+}
+
+func TestCrossAccountCopyEgress(t *testing.T) {
+	tests := []struct {
+		name      string
+		dstRegion string
+		confirmed bool
+		wantErr   bool
+	}{
+		{"same region", "us-east-1", false, false},
+		{"cross region", "eu-west-1", false, true},
+		{"cross region confirmed", "eu-west-1", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srcFake, dstFake := newFakeS3(), newFakeS3()
+			srcFake.put("dev-bucket", "dump.tar", fakeObject{body: []byte("0123456789")})
+			dstFake.bucketRegions = map[string]types.BucketLocationConstraint{"prod-bucket": types.BucketLocationConstraint(tt.dstRegion)}
+
+			_, err := CrossAccountCopy(context.Background(),
+				SrcLocation{Client: &Client{S3: srcFake}, Bucket: "dev-bucket", Key: "dump.tar"},
+				DstLocation{Client: &Client{S3: dstFake}, Bucket: "prod-bucket", Key: "dump.tar",
+					EgressWarnBytes: 10, EgressConfirmed: tt.confirmed},
+			)
+			if tt.wantErr {
+				var egress *EgressError
+				if !errors.As(err, &egress) || !errors.Is(err, ErrNeedsConfirmation) {
+					t.Fatalf("CrossAccountCopy() error = %v, want an EgressError", err)
+				}
+				if egress.From != "us-east-1" || egress.To != "eu-west-1" || egress.Size != 10 {
+					t.Errorf("EgressError = %+v", egress)
+				}
+				if dstFake.countCalls("PutObject") != 0 {
+					t.Error("an unconfirmed cross-region copy must not write")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CrossAccountCopy() error = %v", err)
+			}
+			if _, ok := dstFake.get("prod-bucket", "dump.tar"); !ok {
+				t.Error("destination object not written")
+			}
+		})
+	}
+}
+
+func TestCrossAccountCopyOnExists(t *testing.T) {
+	tests := []struct {
+		policy   OnExists
+		wantKey  string
+		wantSkip bool
+		wantErr  error
+		wantBody string
+	}{
+		{OnExistsFail, "", false, ErrObjectExists, "old"},
+		{OnExistsSkip, "a.txt", true, nil, "old"},
+		{OnExistsOverwrite, "a.txt", false, nil, "new"},
+		{OnExistsRename, "a (1).txt", false, nil, "old"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			srcFake, dstFake := newFakeS3(), newFakeS3()
+			srcFake.put("dev-bucket", "a.txt", fakeObject{body: []byte("new")})
+			dstFake.put("prod-bucket", "a.txt", fakeObject{body: []byte("old")})
+
+			res, err := CrossAccountCopy(context.Background(),
+				SrcLocation{Client: &Client{S3: srcFake}, Bucket: "dev-bucket", Key: "a.txt"},
+				DstLocation{Client: &Client{S3: dstFake}, Bucket: "prod-bucket", Key: "a.txt", OnExists: tt.policy},
+			)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CrossAccountCopy() error = %v, want %v", err, tt.wantErr)
+			}
+			if res.Key != tt.wantKey || res.Skipped != tt.wantSkip {
+				t.Errorf("result = %+v, want key %q skipped %v", res, tt.wantKey, tt.wantSkip)
+			}
+			if got, _ := dstFake.get("prod-bucket", "a.txt"); string(got.body) != tt.wantBody {
+				t.Errorf("a.txt = %q, want %q", got.body, tt.wantBody)
+			}
+			if (tt.wantErr != nil || tt.wantSkip) && srcFake.countCalls("GetObject") != 0 {
+				t.Error("the source was read although nothing was written")
+			}
+			if tt.policy == OnExistsRename {
+				if got, _ := dstFake.get("prod-bucket", tt.wantKey); string(got.body) != "new" {
+					t.Errorf("%s = %q, want the copied object", tt.wantKey, got.body)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// DefaultMaxEditBytes is the largest object EditObject fetches when the
+// caller doesn't give a limit
+const DefaultMaxEditBytes = 1 << 20
+
+// ErrPreconditionFailed is returned when saving an edit finds the object
+// changed or deleted since it was read
+var ErrPreconditionFailed = errors.New("object changed since it was read")
+
+// ErrNotEditable is returned for objects that aren't text
+var ErrNotEditable = errors.New("only text objects can be edited")
+
+// EditBuffer is a small text object fetched for editing. SaveEdit writes it
+// back only if the object still has ETag.
+type EditBuffer struct {
+	Bucket string
+	Key    string
+	Body   []byte
+	// ETag identifies the version that was read; SaveEdit advances it
+	ETag         string
+	ContentType  string
+	Metadata     map[string]string
+	StorageClass string
+
+	// Headers, encryption and tags that PutObject would otherwise reset
+	CacheControl         string
+	ContentDisposition   string
+	ContentEncoding      string
+	ContentLanguage      string
+	ServerSideEncryption types.ServerSideEncryption
+	SSEKMSKeyID          string
+	Tags                 map[string]string
+}
+
+// EditObject fetches a text object of at most limit bytes for editing; zero
+// uses DefaultMaxEditBytes. Larger objects fail with a *SizeLimitError and
+// binary ones with ErrNotEditable.
+func (c *Client) EditObject(ctx context.Context, bucket, key string, limit int64) (*EditBuffer, error) {
+	if err := c.checkWritable(bucket); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = DefaultMaxEditBytes
+	}
+	head, err := c.S3.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, &OpError{Op: "Reading object metadata", Bucket: bucket, Key: key, Err: err}
+	}
+	if err := CheckSize(aws.ToInt64(head.ContentLength), limit); err != nil {
+		return nil, err
+	}
+	var tags map[string]string
+	if aws.ToInt32(head.TagCount) > 0 {
+		// Saving without them would strip the object's tags
+		if tags, err = c.objectTags(ctx, bucket, key); err != nil {
+			return nil, err
+		}
+	}
+
+	// The size is known to fit, so fetch it whole. Should the object change
+	// between the HEAD and the GET, the save fails on the older ETag rather
+	// than overwriting anything.
+	body, err := c.PreviewObject(ctx, bucket, key, limit, true)
+	if err != nil {
+		return nil, err
+	}
+	if !utf8.Valid(body) || bytes.IndexByte(body, 0) >= 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotEditable, FormatS3URI(bucket, key))
+	}
+
+	buf := &EditBuffer{
+		Bucket:             bucket,
+		Key:                key,
+		Body:               body,
+		ETag:               strings.Trim(aws.ToString(head.ETag), "\""),
+		ContentType:        aws.ToString(head.ContentType),
+		Metadata:           head.Metadata,
+		StorageClass:       GetStorageClass(head.StorageClass),
+		CacheControl:       aws.ToString(head.CacheControl),
+		ContentDisposition: aws.ToString(head.ContentDisposition),
+		ContentEncoding:    aws.ToString(head.ContentEncoding),
+		ContentLanguage:    aws.ToString(head.ContentLanguage),
+		Tags:               tags,
+	}
+	// Without these the save would fall back to the bucket's default encryption
+	if head.ServerSideEncryption == types.ServerSideEncryptionAwsKms {
+		buf.ServerSideEncryption = head.ServerSideEncryption
+		buf.SSEKMSKeyID = aws.ToString(head.SSEKMSKeyId)
+	}
+	return buf, nil
+}
+
+// objectTags returns an object's tags
+func (c *Client) objectTags(ctx context.Context, bucket, key string) (map[string]string, error) {
+	output, err := c.S3.GetObjectTagging(ctx, &s3.GetObjectTaggingInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, &OpError{Op: "Reading object tags", Bucket: bucket, Key: key, Err: err}
+	}
+	tags := make(map[string]string, len(output.TagSet))
+	for _, tag := range output.TagSet {
+		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return tags, nil
+}
+
+// SaveEdit writes body over the object buf was read from, with If-Match on
+// buf.ETag so a concurrent change is never overwritten; that case fails with
+// ErrPreconditionFailed. Content headers, metadata, storage class, KMS
+// encryption and tags are kept. On success buf holds the saved body and its
+// new ETag.
+func (c *Client) SaveEdit(ctx context.Context, buf *EditBuffer, body []byte) error {
+	if err := c.checkWritable(buf.Bucket); err != nil {
+		return err
+	}
+
+	input := &s3.PutObjectInput{
+		Bucket:               aws.String(buf.Bucket),
+		Key:                  aws.String(buf.Key),
+		Body:                 bytes.NewReader(body),
+		IfMatch:              aws.String(`"` + buf.ETag + `"`),
+		Metadata:             buf.Metadata,
+		ContentType:          optional(buf.ContentType),
+		CacheControl:         optional(buf.CacheControl),
+		ContentDisposition:   optional(buf.ContentDisposition),
+		ContentEncoding:      optional(buf.ContentEncoding),
+		ContentLanguage:      optional(buf.ContentLanguage),
+		ServerSideEncryption: buf.ServerSideEncryption,
+		SSEKMSKeyId:          optional(buf.SSEKMSKeyID),
+	}
+	if len(buf.Tags) > 0 {
+		tags := make(url.Values, len(buf.Tags))
+		for k, v := range buf.Tags {
+			tags.Set(k, v)
+		}
+		input.Tagging = aws.String(tags.Encode())
+	}
+	if buf.StorageClass != "" && buf.StorageClass != "STANDARD" {
+		input.StorageClass = types.StorageClass(buf.StorageClass)
+	}
+
+	output, err := c.S3.PutObject(ctx, input)
+	if err != nil {
+		// A deleted object fails If-Match with NoSuchKey
+		if IsPreconditionFailed(err) || IsNotFound(err) {
+			err = fmt.Errorf("%w: %w", ErrPreconditionFailed, err)
+		}
+		return &OpError{Op: "Saving object", Bucket: buf.Bucket, Key: buf.Key, Err: err}
+	}
+	c.Options.Metrics.AddBytesUp(int64(len(body)))
+
+	buf.Body = body
+	buf.ETag = strings.Trim(aws.ToString(output.ETag), "\"")
+	return nil
+}
+
+// optional returns s, or nil when it is empty so the header is left out of
+// the request rather than sent blank
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return aws.String(s)
+}
@@ -0,0 +1,134 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func TestEditObjectRoundTrip(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("b", "config/app.yaml", fakeObject{
+		body:        []byte("replicas: 2\n"),
+		contentType: "application/yaml",
+		metadata:    map[string]string{"owner": "ops"},
+	})
+	client := &Client{S3: fake}
+	ctx := context.Background()
+
+	buf, err := client.EditObject(ctx, "b", "config/app.yaml", 0)
+	if err != nil {
+		t.Fatalf("EditObject() error = %v", err)
+	}
+	if string(buf.Body) != "replicas: 2\n" {
+		t.Fatalf("Body = %q, want the object's content", buf.Body)
+	}
+
+	if err := client.SaveEdit(ctx, buf, []byte("replicas: 3\n")); err != nil {
+		t.Fatalf("SaveEdit() error = %v", err)
+	}
+	got, _ := fake.get("b", "config/app.yaml")
+	if string(got.body) != "replicas: 3\n" {
+		t.Errorf("object = %q, want the edit", got.body)
+	}
+	if got.contentType != "application/yaml" || got.metadata["owner"] != "ops" {
+		t.Errorf("content type %q and metadata %v weren't kept", got.contentType, got.metadata)
+	}
+	if buf.ETag != got.etag() {
+		t.Errorf("buffer ETag = %q, want the saved object's %q", buf.ETag, got.etag())
+	}
+
+	// The buffer tracks the saved version, so a second save goes through too
+	if err := client.SaveEdit(ctx, buf, []byte("replicas: 4\n")); err != nil {
+		t.Errorf("second SaveEdit() error = %v", err)
+	}
+}
+
+func TestSaveEditConcurrentModification(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("b", "config/app.json", fakeObject{body: []byte(`{"debug": false}`)})
+	client := &Client{S3: fake}
+	ctx := context.Background()
+
+	buf, err := client.EditObject(ctx, "b", "config/app.json", 0)
+	if err != nil {
+		t.Fatalf("EditObject() error = %v", err)
+	}
+
+	// Someone else saves first
+	fake.put("b", "config/app.json", fakeObject{body: []byte(`{"debug": false, "workers": 8}`)})
+
+	err = client.SaveEdit(ctx, buf, []byte(`{"debug": true}`))
+	if !errors.Is(err, ErrPreconditionFailed) {
+		t.Fatalf("SaveEdit() error = %v, want ErrPreconditionFailed", err)
+	}
+	if got, _ := fake.get("b", "config/app.json"); string(got.body) != `{"debug": false, "workers": 8}` {
+		t.Errorf("object = %q, want the concurrent change kept", got.body)
+	}
+
+	// A delete in the meantime is a change too
+	if _, err := fake.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: aws.String("b"), Key: aws.String("config/app.json")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.SaveEdit(ctx, buf, []byte(`{}`)); !errors.Is(err, ErrPreconditionFailed) {
+		t.Errorf("SaveEdit() after delete error = %v, want ErrPreconditionFailed", err)
+	}
+}
+
+func TestEditObjectRefusals(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("b", "big.txt", fakeObject{body: make([]byte, 100)})
+	fake.put("b", "image.png", fakeObject{body: []byte{0x89, 'P', 'N', 'G', 0x00}})
+	ctx := context.Background()
+
+	client := &Client{S3: fake}
+	var limitErr *SizeLimitError
+	if _, err := client.EditObject(ctx, "b", "big.txt", 10); !errors.As(err, &limitErr) {
+		t.Errorf("EditObject(big) error = %v, want *SizeLimitError", err)
+	}
+	if _, err := client.EditObject(ctx, "b", "image.png", 0); !errors.Is(err, ErrNotEditable) {
+		t.Errorf("EditObject(binary) error = %v, want ErrNotEditable", err)
+	}
+
+	readOnly := &Client{S3: fake, Options: ClientOptions{ReadOnly: true}}
+	if _, err := readOnly.EditObject(ctx, "b", "big.txt", 0); !errors.Is(err, ErrReadOnlyMode) {
+		t.Errorf("EditObject() in read-only mode error = %v, want ErrReadOnlyMode", err)
+	}
+}
+
+func TestSaveEditKeepsEncryptionHeadersAndTags(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("b", "config/app.yaml", fakeObject{
+		body:         []byte("replicas: 2\n"),
+		cacheControl: "no-cache",
+		sse:          types.ServerSideEncryptionAwsKms,
+		kmsKeyID:     "arn:aws:kms:us-east-1:123456789012:key/app",
+		tags:         map[string]string{"team": "ops", "cost center": "a&b"},
+	})
+	client := &Client{S3: fake}
+	ctx := context.Background()
+
+	buf, err := client.EditObject(ctx, "b", "config/app.yaml", 0)
+	if err != nil {
+		t.Fatalf("EditObject() error = %v", err)
+	}
+	if err := client.SaveEdit(ctx, buf, []byte("replicas: 3\n")); err != nil {
+		t.Fatalf("SaveEdit() error = %v", err)
+	}
+
+	got, _ := fake.get("b", "config/app.yaml")
+	if got.cacheControl != "no-cache" {
+		t.Errorf("Cache-Control = %q, want no-cache", got.cacheControl)
+	}
+	if got.sse != types.ServerSideEncryptionAwsKms || got.kmsKeyID != "arn:aws:kms:us-east-1:123456789012:key/app" {
+		t.Errorf("encryption = %q with key %q, want the original KMS key", got.sse, got.kmsKeyID)
+	}
+	if want := map[string]string{"team": "ops", "cost center": "a&b"}; !maps.Equal(got.tags, want) {
+		t.Errorf("tags = %v, want %v", got.tags, want)
+	}
+}
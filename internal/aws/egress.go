@@ -0,0 +1,54 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dustin/go-humanize"
+)
+
+// Location is one end of a transfer, for estimating data transfer charges
+type Location struct {
+	// Region holds the data; for the local machine it is the configured home
+	// region. Empty means unknown.
+	Region string
+}
+
+// WouldIncurEgress reports whether moving data from src to dst crosses
+// regions, which AWS bills as data transfer. Unknown regions never warn, so
+// users who haven't set a home region aren't nagged.
+func WouldIncurEgress(src, dst Location) bool {
+	return src.Region != "" && dst.Region != "" && src.Region != dst.Region
+}
+
+// EgressError reports a transfer that crosses regions and so incurs data
+// transfer charges. It matches ErrNeedsConfirmation with errors.Is.
+type EgressError struct {
+	Size int64
+	From string
+	To   string
+}
+
+func (e *EgressError) Error() string {
+	return fmt.Sprintf("transferring %s from %s to %s incurs data transfer charges: %v",
+		humanize.Bytes(uint64(e.Size)), e.From, e.To, ErrNeedsConfirmation)
+}
+
+func (e *EgressError) Unwrap() error {
+	return ErrNeedsConfirmation
+}
+
+// checkCopyEgress returns an *EgressError if copying size bytes from
+// srcBucket to dstBucket crosses regions and size reaches warnBytes. A
+// region that can't be looked up is unknown and never warns.
+func checkCopyEgress(ctx context.Context, src SrcLocation, dst DstLocation, size int64) error {
+	if dst.EgressWarnBytes <= 0 || size < dst.EgressWarnBytes {
+		return nil
+	}
+	from, _ := src.Client.GetBucketRegion(ctx, src.Bucket)
+	to, _ := dst.Client.GetBucketRegion(ctx, dst.Bucket)
+	if !WouldIncurEgress(Location{Region: from}, Location{Region: to}) {
+		return nil
+	}
+	return &EgressError{Size: size, From: from, To: to}
+}
@@ -0,0 +1,24 @@
+package aws
+
+import "testing"
+
+func TestWouldIncurEgress(t *testing.T) {
+	tests := []struct {
+		name     string
+		src, dst Location
+		want     bool
+	}{
+		{"same region", Location{Region: "us-east-1"}, Location{Region: "us-east-1"}, false},
+		{"cross region", Location{Region: "eu-west-1"}, Location{Region: "us-east-1"}, true},
+		{"no home region", Location{Region: "eu-west-1"}, Location{}, false},
+		{"unknown source", Location{}, Location{Region: "us-east-1"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WouldIncurEgress(tt.src, tt.dst); got != tt.want {
+				t.Errorf("WouldIncurEgress(%+v, %+v) = %v, want %v", tt.src, tt.dst, got, tt.want)
+			}
+		})
+	}
+}
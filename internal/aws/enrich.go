@@ -0,0 +1,62 @@
+package aws
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// DefaultEnrichConcurrency is the number of HEAD requests issued in parallel
+// when the caller doesn't specify a limit
+const DefaultEnrichConcurrency = 8
+
+// EnrichObjects fills in content type, metadata, and storage class for a
+// listing using at most concurrency parallel HeadObject calls. Objects are
+// updated in place; prefixes are skipped. A failed HEAD leaves that row
+// un-enriched without stopping the rest, so the only error returned is
+// cancellation of ctx.
+func (c *Client) EnrichObjects(ctx context.Context, bucket string, objs []S3Object, concurrency int) error {
+	if concurrency <= 0 {
+		concurrency = DefaultEnrichConcurrency
+	}
+
+	jobs := make(chan int, len(objs))
+	for i := range objs {
+		if !objs[i].IsPrefix {
+			jobs <- i
+		}
+	}
+	close(jobs)
+
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if ctx.Err() != nil {
+					return
+				}
+
+				output, err := c.S3.HeadObject(ctx, &s3.HeadObjectInput{
+					Bucket: aws.String(bucket),
+					Key:    aws.String(objs[i].Key),
+				})
+				if err != nil {
+					continue
+				}
+
+				// Each worker owns distinct indexes, so no lock is needed
+				objs[i].ContentType = aws.ToString(output.ContentType)
+				objs[i].Metadata = output.Metadata
+				objs[i].StorageClass = GetStorageClass(output.StorageClass)
+				objs[i].Enriched = true
+			}
+		}()
+	}
+	wg.Wait()
+
+	return ctx.Err()
+}
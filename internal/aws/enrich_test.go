@@ -0,0 +1,118 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func enrichFixture(n int) (*fakeS3, []S3Object) {
+	fake := newFakeS3()
+	objs := make([]S3Object, 0, n+1)
+	for i := 0; i < n; i++ {
+		key := fmt.Sprintf("data/file-%02d.json", i)
+		fake.put("b", key, fakeObject{
+			body:         []byte("{}"),
+			contentType:  "application/json",
+			metadata:     map[string]string{"index": fmt.Sprint(i)},
+			storageClass: types.StorageClassGlacierIr,
+		})
+		objs = append(objs, S3Object{Key: key})
+	}
+	objs = append(objs, S3Object{Key: "data/nested/", IsPrefix: true})
+	return fake, objs
+}
+
+func TestEnrichObjectsFillsAllRows(t *testing.T) {
+	fake, objs := enrichFixture(20)
+	client := &Client{S3: fake}
+
+	if err := client.EnrichObjects(context.Background(), "b", objs, 4); err != nil {
+		t.Fatalf("EnrichObjects() error = %v", err)
+	}
+
+	for i, obj := range objs {
+		if obj.IsPrefix {
+			if obj.Enriched {
+				t.Errorf("prefix %q should not be enriched", obj.Key)
+			}
+			continue
+		}
+		if !obj.Enriched {
+			t.Errorf("%q not enriched", obj.Key)
+		}
+		if obj.ContentType != "application/json" {
+			t.Errorf("%q content type = %q", obj.Key, obj.ContentType)
+		}
+		if obj.Metadata["index"] != fmt.Sprint(i) {
+			t.Errorf("%q metadata = %v", obj.Key, obj.Metadata)
+		}
+		if obj.StorageClass != "GLACIER_IR" {
+			t.Errorf("%q storage class = %q", obj.Key, obj.StorageClass)
+		}
+	}
+	if got := fake.countCalls("HeadObject"); got != 20 {
+		t.Errorf("HeadObject calls = %d, want 20 (prefixes skipped)", got)
+	}
+}
+
+func TestEnrichObjectsBoundsConcurrency(t *testing.T) {
+	fake, objs := enrichFixture(30)
+	client := &Client{S3: fake}
+
+	var inFlight, peak int32
+	fake.errFor = func(op, key string) error {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		return nil
+	}
+
+	if err := client.EnrichObjects(context.Background(), "b", objs, 3); err != nil {
+		t.Fatalf("EnrichObjects() error = %v", err)
+	}
+	if peak > 3 {
+		t.Errorf("peak concurrent HEADs = %d, want <= 3", peak)
+	}
+	if peak < 2 {
+		t.Errorf("peak concurrent HEADs = %d, expected requests to run in parallel", peak)
+	}
+}
+
+func TestEnrichObjectsToleratesFailures(t *testing.T) {
+	fake, objs := enrichFixture(10)
+	client := &Client{S3: fake}
+
+	failing := "data/file-03.json"
+	fake.errFor = func(op, key string) error {
+		if key == failing {
+			return errors.New("AccessDenied")
+		}
+		return nil
+	}
+
+	if err := client.EnrichObjects(context.Background(), "b", objs, 4); err != nil {
+		t.Fatalf("EnrichObjects() error = %v, want nil for a partial failure", err)
+	}
+
+	for _, obj := range objs {
+		if obj.IsPrefix {
+			continue
+		}
+		want := obj.Key != failing
+		if obj.Enriched != want {
+			t.Errorf("%q enriched = %v, want %v", obj.Key, obj.Enriched, want)
+		}
+	}
+}
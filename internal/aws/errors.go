@@ -0,0 +1,153 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/natevick/stui/internal/security"
+)
+
+// OpError records the operation and location of a failed S3 call. Its
+// message is a short, sanitized summary safe to show in the UI; errors.Is/As
+// still see the original error.
+type OpError struct {
+	Op     string
+	Bucket string
+	Key    string
+	Err    error
+}
+
+func (e *OpError) Error() string {
+	return security.SanitizeErrorGeneric(e.Err, e.Op)
+}
+
+func (e *OpError) Unwrap() error {
+	return e.Err
+}
+
+// Detail returns a sanitized message that keeps the location and the
+// underlying error text, for logs and the error history. The location is
+// sanitized too, so paranoid redaction hides the bucket name.
+func (e *OpError) Detail() string {
+	msg := e.Op
+	if e.Bucket != "" {
+		msg += " " + FormatS3URI(e.Bucket, e.Key)
+	}
+	return security.SanitizeError(fmt.Errorf("%s: %w", msg, e.Err))
+}
+
+// IsNotFound reports whether err means the requested object or bucket doesn't exist
+func IsNotFound(err error) bool {
+	var notFound *types.NotFound
+	if errors.As(err, &notFound) {
+		return true
+	}
+	var noSuchKey *types.NoSuchKey
+	if errors.As(err, &noSuchKey) {
+		return true
+	}
+	var respErr *smithyhttp.ResponseError
+	if errors.As(err, &respErr) {
+		return respErr.HTTPStatusCode() == 404
+	}
+	return false
+}
+
+// IsAccessDenied reports whether err is an S3 or KMS permission failure
+func IsAccessDenied(err error) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "AccessDenied", "AccessDeniedException", "AllAccessDisabled":
+			return true
+		}
+	}
+	var respErr *smithyhttp.ResponseError
+	if errors.As(err, &respErr) {
+		return respErr.HTTPStatusCode() == 403
+	}
+	return false
+}
+
+// IsPreconditionFailed reports whether err is S3 refusing a conditional
+// request because its If-Match or If-None-Match condition didn't hold
+func IsPreconditionFailed(err error) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && apiErr.ErrorCode() == "PreconditionFailed" {
+		return true
+	}
+	var respErr *smithyhttp.ResponseError
+	return errors.As(err, &respErr) && respErr.HTTPStatusCode() == 412
+}
+
+// isBucketAlreadyOwned reports whether CreateBucket failed because the
+// caller already owns the bucket
+func isBucketAlreadyOwned(err error) bool {
+	var owned *types.BucketAlreadyOwnedByYou
+	if errors.As(err, &owned) {
+		return true
+	}
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && apiErr.ErrorCode() == "BucketAlreadyOwnedByYou"
+}
+
+// isBucketNameTaken reports whether CreateBucket failed because another
+// account owns a bucket with the name
+func isBucketNameTaken(err error) bool {
+	var exists *types.BucketAlreadyExists
+	if errors.As(err, &exists) {
+		return true
+	}
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && apiErr.ErrorCode() == "BucketAlreadyExists"
+}
+
+// isIllegalLocationConstraint reports whether CreateBucket failed because
+// the location constraint doesn't match the region the request was sent to
+func isIllegalLocationConstraint(err error) bool {
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && apiErr.ErrorCode() == "IllegalLocationConstraintException"
+}
+
+// IsNotImplemented reports whether err means the endpoint doesn't support
+// the operation, as S3-compatible stores answer for APIs they lack
+func IsNotImplemented(err error) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "NotImplemented", "MethodNotAllowed":
+			return true
+		}
+	}
+	var respErr *smithyhttp.ResponseError
+	if errors.As(err, &respErr) {
+		code := respErr.HTTPStatusCode()
+		return code == 501 || code == 405
+	}
+	return false
+}
+
+// IsThrottled reports whether err means S3 is throttling requests or a request timed out
+func IsThrottled(err error) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "SlowDown", "Throttling", "ThrottlingException", "RequestTimeout", "RequestLimitExceeded":
+			return true
+		}
+	}
+	var respErr *smithyhttp.ResponseError
+	if errors.As(err, &respErr) && respErr.HTTPStatusCode() == 503 {
+		return true
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,68 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/smithy-go"
+)
+
+func TestIsThrottled(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"slow down", &smithy.GenericAPIError{Code: "SlowDown"}, true},
+		{"request timeout", fmt.Errorf("get: %w", &smithy.GenericAPIError{Code: "RequestTimeout"}), true},
+		{"deadline", context.DeadlineExceeded, true},
+		{"access denied", &smithy.GenericAPIError{Code: "AccessDenied"}, false},
+		{"cancelled", context.Canceled, false},
+		{"plain", errors.New("boom"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsThrottled(tt.err); got != tt.want {
+				t.Errorf("IsThrottled(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpError(t *testing.T) {
+	denied := &smithy.GenericAPIError{
+		Code:    "AccessDenied",
+		Message: "User arn:aws:iam::123456789012:user/alice is not authorized",
+	}
+	err := fmt.Errorf("loading: %w", &OpError{Op: "Listing objects", Bucket: "b", Key: "logs/", Err: denied})
+
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) || apiErr.ErrorCode() != "AccessDenied" {
+		t.Errorf("errors.As did not reach the AccessDenied error: %v", err)
+	}
+	if !IsAccessDenied(err) {
+		t.Error("IsAccessDenied() = false through OpError")
+	}
+
+	var opErr *OpError
+	if !errors.As(err, &opErr) {
+		t.Fatal("errors.As(*OpError) = false")
+	}
+	if got, want := opErr.Error(), "Listing objects: access denied - check your permissions"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+
+	detail := opErr.Detail()
+	if !strings.HasPrefix(detail, "Listing objects s3://b/logs/: ") {
+		t.Errorf("Detail() = %q, want location prefix", detail)
+	}
+	for _, secret := range []string{"123456789012", "arn:aws"} {
+		if strings.Contains(opErr.Error(), secret) || strings.Contains(detail, secret) {
+			t.Errorf("message leaks %q: %q / %q", secret, opErr.Error(), detail)
+		}
+	}
+}
@@ -0,0 +1,950 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"maps"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+// fakeObject is an object stored in fakeS3
+type fakeObject struct {
+	body         []byte
+	contentType  string
+	cacheControl string
+	metadata     map[string]string
+	storageClass types.StorageClass
+	replication  types.ReplicationStatus
+	modified     time.Time
+	sse          types.ServerSideEncryption
+	kmsKeyID     string
+	tags         map[string]string
+	etagOverride string // reported instead of the body's MD5 when set
+	partSize     int64  // part size it was uploaded with; zero fails HEAD with a PartNumber
+}
+
+func (o fakeObject) etag() string {
+	if o.etagOverride != "" {
+		return o.etagOverride
+	}
+	sum := md5.Sum(o.body)
+	return hex.EncodeToString(sum[:])
+}
+
+// fakeS3 is an in-memory S3API for tests. Methods not implemented here
+// panic via the nil embedded interface.
+type fakeS3 struct {
+	S3API
+
+	mu      sync.Mutex
+	objects map[string]map[string]fakeObject // bucket -> key -> object
+	calls   []string
+
+	copyInputs []*s3.CopyObjectInput
+	listInputs []*s3.ListObjectsV2Input
+
+	// errFor returns an error to inject for an operation on a key, or nil
+	errFor func(op, key string) error
+
+	// Bucket public-access-block settings and object ACLs
+	publicAccessBlocks map[string]*types.PublicAccessBlockConfiguration
+	acls               map[string]types.ObjectCannedACL // bucket/key -> ACL
+	policies           map[string]string
+	cors               map[string][]types.CORSRule
+	retention          map[string]*types.ObjectLockRetention // bucket/key -> retention
+
+	// Versioned buckets get a delete marker on delete; the object it hides
+	// is kept under bucket/key@markerID until the marker is deleted. Its
+	// version is listed as "v-"+markerID; deleting that version leaves the
+	// marker alone in bareMarkers.
+	versioned   map[string]bool
+	hidden      map[string]fakeObject
+	bareMarkers map[string]time.Time
+	markerID    int
+
+	// bucketPages are served in order by ListBuckets, linked by continuation tokens
+	bucketPages [][]string
+	// bucketRegions are the LocationConstraints GetBucketLocation reports;
+	// buckets not listed are in us-east-1, reported as an empty constraint
+	bucketRegions map[string]types.BucketLocationConstraint
+
+	// In-progress multipart uploads: upload ID -> part number -> bytes
+	uploads    map[string]map[int32][]byte
+	uploadMeta map[string]fakeObject
+	uploadKeys map[string][2]string // upload ID -> bucket, key
+	uploadID   int
+
+	// noListV2 makes ListObjectsV2 fail as not implemented, like an
+	// S3-compatible store that only has ListObjects
+	noListV2 bool
+
+	// ignoreRange makes GetObject answer ranged requests with the whole
+	// object and no Content-Range, like a non-compliant server
+	ignoreRange bool
+	// contentRange, when set, replaces the Content-Range of ranged responses
+	contentRange string
+	// ignoreIfMatch makes writes and deletes skip If-Match conditions, like
+	// a store without conditional request support
+	ignoreIfMatch bool
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{
+		objects:    make(map[string]map[string]fakeObject),
+		uploads:    make(map[string]map[int32][]byte),
+		uploadMeta: make(map[string]fakeObject),
+		uploadKeys: make(map[string][2]string),
+
+		publicAccessBlocks: make(map[string]*types.PublicAccessBlockConfiguration),
+		acls:               make(map[string]types.ObjectCannedACL),
+		policies:           make(map[string]string),
+		cors:               make(map[string][]types.CORSRule),
+		retention:          make(map[string]*types.ObjectLockRetention),
+
+		versioned:   make(map[string]bool),
+		hidden:      make(map[string]fakeObject),
+		bareMarkers: make(map[string]time.Time),
+	}
+}
+
+func (f *fakeS3) put(bucket, key string, obj fakeObject) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.objects[bucket] == nil {
+		f.objects[bucket] = make(map[string]fakeObject)
+	}
+	if obj.modified.IsZero() {
+		obj.modified = time.Now()
+	}
+	f.objects[bucket][key] = obj
+}
+
+func (f *fakeS3) get(bucket, key string) (fakeObject, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	obj, ok := f.objects[bucket][key]
+	return obj, ok
+}
+
+func (f *fakeS3) record(op, key string) error {
+	f.mu.Lock()
+	f.calls = append(f.calls, op+" "+key)
+	errFor := f.errFor
+	f.mu.Unlock()
+	if errFor != nil {
+		return errFor(op, key)
+	}
+	return nil
+}
+
+func (f *fakeS3) countCalls(op string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, c := range f.calls {
+		if strings.HasPrefix(c, op+" ") {
+			n++
+		}
+	}
+	return n
+}
+
+func (f *fakeS3) HeadObject(ctx context.Context, in *s3.HeadObjectInput, _ ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+	key := aws.ToString(in.Key)
+	if err := f.record("HeadObject", key); err != nil {
+		return nil, err
+	}
+	obj, ok := f.get(aws.ToString(in.Bucket), key)
+	if !ok {
+		return nil, &types.NotFound{}
+	}
+	size := int64(len(obj.body))
+	if in.PartNumber != nil {
+		if obj.partSize == 0 {
+			return nil, &smithy.GenericAPIError{Code: "NotImplemented"}
+		}
+		start := int64(aws.ToInt32(in.PartNumber)-1) * obj.partSize
+		size = max(0, min(obj.partSize, size-start))
+	}
+	return &s3.HeadObjectOutput{
+		ContentLength:        aws.Int64(size),
+		ContentType:          aws.String(obj.contentType),
+		CacheControl:         aws.String(obj.cacheControl),
+		ETag:                 aws.String(`"` + obj.etag() + `"`),
+		LastModified:         aws.Time(obj.modified),
+		Metadata:             obj.metadata,
+		StorageClass:         obj.storageClass,
+		ReplicationStatus:    obj.replication,
+		ServerSideEncryption: obj.sse,
+		SSEKMSKeyId:          optional(obj.kmsKeyID),
+		TagCount:             optionalInt32(int32(len(obj.tags))),
+	}, nil
+}
+
+func optionalInt32(n int32) *int32 {
+	if n == 0 {
+		return nil
+	}
+	return aws.Int32(n)
+}
+
+func (f *fakeS3) GetObjectTagging(ctx context.Context, in *s3.GetObjectTaggingInput, _ ...func(*s3.Options)) (*s3.GetObjectTaggingOutput, error) {
+	key := aws.ToString(in.Key)
+	if err := f.record("GetObjectTagging", key); err != nil {
+		return nil, err
+	}
+	obj, ok := f.get(aws.ToString(in.Bucket), key)
+	if !ok {
+		return nil, &types.NoSuchKey{}
+	}
+	out := &s3.GetObjectTaggingOutput{}
+	for k, v := range obj.tags {
+		out.TagSet = append(out.TagSet, types.Tag{Key: aws.String(k), Value: aws.String(v)})
+	}
+	return out, nil
+}
+
+func (f *fakeS3) GetObject(ctx context.Context, in *s3.GetObjectInput, _ ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	key := aws.ToString(in.Key)
+	if err := f.record("GetObject", key); err != nil {
+		return nil, err
+	}
+	if err := f.checkIfMatch(aws.ToString(in.Bucket), key, in.IfMatch); err != nil {
+		return nil, err
+	}
+	obj, ok := f.get(aws.ToString(in.Bucket), key)
+	if !ok {
+		return nil, &types.NoSuchKey{}
+	}
+	out := &s3.GetObjectOutput{
+		ContentType: aws.String(obj.contentType),
+		ETag:        aws.String(`"` + obj.etag() + `"`),
+		Metadata:    obj.metadata,
+	}
+	body := obj.body
+	var first, last int
+	n, _ := fmt.Sscanf(aws.ToString(in.Range), "bytes=%d-%d", &first, &last)
+	if n == 1 && strings.HasSuffix(aws.ToString(in.Range), "-") {
+		// An open-ended range runs to the end
+		n, last = 2, len(body)-1
+	}
+	if n == 2 && !f.ignoreRange {
+		last = min(last, len(body)-1)
+		body = body[first : last+1]
+		out.ContentRange = aws.String(fmt.Sprintf("bytes %d-%d/%d", first, last, len(obj.body)))
+		if f.contentRange != "" {
+			out.ContentRange = aws.String(f.contentRange)
+		}
+	}
+	out.Body = io.NopCloser(bytes.NewReader(body))
+	out.ContentLength = aws.Int64(int64(len(body)))
+	return out, nil
+}
+
+func (f *fakeS3) CopyObject(ctx context.Context, in *s3.CopyObjectInput, _ ...func(*s3.Options)) (*s3.CopyObjectOutput, error) {
+	key := aws.ToString(in.Key)
+	if err := f.record("CopyObject", key); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	f.copyInputs = append(f.copyInputs, in)
+	f.mu.Unlock()
+
+	source, err := url.PathUnescape(aws.ToString(in.CopySource))
+	if err != nil {
+		return nil, err
+	}
+	srcBucket, srcKey, _ := strings.Cut(source, "/")
+	src, ok := f.get(srcBucket, srcKey)
+	if !ok {
+		return nil, &types.NoSuchKey{}
+	}
+
+	dst := fakeObject{
+		body:         src.body,
+		contentType:  src.contentType,
+		cacheControl: src.cacheControl,
+		metadata:     src.metadata,
+		storageClass: in.StorageClass,
+	}
+	if in.MetadataDirective == types.MetadataDirectiveReplace {
+		dst.contentType = aws.ToString(in.ContentType)
+		dst.cacheControl = aws.ToString(in.CacheControl)
+		dst.metadata = in.Metadata
+	}
+	f.put(aws.ToString(in.Bucket), key, dst)
+	return &s3.CopyObjectOutput{}, nil
+}
+
+func (f *fakeS3) DeleteObject(ctx context.Context, in *s3.DeleteObjectInput, _ ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	key := aws.ToString(in.Key)
+	if err := f.record("DeleteObject", key); err != nil {
+		return nil, err
+	}
+	bucket := aws.ToString(in.Bucket)
+	if err := f.checkIfMatch(bucket, key, in.IfMatch); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if in.VersionId != nil {
+		// Deleting a delete marker brings back the version it hid
+		id := bucket + "/" + key + "@" + aws.ToString(in.VersionId)
+		if obj, ok := f.hidden[id]; ok {
+			delete(f.hidden, id)
+			f.objects[bucket][key] = obj
+		}
+		return &s3.DeleteObjectOutput{}, nil
+	}
+	delete(f.objects[bucket], key)
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+func (f *fakeS3) DeleteObjects(ctx context.Context, in *s3.DeleteObjectsInput, _ ...func(*s3.Options)) (*s3.DeleteObjectsOutput, error) {
+	if err := f.record("DeleteObjects", aws.ToString(in.Bucket)); err != nil {
+		return nil, err
+	}
+	out := &s3.DeleteObjectsOutput{}
+	for _, id := range in.Delete.Objects {
+		key := aws.ToString(id.Key)
+		if f.errFor != nil {
+			if err := f.errFor("DeleteObjects.Key", key); err != nil {
+				out.Errors = append(out.Errors, types.Error{Key: id.Key, Message: aws.String(err.Error())})
+				continue
+			}
+		}
+		bucket := aws.ToString(in.Bucket)
+		deleted := types.DeletedObject{Key: id.Key, VersionId: id.VersionId}
+		f.mu.Lock()
+		if id.VersionId != nil {
+			f.deleteVersion(bucket, key, aws.ToString(id.VersionId))
+		} else if obj, ok := f.objects[bucket][key]; ok && f.versioned[bucket] {
+			f.markerID++
+			marker := fmt.Sprintf("marker-%d", f.markerID)
+			f.hidden[bucket+"/"+key+"@"+marker] = obj
+			deleted.DeleteMarker = aws.Bool(true)
+			deleted.DeleteMarkerVersionId = aws.String(marker)
+		}
+		if id.VersionId == nil {
+			delete(f.objects[bucket], key)
+		}
+		f.mu.Unlock()
+		if !aws.ToBool(in.Delete.Quiet) {
+			out.Deleted = append(out.Deleted, deleted)
+		}
+	}
+	return out, nil
+}
+
+// deleteVersion permanently deletes one version or delete marker. The
+// current object is version "null". Callers hold f.mu.
+func (f *fakeS3) deleteVersion(bucket, key, versionID string) {
+	if versionID == "null" {
+		delete(f.objects[bucket], key)
+		return
+	}
+	if marker, ok := strings.CutPrefix(versionID, "v-"); ok {
+		id := bucket + "/" + key + "@" + marker
+		if obj, ok := f.hidden[id]; ok {
+			delete(f.hidden, id)
+			f.bareMarkers[id] = obj.modified
+		}
+		return
+	}
+	id := bucket + "/" + key + "@" + versionID
+	if obj, ok := f.hidden[id]; ok {
+		// Deleting a delete marker brings back the version it hid
+		delete(f.hidden, id)
+		f.objects[bucket][key] = obj
+	}
+	delete(f.bareMarkers, id)
+}
+
+// ListObjectVersions lists every version and delete marker under the
+// prefix in one page. A key's newest marker is latest unless the key has a
+// current object.
+func (f *fakeS3) ListObjectVersions(ctx context.Context, in *s3.ListObjectVersionsInput, _ ...func(*s3.Options)) (*s3.ListObjectVersionsOutput, error) {
+	prefix := aws.ToString(in.Prefix)
+	if err := f.record("ListObjectVersions", prefix); err != nil {
+		return nil, err
+	}
+	bucket := aws.ToString(in.Bucket)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	out := &s3.ListObjectVersionsOutput{IsTruncated: aws.Bool(false)}
+	for key, obj := range f.objects[bucket] {
+		if strings.HasPrefix(key, prefix) {
+			out.Versions = append(out.Versions, types.ObjectVersion{
+				Key:          aws.String(key),
+				VersionId:    aws.String("null"),
+				IsLatest:     aws.Bool(true),
+				LastModified: aws.Time(obj.modified),
+			})
+		}
+	}
+
+	markers := make(map[string]time.Time)
+	for id, obj := range f.hidden {
+		markers[id] = obj.modified
+	}
+	maps.Copy(markers, f.bareMarkers)
+	newest := make(map[string]int)
+	for id := range markers {
+		rest, ok := strings.CutPrefix(id, bucket+"/")
+		key, marker, _ := strings.Cut(rest, "@")
+		if !ok || !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		n, _ := strconv.Atoi(strings.TrimPrefix(marker, "marker-"))
+		newest[key] = max(newest[key], n)
+	}
+	for id, modified := range markers {
+		rest, ok := strings.CutPrefix(id, bucket+"/")
+		key, marker, _ := strings.Cut(rest, "@")
+		if !ok || !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		_, current := f.objects[bucket][key]
+		n, _ := strconv.Atoi(strings.TrimPrefix(marker, "marker-"))
+		out.DeleteMarkers = append(out.DeleteMarkers, types.DeleteMarkerEntry{
+			Key:          aws.String(key),
+			VersionId:    aws.String(marker),
+			IsLatest:     aws.Bool(!current && n == newest[key]),
+			LastModified: aws.Time(modified),
+		})
+		if _, ok := f.hidden[id]; ok {
+			out.Versions = append(out.Versions, types.ObjectVersion{
+				Key:          aws.String(key),
+				VersionId:    aws.String("v-" + marker),
+				IsLatest:     aws.Bool(false),
+				LastModified: aws.Time(modified),
+			})
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeS3) HeadBucket(ctx context.Context, in *s3.HeadBucketInput, _ ...func(*s3.Options)) (*s3.HeadBucketOutput, error) {
+	bucket := aws.ToString(in.Bucket)
+	if err := f.record("HeadBucket", bucket); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.objects[bucket] == nil {
+		return nil, &types.NotFound{}
+	}
+	return &s3.HeadBucketOutput{}, nil
+}
+
+func (f *fakeS3) CreateBucket(ctx context.Context, in *s3.CreateBucketInput, _ ...func(*s3.Options)) (*s3.CreateBucketOutput, error) {
+	bucket := aws.ToString(in.Bucket)
+	if err := f.record("CreateBucket", bucket); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.objects[bucket] == nil {
+		f.objects[bucket] = make(map[string]fakeObject)
+	}
+	if f.bucketRegions == nil {
+		f.bucketRegions = make(map[string]types.BucketLocationConstraint)
+	}
+	if cfg := in.CreateBucketConfiguration; cfg != nil {
+		f.bucketRegions[bucket] = cfg.LocationConstraint
+	}
+	return &s3.CreateBucketOutput{}, nil
+}
+
+func (f *fakeS3) DeleteBucket(ctx context.Context, in *s3.DeleteBucketInput, _ ...func(*s3.Options)) (*s3.DeleteBucketOutput, error) {
+	bucket := aws.ToString(in.Bucket)
+	if err := f.record("DeleteBucket", bucket); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	delete(f.objects, bucket)
+	f.mu.Unlock()
+	return &s3.DeleteBucketOutput{}, nil
+}
+
+func (f *fakeS3) PutObject(ctx context.Context, in *s3.PutObjectInput, _ ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	key := aws.ToString(in.Key)
+	if err := f.record("PutObject", key); err != nil {
+		return nil, err
+	}
+	if err := f.checkIfMatch(aws.ToString(in.Bucket), key, in.IfMatch); err != nil {
+		return nil, err
+	}
+	var body []byte
+	if in.Body != nil {
+		var err error
+		if body, err = io.ReadAll(in.Body); err != nil {
+			return nil, err
+		}
+	}
+	obj := fakeObject{
+		body:         body,
+		contentType:  aws.ToString(in.ContentType),
+		cacheControl: aws.ToString(in.CacheControl),
+		metadata:     in.Metadata,
+		storageClass: in.StorageClass,
+		sse:          in.ServerSideEncryption,
+		kmsKeyID:     aws.ToString(in.SSEKMSKeyId),
+	}
+	if in.Tagging != nil {
+		values, err := url.ParseQuery(aws.ToString(in.Tagging))
+		if err != nil {
+			return nil, err
+		}
+		obj.tags = make(map[string]string, len(values))
+		for k := range values {
+			obj.tags[k] = values.Get(k)
+		}
+	}
+	f.put(aws.ToString(in.Bucket), key, obj)
+	return &s3.PutObjectOutput{ETag: aws.String(`"` + obj.etag() + `"`)}, nil
+}
+
+// checkIfMatch applies an If-Match condition like S3: a missing object is
+// NoSuchKey and a different ETag is PreconditionFailed
+func (f *fakeS3) checkIfMatch(bucket, key string, ifMatch *string) error {
+	if ifMatch == nil || f.ignoreIfMatch {
+		return nil
+	}
+	obj, ok := f.get(bucket, key)
+	if !ok {
+		return &types.NoSuchKey{}
+	}
+	if want := aws.ToString(ifMatch); want != "*" && want != `"`+obj.etag()+`"` {
+		return &smithy.GenericAPIError{Code: "PreconditionFailed", Message: "At least one of the pre-conditions you specified did not hold"}
+	}
+	return nil
+}
+
+func (f *fakeS3) CreateMultipartUpload(ctx context.Context, in *s3.CreateMultipartUploadInput, _ ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error) {
+	if err := f.record("CreateMultipartUpload", aws.ToString(in.Key)); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.uploadID++
+	id := fmt.Sprintf("upload-%d", f.uploadID)
+	f.uploads[id] = make(map[int32][]byte)
+	f.uploadMeta[id] = fakeObject{contentType: aws.ToString(in.ContentType), metadata: in.Metadata, storageClass: in.StorageClass, modified: time.Now()}
+	f.uploadKeys[id] = [2]string{aws.ToString(in.Bucket), aws.ToString(in.Key)}
+	return &s3.CreateMultipartUploadOutput{UploadId: aws.String(id)}, nil
+}
+
+func (f *fakeS3) UploadPart(ctx context.Context, in *s3.UploadPartInput, _ ...func(*s3.Options)) (*s3.UploadPartOutput, error) {
+	if err := f.record("UploadPart", aws.ToString(in.Key)); err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(in.Body)
+	if err != nil {
+		return nil, err
+	}
+	sum := md5.Sum(body)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	parts, ok := f.uploads[aws.ToString(in.UploadId)]
+	if !ok {
+		return nil, &types.NoSuchUpload{}
+	}
+	parts[aws.ToInt32(in.PartNumber)] = body
+	return &s3.UploadPartOutput{ETag: aws.String(`"` + hex.EncodeToString(sum[:]) + `"`)}, nil
+}
+
+func (f *fakeS3) CompleteMultipartUpload(ctx context.Context, in *s3.CompleteMultipartUploadInput, _ ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error) {
+	key := aws.ToString(in.Key)
+	if err := f.record("CompleteMultipartUpload", key); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	id := aws.ToString(in.UploadId)
+	parts, ok := f.uploads[id]
+	obj := f.uploadMeta[id]
+	delete(f.uploads, id)
+	delete(f.uploadMeta, id)
+	delete(f.uploadKeys, id)
+	f.mu.Unlock()
+	if !ok {
+		return nil, &types.NoSuchUpload{}
+	}
+
+	for _, p := range in.MultipartUpload.Parts {
+		obj.body = append(obj.body, parts[aws.ToInt32(p.PartNumber)]...)
+	}
+	f.put(aws.ToString(in.Bucket), key, obj)
+	return &s3.CompleteMultipartUploadOutput{}, nil
+}
+
+func (f *fakeS3) AbortMultipartUpload(ctx context.Context, in *s3.AbortMultipartUploadInput, _ ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error) {
+	if err := f.record("AbortMultipartUpload", aws.ToString(in.Key)); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	delete(f.uploads, aws.ToString(in.UploadId))
+	delete(f.uploadKeys, aws.ToString(in.UploadId))
+	f.mu.Unlock()
+	return &s3.AbortMultipartUploadOutput{}, nil
+}
+
+func (f *fakeS3) ListParts(ctx context.Context, in *s3.ListPartsInput, _ ...func(*s3.Options)) (*s3.ListPartsOutput, error) {
+	if err := f.record("ListParts", aws.ToString(in.Key)); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	parts, ok := f.uploads[aws.ToString(in.UploadId)]
+	if !ok {
+		return nil, &types.NoSuchUpload{}
+	}
+
+	var numbers []int32
+	for n := range parts {
+		numbers = append(numbers, n)
+	}
+	slices.Sort(numbers)
+
+	maxParts := int(aws.ToInt32(in.MaxParts))
+	if maxParts <= 0 {
+		maxParts = 1000
+	}
+	marker, _ := strconv.Atoi(aws.ToString(in.PartNumberMarker))
+	out := &s3.ListPartsOutput{}
+	for _, n := range numbers {
+		if int(n) <= marker {
+			continue
+		}
+		if len(out.Parts) == maxParts {
+			out.IsTruncated = aws.Bool(true)
+			out.NextPartNumberMarker = aws.String(strconv.Itoa(int(aws.ToInt32(out.Parts[len(out.Parts)-1].PartNumber))))
+			break
+		}
+		sum := md5.Sum(parts[n])
+		out.Parts = append(out.Parts, types.Part{
+			PartNumber: aws.Int32(n),
+			ETag:       aws.String(`"` + hex.EncodeToString(sum[:]) + `"`),
+			Size:       aws.Int64(int64(len(parts[n]))),
+		})
+	}
+	return out, nil
+}
+
+func (f *fakeS3) ListMultipartUploads(ctx context.Context, in *s3.ListMultipartUploadsInput, _ ...func(*s3.Options)) (*s3.ListMultipartUploadsOutput, error) {
+	bucket := aws.ToString(in.Bucket)
+	if err := f.record("ListMultipartUploads", bucket); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	var uploads []types.MultipartUpload
+	for id, target := range f.uploadKeys {
+		if target[0] == bucket && strings.HasPrefix(target[1], aws.ToString(in.Prefix)) {
+			uploads = append(uploads, types.MultipartUpload{
+				UploadId:  aws.String(id),
+				Key:       aws.String(target[1]),
+				Initiated: aws.Time(f.uploadMeta[id].modified),
+			})
+		}
+	}
+	f.mu.Unlock()
+	// S3 orders uploads by key, then by upload ID
+	slices.SortFunc(uploads, func(a, b types.MultipartUpload) int {
+		if c := strings.Compare(aws.ToString(a.Key), aws.ToString(b.Key)); c != 0 {
+			return c
+		}
+		return strings.Compare(aws.ToString(a.UploadId), aws.ToString(b.UploadId))
+	})
+
+	maxUploads := int(aws.ToInt32(in.MaxUploads))
+	if maxUploads <= 0 {
+		maxUploads = 1000
+	}
+	keyMarker, idMarker := aws.ToString(in.KeyMarker), aws.ToString(in.UploadIdMarker)
+	out := &s3.ListMultipartUploadsOutput{IsTruncated: aws.Bool(false)}
+	for _, up := range uploads {
+		key, id := aws.ToString(up.Key), aws.ToString(up.UploadId)
+		if keyMarker != "" && (key < keyMarker || key == keyMarker && id <= idMarker) {
+			continue
+		}
+		if len(out.Uploads) == maxUploads {
+			last := out.Uploads[len(out.Uploads)-1]
+			out.IsTruncated = aws.Bool(true)
+			out.NextKeyMarker, out.NextUploadIdMarker = last.Key, last.UploadId
+			break
+		}
+		out.Uploads = append(out.Uploads, up)
+	}
+	return out, nil
+}
+
+func (f *fakeS3) GetPublicAccessBlock(ctx context.Context, in *s3.GetPublicAccessBlockInput, _ ...func(*s3.Options)) (*s3.GetPublicAccessBlockOutput, error) {
+	bucket := aws.ToString(in.Bucket)
+	if err := f.record("GetPublicAccessBlock", bucket); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	cfg, ok := f.publicAccessBlocks[bucket]
+	f.mu.Unlock()
+	if !ok {
+		return nil, &smithy.GenericAPIError{Code: "NoSuchPublicAccessBlockConfiguration"}
+	}
+	return &s3.GetPublicAccessBlockOutput{PublicAccessBlockConfiguration: cfg}, nil
+}
+
+func (f *fakeS3) GetBucketCors(ctx context.Context, in *s3.GetBucketCorsInput, _ ...func(*s3.Options)) (*s3.GetBucketCorsOutput, error) {
+	bucket := aws.ToString(in.Bucket)
+	if err := f.record("GetBucketCors", bucket); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	rules, ok := f.cors[bucket]
+	f.mu.Unlock()
+	if !ok {
+		return nil, &smithy.GenericAPIError{Code: "NoSuchCORSConfiguration"}
+	}
+	return &s3.GetBucketCorsOutput{CORSRules: rules}, nil
+}
+
+func (f *fakeS3) ListBuckets(ctx context.Context, in *s3.ListBucketsInput, _ ...func(*s3.Options)) (*s3.ListBucketsOutput, error) {
+	token := aws.ToString(in.ContinuationToken)
+	if err := f.record("ListBuckets", token); err != nil {
+		return nil, err
+	}
+
+	page := 0
+	if token != "" {
+		if _, err := fmt.Sscanf(token, "page-%d", &page); err != nil || page >= len(f.bucketPages) {
+			return nil, &smithy.GenericAPIError{Code: "InvalidArgument", Message: "bad continuation token"}
+		}
+	}
+
+	out := &s3.ListBucketsOutput{}
+	if page < len(f.bucketPages) {
+		for _, name := range f.bucketPages[page] {
+			out.Buckets = append(out.Buckets, types.Bucket{Name: aws.String(name), BucketRegion: aws.String("us-east-1")})
+		}
+	}
+	if page+1 < len(f.bucketPages) {
+		out.ContinuationToken = aws.String(fmt.Sprintf("page-%d", page+1))
+	}
+	return out, nil
+}
+
+func (f *fakeS3) GetBucketLocation(ctx context.Context, in *s3.GetBucketLocationInput, _ ...func(*s3.Options)) (*s3.GetBucketLocationOutput, error) {
+	bucket := aws.ToString(in.Bucket)
+	if err := f.record("GetBucketLocation", bucket); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return &s3.GetBucketLocationOutput{LocationConstraint: f.bucketRegions[bucket]}, nil
+}
+
+func (f *fakeS3) GetBucketPolicy(ctx context.Context, in *s3.GetBucketPolicyInput, _ ...func(*s3.Options)) (*s3.GetBucketPolicyOutput, error) {
+	bucket := aws.ToString(in.Bucket)
+	if err := f.record("GetBucketPolicy", bucket); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	policy, ok := f.policies[bucket]
+	f.mu.Unlock()
+	if !ok {
+		return nil, &smithy.GenericAPIError{Code: "NoSuchBucketPolicy"}
+	}
+	return &s3.GetBucketPolicyOutput{Policy: aws.String(policy)}, nil
+}
+
+func (f *fakeS3) PutObjectAcl(ctx context.Context, in *s3.PutObjectAclInput, _ ...func(*s3.Options)) (*s3.PutObjectAclOutput, error) {
+	key := aws.ToString(in.Key)
+	if err := f.record("PutObjectAcl", key); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	f.acls[aws.ToString(in.Bucket)+"/"+key] = in.ACL
+	f.mu.Unlock()
+	return &s3.PutObjectAclOutput{}, nil
+}
+
+func (f *fakeS3) GetObjectRetention(ctx context.Context, in *s3.GetObjectRetentionInput, _ ...func(*s3.Options)) (*s3.GetObjectRetentionOutput, error) {
+	key := aws.ToString(in.Key)
+	if err := f.record("GetObjectRetention", key); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	retention, ok := f.retention[aws.ToString(in.Bucket)+"/"+key]
+	f.mu.Unlock()
+	if !ok {
+		return nil, &smithy.GenericAPIError{Code: "NoSuchObjectLockConfiguration"}
+	}
+	return &s3.GetObjectRetentionOutput{Retention: retention}, nil
+}
+
+func (f *fakeS3) PutObjectRetention(ctx context.Context, in *s3.PutObjectRetentionInput, _ ...func(*s3.Options)) (*s3.PutObjectRetentionOutput, error) {
+	key := aws.ToString(in.Key)
+	if err := f.record("PutObjectRetention", key); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	f.retention[aws.ToString(in.Bucket)+"/"+key] = in.Retention
+	f.mu.Unlock()
+	return &s3.PutObjectRetentionOutput{}, nil
+}
+
+func (f *fakeS3) ListObjectsV2(ctx context.Context, in *s3.ListObjectsV2Input, _ ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	prefix := aws.ToString(in.Prefix)
+	if err := f.record("ListObjectsV2", prefix); err != nil {
+		return nil, err
+	}
+	if f.noListV2 {
+		return nil, &smithy.GenericAPIError{Code: "NotImplemented", Message: "ListObjectsV2 is not implemented"}
+	}
+
+	f.mu.Lock()
+	inCopy := *in
+	f.listInputs = append(f.listInputs, &inCopy)
+	f.mu.Unlock()
+	return f.listV2(in), nil
+}
+
+// ListObjects serves V1 listings from the V2 implementation. Like S3, it
+// only sends NextMarker when a delimiter is set, and a marker naming a
+// common prefix skips everything under it.
+func (f *fakeS3) ListObjects(ctx context.Context, in *s3.ListObjectsInput, _ ...func(*s3.Options)) (*s3.ListObjectsOutput, error) {
+	if err := f.record("ListObjects", aws.ToString(in.Marker)); err != nil {
+		return nil, err
+	}
+	start := aws.ToString(in.Marker)
+	delimiter := aws.ToString(in.Delimiter)
+	if delimiter != "" && strings.HasSuffix(start, delimiter) {
+		start += "\uffff"
+	}
+	v2 := f.listV2(&s3.ListObjectsV2Input{
+		Bucket:            in.Bucket,
+		Prefix:            in.Prefix,
+		Delimiter:         in.Delimiter,
+		MaxKeys:           in.MaxKeys,
+		ContinuationToken: aws.String(start),
+		EncodingType:      in.EncodingType,
+	})
+	out := &s3.ListObjectsOutput{
+		Contents:       v2.Contents,
+		CommonPrefixes: v2.CommonPrefixes,
+		IsTruncated:    v2.IsTruncated,
+		EncodingType:   v2.EncodingType,
+		Marker:         in.Marker,
+	}
+	if delimiter != "" && aws.ToBool(v2.IsTruncated) {
+		next := strings.TrimSuffix(lastListed(v2), "\uffff")
+		if in.EncodingType == types.EncodingTypeUrl {
+			next = url.QueryEscape(next)
+		}
+		out.NextMarker = aws.String(next)
+	}
+	return out, nil
+}
+
+// listV2 lists the fake's objects like ListObjectsV2
+func (f *fakeS3) listV2(in *s3.ListObjectsV2Input) *s3.ListObjectsV2Output {
+	prefix := aws.ToString(in.Prefix)
+	f.mu.Lock()
+	var keys []string
+	for k := range f.objects[aws.ToString(in.Bucket)] {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	f.mu.Unlock()
+	sort.Strings(keys)
+
+	maxKeys := int(aws.ToInt32(in.MaxKeys))
+	if maxKeys <= 0 {
+		maxKeys = 1000
+	}
+
+	start := aws.ToString(in.ContinuationToken)
+	if start == "" {
+		start = aws.ToString(in.StartAfter)
+	}
+	delimiter := aws.ToString(in.Delimiter)
+	out := &s3.ListObjectsV2Output{EncodingType: in.EncodingType}
+	// S3 encodes keys like a query string when asked
+	encodeKey := func(k string) string {
+		if in.EncodingType == types.EncodingTypeUrl {
+			return url.QueryEscape(k)
+		}
+		return k
+	}
+	seenPrefixes := make(map[string]bool)
+	count := 0
+	for _, k := range keys {
+		if start != "" && k <= start {
+			continue
+		}
+		if count == maxKeys {
+			out.IsTruncated = aws.Bool(true)
+			out.NextContinuationToken = aws.String(lastListed(out))
+			break
+		}
+		if delimiter != "" {
+			if i := strings.Index(k[len(prefix):], delimiter); i >= 0 {
+				cp := k[:len(prefix)+i+len(delimiter)]
+				if !seenPrefixes[cp] {
+					seenPrefixes[cp] = true
+					out.CommonPrefixes = append(out.CommonPrefixes, types.CommonPrefix{Prefix: aws.String(encodeKey(cp))})
+					count++
+				}
+				continue
+			}
+		}
+		obj, _ := f.get(aws.ToString(in.Bucket), k)
+		out.Contents = append(out.Contents, types.Object{
+			Key:          aws.String(encodeKey(k)),
+			Size:         aws.Int64(int64(len(obj.body))),
+			ETag:         aws.String(`"` + obj.etag() + `"`),
+			LastModified: aws.Time(obj.modified),
+			StorageClass: types.ObjectStorageClass(obj.storageClass),
+		})
+		count++
+	}
+	out.KeyCount = aws.Int32(int32(count))
+	return out
+}
+
+// lastListed returns the last key or prefix in a page, used as the continuation token
+func lastListed(out *s3.ListObjectsV2Output) string {
+	decode := func(k string) string {
+		if out.EncodingType == types.EncodingTypeUrl {
+			k, _ = url.QueryUnescape(k)
+		}
+		return k
+	}
+	last := ""
+	if n := len(out.Contents); n > 0 {
+		last = decode(aws.ToString(out.Contents[n-1].Key))
+	}
+	if n := len(out.CommonPrefixes); n > 0 {
+		// Skip everything under the last common prefix
+		if p := decode(aws.ToString(out.CommonPrefixes[n-1].Prefix)) + "￿"; p > last {
+			last = p
+		}
+	}
+	return last
+}
@@ -0,0 +1,167 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/natevick/stui/internal/security"
+)
+
+// ProfileS3 is an S3 client signed with a named profile's credentials
+type ProfileS3 struct {
+	Profile string
+	S3      S3API
+}
+
+// fallbackS3 retries reads that fail with AccessDenied through each fallback
+// profile in order. Mutating requests come from the embedded primary only,
+// so a write or delete is never sent with credentials the user didn't pick.
+// Every read tries the primary first; a fallback is not remembered.
+type fallbackS3 struct {
+	S3API
+	fallbacks []ProfileS3
+
+	mu   sync.Mutex
+	used string // profile that served the last read the primary was denied
+}
+
+// newFallbackS3 wraps primary so reads fall back to fallbacks
+func newFallbackS3(primary S3API, fallbacks []ProfileS3) *fallbackS3 {
+	return &fallbackS3{S3API: primary, fallbacks: fallbacks}
+}
+
+// takeUsed returns the profile that last served a denied read and clears it
+func (f *fallbackS3) takeUsed() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	used := f.used
+	f.used = ""
+	return used
+}
+
+// fallbackRead calls read with the primary, then with each fallback while
+// the error is AccessDenied. The last AccessDenied is returned if every
+// profile is denied; any other error stops the search.
+func fallbackRead[T any](f *fallbackS3, read func(S3API) (T, error)) (T, error) {
+	out, err := read(f.S3API)
+	if err == nil || !IsAccessDenied(err) {
+		return out, err
+	}
+	for _, fb := range f.fallbacks {
+		fbOut, fbErr := read(fb.S3)
+		if fbErr == nil {
+			f.mu.Lock()
+			f.used = fb.Profile
+			f.mu.Unlock()
+			return fbOut, nil
+		}
+		if !IsAccessDenied(fbErr) {
+			return fbOut, fbErr
+		}
+		out, err = fbOut, fbErr
+	}
+	return out, err
+}
+
+func (f *fallbackS3) ListBuckets(ctx context.Context, in *s3.ListBucketsInput, optFns ...func(*s3.Options)) (*s3.ListBucketsOutput, error) {
+	return fallbackRead(f, func(c S3API) (*s3.ListBucketsOutput, error) {
+		return c.ListBuckets(ctx, in, optFns...)
+	})
+}
+
+func (f *fallbackS3) GetBucketLocation(ctx context.Context, in *s3.GetBucketLocationInput, optFns ...func(*s3.Options)) (*s3.GetBucketLocationOutput, error) {
+	return fallbackRead(f, func(c S3API) (*s3.GetBucketLocationOutput, error) {
+		return c.GetBucketLocation(ctx, in, optFns...)
+	})
+}
+
+func (f *fallbackS3) HeadBucket(ctx context.Context, in *s3.HeadBucketInput, optFns ...func(*s3.Options)) (*s3.HeadBucketOutput, error) {
+	return fallbackRead(f, func(c S3API) (*s3.HeadBucketOutput, error) {
+		return c.HeadBucket(ctx, in, optFns...)
+	})
+}
+
+func (f *fallbackS3) ListObjects(ctx context.Context, in *s3.ListObjectsInput, optFns ...func(*s3.Options)) (*s3.ListObjectsOutput, error) {
+	return fallbackRead(f, func(c S3API) (*s3.ListObjectsOutput, error) {
+		return c.ListObjects(ctx, in, optFns...)
+	})
+}
+
+func (f *fallbackS3) ListObjectsV2(ctx context.Context, in *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	return fallbackRead(f, func(c S3API) (*s3.ListObjectsV2Output, error) {
+		return c.ListObjectsV2(ctx, in, optFns...)
+	})
+}
+
+func (f *fallbackS3) HeadObject(ctx context.Context, in *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+	return fallbackRead(f, func(c S3API) (*s3.HeadObjectOutput, error) {
+		return c.HeadObject(ctx, in, optFns...)
+	})
+}
+
+func (f *fallbackS3) GetObject(ctx context.Context, in *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	return fallbackRead(f, func(c S3API) (*s3.GetObjectOutput, error) {
+		return c.GetObject(ctx, in, optFns...)
+	})
+}
+
+func (f *fallbackS3) ListParts(ctx context.Context, in *s3.ListPartsInput, optFns ...func(*s3.Options)) (*s3.ListPartsOutput, error) {
+	return fallbackRead(f, func(c S3API) (*s3.ListPartsOutput, error) {
+		return c.ListParts(ctx, in, optFns...)
+	})
+}
+
+func (f *fallbackS3) ListMultipartUploads(ctx context.Context, in *s3.ListMultipartUploadsInput, optFns ...func(*s3.Options)) (*s3.ListMultipartUploadsOutput, error) {
+	return fallbackRead(f, func(c S3API) (*s3.ListMultipartUploadsOutput, error) {
+		return c.ListMultipartUploads(ctx, in, optFns...)
+	})
+}
+
+func (f *fallbackS3) GetPublicAccessBlock(ctx context.Context, in *s3.GetPublicAccessBlockInput, optFns ...func(*s3.Options)) (*s3.GetPublicAccessBlockOutput, error) {
+	return fallbackRead(f, func(c S3API) (*s3.GetPublicAccessBlockOutput, error) {
+		return c.GetPublicAccessBlock(ctx, in, optFns...)
+	})
+}
+
+func (f *fallbackS3) GetBucketCors(ctx context.Context, in *s3.GetBucketCorsInput, optFns ...func(*s3.Options)) (*s3.GetBucketCorsOutput, error) {
+	return fallbackRead(f, func(c S3API) (*s3.GetBucketCorsOutput, error) {
+		return c.GetBucketCors(ctx, in, optFns...)
+	})
+}
+
+func (f *fallbackS3) GetBucketPolicy(ctx context.Context, in *s3.GetBucketPolicyInput, optFns ...func(*s3.Options)) (*s3.GetBucketPolicyOutput, error) {
+	return fallbackRead(f, func(c S3API) (*s3.GetBucketPolicyOutput, error) {
+		return c.GetBucketPolicy(ctx, in, optFns...)
+	})
+}
+
+func (f *fallbackS3) GetObjectRetention(ctx context.Context, in *s3.GetObjectRetentionInput, optFns ...func(*s3.Options)) (*s3.GetObjectRetentionOutput, error) {
+	return fallbackRead(f, func(c S3API) (*s3.GetObjectRetentionOutput, error) {
+		return c.GetObjectRetention(ctx, in, optFns...)
+	})
+}
+
+// validFallbackProfiles checks each fallback profile name
+func validFallbackProfiles(profiles []string) error {
+	for _, p := range profiles {
+		if p == "" {
+			return fmt.Errorf("fallback profile names cannot be empty")
+		}
+		if err := security.ValidProfileName(p); err != nil {
+			return fmt.Errorf("fallback profile %q: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// TakeFallbackProfile returns the fallback profile that served the most
+// recent read the client's own profile was denied, and clears it. It returns
+// "" when no read has needed a fallback since the last call.
+func (c *Client) TakeFallbackProfile() string {
+	if f, ok := c.S3.(*fallbackS3); ok {
+		return f.takeUsed()
+	}
+	return ""
+}
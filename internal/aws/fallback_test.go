@@ -0,0 +1,115 @@
+package aws
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/smithy-go"
+)
+
+// deniedFake is a fake whose every request is denied
+func deniedFake() *fakeS3 {
+	fake := newFakeS3()
+	fake.errFor = func(op, key string) error {
+		return &smithy.GenericAPIError{Code: "AccessDenied"}
+	}
+	return fake
+}
+
+func TestFallbackReadSucceedsOnSecondProfile(t *testing.T) {
+	primary := deniedFake()
+	first := deniedFake()
+	second := newFakeS3()
+	second.put("b", "a.txt", fakeObject{body: []byte("hello")})
+
+	client := &Client{S3: newFallbackS3(primary, []ProfileS3{
+		{Profile: "staging", S3: first},
+		{Profile: "prod-read", S3: second},
+	})}
+
+	obj, err := client.GetObjectMetadata(context.Background(), "b", "a.txt")
+	if err != nil {
+		t.Fatalf("GetObjectMetadata() error = %v", err)
+	}
+	if obj.Size != 5 {
+		t.Errorf("Size = %d, want 5", obj.Size)
+	}
+	if got := client.TakeFallbackProfile(); got != "prod-read" {
+		t.Errorf("TakeFallbackProfile() = %q, want prod-read", got)
+	}
+	if got := client.TakeFallbackProfile(); got != "" {
+		t.Errorf("TakeFallbackProfile() after taking = %q, want empty", got)
+	}
+	for name, fake := range map[string]*fakeS3{"primary": primary, "staging": first, "prod-read": second} {
+		if n := fake.countCalls("HeadObject"); n != 1 {
+			t.Errorf("%s got %d HeadObject calls, want 1", name, n)
+		}
+	}
+}
+
+func TestFallbackStopsOnOtherErrors(t *testing.T) {
+	primary := newFakeS3() // the object is missing, not denied
+	fallback := newFakeS3()
+	fallback.put("b", "a.txt", fakeObject{body: []byte("hello")})
+
+	client := &Client{S3: newFallbackS3(primary, []ProfileS3{{Profile: "other", S3: fallback}})}
+	if _, err := client.GetObjectMetadata(context.Background(), "b", "a.txt"); !IsNotFound(err) {
+		t.Errorf("GetObjectMetadata() error = %v, want NotFound from the primary", err)
+	}
+	if n := fallback.countCalls("HeadObject"); n != 0 {
+		t.Errorf("fallback got %d HeadObject calls, want 0", n)
+	}
+}
+
+func TestFallbackAllDeniedReturnsAccessDenied(t *testing.T) {
+	client := &Client{S3: newFallbackS3(deniedFake(), []ProfileS3{{Profile: "other", S3: deniedFake()}})}
+	_, err := client.GetObjectMetadata(context.Background(), "b", "a.txt")
+	if !IsAccessDenied(err) {
+		t.Errorf("GetObjectMetadata() error = %v, want AccessDenied", err)
+	}
+	if got := client.TakeFallbackProfile(); got != "" {
+		t.Errorf("TakeFallbackProfile() = %q, want empty", got)
+	}
+}
+
+func TestFallbackNeverAppliesToMutations(t *testing.T) {
+	primary := deniedFake()
+	fallback := newFakeS3()
+	fallback.put("b", "a.txt", fakeObject{body: []byte("hello")})
+
+	client := &Client{S3: newFallbackS3(primary, []ProfileS3{{Profile: "admin", S3: fallback}})}
+	ctx := context.Background()
+
+	if _, err := client.DeleteObjects(ctx, "b", []string{"a.txt"}); !IsAccessDenied(err) {
+		t.Errorf("DeleteObjects() error = %v, want AccessDenied", err)
+	}
+	err := client.UploadStream(ctx, "b", "new.txt", strings.NewReader("x"), UploadOptions{})
+	if !IsAccessDenied(err) {
+		t.Errorf("UploadStream() error = %v, want AccessDenied", err)
+	}
+
+	for _, op := range []string{"DeleteObjects", "PutObject", "CreateMultipartUpload"} {
+		if n := fallback.countCalls(op); n != 0 {
+			t.Errorf("fallback got %d %s calls, want 0", n, op)
+		}
+	}
+	if _, ok := fallback.get("b", "a.txt"); !ok {
+		t.Error("the fallback profile deleted the object")
+	}
+	if got := client.TakeFallbackProfile(); got != "" {
+		t.Errorf("TakeFallbackProfile() = %q, want empty", got)
+	}
+}
+
+func TestValidateFallbackProfiles(t *testing.T) {
+	if err := (ClientOptions{FallbackProfiles: []string{"dev", "prod_read"}}).Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	if err := (ClientOptions{FallbackProfiles: []string{"bad profile"}}).Validate(); err == nil {
+		t.Error("Validate() = nil error for an invalid profile name")
+	}
+	if err := (ClientOptions{FallbackProfiles: []string{""}}).Validate(); err == nil {
+		t.Error("Validate() = nil error for an empty profile name")
+	}
+}
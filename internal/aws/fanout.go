@@ -0,0 +1,82 @@
+package aws
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultFanOutConcurrency is the number of destinations uploaded in parallel
+const DefaultFanOutConcurrency = 4
+
+// Destination is one place a fan-out upload writes to
+type Destination struct {
+	// Client uploads to this destination; nil uses the uploader's client.
+	// Buckets in other regions or accounts need their own.
+	Client *Client
+	Bucket string
+	Key    string
+}
+
+// FanOutOptions configures FanOutUpload
+type FanOutOptions struct {
+	// Concurrency bounds the uploads in flight; zero uses DefaultFanOutConcurrency
+	Concurrency int
+}
+
+// FanOutResult is the outcome of the upload to one destination
+type FanOutResult struct {
+	Destination Destination
+	UploadResult
+	// Unchanged is set when SkipUnchanged found identical content there
+	Unchanged bool
+	Err       error
+}
+
+// FanOutUpload uploads one local file to every destination, reading the
+// file afresh for each. A failed destination doesn't stop the others;
+// results are in dests order, and if any failed the error is a
+// *PartialFailureError naming them by s3:// URI.
+func (u *Uploader) FanOutUpload(ctx context.Context, localPath string, dests []Destination, opts FanOutOptions) ([]FanOutResult, error) {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultFanOutConcurrency
+	}
+
+	results := make([]FanOutResult, len(dests))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, dest := range dests {
+		results[i].Destination = dest
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			du := *u
+			if dest.Client != nil {
+				du.client = dest.Client
+			}
+			if err := du.client.checkWritable(dest.Bucket); err != nil {
+				results[i].Err = err
+				return
+			}
+			up, unchanged, err := du.uploadFile(ctx, localPath, dest.Bucket, dest.Key)
+			results[i].UploadResult = up
+			results[i].Unchanged = unchanged
+			results[i].Err = err
+		}()
+	}
+	wg.Wait()
+
+	batch := make([]ObjectResult, len(results))
+	for i, r := range results {
+		batch[i] = ObjectResult{Key: FormatS3URI(r.Destination.Bucket, r.Destination.Key), NewKey: r.Key, Err: r.Err}
+	}
+	return results, batchError(batch)
+}
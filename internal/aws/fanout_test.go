@@ -0,0 +1,83 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFanOutUploadPartialFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.csv")
+	if err := os.WriteFile(path, []byte("a,b\n1,2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fake := newFakeS3()
+	denied := errors.New("access denied")
+	fake.errFor = func(op, key string) error {
+		if op == "PutObject" && key == "blocked/report.csv" {
+			return denied
+		}
+		return nil
+	}
+	u := (&Client{S3: fake}).NewUploader(OnExistsOverwrite)
+
+	dests := []Destination{
+		{Bucket: "us-east", Key: "reports/report.csv"},
+		{Bucket: "eu-west", Key: "blocked/report.csv"},
+		{Bucket: "ap-south", Key: "replica/report.csv"},
+	}
+	results, err := u.FanOutUpload(context.Background(), path, dests, FanOutOptions{Concurrency: 2})
+
+	var partial *PartialFailureError
+	if !errors.As(err, &partial) {
+		t.Fatalf("FanOutUpload() error = %v, want *PartialFailureError", err)
+	}
+	if partial.Total != 3 || len(partial.Failed) != 1 || partial.Failed[0].Key != "s3://eu-west/blocked/report.csv" {
+		t.Errorf("PartialFailureError = %+v, want only s3://eu-west/blocked/report.csv of 3", partial)
+	}
+
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3", len(results))
+	}
+	if !errors.Is(results[1].Err, denied) {
+		t.Errorf("results[1].Err = %v, want the injected error", results[1].Err)
+	}
+	for _, i := range []int{0, 2} {
+		r := results[i]
+		if r.Err != nil || r.Key != dests[i].Key {
+			t.Errorf("results[%d] = %+v, want success at %s", i, r, dests[i].Key)
+		}
+		if got, ok := fake.get(dests[i].Bucket, dests[i].Key); !ok || string(got.body) != "a,b\n1,2\n" {
+			t.Errorf("%s/%s = %q, %v, want the file contents", dests[i].Bucket, dests[i].Key, got.body, ok)
+		}
+	}
+}
+
+func TestFanOutUploadPerDestinationClient(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.txt")
+	if err := os.WriteFile(path, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	home, replica := newFakeS3(), newFakeS3()
+	u := (&Client{S3: home}).NewUploader(OnExistsOverwrite)
+	dests := []Destination{
+		{Bucket: "b", Key: "a.txt"},
+		{Client: &Client{S3: replica}, Bucket: "b-replica", Key: "a.txt"},
+	}
+	if _, err := u.FanOutUpload(context.Background(), path, dests, FanOutOptions{}); err != nil {
+		t.Fatalf("FanOutUpload() error = %v", err)
+	}
+	if _, ok := home.get("b", "a.txt"); !ok {
+		t.Error("home client didn't receive its upload")
+	}
+	if _, ok := replica.get("b-replica", "a.txt"); !ok {
+		t.Error("replica client didn't receive its upload")
+	}
+	if _, ok := home.get("b-replica", "a.txt"); ok {
+		t.Error("replica destination was uploaded with the home client")
+	}
+}
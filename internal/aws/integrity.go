@@ -0,0 +1,152 @@
+package aws
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrIntegrityCheckFailed is returned when a downloaded file doesn't match the object's ETag
+var ErrIntegrityCheckFailed = errors.New("integrity check failed")
+
+// ErrETagUnverifiable is returned when an ETag can't be reproduced locally,
+// such as a multipart ETag from an uploader using an unusual part size. It
+// says nothing about whether the file is intact.
+var ErrETagUnverifiable = errors.New("ETag can't be verified locally")
+
+// Part sizes commonly used by S3 uploaders (SDK, AWS CLI, and others),
+// tried when reproducing a multipart ETag
+var commonPartSizes = []int64{
+	5 * 1024 * 1024,
+	8 * 1024 * 1024,
+	10 * 1024 * 1024,
+	16 * 1024 * 1024,
+	64 * 1024 * 1024,
+	100 * 1024 * 1024,
+}
+
+// VerifiableETag reports whether an ETag can be reproduced from the object's
+// bytes: either a plain MD5 or a multipart "md5-N" ETag
+func VerifiableETag(etag string) bool {
+	etag = strings.Trim(etag, "\"")
+	hash, parts, multipart := strings.Cut(etag, "-")
+	if len(hash) != 32 {
+		return false
+	}
+	if _, err := hex.DecodeString(hash); err != nil {
+		return false
+	}
+	if multipart {
+		n, err := strconv.Atoi(parts)
+		return err == nil && n > 0
+	}
+	return true
+}
+
+// CompareETag checks a local file against an S3 ETag. Plain ETags are
+// compared with the file's MD5. Multipart ETags ("md5-N") are reproduced by
+// hashing the file in parts, trying the part sizes uploaders commonly use;
+// when none reproduces it the result is ErrETagUnverifiable, not a mismatch.
+// CompareETagPartSize settles multipart ETags when the part size is known.
+func CompareETag(path, etag string) error {
+	return CompareETagPartSize(path, etag, 0)
+}
+
+// CompareETagPartSize is CompareETag for an object uploaded in parts of
+// partSize, as HEAD with PartNumber 1 reports. With the part size known, a
+// multipart ETag that doesn't match is ErrIntegrityCheckFailed. A partSize
+// of zero means unknown.
+func CompareETagPartSize(path, etag string, partSize int64) error {
+	etag = strings.ToLower(strings.Trim(etag, "\""))
+	if !VerifiableETag(etag) {
+		return fmt.Errorf("%w: %q", ErrETagUnverifiable, etag)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open file for verification: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat file for verification: %w", err)
+	}
+
+	_, partsStr, multipart := strings.Cut(etag, "-")
+	if !multipart {
+		hash := md5.New()
+		if _, err := io.Copy(hash, file); err != nil {
+			return fmt.Errorf("failed to hash file: %w", err)
+		}
+		if local := hex.EncodeToString(hash.Sum(nil)); local != etag {
+			return fmt.Errorf("%w: local MD5 %s does not match ETag %s", ErrIntegrityCheckFailed, local, etag)
+		}
+		return nil
+	}
+
+	if partSize > 0 {
+		local, err := MultipartETag(file, info.Size(), partSize)
+		if err != nil {
+			return err
+		}
+		if local != etag {
+			return fmt.Errorf("%w: local multipart ETag %s does not match %s", ErrIntegrityCheckFailed, local, etag)
+		}
+		return nil
+	}
+
+	parts, _ := strconv.Atoi(partsStr)
+	for _, partSize := range partSizeCandidates(info.Size(), parts) {
+		local, err := MultipartETag(file, info.Size(), partSize)
+		if err != nil {
+			return err
+		}
+		if local == etag {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: no part size reproduces multipart ETag %s", ErrETagUnverifiable, etag)
+}
+
+// MultipartETag computes the ETag S3 assigns to an object uploaded in parts of partSize
+func MultipartETag(r io.ReaderAt, size, partSize int64) (string, error) {
+	var digests []byte
+	parts := 0
+	for off := int64(0); off < size; off += partSize {
+		hash := md5.New()
+		if _, err := io.Copy(hash, io.NewSectionReader(r, off, min(partSize, size-off))); err != nil {
+			return "", fmt.Errorf("failed to hash file: %w", err)
+		}
+		digests = hash.Sum(digests)
+		parts++
+	}
+	sum := md5.Sum(digests)
+	return fmt.Sprintf("%s-%d", hex.EncodeToString(sum[:]), parts), nil
+}
+
+// partSizeCandidates returns part sizes that split size into exactly parts pieces
+func partSizeCandidates(size int64, parts int) []int64 {
+	const mib = 1024 * 1024
+
+	even := (size + int64(parts) - 1) / int64(parts)
+	candidates := append([]int64{even, (even + mib - 1) / mib * mib}, commonPartSizes...)
+
+	seen := make(map[int64]bool)
+	var valid []int64
+	for _, ps := range candidates {
+		if ps <= 0 || seen[ps] {
+			continue
+		}
+		seen[ps] = true
+		if (size+ps-1)/ps == int64(parts) {
+			valid = append(valid, ps)
+		}
+	}
+	return valid
+}
@@ -0,0 +1,275 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDownloadFileIntegrity(t *testing.T) {
+	// Nine bytes in parts of 4: three parts, but no candidate size tries 4
+	unlisted, err := MultipartETag(bytes.NewReader([]byte("payload!!")), 9, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// What S3 would report had the upload been different bytes
+	corrupt, err := MultipartETag(bytes.NewReader([]byte("payload??")), 9, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		etag       string
+		partSize   int64 // reported by HEAD with a PartNumber; zero if the store can't
+		wantErr    error
+		wantExists bool
+	}{
+		{"matching", "", 0, nil, true},
+		{"mismatched", "0123456789abcdef0123456789abcdef", 0, ErrIntegrityCheckFailed, false},
+		{"multipart with the part size from HEAD", unlisted, 4, nil, true},
+		{"multipart mismatch with the part size from HEAD", corrupt, 4, ErrIntegrityCheckFailed, false},
+		{"multipart with an unknown part size", unlisted, 0, ErrETagUnverifiable, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeS3()
+			fake.put("b", "data.txt", fakeObject{body: []byte("payload!!"), etagOverride: tt.etag, partSize: tt.partSize})
+			client := &Client{S3: fake, Options: ClientOptions{
+				VerifyDownloads:        true,
+				RemoveCorruptDownloads: true,
+			}}
+
+			localPath := filepath.Join(t.TempDir(), "data.txt")
+			err := client.DownloadFile(context.Background(), "b", "data.txt", localPath, nil)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("DownloadFile() error = %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DownloadFile() error = %v, want %v", err, tt.wantErr)
+			}
+
+			_, statErr := os.Stat(localPath)
+			if exists := statErr == nil; exists != tt.wantExists {
+				t.Errorf("file exists = %v, want %v", exists, tt.wantExists)
+			}
+		})
+	}
+}
+
+func TestDownloadFileObjectReplacedAfterHead(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("b", "data.txt", fakeObject{body: []byte("payload")})
+	fake.errFor = func(op, key string) error {
+		if op == "GetObject" {
+			fake.put("b", "data.txt", fakeObject{body: []byte("replaced")})
+		}
+		return nil
+	}
+	client := &Client{S3: fake, Options: ClientOptions{
+		VerifyDownloads:        true,
+		RemoveCorruptDownloads: true,
+	}}
+
+	localPath := filepath.Join(t.TempDir(), "data.txt")
+	err := client.DownloadFile(context.Background(), "b", "data.txt", localPath, nil)
+	if err == nil || errors.Is(err, ErrIntegrityCheckFailed) {
+		t.Fatalf("DownloadFile() error = %v, want a failed precondition, not an integrity failure", err)
+	}
+	if !strings.Contains(err.Error(), "PreconditionFailed") {
+		t.Errorf("DownloadFile() error = %v, want PreconditionFailed", err)
+	}
+}
+
+func TestDownloadFileSkipsVerificationOverLimit(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("b", "big.bin", fakeObject{body: []byte("0123456789"), etagOverride: "0123456789abcdef0123456789abcdef"})
+	client := &Client{S3: fake, Options: ClientOptions{VerifyDownloads: true, VerifyMaxBytes: 5}}
+
+	localPath := filepath.Join(t.TempDir(), "big.bin")
+	if err := client.DownloadFile(context.Background(), "b", "big.bin", localPath, nil); err != nil {
+		t.Fatalf("DownloadFile() error = %v, want verification skipped", err)
+	}
+}
+
+func TestDownloadFilePartFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		cancel   bool
+		wantPart bool
+	}{
+		{"cancelled keeps part", true, true},
+		{"failed removes part", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			fake := newFakeS3()
+			fake.put("b", "data.txt", fakeObject{body: []byte("payload")})
+			fake.errFor = func(op, key string) error {
+				if op != "GetObject" {
+					return nil
+				}
+				if tt.cancel {
+					cancel()
+					return ctx.Err()
+				}
+				return errors.New("connection reset")
+			}
+			tempDir := t.TempDir()
+			client := &Client{S3: fake, Options: ClientOptions{DownloadTempDir: tempDir}}
+
+			localPath := filepath.Join(t.TempDir(), "data.txt")
+			if err := client.DownloadFile(ctx, "b", "data.txt", localPath, nil); err == nil {
+				t.Fatal("DownloadFile() error = nil, want failure")
+			}
+
+			if _, err := os.Stat(localPath); err == nil {
+				t.Error("final file exists after a failed download")
+			}
+			obj, _ := fake.get("b", "data.txt")
+			partPath, err := PartPath(tempDir, localPath, obj.etag())
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, statErr := os.Stat(partPath)
+			if exists := statErr == nil; exists != tt.wantPart {
+				t.Errorf("part file exists = %v, want %v", exists, tt.wantPart)
+			}
+		})
+	}
+}
+
+func TestDownloadFileResumesPart(t *testing.T) {
+	tests := []struct {
+		name        string
+		ignoreRange bool
+		want        string
+	}{
+		// The part's bytes differ from the object's so the result shows
+		// whether they were kept
+		{"fetches the rest", false, "PAYload"},
+		{"starts over when the range is ignored", true, "payload"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeS3()
+			fake.put("b", "data.txt", fakeObject{body: []byte("payload")})
+			fake.ignoreRange = tt.ignoreRange
+			tempDir := t.TempDir()
+			client := &Client{S3: fake, Options: ClientOptions{DownloadTempDir: tempDir}}
+
+			localPath := filepath.Join(t.TempDir(), "data.txt")
+			obj, _ := fake.get("b", "data.txt")
+			partPath, err := PartPath(tempDir, localPath, obj.etag())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(partPath, []byte("PAY"), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := client.DownloadFile(context.Background(), "b", "data.txt", localPath, nil); err != nil {
+				t.Fatalf("DownloadFile() error = %v", err)
+			}
+			got, err := os.ReadFile(localPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("downloaded %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDownloadFileIgnoresPartOfOtherVersion(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("b", "data.txt", fakeObject{body: []byte("payload")})
+	tempDir := t.TempDir()
+	client := &Client{S3: fake, Options: ClientOptions{DownloadTempDir: tempDir}}
+
+	localPath := filepath.Join(t.TempDir(), "data.txt")
+	stale, err := PartPath(tempDir, localPath, "0123456789abcdef0123456789abcdef")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(stale, []byte("OLD"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.DownloadFile(context.Background(), "b", "data.txt", localPath, nil); err != nil {
+		t.Fatalf("DownloadFile() error = %v", err)
+	}
+	if got, _ := os.ReadFile(localPath); string(got) != "payload" {
+		t.Errorf("downloaded %q, want %q", got, "payload")
+	}
+}
+
+func TestCompareETagMultipart(t *testing.T) {
+	const partSize = 5 * 1024 * 1024
+	body := bytes.Repeat([]byte("stui"), (2*partSize+1024)/4) // three parts, the last one short
+
+	// Build the expected ETag independently: MD5 of the concatenated part MD5s
+	var digests []byte
+	for off := 0; off < len(body); off += partSize {
+		end := min(off+partSize, len(body))
+		sum := md5.Sum(body[off:end])
+		digests = append(digests, sum[:]...)
+	}
+	sum := md5.Sum(digests)
+	etag := fmt.Sprintf("%s-3", hex.EncodeToString(sum[:]))
+
+	path := filepath.Join(t.TempDir(), "multipart.bin")
+	if err := os.WriteFile(path, body, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := CompareETag(path, `"`+etag+`"`); err != nil {
+		t.Errorf("CompareETag() error = %v, want match", err)
+	}
+
+	// Same part count, wrong digest: no part size reproduces it, which could
+	// as well be an unusual part size as a corrupt file
+	if err := CompareETag(path, "0123456789abcdef0123456789abcdef-3"); !errors.Is(err, ErrETagUnverifiable) {
+		t.Errorf("CompareETag() error = %v, want ErrETagUnverifiable", err)
+	}
+	// Knowing the part size settles it
+	if err := CompareETagPartSize(path, `"`+etag+`"`, partSize); err != nil {
+		t.Errorf("CompareETagPartSize() error = %v, want match", err)
+	}
+	if err := CompareETagPartSize(path, "0123456789abcdef0123456789abcdef-3", partSize); !errors.Is(err, ErrIntegrityCheckFailed) {
+		t.Errorf("CompareETagPartSize() error = %v, want ErrIntegrityCheckFailed", err)
+	}
+}
+
+func TestVerifiableETag(t *testing.T) {
+	tests := []struct {
+		etag string
+		want bool
+	}{
+		{`"d41d8cd98f00b204e9800998ecf8427e"`, true},
+		{"d41d8cd98f00b204e9800998ecf8427e-12", true},
+		{"d41d8cd98f00b204e9800998ecf8427e-", false},
+		{"not-an-md5", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := VerifiableETag(tt.etag); got != tt.want {
+			t.Errorf("VerifiableETag(%q) = %v, want %v", tt.etag, got, tt.want)
+		}
+	}
+}
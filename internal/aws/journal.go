@@ -0,0 +1,107 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// ErrNotRestorable is returned when restoring a delete that left no delete
+// marker, because the bucket isn't versioned
+var ErrNotRestorable = errors.New("object was deleted from an unversioned bucket and can't be restored")
+
+// DeletionEntry records one object deleted this session
+type DeletionEntry struct {
+	Bucket string
+	Key    string
+	// VersionID is the delete marker S3 created; empty if the bucket isn't versioned
+	VersionID string
+	When      time.Time
+}
+
+// Restorable reports whether removing the delete marker would bring the object back
+func (e DeletionEntry) Restorable() bool {
+	return e.VersionID != ""
+}
+
+// MaxJournalEntries is how many deletes a DeletionJournal remembers; a
+// recursive delete of a large prefix would otherwise grow it without bound
+const MaxJournalEntries = 1000
+
+// DeletionJournal remembers the objects deleted this session so deletes on
+// versioned buckets can be undone. Only the newest MaxJournalEntries are
+// kept. It is safe for concurrent use.
+type DeletionJournal struct {
+	mu      sync.Mutex
+	entries []DeletionEntry
+}
+
+// NewDeletionJournal creates an empty journal
+func NewDeletionJournal() *DeletionJournal {
+	return &DeletionJournal{}
+}
+
+// Record adds an entry, dropping the oldest once the journal is full
+func (j *DeletionJournal) Record(e DeletionEntry) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if len(j.entries) >= MaxJournalEntries {
+		j.entries = slices.Delete(j.entries, 0, len(j.entries)-MaxJournalEntries+1)
+	}
+	j.entries = append(j.entries, e)
+}
+
+// Entries returns the recorded deletes, newest first
+func (j *DeletionJournal) Entries() []DeletionEntry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	entries := slices.Clone(j.entries)
+	slices.Reverse(entries)
+	return entries
+}
+
+// Len returns the number of recorded deletes
+func (j *DeletionJournal) Len() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return len(j.entries)
+}
+
+// remove drops e from the journal
+func (j *DeletionJournal) remove(e DeletionEntry) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.entries = slices.DeleteFunc(j.entries, func(x DeletionEntry) bool {
+		return x.Bucket == e.Bucket && x.Key == e.Key && x.VersionID == e.VersionID
+	})
+}
+
+// Restore undoes a delete by removing its delete marker, which makes the
+// previous version current again. The entry leaves the journal once restored.
+func (c *Client) Restore(ctx context.Context, e DeletionEntry) error {
+	if !e.Restorable() {
+		return ErrNotRestorable
+	}
+	if err := c.checkWritable(e.Bucket); err != nil {
+		return err
+	}
+
+	_, err := c.S3.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket:    aws.String(e.Bucket),
+		Key:       aws.String(e.Key),
+		VersionId: aws.String(e.VersionID),
+	})
+	if err != nil {
+		return &OpError{Op: "Restoring object", Bucket: e.Bucket, Key: e.Key, Err: err}
+	}
+
+	if c.Options.Journal != nil {
+		c.Options.Journal.remove(e)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestDeleteObjectsRecordsJournal(t *testing.T) {
+	fake := newFakeS3()
+	fake.versioned["versioned"] = true
+	fake.put("versioned", "a.txt", fakeObject{body: []byte("a")})
+	fake.put("versioned", "b.txt", fakeObject{body: []byte("b")})
+	fake.put("plain", "c.txt", fakeObject{body: []byte("c")})
+	journal := NewDeletionJournal()
+	client := &Client{S3: fake, Options: ClientOptions{Journal: journal}}
+
+	if _, err := client.DeleteObjects(context.Background(), "versioned", []string{"a.txt", "b.txt"}); err != nil {
+		t.Fatalf("DeleteObjects() error = %v", err)
+	}
+	if _, err := client.DeleteObjects(context.Background(), "plain", []string{"c.txt"}); err != nil {
+		t.Fatalf("DeleteObjects() error = %v", err)
+	}
+
+	entries := journal.Entries()
+	if len(entries) != 3 {
+		t.Fatalf("journal has %d entries, want 3: %+v", len(entries), entries)
+	}
+	// Newest first
+	if entries[0].Key != "c.txt" || entries[0].Restorable() {
+		t.Errorf("entries[0] = %+v, want unrestorable c.txt", entries[0])
+	}
+	for _, e := range entries[1:] {
+		if e.Bucket != "versioned" || !e.Restorable() || e.When.IsZero() {
+			t.Errorf("entry %+v, want a restorable delete with a time", e)
+		}
+	}
+}
+
+func TestRestoreRemovesDeleteMarker(t *testing.T) {
+	fake := newFakeS3()
+	fake.versioned["b"] = true
+	fake.put("b", "report.csv", fakeObject{body: []byte("1,2,3")})
+	journal := NewDeletionJournal()
+	client := &Client{S3: fake, Options: ClientOptions{Journal: journal}}
+
+	if _, err := client.DeleteObjects(context.Background(), "b", []string{"report.csv"}); err != nil {
+		t.Fatalf("DeleteObjects() error = %v", err)
+	}
+	if _, ok := fake.get("b", "report.csv"); ok {
+		t.Fatal("object still current after delete")
+	}
+
+	entry := journal.Entries()[0]
+	if err := client.Restore(context.Background(), entry); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	obj, ok := fake.get("b", "report.csv")
+	if !ok || string(obj.body) != "1,2,3" {
+		t.Errorf("object after restore = %q, %v, want the deleted content", obj.body, ok)
+	}
+	if journal.Len() != 0 {
+		t.Errorf("journal has %d entries after restore, want 0", journal.Len())
+	}
+}
+
+func TestRestoreUnversionedDelete(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("plain", "gone.txt", fakeObject{body: []byte("x")})
+	journal := NewDeletionJournal()
+	client := &Client{S3: fake, Options: ClientOptions{Journal: journal}}
+
+	if _, err := client.DeleteObjects(context.Background(), "plain", []string{"gone.txt"}); err != nil {
+		t.Fatalf("DeleteObjects() error = %v", err)
+	}
+
+	err := client.Restore(context.Background(), journal.Entries()[0])
+	if !errors.Is(err, ErrNotRestorable) {
+		t.Errorf("Restore() error = %v, want ErrNotRestorable", err)
+	}
+	if n := fake.countCalls("DeleteObject"); n != 0 {
+		t.Errorf("made %d DeleteObject calls, want 0", n)
+	}
+	if journal.Len() != 1 {
+		t.Error("unrestorable entry was dropped from the journal")
+	}
+}
+
+func TestRestoreProtectedBucket(t *testing.T) {
+	client := &Client{S3: newFakeS3(), Options: ClientOptions{ProtectedBuckets: []string{"prod-*"}}}
+	err := client.Restore(context.Background(), DeletionEntry{Bucket: "prod-data", Key: "k", VersionID: "v1"})
+	if !errors.Is(err, ErrProtectedBucket) {
+		t.Errorf("Restore() error = %v, want ErrProtectedBucket", err)
+	}
+}
+
+func TestDeletionJournalIsCapped(t *testing.T) {
+	journal := NewDeletionJournal()
+	for i := range MaxJournalEntries + 5 {
+		journal.Record(DeletionEntry{Bucket: "b", Key: fmt.Sprintf("k%d", i)})
+	}
+
+	if got := journal.Len(); got != MaxJournalEntries {
+		t.Fatalf("Len() = %d, want %d", got, MaxJournalEntries)
+	}
+	entries := journal.Entries()
+	if newest := entries[0].Key; newest != fmt.Sprintf("k%d", MaxJournalEntries+4) {
+		t.Errorf("newest entry = %s", newest)
+	}
+	if oldest := entries[len(entries)-1].Key; oldest != "k5" {
+		t.Errorf("oldest entry = %s, want k5", oldest)
+	}
+}
@@ -0,0 +1,131 @@
+package aws
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/natevick/stui/internal/security"
+)
+
+// ErrDuplicateKey is returned for files a KeyTemplate gives the same key,
+// such as "{date}/{basename}" over two folders that each hold an index.html
+var ErrDuplicateKey = errors.New("another file in the upload expands to the same key")
+
+// keyTemplateDateFormat is how {date} expands (always UTC)
+const keyTemplateDateFormat = "2006-01-02"
+
+// Placeholders a KeyTemplate can expand
+var keyTemplatePlaceholders = []string{"basename", "ext", "date", "uuid", "dir"}
+
+// KeyTemplate builds upload keys from each file's path, e.g.
+// "{date}/{basename}" or "uploads/{uuid}-{basename}". Placeholders:
+//
+//	{basename}  the file name, "report.csv"
+//	{ext}       its extension without the dot, "csv"; empty if it has none
+//	{date}      the upload date in UTC, "2024-03-05"
+//	{uuid}      a random UUID, new for every file
+//	{dir}       the folder the file is in relative to the upload root,
+//	            "logs/2024"; empty at the root, where "{dir}/" is dropped
+type KeyTemplate struct {
+	raw   string
+	parts []keyTemplatePart
+}
+
+// keyTemplatePart is either literal text or a placeholder name
+type keyTemplatePart struct {
+	literal     string
+	placeholder string
+}
+
+// ParseKeyTemplate parses a template, rejecting unknown placeholders,
+// unbalanced braces and templates whose keys would fail ValidNewObjectKey
+func ParseKeyTemplate(s string) (*KeyTemplate, error) {
+	if s == "" {
+		return nil, fmt.Errorf("key template cannot be empty")
+	}
+	t := &KeyTemplate{raw: s}
+	rest := s
+	for rest != "" {
+		open := strings.IndexAny(rest, "{}")
+		if open < 0 {
+			t.parts = append(t.parts, keyTemplatePart{literal: rest})
+			break
+		}
+		if rest[open] == '}' {
+			return nil, fmt.Errorf("key template %q has an unmatched }", s)
+		}
+		if open > 0 {
+			t.parts = append(t.parts, keyTemplatePart{literal: rest[:open]})
+		}
+		end := strings.IndexAny(rest[open+1:], "{}")
+		if end < 0 || rest[open+1+end] == '{' {
+			return nil, fmt.Errorf("key template %q has an unmatched {", s)
+		}
+		name := rest[open+1 : open+1+end]
+		if !slices.Contains(keyTemplatePlaceholders, name) {
+			return nil, fmt.Errorf("key template %q: unknown placeholder {%s}", s, name)
+		}
+		t.parts = append(t.parts, keyTemplatePart{placeholder: name})
+		rest = rest[open+1+end+1:]
+	}
+
+	// A sample file catches literal text no expansion could make valid
+	if _, err := t.Expand("dir/file.txt", time.Now()); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// String returns the template as written
+func (t *KeyTemplate) String() string {
+	return t.raw
+}
+
+// Expand builds the key for a file at rel, a slash-separated path relative
+// to the upload root, uploaded at now. The result must pass ValidNewObjectKey.
+func (t *KeyTemplate) Expand(rel string, now time.Time) (string, error) {
+	dir := path.Dir(rel)
+	if dir == "." {
+		dir = ""
+	}
+	base := path.Base(rel)
+
+	var b strings.Builder
+	dropSlash := false
+	for _, part := range t.parts {
+		if part.placeholder == "" {
+			lit := part.literal
+			if dropSlash {
+				lit = strings.TrimPrefix(lit, "/")
+			}
+			b.WriteString(lit)
+			dropSlash = false
+			continue
+		}
+		dropSlash = false
+		switch part.placeholder {
+		case "basename":
+			b.WriteString(base)
+		case "ext":
+			b.WriteString(strings.TrimPrefix(path.Ext(base), "."))
+		case "date":
+			b.WriteString(now.UTC().Format(keyTemplateDateFormat))
+		case "uuid":
+			b.WriteString(uuid.NewString())
+		case "dir":
+			b.WriteString(dir)
+			dropSlash = dir == ""
+		}
+	}
+
+	key := b.String()
+	if err := security.ValidNewObjectKey(key); err != nil {
+		return "", fmt.Errorf("key template %q gives an invalid key for %s: %w", t.raw, rel, err)
+	}
+	return key, nil
+}
@@ -0,0 +1,167 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestKeyTemplateExpand(t *testing.T) {
+	now := time.Date(2024, 3, 5, 23, 30, 0, 0, time.FixedZone("EST", -5*3600))
+
+	tests := []struct {
+		name     string
+		template string
+		rel      string
+		want     string
+	}{
+		{"basename", "uploads/{basename}", "logs/app.log", "uploads/app.log"},
+		{"ext", "by-type/{ext}/{basename}", "logs/app.log", "by-type/log/app.log"},
+		{"no ext", "{ext}-{basename}", "Makefile", "-Makefile"},
+		{"date is UTC", "{date}/{basename}", "app.log", "2024-03-06/app.log"},
+		{"dir", "{dir}/{basename}", "logs/2024/app.log", "logs/2024/app.log"},
+		{"dir at the root", "{dir}/{basename}", "app.log", "app.log"},
+		{"combined", "archive/{date}/{dir}/{basename}.{ext}.bak", "logs/app.log", "archive/2024-03-06/logs/app.log.log.bak"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := ParseKeyTemplate(tt.template)
+			if err != nil {
+				t.Fatalf("ParseKeyTemplate(%q) error = %v", tt.template, err)
+			}
+			got, err := tmpl.Expand(tt.rel, now)
+			if err != nil {
+				t.Fatalf("Expand(%q) error = %v", tt.rel, err)
+			}
+			if got != tt.want {
+				t.Errorf("Expand(%q) = %q, want %q", tt.rel, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyTemplateUUIDPerFile(t *testing.T) {
+	tmpl, err := ParseKeyTemplate("uploads/{uuid}-{basename}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []string
+	for range 2 {
+		key, err := tmpl.Expand("a.txt", time.Now())
+		if err != nil {
+			t.Fatalf("Expand() error = %v", err)
+		}
+		id, ok := strings.CutSuffix(strings.TrimPrefix(key, "uploads/"), "-a.txt")
+		if !ok {
+			t.Fatalf("Expand() = %q, want uploads/<uuid>-a.txt", key)
+		}
+		if _, err := uuid.Parse(id); err != nil {
+			t.Errorf("Expand() = %q: %q is not a UUID", key, id)
+		}
+		ids = append(ids, id)
+	}
+	if ids[0] == ids[1] {
+		t.Errorf("two files got the same UUID %s", ids[0])
+	}
+}
+
+func TestParseKeyTemplateRejects(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+	}{
+		{"empty", ""},
+		{"unknown placeholder", "{year}/{basename}"},
+		{"unclosed", "{date/{basename}"},
+		{"unopened", "date}/{basename}"},
+		{"climbs out of the bucket", "../{basename}"},
+		{"double slash", "uploads//{basename}"},
+		{"control character", "uploads/\x07{basename}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseKeyTemplate(tt.template); err == nil {
+				t.Errorf("ParseKeyTemplate(%q) = nil error, want one", tt.template)
+			}
+		})
+	}
+}
+
+func TestKeyTemplateExpandRejectsInvalidKey(t *testing.T) {
+	tmpl, err := ParseKeyTemplate("uploads/{basename}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The template is fine, but a file name with a control character isn't
+	if _, err := tmpl.Expand("logs/bell\x07.txt", time.Now()); err == nil {
+		t.Error("Expand() = nil error for a key with a control character")
+	}
+}
+
+func TestUploadDirKeyTemplate(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "nested/b.csv"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fake := newFakeS3()
+	client := &Client{S3: fake, Options: ClientOptions{UploadKeyTemplate: "{ext}/{dir}/{basename}"}}
+	res, err := client.NewUploader(OnExistsFail).UploadDir(context.Background(), dir, "b", "site")
+	if err != nil {
+		t.Fatalf("UploadDir() error = %v", err)
+	}
+
+	slices.Sort(res.Uploaded)
+	if want := []string{"site/csv/nested/b.csv", "site/txt/a.txt"}; !slices.Equal(res.Uploaded, want) {
+		t.Errorf("Uploaded = %v, want %v", res.Uploaded, want)
+	}
+}
+
+func TestUploadDirKeyTemplateDuplicateKeys(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a/index.html", "b/index.html", "c.txt"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fake := newFakeS3()
+	client := &Client{S3: fake, Options: ClientOptions{UploadKeyTemplate: "{basename}"}}
+	res, err := client.NewUploader(OnExistsOverwrite).UploadDir(context.Background(), dir, "b", "")
+
+	var partial *PartialFailureError
+	if !errors.As(err, &partial) || len(partial.Failed) != 2 {
+		t.Fatalf("UploadDir() error = %v, want both index.html files failed", err)
+	}
+	for _, f := range partial.Failed {
+		if f.Key != "index.html" || !errors.Is(f.Err, ErrDuplicateKey) {
+			t.Errorf("failure %s: %v, want ErrDuplicateKey", f.Key, f.Err)
+		}
+	}
+	if !slices.Equal(res.Uploaded, []string{"c.txt"}) {
+		t.Errorf("Uploaded = %v, want only c.txt", res.Uploaded)
+	}
+	if _, ok := fake.get("b", "index.html"); ok {
+		t.Error("a file with a duplicate key was uploaded")
+	}
+}
@@ -0,0 +1,44 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrLimitExceeded is returned when a recursive listing finds more objects
+// than the configured limit
+var ErrLimitExceeded = errors.New("too many objects")
+
+// ObjectLimitError reports a recursive listing stopped at the object limit.
+// It matches ErrLimitExceeded with errors.Is.
+type ObjectLimitError struct {
+	// Seen is how many objects were listed before stopping; more may exist
+	Seen  int
+	Limit int
+}
+
+func (e *ObjectLimitError) Error() string {
+	return fmt.Sprintf("stopped after listing %d objects, over the limit of %d: %v", e.Seen, e.Limit, ErrLimitExceeded)
+}
+
+func (e *ObjectLimitError) Unwrap() error {
+	return ErrLimitExceeded
+}
+
+// objectLimitKey carries a per-call object limit on the context
+type objectLimitKey struct{}
+
+// WithObjectLimit overrides ClientOptions.MaxRecursiveObjects for recursive
+// operations run with the returned context. Zero or less removes the limit.
+func WithObjectLimit(ctx context.Context, limit int) context.Context {
+	return context.WithValue(ctx, objectLimitKey{}, limit)
+}
+
+// objectLimit returns the object limit in effect for ctx; zero means none
+func (c *Client) objectLimit(ctx context.Context) int {
+	if limit, ok := ctx.Value(objectLimitKey{}).(int); ok {
+		return max(limit, 0)
+	}
+	return max(c.Options.MaxRecursiveObjects, 0)
+}
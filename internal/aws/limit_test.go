@@ -0,0 +1,70 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestListAllObjectsStopsAtLimit(t *testing.T) {
+	fake := newListerFake(25)
+	client := &Client{S3: fake, Options: ClientOptions{PageSize: 10, MaxRecursiveObjects: 12}}
+
+	_, err := client.ListAllObjects(context.Background(), "b", "logs/")
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("ListAllObjects() error = %v, want ErrLimitExceeded", err)
+	}
+	var limitErr *ObjectLimitError
+	if !errors.As(err, &limitErr) || limitErr.Seen != 20 || limitErr.Limit != 12 {
+		t.Errorf("error = %#v, want Seen 20 and Limit 12", err)
+	}
+	if !strings.Contains(err.Error(), "20 objects") {
+		t.Errorf("error %q does not report how many objects were seen", err)
+	}
+
+	// Listing stopped on the page that crossed the limit
+	if n := fake.countCalls("ListObjectsV2"); n != 2 {
+		t.Errorf("made %d list requests, want 2", n)
+	}
+}
+
+func TestListAllObjectsLimitOverride(t *testing.T) {
+	tests := []struct {
+		name    string
+		limit   int
+		wantErr bool
+	}{
+		{"raised", 30, false},
+		{"removed", 0, false},
+		{"lowered", 5, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &Client{S3: newListerFake(25), Options: ClientOptions{MaxRecursiveObjects: 12}}
+			ctx := WithObjectLimit(context.Background(), tt.limit)
+
+			objects, err := client.ListAllObjects(ctx, "b", "logs/")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ListAllObjects() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && len(objects) != 25 {
+				t.Errorf("got %d objects, want 25", len(objects))
+			}
+		})
+	}
+}
+
+func TestRenamePrefixHonorsLimit(t *testing.T) {
+	fake := newListerFake(5)
+	client := &Client{S3: fake, Options: ClientOptions{MaxRecursiveObjects: 3}}
+
+	_, err := client.RenamePrefix(context.Background(), "b", "logs/", "archive/")
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("RenamePrefix() error = %v, want ErrLimitExceeded", err)
+	}
+	if n := fake.countCalls("CopyObject"); n != 0 {
+		t.Errorf("copied %d objects after the limit tripped, want 0", n)
+	}
+}
@@ -0,0 +1,37 @@
+package aws
+
+import "sync"
+
+// ListAPICache remembers endpoints that reject ListObjectsV2 and only speak
+// the original ListObjects API, as some S3-compatible stores do, so listers
+// there skip straight to V1. NewClient creates one shared the same way as
+// Metrics. A nil *ListAPICache remembers nothing.
+type ListAPICache struct {
+	mu     sync.Mutex
+	v1Only map[string]bool
+}
+
+// NewListAPICache creates an empty cache
+func NewListAPICache() *ListAPICache {
+	return &ListAPICache{v1Only: make(map[string]bool)}
+}
+
+// V1Only reports whether endpoint is known to lack ListObjectsV2
+func (c *ListAPICache) V1Only(endpoint string) bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.v1Only[endpoint]
+}
+
+// SetV1Only records that endpoint lacks ListObjectsV2
+func (c *ListAPICache) SetV1Only(endpoint string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.v1Only[endpoint] = true
+}
@@ -0,0 +1,303 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/natevick/stui/internal/security"
+)
+
+// Page size limits for ListObjectsV2 (S3 never returns more than 1000 keys)
+const (
+	MinPageSize     = 1
+	MaxPageSize     = 1000
+	DefaultPageSize = 1000
+)
+
+// ClampPageSize limits n to the range S3 accepts, using the default for zero
+func ClampPageSize(n int) int {
+	switch {
+	case n == 0:
+		return DefaultPageSize
+	case n < MinPageSize:
+		return MinPageSize
+	case n > MaxPageSize:
+		return MaxPageSize
+	default:
+		return n
+	}
+}
+
+// Lister pages through the objects under a prefix one request at a time.
+// The page size can be changed between pages without losing the position.
+// When S3 throttles a request the page size is halved, then doubled back
+// toward the configured size with each successful page.
+type Lister struct {
+	client    *Client
+	bucket    string
+	prefix    string
+	delimiter string
+	// listPrefix is the prefix sent to S3; directory buckets only accept
+	// whole folders, so partial prefixes are filtered client-side
+	listPrefix string
+
+	mu       sync.Mutex
+	pageSize int
+	// ceiling is the configured page size that throttling backs off from
+	ceiling int
+	token   *string
+	started bool
+	done    bool
+	// v1 pages with ListObjects and marker instead of ListObjectsV2
+	v1     bool
+	marker *string
+}
+
+// NewLister creates a lister for bucket/prefix. A "/" delimiter groups keys
+// into folder-like common prefixes; an empty delimiter lists recursively.
+func (c *Client) NewLister(bucket, prefix, delimiter string) *Lister {
+	return &Lister{
+		client:    c,
+		bucket:    bucket,
+		prefix:    prefix,
+		delimiter: delimiter,
+		pageSize:  ClampPageSize(c.Options.PageSize),
+		ceiling:   ClampPageSize(c.Options.PageSize),
+		v1:        c.Options.ListAPIs.V1Only(c.endpoint()),
+
+		listPrefix: listPrefix(bucket, prefix),
+	}
+}
+
+// listPrefix returns the prefix to request from S3 for bucket/prefix
+func listPrefix(bucket, prefix string) string {
+	if !security.IsDirectoryBucket(bucket) || strings.HasSuffix(prefix, "/") {
+		return prefix
+	}
+	return prefix[:strings.LastIndex(prefix, "/")+1]
+}
+
+// SetPageSize changes the number of keys requested per page.
+// It takes effect on the next NextPage call.
+func (l *Lister) SetPageSize(n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pageSize = ClampPageSize(n)
+	l.ceiling = l.pageSize
+}
+
+// PageSize returns the number of keys the next request will ask for. It is
+// below the configured size while the lister is backing off from throttling.
+func (l *Lister) PageSize() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.pageSize
+}
+
+// HasMorePages reports whether another page can be fetched
+func (l *Lister) HasMorePages() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return !l.started || !l.done
+}
+
+// NextPage fetches the next page of objects and common prefixes. When the
+// endpoint rejects ListObjectsV2 as not implemented, the lister switches to
+// ListObjects (V1) with marker pagination and the client remembers that for
+// the endpoint. A throttled page is retried with a smaller page size, up to
+// listThrottleAttempts times in all.
+func (l *Lister) NextPage(ctx context.Context) ([]S3Object, error) {
+	l.mu.Lock()
+	if l.started && l.done {
+		l.mu.Unlock()
+		return nil, fmt.Errorf("no more pages")
+	}
+	v1, first := l.v1, !l.started
+	l.mu.Unlock()
+
+	page, err := l.fetchThrottled(ctx, v1)
+	if err != nil && !v1 && first && IsNotImplemented(err) {
+		l.client.Options.ListAPIs.SetV1Only(l.client.endpoint())
+		l.mu.Lock()
+		l.v1 = true
+		l.mu.Unlock()
+		page, err = l.fetchThrottled(ctx, true)
+	}
+	if err != nil {
+		return nil, &OpError{Op: "Listing objects", Bucket: l.bucket, Key: l.prefix, Err: err}
+	}
+
+	var objects []S3Object
+
+	// Add common prefixes (folders)
+	encoded := page.encoded
+	for _, cp := range page.prefixes {
+		key := decodeKey(aws.ToString(cp.Prefix), encoded)
+		if !strings.HasPrefix(key, l.prefix) {
+			continue
+		}
+		objects = append(objects, S3Object{
+			Key:      key,
+			IsPrefix: true,
+		})
+	}
+
+	// Add objects (files)
+	for _, obj := range page.contents {
+		key := decodeKey(aws.ToString(obj.Key), encoded)
+		// Skip the prefix itself if it appears as an object, and anything
+		// outside a partial prefix that was filtered client-side
+		if key == l.prefix || !strings.HasPrefix(key, l.prefix) {
+			continue
+		}
+		objects = append(objects, S3Object{
+			Key:          key,
+			Size:         aws.ToInt64(obj.Size),
+			LastModified: aws.ToTime(obj.LastModified),
+			ETag:         strings.Trim(aws.ToString(obj.ETag), "\""),
+			StorageClass: GetStorageClass(types.StorageClass(obj.StorageClass)),
+			IsPrefix:     false,
+		})
+	}
+
+	return objects, nil
+}
+
+// listThrottleAttempts is how many times NextPage requests a page that S3
+// keeps throttling before giving up
+const listThrottleAttempts = 4
+
+// fetchThrottled is fetch, retried with backoff while S3 throttles it. Each
+// throttled attempt halves the page size first.
+func (l *Lister) fetchThrottled(ctx context.Context, v1 bool) (listPage, error) {
+	delay := retryBaseDelay
+	for attempt := 1; ; attempt++ {
+		page, err := l.fetch(ctx, v1)
+		if err == nil || !IsThrottled(err) {
+			return page, err
+		}
+		l.mu.Lock()
+		l.pageSize = max(l.pageSize/2, MinPageSize)
+		l.mu.Unlock()
+		if attempt == listThrottleAttempts {
+			return page, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return listPage{}, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// listPage is one page of a listing from either API
+type listPage struct {
+	contents []types.Object
+	prefixes []types.CommonPrefix
+	encoded  bool
+}
+
+// fetch requests the next page with ListObjectsV2, or ListObjects when v1
+// is set, and advances the pagination state
+func (l *Lister) fetch(ctx context.Context, v1 bool) (listPage, error) {
+	lists := l.client.Options.ListSemaphore
+	if err := lists.Acquire(ctx); err != nil {
+		return listPage{}, err
+	}
+	defer lists.Release()
+
+	l.mu.Lock()
+	var delimiter *string
+	if l.delimiter != "" {
+		delimiter = aws.String(l.delimiter)
+	}
+	bucket, prefix, maxKeys := aws.String(l.bucket), aws.String(l.listPrefix), aws.Int32(int32(l.pageSize))
+	token, marker := l.token, l.marker
+	l.mu.Unlock()
+
+	// Keys with characters XML can't carry (e.g. control bytes) would
+	// otherwise break the response; decodeKey undoes the encoding
+	var page listPage
+	if v1 {
+		output, err := l.client.S3.ListObjects(ctx, &s3.ListObjectsInput{
+			Bucket:       bucket,
+			Prefix:       prefix,
+			Delimiter:    delimiter,
+			MaxKeys:      maxKeys,
+			Marker:       marker,
+			EncodingType: types.EncodingTypeUrl,
+		})
+		if err != nil {
+			return listPage{}, err
+		}
+		page = listPage{contents: output.Contents, prefixes: output.CommonPrefixes, encoded: output.EncodingType == types.EncodingTypeUrl}
+		marker = nextMarker(output, page.encoded)
+		l.mu.Lock()
+		l.marker = marker
+		l.done = !aws.ToBool(output.IsTruncated) || marker == nil
+	} else {
+		output, err := l.client.S3.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+			Bucket:            bucket,
+			Prefix:            prefix,
+			Delimiter:         delimiter,
+			MaxKeys:           maxKeys,
+			ContinuationToken: token,
+			EncodingType:      types.EncodingTypeUrl,
+		})
+		if err != nil {
+			return listPage{}, err
+		}
+		page = listPage{contents: output.Contents, prefixes: output.CommonPrefixes, encoded: output.EncodingType == types.EncodingTypeUrl}
+		l.mu.Lock()
+		l.token = output.NextContinuationToken
+		l.done = !aws.ToBool(output.IsTruncated) || l.token == nil
+	}
+	l.pageSize = min(l.pageSize*2, l.ceiling)
+	l.started = true
+	l.mu.Unlock()
+	return page, nil
+}
+
+// nextMarker returns where the next V1 page starts. S3 only sends
+// NextMarker when a delimiter is set; otherwise it is the last key listed,
+// or the last common prefix if that sorts later.
+func nextMarker(output *s3.ListObjectsOutput, encoded bool) *string {
+	if m := aws.ToString(output.NextMarker); m != "" {
+		return aws.String(decodeKey(m, encoded))
+	}
+	var last string
+	if n := len(output.Contents); n > 0 {
+		last = decodeKey(aws.ToString(output.Contents[n-1].Key), encoded)
+	}
+	if n := len(output.CommonPrefixes); n > 0 {
+		last = max(last, decodeKey(aws.ToString(output.CommonPrefixes[n-1].Prefix), encoded))
+	}
+	if last == "" {
+		return nil
+	}
+	return aws.String(last)
+}
+
+// decodeKey undoes the URL encoding S3 applies to keys when a listing asks
+// for EncodingType=url. S3 encodes like a query string, so a space arrives
+// as "+" and a literal "+" as "%2B". A key that doesn't decode is returned
+// as-is.
+func decodeKey(key string, encoded bool) string {
+	if !encoded {
+		return key
+	}
+	decoded, err := url.QueryUnescape(key)
+	if err != nil {
+		return key
+	}
+	return decoded
+}
@@ -0,0 +1,378 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/smithy-go"
+)
+
+func newListerFake(n int) *fakeS3 {
+	fake := newFakeS3()
+	for i := 0; i < n; i++ {
+		fake.put("b", fmt.Sprintf("logs/%03d.txt", i), fakeObject{body: []byte("x")})
+	}
+	return fake
+}
+
+func TestClampPageSize(t *testing.T) {
+	tests := []struct{ in, want int }{
+		{0, DefaultPageSize},
+		{-5, 1},
+		{1, 1},
+		{500, 500},
+		{1000, 1000},
+		{1001, 1000},
+		{100000, 1000},
+	}
+	for _, tt := range tests {
+		if got := ClampPageSize(tt.in); got != tt.want {
+			t.Errorf("ClampPageSize(%d) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+
+	client := &Client{S3: newFakeS3(), Options: ClientOptions{PageSize: 5000}}
+	if got := client.NewLister("b", "", "/").PageSize(); got != MaxPageSize {
+		t.Errorf("lister page size = %d, want %d", got, MaxPageSize)
+	}
+}
+
+func TestListerPageSizeChangeMidStream(t *testing.T) {
+	fake := newListerFake(10)
+	client := &Client{S3: fake, Options: ClientOptions{PageSize: 3}}
+	lister := client.NewLister("b", "logs/", "")
+
+	first, err := lister.NextPage(context.Background())
+	if err != nil {
+		t.Fatalf("NextPage() error = %v", err)
+	}
+	if len(first) != 3 {
+		t.Fatalf("first page has %d objects, want 3", len(first))
+	}
+
+	lister.SetPageSize(5)
+
+	second, err := lister.NextPage(context.Background())
+	if err != nil {
+		t.Fatalf("NextPage() error = %v", err)
+	}
+	if len(second) != 5 {
+		t.Errorf("second page has %d objects, want 5", len(second))
+	}
+
+	// The second request used the new size and resumed from the first page's token
+	req := fake.listInputs[1]
+	if aws.ToInt32(req.MaxKeys) != 5 {
+		t.Errorf("second request MaxKeys = %d, want 5", aws.ToInt32(req.MaxKeys))
+	}
+	if aws.ToString(req.ContinuationToken) != "logs/002.txt" {
+		t.Errorf("second request token = %q, want logs/002.txt", aws.ToString(req.ContinuationToken))
+	}
+	if second[0].Key != "logs/003.txt" {
+		t.Errorf("second page starts at %q, want logs/003.txt", second[0].Key)
+	}
+
+	// Drain the rest: 10 keys total, none repeated
+	seen := make(map[string]bool)
+	for _, o := range append(first, second...) {
+		seen[o.Key] = true
+	}
+	for lister.HasMorePages() {
+		page, err := lister.NextPage(context.Background())
+		if err != nil {
+			t.Fatalf("NextPage() error = %v", err)
+		}
+		for _, o := range page {
+			if seen[o.Key] {
+				t.Errorf("key %q listed twice", o.Key)
+			}
+			seen[o.Key] = true
+		}
+	}
+	if len(seen) != 10 {
+		t.Errorf("listed %d keys, want 10", len(seen))
+	}
+}
+
+func TestListObjectsUsesConfiguredPageSize(t *testing.T) {
+	fake := newListerFake(7)
+	client := &Client{S3: fake, Options: ClientOptions{PageSize: 2}}
+
+	objects, err := client.ListAllObjects(context.Background(), "b", "logs/")
+	if err != nil {
+		t.Fatalf("ListAllObjects() error = %v", err)
+	}
+	if len(objects) != 7 {
+		t.Errorf("got %d objects, want 7", len(objects))
+	}
+	if len(fake.listInputs) != 4 {
+		t.Errorf("made %d list requests, want 4", len(fake.listInputs))
+	}
+}
+
+func TestListerBacksOffWhenThrottled(t *testing.T) {
+	defer func(d time.Duration) { retryBaseDelay = d }(retryBaseDelay)
+	retryBaseDelay = 0
+
+	fake := newListerFake(50)
+	client := &Client{S3: fake, Options: ClientOptions{PageSize: 8}}
+	lister := client.NewLister("b", "logs/", "")
+
+	throttle := 2
+	fake.errFor = func(op, key string) error {
+		if op == "ListObjectsV2" && throttle > 0 {
+			throttle--
+			return &smithy.GenericAPIError{Code: "SlowDown"}
+		}
+		return nil
+	}
+
+	// Each SlowDown halves the page size and the page is retried, so the
+	// first page arrives at a quarter of the size
+	var sizes []int
+	for range 4 {
+		page, err := lister.NextPage(context.Background())
+		if err != nil {
+			t.Fatalf("NextPage() error = %v", err)
+		}
+		sizes = append(sizes, len(page))
+	}
+	if n := fake.countCalls("ListObjectsV2"); n != 6 {
+		t.Errorf("made %d ListObjectsV2 calls, want 6", n)
+	}
+
+	// Successes double it back up to the configured ceiling and no further
+	if want := []int{2, 4, 8, 8}; !slices.Equal(sizes, want) {
+		t.Errorf("page sizes after recovery = %v, want %v", sizes, want)
+	}
+	if got := lister.PageSize(); got != 8 {
+		t.Errorf("page size = %d, want the ceiling 8", got)
+	}
+}
+
+func TestListerGivesUpWhenAlwaysThrottled(t *testing.T) {
+	defer func(d time.Duration) { retryBaseDelay = d }(retryBaseDelay)
+	retryBaseDelay = 0
+
+	fake := newListerFake(50)
+	fake.errFor = func(op, key string) error {
+		return &smithy.GenericAPIError{Code: "SlowDown"}
+	}
+	client := &Client{S3: fake, Options: ClientOptions{PageSize: 100}}
+	lister := client.NewLister("b", "logs/", "")
+
+	if _, err := lister.NextPage(context.Background()); !IsThrottled(err) {
+		t.Fatalf("NextPage() error = %v, want a throttling error", err)
+	}
+	if n := fake.countCalls("ListObjectsV2"); n != listThrottleAttempts {
+		t.Errorf("made %d ListObjectsV2 calls, want %d", n, listThrottleAttempts)
+	}
+	if got := lister.PageSize(); got != 100>>listThrottleAttempts {
+		t.Errorf("page size = %d, want %d", got, 100>>listThrottleAttempts)
+	}
+}
+
+func TestListAllObjectsRetriesThrottledPage(t *testing.T) {
+	defer func(d time.Duration) { retryBaseDelay = d }(retryBaseDelay)
+	retryBaseDelay = 0
+
+	fake := newListerFake(50)
+	throttled := false
+	fake.errFor = func(op, key string) error {
+		if op == "ListObjectsV2" && !throttled {
+			throttled = true
+			return &smithy.GenericAPIError{Code: "SlowDown"}
+		}
+		return nil
+	}
+	client := &Client{S3: fake, Options: ClientOptions{PageSize: 20}}
+
+	objects, err := client.ListAllObjects(context.Background(), "b", "logs/")
+	if err != nil {
+		t.Fatalf("ListAllObjects() error = %v", err)
+	}
+	if len(objects) != 50 {
+		t.Errorf("ListAllObjects() = %d objects, want 50", len(objects))
+	}
+}
+
+func TestListerIgnoresOtherErrorsForPageSize(t *testing.T) {
+	fake := newListerFake(5)
+	fake.errFor = func(op, key string) error {
+		return &smithy.GenericAPIError{Code: "AccessDenied"}
+	}
+	client := &Client{S3: fake, Options: ClientOptions{PageSize: 100}}
+	lister := client.NewLister("b", "logs/", "")
+
+	if _, err := lister.NextPage(context.Background()); err == nil {
+		t.Fatal("expected an error")
+	}
+	if got := lister.PageSize(); got != 100 {
+		t.Errorf("page size = %d, want 100 after a non-throttling error", got)
+	}
+}
+
+func TestListerDirectoryBucketPartialPrefix(t *testing.T) {
+	const bucket = "logs--usw2-az1--x-s3"
+	fake := newFakeS3()
+	for _, key := range []string{"app/2024-01.log", "app/2024-02.log", "app/2025-01.log", "app/archive/old.log"} {
+		fake.put(bucket, key, fakeObject{body: []byte("x")})
+	}
+	client := &Client{S3: fake}
+
+	objects, err := client.ListObjects(context.Background(), bucket, "app/2024")
+	if err != nil {
+		t.Fatalf("ListObjects() error = %v", err)
+	}
+
+	// Directory buckets only accept whole-folder prefixes
+	if got := aws.ToString(fake.listInputs[0].Prefix); got != "app/" {
+		t.Errorf("requested prefix = %q, want app/", got)
+	}
+	var keys []string
+	for _, o := range objects {
+		keys = append(keys, o.Key)
+	}
+	if want := []string{"app/2024-01.log", "app/2024-02.log"}; !slices.Equal(keys, want) {
+		t.Errorf("keys = %v, want %v", keys, want)
+	}
+
+	// General purpose buckets still send the prefix as typed
+	fake.put("b", "app/2024-01.log", fakeObject{body: []byte("x")})
+	if _, err := client.ListObjects(context.Background(), "b", "app/2024"); err != nil {
+		t.Fatalf("ListObjects() error = %v", err)
+	}
+	if got := aws.ToString(fake.listInputs[1].Prefix); got != "app/2024" {
+		t.Errorf("requested prefix = %q, want app/2024", got)
+	}
+}
+
+func TestListerDecodesURLEncodedKeys(t *testing.T) {
+	keys := []string{
+		"docs/annual report.pdf",
+		"docs/c++ notes+draft.txt",
+		"docs/日本語/ファイル.txt",
+		"docs/100% done?.txt",
+	}
+	fake := newFakeS3()
+	for _, k := range keys {
+		fake.put("b", k, fakeObject{body: []byte("x")})
+	}
+	client := &Client{S3: fake}
+
+	objects, err := client.ListObjects(context.Background(), "b", "docs/")
+	if err != nil {
+		t.Fatalf("ListObjects() error = %v", err)
+	}
+	if got := fake.listInputs[0].EncodingType; got != "url" {
+		t.Errorf("request EncodingType = %q, want url", got)
+	}
+
+	var got []string
+	for _, o := range objects {
+		got = append(got, o.Key)
+	}
+	slices.Sort(got)
+	want := []string{"docs/100% done?.txt", "docs/annual report.pdf", "docs/c++ notes+draft.txt", "docs/日本語/"}
+	if !slices.Equal(got, want) {
+		t.Errorf("keys = %q, want %q", got, want)
+	}
+
+	// Decoded keys round-trip: each one is found again when listed recursively
+	all, err := client.ListAllObjects(context.Background(), "b", "docs/")
+	if err != nil {
+		t.Fatalf("ListAllObjects() error = %v", err)
+	}
+	for _, o := range all {
+		if _, ok := fake.get("b", o.Key); !ok {
+			t.Errorf("listed key %q does not name a stored object", o.Key)
+		}
+	}
+	if len(all) != len(keys) {
+		t.Errorf("recursive listing found %d keys, want %d", len(all), len(keys))
+	}
+}
+
+func TestListerFallsBackToListObjectsV1(t *testing.T) {
+	fake := newListerFake(7)
+	fake.put("b", "logs/a b/1.txt", fakeObject{body: []byte("x")})
+	fake.put("b", "logs/a b/2.txt", fakeObject{body: []byte("x")})
+	fake.put("b", "logs/z.txt", fakeObject{body: []byte("x")})
+	fake.noListV2 = true
+	client := &Client{S3: fake, Options: ClientOptions{PageSize: 3, ListAPIs: NewListAPICache()}}
+
+	drain := func(delimiter string) []string {
+		t.Helper()
+		var keys []string
+		lister := client.NewLister("b", "logs/", delimiter)
+		for lister.HasMorePages() {
+			page, err := lister.NextPage(context.Background())
+			if err != nil {
+				t.Fatalf("NextPage() error = %v", err)
+			}
+			for _, o := range page {
+				keys = append(keys, o.Key)
+			}
+		}
+		return keys
+	}
+
+	// Recursive listings page by the last key; delimited ones by NextMarker
+	recursive := drain("")
+	if len(recursive) != 10 || !slices.IsSorted(recursive) || len(slices.Compact(slices.Clone(recursive))) != 10 {
+		t.Errorf("recursive V1 listing = %v, want 10 distinct keys in order", recursive)
+	}
+	grouped := drain("/")
+	if len(grouped) != 9 || !slices.Contains(grouped, "logs/a b/") || !slices.Contains(grouped, "logs/z.txt") {
+		t.Errorf("delimited V1 listing = %v, want 7 files, one folder and z.txt", grouped)
+	}
+
+	// Only the first lister tried V2; the endpoint is remembered as V1-only
+	if n := fake.countCalls("ListObjectsV2"); n != 1 {
+		t.Errorf("ListObjectsV2 calls = %d, want 1", n)
+	}
+	if n := fake.countCalls("ListObjects"); n != 7 {
+		t.Errorf("ListObjects calls = %d, want 7 (4 recursive pages, 3 delimited)", n)
+	}
+}
+
+func TestListerKeepsV2ErrorsOtherThanNotImplemented(t *testing.T) {
+	fake := newListerFake(3)
+	fake.errFor = func(op, key string) error {
+		if op == "ListObjectsV2" {
+			return &smithy.GenericAPIError{Code: "AccessDenied"}
+		}
+		return nil
+	}
+	cache := NewListAPICache()
+	client := &Client{S3: fake, Options: ClientOptions{ListAPIs: cache}}
+
+	if _, err := client.NewLister("b", "logs/", "").NextPage(context.Background()); !IsAccessDenied(err) {
+		t.Fatalf("NextPage() error = %v, want AccessDenied", err)
+	}
+	if fake.countCalls("ListObjects") != 0 || cache.V1Only(client.endpoint()) {
+		t.Error("AccessDenied must not switch the endpoint to V1")
+	}
+}
+
+func TestDecodeKey(t *testing.T) {
+	tests := []struct {
+		in      string
+		encoded bool
+		want    string
+	}{
+		{"a+b%2Bc", true, "a b+c"},
+		{"%E6%97%A5%E6%9C%AC.txt", true, "日本.txt"},
+		{"a+b", false, "a+b"},
+		{"bad%zzkey", true, "bad%zzkey"}, // not valid encoding: left alone
+	}
+	for _, tt := range tests {
+		if got := decodeKey(tt.in, tt.encoded); got != tt.want {
+			t.Errorf("decodeKey(%q, %v) = %q, want %q", tt.in, tt.encoded, got, tt.want)
+		}
+	}
+}
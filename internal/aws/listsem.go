@@ -0,0 +1,52 @@
+package aws
+
+import "context"
+
+// DefaultMaxConcurrentLists caps ListObjectsV2 requests in flight app-wide
+const DefaultMaxConcurrentLists = 8
+
+// ListSemaphore caps the ListObjectsV2 requests in flight across every
+// client that shares it, so features listing at once (cache warming,
+// audits, recursive downloads) don't add up to S3 SlowDown errors. A nil
+// *ListSemaphore doesn't limit anything.
+type ListSemaphore struct {
+	slots chan struct{}
+}
+
+// NewListSemaphore allows n listing requests at once; n <= 0 returns nil,
+// which is unlimited
+func NewListSemaphore(n int) *ListSemaphore {
+	if n <= 0 {
+		return nil
+	}
+	return &ListSemaphore{slots: make(chan struct{}, n)}
+}
+
+// Acquire waits for a free slot, or returns the context's error
+func (s *ListSemaphore) Acquire(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Release frees a slot taken by Acquire
+func (s *ListSemaphore) Release() {
+	if s == nil {
+		return
+	}
+	<-s.slots
+}
+
+// Limit returns the number of requests allowed at once, or 0 for unlimited
+func (s *ListSemaphore) Limit() int {
+	if s == nil {
+		return 0
+	}
+	return cap(s.slots)
+}
@@ -0,0 +1,109 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// slowLister is a fakeS3 whose listings take a while and record how many
+// were in flight at once
+type slowLister struct {
+	*fakeS3
+	inFlight atomic.Int32
+	peak     atomic.Int32
+}
+
+func (s *slowLister) ListObjectsV2(ctx context.Context, in *s3.ListObjectsV2Input, opts ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	n := s.inFlight.Add(1)
+	defer s.inFlight.Add(-1)
+	for {
+		peak := s.peak.Load()
+		if n <= peak || s.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+	return s.fakeS3.ListObjectsV2(ctx, in, opts...)
+}
+
+func TestListSemaphoreCapsListingAcrossFeatures(t *testing.T) {
+	const limit = 3
+	fake := &slowLister{fakeS3: newFakeS3()}
+	for i := range 6 {
+		for j := range 4 {
+			fake.put("b", fmt.Sprintf("p%d/obj%d.txt", i, j), fakeObject{body: []byte("x")})
+		}
+	}
+
+	// Two clients sharing one semaphore, as WithRegion clients do
+	lists := NewListSemaphore(limit)
+	browse := &Client{S3: fake, Options: ClientOptions{ListSemaphore: lists}}
+	recursive := &Client{S3: fake, Options: ClientOptions{ListSemaphore: lists, PageSize: 1}}
+
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	errs := make(chan error, 12)
+	for i := range 6 {
+		prefix := fmt.Sprintf("p%d/", i)
+		wg.Add(2)
+		// Browsing (e.g. cache warming) lists one level at a time
+		go func() {
+			defer wg.Done()
+			_, err := browse.ListObjects(ctx, "b", prefix)
+			errs <- err
+		}()
+		// Recursive listings page through everything, one key per page here
+		go func() {
+			defer wg.Done()
+			_, err := recursive.ListAllObjects(ctx, "b", prefix)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("listing error = %v", err)
+		}
+	}
+	if peak := fake.peak.Load(); peak > limit {
+		t.Errorf("%d listings in flight at once, want at most %d", peak, limit)
+	}
+	if fake.countCalls("ListObjectsV2") < 12 {
+		t.Errorf("made %d listing calls, want every listing to run", fake.countCalls("ListObjectsV2"))
+	}
+}
+
+func TestListSemaphoreHonorsContext(t *testing.T) {
+	lists := NewListSemaphore(1)
+	if err := lists.Acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer lists.Release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := lists.Acquire(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Acquire() on a full semaphore = %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestNilListSemaphoreIsUnlimited(t *testing.T) {
+	lists := NewListSemaphore(0)
+	if lists != nil || lists.Limit() != 0 {
+		t.Fatalf("NewListSemaphore(0) = %v, want nil", lists)
+	}
+	for range 100 {
+		if err := lists.Acquire(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lists.Release()
+}
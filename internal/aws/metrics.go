@@ -0,0 +1,159 @@
+package aws
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aws/smithy-go/middleware"
+)
+
+// Metrics counts the S3 traffic of a session. It is safe for concurrent use,
+// and a nil *Metrics ignores all updates.
+type Metrics struct {
+	mu        sync.Mutex
+	requests  map[string]int64
+	errors    map[string]int64
+	retries   int64
+	bytesUp   int64
+	bytesDown int64
+}
+
+// MetricsSnapshot is a point-in-time copy of Metrics
+type MetricsSnapshot struct {
+	// Requests counts calls per S3 operation, not counting retries
+	Requests map[string]int64
+	// Errors counts calls per operation that failed after all attempts
+	Errors    map[string]int64
+	Retries   int64
+	BytesUp   int64
+	BytesDown int64
+}
+
+// TotalRequests sums requests across operations
+func (s MetricsSnapshot) TotalRequests() int64 {
+	var n int64
+	for _, v := range s.Requests {
+		n += v
+	}
+	return n
+}
+
+// TotalErrors sums errors across operations
+func (s MetricsSnapshot) TotalErrors() int64 {
+	var n int64
+	for _, v := range s.Errors {
+		n += v
+	}
+	return n
+}
+
+// NewMetrics creates an empty collector
+func NewMetrics() *Metrics {
+	return &Metrics{
+		requests: make(map[string]int64),
+		errors:   make(map[string]int64),
+	}
+}
+
+// RecordRequest counts one call to op and, if err is set, one error
+func (m *Metrics) RecordRequest(op string, err error) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[op]++
+	if err != nil {
+		m.errors[op]++
+	}
+}
+
+// RecordRetry counts an attempt after the first
+func (m *Metrics) RecordRetry() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.retries++
+}
+
+// AddBytesUp counts uploaded bytes
+func (m *Metrics) AddBytesUp(n int64) {
+	if m == nil || n <= 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.bytesUp += n
+}
+
+// AddBytesDown counts downloaded bytes
+func (m *Metrics) AddBytesDown(n int64) {
+	if m == nil || n <= 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.bytesDown += n
+}
+
+// Snapshot returns a copy of the current counters
+func (m *Metrics) Snapshot() MetricsSnapshot {
+	s := MetricsSnapshot{
+		Requests: make(map[string]int64),
+		Errors:   make(map[string]int64),
+	}
+	if m == nil {
+		return s
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for op, n := range m.requests {
+		s.Requests[op] = n
+	}
+	for op, n := range m.errors {
+		s.Errors[op] = n
+	}
+	s.Retries = m.retries
+	s.BytesUp = m.bytesUp
+	s.BytesDown = m.bytesDown
+	return s
+}
+
+// attemptsKey holds the per-call attempt counter in the request context
+type attemptsKey struct{}
+
+// AddMiddleware registers the collector on an SDK middleware stack. Calls are
+// counted once in the initialize step; attempts are counted after the SDK
+// retryer so retries are seen separately.
+func (m *Metrics) AddMiddleware(stack *middleware.Stack) error {
+	count := middleware.InitializeMiddlewareFunc("stui.Metrics",
+		func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+			attempts := 0
+			ctx = context.WithValue(ctx, attemptsKey{}, &attempts)
+			out, md, err := next.HandleInitialize(ctx, in)
+			m.RecordRequest(middleware.GetOperationName(ctx), err)
+			return out, md, err
+		})
+	if err := stack.Initialize.Add(count, middleware.Before); err != nil {
+		return err
+	}
+
+	attempt := middleware.FinalizeMiddlewareFunc("stui.MetricsAttempt",
+		func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+			if attempts, ok := ctx.Value(attemptsKey{}).(*int); ok {
+				*attempts++
+				if *attempts > 1 {
+					m.RecordRetry()
+				}
+			}
+			return next.HandleFinalize(ctx, in)
+		})
+	// Sit after the retry middleware so each attempt passes through
+	if err := stack.Finalize.Insert(attempt, "Retry", middleware.After); err != nil {
+		return stack.Finalize.Add(attempt, middleware.After)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go/middleware"
+)
+
+func TestMetricsConcurrent(t *testing.T) {
+	m := NewMetrics()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var err error
+			if i%10 == 0 {
+				err = errors.New("boom")
+			}
+			m.RecordRequest("GetObject", err)
+			m.RecordRequest("HeadObject", nil)
+			m.AddBytesDown(100)
+			m.AddBytesUp(10)
+		}(i)
+	}
+	wg.Wait()
+
+	s := m.Snapshot()
+	if s.Requests["GetObject"] != 50 || s.Requests["HeadObject"] != 50 {
+		t.Errorf("requests = %v, want 50 each", s.Requests)
+	}
+	if s.TotalRequests() != 100 {
+		t.Errorf("TotalRequests() = %d, want 100", s.TotalRequests())
+	}
+	if s.Errors["GetObject"] != 5 || s.TotalErrors() != 5 {
+		t.Errorf("errors = %v, want 5 GetObject", s.Errors)
+	}
+	if s.BytesDown != 5000 || s.BytesUp != 500 {
+		t.Errorf("bytes down/up = %d/%d, want 5000/500", s.BytesDown, s.BytesUp)
+	}
+
+	// Snapshots are copies
+	s.Requests["GetObject"] = 0
+	if m.Snapshot().Requests["GetObject"] != 50 {
+		t.Error("snapshot shares state with the collector")
+	}
+}
+
+func TestMetricsNilSafe(t *testing.T) {
+	var m *Metrics
+	m.RecordRequest("GetObject", nil)
+	m.RecordRetry()
+	m.AddBytesDown(1)
+	if m.Snapshot().TotalRequests() != 0 {
+		t.Error("nil metrics reported requests")
+	}
+}
+
+// scriptedHTTP replies with the given status codes in order
+type scriptedHTTP struct {
+	mu       sync.Mutex
+	statuses []int
+}
+
+func (s *scriptedHTTP) Do(req *http.Request) (*http.Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := http.StatusOK
+	if len(s.statuses) > 0 {
+		status, s.statuses = s.statuses[0], s.statuses[1:]
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader("")),
+		Request:    req,
+	}, nil
+}
+
+func TestMetricsCountsRetriesSeparately(t *testing.T) {
+	m := NewMetrics()
+	httpClient := &scriptedHTTP{statuses: []int{http.StatusServiceUnavailable, http.StatusOK}}
+
+	client := s3.New(s3.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String("https://s3.test"),
+		UsePathStyle: true,
+		Credentials:  aws.AnonymousCredentials{},
+		HTTPClient:   httpClient,
+		Retryer: retry.NewStandard(func(o *retry.StandardOptions) {
+			o.MaxAttempts = 3
+			o.Backoff = retry.BackoffDelayerFunc(func(int, error) (time.Duration, error) { return 0, nil })
+		}),
+		APIOptions: []func(*middleware.Stack) error{m.AddMiddleware},
+	})
+
+	_, err := client.HeadBucket(context.Background(), &s3.HeadBucketInput{Bucket: aws.String("b")})
+	if err != nil {
+		t.Fatalf("HeadBucket() error = %v", err)
+	}
+
+	s := m.Snapshot()
+	if s.Requests["HeadBucket"] != 1 {
+		t.Errorf("requests = %v, want one HeadBucket", s.Requests)
+	}
+	if s.Retries != 1 {
+		t.Errorf("retries = %d, want 1", s.Retries)
+	}
+	if s.TotalErrors() != 0 {
+		t.Errorf("errors = %v, want none after a successful retry", s.Errors)
+	}
+}
@@ -0,0 +1,83 @@
+package aws
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// IncompleteUpload is a multipart upload that was started but never
+// completed or aborted. S3 bills for its parts until it is aborted.
+type IncompleteUpload struct {
+	Key       string
+	UploadID  string
+	Initiated time.Time
+}
+
+// ListIncompleteUploads returns the multipart uploads in progress under
+// prefix, following every page of ListMultipartUploads
+func (c *Client) ListIncompleteUploads(ctx context.Context, bucket, prefix string) ([]IncompleteUpload, error) {
+	var uploads []IncompleteUpload
+	var keyMarker, idMarker *string
+	for {
+		out, err := c.S3.ListMultipartUploads(ctx, &s3.ListMultipartUploadsInput{
+			Bucket:         aws.String(bucket),
+			Prefix:         aws.String(prefix),
+			KeyMarker:      keyMarker,
+			UploadIdMarker: idMarker,
+		})
+		if err != nil {
+			return nil, &OpError{Op: "Listing uploads", Bucket: bucket, Key: prefix, Err: err}
+		}
+		for _, up := range out.Uploads {
+			uploads = append(uploads, IncompleteUpload{
+				Key:       aws.ToString(up.Key),
+				UploadID:  aws.ToString(up.UploadId),
+				Initiated: aws.ToTime(up.Initiated),
+			})
+		}
+		if !aws.ToBool(out.IsTruncated) {
+			return uploads, nil
+		}
+		keyMarker, idMarker = out.NextKeyMarker, out.NextUploadIdMarker
+	}
+}
+
+// AbortIncompleteUploads aborts every multipart upload in bucket started
+// more than olderThan ago, deleting its parts, and returns how many were
+// aborted. Recent uploads are left alone since they may still be running.
+// Uploads that fail to abort are reported in a *PartialFailureError.
+func (c *Client) AbortIncompleteUploads(ctx context.Context, bucket string, olderThan time.Duration) (int, error) {
+	if err := c.checkWritable(bucket); err != nil {
+		return 0, err
+	}
+	uploads, err := c.ListIncompleteUploads(ctx, bucket, "")
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := time.Now().Add(-olderThan)
+	var results []ObjectResult
+	aborted := 0
+	for _, up := range uploads {
+		if !up.Initiated.Before(cutoff) {
+			continue
+		}
+		_, err := c.S3.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(bucket),
+			Key:      aws.String(up.Key),
+			UploadId: aws.String(up.UploadID),
+		})
+		switch {
+		case err == nil:
+			aborted++
+		case isNoSuchUpload(err):
+			// Finished or aborted since it was listed: nothing left to clean up
+			continue
+		}
+		results = append(results, ObjectResult{Key: up.Key, Err: err})
+	}
+	return aborted, batchError(results)
+}
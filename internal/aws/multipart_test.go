@@ -0,0 +1,125 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// startUpload begins a multipart upload in fake that looks age old
+func startUpload(t *testing.T, fake *fakeS3, bucket, key string, age time.Duration) string {
+	t.Helper()
+	out, err := fake.CreateMultipartUpload(context.Background(), &s3.CreateMultipartUploadInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := aws.ToString(out.UploadId)
+	fake.mu.Lock()
+	meta := fake.uploadMeta[id]
+	meta.modified = time.Now().Add(-age)
+	fake.uploadMeta[id] = meta
+	fake.mu.Unlock()
+	return id
+}
+
+func TestListIncompleteUploads(t *testing.T) {
+	fake := newFakeS3()
+	old := startUpload(t, fake, "b", "backups/db.tar", 72*time.Hour)
+	startUpload(t, fake, "b", "logs/app.log", time.Hour)
+	startUpload(t, fake, "other", "backups/x.tar", time.Hour)
+	client := &Client{S3: fake}
+
+	uploads, err := client.ListIncompleteUploads(context.Background(), "b", "backups/")
+	if err != nil {
+		t.Fatalf("ListIncompleteUploads() error = %v", err)
+	}
+	if len(uploads) != 1 {
+		t.Fatalf("got %d uploads, want 1: %+v", len(uploads), uploads)
+	}
+	up := uploads[0]
+	if up.Key != "backups/db.tar" || up.UploadID != old {
+		t.Errorf("upload = %+v", up)
+	}
+	if age := time.Since(up.Initiated); age < 71*time.Hour || age > 73*time.Hour {
+		t.Errorf("initiated %v ago, want about 72h", age)
+	}
+
+	all, err := client.ListIncompleteUploads(context.Background(), "b", "")
+	if err != nil || len(all) != 2 {
+		t.Errorf("ListIncompleteUploads(all) = %d uploads, %v; want 2", len(all), err)
+	}
+}
+
+func TestListIncompleteUploadsFollowsPages(t *testing.T) {
+	fake := newFakeS3()
+	for i := 0; i < 1005; i++ {
+		startUpload(t, fake, "b", fmt.Sprintf("k%04d", i), time.Hour)
+	}
+	client := &Client{S3: fake}
+
+	uploads, err := client.ListIncompleteUploads(context.Background(), "b", "")
+	if err != nil {
+		t.Fatalf("ListIncompleteUploads() error = %v", err)
+	}
+	if len(uploads) != 1005 {
+		t.Errorf("got %d uploads, want 1005", len(uploads))
+	}
+	if got := fake.countCalls("ListMultipartUploads"); got != 2 {
+		t.Errorf("made %d list requests, want 2", got)
+	}
+}
+
+func TestAbortIncompleteUploads(t *testing.T) {
+	fake := newFakeS3()
+	stale := startUpload(t, fake, "b", "a.bin", 8*24*time.Hour)
+	staler := startUpload(t, fake, "b", "b.bin", 30*24*time.Hour)
+	recent := startUpload(t, fake, "b", "c.bin", time.Hour)
+	elsewhere := startUpload(t, fake, "other", "d.bin", 30*24*time.Hour)
+	client := &Client{S3: fake}
+
+	n, err := client.AbortIncompleteUploads(context.Background(), "b", 7*24*time.Hour)
+	if err != nil {
+		t.Fatalf("AbortIncompleteUploads() error = %v", err)
+	}
+	if n != 2 {
+		t.Errorf("aborted %d uploads, want 2", n)
+	}
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	for id, want := range map[string]bool{stale: false, staler: false, recent: true, elsewhere: true} {
+		if _, live := fake.uploads[id]; live != want {
+			t.Errorf("upload %s live = %v, want %v", id, live, want)
+		}
+	}
+}
+
+func TestAbortIncompleteUploadsReportsFailures(t *testing.T) {
+	fake := newFakeS3()
+	startUpload(t, fake, "b", "a.bin", 48*time.Hour)
+	startUpload(t, fake, "b", "locked.bin", 48*time.Hour)
+	fake.errFor = func(op, key string) error {
+		if op == "AbortMultipartUpload" && key == "locked.bin" {
+			return errors.New("AccessDenied")
+		}
+		return nil
+	}
+	client := &Client{S3: fake}
+
+	n, err := client.AbortIncompleteUploads(context.Background(), "b", 24*time.Hour)
+	if n != 1 {
+		t.Errorf("aborted %d uploads, want 1", n)
+	}
+	var partial *PartialFailureError
+	if !errors.As(err, &partial) || len(partial.Failed) != 1 || partial.Failed[0].Key != "locked.bin" {
+		t.Errorf("AbortIncompleteUploads() error = %v, want locked.bin reported", err)
+	}
+}
@@ -0,0 +1,478 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/natevick/stui/internal/security"
+)
+
+// TrashPrefix is the bucket-root prefix stui reserves for soft-deleted objects
+const TrashPrefix = ".s3-tui-trash/"
+
+// ErrObjectExists is returned when a destination object already exists
+var ErrObjectExists = errors.New("destination object already exists")
+
+// ObjectExists reports whether an object exists at key
+func (c *Client) ObjectExists(ctx context.Context, bucket, key string) (bool, error) {
+	_, err := c.S3.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		if IsNotFound(err) {
+			return false, nil
+		}
+		return false, &OpError{Op: "Checking object", Bucket: bucket, Key: key, Err: err}
+	}
+	return true, nil
+}
+
+// RenameObject renames an object within a bucket by copying it to newKey and
+// deleting oldKey. Metadata and storage class are preserved. Unless overwrite
+// is set, an existing object at newKey is never replaced. Keys with "//",
+// "./" or "../" segments are refused with security.ErrAmbiguousKey.
+func (c *Client) RenameObject(ctx context.Context, bucket, oldKey, newKey string, overwrite bool) error {
+	if err := security.ValidNewObjectKey(newKey); err != nil {
+		return err
+	}
+	return c.MoveObject(ctx, bucket, oldKey, bucket, newKey, overwrite)
+}
+
+// MoveObject copies an object to dstBucket/dstKey and deletes the source.
+// Metadata and storage class are preserved. Unless overwrite is set, an
+// existing destination object is never replaced.
+func (c *Client) MoveObject(ctx context.Context, srcBucket, srcKey, dstBucket, dstKey string, overwrite bool) error {
+	if err := c.checkWritable(srcBucket); err != nil {
+		return err
+	}
+	if err := c.CopyObject(ctx, srcBucket, srcKey, dstBucket, dstKey, overwrite); err != nil {
+		return err
+	}
+
+	_, err := c.S3.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(srcBucket),
+		Key:    aws.String(srcKey),
+	})
+	if err != nil {
+		return fmt.Errorf("copied to new key but failed to delete original: %w", err)
+	}
+
+	return nil
+}
+
+// CopyObject server-side copies an object to dstBucket/dstKey, preserving
+// metadata and storage class. Unless overwrite is set, an existing
+// destination object is never replaced.
+func (c *Client) CopyObject(ctx context.Context, srcBucket, srcKey, dstBucket, dstKey string, overwrite bool) error {
+	if srcBucket == dstBucket && srcKey == dstKey {
+		return fmt.Errorf("new key is the same as the old key")
+	}
+	head, err := c.prepareCopy(ctx, srcBucket, srcKey, dstBucket, dstKey, overwrite)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.S3.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:            aws.String(dstBucket),
+		Key:               aws.String(dstKey),
+		CopySource:        aws.String(copySource(srcBucket, srcKey)),
+		MetadataDirective: types.MetadataDirectiveCopy,
+		StorageClass:      types.StorageClass(head.StorageClass),
+	})
+	if err != nil {
+		return &OpError{Op: "Copying object", Bucket: dstBucket, Key: dstKey, Err: err}
+	}
+
+	return nil
+}
+
+// TouchObject bumps an object's last-modified time by copying it onto itself.
+// Content, metadata, storage class, and KMS encryption are kept; in a
+// versioned bucket the copy becomes a new version.
+func (c *Client) TouchObject(ctx context.Context, bucket, key string) error {
+	head, err := c.prepareCopy(ctx, bucket, key, bucket, key, true)
+	if err != nil {
+		return err
+	}
+
+	// S3 refuses a self-copy that changes nothing, so the storage class is
+	// always sent explicitly; HeadObject omits it for STANDARD
+	storageClass := types.StorageClass(head.StorageClass)
+	if storageClass == "" {
+		storageClass = types.StorageClassStandard
+	}
+	input := &s3.CopyObjectInput{
+		Bucket:            aws.String(bucket),
+		Key:               aws.String(key),
+		CopySource:        aws.String(copySource(bucket, key)),
+		MetadataDirective: types.MetadataDirectiveCopy,
+		StorageClass:      storageClass,
+	}
+	// Without these the copy would fall back to the bucket's default encryption
+	if head.ServerSideEncryption == types.ServerSideEncryptionAwsKms {
+		input.ServerSideEncryption = head.ServerSideEncryption
+		input.SSEKMSKeyId = head.SSEKMSKeyId
+	}
+
+	if _, err := c.S3.CopyObject(ctx, input); err != nil {
+		return &OpError{Op: "Touching object", Bucket: bucket, Key: key, Err: err}
+	}
+	return nil
+}
+
+// MetaOverrides are the headers to change when copying an object. Empty
+// fields keep the source object's value.
+type MetaOverrides struct {
+	ContentType  string
+	CacheControl string
+	// Metadata is merged over the source's user metadata; an empty value removes that key
+	Metadata map[string]string
+}
+
+// CopyObjectWithMeta server-side copies an object while replacing its
+// headers. S3's REPLACE directive drops every header the request leaves out,
+// so the source's headers are carried over for anything not overridden.
+// Copying an object onto itself edits its metadata in place.
+func (c *Client) CopyObjectWithMeta(ctx context.Context, srcBucket, srcKey, dstBucket, dstKey string, meta MetaOverrides, overwrite bool) error {
+	if meta.ContentType != "" {
+		if err := ValidContentType(meta.ContentType); err != nil {
+			return err
+		}
+	}
+	inPlace := srcBucket == dstBucket && srcKey == dstKey
+	head, err := c.prepareCopy(ctx, srcBucket, srcKey, dstBucket, dstKey, overwrite || inPlace)
+	if err != nil {
+		return err
+	}
+
+	metadata := make(map[string]string, len(head.Metadata)+len(meta.Metadata))
+	for k, v := range head.Metadata {
+		metadata[k] = v
+	}
+	for k, v := range meta.Metadata {
+		if v == "" {
+			delete(metadata, k)
+		} else {
+			metadata[k] = v
+		}
+	}
+
+	_, err = c.S3.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:             aws.String(dstBucket),
+		Key:                aws.String(dstKey),
+		CopySource:         aws.String(copySource(srcBucket, srcKey)),
+		MetadataDirective:  types.MetadataDirectiveReplace,
+		StorageClass:       types.StorageClass(head.StorageClass),
+		ContentType:        override(meta.ContentType, head.ContentType),
+		CacheControl:       override(meta.CacheControl, head.CacheControl),
+		ContentDisposition: override("", head.ContentDisposition),
+		ContentEncoding:    override("", head.ContentEncoding),
+		ContentLanguage:    override("", head.ContentLanguage),
+		Metadata:           metadata,
+	})
+	if err != nil {
+		return &OpError{Op: "Copying object", Bucket: dstBucket, Key: dstKey, Err: err}
+	}
+
+	return nil
+}
+
+// override returns value if set, otherwise the source's header. An unset
+// header stays nil so it is left out of the request rather than sent blank.
+func override(value string, source *string) *string {
+	if value != "" {
+		return aws.String(value)
+	}
+	if aws.ToString(source) == "" {
+		return nil
+	}
+	return source
+}
+
+// ValidContentType checks that contentType is a well-formed type/subtype media type
+func ValidContentType(contentType string) error {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("invalid content type %q: %w", contentType, err)
+	}
+	if typ, sub, ok := strings.Cut(mediaType, "/"); !ok || typ == "" || sub == "" {
+		return fmt.Errorf("invalid content type %q: expected type/subtype", contentType)
+	}
+	return nil
+}
+
+// prepareCopy validates the destination and returns the source object's
+// headers. Unless overwrite is set, an existing destination is refused.
+func (c *Client) prepareCopy(ctx context.Context, srcBucket, srcKey, dstBucket, dstKey string, overwrite bool) (*s3.HeadObjectOutput, error) {
+	if err := c.checkWritable(dstBucket); err != nil {
+		return nil, err
+	}
+	if err := security.ValidObjectKey(dstKey); err != nil {
+		return nil, err
+	}
+
+	if !overwrite {
+		exists, err := c.ObjectExists(ctx, dstBucket, dstKey)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, ErrObjectExists
+		}
+	}
+
+	// Look up the storage class; CopyObject would otherwise reset it to STANDARD
+	head, err := c.S3.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(srcBucket),
+		Key:    aws.String(srcKey),
+	})
+	if err != nil {
+		return nil, &OpError{Op: "Reading object metadata", Bucket: srcBucket, Key: srcKey, Err: err}
+	}
+	return head, nil
+}
+
+// RenamePrefix moves every object under oldPrefix to the same relative key
+// under newPrefix, one copy-and-delete at a time. Every object is attempted
+// and reported in the results; if any fail, the returned error is a
+// *PartialFailureError and the objects already moved stay moved.
+func (c *Client) RenamePrefix(ctx context.Context, bucket, oldPrefix, newPrefix string) ([]ObjectResult, error) {
+	if err := c.checkWritable(bucket); err != nil {
+		return nil, err
+	}
+	if oldPrefix == "" || newPrefix == "" {
+		return nil, fmt.Errorf("prefixes must not be empty")
+	}
+	oldPrefix = ensureTrailingSlash(oldPrefix)
+	newPrefix = ensureTrailingSlash(newPrefix)
+	if oldPrefix == newPrefix {
+		return nil, fmt.Errorf("new prefix is the same as the old prefix")
+	}
+	if err := security.ValidNewObjectKey(newPrefix); err != nil {
+		return nil, err
+	}
+
+	// List everything up front so keys written under newPrefix aren't revisited
+	objects, err := c.listAll(ctx, c.NewLister(bucket, oldPrefix, ""), c.objectLimit(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]ObjectResult, 0, len(objects))
+	for _, obj := range objects {
+		if ctx.Err() != nil {
+			break
+		}
+		newKey := newPrefix + strings.TrimPrefix(obj.Key, oldPrefix)
+		err := c.MoveObject(ctx, bucket, obj.Key, bucket, newKey, false)
+		results = append(results, ObjectResult{Key: obj.Key, NewKey: newKey, Err: err})
+	}
+
+	if err := ctx.Err(); err != nil {
+		return results, err
+	}
+	return results, batchError(results)
+}
+
+// MoveSelection moves each key to destPrefix plus its base name, by
+// server-side copy and delete. onExists decides what happens when the
+// destination is taken: OnExistsSkip leaves both objects and reports an
+// empty NewKey, OnExistsRename picks "name (1).ext" and so on. Folders
+// (keys ending in "/") are refused; use RenamePrefix for those. Every key is
+// attempted; if any fail, the error is a *PartialFailureError.
+func (c *Client) MoveSelection(ctx context.Context, bucket string, keys []string, destPrefix string, onExists OnExists) ([]ObjectResult, error) {
+	if err := c.checkWritable(bucket); err != nil {
+		return nil, err
+	}
+	if destPrefix != "" {
+		destPrefix = ensureTrailingSlash(destPrefix)
+		if err := security.ValidNewObjectKey(destPrefix); err != nil {
+			return nil, err
+		}
+	}
+
+	u := c.NewUploader(onExists)
+	results := make([]ObjectResult, 0, len(keys))
+	for _, key := range keys {
+		if ctx.Err() != nil {
+			break
+		}
+		result := ObjectResult{Key: key}
+		newKey := destPrefix + path.Base(key)
+		switch {
+		case strings.HasSuffix(key, "/"):
+			result.Err = fmt.Errorf("%s is a folder; rename it instead", key)
+		case newKey == key:
+			result.NewKey = key
+		default:
+			target, skip, err := u.resolveKey(ctx, bucket, newKey)
+			switch {
+			case err != nil:
+				result.Err = err
+			case skip:
+			default:
+				result.NewKey = target
+				result.Err = c.MoveObject(ctx, bucket, key, bucket, target, onExists == OnExistsOverwrite)
+			}
+		}
+		results = append(results, result)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return results, err
+	}
+	return results, batchError(results)
+}
+
+// ensureTrailingSlash appends "/" to a prefix that lacks one
+func ensureTrailingSlash(prefix string) string {
+	if strings.HasSuffix(prefix, "/") {
+		return prefix
+	}
+	return prefix + "/"
+}
+
+// maxDeleteBatch is the most keys a single DeleteObjects request accepts
+const maxDeleteBatch = 1000
+
+// DeleteFailure describes a key S3 refused to delete
+type DeleteFailure struct {
+	Key     string
+	Message string
+}
+
+// DeleteObjects deletes keys from bucket in batches of up to 1000.
+// Keys S3 rejects individually are returned as failures; the error is
+// reserved for requests that fail outright. Deleted keys are recorded in
+// the client's Journal, if it has one.
+func (c *Client) DeleteObjects(ctx context.Context, bucket string, keys []string) ([]DeleteFailure, error) {
+	if err := c.checkWritable(bucket); err != nil {
+		return nil, err
+	}
+
+	journal := c.Options.Journal
+	var failures []DeleteFailure
+	for start := 0; start < len(keys); start += maxDeleteBatch {
+		end := min(start+maxDeleteBatch, len(keys))
+
+		ids := make([]types.ObjectIdentifier, 0, end-start)
+		for _, key := range keys[start:end] {
+			ids = append(ids, types.ObjectIdentifier{Key: aws.String(key)})
+		}
+
+		output, err := c.S3.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(bucket),
+			Delete: &types.Delete{
+				Objects: ids,
+				// Verbose responses carry the delete marker version IDs
+				Quiet: aws.Bool(journal == nil),
+			},
+		})
+		if err != nil {
+			return failures, &OpError{Op: "Deleting objects", Bucket: bucket, Err: err}
+		}
+
+		for _, e := range output.Errors {
+			failures = append(failures, DeleteFailure{
+				Key:     aws.ToString(e.Key),
+				Message: aws.ToString(e.Message),
+			})
+		}
+
+		if journal != nil {
+			now := time.Now()
+			for _, d := range output.Deleted {
+				entry := DeletionEntry{Bucket: bucket, Key: aws.ToString(d.Key), When: now}
+				if aws.ToBool(d.DeleteMarker) {
+					entry.VersionID = aws.ToString(d.DeleteMarkerVersionId)
+				}
+				journal.Record(entry)
+			}
+		}
+	}
+
+	return failures, nil
+}
+
+// ErrBucketAlreadyOwned is returned by CreateBucket when the caller already
+// owns the bucket; callers that only need it to exist can treat it as success
+var ErrBucketAlreadyOwned = errors.New("you already own this bucket")
+
+// ErrBucketNameTaken is returned by CreateBucket when another account owns a
+// bucket with that name. Bucket names are global, so a different one is needed.
+var ErrBucketNameTaken = errors.New("bucket name is taken by another account")
+
+// ErrRegionMismatch is returned by CreateBucket when S3 rejects the
+// requested region because the client's endpoint is in a different one
+var ErrRegionMismatch = errors.New("requested bucket region conflicts with the endpoint's region")
+
+// CreateBucket creates a bucket in region; empty uses the client's region.
+// An existing bucket fails with ErrBucketAlreadyOwned or ErrBucketNameTaken,
+// and a region the endpoint won't create in with ErrRegionMismatch, each
+// wrapped with the S3 error.
+func (c *Client) CreateBucket(ctx context.Context, bucket, region string) error {
+	if err := c.checkWritable(bucket); err != nil {
+		return err
+	}
+	if err := security.ValidBucketName(bucket); err != nil {
+		return err
+	}
+	if region == "" {
+		region = c.Region
+	}
+
+	input := &s3.CreateBucketInput{Bucket: aws.String(bucket)}
+	// us-east-1 is the default location and S3 rejects it as a constraint
+	if region != "" && region != "us-east-1" {
+		input.CreateBucketConfiguration = &types.CreateBucketConfiguration{
+			LocationConstraint: types.BucketLocationConstraint(region),
+		}
+	}
+	if _, err := c.S3.CreateBucket(ctx, input); err != nil {
+		switch {
+		case isBucketAlreadyOwned(err):
+			err = fmt.Errorf("%w: %w", ErrBucketAlreadyOwned, err)
+		case isBucketNameTaken(err):
+			err = fmt.Errorf("%w: %w", ErrBucketNameTaken, err)
+		case isIllegalLocationConstraint(err):
+			err = fmt.Errorf("%w: %w", ErrRegionMismatch, err)
+		}
+		return &OpError{Op: "Creating bucket", Bucket: bucket, Err: err}
+	}
+	c.Options.BucketRegions.Set(bucket, region)
+	return nil
+}
+
+// DeleteBucket deletes an empty bucket
+func (c *Client) DeleteBucket(ctx context.Context, bucket string) error {
+	if err := c.checkWritable(bucket); err != nil {
+		return err
+	}
+
+	_, err := c.S3.DeleteBucket(ctx, &s3.DeleteBucketInput{
+		Bucket: aws.String(bucket),
+	})
+	if err != nil {
+		return &OpError{Op: "Deleting bucket", Bucket: bucket, Err: err}
+	}
+	return nil
+}
+
+// copySource builds the URL-encoded "bucket/key" value CopyObject expects
+func copySource(bucket, key string) string {
+	segments := strings.Split(key, "/")
+	for i, seg := range segments {
+		segments[i] = url.PathEscape(seg)
+	}
+	return bucket + "/" + strings.Join(segments, "/")
+}
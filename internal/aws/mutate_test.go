@@ -0,0 +1,371 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"github.com/natevick/stui/internal/security"
+)
+
+func TestRenameObject(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("b", "docs/old.txt", fakeObject{
+		body:         []byte("hello"),
+		contentType:  "text/plain",
+		metadata:     map[string]string{"owner": "team-a"},
+		storageClass: types.StorageClassStandardIa,
+	})
+	client := &Client{S3: fake}
+
+	if err := client.RenameObject(context.Background(), "b", "docs/old.txt", "docs/new.txt", false); err != nil {
+		t.Fatalf("RenameObject() error = %v", err)
+	}
+
+	if _, ok := fake.get("b", "docs/old.txt"); ok {
+		t.Error("expected old key to be deleted")
+	}
+	renamed, ok := fake.get("b", "docs/new.txt")
+	if !ok {
+		t.Fatal("expected new key to exist")
+	}
+	if string(renamed.body) != "hello" {
+		t.Errorf("body = %q, want hello", renamed.body)
+	}
+	if renamed.storageClass != types.StorageClassStandardIa {
+		t.Errorf("storage class = %q, want %q", renamed.storageClass, types.StorageClassStandardIa)
+	}
+	if renamed.metadata["owner"] != "team-a" || renamed.contentType != "text/plain" {
+		t.Errorf("metadata not preserved: %+v", renamed)
+	}
+	if dir := fake.copyInputs[0].MetadataDirective; dir != types.MetadataDirectiveCopy {
+		t.Errorf("MetadataDirective = %q, want COPY", dir)
+	}
+}
+
+func TestRenameObjectRefusesExistingDestination(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("b", "a.txt", fakeObject{body: []byte("a")})
+	fake.put("b", "b.txt", fakeObject{body: []byte("b")})
+	client := &Client{S3: fake}
+
+	err := client.RenameObject(context.Background(), "b", "a.txt", "b.txt", false)
+	if !errors.Is(err, ErrObjectExists) {
+		t.Fatalf("expected ErrObjectExists, got %v", err)
+	}
+	if fake.countCalls("CopyObject") != 0 || fake.countCalls("DeleteObject") != 0 {
+		t.Error("expected no copy or delete when the destination exists")
+	}
+	if obj, _ := fake.get("b", "b.txt"); string(obj.body) != "b" {
+		t.Error("destination was modified")
+	}
+
+	// Forcing replaces the destination
+	if err := client.RenameObject(context.Background(), "b", "a.txt", "b.txt", true); err != nil {
+		t.Fatalf("forced RenameObject() error = %v", err)
+	}
+	if obj, _ := fake.get("b", "b.txt"); string(obj.body) != "a" {
+		t.Error("expected forced rename to replace the destination")
+	}
+}
+
+func TestRenameObjectRejectsControlCharacters(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("b", "a.txt", fakeObject{body: []byte("a")})
+	client := &Client{S3: fake}
+
+	err := client.RenameObject(context.Background(), "b", "a.txt", "evil\nname.txt", false)
+	if err == nil {
+		t.Fatal("expected error for key with control characters")
+	}
+	if len(fake.calls) != 0 {
+		t.Errorf("expected no requests for an invalid key, got %v", fake.calls)
+	}
+}
+
+func TestRenameObjectRejectsAmbiguousKey(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("b", "a.txt", fakeObject{body: []byte("a")})
+	client := &Client{S3: fake}
+
+	err := client.RenameObject(context.Background(), "b", "a.txt", "docs/../a2.txt", false)
+	if !errors.Is(err, security.ErrAmbiguousKey) {
+		t.Fatalf("expected ErrAmbiguousKey, got %v", err)
+	}
+	if len(fake.calls) != 0 {
+		t.Errorf("expected no requests for an ambiguous key, got %v", fake.calls)
+	}
+
+	// An object that already has such a name can still be renamed to a clean one
+	fake.put("b", "docs//old.txt", fakeObject{body: []byte("old")})
+	if err := client.RenameObject(context.Background(), "b", "docs//old.txt", "docs/old.txt", false); err != nil {
+		t.Fatalf("RenameObject() from an ambiguous key error = %v", err)
+	}
+}
+
+func TestCopyObjectWithMeta(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("b", "data.txt", fakeObject{
+		body:         []byte(`{"a":1}`),
+		contentType:  "text/plain",
+		cacheControl: "max-age=60",
+		metadata:     map[string]string{"owner": "team-a", "stale": "yes"},
+		storageClass: types.StorageClassStandardIa,
+	})
+	client := &Client{S3: fake}
+
+	meta := MetaOverrides{
+		ContentType: "application/json; charset=utf-8",
+		Metadata:    map[string]string{"stale": "", "env": "prod"},
+	}
+	if err := client.CopyObjectWithMeta(context.Background(), "b", "data.txt", "b", "data.json", meta, false); err != nil {
+		t.Fatalf("CopyObjectWithMeta() error = %v", err)
+	}
+
+	copied, ok := fake.get("b", "data.json")
+	if !ok {
+		t.Fatal("expected copy to exist")
+	}
+	if copied.contentType != "application/json; charset=utf-8" {
+		t.Errorf("content type = %q, want the override", copied.contentType)
+	}
+	if copied.cacheControl != "max-age=60" {
+		t.Errorf("cache control = %q, want it carried over from the source", copied.cacheControl)
+	}
+	if want := map[string]string{"owner": "team-a", "env": "prod"}; !maps.Equal(copied.metadata, want) {
+		t.Errorf("metadata = %v, want %v", copied.metadata, want)
+	}
+	if copied.storageClass != types.StorageClassStandardIa {
+		t.Errorf("storage class = %q, want %q", copied.storageClass, types.StorageClassStandardIa)
+	}
+
+	in := fake.copyInputs[0]
+	if in.MetadataDirective != types.MetadataDirectiveReplace {
+		t.Errorf("MetadataDirective = %q, want REPLACE", in.MetadataDirective)
+	}
+	// Headers the source doesn't have are left out, not sent empty
+	if in.ContentDisposition != nil || in.ContentEncoding != nil || in.ContentLanguage != nil {
+		t.Errorf("unset headers sent: disposition=%v encoding=%v language=%v",
+			in.ContentDisposition, in.ContentEncoding, in.ContentLanguage)
+	}
+	if src, _ := fake.get("b", "data.txt"); src.metadata["stale"] != "yes" {
+		t.Error("source metadata was modified")
+	}
+}
+
+func TestCopyObjectWithMetaInPlace(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("b", "a.bin", fakeObject{body: []byte("a"), contentType: "binary/octet-stream"})
+	client := &Client{S3: fake}
+
+	meta := MetaOverrides{ContentType: "image/png", CacheControl: "no-cache"}
+	if err := client.CopyObjectWithMeta(context.Background(), "b", "a.bin", "b", "a.bin", meta, false); err != nil {
+		t.Fatalf("CopyObjectWithMeta() error = %v", err)
+	}
+	obj, _ := fake.get("b", "a.bin")
+	if obj.contentType != "image/png" || obj.cacheControl != "no-cache" {
+		t.Errorf("headers = %q/%q, want image/png/no-cache", obj.contentType, obj.cacheControl)
+	}
+}
+
+func TestValidContentType(t *testing.T) {
+	tests := []struct {
+		contentType string
+		wantErr     bool
+	}{
+		{"text/plain", false},
+		{"application/json; charset=utf-8", false},
+		{"image/svg+xml", false},
+		{"text", true},
+		{"text/", true},
+		{"/plain", true},
+		{"text/plain; charset", true},
+		{"text/plain\r\nX-Evil: 1", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			if err := ValidContentType(tt.contentType); (err != nil) != tt.wantErr {
+				t.Errorf("ValidContentType(%q) error = %v, wantErr %v", tt.contentType, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCopyObjectWithMetaRejectsBadContentType(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("b", "a.txt", fakeObject{body: []byte("a")})
+	client := &Client{S3: fake}
+
+	err := client.CopyObjectWithMeta(context.Background(), "b", "a.txt", "b", "b.txt", MetaOverrides{ContentType: "not a type"}, false)
+	if err == nil {
+		t.Fatal("expected error for malformed content type")
+	}
+	if len(fake.calls) != 0 {
+		t.Errorf("expected no requests for a malformed content type, got %v", fake.calls)
+	}
+}
+
+func TestTouchObject(t *testing.T) {
+	fake := newFakeS3()
+	old := time.Now().Add(-48 * time.Hour)
+	fake.put("b", "logs/app.log", fakeObject{
+		body:         []byte("hello"),
+		contentType:  "text/plain",
+		metadata:     map[string]string{"owner": "team-a"},
+		storageClass: types.StorageClassGlacierIr,
+		modified:     old,
+	})
+	fake.put("b", "plain.txt", fakeObject{body: []byte("x"), modified: old})
+	client := &Client{S3: fake}
+
+	if err := client.TouchObject(context.Background(), "b", "logs/app.log"); err != nil {
+		t.Fatalf("TouchObject() error = %v", err)
+	}
+
+	in := fake.copyInputs[0]
+	if aws.ToString(in.Key) != "logs/app.log" || aws.ToString(in.CopySource) != "b/logs/app.log" {
+		t.Errorf("copy = %s -> %s, want a self-copy", aws.ToString(in.CopySource), aws.ToString(in.Key))
+	}
+	if in.MetadataDirective != types.MetadataDirectiveCopy {
+		t.Errorf("MetadataDirective = %q, want COPY", in.MetadataDirective)
+	}
+	if in.StorageClass != types.StorageClassGlacierIr {
+		t.Errorf("StorageClass = %q, want %q", in.StorageClass, types.StorageClassGlacierIr)
+	}
+
+	touched, _ := fake.get("b", "logs/app.log")
+	if string(touched.body) != "hello" || touched.contentType != "text/plain" || touched.metadata["owner"] != "team-a" {
+		t.Errorf("object changed: %+v", touched)
+	}
+	if !touched.modified.After(old) {
+		t.Errorf("last modified = %v, want it bumped past %v", touched.modified, old)
+	}
+
+	// STANDARD objects report no storage class but must still send one
+	if err := client.TouchObject(context.Background(), "b", "plain.txt"); err != nil {
+		t.Fatalf("TouchObject() error = %v", err)
+	}
+	if got := fake.copyInputs[1].StorageClass; got != types.StorageClassStandard {
+		t.Errorf("StorageClass = %q, want STANDARD", got)
+	}
+
+	client.Options.ProtectedBuckets = []string{"b"}
+	if err := client.TouchObject(context.Background(), "b", "plain.txt"); !errors.Is(err, ErrProtectedBucket) {
+		t.Errorf("TouchObject() on protected bucket error = %v, want ErrProtectedBucket", err)
+	}
+}
+
+func TestCreateBucket(t *testing.T) {
+	fake := newFakeS3()
+	client := &Client{S3: fake, Region: "eu-west-1", Options: ClientOptions{BucketRegions: NewBucketRegionCache()}}
+	ctx := context.Background()
+
+	if err := client.CreateBucket(ctx, "new-bucket", ""); err != nil {
+		t.Fatalf("CreateBucket() error = %v", err)
+	}
+	if got := fake.bucketRegions["new-bucket"]; got != types.BucketLocationConstraintEuWest1 {
+		t.Errorf("location constraint = %q, want the client's region", got)
+	}
+	if err := client.CreateBucket(ctx, "east-bucket", "us-east-1"); err != nil {
+		t.Fatalf("CreateBucket(us-east-1) error = %v", err)
+	}
+	if got, ok := fake.bucketRegions["east-bucket"]; ok {
+		t.Errorf("location constraint = %q, want none for us-east-1", got)
+	}
+	if region, _ := client.GetBucketRegion(ctx, "new-bucket"); region != "eu-west-1" {
+		t.Errorf("GetBucketRegion() = %q, want eu-west-1", region)
+	}
+	if n := fake.countCalls("GetBucketLocation"); n != 0 {
+		t.Errorf("made %d GetBucketLocation calls for a bucket just created, want 0", n)
+	}
+
+	if err := client.CreateBucket(ctx, "Bad_Name", ""); err == nil {
+		t.Error("CreateBucket() accepted an invalid bucket name")
+	}
+}
+
+func TestCreateBucketExistingBucketErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		sdkErr  error
+		want    error
+		notWant error
+		message string
+	}{
+		{"owned", &types.BucketAlreadyOwnedByYou{Message: aws.String("Your previous request to create the named bucket succeeded and you already own it.")},
+			ErrBucketAlreadyOwned, ErrBucketNameTaken, "Creating bucket: you already own this bucket"},
+		{"taken", &types.BucketAlreadyExists{Message: aws.String("The requested bucket name is not available.")},
+			ErrBucketNameTaken, ErrBucketAlreadyOwned, "Creating bucket: bucket name is taken by another account — choose a different name"},
+		{"owned on an S3-compatible store", &smithy.GenericAPIError{Code: "BucketAlreadyOwnedByYou"},
+			ErrBucketAlreadyOwned, ErrBucketNameTaken, "Creating bucket: you already own this bucket"},
+		{"taken on an S3-compatible store", &smithy.GenericAPIError{Code: "BucketAlreadyExists"},
+			ErrBucketNameTaken, ErrBucketAlreadyOwned, "Creating bucket: bucket name is taken by another account"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeS3()
+			fake.errFor = func(op, key string) error {
+				if op == "CreateBucket" {
+					return tt.sdkErr
+				}
+				return nil
+			}
+			client := &Client{S3: fake}
+
+			err := client.CreateBucket(context.Background(), "taken-name", "")
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("CreateBucket() error = %v, want %v", err, tt.want)
+			}
+			if errors.Is(err, tt.notWant) {
+				t.Errorf("CreateBucket() error = %v, also matches %v", err, tt.notWant)
+			}
+			if !errors.As(err, new(*OpError)) {
+				t.Errorf("CreateBucket() error = %T, want an *OpError", err)
+			}
+			if got := err.Error(); !strings.HasPrefix(got, tt.message) {
+				t.Errorf("Error() = %q, want prefix %q", got, tt.message)
+			}
+		})
+	}
+
+	// Other failures are neither
+	fake := newFakeS3()
+	fake.errFor = func(op, key string) error { return &smithy.GenericAPIError{Code: "AccessDenied"} }
+	err := (&Client{S3: fake}).CreateBucket(context.Background(), "some-bucket", "")
+	if err == nil || errors.Is(err, ErrBucketAlreadyOwned) || errors.Is(err, ErrBucketNameTaken) {
+		t.Errorf("CreateBucket() denied error = %v", err)
+	}
+}
+
+func TestCreateBucketRegionMismatch(t *testing.T) {
+	fake := newFakeS3()
+	fake.errFor = func(op, key string) error {
+		return &smithy.GenericAPIError{
+			Code:    "IllegalLocationConstraintException",
+			Message: "The eu-west-1 location constraint is incompatible for the region specific endpoint this request was sent to. arn:aws:iam::123456789012:user/bob",
+		}
+	}
+	client := &Client{S3: fake, Region: "us-west-2"}
+
+	err := client.CreateBucket(context.Background(), "prod-logs-123456789012", "eu-west-1")
+	if !errors.Is(err, ErrRegionMismatch) {
+		t.Fatalf("CreateBucket() error = %v, want ErrRegionMismatch", err)
+	}
+	want := "Creating bucket: region mismatch — your endpoint/region settings conflict with the requested bucket region"
+	if got := err.Error(); got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	for _, leak := range []string{"123456789012", "arn:", "bob", "prod-logs"} {
+		if strings.Contains(err.Error(), leak) {
+			t.Errorf("Error() = %q leaks %s", err.Error(), leak)
+		}
+	}
+}
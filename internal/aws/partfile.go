@@ -0,0 +1,103 @@
+package aws
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/natevick/stui/internal/security"
+)
+
+// In-progress downloads are written to <temp dir>/.s3-tui-<name>-<hash>.part
+const (
+	PartPrefix = ".s3-tui-"
+	PartSuffix = ".part"
+)
+
+// DefaultPartMaxAge is how old a leftover part file must be before the startup sweep removes it
+const DefaultPartMaxAge = 24 * time.Hour
+
+// TempDir returns the directory for in-progress downloads, falling back to the system temp dir
+func (o ClientOptions) TempDir() string {
+	if o.DownloadTempDir != "" {
+		return o.DownloadTempDir
+	}
+	return os.TempDir()
+}
+
+// PartPath returns where the download of the object with etag to localPath
+// is staged inside dir. The name carries a hash of the full destination and
+// the ETag, so files with the same base name in different folders don't
+// collide, and a retry resumes its own part only while the object is
+// unchanged.
+func PartPath(dir, localPath, etag string) (string, error) {
+	abs, err := filepath.Abs(localPath)
+	if err != nil {
+		return "", fmt.Errorf("invalid local path: %w", err)
+	}
+	sum := sha256.Sum256([]byte(abs + "\x00" + etag))
+	name := PartPrefix + filepath.Base(abs) + "-" + hex.EncodeToString(sum[:4]) + PartSuffix
+	return security.SafePath(dir, name)
+}
+
+// SweepPartFiles removes part files in dir last modified before now-maxAge.
+// Files that don't match the part file naming are never touched.
+func SweepPartFiles(dir string, maxAge time.Duration, now time.Time) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to read temp directory: %w", err)
+	}
+
+	cutoff := now.Add(-maxAge)
+	removed := 0
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || !strings.HasPrefix(name, PartPrefix) || !strings.HasSuffix(name, PartSuffix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, name)); err == nil {
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// moveFile renames src to dst, copying when they are on different filesystems
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	return os.Remove(src)
+}
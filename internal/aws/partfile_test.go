@@ -0,0 +1,110 @@
+package aws
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSweepPartFiles(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+
+	files := map[string]time.Duration{
+		".s3-tui-old.csv-0a1b2c3d.part":    48 * time.Hour,
+		".s3-tui-recent.csv-0a1b2c3d.part": time.Hour,
+		"unrelated.part":                   48 * time.Hour,
+		".s3-tui-notes.txt":                48 * time.Hour,
+	}
+	for name, age := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("x"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, now.Add(-age), now.Add(-age)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed, err := SweepPartFiles(dir, 24*time.Hour, now)
+	if err != nil {
+		t.Fatalf("SweepPartFiles() error = %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("removed %d files, want 1", removed)
+	}
+
+	for name := range files {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if exists, want := err == nil, name != ".s3-tui-old.csv-0a1b2c3d.part"; exists != want {
+			t.Errorf("%s exists = %v, want %v", name, exists, want)
+		}
+	}
+}
+
+func TestSweepPartFilesMissingDir(t *testing.T) {
+	removed, err := SweepPartFiles(filepath.Join(t.TempDir(), "missing"), time.Hour, time.Now())
+	if err != nil || removed != 0 {
+		t.Errorf("SweepPartFiles() = %d, %v; want 0, nil", removed, err)
+	}
+}
+
+func TestPartPath(t *testing.T) {
+	dir := t.TempDir()
+
+	a, err := PartPath(dir, "/downloads/2024/report.csv", "etag-1")
+	if err != nil {
+		t.Fatalf("PartPath() error = %v", err)
+	}
+	b, _ := PartPath(dir, "/downloads/2025/report.csv", "etag-1")
+	c, _ := PartPath(dir, "/downloads/2024/report.csv", "etag-2")
+
+	if filepath.Dir(a) != dir {
+		t.Errorf("PartPath() = %q, want it inside %q", a, dir)
+	}
+	if name := filepath.Base(a); !strings.HasPrefix(name, PartPrefix+"report.csv-") || !strings.HasSuffix(name, PartSuffix) {
+		t.Errorf("part name = %q", name)
+	}
+	if a == b {
+		t.Error("same base name in different folders must not share a part file")
+	}
+	if a == c {
+		t.Error("a new version of the object must not resume the old version's part")
+	}
+}
+
+func TestDownloadFileUsesTempDir(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("b", "data.txt", fakeObject{body: []byte("payload")})
+
+	tempDir := filepath.Join(t.TempDir(), "staging")
+	var partSeen bool
+	fake.errFor = func(op, key string) error {
+		// The part file exists while the body is being fetched
+		if op == "GetObject" {
+			matches, _ := filepath.Glob(filepath.Join(tempDir, PartPrefix+"*"+PartSuffix))
+			partSeen = len(matches) == 1
+		}
+		return nil
+	}
+	client := &Client{S3: fake, Options: ClientOptions{DownloadTempDir: tempDir}}
+
+	localPath := filepath.Join(t.TempDir(), "out", "data.txt")
+	if err := client.DownloadFile(context.Background(), "b", "data.txt", localPath, nil); err != nil {
+		t.Fatalf("DownloadFile() error = %v", err)
+	}
+	if !partSeen {
+		t.Error("download was not staged in the configured temp dir")
+	}
+
+	data, err := os.ReadFile(localPath)
+	if err != nil || string(data) != "payload" {
+		t.Errorf("local file = %q, %v", data, err)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(tempDir, "*")); len(matches) != 0 {
+		t.Errorf("temp dir not cleaned up: %v", matches)
+	}
+}
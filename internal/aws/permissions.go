@@ -0,0 +1,157 @@
+package aws
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// Action is an S3 permission CheckPermissions can probe, named after its IAM action
+type Action string
+
+const (
+	ActionRead   Action = "s3:GetObject"
+	ActionList   Action = "s3:ListBucket"
+	ActionWrite  Action = "s3:PutObject"
+	ActionDelete Action = "s3:DeleteObject"
+)
+
+// probeETag never matches a real object, so conditional probes carrying it
+// are authorized and then refused without touching anything
+const probeETag = `"stui-permission-probe"`
+
+// errProbeInconclusive is a write or delete probe that went through: the
+// store ignored its If-Match, so the answer says nothing reliable
+var errProbeInconclusive = errors.New("permission probe was not refused")
+
+// CheckPermissions probes which actions the caller may perform on
+// bucket/key without changing anything. Reads use HEAD and lists a one-key
+// listing. Writes and deletes send PutObject and DeleteObject with an
+// If-Match that can't hold, against a random key beside key rather than key
+// itself: S3 checks authorization first, so a PreconditionFailed or NoSuchKey
+// answer means allowed and AccessDenied means denied. A probe that succeeds
+// had its If-Match ignored; it is cleaned up and counts as unknown. Custom
+// endpoints may ignore If-Match, so those two are only probed against AWS
+// itself. Read-only mode and protected buckets deny writes and
+// deletes up front.
+//
+// An action missing from the result is unknown: the probe wasn't safe to
+// send or its answer didn't settle the question. The error is only set when
+// ctx ends.
+func (c *Client) CheckPermissions(ctx context.Context, bucket, key string, actions []Action) (map[Action]bool, error) {
+	result := make(map[Action]bool, len(actions))
+	for _, action := range actions {
+		var err error
+		switch action {
+		case ActionRead:
+			if key == "" {
+				continue
+			}
+			_, err = c.S3.HeadObject(ctx, &s3.HeadObjectInput{
+				Bucket: aws.String(bucket),
+				Key:    aws.String(key),
+			})
+		case ActionList:
+			lister := c.NewLister(bucket, key, "")
+			lister.SetPageSize(1)
+			_, err = lister.NextPage(ctx)
+		case ActionWrite, ActionDelete:
+			if c.checkWritable(bucket) != nil {
+				result[action] = false
+				continue
+			}
+			if key == "" || aws.ToString(c.Config.BaseEndpoint) != "" {
+				continue
+			}
+			err = c.probeChange(ctx, bucket, probeKey(key), action)
+		default:
+			continue
+		}
+
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+		if allowed, known := probeOutcome(err); known {
+			result[action] = allowed
+		}
+	}
+	return result, nil
+}
+
+// probeKey returns a key beside key, under the same prefix, that almost
+// certainly doesn't exist, so a probe whose If-Match is ignored harms nothing
+func probeKey(key string) string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	dir := key
+	if !strings.HasSuffix(dir, "/") {
+		dir = path.Dir(key) + "/"
+	}
+	if dir == "./" || dir == "/" {
+		dir = ""
+	}
+	return dir + ".stui-probe-" + hex.EncodeToString(b)
+}
+
+// probeChange sends a write or delete probe for key, which should not exist.
+// If it goes through, whatever it left behind is removed and the probe
+// reports errProbeInconclusive.
+func (c *Client) probeChange(ctx context.Context, bucket, key string, action Action) error {
+	if action == ActionWrite {
+		out, err := c.S3.PutObject(ctx, &s3.PutObjectInput{
+			Bucket:  aws.String(bucket),
+			Key:     aws.String(key),
+			IfMatch: aws.String(probeETag),
+		})
+		if err != nil {
+			return err
+		}
+		c.S3.DeleteObject(ctx, &s3.DeleteObjectInput{
+			Bucket:    aws.String(bucket),
+			Key:       aws.String(key),
+			VersionId: out.VersionId,
+		})
+		return errProbeInconclusive
+	}
+
+	out, err := c.S3.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket:  aws.String(bucket),
+		Key:     aws.String(key),
+		IfMatch: aws.String(probeETag),
+	})
+	if err != nil {
+		return err
+	}
+	// In a versioned bucket the delete left a marker behind
+	if aws.ToBool(out.DeleteMarker) && out.VersionId != nil {
+		c.S3.DeleteObject(ctx, &s3.DeleteObjectInput{
+			Bucket:    aws.String(bucket),
+			Key:       aws.String(key),
+			VersionId: out.VersionId,
+		})
+	}
+	return errProbeInconclusive
+}
+
+// probeOutcome reads a probe's answer. Any answer past authorization (success,
+// a missing object, a failed precondition) means allowed; AccessDenied means
+// denied; anything else, including a missing bucket or an inconclusive write
+// or delete probe, settles nothing.
+func probeOutcome(err error) (allowed, known bool) {
+	switch {
+	case hasErrorCode(err, "NoSuchBucket"):
+		return false, false
+	case err == nil, IsNotFound(err), IsPreconditionFailed(err):
+		return true, true
+	case IsAccessDenied(err):
+		return false, true
+	default:
+		return false, false
+	}
+}
@@ -0,0 +1,137 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/smithy-go"
+)
+
+var allActions = []Action{ActionRead, ActionList, ActionWrite, ActionDelete}
+
+func TestCheckPermissionsReadAllowedWriteDenied(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("b", "docs/a.txt", fakeObject{body: []byte("keep me")})
+	fake.errFor = func(op, key string) error {
+		if op == "PutObject" || op == "DeleteObject" {
+			return &smithy.GenericAPIError{Code: "AccessDenied"}
+		}
+		return nil
+	}
+	client := &Client{S3: fake}
+
+	got, err := client.CheckPermissions(context.Background(), "b", "docs/a.txt", allActions)
+	if err != nil {
+		t.Fatalf("CheckPermissions() error = %v", err)
+	}
+	want := map[Action]bool{ActionRead: true, ActionList: true, ActionWrite: false, ActionDelete: false}
+	if !maps.Equal(got, want) {
+		t.Errorf("CheckPermissions() = %v, want %v", got, want)
+	}
+}
+
+func TestCheckPermissionsProbesChangeNothing(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("b", "docs/a.txt", fakeObject{body: []byte("keep me")})
+	client := &Client{S3: fake}
+
+	// Existing objects fail the If-Match; missing ones are NoSuchKey. Both mean allowed.
+	for _, key := range []string{"docs/a.txt", "docs/missing.txt"} {
+		got, err := client.CheckPermissions(context.Background(), "b", key, allActions)
+		if err != nil {
+			t.Fatalf("CheckPermissions(%s) error = %v", key, err)
+		}
+		want := map[Action]bool{ActionRead: true, ActionList: true, ActionWrite: true, ActionDelete: true}
+		if !maps.Equal(got, want) {
+			t.Errorf("CheckPermissions(%s) = %v, want %v", key, got, want)
+		}
+	}
+	if obj, ok := fake.get("b", "docs/a.txt"); !ok || string(obj.body) != "keep me" {
+		t.Error("probes changed the object")
+	}
+	if _, ok := fake.get("b", "docs/missing.txt"); ok {
+		t.Error("probes created an object")
+	}
+	for _, call := range fake.calls {
+		op, key, _ := strings.Cut(call, " ")
+		if (op == "PutObject" || op == "DeleteObject") && !strings.HasPrefix(key, "docs/.stui-probe-") {
+			t.Errorf("%s probed %s, want a random key beside it", op, key)
+		}
+	}
+}
+
+func TestCheckPermissionsIgnoredIfMatchIsUnknown(t *testing.T) {
+	fake := newFakeS3()
+	fake.ignoreIfMatch = true
+	fake.put("b", "docs/a.txt", fakeObject{body: []byte("keep me")})
+	client := &Client{S3: fake}
+
+	got, err := client.CheckPermissions(context.Background(), "b", "docs/a.txt", allActions)
+	if err != nil {
+		t.Fatalf("CheckPermissions() error = %v", err)
+	}
+	// The probes went through, which doesn't prove the condition was checked
+	if want := map[Action]bool{ActionRead: true, ActionList: true}; !maps.Equal(got, want) {
+		t.Errorf("CheckPermissions() = %v, want writes and deletes unknown", got)
+	}
+	if obj, ok := fake.get("b", "docs/a.txt"); !ok || string(obj.body) != "keep me" {
+		t.Error("probes changed the object")
+	}
+	if n := len(fake.objects["b"]); n != 1 {
+		t.Errorf("bucket holds %d objects after probing, want the probe cleaned up", n)
+	}
+}
+
+func TestProbeKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"docs/a.txt", "docs/.stui-probe-"},
+		{"docs/", "docs/.stui-probe-"},
+		{"a.txt", ".stui-probe-"},
+	}
+	for _, tt := range tests {
+		got := probeKey(tt.key)
+		if !strings.HasPrefix(got, tt.want) || len(got) != len(tt.want)+16 {
+			t.Errorf("probeKey(%q) = %q, want %s and 16 hex digits", tt.key, got, tt.want)
+		}
+	}
+	if probeKey("a.txt") == probeKey("a.txt") {
+		t.Error("probeKey() repeated a key")
+	}
+}
+
+func TestCheckPermissionsUnknown(t *testing.T) {
+	fake := newFakeS3()
+	fake.errFor = func(op, key string) error {
+		if op == "HeadObject" {
+			return errors.New("connection reset")
+		}
+		return nil
+	}
+
+	// A custom endpoint might ignore If-Match, so writes and deletes aren't probed
+	client := &Client{S3: fake, Config: aws.Config{BaseEndpoint: aws.String("https://minio.local")}}
+	got, err := client.CheckPermissions(context.Background(), "b", "a.txt", allActions)
+	if err != nil {
+		t.Fatalf("CheckPermissions() error = %v", err)
+	}
+	if want := map[Action]bool{ActionList: true}; !maps.Equal(got, want) {
+		t.Errorf("CheckPermissions() = %v, want only %v", got, want)
+	}
+	if fake.countCalls("PutObject") != 0 || fake.countCalls("DeleteObject") != 0 {
+		t.Error("expected no write or delete probes against a custom endpoint")
+	}
+
+	// Read-only mode denies writes and deletes without asking S3
+	client = &Client{S3: fake, Options: ClientOptions{ReadOnly: true}}
+	got, _ = client.CheckPermissions(context.Background(), "b", "a.txt", []Action{ActionWrite, ActionDelete})
+	if want := map[Action]bool{ActionWrite: false, ActionDelete: false}; !maps.Equal(got, want) {
+		t.Errorf("CheckPermissions(read-only) = %v, want %v", got, want)
+	}
+}
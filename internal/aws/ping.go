@@ -0,0 +1,83 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+)
+
+// DefaultPingTimeout bounds the startup connectivity check
+const DefaultPingTimeout = 5 * time.Second
+
+// ErrCheckDenied is returned by Ping and PingBucket when the endpoint
+// answered but the identity may not make the check request, as with a role
+// that can't list buckets. The endpoint is reachable and the credentials
+// were accepted, so callers should treat it as connected.
+var ErrCheckDenied = errors.New("connected, but not allowed to run the connection check")
+
+// Ping checks that the endpoint is reachable and the credentials are accepted
+// with a single one-bucket ListBuckets request. It isn't retried, so an
+// unreachable endpoint fails within the ping timeout instead of after backoff.
+// The error reads as a short friendly message. Being refused permission is
+// ErrCheckDenied; any other error means the endpoint, DNS or credentials
+// failed.
+func (c *Client) Ping(ctx context.Context) error {
+	return c.ping(ctx, func(ctx context.Context, noRetry func(*s3.Options)) error {
+		_, err := c.S3.ListBuckets(ctx, &s3.ListBucketsInput{
+			MaxBuckets: aws.Int32(1),
+		}, noRetry)
+		return err
+	})
+}
+
+// PingBucket is Ping for clients that can't list buckets, such as anonymous
+// ones: it checks the endpoint with HeadBucket on bucket instead
+func (c *Client) PingBucket(ctx context.Context, bucket string) error {
+	return c.ping(ctx, func(ctx context.Context, noRetry func(*s3.Options)) error {
+		_, err := c.S3.HeadBucket(ctx, &s3.HeadBucketInput{
+			Bucket: aws.String(bucket),
+		}, noRetry)
+		return err
+	})
+}
+
+// ping runs a connection check request within the ping timeout
+func (c *Client) ping(ctx context.Context, check func(context.Context, func(*s3.Options)) error) error {
+	timeout := c.Options.PingTimeout
+	if timeout <= 0 {
+		timeout = DefaultPingTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := check(ctx, func(o *s3.Options) {
+		o.RetryMaxAttempts = 1
+	})
+	if isCheckDenied(err) {
+		return &OpError{Op: "Connection check", Err: fmt.Errorf("%w: %w", ErrCheckDenied, err)}
+	}
+	if err != nil {
+		return &OpError{Op: "Connection check", Err: err}
+	}
+	return nil
+}
+
+// isCheckDenied reports whether S3 refused a check by policy. Unlike
+// IsAccessDenied it ignores bare 403s, which bad credentials get too; a
+// HEAD response has no body, so HeadBucket's refusal is only "Forbidden".
+func isCheckDenied(err error) bool {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.ErrorCode() {
+	case "AccessDenied", "AllAccessDisabled", "Forbidden":
+		return true
+	}
+	return false
+}
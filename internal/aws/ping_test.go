@@ -0,0 +1,91 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/aws/smithy-go"
+)
+
+func TestPing(t *testing.T) {
+	fake := newFakeS3()
+	fake.bucketPages = [][]string{{"alpha"}}
+	client := &Client{S3: fake}
+
+	if err := client.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping() error = %v", err)
+	}
+	if n := fake.countCalls("ListBuckets"); n != 1 {
+		t.Errorf("made %d ListBuckets calls, want 1", n)
+	}
+}
+
+func TestPingConnectionRefused(t *testing.T) {
+	fake := newFakeS3()
+	fake.errFor = func(op, key string) error {
+		return &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+	}
+	client := &Client{S3: fake}
+
+	err := client.Ping(context.Background())
+	if err == nil {
+		t.Fatal("expected an error for a refused connection")
+	}
+	if got := err.Error(); !strings.Contains(got, "connection error") {
+		t.Errorf("Ping() error = %q, want a connection error message", got)
+	}
+	if !strings.HasPrefix(err.Error(), "Connection check") {
+		t.Errorf("Ping() error = %q, want it to name the check", err)
+	}
+}
+
+func TestPingBucket(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("public-data", "a.txt", fakeObject{body: []byte("a")})
+	client := &Client{S3: fake}
+
+	if err := client.PingBucket(context.Background(), "public-data"); err != nil {
+		t.Fatalf("PingBucket() error = %v", err)
+	}
+	if n := fake.countCalls("HeadBucket"); n != 1 {
+		t.Errorf("made %d HeadBucket calls, want 1", n)
+	}
+	if n := fake.countCalls("ListBuckets"); n != 0 {
+		t.Errorf("made %d ListBuckets calls, want none", n)
+	}
+}
+
+func TestPingAccessDeniedIsConnected(t *testing.T) {
+	tests := []struct {
+		code   string
+		denied bool
+	}{
+		{"AccessDenied", true},
+		{"AllAccessDisabled", true},
+		// Bad credentials mean there's no working connection
+		{"InvalidAccessKeyId", false},
+		{"SignatureDoesNotMatch", false},
+		{"ExpiredToken", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			fake := newFakeS3()
+			fake.errFor = func(op, key string) error {
+				return &smithy.GenericAPIError{Code: tt.code}
+			}
+			client := &Client{S3: fake}
+
+			err := client.Ping(context.Background())
+			if err == nil {
+				t.Fatal("Ping() error = nil")
+			}
+			if got := errors.Is(err, ErrCheckDenied); got != tt.denied {
+				t.Errorf("errors.Is(%v, ErrCheckDenied) = %v, want %v", err, got, tt.denied)
+			}
+		})
+	}
+}
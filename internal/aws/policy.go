@@ -0,0 +1,85 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+	"github.com/natevick/stui/internal/security"
+)
+
+// GetBucketPolicy returns the bucket policy as indented JSON with account IDs
+// and ARNs redacted. A bucket without a policy returns an empty string.
+func (c *Client) GetBucketPolicy(ctx context.Context, bucket string) (string, error) {
+	output, err := c.S3.GetBucketPolicy(ctx, &s3.GetBucketPolicyInput{
+		Bucket: aws.String(bucket),
+	})
+	if err != nil {
+		if hasErrorCode(err, "NoSuchBucketPolicy") {
+			return "", nil
+		}
+		return "", &OpError{Op: "Reading bucket policy", Bucket: bucket, Err: err}
+	}
+	return formatPolicy(aws.ToString(output.Policy))
+}
+
+// formatPolicy pretty-prints a policy document, sanitizing every string value
+// so the result stays valid JSON
+func formatPolicy(policy string) (string, error) {
+	if policy == "" {
+		return "", nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader([]byte(policy)))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return "", fmt.Errorf("failed to parse bucket policy: %w", err)
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(sanitizeJSON(doc)); err != nil {
+		return "", fmt.Errorf("failed to format bucket policy: %w", err)
+	}
+	return string(bytes.TrimRight(buf.Bytes(), "\n")), nil
+}
+
+// sanitizeJSON redacts sensitive identifiers in every string of a decoded JSON value
+func sanitizeJSON(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, item := range v {
+			v[k] = sanitizeJSON(item)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = sanitizeJSON(item)
+		}
+		return v
+	case string:
+		return security.SanitizeError(errors.New(v))
+	case json.Number:
+		// A bare 12-digit number is an account ID; redact it as a string
+		if s := security.SanitizeError(errors.New(v.String())); s != v.String() {
+			return s
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// hasErrorCode reports whether err is an AWS API error with the given code
+func hasErrorCode(err error, code string) bool {
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && apiErr.ErrorCode() == code
+}
@@ -0,0 +1,69 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestGetBucketPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   string
+		set      bool
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "pretty prints compact policy",
+			policy:   `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:GetObject"}]}`,
+			set:      true,
+			contains: []string{"{\n  \"Statement\": [\n", `    {`, `"Action": "s3:GetObject"`, `"Version": "2012-10-17"`},
+		},
+		{
+			name:     "redacts account IDs",
+			policy:   `{"Statement":[{"Effect":"Allow","Principal":{"AWS":"123456789012"},"Condition":{"StringEquals":{"aws:SourceAccount":"210987654321"}}}]}`,
+			set:      true,
+			contains: []string{`"AWS": "[account-id]"`, `"aws:SourceAccount": "[account-id]"`},
+			excludes: []string{"123456789012", "210987654321"},
+		},
+		{
+			name: "no policy",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeS3()
+			if tt.set {
+				fake.policies["b"] = tt.policy
+			}
+			client := &Client{S3: fake}
+
+			got, err := client.GetBucketPolicy(context.Background(), "b")
+			if err != nil {
+				t.Fatalf("GetBucketPolicy() error = %v", err)
+			}
+			if !tt.set {
+				if got != "" {
+					t.Errorf("GetBucketPolicy() = %q, want empty", got)
+				}
+				return
+			}
+			if !json.Valid([]byte(got)) {
+				t.Errorf("result is not valid JSON:\n%s", got)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(got, want) {
+					t.Errorf("result missing %q:\n%s", want, got)
+				}
+			}
+			for _, bad := range tt.excludes {
+				if strings.Contains(got, bad) {
+					t.Errorf("result still contains %q", bad)
+				}
+			}
+		})
+	}
+}
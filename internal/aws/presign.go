@@ -0,0 +1,136 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// MaxPresignExpiry is the longest lifetime SigV4 allows for a presigned URL
+const MaxPresignExpiry = 7 * 24 * time.Hour
+
+// Presigner creates presigned requests. It is satisfied by *s3.PresignClient.
+type Presigner interface {
+	PresignGetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.PresignOptions)) (*v4.PresignedHTTPRequest, error)
+}
+
+var _ Presigner = (*s3.PresignClient)(nil)
+
+// PresignedURL is a presigned download link
+type PresignedURL struct {
+	URL string
+	// Expires is how long the URL stays valid, which is less than requested
+	// when Capped is set
+	Expires time.Duration
+	// Capped is set when the credentials expire before the requested lifetime
+	// and refreshing them didn't help; the URL stops working when they do
+	Capped bool
+	// SignedAt is the signing time the URL carries, and ExpiresAt is
+	// SignedAt plus Expires
+	SignedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// fixedTimeSigner signs with a set time instead of the clock, so the same
+// inputs always produce the same URL
+type fixedTimeSigner struct {
+	signer *v4.Signer
+	at     time.Time
+}
+
+func (s fixedTimeSigner) PresignHTTP(ctx context.Context, credentials aws.Credentials, r *http.Request, payloadHash, service, region string, _ time.Time, optFns ...func(*v4.SignerOptions)) (string, http.Header, error) {
+	return s.signer.PresignHTTP(ctx, credentials, r, payloadHash, service, region, s.at, optFns...)
+}
+
+// PresignGet returns a URL that downloads bucket/key without credentials until
+// it expires. A URL is only valid while the credentials that signed it are, so
+// if they would expire first they are refreshed, and if they still would, the
+// lifetime is capped to match them.
+//
+// The URL is signed as of signedAt, or now if it is zero, and its lifetime
+// counts from then; the same signing time, credentials, and object always
+// give the same URL.
+func (c *Client) PresignGet(ctx context.Context, bucket, key string, expires time.Duration, signedAt time.Time) (PresignedURL, error) {
+	if c.Presigner == nil {
+		return PresignedURL{}, fmt.Errorf("presigning is not available for this client")
+	}
+	if c.Options.Anonymous {
+		return PresignedURL{}, fmt.Errorf("presigning needs credentials; a public object's plain URL works without one")
+	}
+	if expires <= 0 || expires > MaxPresignExpiry {
+		return PresignedURL{}, fmt.Errorf("expiry must be between 1s and %s", MaxPresignExpiry)
+	}
+
+	if signedAt.IsZero() {
+		signedAt = time.Now()
+	}
+	// SigV4 timestamps have one-second resolution
+	signedAt = signedAt.UTC().Truncate(time.Second)
+
+	result := PresignedURL{Expires: expires, SignedAt: signedAt}
+	remaining, ok := c.credentialsFrom(ctx, signedAt)
+	if ok && remaining < expires {
+		if cache, isCache := c.Config.Credentials.(*aws.CredentialsCache); isCache {
+			cache.Invalidate()
+			remaining, ok = c.credentialsFrom(ctx, signedAt)
+		}
+		if ok && remaining < expires {
+			if remaining < time.Second {
+				return PresignedURL{}, fmt.Errorf("credentials have expired - run 'aws sso login'")
+			}
+			result.Expires = remaining.Truncate(time.Second)
+			result.Capped = true
+		}
+	}
+
+	req, err := c.Presigner.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}, s3.WithPresignExpires(result.Expires), func(o *s3.PresignOptions) {
+		o.Presigner = fixedTimeSigner{
+			signer: v4.NewSigner(func(so *v4.SignerOptions) {
+				// S3 keys are signed as-is, matching the SDK's own S3 signer
+				so.DisableURIPathEscaping = true
+			}),
+			at: signedAt,
+		}
+	})
+	if err != nil {
+		return PresignedURL{}, &OpError{Op: "Presigning URL", Bucket: bucket, Key: key, Err: err}
+	}
+	result.URL = req.URL
+	result.ExpiresAt = signedAt.Add(result.Expires)
+	return result, nil
+}
+
+// PresignResult is a presigned download link and when it stops working
+type PresignResult struct {
+	URL       string
+	ExpiresAt time.Time
+}
+
+// PresignGetInfo presigns bucket/key as of now for ttl, for showing the link
+// together with its expiry. ExpiresAt is the signing time the URL carries
+// plus its lifetime, which is less than ttl when the credentials run out first.
+func (c *Client) PresignGetInfo(ctx context.Context, bucket, key string, ttl time.Duration) (PresignResult, error) {
+	presigned, err := c.PresignGet(ctx, bucket, key, ttl, time.Time{})
+	if err != nil {
+		return PresignResult{}, err
+	}
+	return PresignResult{URL: presigned.URL, ExpiresAt: presigned.ExpiresAt}, nil
+}
+
+// credentialsFrom returns how long the credentials stay valid after t, if
+// they expire at all
+func (c *Client) credentialsFrom(ctx context.Context, t time.Time) (time.Duration, bool) {
+	expires, ok := c.CredentialExpiry(ctx)
+	if !ok {
+		return 0, false
+	}
+	return expires.Sub(t), true
+}
@@ -0,0 +1,206 @@
+package aws
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// fakePresigner records the lifetime each URL was signed for
+type fakePresigner struct {
+	expires time.Duration
+}
+
+func (f *fakePresigner) PresignGetObject(ctx context.Context, in *s3.GetObjectInput, optFns ...func(*s3.PresignOptions)) (*v4.PresignedHTTPRequest, error) {
+	var opts s3.PresignOptions
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+	f.expires = opts.Expires
+	return &v4.PresignedHTTPRequest{URL: "https://example.com/" + aws.ToString(in.Key)}, nil
+}
+
+// expiringCredentials returns credentials that expire after each lifetime in
+// turn, repeating the last one
+func expiringCredentials(lifetimes ...time.Duration) (aws.CredentialsProvider, *atomic.Int32) {
+	var calls atomic.Int32
+	provider := aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+		i := min(int(calls.Add(1)), len(lifetimes)) - 1
+		return aws.Credentials{
+			AccessKeyID:     "AKID",
+			SecretAccessKey: "secret",
+			CanExpire:       true,
+			Expires:         time.Now().Add(lifetimes[i]),
+		}, nil
+	})
+	return aws.NewCredentialsCache(provider), &calls
+}
+
+func TestPresignGetCredentialsOutliveTTL(t *testing.T) {
+	creds, calls := expiringCredentials(12 * time.Hour)
+	presigner := &fakePresigner{}
+	client := &Client{Presigner: presigner, Config: aws.Config{Credentials: creds}}
+
+	got, err := client.PresignGet(context.Background(), "b", "a.txt", time.Hour, time.Time{})
+	if err != nil {
+		t.Fatalf("PresignGet() error = %v", err)
+	}
+	if got.Capped || got.Expires != time.Hour || presigner.expires != time.Hour {
+		t.Errorf("got %+v signed for %s, want an uncapped 1h URL", got, presigner.expires)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("credentials retrieved %d times, want 1 (no refresh)", n)
+	}
+}
+
+func TestPresignGetRefreshesShortCredentials(t *testing.T) {
+	creds, calls := expiringCredentials(10*time.Minute, 12*time.Hour)
+	presigner := &fakePresigner{}
+	client := &Client{Presigner: presigner, Config: aws.Config{Credentials: creds}}
+
+	got, err := client.PresignGet(context.Background(), "b", "a.txt", time.Hour, time.Time{})
+	if err != nil {
+		t.Fatalf("PresignGet() error = %v", err)
+	}
+	if got.Capped || presigner.expires != time.Hour {
+		t.Errorf("got %+v signed for %s, want refreshed credentials and a 1h URL", got, presigner.expires)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("credentials retrieved %d times, want 2 (one refresh)", n)
+	}
+}
+
+func TestPresignGetCapsToCredentialLifetime(t *testing.T) {
+	creds, _ := expiringCredentials(10 * time.Minute)
+	presigner := &fakePresigner{}
+	client := &Client{Presigner: presigner, Config: aws.Config{Credentials: creds}}
+
+	got, err := client.PresignGet(context.Background(), "b", "a.txt", time.Hour, time.Time{})
+	if err != nil {
+		t.Fatalf("PresignGet() error = %v", err)
+	}
+	if !got.Capped {
+		t.Fatal("expected the lifetime to be capped")
+	}
+	if got.Expires > 10*time.Minute || got.Expires < 9*time.Minute {
+		t.Errorf("Expires = %s, want about 10m", got.Expires)
+	}
+	if presigner.expires != got.Expires {
+		t.Errorf("signed for %s, want %s", presigner.expires, got.Expires)
+	}
+}
+
+func TestPresignGetExpiredCredentials(t *testing.T) {
+	creds, _ := expiringCredentials(-time.Minute)
+	client := &Client{Presigner: &fakePresigner{}, Config: aws.Config{Credentials: creds}}
+
+	_, err := client.PresignGet(context.Background(), "b", "a.txt", time.Hour, time.Time{})
+	if err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Errorf("PresignGet() error = %v, want an expired credentials error", err)
+	}
+}
+
+func staticPresignClient() *Client {
+	creds := aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+		return aws.Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "secret"}, nil
+	})
+	s3Client := s3.New(s3.Options{Region: "us-east-1", Credentials: creds})
+	return &Client{Presigner: s3.NewPresignClient(s3Client), Config: aws.Config{Credentials: creds}}
+}
+
+func TestPresignGetFixedSigningTimeIsReproducible(t *testing.T) {
+	client := staticPresignClient()
+	at := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+
+	first, err := client.PresignGet(context.Background(), "b", "dir/a b.txt", time.Hour, at)
+	if err != nil {
+		t.Fatalf("PresignGet() error = %v", err)
+	}
+	second, err := client.PresignGet(context.Background(), "b", "dir/a b.txt", time.Hour, at)
+	if err != nil {
+		t.Fatalf("PresignGet() error = %v", err)
+	}
+	if first.URL != second.URL {
+		t.Errorf("URLs differ for the same signing time:\n%s\n%s", first.URL, second.URL)
+	}
+
+	later, err := client.PresignGet(context.Background(), "b", "dir/a b.txt", time.Hour, at.Add(time.Second))
+	if err != nil {
+		t.Fatalf("PresignGet() error = %v", err)
+	}
+	if later.URL == first.URL {
+		t.Error("a different signing time produced the same URL")
+	}
+}
+
+func TestPresignGetTTLCountsFromSigningTime(t *testing.T) {
+	client := staticPresignClient()
+	at := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+
+	got, err := client.PresignGet(context.Background(), "b", "a.txt", 90*time.Minute, at)
+	if err != nil {
+		t.Fatalf("PresignGet() error = %v", err)
+	}
+	if !got.SignedAt.Equal(at) || !got.ExpiresAt.Equal(at.Add(90*time.Minute)) {
+		t.Errorf("SignedAt = %s, ExpiresAt = %s, want %s and 90m later", got.SignedAt, got.ExpiresAt, at)
+	}
+	for _, want := range []string{"X-Amz-Date=20240301T093000Z", "X-Amz-Expires=5400"} {
+		if !strings.Contains(got.URL, want) {
+			t.Errorf("URL %s is missing %s", got.URL, want)
+		}
+	}
+}
+
+func TestPresignGetInfoExpiresAtSigningTimePlusTTL(t *testing.T) {
+	client := staticPresignClient()
+	before := time.Now().UTC().Truncate(time.Second)
+
+	got, err := client.PresignGetInfo(context.Background(), "b", "dir/a.txt", 45*time.Minute)
+	if err != nil {
+		t.Fatalf("PresignGetInfo() error = %v", err)
+	}
+	if !strings.HasPrefix(got.URL, "https://") || !strings.Contains(got.URL, "X-Amz-Signature=") {
+		t.Errorf("URL = %q, want a presigned https URL", got.URL)
+	}
+
+	// The signing time is the one the URL carries
+	i := strings.Index(got.URL, "X-Amz-Date=")
+	if i < 0 {
+		t.Fatalf("URL %s has no X-Amz-Date", got.URL)
+	}
+	signedAt, err := time.Parse("20060102T150405Z", got.URL[i+len("X-Amz-Date="):][:len("20060102T150405Z")])
+	if err != nil {
+		t.Fatalf("parsing X-Amz-Date: %v", err)
+	}
+	if signedAt.Before(before) || signedAt.After(time.Now()) {
+		t.Errorf("signed at %s, want now", signedAt)
+	}
+	if want := signedAt.Add(45 * time.Minute); !got.ExpiresAt.Equal(want) {
+		t.Errorf("ExpiresAt = %s, want %s (signing time plus TTL)", got.ExpiresAt, want)
+	}
+	if !strings.Contains(got.URL, "X-Amz-Expires=2700") {
+		t.Errorf("URL %s is missing X-Amz-Expires=2700", got.URL)
+	}
+}
+
+func TestPresignGetInfoCappedExpiry(t *testing.T) {
+	creds, _ := expiringCredentials(20 * time.Minute)
+	client := &Client{Presigner: &fakePresigner{}, Config: aws.Config{Credentials: creds}}
+
+	got, err := client.PresignGetInfo(context.Background(), "b", "a.txt", time.Hour)
+	if err != nil {
+		t.Fatalf("PresignGetInfo() error = %v", err)
+	}
+	if got.URL == "" {
+		t.Error("PresignGetInfo() returned no URL")
+	}
+	if until := time.Until(got.ExpiresAt); until > 20*time.Minute || until < 19*time.Minute {
+		t.Errorf("ExpiresAt is %s away, want about the credentials' 20m", until)
+	}
+}
@@ -0,0 +1,115 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/dustin/go-humanize"
+)
+
+// ErrNeedsConfirmation is returned when an object exceeds a size limit and
+// the user has to confirm before stui proceeds
+var ErrNeedsConfirmation = errors.New("too large, confirm to proceed")
+
+// SizeLimitError reports an object over a configured size limit.
+// It matches ErrNeedsConfirmation with errors.Is.
+type SizeLimitError struct {
+	Size  int64
+	Limit int64
+}
+
+func (e *SizeLimitError) Error() string {
+	return fmt.Sprintf("object is %s, over the %s limit: %v",
+		humanize.Bytes(uint64(e.Size)), humanize.Bytes(uint64(e.Limit)), ErrNeedsConfirmation)
+}
+
+func (e *SizeLimitError) Unwrap() error {
+	return ErrNeedsConfirmation
+}
+
+// checkContentRange validates the Content-Range of a response to a request
+// for the first want bytes. An empty header means the server ignored the
+// range and sent a plain 200, which the caller truncates; a range that
+// doesn't start at zero or runs past what was asked for is an error.
+func checkContentRange(header string, want int64) error {
+	if header == "" {
+		return nil
+	}
+	var first, last int64
+	var total string
+	if n, err := fmt.Sscanf(header, "bytes %d-%d/%s", &first, &last, &total); n != 3 || err != nil {
+		return fmt.Errorf("malformed Content-Range %q", header)
+	}
+	if first != 0 || last < first || last >= want {
+		return fmt.Errorf("Content-Range %q does not match the requested bytes 0-%d", header, want-1)
+	}
+	return nil
+}
+
+// CheckSize returns a *SizeLimitError if size exceeds limit.
+// A limit of zero or less disables the check.
+func CheckSize(size, limit int64) error {
+	if limit > 0 && size > limit {
+		return &SizeLimitError{Size: size, Limit: limit}
+	}
+	return nil
+}
+
+// maxPreviewBytes is the most a preview reads, even once confirmed; the
+// panel shows a screenful, and a multi-gigabyte object would otherwise be
+// read into memory whole
+var maxPreviewBytes int64 = 32 * 1024 * 1024
+
+// PreviewObject returns the contents of an object for display. Objects larger
+// than limit are not fetched unless confirmed is set; the caller gets a
+// *SizeLimitError instead so it can ask the user first. Reads ask for the
+// first limit bytes only (maxPreviewBytes once confirmed) and never read
+// more, even from a server that ignores the Range header and sends the
+// whole object.
+func (c *Client) PreviewObject(ctx context.Context, bucket, key string, limit int64, confirmed bool) ([]byte, error) {
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+	ceiling := maxPreviewBytes
+	if !confirmed && limit > 0 {
+		ceiling = min(limit, ceiling)
+	}
+	if !confirmed {
+		meta, err := c.GetObjectMetadata(ctx, bucket, key)
+		if err != nil {
+			return nil, err
+		}
+		if err := CheckSize(meta.Size, limit); err != nil {
+			return nil, err
+		}
+		// A range on an empty object fails with InvalidRange
+		if meta.Size > 0 {
+			input.Range = aws.String(fmt.Sprintf("bytes=0-%d", ceiling-1))
+		}
+	}
+
+	output, err := c.S3.GetObject(ctx, input)
+	if err != nil {
+		return nil, &OpError{Op: "Previewing object", Bucket: bucket, Key: key, Err: err}
+	}
+	defer output.Body.Close()
+
+	if input.Range != nil {
+		if err := checkContentRange(aws.ToString(output.ContentRange), ceiling); err != nil {
+			return nil, &OpError{Op: "Previewing object", Bucket: bucket, Key: key, Err: err}
+		}
+	}
+
+	// A 200 without Content-Range carries the whole object; stop at the cap
+	data, err := io.ReadAll(io.LimitReader(output.Body, ceiling))
+	c.Options.Metrics.AddBytesDown(int64(len(data)))
+	if err != nil {
+		return nil, &OpError{Op: "Previewing object", Bucket: bucket, Key: key, Err: err}
+	}
+	return data, nil
+}
@@ -0,0 +1,135 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestPreviewObjectSizeLimit(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("b", "small.txt", fakeObject{body: []byte("hello")})
+	fake.put("b", "big.bin", fakeObject{body: bytes.Repeat([]byte{0}, 64)})
+	client := &Client{S3: fake}
+	ctx := context.Background()
+
+	data, err := client.PreviewObject(ctx, "b", "small.txt", 32, false)
+	if err != nil {
+		t.Fatalf("PreviewObject(small) error = %v", err)
+	}
+	if string(data) != "hello" {
+		t.Errorf("PreviewObject(small) = %q, want hello", data)
+	}
+
+	_, err = client.PreviewObject(ctx, "b", "big.bin", 32, false)
+	if !errors.Is(err, ErrNeedsConfirmation) {
+		t.Fatalf("PreviewObject(big) error = %v, want ErrNeedsConfirmation", err)
+	}
+	var limitErr *SizeLimitError
+	if !errors.As(err, &limitErr) || limitErr.Size != 64 || limitErr.Limit != 32 {
+		t.Errorf("expected SizeLimitError{64, 32}, got %v", err)
+	}
+	if got := fake.countCalls("GetObject"); got != 1 {
+		t.Errorf("GetObject calls = %d, want 1 (big object must not be fetched)", got)
+	}
+
+	data, err = client.PreviewObject(ctx, "b", "big.bin", 32, true)
+	if err != nil {
+		t.Fatalf("PreviewObject(big, confirmed) error = %v", err)
+	}
+	if len(data) != 64 {
+		t.Errorf("confirmed preview returned %d bytes, want 64", len(data))
+	}
+}
+
+func TestCheckSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    int64
+		limit   int64
+		wantErr bool
+	}{
+		{"under", 10, 100, false},
+		{"equal", 100, 100, false},
+		{"over", 101, 100, true},
+		{"no limit", 1 << 40, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckSize(tt.size, tt.limit)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckSize(%d, %d) error = %v, wantErr %v", tt.size, tt.limit, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPreviewObjectRanged(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("b", "small.txt", fakeObject{body: []byte("hello")})
+	fake.put("b", "empty.txt", fakeObject{})
+	client := &Client{S3: fake}
+	ctx := context.Background()
+
+	// A compliant server answers 206 with a matching Content-Range
+	data, err := client.PreviewObject(ctx, "b", "small.txt", 32, false)
+	if err != nil {
+		t.Fatalf("PreviewObject() error = %v", err)
+	}
+	if string(data) != "hello" {
+		t.Errorf("PreviewObject() = %q, want hello", data)
+	}
+
+	// Empty objects are fetched without a range, which S3 would refuse
+	if data, err := client.PreviewObject(ctx, "b", "empty.txt", 32, false); err != nil || len(data) != 0 {
+		t.Errorf("PreviewObject(empty) = %q, %v", data, err)
+	}
+
+	fake.contentRange = "bytes 8-12/64"
+	if _, err := client.PreviewObject(ctx, "b", "small.txt", 32, false); err == nil {
+		t.Error("expected an error for a Content-Range that doesn't start at zero")
+	}
+}
+
+func TestPreviewObjectTruncatesIgnoredRange(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("b", "grows.bin", fakeObject{body: bytes.Repeat([]byte{'x'}, 16)})
+	fake.ignoreRange = true
+	client := &Client{S3: fake}
+
+	// The object grows between HEAD and GET, and the server sends all of it
+	fake.errFor = func(op, key string) error {
+		if op == "GetObject" {
+			fake.put("b", "grows.bin", fakeObject{body: bytes.Repeat([]byte{'x'}, 1024)})
+		}
+		return nil
+	}
+
+	data, err := client.PreviewObject(context.Background(), "b", "grows.bin", 32, false)
+	if err != nil {
+		t.Fatalf("PreviewObject() error = %v", err)
+	}
+	if len(data) != 32 {
+		t.Errorf("PreviewObject() read %d bytes from a plain 200, want the 32-byte cap", len(data))
+	}
+}
+
+func TestPreviewObjectConfirmedHasCeiling(t *testing.T) {
+	saved := maxPreviewBytes
+	maxPreviewBytes = 48
+	defer func() { maxPreviewBytes = saved }()
+
+	fake := newFakeS3()
+	fake.put("b", "big.bin", fakeObject{body: bytes.Repeat([]byte{'x'}, 1024)})
+	client := &Client{S3: fake}
+
+	data, err := client.PreviewObject(context.Background(), "b", "big.bin", 32, true)
+	if err != nil {
+		t.Fatalf("PreviewObject(confirmed) error = %v", err)
+	}
+	if len(data) != 48 {
+		t.Errorf("confirmed preview read %d bytes, want the 48-byte ceiling", len(data))
+	}
+}
@@ -0,0 +1,56 @@
+package aws
+
+import (
+	"errors"
+	"fmt"
+	"path"
+)
+
+// ErrProtectedBucket is returned when a mutating operation targets a protected bucket
+var ErrProtectedBucket = errors.New("bucket is protected from modification")
+
+// ErrReadOnlyMode is returned by every mutating operation when the client is read-only
+var ErrReadOnlyMode = errors.New("read-only mode: changes are disabled")
+
+// ErrAnonymousMode is returned by every mutating operation when the client
+// sends unsigned requests. It wraps ErrReadOnlyMode.
+var ErrAnonymousMode = fmt.Errorf("%w: browsing anonymously without credentials", ErrReadOnlyMode)
+
+// IsProtected reports whether bucket matches any of the configured
+// protected-bucket patterns. Patterns use path.Match glob syntax (e.g. "prod-*").
+func (o ClientOptions) IsProtected(bucket string) bool {
+	for _, pattern := range o.ProtectedBuckets {
+		if ok, _ := path.Match(pattern, bucket); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// checkWritable returns ErrAnonymousMode or ErrReadOnlyMode if the client
+// can't make changes, or ErrProtectedBucket if any bucket is protected.
+// Every mutating operation calls it before sending anything.
+func (c *Client) checkWritable(buckets ...string) error {
+	if c.Options.Anonymous {
+		return ErrAnonymousMode
+	}
+	if c.Options.ReadOnly {
+		return ErrReadOnlyMode
+	}
+	for _, bucket := range buckets {
+		if c.Options.IsProtected(bucket) {
+			return fmt.Errorf("%w: %s", ErrProtectedBucket, bucket)
+		}
+	}
+	return nil
+}
+
+// validatePatterns checks that every protected-bucket pattern is well formed
+func validatePatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid protected bucket pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProtectedBucketsBlockMutations(t *testing.T) {
+	tests := []struct {
+		name        string
+		bucket      string
+		wantBlocked bool
+	}{
+		{"exact match", "billing-ledger", true},
+		{"glob match", "prod-assets", true},
+		{"not protected", "dev-assets", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeS3()
+			fake.put(tt.bucket, "a.txt", fakeObject{body: []byte("a")})
+			client := &Client{S3: fake, Options: ClientOptions{
+				ProtectedBuckets: []string{"billing-ledger", "prod-*"},
+			}}
+
+			_, err := client.DeleteObjects(context.Background(), tt.bucket, []string{"a.txt"})
+			if got := errors.Is(err, ErrProtectedBucket); got != tt.wantBlocked {
+				t.Fatalf("DeleteObjects() error = %v, want blocked %v", err, tt.wantBlocked)
+			}
+
+			_, exists := fake.get(tt.bucket, "a.txt")
+			if tt.wantBlocked {
+				if !exists {
+					t.Error("protected object was deleted")
+				}
+				if len(fake.calls) != 0 {
+					t.Errorf("expected no requests for a protected bucket, got %v", fake.calls)
+				}
+			} else if exists {
+				t.Error("expected object to be deleted")
+			}
+		})
+	}
+}
+
+func TestProtectedBucketBlocksMoveAndDeleteBucket(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("prod-assets", "a.txt", fakeObject{body: []byte("a")})
+	client := &Client{S3: fake, Options: ClientOptions{ProtectedBuckets: []string{"prod-*"}}}
+	ctx := context.Background()
+
+	if err := client.MoveObject(ctx, "prod-assets", "a.txt", "dev", "a.txt", false); !errors.Is(err, ErrProtectedBucket) {
+		t.Errorf("MoveObject() from protected bucket error = %v", err)
+	}
+	if err := client.RenameObject(ctx, "prod-assets", "a.txt", "b.txt", false); !errors.Is(err, ErrProtectedBucket) {
+		t.Errorf("RenameObject() in protected bucket error = %v", err)
+	}
+	if err := client.DeleteBucket(ctx, "prod-assets"); !errors.Is(err, ErrProtectedBucket) {
+		t.Errorf("DeleteBucket() error = %v", err)
+	}
+	if len(fake.calls) != 0 {
+		t.Errorf("expected no requests, got %v", fake.calls)
+	}
+}
+
+func TestDeleteObjectsReportsFailures(t *testing.T) {
+	fake := newFakeS3()
+	for _, k := range []string{"a", "b", "c"} {
+		fake.put("b", k, fakeObject{body: []byte(k)})
+	}
+	fake.errFor = func(op, key string) error {
+		if op == "DeleteObjects.Key" && key == "b" {
+			return errors.New("AccessDenied")
+		}
+		return nil
+	}
+	client := &Client{S3: fake}
+
+	failures, err := client.DeleteObjects(context.Background(), "b", []string{"a", "b", "c"})
+	if err != nil {
+		t.Fatalf("DeleteObjects() error = %v", err)
+	}
+	if len(failures) != 1 || failures[0].Key != "b" {
+		t.Errorf("failures = %+v, want only b", failures)
+	}
+}
+
+func TestValidateProtectedPatterns(t *testing.T) {
+	if err := (ClientOptions{ProtectedBuckets: []string{"prod-[a"}}).Validate(); err == nil {
+		t.Error("expected malformed pattern to be rejected")
+	}
+}
+
+// mutations calls every mutating operation on client against bucket "b"
+func mutations(t *testing.T, client *Client) map[string]func() error {
+	ctx := context.Background()
+	until := time.Now().Add(24 * time.Hour)
+
+	return map[string]func() error{
+		"UploadStream": func() error {
+			return client.UploadStream(ctx, "b", "new.txt", strings.NewReader("x"), UploadOptions{})
+		},
+		"Upload": func() error {
+			_, err := client.NewUploader(OnExistsOverwrite).Upload(ctx, "b", "new.txt", strings.NewReader("x"))
+			return err
+		},
+		"UploadDir": func() error {
+			_, err := client.NewUploader(OnExistsOverwrite).UploadDir(ctx, t.TempDir(), "b", "")
+			return err
+		},
+		"DeleteObjects": func() error {
+			_, err := client.DeleteObjects(ctx, "b", []string{"a.txt"})
+			return err
+		},
+		"DeleteBucket": func() error { return client.DeleteBucket(ctx, "b") },
+		"CopyObject":   func() error { return client.CopyObject(ctx, "b", "a.txt", "b", "c.txt", false) },
+		"CopyObjectWithMeta": func() error {
+			return client.CopyObjectWithMeta(ctx, "b", "a.txt", "b", "a.txt", MetaOverrides{ContentType: "text/plain"}, false)
+		},
+		"MoveObject":   func() error { return client.MoveObject(ctx, "b", "a.txt", "b", "c.txt", false) },
+		"RenameObject": func() error { return client.RenameObject(ctx, "b", "a.txt", "c.txt", false) },
+		"RenamePrefix": func() error {
+			_, err := client.RenamePrefix(ctx, "b", "x/", "y/")
+			return err
+		},
+		"TouchObject":        func() error { return client.TouchObject(ctx, "b", "a.txt") },
+		"SetPublicRead":      func() error { return client.SetPublicRead(ctx, "b", "a.txt") },
+		"SetObjectRetention": func() error { return client.SetObjectRetention(ctx, "b", "a.txt", RetentionGovernance, until) },
+		"Restore": func() error {
+			return client.Restore(ctx, DeletionEntry{Bucket: "b", Key: "a.txt", VersionID: "v1"})
+		},
+		"CrossAccountCopy": func() error {
+			_, err := CrossAccountCopy(ctx, SrcLocation{Client: client, Bucket: "b", Key: "a.txt"}, DstLocation{Client: client, Bucket: "b", Key: "c.txt"})
+			return err
+		},
+		"MoveSelection": func() error {
+			_, err := client.MoveSelection(ctx, "b", []string{"a.txt"}, "dst/", OnExistsFail)
+			return err
+		},
+		"AbortIncompleteUploads": func() error {
+			_, err := client.AbortIncompleteUploads(ctx, "b", time.Hour)
+			return err
+		},
+		"AbortOrphanedUploads": func() error {
+			_, err := client.NewUploader(OnExistsFail).AbortOrphanedUploads(ctx, "b")
+			return err
+		},
+		"ArchiveToLocal": func() error {
+			_, err := client.ArchiveToLocal(ctx, "b", "a.txt", t.TempDir())
+			return err
+		},
+	}
+}
+
+func TestReadOnlyModeBlocksMutations(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("b", "a.txt", fakeObject{body: []byte("a")})
+	client := &Client{S3: fake, Options: ClientOptions{ReadOnly: true}}
+
+	for name, mutate := range mutations(t, client) {
+		t.Run(name, func(t *testing.T) {
+			if err := mutate(); !errors.Is(err, ErrReadOnlyMode) {
+				t.Errorf("%s error = %v, want ErrReadOnlyMode", name, err)
+			}
+		})
+	}
+	if len(fake.calls) != 0 {
+		t.Errorf("expected no requests in read-only mode, got %v", fake.calls)
+	}
+	if _, ok := fake.get("b", "a.txt"); !ok {
+		t.Error("object was removed in read-only mode")
+	}
+}
+
+func TestReadOnlyModeAllowsReads(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("b", "docs/a.txt", fakeObject{body: []byte("hello")})
+	client := &Client{S3: fake, Options: ClientOptions{ReadOnly: true}}
+	ctx := context.Background()
+
+	if objects, err := client.ListObjects(ctx, "b", "docs/"); err != nil || len(objects) != 1 {
+		t.Errorf("ListObjects() = %v, %v", objects, err)
+	}
+	if _, err := client.GetObjectMetadata(ctx, "b", "docs/a.txt"); err != nil {
+		t.Errorf("GetObjectMetadata() error = %v", err)
+	}
+	body, err := client.PreviewObject(ctx, "b", "docs/a.txt", 0, true)
+	if err != nil || string(body) != "hello" {
+		t.Errorf("PreviewObject() = %q, %v", body, err)
+	}
+}
+
+func TestAnonymousModeBlocksMutations(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("b", "a.txt", fakeObject{body: []byte("a")})
+	client := &Client{S3: fake, Options: ClientOptions{Anonymous: true}}
+
+	for name, mutate := range mutations(t, client) {
+		t.Run(name, func(t *testing.T) {
+			err := mutate()
+			if !errors.Is(err, ErrAnonymousMode) || !errors.Is(err, ErrReadOnlyMode) {
+				t.Errorf("%s error = %v, want ErrAnonymousMode", name, err)
+			}
+		})
+	}
+	if len(fake.calls) != 0 {
+		t.Errorf("expected no requests in anonymous mode, got %v", fake.calls)
+	}
+	if _, err := client.PresignGet(context.Background(), "b", "a.txt", time.Hour, time.Time{}); err == nil {
+		t.Error("PresignGet() succeeded without credentials")
+	}
+}
@@ -0,0 +1,160 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3control"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// PublicAccessBlock is one level of S3 Block Public Access settings
+type PublicAccessBlock struct {
+	BlockPublicACLs       bool
+	IgnorePublicACLs      bool
+	BlockPublicPolicy     bool
+	RestrictPublicBuckets bool
+}
+
+// PABStatus combines the account and bucket public-access-block settings.
+// A nil level has no configuration; S3 applies whichever setting is stricter.
+type PABStatus struct {
+	Account *PublicAccessBlock
+	Bucket  *PublicAccessBlock
+	// AccountUnknown is set when the account settings couldn't be read
+	AccountUnknown bool
+}
+
+// Effective returns the settings S3 enforces for the bucket
+func (s PABStatus) Effective() PublicAccessBlock {
+	var e PublicAccessBlock
+	for _, level := range []*PublicAccessBlock{s.Account, s.Bucket} {
+		if level == nil {
+			continue
+		}
+		e.BlockPublicACLs = e.BlockPublicACLs || level.BlockPublicACLs
+		e.IgnorePublicACLs = e.IgnorePublicACLs || level.IgnorePublicACLs
+		e.BlockPublicPolicy = e.BlockPublicPolicy || level.BlockPublicPolicy
+		e.RestrictPublicBuckets = e.RestrictPublicBuckets || level.RestrictPublicBuckets
+	}
+	return e
+}
+
+// PublicACLWarning describes what setting a public-read ACL would do
+func (s PABStatus) PublicACLWarning() string {
+	e := s.Effective()
+	switch {
+	case e.BlockPublicACLs:
+		return "Block Public Access rejects public ACLs here; the change will fail."
+	case e.IgnorePublicACLs:
+		return "Block Public Access ignores public ACLs here; the object will stay private."
+	case s.AccountUnknown:
+		return "Account-level Block Public Access couldn't be checked; the object may become readable by anyone."
+	default:
+		return "No Block Public Access setting applies; the object will be readable by anyone on the internet."
+	}
+}
+
+// AccountSettingsAPI reads account-wide S3 settings
+type AccountSettingsAPI interface {
+	GetAccountPublicAccessBlock(ctx context.Context) (*PublicAccessBlock, error)
+}
+
+// PublicAccessStatus reads the account and bucket public-access-block settings
+// that decide whether a public ACL on bucket takes effect
+func (c *Client) PublicAccessStatus(ctx context.Context, bucket string) (PABStatus, error) {
+	var status PABStatus
+
+	output, err := c.S3.GetPublicAccessBlock(ctx, &s3.GetPublicAccessBlockInput{
+		Bucket: aws.String(bucket),
+	})
+	switch {
+	case err == nil:
+		status.Bucket = publicAccessBlockFrom(output.PublicAccessBlockConfiguration)
+	case !hasErrorCode(err, "NoSuchPublicAccessBlockConfiguration"):
+		return status, &OpError{Op: "Reading public access settings", Bucket: bucket, Err: err}
+	}
+
+	if c.Account == nil {
+		status.AccountUnknown = true
+		return status, nil
+	}
+	// Reading the account settings needs its own permission; treat failure as unknown
+	if account, err := c.Account.GetAccountPublicAccessBlock(ctx); err != nil {
+		status.AccountUnknown = true
+	} else {
+		status.Account = account
+	}
+	return status, nil
+}
+
+// publicAccessBlockFrom converts the SDK configuration, treating nil as unset
+func publicAccessBlockFrom(cfg *types.PublicAccessBlockConfiguration) *PublicAccessBlock {
+	if cfg == nil {
+		return nil
+	}
+	return &PublicAccessBlock{
+		BlockPublicACLs:       aws.ToBool(cfg.BlockPublicAcls),
+		IgnorePublicACLs:      aws.ToBool(cfg.IgnorePublicAcls),
+		BlockPublicPolicy:     aws.ToBool(cfg.BlockPublicPolicy),
+		RestrictPublicBuckets: aws.ToBool(cfg.RestrictPublicBuckets),
+	}
+}
+
+// SetPublicRead grants everyone read access to an object with a public-read ACL
+func (c *Client) SetPublicRead(ctx context.Context, bucket, key string) error {
+	if err := c.checkWritable(bucket); err != nil {
+		return err
+	}
+	_, err := c.S3.PutObjectAcl(ctx, &s3.PutObjectAclInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		ACL:    types.ObjectCannedACLPublicRead,
+	})
+	if err != nil {
+		return &OpError{Op: "Setting object ACL", Bucket: bucket, Key: key, Err: err}
+	}
+	return nil
+}
+
+// accountSettings reads account-level settings from the S3 Control API
+type accountSettings struct {
+	cfg aws.Config
+	// accountID is looked up with STS when empty
+	accountID string
+}
+
+// GetAccountPublicAccessBlock returns the account's settings, or nil if none are configured
+func (a *accountSettings) GetAccountPublicAccessBlock(ctx context.Context) (*PublicAccessBlock, error) {
+	accountID := a.accountID
+	if accountID == "" {
+		identity, err := sts.NewFromConfig(a.cfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up account: %w", err)
+		}
+		accountID = aws.ToString(identity.Account)
+	}
+
+	output, err := s3control.NewFromConfig(a.cfg).GetPublicAccessBlock(ctx, &s3control.GetPublicAccessBlockInput{
+		AccountId: aws.String(accountID),
+	})
+	if err != nil {
+		if hasErrorCode(err, "NoSuchPublicAccessBlockConfiguration") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read account public access block: %w", err)
+	}
+	cfg := output.PublicAccessBlockConfiguration
+	if cfg == nil {
+		return nil, nil
+	}
+	return &PublicAccessBlock{
+		BlockPublicACLs:       aws.ToBool(cfg.BlockPublicAcls),
+		IgnorePublicACLs:      aws.ToBool(cfg.IgnorePublicAcls),
+		BlockPublicPolicy:     aws.ToBool(cfg.BlockPublicPolicy),
+		RestrictPublicBuckets: aws.ToBool(cfg.RestrictPublicBuckets),
+	}, nil
+}
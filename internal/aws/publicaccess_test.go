@@ -0,0 +1,184 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// fakeAccount is an AccountSettingsAPI returning fixed settings
+type fakeAccount struct {
+	block *PublicAccessBlock
+	err   error
+}
+
+func (f fakeAccount) GetAccountPublicAccessBlock(ctx context.Context) (*PublicAccessBlock, error) {
+	return f.block, f.err
+}
+
+func TestPublicAccessStatus(t *testing.T) {
+	tests := []struct {
+		name        string
+		bucket      *types.PublicAccessBlockConfiguration
+		account     AccountSettingsAPI
+		want        PublicAccessBlock
+		wantUnknown bool
+		wantWarning string
+	}{
+		{
+			name:        "no public access block",
+			account:     fakeAccount{},
+			wantWarning: "readable by anyone on the internet",
+		},
+		{
+			name: "bucket blocks public ACLs",
+			bucket: &types.PublicAccessBlockConfiguration{
+				BlockPublicAcls:   aws.Bool(true),
+				BlockPublicPolicy: aws.Bool(true),
+			},
+			account:     fakeAccount{},
+			want:        PublicAccessBlock{BlockPublicACLs: true, BlockPublicPolicy: true},
+			wantWarning: "will fail",
+		},
+		{
+			name:        "account ignores public ACLs",
+			account:     fakeAccount{block: &PublicAccessBlock{IgnorePublicACLs: true}},
+			want:        PublicAccessBlock{IgnorePublicACLs: true},
+			wantWarning: "stay private",
+		},
+		{
+			name:        "account settings unreadable",
+			account:     fakeAccount{err: errors.New("access denied")},
+			wantUnknown: true,
+			wantWarning: "couldn't be checked",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeS3()
+			if tt.bucket != nil {
+				fake.publicAccessBlocks["b"] = tt.bucket
+			}
+			client := &Client{S3: fake, Account: tt.account}
+
+			status, err := client.PublicAccessStatus(context.Background(), "b")
+			if err != nil {
+				t.Fatalf("PublicAccessStatus() error = %v", err)
+			}
+			if got := status.Effective(); got != tt.want {
+				t.Errorf("Effective() = %+v, want %+v", got, tt.want)
+			}
+			if status.AccountUnknown != tt.wantUnknown {
+				t.Errorf("AccountUnknown = %v, want %v", status.AccountUnknown, tt.wantUnknown)
+			}
+			if got := status.PublicACLWarning(); !strings.Contains(got, tt.wantWarning) {
+				t.Errorf("PublicACLWarning() = %q, want it to mention %q", got, tt.wantWarning)
+			}
+		})
+	}
+}
+
+// handlerTransport serves requests to any host from handler, so a test can
+// answer the S3 Control endpoint, whose host carries the account ID
+type handlerTransport http.HandlerFunc
+
+func (h handlerTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	rec := httptest.NewRecorder()
+	h(rec, r)
+	return rec.Result(), nil
+}
+
+func TestAccountSettings(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    *PublicAccessBlock
+		wantErr bool
+	}{
+		{
+			name:   "configured",
+			status: http.StatusOK,
+			body: `<?xml version="1.0" encoding="UTF-8"?>
+<PublicAccessBlockConfiguration>
+  <BlockPublicAcls>true</BlockPublicAcls>
+  <IgnorePublicAcls>false</IgnorePublicAcls>
+  <BlockPublicPolicy>true</BlockPublicPolicy>
+  <RestrictPublicBuckets>false</RestrictPublicBuckets>
+</PublicAccessBlockConfiguration>`,
+			want: &PublicAccessBlock{BlockPublicACLs: true, BlockPublicPolicy: true},
+		},
+		{
+			name:   "not configured",
+			status: http.StatusNotFound,
+			body:   `<ErrorResponse><Error><Code>NoSuchPublicAccessBlockConfiguration</Code><Message>none</Message></Error></ErrorResponse>`,
+		},
+		{
+			name:    "access denied",
+			status:  http.StatusForbidden,
+			body:    `<ErrorResponse><Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error></ErrorResponse>`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/v20180820/configuration/publicAccessBlock" {
+					t.Errorf("path = %q", r.URL.Path)
+				}
+				if got := r.Header.Get("x-amz-account-id"); got != "123456789012" {
+					t.Errorf("x-amz-account-id = %q", got)
+				}
+				if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256") {
+					t.Errorf("request is not SigV4 signed")
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}
+			a := &accountSettings{
+				cfg: aws.Config{
+					Region: "us-east-1",
+					Credentials: aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+						return aws.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}, nil
+					}),
+					HTTPClient:       &http.Client{Transport: handlerTransport(handler)},
+					RetryMaxAttempts: 1,
+				},
+				accountID: "123456789012",
+			}
+
+			got, err := a.GetAccountPublicAccessBlock(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetAccountPublicAccessBlock() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetPublicRead(t *testing.T) {
+	fake := newFakeS3()
+	client := &Client{S3: fake}
+
+	if err := client.SetPublicRead(context.Background(), "b", "a.txt"); err != nil {
+		t.Fatalf("SetPublicRead() error = %v", err)
+	}
+	if got := fake.acls["b/a.txt"]; got != types.ObjectCannedACLPublicRead {
+		t.Errorf("ACL = %q, want public-read", got)
+	}
+
+	client.Options.ProtectedBuckets = []string{"b"}
+	if err := client.SetPublicRead(context.Background(), "b", "a.txt"); !errors.Is(err, ErrProtectedBucket) {
+		t.Errorf("SetPublicRead() on protected bucket error = %v, want ErrProtectedBucket", err)
+	}
+}
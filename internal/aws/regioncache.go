@@ -0,0 +1,38 @@
+package aws
+
+import "sync"
+
+// BucketRegionCache remembers where buckets live, so GetBucketRegion asks
+// S3 once per bucket. Regions don't change without deleting the bucket.
+// NewClient creates one shared the same way as Metrics. A nil
+// *BucketRegionCache remembers nothing.
+type BucketRegionCache struct {
+	mu      sync.Mutex
+	regions map[string]string
+}
+
+// NewBucketRegionCache creates an empty cache
+func NewBucketRegionCache() *BucketRegionCache {
+	return &BucketRegionCache{regions: make(map[string]string)}
+}
+
+// Get returns bucket's region, if known
+func (c *BucketRegionCache) Get(bucket string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	region, ok := c.regions[bucket]
+	return region, ok
+}
+
+// Set records bucket's region; an empty region is ignored
+func (c *BucketRegionCache) Set(bucket, region string) {
+	if c == nil || region == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.regions[bucket] = region
+}
@@ -0,0 +1,534 @@
+package aws
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"github.com/natevick/stui/internal/security"
+)
+
+// ErrUploadStale is returned when resuming an upload whose local file has
+// changed since the upload started
+var ErrUploadStale = errors.New("local file changed since the upload started")
+
+// UploadRecord is a multipart upload of a local file in progress, saved so
+// it can be resumed or cleaned up after a crash
+type UploadRecord struct {
+	Bucket    string `json:"bucket"`
+	Key       string `json:"key"`
+	UploadID  string `json:"upload_id"`
+	LocalPath string `json:"local_path"`
+	// Size and ModTime identify the version of the file being uploaded
+	Size        int64     `json:"size"`
+	ModTime     time.Time `json:"mod_time"`
+	PartSize    int64     `json:"part_size"`
+	ContentType string    `json:"content_type,omitempty"`
+	// Parts maps the numbers of parts already uploaded to their ETags
+	Parts   map[int32]string `json:"parts"`
+	Started time.Time        `json:"started"`
+}
+
+// partCount returns how many parts the file splits into
+func (r UploadRecord) partCount() int32 {
+	return int32((r.Size + r.PartSize - 1) / r.PartSize)
+}
+
+// partLength returns the size of part n; only the last part is short
+func (r UploadRecord) partLength(n int32) int64 {
+	return min(r.PartSize, r.Size-int64(n-1)*r.PartSize)
+}
+
+// matches reports whether info still describes the file being uploaded
+func (r UploadRecord) matches(info os.FileInfo) bool {
+	return info.Size() == r.Size && info.ModTime().Equal(r.ModTime)
+}
+
+// UploadJournal keeps one JSON file per multipart upload in a directory, so
+// uploads interrupted by a crash can be found on the next run. It is safe
+// for concurrent use.
+type UploadJournal struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewUploadJournal creates a journal stored in dir, which is created on the
+// first save
+func NewUploadJournal(dir string) *UploadJournal {
+	return &UploadJournal{dir: dir}
+}
+
+// path returns the file holding the record for an upload ID
+func (j *UploadJournal) path(uploadID string) string {
+	sum := sha256.Sum256([]byte(uploadID))
+	return filepath.Join(j.dir, hex.EncodeToString(sum[:8])+".json")
+}
+
+// Save writes rec, replacing any earlier version of it
+func (j *UploadJournal) Save(rec UploadRecord) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if err := os.MkdirAll(j.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create upload journal: %w", err)
+	}
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode upload record: %w", err)
+	}
+	// Write then rename so a crash mid-write never leaves a torn record
+	path := j.path(rec.UploadID)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to save upload record: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to save upload record: %w", err)
+	}
+	return nil
+}
+
+// Remove deletes the record for rec's upload, if there is one
+func (j *UploadJournal) Remove(rec UploadRecord) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err := os.Remove(j.path(rec.UploadID)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove upload record: %w", err)
+	}
+	return nil
+}
+
+// Records returns the saved uploads, oldest first. Files that can't be
+// parsed are skipped.
+func (j *UploadJournal) Records() ([]UploadRecord, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	entries, err := os.ReadDir(j.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload journal: %w", err)
+	}
+
+	var records []UploadRecord
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(j.dir, e.Name()))
+		if err != nil {
+			continue
+		}
+		var rec UploadRecord
+		if json.Unmarshal(data, &rec) != nil || rec.UploadID == "" || rec.PartSize <= 0 {
+			continue
+		}
+		records = append(records, rec)
+	}
+	slices.SortFunc(records, func(a, b UploadRecord) int {
+		return a.Started.Compare(b.Started)
+	})
+	return records, nil
+}
+
+// Find returns the saved upload of localPath to bucket/key, if any
+func (j *UploadJournal) Find(bucket, key, localPath string) (UploadRecord, bool, error) {
+	records, err := j.Records()
+	if err != nil {
+		return UploadRecord{}, false, err
+	}
+	for _, rec := range records {
+		if rec.Bucket == bucket && rec.Key == key && rec.LocalPath == localPath {
+			return rec, true, nil
+		}
+	}
+	return UploadRecord{}, false, nil
+}
+
+// UploadFile uploads a local file to bucket/key. With a Journal, files
+// larger than one part are sent as a journaled multipart upload, and an
+// earlier interrupted upload of the same unchanged file is resumed instead
+// of starting over. Under SkipUnchanged, identical content already at key
+// is reported as Skipped.
+func (u *Uploader) UploadFile(ctx context.Context, localPath, bucket, key string) (UploadResult, error) {
+	res, unchanged, err := u.uploadFile(ctx, localPath, bucket, key)
+	if unchanged {
+		res.Skipped = true
+	}
+	return res, err
+}
+
+// uploadJournaled uploads a file as a multipart upload recorded in u.Journal.
+// Files that fit in one part go through Upload unchanged.
+func (u *Uploader) uploadJournaled(ctx context.Context, path, bucket, key string) (UploadResult, error) {
+	// Records are matched by path on later runs, whatever the working directory
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return UploadResult{}, fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return UploadResult{}, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	partSize := streamPartSize(u.Options.PartSize)
+	contentType := u.Options.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(path))
+	}
+
+	if info.Size() <= partSize {
+		file, err := os.Open(path)
+		if err != nil {
+			return UploadResult{}, fmt.Errorf("failed to open %s: %w", path, err)
+		}
+		defer file.Close()
+		fu := *u
+		fu.Options.ContentType = contentType
+		return fu.Upload(ctx, bucket, key, file)
+	}
+
+	if rec, ok, err := u.Journal.Find(bucket, key, path); err != nil {
+		return UploadResult{}, err
+	} else if ok && rec.matches(info) {
+		return u.Resume(ctx, rec)
+	}
+
+	if err := u.client.checkWritable(bucket); err != nil {
+		return UploadResult{}, err
+	}
+	if err := security.ValidNewObjectKey(key); err != nil {
+		return UploadResult{}, err
+	}
+	target, skip, err := u.resolveKey(ctx, bucket, key)
+	if err != nil {
+		return UploadResult{}, err
+	}
+	if skip {
+		return UploadResult{Key: key, Skipped: true}, nil
+	}
+
+	var ct *string
+	if contentType != "" {
+		ct = aws.String(contentType)
+	}
+	created, err := u.client.S3.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:       aws.String(bucket),
+		Key:          aws.String(target),
+		ContentType:  ct,
+		Metadata:     u.Options.Metadata,
+		StorageClass: u.storageClass(bucket),
+	})
+	if err != nil {
+		return UploadResult{}, &OpError{Op: "Uploading", Bucket: bucket, Key: target, Err: fmt.Errorf("failed to start multipart upload: %w", err)}
+	}
+
+	rec := UploadRecord{
+		Bucket:      bucket,
+		Key:         target,
+		UploadID:    aws.ToString(created.UploadId),
+		LocalPath:   path,
+		Size:        info.Size(),
+		ModTime:     info.ModTime(),
+		PartSize:    partSize,
+		ContentType: contentType,
+		Parts:       make(map[int32]string),
+		Started:     time.Now(),
+	}
+	if err := u.Journal.Save(rec); err != nil {
+		// An upload nobody can find again would only leak parts
+		u.client.abortUpload(context.WithoutCancel(ctx), rec)
+		return UploadResult{}, err
+	}
+	return u.finishUpload(ctx, rec)
+}
+
+// Resume continues a journaled upload. The parts S3 already holds are
+// listed with ListParts and only the missing ones are sent. The local file
+// must not have changed since the upload started; if S3 no longer knows the
+// upload, its record is dropped.
+func (u *Uploader) Resume(ctx context.Context, rec UploadRecord) (UploadResult, error) {
+	if err := u.client.checkWritable(rec.Bucket); err != nil {
+		return UploadResult{}, err
+	}
+	info, err := os.Stat(rec.LocalPath)
+	if err != nil {
+		return UploadResult{}, fmt.Errorf("failed to stat %s: %w", rec.LocalPath, err)
+	}
+	if !rec.matches(info) {
+		return UploadResult{}, fmt.Errorf("%w: %s", ErrUploadStale, rec.LocalPath)
+	}
+
+	present, err := u.client.listParts(ctx, rec)
+	if err != nil {
+		if isNoSuchUpload(err) && u.Journal != nil {
+			u.Journal.Remove(rec)
+		}
+		return UploadResult{}, &OpError{Op: "Resuming upload", Bucket: rec.Bucket, Key: rec.Key, Err: err}
+	}
+
+	// S3 is the authority on what arrived; a part of the wrong size was
+	// cut short and is sent again
+	rec.Parts = make(map[int32]string)
+	for _, p := range present {
+		n := aws.ToInt32(p.PartNumber)
+		if n >= 1 && n <= rec.partCount() && aws.ToInt64(p.Size) == rec.partLength(n) {
+			rec.Parts[n] = aws.ToString(p.ETag)
+		}
+	}
+	if u.Journal != nil {
+		if err := u.Journal.Save(rec); err != nil {
+			return UploadResult{}, err
+		}
+	}
+	res, err := u.finishUpload(ctx, rec)
+	res.Resumed = err == nil
+	return res, err
+}
+
+// finishUpload sends the parts rec is missing, Options.Concurrency at a
+// time, and completes the upload, saving progress as each part arrives. On
+// failure the upload is left in place to be resumed.
+func (u *Uploader) finishUpload(ctx context.Context, rec UploadRecord) (UploadResult, error) {
+	fail := func(err error) (UploadResult, error) {
+		return UploadResult{}, &OpError{Op: "Uploading", Bucket: rec.Bucket, Key: rec.Key, Err: err}
+	}
+
+	file, err := os.Open(rec.LocalPath)
+	if err != nil {
+		return fail(fmt.Errorf("failed to open %s: %w", rec.LocalPath, err))
+	}
+	defer file.Close()
+
+	concurrency := u.Options.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultUploadConcurrency
+	}
+
+	// The first failure stops the parts still to be sent
+	partCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		mu       sync.Mutex // guards rec.Parts and firstErr
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	setErr := func(err error) {
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+	var missing []int32
+	for n := int32(1); n <= rec.partCount(); n++ {
+		if _, done := rec.Parts[n]; !done {
+			missing = append(missing, n)
+		}
+	}
+
+	sem := make(chan struct{}, concurrency)
+send:
+	for _, n := range missing {
+		select {
+		case sem <- struct{}{}:
+		case <-partCtx.Done():
+			break send
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			length := rec.partLength(n)
+			out, err := u.client.S3.UploadPart(partCtx, &s3.UploadPartInput{
+				Bucket:        aws.String(rec.Bucket),
+				Key:           aws.String(rec.Key),
+				UploadId:      aws.String(rec.UploadID),
+				PartNumber:    aws.Int32(n),
+				Body:          io.NewSectionReader(file, int64(n-1)*rec.PartSize, length),
+				ContentLength: aws.Int64(length),
+			})
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				setErr(fmt.Errorf("failed to upload part %d: %w", n, err))
+				return
+			}
+			u.client.Options.Metrics.AddBytesUp(length)
+			rec.Parts[n] = aws.ToString(out.ETag)
+			if u.Journal != nil {
+				if err := u.Journal.Save(rec); err != nil {
+					setErr(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return fail(firstErr)
+	}
+	if err := ctx.Err(); err != nil {
+		return fail(err)
+	}
+
+	parts := make([]types.CompletedPart, 0, len(rec.Parts))
+	for n := int32(1); n <= rec.partCount(); n++ {
+		parts = append(parts, types.CompletedPart{ETag: aws.String(rec.Parts[n]), PartNumber: aws.Int32(n)})
+	}
+	_, err = u.client.S3.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(rec.Bucket),
+		Key:             aws.String(rec.Key),
+		UploadId:        aws.String(rec.UploadID),
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+	})
+	if err != nil {
+		if isNoSuchUpload(err) && u.Journal != nil {
+			u.Journal.Remove(rec)
+		}
+		return fail(fmt.Errorf("failed to complete multipart upload: %w", err))
+	}
+	if u.Journal != nil {
+		if err := u.Journal.Remove(rec); err != nil {
+			return UploadResult{Key: rec.Key}, err
+		}
+	}
+	return UploadResult{Key: rec.Key}, nil
+}
+
+// AbortUpload gives up on a journaled upload, deleting the parts S3 holds
+// for it and dropping its record
+func (u *Uploader) AbortUpload(ctx context.Context, rec UploadRecord) error {
+	if err := u.client.checkWritable(rec.Bucket); err != nil {
+		return err
+	}
+	if err := u.client.abortUpload(ctx, rec); err != nil && !isNoSuchUpload(err) {
+		return &OpError{Op: "Aborting upload", Bucket: rec.Bucket, Key: rec.Key, Err: err}
+	}
+	if u.Journal != nil {
+		return u.Journal.Remove(rec)
+	}
+	return nil
+}
+
+// AbortOrphanedUploads cleans up the journaled uploads to bucket that were
+// never finished. Uploads S3 still lists with ListMultipartUploads are
+// aborted; records of uploads S3 no longer has are dropped. Uploads started
+// by other tools aren't in the journal and are left alone. It returns the
+// uploads that were aborted.
+func (u *Uploader) AbortOrphanedUploads(ctx context.Context, bucket string) ([]UploadRecord, error) {
+	if err := u.client.checkWritable(bucket); err != nil {
+		return nil, err
+	}
+	if u.Journal == nil {
+		return nil, nil
+	}
+	records, err := u.Journal.Records()
+	if err != nil {
+		return nil, err
+	}
+	records = slices.DeleteFunc(records, func(r UploadRecord) bool { return r.Bucket != bucket })
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	live, err := u.client.listUploadIDs(ctx, bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	var aborted []UploadRecord
+	for _, rec := range records {
+		if live[rec.UploadID] {
+			if err := u.AbortUpload(ctx, rec); err != nil {
+				return aborted, err
+			}
+			aborted = append(aborted, rec)
+			continue
+		}
+		if err := u.Journal.Remove(rec); err != nil {
+			return aborted, err
+		}
+	}
+	return aborted, nil
+}
+
+// abortUpload sends AbortMultipartUpload for rec
+func (c *Client) abortUpload(ctx context.Context, rec UploadRecord) error {
+	_, err := c.S3.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(rec.Bucket),
+		Key:      aws.String(rec.Key),
+		UploadId: aws.String(rec.UploadID),
+	})
+	return err
+}
+
+// listParts returns every part S3 holds for rec's upload
+func (c *Client) listParts(ctx context.Context, rec UploadRecord) ([]types.Part, error) {
+	var parts []types.Part
+	var marker *string
+	for {
+		out, err := c.S3.ListParts(ctx, &s3.ListPartsInput{
+			Bucket:           aws.String(rec.Bucket),
+			Key:              aws.String(rec.Key),
+			UploadId:         aws.String(rec.UploadID),
+			PartNumberMarker: marker,
+		})
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, out.Parts...)
+		if !aws.ToBool(out.IsTruncated) || out.NextPartNumberMarker == nil {
+			return parts, nil
+		}
+		marker = out.NextPartNumberMarker
+	}
+}
+
+// listUploadIDs returns the IDs of the multipart uploads in progress in bucket
+func (c *Client) listUploadIDs(ctx context.Context, bucket string) (map[string]bool, error) {
+	uploads, err := c.ListIncompleteUploads(ctx, bucket, "")
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]bool, len(uploads))
+	for _, up := range uploads {
+		ids[up.UploadID] = true
+	}
+	return ids, nil
+}
+
+// isNoSuchUpload reports whether err means the multipart upload is gone,
+// because it was completed or aborted
+func isNoSuchUpload(err error) bool {
+	var noSuchUpload *types.NoSuchUpload
+	if errors.As(err, &noSuchUpload) {
+		return true
+	}
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && apiErr.ErrorCode() == "NoSuchUpload"
+}
+
+// streamPartSize applies the default and S3's minimum to a configured part size
+func streamPartSize(n int64) int64 {
+	if n <= 0 {
+		n = DefaultStreamPartSize
+	}
+	return max(n, MinStreamPartSize)
+}
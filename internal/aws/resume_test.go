@@ -0,0 +1,268 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// writeBigFile writes a file spanning three minimum-size parts
+func writeBigFile(t *testing.T) (string, []byte) {
+	t.Helper()
+	data := bytes.Repeat([]byte("0123456789abcdef"), (2*MinStreamPartSize+1024)/16)
+	path := filepath.Join(t.TempDir(), "big.bin")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path, data
+}
+
+// journaledUploader returns an uploader with a journal in a temp dir
+func journaledUploader(t *testing.T, fake *fakeS3) *Uploader {
+	t.Helper()
+	client := &Client{S3: fake, Options: ClientOptions{UploadJournal: NewUploadJournal(t.TempDir())}}
+	return client.NewUploader(OnExistsOverwrite)
+}
+
+func TestUploadFileResumesFromJournal(t *testing.T) {
+	fake := newFakeS3()
+	u := journaledUploader(t, fake)
+	path, data := writeBigFile(t)
+	info, _ := os.Stat(path)
+
+	// A previous run got the first part to S3 and crashed before saving its ETag
+	ctx := context.Background()
+	created, _ := fake.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{Bucket: aws.String("b"), Key: aws.String("big.bin")})
+	_, _ = fake.UploadPart(ctx, &s3.UploadPartInput{
+		Bucket:     aws.String("b"),
+		Key:        aws.String("big.bin"),
+		UploadId:   created.UploadId,
+		PartNumber: aws.Int32(1),
+		Body:       bytes.NewReader(data[:MinStreamPartSize]),
+	})
+	rec := UploadRecord{
+		Bucket:    "b",
+		Key:       "big.bin",
+		UploadID:  aws.ToString(created.UploadId),
+		LocalPath: path,
+		Size:      info.Size(),
+		ModTime:   info.ModTime(),
+		PartSize:  MinStreamPartSize,
+		Parts:     map[int32]string{},
+		Started:   time.Now(),
+	}
+	if err := u.Journal.Save(rec); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := u.UploadFile(ctx, path, "b", "big.bin")
+	if err != nil {
+		t.Fatalf("UploadFile() error = %v", err)
+	}
+	if res.Key != "big.bin" || !res.Resumed {
+		t.Errorf("UploadFile() = %+v, want resumed upload to big.bin", res)
+	}
+	if got := fake.countCalls("CreateMultipartUpload"); got != 1 {
+		t.Errorf("started %d uploads, want the journaled one resumed", got)
+	}
+	if got := fake.countCalls("UploadPart"); got != 3 {
+		t.Errorf("UploadPart called %d times, want 1 before the crash + 2 missing parts", got)
+	}
+	if obj, ok := fake.get("b", "big.bin"); !ok || !bytes.Equal(obj.body, data) {
+		t.Error("completed object doesn't match the file")
+	}
+	if records, _ := u.Journal.Records(); len(records) != 0 {
+		t.Errorf("journal still holds %d records", len(records))
+	}
+}
+
+func TestUploadFileInterruptedThenResumed(t *testing.T) {
+	fake := newFakeS3()
+	u := journaledUploader(t, fake)
+	u.Options.Concurrency = 1 // so the second part is the one that fails
+	path, data := writeBigFile(t)
+
+	parts := 0
+	fake.errFor = func(op, key string) error {
+		if op == "UploadPart" {
+			if parts++; parts == 2 {
+				return errors.New("connection reset")
+			}
+		}
+		return nil
+	}
+	if _, err := u.UploadFile(context.Background(), path, "b", "big.bin"); err == nil {
+		t.Fatal("UploadFile() error = nil, want interrupted upload")
+	}
+	records, _ := u.Journal.Records()
+	if len(records) != 1 || len(records[0].Parts) != 1 {
+		t.Fatalf("journal = %+v, want one upload with one part", records)
+	}
+	if fake.countCalls("AbortMultipartUpload") != 0 {
+		t.Error("interrupted upload was aborted instead of kept for resuming")
+	}
+
+	fake.errFor = nil
+	if _, err := u.UploadFile(context.Background(), path, "b", "big.bin"); err != nil {
+		t.Fatalf("UploadFile() retry error = %v", err)
+	}
+	if obj, ok := fake.get("b", "big.bin"); !ok || !bytes.Equal(obj.body, data) {
+		t.Error("resumed object doesn't match the file")
+	}
+	if got := fake.countCalls("CreateMultipartUpload"); got != 1 {
+		t.Errorf("started %d uploads, want 1", got)
+	}
+}
+
+func TestResumeRefusesChangedFile(t *testing.T) {
+	fake := newFakeS3()
+	u := journaledUploader(t, fake)
+	path, _ := writeBigFile(t)
+	info, _ := os.Stat(path)
+
+	rec := UploadRecord{Bucket: "b", Key: "big.bin", UploadID: "upload-x", LocalPath: path,
+		Size: info.Size() - 1, ModTime: info.ModTime(), PartSize: MinStreamPartSize}
+	if _, err := u.Resume(context.Background(), rec); !errors.Is(err, ErrUploadStale) {
+		t.Errorf("Resume() error = %v, want ErrUploadStale", err)
+	}
+}
+
+func TestResumeDropsRecordOfVanishedUpload(t *testing.T) {
+	fake := newFakeS3()
+	u := journaledUploader(t, fake)
+	path, _ := writeBigFile(t)
+	info, _ := os.Stat(path)
+
+	rec := UploadRecord{Bucket: "b", Key: "big.bin", UploadID: "gone", LocalPath: path,
+		Size: info.Size(), ModTime: info.ModTime(), PartSize: MinStreamPartSize, Started: time.Now()}
+	u.Journal.Save(rec)
+	if _, err := u.Resume(context.Background(), rec); err == nil {
+		t.Fatal("Resume() error = nil, want NoSuchUpload")
+	}
+	if records, _ := u.Journal.Records(); len(records) != 0 {
+		t.Errorf("journal still holds %d records", len(records))
+	}
+}
+
+func TestAbortOrphanedUploads(t *testing.T) {
+	fake := newFakeS3()
+	u := journaledUploader(t, fake)
+	ctx := context.Background()
+
+	start := func(bucket, key string) string {
+		out, _ := fake.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+		return aws.ToString(out.UploadId)
+	}
+	orphan := start("b", "crashed.bin")
+	otherBucket := start("other", "crashed.bin")
+	foreign := start("b", "someone-elses.bin")
+
+	for i, rec := range []UploadRecord{
+		{Bucket: "b", Key: "crashed.bin", UploadID: orphan},
+		{Bucket: "other", Key: "crashed.bin", UploadID: otherBucket},
+		// Completed or aborted elsewhere; only the record remains
+		{Bucket: "b", Key: "finished.bin", UploadID: "gone"},
+	} {
+		rec.PartSize = MinStreamPartSize
+		rec.Started = time.Unix(int64(i), 0)
+		if err := u.Journal.Save(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	aborted, err := u.AbortOrphanedUploads(ctx, "b")
+	if err != nil {
+		t.Fatalf("AbortOrphanedUploads() error = %v", err)
+	}
+	if len(aborted) != 1 || aborted[0].UploadID != orphan {
+		t.Errorf("aborted = %+v, want only %s", aborted, orphan)
+	}
+	if fake.countCalls("ListMultipartUploads") != 1 {
+		t.Error("live uploads weren't listed")
+	}
+
+	fake.mu.Lock()
+	_, orphanLive := fake.uploads[orphan]
+	_, foreignLive := fake.uploads[foreign]
+	_, otherLive := fake.uploads[otherBucket]
+	fake.mu.Unlock()
+	if orphanLive {
+		t.Error("orphaned upload still in S3")
+	}
+	if !foreignLive || !otherLive {
+		t.Error("uploads outside the journal or bucket were aborted")
+	}
+
+	records, _ := u.Journal.Records()
+	if len(records) != 1 || records[0].UploadID != otherBucket {
+		t.Errorf("journal = %+v, want only the other bucket's upload", records)
+	}
+}
+
+func TestUploadJournalSkipsCorruptRecords(t *testing.T) {
+	dir := t.TempDir()
+	j := NewUploadJournal(dir)
+	if err := j.Save(UploadRecord{Bucket: "b", Key: "k", UploadID: "u1", PartSize: MinStreamPartSize}); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "torn.json"), []byte(`{"bucket":`), 0600)
+
+	records, err := j.Records()
+	if err != nil {
+		t.Fatalf("Records() error = %v", err)
+	}
+	if len(records) != 1 || records[0].UploadID != "u1" {
+		t.Errorf("Records() = %+v", records)
+	}
+}
+
+// barrierS3 holds every UploadPart until want of them are in flight at once
+type barrierS3 struct {
+	*fakeS3
+	want    int
+	mu      sync.Mutex
+	arrived int
+	all     chan struct{}
+}
+
+func (b *barrierS3) UploadPart(ctx context.Context, in *s3.UploadPartInput, opts ...func(*s3.Options)) (*s3.UploadPartOutput, error) {
+	b.mu.Lock()
+	if b.arrived++; b.arrived == b.want {
+		close(b.all)
+	}
+	b.mu.Unlock()
+	select {
+	case <-b.all:
+	case <-time.After(5 * time.Second):
+		return nil, errors.New("parts were uploaded one at a time")
+	}
+	return b.fakeS3.UploadPart(ctx, in, opts...)
+}
+
+func TestUploadFileSendsPartsConcurrently(t *testing.T) {
+	fake := newFakeS3()
+	api := &barrierS3{fakeS3: fake, want: 3, all: make(chan struct{})}
+	client := &Client{S3: api, Options: ClientOptions{UploadJournal: NewUploadJournal(t.TempDir())}}
+	u := client.NewUploader(OnExistsOverwrite)
+	u.Options.PartSize = MinStreamPartSize
+	u.Options.Concurrency = 3
+	path, data := writeBigFile(t)
+
+	if _, err := u.UploadFile(context.Background(), path, "b", "big.bin"); err != nil {
+		t.Fatalf("UploadFile() error = %v", err)
+	}
+	if obj, ok := fake.get("b", "big.bin"); !ok || !bytes.Equal(obj.body, data) {
+		t.Error("completed object doesn't match the file")
+	}
+	if records, _ := u.Journal.Records(); len(records) != 0 {
+		t.Errorf("journal = %+v, want the finished upload removed", records)
+	}
+}
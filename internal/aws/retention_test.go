@@ -0,0 +1,141 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func TestGetObjectRetention(t *testing.T) {
+	until := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	fake := newFakeS3()
+	fake.retention["vault/ledger.csv"] = &types.ObjectLockRetention{
+		Mode:            types.ObjectLockRetentionModeCompliance,
+		RetainUntilDate: aws.Time(until),
+	}
+	client := &Client{S3: fake}
+
+	got, err := client.GetObjectRetention(context.Background(), "vault", "ledger.csv", "")
+	if err != nil {
+		t.Fatalf("GetObjectRetention() error = %v", err)
+	}
+	if got == nil || got.Mode != RetentionCompliance || !got.Until.Equal(until) {
+		t.Errorf("GetObjectRetention() = %+v, want COMPLIANCE until %v", got, until)
+	}
+
+	// No retention configured is not an error
+	got, err = client.GetObjectRetention(context.Background(), "vault", "other.csv", "")
+	if err != nil || got != nil {
+		t.Errorf("GetObjectRetention() without retention = %+v, %v; want nil, nil", got, err)
+	}
+}
+
+func TestSetObjectRetention(t *testing.T) {
+	ctx := context.Background()
+	future := time.Now().Add(30 * 24 * time.Hour).Truncate(time.Second)
+
+	tests := []struct {
+		name     string
+		existing *types.ObjectLockRetention
+		mode     RetentionMode
+		until    time.Time
+		wantErr  bool
+		wantPut  bool
+	}{
+		{
+			name:    "governance in the future",
+			mode:    RetentionGovernance,
+			until:   future,
+			wantPut: true,
+		},
+		{
+			name:    "past date rejected",
+			mode:    RetentionGovernance,
+			until:   time.Now().Add(-time.Hour),
+			wantErr: true,
+		},
+		{
+			name:    "unknown mode rejected",
+			mode:    "LEGAL",
+			until:   future,
+			wantErr: true,
+		},
+		{
+			name: "compliance can't be shortened",
+			existing: &types.ObjectLockRetention{
+				Mode:            types.ObjectLockRetentionModeCompliance,
+				RetainUntilDate: aws.Time(future.Add(time.Hour)),
+			},
+			mode:    RetentionCompliance,
+			until:   future,
+			wantErr: true,
+		},
+		{
+			name: "compliance can be extended",
+			existing: &types.ObjectLockRetention{
+				Mode:            types.ObjectLockRetentionModeCompliance,
+				RetainUntilDate: aws.Time(future.Add(-time.Hour)),
+			},
+			mode:    RetentionCompliance,
+			until:   future,
+			wantPut: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeS3()
+			if tt.existing != nil {
+				fake.retention["vault/ledger.csv"] = tt.existing
+			}
+			client := &Client{S3: fake}
+
+			err := client.SetObjectRetention(ctx, "vault", "ledger.csv", tt.mode, tt.until)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetObjectRetention() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if n := fake.countCalls("PutObjectRetention"); (n == 1) != tt.wantPut {
+				t.Fatalf("made %d PutObjectRetention calls, want put=%v", n, tt.wantPut)
+			}
+			if tt.wantPut {
+				got := fake.retention["vault/ledger.csv"]
+				if got.Mode != types.ObjectLockRetentionMode(tt.mode) || !got.RetainUntilDate.Equal(tt.until) {
+					t.Errorf("stored retention = %s until %v", got.Mode, got.RetainUntilDate)
+				}
+			}
+		})
+	}
+}
+
+func TestSetObjectRetentionShortenedError(t *testing.T) {
+	fake := newFakeS3()
+	fake.retention["vault/ledger.csv"] = &types.ObjectLockRetention{
+		Mode:            types.ObjectLockRetentionModeCompliance,
+		RetainUntilDate: aws.Time(time.Now().Add(48 * time.Hour)),
+	}
+	client := &Client{S3: fake}
+
+	err := client.SetObjectRetention(context.Background(), "vault", "ledger.csv",
+		RetentionGovernance, time.Now().Add(72*time.Hour))
+	if !errors.Is(err, ErrRetentionShortened) {
+		t.Errorf("switching Compliance to Governance: error = %v, want ErrRetentionShortened", err)
+	}
+}
+
+func TestParseRetentionMode(t *testing.T) {
+	for in, want := range map[string]RetentionMode{
+		"governance": RetentionGovernance,
+		"COMPLIANCE": RetentionCompliance,
+	} {
+		if got, err := ParseRetentionMode(in); err != nil || got != want {
+			t.Errorf("ParseRetentionMode(%q) = %q, %v", in, got, err)
+		}
+	}
+	if _, err := ParseRetentionMode("legal-hold"); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+}
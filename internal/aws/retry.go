@@ -0,0 +1,37 @@
+package aws
+
+import (
+	"context"
+	"time"
+)
+
+// retryBaseDelay is the delay before the first retry; it doubles each attempt
+var retryBaseDelay = 200 * time.Millisecond
+
+// Retry calls fn until it succeeds, attempts are exhausted, or ctx is done.
+// The SDK retryer handles individual requests; this covers multi-step
+// operations (e.g. copy then delete) that should be retried as a whole.
+func Retry(ctx context.Context, attempts int, fn func() error) error {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var err error
+	delay := retryBaseDelay
+	for i := 0; i < attempts; i++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+		if i == attempts-1 {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+	return err
+}
@@ -2,8 +2,10 @@ package aws
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"iter"
 	"os"
 	"path/filepath"
 	"strings"
@@ -13,6 +15,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/natevick/stui/internal/security"
 )
 
 // Bucket represents an S3 bucket
@@ -28,7 +31,19 @@ type S3Object struct {
 	Size         int64
 	LastModified time.Time
 	ETag         string
+	StorageClass string
 	IsPrefix     bool // true if this is a "folder" (common prefix)
+
+	// Filled in by EnrichObjects; listings don't return these
+	ContentType string
+	Metadata    map[string]string
+	Enriched    bool
+
+	// ServerSideEncryption is set by GetObjectMetadata ("AES256", "aws:kms", ...)
+	ServerSideEncryption string
+	// ReplicationStatus is set by GetObjectMetadata (see GetReplicationStatus);
+	// empty for objects outside replication
+	ReplicationStatus string
 }
 
 // DisplayName returns the object's display name (last part of key)
@@ -45,31 +60,78 @@ func (o S3Object) DisplayName() string {
 	return o.Key
 }
 
-// ListBuckets returns all S3 buckets accessible to the current credentials
-func (c *Client) ListBuckets(ctx context.Context) ([]Bucket, error) {
-	output, err := c.S3.ListBuckets(ctx, &s3.ListBucketsInput{})
-	if err != nil {
-		return nil, fmt.Errorf("failed to list buckets: %w", err)
-	}
+// bucketPageSize is the number of buckets requested per ListBuckets page
+const bucketPageSize = 1000
 
-	buckets := make([]Bucket, len(output.Buckets))
-	for i, b := range output.Buckets {
-		buckets[i] = Bucket{
-			Name:         aws.ToString(b.Name),
-			CreationDate: aws.ToTime(b.CreationDate),
+// ListAllBuckets returns all S3 buckets accessible to the current
+// credentials, following continuation tokens across pages
+func (c *Client) ListAllBuckets(ctx context.Context) ([]Bucket, error) {
+	var buckets []Bucket
+	for page, err := range c.BucketPages(ctx) {
+		if err != nil {
+			return nil, err
 		}
+		buckets = append(buckets, page...)
 	}
-
 	return buckets, nil
 }
 
-// GetBucketRegion returns the region for a bucket
+// BucketPages streams the accessible buckets one ListBuckets page at a time,
+// so large accounts can be shown before the listing finishes. Iteration stops
+// after the first error.
+func (c *Client) BucketPages(ctx context.Context) iter.Seq2[[]Bucket, error] {
+	return func(yield func([]Bucket, error) bool) {
+		var token *string
+		for {
+			output, err := c.S3.ListBuckets(ctx, &s3.ListBucketsInput{
+				ContinuationToken: token,
+				MaxBuckets:        aws.Int32(bucketPageSize),
+			})
+			if err != nil {
+				yield(nil, &OpError{Op: "Listing buckets", Err: err})
+				return
+			}
+
+			buckets := make([]Bucket, len(output.Buckets))
+			for i, b := range output.Buckets {
+				buckets[i] = Bucket{
+					Name:         aws.ToString(b.Name),
+					CreationDate: aws.ToTime(b.CreationDate),
+					Region:       aws.ToString(b.BucketRegion),
+				}
+				c.Options.BucketRegions.Set(buckets[i].Name, buckets[i].Region)
+			}
+			if !yield(buckets, nil) {
+				return
+			}
+
+			token = output.ContinuationToken
+			if aws.ToString(token) == "" {
+				return
+			}
+		}
+	}
+}
+
+// GetBucketRegion returns the region for a bucket. An Access Point ARN
+// names its region, so no request is made.
 func (c *Client) GetBucketRegion(ctx context.Context, bucket string) (string, error) {
+	if security.IsAccessPointARN(bucket) {
+		a, err := security.ParseARN(bucket)
+		if err != nil {
+			return "", err
+		}
+		return a.Region, nil
+	}
+
+	if region, ok := c.Options.BucketRegions.Get(bucket); ok {
+		return region, nil
+	}
 	output, err := c.S3.GetBucketLocation(ctx, &s3.GetBucketLocationInput{
 		Bucket: aws.String(bucket),
 	})
 	if err != nil {
-		return "", fmt.Errorf("failed to get bucket location: %w", err)
+		return "", &OpError{Op: "Getting bucket region", Bucket: bucket, Err: err}
 	}
 
 	region := string(output.LocationConstraint)
@@ -77,87 +139,95 @@ func (c *Client) GetBucketRegion(ctx context.Context, bucket string) (string, er
 		region = "us-east-1" // Default region for buckets without explicit location
 	}
 
+	c.Options.BucketRegions.Set(bucket, region)
 	return region, nil
 }
 
+// IsFolderMarker reports whether o is a zero-byte "folder/" object, as some
+// tools create to make an empty folder visible
+func (o S3Object) IsFolderMarker() bool {
+	return !o.IsPrefix && strings.HasSuffix(o.Key, "/")
+}
+
 // ListObjects lists objects and common prefixes at the given prefix
 func (c *Client) ListObjects(ctx context.Context, bucket, prefix string) ([]S3Object, error) {
-	var objects []S3Object
+	return c.ListObjectsPages(ctx, bucket, prefix, nil)
+}
 
+// ListObjectsPages is ListObjects calling onPage with each raw page as it
+// arrives, for progress shown before the listing is complete. Pages aren't
+// normalized, so a folder may appear in them twice; the result is.
+func (c *Client) ListObjectsPages(ctx context.Context, bucket, prefix string, onPage func([]S3Object)) ([]S3Object, error) {
 	// Use delimiter to get "folder-like" behavior
-	delimiter := "/"
-
-	paginator := s3.NewListObjectsV2Paginator(c.S3, &s3.ListObjectsV2Input{
-		Bucket:    aws.String(bucket),
-		Prefix:    aws.String(prefix),
-		Delimiter: aws.String(delimiter),
-	})
-
-	for paginator.HasMorePages() {
-		output, err := paginator.NextPage(ctx)
+	lister := c.NewLister(bucket, prefix, "/")
+	var objects []S3Object
+	for lister.HasMorePages() {
+		page, err := lister.NextPage(ctx)
 		if err != nil {
-			return nil, fmt.Errorf("failed to list objects: %w", err)
+			return nil, err
 		}
-
-		// Add common prefixes (folders)
-		for _, cp := range output.CommonPrefixes {
-			objects = append(objects, S3Object{
-				Key:      aws.ToString(cp.Prefix),
-				IsPrefix: true,
-			})
+		if onPage != nil {
+			onPage(page)
 		}
+		objects = append(objects, page...)
+	}
+	return normalizeListing(objects), nil
+}
 
-		// Add objects (files)
-		for _, obj := range output.Contents {
-			key := aws.ToString(obj.Key)
-			// Skip the prefix itself if it appears as an object
-			if key == prefix {
+// normalizeListing turns folder markers into folders and drops folders
+// already listed, so a marker and its common prefix show up once
+func normalizeListing(objects []S3Object) []S3Object {
+	seen := make(map[string]bool)
+	normalized := objects[:0]
+	for _, obj := range objects {
+		if obj.IsFolderMarker() {
+			obj = S3Object{Key: obj.Key, LastModified: obj.LastModified, IsPrefix: true}
+		}
+		if obj.IsPrefix {
+			if seen[obj.Key] {
 				continue
 			}
-			objects = append(objects, S3Object{
-				Key:          key,
-				Size:         aws.ToInt64(obj.Size),
-				LastModified: aws.ToTime(obj.LastModified),
-				ETag:         strings.Trim(aws.ToString(obj.ETag), "\""),
-				IsPrefix:     false,
-			})
+			seen[obj.Key] = true
 		}
+		normalized = append(normalized, obj)
 	}
-
-	return objects, nil
+	return normalized
 }
 
-// ListAllObjects lists all objects recursively under a prefix (no delimiter)
+// ListAllObjects lists all objects recursively under a prefix (no delimiter).
+// It fails with an *ObjectLimitError once the listing passes the object limit;
+// see WithObjectLimit.
 func (c *Client) ListAllObjects(ctx context.Context, bucket, prefix string) ([]S3Object, error) {
-	var objects []S3Object
+	all, err := c.listAll(ctx, c.NewLister(bucket, prefix, ""), c.objectLimit(ctx))
+	if err != nil {
+		return nil, err
+	}
 
-	paginator := s3.NewListObjectsV2Paginator(c.S3, &s3.ListObjectsV2Input{
-		Bucket: aws.String(bucket),
-		Prefix: aws.String(prefix),
-	})
+	objects := all[:0]
+	for _, obj := range all {
+		if obj.IsFolderMarker() {
+			continue
+		}
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
 
-	for paginator.HasMorePages() {
-		output, err := paginator.NextPage(ctx)
+// listAll drains a lister into a single slice, stopping with an
+// *ObjectLimitError once it holds more than limit entries. A limit of zero
+// means no limit.
+func (c *Client) listAll(ctx context.Context, lister *Lister, limit int) ([]S3Object, error) {
+	var objects []S3Object
+	for lister.HasMorePages() {
+		page, err := lister.NextPage(ctx)
 		if err != nil {
-			return nil, fmt.Errorf("failed to list objects: %w", err)
+			return nil, err
 		}
-
-		for _, obj := range output.Contents {
-			key := aws.ToString(obj.Key)
-			// Skip if it ends with / (folder marker)
-			if strings.HasSuffix(key, "/") {
-				continue
-			}
-			objects = append(objects, S3Object{
-				Key:          key,
-				Size:         aws.ToInt64(obj.Size),
-				LastModified: aws.ToTime(obj.LastModified),
-				ETag:         strings.Trim(aws.ToString(obj.ETag), "\""),
-				IsPrefix:     false,
-			})
+		objects = append(objects, page...)
+		if limit > 0 && len(objects) > limit {
+			return nil, &ObjectLimitError{Seen: len(objects), Limit: limit}
 		}
 	}
-
 	return objects, nil
 }
 
@@ -168,15 +238,25 @@ func (c *Client) GetObjectMetadata(ctx context.Context, bucket, key string) (*S3
 		Key:    aws.String(key),
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get object metadata: %w", err)
+		return nil, &OpError{Op: "Reading object metadata", Bucket: bucket, Key: key, Err: err}
+	}
+
+	sse := string(output.ServerSideEncryption)
+	if output.SSECustomerAlgorithm != nil {
+		sse = "SSE-C"
 	}
 
 	return &S3Object{
-		Key:          key,
-		Size:         aws.ToInt64(output.ContentLength),
-		LastModified: aws.ToTime(output.LastModified),
-		ETag:         strings.Trim(aws.ToString(output.ETag), "\""),
-		IsPrefix:     false,
+		Key:                  key,
+		Size:                 aws.ToInt64(output.ContentLength),
+		LastModified:         aws.ToTime(output.LastModified),
+		ETag:                 strings.Trim(aws.ToString(output.ETag), "\""),
+		IsPrefix:             false,
+		ContentType:          aws.ToString(output.ContentType),
+		Metadata:             output.Metadata,
+		StorageClass:         GetStorageClass(output.StorageClass),
+		ServerSideEncryption: sse,
+		ReplicationStatus:    GetReplicationStatus(output.ReplicationStatus),
 	}, nil
 }
 
@@ -225,8 +305,22 @@ func (c *Client) DownloadFile(ctx context.Context, bucket, key, localPath string
 		return err
 	}
 
-	// Create local file with secure permissions (owner read/write only)
-	file, err := os.OpenFile(localPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	// Download into a part file so an interrupted transfer never looks complete
+	tempDir := c.Options.TempDir()
+	if err := os.MkdirAll(tempDir, 0750); err != nil {
+		return fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	partPath, err := PartPath(tempDir, localPath, obj.ETag)
+	if err != nil {
+		return err
+	}
+	// A part left by an interrupted download of this version is continued
+	offset := resumeOffset(partPath, obj)
+	flags := os.O_WRONLY | os.O_CREATE
+	if offset == 0 {
+		flags |= os.O_TRUNC
+	}
+	file, err := os.OpenFile(partPath, flags, 0600)
 	if err != nil {
 		return fmt.Errorf("failed to create local file: %w", err)
 	}
@@ -241,23 +335,146 @@ func (c *Client) DownloadFile(ctx context.Context, bucket, key, localPath string
 	// Wrap writer for progress tracking
 	pw := &ProgressWriter{
 		writer:     file,
+		downloaded: offset,
 		total:      obj.Size,
 		key:        key,
 		onProgress: onProgress,
 	}
 
-	_, err = downloader.Download(ctx, pw, &s3.GetObjectInput{
+	input := &s3.GetObjectInput{
 		Bucket: aws.String(bucket),
 		Key:    aws.String(key),
-	})
+	}
+	// Fail rather than verify against the wrong version if the object is
+	// replaced after the HEAD above
+	if obj.ETag != "" {
+		input.IfMatch = aws.String(`"` + obj.ETag + `"`)
+	}
+	var n int64
+	if offset > 0 {
+		n, err = c.downloadRest(ctx, input, pw, offset)
+		if errors.Is(err, errRangeIgnored) {
+			// Start over rather than append the whole object to the part
+			if err = file.Truncate(0); err == nil {
+				offset, pw.downloaded = 0, 0
+			}
+		}
+	}
+	if offset == 0 && err == nil {
+		n, err = downloader.Download(ctx, pw, input)
+	}
+	c.Options.Metrics.AddBytesDown(n)
 	if err != nil {
-		os.Remove(localPath) // Clean up on failure
-		return fmt.Errorf("failed to download file: %w", err)
+		file.Close()
+		// Keep the partial file when cancelled so the download can be resumed
+		if ctx.Err() == nil {
+			os.Remove(partPath)
+		}
+		return &OpError{Op: "Downloading", Bucket: bucket, Key: key, Err: err}
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(partPath)
+		return fmt.Errorf("failed to write local file: %w", err)
+	}
+
+	if c.shouldVerify(obj) {
+		if err := c.verifyDownload(ctx, bucket, key, partPath, obj); err != nil {
+			if errors.Is(err, ErrIntegrityCheckFailed) && c.Options.RemoveCorruptDownloads {
+				os.Remove(partPath)
+			} else {
+				// Kept, but the caller learns it couldn't be checked or didn't match
+				moveFile(partPath, localPath)
+			}
+			return err
+		}
 	}
 
+	if err := moveFile(partPath, localPath); err != nil {
+		return fmt.Errorf("failed to finalize local file: %w", err)
+	}
 	return nil
 }
 
+// errRangeIgnored is returned by downloadRest when the server answers a
+// ranged GET with something other than the bytes asked for
+var errRangeIgnored = errors.New("server ignored the requested range")
+
+// resumeOffset returns how much of obj an earlier, interrupted download
+// already wrote to partPath, or zero to start afresh. Without an ETag the
+// part can't be tied to this version, so it is never resumed.
+func resumeOffset(partPath string, obj *S3Object) int64 {
+	if obj.ETag == "" {
+		return 0
+	}
+	info, err := os.Lstat(partPath)
+	if err != nil || !info.Mode().IsRegular() || info.Size() >= obj.Size {
+		return 0
+	}
+	return info.Size()
+}
+
+// downloadRest fetches the object from offset on with a ranged GET and
+// writes it after the bytes already in pw. input's If-Match keeps the new
+// bytes to the version the part started with.
+func (c *Client) downloadRest(ctx context.Context, input *s3.GetObjectInput, pw *ProgressWriter, offset int64) (int64, error) {
+	ranged := *input
+	ranged.Range = aws.String(fmt.Sprintf("bytes=%d-", offset))
+	output, err := c.S3.GetObject(ctx, &ranged)
+	if err != nil {
+		return 0, err
+	}
+	defer output.Body.Close()
+
+	var first int64
+	if n, _ := fmt.Sscanf(aws.ToString(output.ContentRange), "bytes %d-", &first); n != 1 || first != offset {
+		return 0, errRangeIgnored
+	}
+	return io.Copy(io.NewOffsetWriter(pw, offset), output.Body)
+}
+
+// verifyDownload checks a downloaded file against the object's ETag. For a
+// multipart ETag it asks S3 for the size of the first part, so the ETag can
+// be rebuilt exactly; only when that's unavailable does it fall back to
+// guessing part sizes, which may end in ErrETagUnverifiable.
+func (c *Client) verifyDownload(ctx context.Context, bucket, key, path string, obj *S3Object) error {
+	if !strings.Contains(obj.ETag, "-") {
+		return CompareETag(path, obj.ETag)
+	}
+	var partSize int64
+	head, err := c.S3.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket:     aws.String(bucket),
+		Key:        aws.String(key),
+		PartNumber: aws.Int32(1),
+		IfMatch:    aws.String(`"` + obj.ETag + `"`),
+	})
+	if err == nil {
+		partSize = aws.ToInt64(head.ContentLength)
+	}
+	return CompareETagPartSize(path, obj.ETag, partSize)
+}
+
+// shouldVerify reports whether a downloaded object's ETag should be checked.
+// Objects over VerifyMaxBytes are skipped to avoid re-reading huge files.
+func (c *Client) shouldVerify(obj *S3Object) bool {
+	if !c.Options.VerifyDownloads {
+		return false
+	}
+	if c.Options.VerifyMaxBytes > 0 && obj.Size > c.Options.VerifyMaxBytes {
+		return false
+	}
+	return reproducibleETag(obj)
+}
+
+// reproducibleETag reports whether an object's ETag is derived from its
+// bytes; KMS and customer-key encryption produce ETags that aren't
+func reproducibleETag(obj *S3Object) bool {
+	switch obj.ServerSideEncryption {
+	case "aws:kms", "aws:kms:dsse", "SSE-C":
+		return false
+	}
+	return VerifiableETag(obj.ETag)
+}
+
 // GetObject retrieves an object's content
 func (c *Client) GetObject(ctx context.Context, bucket, key string) (io.ReadCloser, error) {
 	output, err := c.S3.GetObject(ctx, &s3.GetObjectInput{
@@ -265,7 +482,7 @@ func (c *Client) GetObject(ctx context.Context, bucket, key string) (io.ReadClos
 		Key:    aws.String(key),
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get object: %w", err)
+		return nil, &OpError{Op: "Reading object", Bucket: bucket, Key: key, Err: err}
 	}
 
 	return output.Body, nil
@@ -277,7 +494,7 @@ func (c *Client) CheckBucketAccess(ctx context.Context, bucket string) error {
 		Bucket: aws.String(bucket),
 	})
 	if err != nil {
-		return fmt.Errorf("no access to bucket %s: %w", bucket, err)
+		return &OpError{Op: "Checking bucket access", Bucket: bucket, Err: err}
 	}
 	return nil
 }
@@ -289,3 +506,14 @@ func GetStorageClass(class types.StorageClass) string {
 	}
 	return string(class)
 }
+
+// GetReplicationStatus returns an object's x-amz-replication-status:
+// PENDING, COMPLETED or FAILED on a replication source, REPLICA on a copy,
+// and empty when the object isn't replicated. Some endpoints send COMPLETE,
+// which is reported as COMPLETED.
+func GetReplicationStatus(status types.ReplicationStatus) string {
+	if status == types.ReplicationStatusComplete {
+		return string(types.ReplicationStatusCompleted)
+	}
+	return string(status)
+}
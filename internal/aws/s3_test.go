@@ -0,0 +1,266 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+func TestListAllBucketsFollowsContinuationTokens(t *testing.T) {
+	fake := newFakeS3()
+	fake.bucketPages = [][]string{
+		{"alpha", "bravo", "charlie"},
+		{"delta", "echo"},
+	}
+	client := &Client{S3: fake}
+
+	buckets, err := client.ListAllBuckets(context.Background())
+	if err != nil {
+		t.Fatalf("ListAllBuckets() error = %v", err)
+	}
+
+	want := []string{"alpha", "bravo", "charlie", "delta", "echo"}
+	if len(buckets) != len(want) {
+		t.Fatalf("got %d buckets, want %d: %+v", len(buckets), len(want), buckets)
+	}
+	seen := make(map[string]bool)
+	for i, b := range buckets {
+		if b.Name != want[i] {
+			t.Errorf("bucket %d = %q, want %q", i, b.Name, want[i])
+		}
+		if seen[b.Name] {
+			t.Errorf("bucket %q returned twice", b.Name)
+		}
+		seen[b.Name] = true
+		if b.Region != "us-east-1" {
+			t.Errorf("bucket %q region = %q, want us-east-1", b.Name, b.Region)
+		}
+	}
+	if n := fake.countCalls("ListBuckets"); n != 2 {
+		t.Errorf("made %d ListBuckets calls, want 2", n)
+	}
+}
+
+func TestBucketPagesStreamsAndStops(t *testing.T) {
+	fake := newFakeS3()
+	fake.bucketPages = [][]string{{"alpha"}, {"bravo"}, {"charlie"}}
+	client := &Client{S3: fake}
+
+	var pages [][]Bucket
+	for page, err := range client.BucketPages(context.Background()) {
+		if err != nil {
+			t.Fatalf("BucketPages() error = %v", err)
+		}
+		pages = append(pages, page)
+		if len(pages) == 2 {
+			break
+		}
+	}
+	if len(pages) != 2 || pages[1][0].Name != "bravo" {
+		t.Errorf("pages = %+v, want alpha then bravo", pages)
+	}
+	// Stopping early must not fetch the remaining pages
+	if n := fake.countCalls("ListBuckets"); n != 2 {
+		t.Errorf("made %d ListBuckets calls, want 2", n)
+	}
+}
+
+func TestListAllBucketsError(t *testing.T) {
+	fake := newFakeS3()
+	fake.bucketPages = [][]string{{"alpha"}, {"bravo"}}
+	fake.errFor = func(op, key string) error {
+		if op == "ListBuckets" && key == "page-1" {
+			return &smithy.GenericAPIError{Code: "AccessDenied"}
+		}
+		return nil
+	}
+	client := &Client{S3: fake}
+
+	buckets, err := client.ListAllBuckets(context.Background())
+	var opErr *OpError
+	if !errors.As(err, &opErr) || opErr.Op != "Listing buckets" {
+		t.Fatalf("ListAllBuckets() error = %v, want a Listing buckets OpError", err)
+	}
+	if buckets != nil {
+		t.Errorf("got partial result %+v on error", buckets)
+	}
+}
+
+func TestNormalizeListingMergesFolderMarkers(t *testing.T) {
+	listing := []S3Object{
+		{Key: "docs/", IsPrefix: true},
+		{Key: "logs/", IsPrefix: true},
+		{Key: "docs/", Size: 0},        // marker duplicating a common prefix
+		{Key: "empty/", Size: 0},       // marker for a folder with nothing in it
+		{Key: "logs/", IsPrefix: true}, // repeated across pages
+		{Key: "readme.txt", Size: 12},
+	}
+
+	got := normalizeListing(listing)
+
+	want := []S3Object{
+		{Key: "docs/", IsPrefix: true},
+		{Key: "logs/", IsPrefix: true},
+		{Key: "empty/", IsPrefix: true},
+		{Key: "readme.txt", Size: 12},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("normalizeListing() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i].Key != want[i].Key || got[i].IsPrefix != want[i].IsPrefix || got[i].Size != want[i].Size {
+			t.Errorf("entry %d = %+v, want %+v", i, got[i], want[i])
+		}
+		if got[i].IsFolderMarker() {
+			t.Errorf("entry %d (%s) is still listed as a file", i, got[i].Key)
+		}
+	}
+}
+
+func TestListObjectsShowsFolderMarkerOnce(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("b", "docs/", fakeObject{})
+	fake.put("b", "docs/guide.md", fakeObject{body: []byte("# guide")})
+	fake.put("b", "empty/", fakeObject{})
+	fake.put("b", "readme.txt", fakeObject{body: []byte("hi")})
+	client := &Client{S3: fake, Options: ClientOptions{PageSize: 1}}
+
+	objects, err := client.ListObjects(context.Background(), "b", "")
+	if err != nil {
+		t.Fatalf("ListObjects() error = %v", err)
+	}
+
+	folders := make(map[string]int)
+	for _, obj := range objects {
+		if obj.IsFolderMarker() {
+			t.Errorf("%s listed as a file", obj.Key)
+		}
+		if obj.IsPrefix {
+			folders[obj.Key]++
+		}
+	}
+	if folders["docs/"] != 1 || folders["empty/"] != 1 || len(folders) != 2 {
+		t.Errorf("folders = %v, want docs/ and empty/ once each", folders)
+	}
+	if len(objects) != 3 {
+		t.Errorf("got %d entries, want 3: %+v", len(objects), objects)
+	}
+}
+
+func TestAccessPointARNTargetsOperations(t *testing.T) {
+	const ap = "arn:aws:s3:eu-west-1:123456789012:accesspoint/shared-logs"
+	fake := newFakeS3()
+	fake.put(ap, "2024/app.log", fakeObject{body: []byte("line")})
+	client := &Client{S3: fake}
+
+	objects, err := client.ListObjects(context.Background(), ap, "2024/")
+	if err != nil {
+		t.Fatalf("ListObjects() error = %v", err)
+	}
+	if len(objects) != 1 || objects[0].Key != "2024/app.log" {
+		t.Errorf("ListObjects() = %+v, want the object behind the access point", objects)
+	}
+	if got := aws.ToString(fake.listInputs[0].Bucket); got != ap {
+		t.Errorf("request Bucket = %q, want the access point ARN", got)
+	}
+
+	// The ARN carries its region; GetBucketLocation doesn't accept access points
+	region, err := client.GetBucketRegion(context.Background(), ap)
+	if err != nil || region != "eu-west-1" {
+		t.Errorf("GetBucketRegion() = %q, %v, want eu-west-1", region, err)
+	}
+	if n := fake.countCalls("GetBucketLocation"); n != 0 {
+		t.Errorf("made %d GetBucketLocation calls for an ARN, want 0", n)
+	}
+}
+
+func TestGetObjectMetadataReplicationStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		header types.ReplicationStatus
+		want   string
+	}{
+		{"pending", types.ReplicationStatusPending, "PENDING"},
+		{"completed", types.ReplicationStatusCompleted, "COMPLETED"},
+		{"complete reported as completed", types.ReplicationStatusComplete, "COMPLETED"},
+		{"failed", types.ReplicationStatusFailed, "FAILED"},
+		{"replica", types.ReplicationStatusReplica, "REPLICA"},
+		{"not replicated", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeS3()
+			fake.put("b", "a.txt", fakeObject{body: []byte("a"), replication: tt.header})
+			client := &Client{S3: fake}
+
+			obj, err := client.GetObjectMetadata(context.Background(), "b", "a.txt")
+			if err != nil {
+				t.Fatalf("GetObjectMetadata() error = %v", err)
+			}
+			if obj.ReplicationStatus != tt.want {
+				t.Errorf("ReplicationStatus = %q, want %q", obj.ReplicationStatus, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetBucketRegionCaches(t *testing.T) {
+	fake := newFakeS3()
+	fake.bucketRegions = map[string]types.BucketLocationConstraint{"eu": types.BucketLocationConstraintEuWest1}
+	client := &Client{S3: fake, Options: ClientOptions{BucketRegions: NewBucketRegionCache()}}
+	ctx := context.Background()
+
+	for range 3 {
+		region, err := client.GetBucketRegion(ctx, "eu")
+		if err != nil {
+			t.Fatalf("GetBucketRegion() error = %v", err)
+		}
+		if region != "eu-west-1" {
+			t.Fatalf("GetBucketRegion() = %q, want eu-west-1", region)
+		}
+	}
+	if n := fake.countCalls("GetBucketLocation"); n != 1 {
+		t.Errorf("made %d GetBucketLocation calls, want 1", n)
+	}
+
+	// Regions ListBuckets reported need no lookup at all
+	fake.bucketPages = [][]string{{"listed"}}
+	if _, err := client.ListAllBuckets(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if region, _ := client.GetBucketRegion(ctx, "listed"); region != "us-east-1" {
+		t.Errorf("GetBucketRegion(listed) = %q, want us-east-1", region)
+	}
+	if n := fake.countCalls("GetBucketLocation"); n != 1 {
+		t.Errorf("made %d GetBucketLocation calls after listing, want 1", n)
+	}
+}
+
+func TestListObjectsPagesReportsEachPage(t *testing.T) {
+	fake := newFakeS3()
+	for i := range 5 {
+		fake.put("b", fmt.Sprintf("logs/%d.txt", i), fakeObject{body: []byte("x")})
+	}
+	client := &Client{S3: fake, Options: ClientOptions{PageSize: 2}}
+
+	var sizes []int
+	objects, err := client.ListObjectsPages(context.Background(), "b", "logs/", func(page []S3Object) {
+		sizes = append(sizes, len(page))
+	})
+	if err != nil {
+		t.Fatalf("ListObjectsPages() error = %v", err)
+	}
+	if len(objects) != 5 {
+		t.Errorf("got %d objects, want 5", len(objects))
+	}
+	if !slices.Equal(sizes, []int{2, 2, 1}) {
+		t.Errorf("page sizes = %v, want [2 2 1]", sizes)
+	}
+}
@@ -0,0 +1,192 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/natevick/stui/internal/security"
+)
+
+// Part size limits for streamed uploads. S3 requires every part but the last
+// to be at least 5 MiB.
+const (
+	MinStreamPartSize     = 5 * 1024 * 1024
+	DefaultStreamPartSize = 8 * 1024 * 1024
+)
+
+// DefaultUploadConcurrency is the number of parts of a file uploaded in parallel
+const DefaultUploadConcurrency = 5
+
+// UploadOptions configures UploadStream
+type UploadOptions struct {
+	// PartSize is the buffer size and multipart part size; zero uses DefaultStreamPartSize
+	PartSize    int64
+	ContentType string
+	Metadata    map[string]string
+	// StorageClass overrides the bucket's configured default; empty uses it,
+	// or S3's own default (STANDARD) when the bucket has none
+	StorageClass types.StorageClass
+	// Concurrency bounds the parts of a journaled file upload sent at once;
+	// zero uses DefaultUploadConcurrency
+	Concurrency int
+}
+
+// UploadStream uploads a reader of unknown length to bucket/key. Data is
+// buffered one part at a time: input that fits in a single part is sent with
+// PutObject, anything larger becomes a multipart upload whose parts are
+// flushed as the buffer fills. If reading or uploading fails, the multipart
+// upload is aborted so no partial object or orphaned parts are left behind.
+func (c *Client) UploadStream(ctx context.Context, bucket, key string, r io.Reader, opts UploadOptions) error {
+	if err := c.uploadStream(ctx, bucket, key, r, opts); err != nil {
+		return &OpError{Op: "Uploading", Bucket: bucket, Key: key, Err: err}
+	}
+	return nil
+}
+
+// uploadStream does the work of UploadStream
+func (c *Client) uploadStream(ctx context.Context, bucket, key string, r io.Reader, opts UploadOptions) error {
+	if err := c.checkWritable(bucket); err != nil {
+		return err
+	}
+	if err := security.ValidNewObjectKey(key); err != nil {
+		return err
+	}
+
+	partSize := streamPartSize(opts.PartSize)
+	buf := make([]byte, partSize)
+	n, eof, err := fillBuffer(r, buf)
+	if err != nil {
+		return fmt.Errorf("failed to read input: %w", err)
+	}
+
+	var contentType *string
+	if opts.ContentType != "" {
+		contentType = aws.String(opts.ContentType)
+	}
+
+	// Small input: a single PutObject is enough
+	if eof {
+		_, err := c.S3.PutObject(ctx, &s3.PutObjectInput{
+			Bucket:       aws.String(bucket),
+			Key:          aws.String(key),
+			Body:         bytes.NewReader(buf[:n]),
+			ContentType:  contentType,
+			Metadata:     opts.Metadata,
+			StorageClass: opts.StorageClass,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to upload object: %w", err)
+		}
+		c.Options.Metrics.AddBytesUp(int64(n))
+		return nil
+	}
+
+	created, err := c.S3.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:       aws.String(bucket),
+		Key:          aws.String(key),
+		ContentType:  contentType,
+		Metadata:     opts.Metadata,
+		StorageClass: opts.StorageClass,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start multipart upload: %w", err)
+	}
+	uploadID := created.UploadId
+
+	abort := func(cause error) error {
+		// Use a fresh context so cancellation still cleans up the parts
+		_, abortErr := c.S3.AbortMultipartUpload(context.WithoutCancel(ctx), &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(bucket),
+			Key:      aws.String(key),
+			UploadId: uploadID,
+		})
+		if abortErr != nil {
+			return fmt.Errorf("%w (and failed to abort multipart upload: %v)", cause, abortErr)
+		}
+		return cause
+	}
+
+	var parts []types.CompletedPart
+	for partNumber := int32(1); ; partNumber++ {
+		out, err := c.S3.UploadPart(ctx, &s3.UploadPartInput{
+			Bucket:     aws.String(bucket),
+			Key:        aws.String(key),
+			UploadId:   uploadID,
+			PartNumber: aws.Int32(partNumber),
+			Body:       bytes.NewReader(buf[:n]),
+		})
+		if err != nil {
+			return abort(fmt.Errorf("failed to upload part %d: %w", partNumber, err))
+		}
+		c.Options.Metrics.AddBytesUp(int64(n))
+		parts = append(parts, types.CompletedPart{
+			ETag:       out.ETag,
+			PartNumber: aws.Int32(partNumber),
+		})
+
+		if eof {
+			break
+		}
+		n, eof, err = fillBuffer(r, buf)
+		if err != nil {
+			return abort(fmt.Errorf("failed to read input: %w", err))
+		}
+		if n == 0 {
+			break
+		}
+	}
+
+	_, err = c.S3.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(bucket),
+		Key:             aws.String(key),
+		UploadId:        uploadID,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+	})
+	if err != nil {
+		return abort(fmt.Errorf("failed to complete multipart upload: %w", err))
+	}
+
+	return nil
+}
+
+// fillBuffer reads until buf is full or the reader is exhausted.
+// eof reports that no more data follows what was read.
+func fillBuffer(r io.Reader, buf []byte) (n int, eof bool, err error) {
+	n, err = io.ReadFull(r, buf)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return n, true, nil
+	}
+	return n, false, err
+}
+
+// DownloadStream copies an object to w. Nothing is written unless the GET
+// succeeds, and a body that ends early or fails mid-copy always returns an
+// error, so callers piping to stdout can rely on the exit status. Errors are
+// *OpError values safe to print.
+func (c *Client) DownloadStream(ctx context.Context, bucket, key string, w io.Writer) error {
+	output, err := c.S3.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return &OpError{Op: "Downloading", Bucket: bucket, Key: key, Err: err}
+	}
+	defer output.Body.Close()
+
+	written, err := io.Copy(w, output.Body)
+	c.Options.Metrics.AddBytesDown(written)
+	if err != nil {
+		return &OpError{Op: "Downloading", Bucket: bucket, Key: key, Err: err}
+	}
+	if want := aws.ToInt64(output.ContentLength); output.ContentLength != nil && written != want {
+		return &OpError{Op: "Downloading", Bucket: bucket, Key: key, Err: fmt.Errorf("got %d of %d bytes", written, want)}
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestUploadStreamMultipart(t *testing.T) {
+	const partSize = MinStreamPartSize
+	body := bytes.Repeat([]byte("stream!!"), (2*partSize+1000)/8)
+
+	fake := newFakeS3()
+	client := &Client{S3: fake}
+
+	// Hide the length so the upload can't size itself up front
+	r := io.MultiReader(bytes.NewReader(body))
+	err := client.UploadStream(context.Background(), "b", "logs/out.txt", r, UploadOptions{
+		PartSize:    partSize,
+		ContentType: "text/plain",
+	})
+	if err != nil {
+		t.Fatalf("UploadStream() error = %v", err)
+	}
+
+	got, ok := fake.get("b", "logs/out.txt")
+	if !ok {
+		t.Fatal("object not written")
+	}
+	if !bytes.Equal(got.body, body) {
+		t.Errorf("uploaded %d bytes, want %d", len(got.body), len(body))
+	}
+	if got.contentType != "text/plain" {
+		t.Errorf("content type = %q", got.contentType)
+	}
+	if n := fake.countCalls("UploadPart"); n != 3 {
+		t.Errorf("UploadPart calls = %d, want 3", n)
+	}
+	if fake.countCalls("CompleteMultipartUpload") != 1 {
+		t.Error("expected multipart upload to complete")
+	}
+}
+
+func TestUploadStreamSmallUsesPutObject(t *testing.T) {
+	fake := newFakeS3()
+	client := &Client{S3: fake}
+
+	if err := client.UploadStream(context.Background(), "b", "small.txt", bytes.NewBufferString("hi"), UploadOptions{}); err != nil {
+		t.Fatalf("UploadStream() error = %v", err)
+	}
+	if fake.countCalls("PutObject") != 1 || fake.countCalls("CreateMultipartUpload") != 0 {
+		t.Errorf("expected a single PutObject, calls = %v", fake.calls)
+	}
+}
+
+// failingReader returns n bytes of data, then an error
+type failingReader struct {
+	remaining int
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.remaining <= 0 {
+		return 0, errors.New("broken pipe")
+	}
+	n := min(len(p), r.remaining)
+	for i := range p[:n] {
+		p[i] = 'x'
+	}
+	r.remaining -= n
+	return n, nil
+}
+
+func TestUploadStreamAbortsOnReadError(t *testing.T) {
+	fake := newFakeS3()
+	client := &Client{S3: fake}
+
+	// One full part, then the reader fails partway through the second
+	r := &failingReader{remaining: MinStreamPartSize + 1024}
+	err := client.UploadStream(context.Background(), "b", "broken.txt", r, UploadOptions{PartSize: MinStreamPartSize})
+	if err == nil {
+		t.Fatal("UploadStream() succeeded, want read error")
+	}
+
+	if fake.countCalls("AbortMultipartUpload") != 1 {
+		t.Errorf("expected multipart upload to be aborted, calls = %v", fake.calls)
+	}
+	if fake.countCalls("CompleteMultipartUpload") != 0 {
+		t.Error("multipart upload must not complete after a read error")
+	}
+	if _, ok := fake.get("b", "broken.txt"); ok {
+		t.Error("no object should exist after an aborted upload")
+	}
+	if len(fake.uploads) != 0 {
+		t.Error("aborted upload left parts behind")
+	}
+}
+
+func TestDownloadStream(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("b", "report.csv", fakeObject{body: []byte("a,b\n1,2\n")})
+	client := &Client{S3: fake}
+
+	var buf bytes.Buffer
+	if err := client.DownloadStream(context.Background(), "b", "report.csv", &buf); err != nil {
+		t.Fatalf("DownloadStream() error = %v", err)
+	}
+	if buf.String() != "a,b\n1,2\n" {
+		t.Errorf("DownloadStream() wrote %q", buf.String())
+	}
+}
+
+func TestDownloadStreamGetError(t *testing.T) {
+	fake := newFakeS3()
+	fake.errFor = func(op, key string) error {
+		return errors.New("AccessDenied for account 123456789012")
+	}
+	client := &Client{S3: fake}
+
+	var buf bytes.Buffer
+	err := client.DownloadStream(context.Background(), "b", "secret.txt", &buf)
+	if err == nil {
+		t.Fatal("DownloadStream() succeeded, want error")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("wrote %d bytes despite GET failure", buf.Len())
+	}
+	if bytes.Contains([]byte(err.Error()), []byte("123456789012")) {
+		t.Errorf("error not sanitized: %v", err)
+	}
+}
@@ -0,0 +1,182 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// EmptyTrash permanently deletes the soft-deleted objects under TrashPrefix
+// that were last modified more than olderThan ago, which for trash is when
+// they were deleted; zero empties the whole trash. It returns how many were
+// deleted. Keys outside TrashPrefix are never touched. If any deletes fail,
+// the error is a *PartialFailureError.
+//
+// Every version and delete marker of an expired key is deleted, so in a
+// versioned bucket nothing is left behind. Endpoints without
+// ListObjectVersions get a plain delete, which in a versioned bucket only
+// hides the object behind a delete marker.
+func (c *Client) EmptyTrash(ctx context.Context, bucket string, olderThan time.Duration) (int, error) {
+	if err := c.checkWritable(bucket); err != nil {
+		return 0, err
+	}
+	if olderThan < 0 {
+		return 0, fmt.Errorf("trash retention must not be negative")
+	}
+
+	versions, err := c.trashVersions(ctx, bucket)
+	if IsNotImplemented(err) {
+		versions, err = c.trashObjects(ctx, bucket)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := time.Now().Add(-olderThan)
+	expired := make(map[string]bool)
+	for _, v := range versions {
+		if v.latest && (olderThan == 0 || v.modified.Before(cutoff)) {
+			expired[v.key] = true
+		}
+	}
+	if len(expired) == 0 {
+		return 0, nil
+	}
+
+	// Versions go before delete markers: removing a marker first would
+	// briefly bring its version back as the current object
+	var ids, markers []types.ObjectIdentifier
+	for _, v := range versions {
+		if !expired[v.key] {
+			continue
+		}
+		id := types.ObjectIdentifier{Key: aws.String(v.key)}
+		if v.versionID != "" {
+			id.VersionId = aws.String(v.versionID)
+		}
+		if v.marker {
+			markers = append(markers, id)
+		} else {
+			ids = append(ids, id)
+		}
+	}
+
+	failed, err := c.deleteVersions(ctx, bucket, append(ids, markers...))
+	if err != nil {
+		return 0, err
+	}
+	deleted := len(expired) - len(failed)
+	if len(failed) == 0 {
+		return deleted, nil
+	}
+	results := make([]ObjectResult, 0, len(failed))
+	for _, key := range slices.Sorted(maps.Keys(failed)) {
+		results = append(results, ObjectResult{Key: key, Err: fmt.Errorf("%s", failed[key])})
+	}
+	return deleted, &PartialFailureError{Failed: results, Total: len(expired)}
+}
+
+// trashVersion is one object version or delete marker under TrashPrefix
+type trashVersion struct {
+	key       string
+	versionID string // empty when the endpoint doesn't list versions
+	modified  time.Time
+	latest    bool
+	marker    bool
+}
+
+// inTrash reports whether key is an object in the trash. Listings are
+// already confined to the prefix; check again anyway, since a stray key
+// here would be deleted for good.
+func inTrash(key string) bool {
+	return strings.HasPrefix(key, TrashPrefix) && key != TrashPrefix
+}
+
+// trashVersions lists every version and delete marker under TrashPrefix
+func (c *Client) trashVersions(ctx context.Context, bucket string) ([]trashVersion, error) {
+	limit := c.objectLimit(ctx)
+	input := &s3.ListObjectVersionsInput{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(TrashPrefix),
+	}
+
+	var versions []trashVersion
+	for {
+		output, err := c.S3.ListObjectVersions(ctx, input)
+		if err != nil {
+			return nil, &OpError{Op: "Listing trash versions", Bucket: bucket, Err: err}
+		}
+		for _, v := range output.Versions {
+			if key := aws.ToString(v.Key); inTrash(key) {
+				versions = append(versions, trashVersion{
+					key:       key,
+					versionID: aws.ToString(v.VersionId),
+					modified:  aws.ToTime(v.LastModified),
+					latest:    aws.ToBool(v.IsLatest),
+				})
+			}
+		}
+		for _, m := range output.DeleteMarkers {
+			if key := aws.ToString(m.Key); inTrash(key) {
+				versions = append(versions, trashVersion{
+					key:       key,
+					versionID: aws.ToString(m.VersionId),
+					modified:  aws.ToTime(m.LastModified),
+					latest:    aws.ToBool(m.IsLatest),
+					marker:    true,
+				})
+			}
+		}
+		if limit > 0 && len(versions) > limit {
+			return nil, &ObjectLimitError{Seen: len(versions), Limit: limit}
+		}
+		if !aws.ToBool(output.IsTruncated) {
+			return versions, nil
+		}
+		input.KeyMarker = output.NextKeyMarker
+		input.VersionIdMarker = output.NextVersionIdMarker
+	}
+}
+
+// trashObjects lists the current objects under TrashPrefix, for endpoints
+// that can't list versions
+func (c *Client) trashObjects(ctx context.Context, bucket string) ([]trashVersion, error) {
+	objects, err := c.listAll(ctx, c.NewLister(bucket, TrashPrefix, ""), c.objectLimit(ctx))
+	if err != nil {
+		return nil, err
+	}
+	var versions []trashVersion
+	for _, obj := range objects {
+		if inTrash(obj.Key) {
+			versions = append(versions, trashVersion{key: obj.Key, modified: obj.LastModified, latest: true})
+		}
+	}
+	return versions, nil
+}
+
+// deleteVersions deletes ids in batches and returns the failures by key.
+// These deletes are permanent, so unlike DeleteObjects nothing is journaled.
+func (c *Client) deleteVersions(ctx context.Context, bucket string, ids []types.ObjectIdentifier) (map[string]string, error) {
+	failed := make(map[string]string)
+	for start := 0; start < len(ids); start += maxDeleteBatch {
+		end := min(start+maxDeleteBatch, len(ids))
+		output, err := c.S3.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(bucket),
+			Delete: &types.Delete{Objects: ids[start:end], Quiet: aws.Bool(true)},
+		})
+		if err != nil {
+			return failed, &OpError{Op: "Emptying trash", Bucket: bucket, Err: err}
+		}
+		for _, e := range output.Errors {
+			failed[aws.ToString(e.Key)] = aws.ToString(e.Message)
+		}
+	}
+	return failed, nil
+}
@@ -0,0 +1,131 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/smithy-go"
+)
+
+func TestEmptyTrashDeletesOnlyExpiredTrash(t *testing.T) {
+	fake := newFakeS3()
+	old := time.Now().Add(-60 * 24 * time.Hour)
+	for _, key := range []string{
+		TrashPrefix + "old.txt",
+		TrashPrefix + "reports/old.csv",
+		// Old, but not in the trash
+		"old.txt",
+		"docs/" + TrashPrefix + "old.txt",
+		".s3-tui-trash-backup/old.txt",
+	} {
+		fake.put("b", key, fakeObject{body: []byte("x"), modified: old})
+	}
+	fake.put("b", TrashPrefix+"recent.txt", fakeObject{body: []byte("x")})
+	client := &Client{S3: fake}
+
+	n, err := client.EmptyTrash(context.Background(), "b", 30*24*time.Hour)
+	if err != nil {
+		t.Fatalf("EmptyTrash() error = %v", err)
+	}
+	if n != 2 {
+		t.Errorf("EmptyTrash() = %d, want 2", n)
+	}
+
+	for key, want := range map[string]bool{
+		TrashPrefix + "old.txt":           false,
+		TrashPrefix + "reports/old.csv":   false,
+		TrashPrefix + "recent.txt":        true,
+		"old.txt":                         true,
+		"docs/" + TrashPrefix + "old.txt": true,
+		".s3-tui-trash-backup/old.txt":    true,
+	} {
+		if _, ok := fake.get("b", key); ok != want {
+			t.Errorf("%s exists = %v, want %v", key, ok, want)
+		}
+	}
+}
+
+func TestEmptyTrashZeroRetentionEmptiesTrashOnly(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("b", TrashPrefix+"recent.txt", fakeObject{body: []byte("x")})
+	fake.put("b", "keep.txt", fakeObject{body: []byte("x")})
+	client := &Client{S3: fake}
+
+	if n, err := client.EmptyTrash(context.Background(), "b", 0); err != nil || n != 1 {
+		t.Fatalf("EmptyTrash(0) = %d, %v; want 1, nil", n, err)
+	}
+	if _, ok := fake.get("b", "keep.txt"); !ok {
+		t.Error("EmptyTrash(0) deleted a key outside the trash")
+	}
+	if n := fake.countCalls("DeleteObjects"); n != 1 {
+		t.Errorf("made %d DeleteObjects calls, want 1", n)
+	}
+
+	// An empty trash needs no delete request at all
+	if n, err := client.EmptyTrash(context.Background(), "b", 0); err != nil || n != 0 {
+		t.Errorf("EmptyTrash() on an empty trash = %d, %v; want 0, nil", n, err)
+	}
+	if n := fake.countCalls("DeleteObjects"); n != 1 {
+		t.Errorf("made %d DeleteObjects calls, want still 1", n)
+	}
+
+	readOnly := &Client{S3: fake, Options: ClientOptions{ReadOnly: true}}
+	if _, err := readOnly.EmptyTrash(context.Background(), "b", 0); !errors.Is(err, ErrReadOnlyMode) {
+		t.Errorf("EmptyTrash() in read-only mode error = %v, want ErrReadOnlyMode", err)
+	}
+}
+
+func TestEmptyTrashDeletesEveryVersion(t *testing.T) {
+	fake := newFakeS3()
+	fake.versioned["b"] = true
+	old := time.Now().Add(-60 * 24 * time.Hour)
+	fake.put("b", TrashPrefix+"old.txt", fakeObject{body: []byte("x"), modified: old})
+	fake.put("b", TrashPrefix+"recent.txt", fakeObject{body: []byte("x")})
+	// Left by a plain delete: only a delete marker is current
+	fake.put("b", TrashPrefix+"hidden.txt", fakeObject{body: []byte("x"), modified: old})
+	client := &Client{S3: fake}
+	if _, err := client.DeleteObjects(context.Background(), "b", []string{TrashPrefix + "hidden.txt"}); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := client.EmptyTrash(context.Background(), "b", 30*24*time.Hour)
+	if err != nil {
+		t.Fatalf("EmptyTrash() error = %v", err)
+	}
+	if n != 2 {
+		t.Errorf("EmptyTrash() = %d, want 2", n)
+	}
+
+	if _, ok := fake.get("b", TrashPrefix+"old.txt"); ok {
+		t.Error("expired trash object still exists")
+	}
+	if _, ok := fake.get("b", TrashPrefix+"recent.txt"); !ok {
+		t.Error("recent trash object was deleted")
+	}
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if len(fake.hidden) != 0 || len(fake.bareMarkers) != 0 {
+		t.Errorf("left %d hidden versions and %d delete markers, want none", len(fake.hidden), len(fake.bareMarkers))
+	}
+}
+
+func TestEmptyTrashWithoutVersionListing(t *testing.T) {
+	fake := newFakeS3()
+	fake.errFor = func(op, key string) error {
+		if op == "ListObjectVersions" {
+			return &smithy.GenericAPIError{Code: "NotImplemented"}
+		}
+		return nil
+	}
+	fake.put("b", TrashPrefix+"old.txt", fakeObject{body: []byte("x")})
+	client := &Client{S3: fake}
+
+	if n, err := client.EmptyTrash(context.Background(), "b", 0); err != nil || n != 1 {
+		t.Fatalf("EmptyTrash() = %d, %v; want 1, nil", n, err)
+	}
+	if _, ok := fake.get("b", TrashPrefix+"old.txt"); ok {
+		t.Error("trash object still exists")
+	}
+}
@@ -0,0 +1,174 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// OnExists decides what an Uploader does when the destination key is taken
+type OnExists int
+
+const (
+	// OnExistsFail refuses the upload with ErrObjectExists
+	OnExistsFail OnExists = iota
+	// OnExistsOverwrite replaces the existing object
+	OnExistsOverwrite
+	// OnExistsSkip leaves the existing object and uploads nothing
+	OnExistsSkip
+	// OnExistsRename uploads to "name (1).ext", "name (2).ext", ... instead
+	OnExistsRename
+)
+
+// maxRenameAttempts bounds the search for a free key under OnExistsRename
+const maxRenameAttempts = 1000
+
+var onExistsNames = map[OnExists]string{
+	OnExistsFail:      "fail",
+	OnExistsOverwrite: "overwrite",
+	OnExistsSkip:      "skip",
+	OnExistsRename:    "rename",
+}
+
+func (p OnExists) String() string {
+	if name, ok := onExistsNames[p]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ParseOnExists parses "fail", "overwrite", "skip", or "rename"
+func ParseOnExists(s string) (OnExists, error) {
+	for p, name := range onExistsNames {
+		if strings.EqualFold(s, name) {
+			return p, nil
+		}
+	}
+	return OnExistsFail, fmt.Errorf("unknown policy %q: want fail, overwrite, skip, or rename", s)
+}
+
+// UploadResult reports where an upload landed
+type UploadResult struct {
+	// Key is the key written, which differs from the requested key after a rename
+	Key string
+	// Skipped is set when OnExistsSkip found an existing object
+	Skipped bool
+	// Resumed is set when an interrupted upload from the journal was continued
+	Resumed bool
+}
+
+// Uploader uploads objects, checking the destination first according to OnExists
+type Uploader struct {
+	client   *Client
+	OnExists OnExists
+	Options  UploadOptions
+	// SkipUnchanged makes UploadDir skip files whose content matches the destination
+	SkipUnchanged bool
+	// Journal records multipart uploads of local files so an interrupted
+	// upload can be resumed; nil uploads files without a record
+	Journal *UploadJournal
+	// KeyTemplate builds the keys UploadDir writes under its prefix; nil
+	// keys each file by its path relative to the folder
+	KeyTemplate *KeyTemplate
+}
+
+// NewUploader creates an uploader with the given existing-object policy,
+// journaling to the client's UploadJournal and keying folder uploads with
+// the client's UploadKeyTemplate
+func (c *Client) NewUploader(onExists OnExists) *Uploader {
+	u := &Uploader{client: c, OnExists: onExists, Journal: c.Options.UploadJournal}
+	if c.Options.UploadKeyTemplate != "" {
+		// Validate has already rejected templates that don't parse
+		u.KeyTemplate, _ = ParseKeyTemplate(c.Options.UploadKeyTemplate)
+	}
+	return u
+}
+
+// Upload streams r to bucket/key. The existence check and the upload are
+// separate requests, so a concurrent writer can still slip in between them.
+func (u *Uploader) Upload(ctx context.Context, bucket, key string, r io.Reader) (UploadResult, error) {
+	if err := u.client.checkWritable(bucket); err != nil {
+		return UploadResult{}, err
+	}
+	target, skip, err := u.resolveKey(ctx, bucket, key)
+	if err != nil {
+		return UploadResult{}, err
+	}
+	if skip {
+		return UploadResult{Key: key, Skipped: true}, nil
+	}
+
+	opts := u.Options
+	opts.StorageClass = u.storageClass(bucket)
+	if err := u.client.UploadStream(ctx, bucket, target, r, opts); err != nil {
+		return UploadResult{}, err
+	}
+	return UploadResult{Key: target}, nil
+}
+
+// storageClass returns the class to upload to bucket with: the per-upload
+// override, else the bucket's configured default
+func (u *Uploader) storageClass(bucket string) types.StorageClass {
+	if u.Options.StorageClass != "" {
+		return u.Options.StorageClass
+	}
+	return u.client.Options.BucketStorageClasses[bucket]
+}
+
+// ValidStorageClass checks class against the storage classes S3 accepts
+func ValidStorageClass(class types.StorageClass) error {
+	if slices.Contains(class.Values(), class) {
+		return nil
+	}
+	return fmt.Errorf("unknown storage class %q", class)
+}
+
+// resolveKey applies the policy, returning the key to write or skip=true
+func (u *Uploader) resolveKey(ctx context.Context, bucket, key string) (target string, skip bool, err error) {
+	if u.OnExists == OnExistsOverwrite {
+		return key, false, nil
+	}
+
+	exists, err := u.client.ObjectExists(ctx, bucket, key)
+	if err != nil {
+		return "", false, err
+	}
+	if !exists {
+		return key, false, nil
+	}
+
+	switch u.OnExists {
+	case OnExistsSkip:
+		return "", true, nil
+	case OnExistsRename:
+		for n := 1; n <= maxRenameAttempts; n++ {
+			candidate := numberedKey(key, n)
+			exists, err := u.client.ObjectExists(ctx, bucket, candidate)
+			if err != nil {
+				return "", false, err
+			}
+			if !exists {
+				return candidate, false, nil
+			}
+		}
+		return "", false, fmt.Errorf("no free name for %s after %d attempts", key, maxRenameAttempts)
+	default:
+		return "", false, fmt.Errorf("%w: %s", ErrObjectExists, FormatS3URI(bucket, key))
+	}
+}
+
+// numberedKey inserts " (n)" before the extension: "a/report.csv" becomes
+// "a/report (1).csv". Dotfiles like ".env" have no extension.
+func numberedKey(key string, n int) string {
+	dir, base := path.Split(key)
+	ext := path.Ext(base)
+	if ext == base {
+		ext = ""
+	}
+	return fmt.Sprintf("%s%s (%d)%s", dir, strings.TrimSuffix(base, ext), n, ext)
+}
@@ -0,0 +1,225 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func TestUploaderOnExists(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      OnExists
+		wantKey     string
+		wantSkipped bool
+		wantErr     error
+		wantBody    map[string]string
+	}{
+		{
+			name:     "overwrite",
+			policy:   OnExistsOverwrite,
+			wantKey:  "docs/report.csv",
+			wantBody: map[string]string{"docs/report.csv": "new"},
+		},
+		{
+			name:        "skip",
+			policy:      OnExistsSkip,
+			wantKey:     "docs/report.csv",
+			wantSkipped: true,
+			wantBody:    map[string]string{"docs/report.csv": "old"},
+		},
+		{
+			name:     "fail",
+			policy:   OnExistsFail,
+			wantErr:  ErrObjectExists,
+			wantBody: map[string]string{"docs/report.csv": "old"},
+		},
+		{
+			name:    "rename",
+			policy:  OnExistsRename,
+			wantKey: "docs/report (2).csv",
+			wantBody: map[string]string{
+				"docs/report.csv":     "old",
+				"docs/report (1).csv": "older",
+				"docs/report (2).csv": "new",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeS3()
+			fake.put("b", "docs/report.csv", fakeObject{body: []byte("old")})
+			fake.put("b", "docs/report (1).csv", fakeObject{body: []byte("older")})
+
+			u := (&Client{S3: fake}).NewUploader(tt.policy)
+			res, err := u.Upload(context.Background(), "b", "docs/report.csv", strings.NewReader("new"))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Upload() error = %v, want %v", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("Upload() error = %v", err)
+			}
+
+			if res.Key != tt.wantKey || res.Skipped != tt.wantSkipped {
+				t.Errorf("Upload() = %+v, want key %q skipped %v", res, tt.wantKey, tt.wantSkipped)
+			}
+			for key, want := range tt.wantBody {
+				got, ok := fake.get("b", key)
+				if !ok || string(got.body) != want {
+					t.Errorf("%s = %q, want %q", key, got.body, want)
+				}
+			}
+		})
+	}
+}
+
+func TestUploaderNewKeyNeedsNoPolicy(t *testing.T) {
+	fake := newFakeS3()
+	u := (&Client{S3: fake}).NewUploader(OnExistsFail)
+
+	res, err := u.Upload(context.Background(), "b", "fresh.txt", strings.NewReader("hi"))
+	if err != nil || res.Key != "fresh.txt" || res.Skipped {
+		t.Fatalf("Upload() = %+v, %v", res, err)
+	}
+}
+
+func TestUploaderBucketStorageClass(t *testing.T) {
+	fake := newFakeS3()
+	client := &Client{S3: fake, Options: ClientOptions{
+		BucketStorageClasses: map[string]types.StorageClass{"archive": types.StorageClassGlacierIr},
+	}}
+	ctx := context.Background()
+
+	u := client.NewUploader(OnExistsOverwrite)
+	for _, bucket := range []string{"archive", "scratch"} {
+		if _, err := u.Upload(ctx, bucket, "a.txt", strings.NewReader("a")); err != nil {
+			t.Fatalf("Upload(%s) error = %v", bucket, err)
+		}
+	}
+	if obj, _ := fake.get("archive", "a.txt"); obj.storageClass != types.StorageClassGlacierIr {
+		t.Errorf("archive storage class = %q, want the bucket default GLACIER_IR", obj.storageClass)
+	}
+	if obj, _ := fake.get("scratch", "a.txt"); obj.storageClass != "" {
+		t.Errorf("scratch storage class = %q, want S3's default", obj.storageClass)
+	}
+
+	// A per-upload class wins over the bucket default, for multipart uploads too
+	u.Options.StorageClass = types.StorageClassStandardIa
+	u.Options.PartSize = 5
+	if _, err := u.Upload(ctx, "archive", "big.txt", strings.NewReader("0123456789ab")); err != nil {
+		t.Fatalf("Upload(override) error = %v", err)
+	}
+	if obj, _ := fake.get("archive", "big.txt"); obj.storageClass != types.StorageClassStandardIa {
+		t.Errorf("override storage class = %q, want STANDARD_IA", obj.storageClass)
+	}
+}
+
+func TestValidStorageClass(t *testing.T) {
+	if err := ValidStorageClass(types.StorageClassGlacierIr); err != nil {
+		t.Errorf("ValidStorageClass(GLACIER_IR) error = %v", err)
+	}
+	if err := ValidStorageClass("GLACIER-IR"); err == nil {
+		t.Error("expected an error for an unknown storage class")
+	}
+}
+
+func TestNumberedKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		n    int
+		want string
+	}{
+		{"report.csv", 1, "report (1).csv"},
+		{"a/b/report.csv", 3, "a/b/report (3).csv"},
+		{"archive.tar.gz", 1, "archive.tar (1).gz"},
+		{"README", 2, "README (2)"},
+		{"config/.env", 1, "config/.env (1)"},
+	}
+
+	for _, tt := range tests {
+		if got := numberedKey(tt.key, tt.n); got != tt.want {
+			t.Errorf("numberedKey(%q, %d) = %q, want %q", tt.key, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestParseOnExists(t *testing.T) {
+	for _, p := range []OnExists{OnExistsFail, OnExistsOverwrite, OnExistsSkip, OnExistsRename} {
+		got, err := ParseOnExists(p.String())
+		if err != nil || got != p {
+			t.Errorf("ParseOnExists(%q) = %v, %v", p.String(), got, err)
+		}
+	}
+	if _, err := ParseOnExists("clobber"); err == nil {
+		t.Error("expected error for unknown policy")
+	}
+}
+
+func TestUploadDirSkipUnchanged(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"same.txt":       "unchanged",
+		"nested/mod.txt": "edited!", // same length as the remote "original"
+		"new.txt":        "brand new",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fake := newFakeS3()
+	fake.put("b", "site/same.txt", fakeObject{body: []byte("unchanged")})
+	fake.put("b", "site/nested/mod.txt", fakeObject{body: []byte("origin!")})
+
+	u := (&Client{S3: fake}).NewUploader(OnExistsOverwrite)
+	u.SkipUnchanged = true
+	res, err := u.UploadDir(context.Background(), dir, "b", "site")
+	if err != nil {
+		t.Fatalf("UploadDir() error = %v", err)
+	}
+
+	if len(res.Unchanged) != 1 || res.Unchanged[0] != "site/same.txt" {
+		t.Errorf("Unchanged = %v, want [site/same.txt]", res.Unchanged)
+	}
+	slices.Sort(res.Uploaded)
+	if want := []string{"site/nested/mod.txt", "site/new.txt"}; !slices.Equal(res.Uploaded, want) {
+		t.Errorf("Uploaded = %v, want %v", res.Uploaded, want)
+	}
+	if n := fake.countCalls("PutObject"); n != 2 {
+		t.Errorf("made %d PutObject calls, want 2", n)
+	}
+	if got, _ := fake.get("b", "site/nested/mod.txt"); string(got.body) != "edited!" {
+		t.Errorf("nested/mod.txt = %q, want the edited content", got.body)
+	}
+}
+
+func TestUploadDirWithoutSkipUnchangedUploadsEverything(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "same.txt"), []byte("unchanged"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fake := newFakeS3()
+	fake.put("b", "same.txt", fakeObject{body: []byte("unchanged")})
+
+	u := (&Client{S3: fake}).NewUploader(OnExistsOverwrite)
+	res, err := u.UploadDir(context.Background(), dir, "b", "")
+	if err != nil {
+		t.Fatalf("UploadDir() error = %v", err)
+	}
+	if len(res.Uploaded) != 1 || len(res.Unchanged) != 0 {
+		t.Errorf("UploadDir() = %+v, want same.txt uploaded", res)
+	}
+}
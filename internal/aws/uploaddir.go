@@ -0,0 +1,189 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// DirUploadResult reports what UploadDir did with each file, by key
+type DirUploadResult struct {
+	// Uploaded lists the keys written, after any rename
+	Uploaded []string
+	// Skipped lists keys left alone by OnExistsSkip
+	Skipped []string
+	// Unchanged lists keys whose content already matched, under SkipUnchanged
+	Unchanged []string
+}
+
+// UploadDir uploads every regular file under dir to bucket, keyed by its path
+// relative to dir under prefix, or by KeyTemplate when one is set. Symlinks
+// are not followed. A failed file doesn't stop the others; failures are
+// returned as a *PartialFailureError.
+//
+// With SkipUnchanged set, a file whose MD5 reproduces the destination
+// object's ETag is not sent again. This compares content, so a file touched
+// without being edited is still skipped and an edit that keeps the size is
+// still uploaded. Objects with ETags that can't be reproduced locally, such
+// as SSE-KMS ones, are always uploaded.
+func (u *Uploader) UploadDir(ctx context.Context, dir, bucket, prefix string) (DirUploadResult, error) {
+	if err := u.client.checkWritable(bucket); err != nil {
+		return DirUploadResult{}, err
+	}
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return DirUploadResult{}, fmt.Errorf("failed to walk %s: %w", dir, err)
+	}
+
+	var res DirUploadResult
+	results := make([]ObjectResult, 0, len(files))
+	// One date for the whole folder, even across midnight
+	started := time.Now()
+	keys := make([]string, len(files))
+	keyErrs := make([]error, len(files))
+	for i, path := range files {
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return res, fmt.Errorf("failed to resolve %s: %w", path, err)
+		}
+		keys[i] = prefix + filepath.ToSlash(rel)
+		if u.KeyTemplate != nil {
+			expanded, err := u.KeyTemplate.Expand(filepath.ToSlash(rel), started)
+			if err != nil {
+				keyErrs[i] = err
+				continue
+			}
+			keys[i] = prefix + expanded
+		}
+	}
+	// A template can give several files one key; uploading them would leave
+	// whichever happened to go last, so none of them are sent
+	if u.KeyTemplate != nil {
+		firstFile := make(map[string]int, len(files))
+		for i, key := range keys {
+			if keyErrs[i] != nil {
+				continue
+			}
+			j, seen := firstFile[key]
+			if !seen {
+				firstFile[key] = i
+				continue
+			}
+			keyErrs[i] = fmt.Errorf("%w: %s and %s", ErrDuplicateKey, files[j], files[i])
+			if !errors.Is(keyErrs[j], ErrDuplicateKey) {
+				keyErrs[j] = keyErrs[i]
+			}
+		}
+	}
+
+	for i, path := range files {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		key := keys[i]
+		if keyErrs[i] != nil {
+			results = append(results, ObjectResult{Key: key, Err: keyErrs[i]})
+			continue
+		}
+
+		result := ObjectResult{Key: key}
+		switch up, unchanged, err := u.uploadFile(ctx, path, bucket, key); {
+		case err != nil:
+			result.Err = err
+		case unchanged:
+			res.Unchanged = append(res.Unchanged, key)
+		case up.Skipped:
+			res.Skipped = append(res.Skipped, key)
+		default:
+			result.NewKey = up.Key
+			res.Uploaded = append(res.Uploaded, up.Key)
+		}
+		results = append(results, result)
+	}
+	return res, batchError(results)
+}
+
+// uploadFile uploads one local file, reporting unchanged=true when
+// SkipUnchanged found identical content already at key
+func (u *Uploader) uploadFile(ctx context.Context, path, bucket, key string) (UploadResult, bool, error) {
+	if u.SkipUnchanged {
+		same, err := u.client.sameContent(ctx, path, bucket, key)
+		if err != nil {
+			return UploadResult{}, false, err
+		}
+		if same {
+			return UploadResult{Key: key}, true, nil
+		}
+	}
+
+	if u.Journal != nil {
+		res, err := u.uploadJournaled(ctx, path, bucket, key)
+		return res, false, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return UploadResult{}, false, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	fu := *u
+	if fu.Options.ContentType == "" {
+		fu.Options.ContentType = mime.TypeByExtension(filepath.Ext(path))
+	}
+	res, err := fu.Upload(ctx, bucket, key, file)
+	return res, false, err
+}
+
+// sameContent reports whether bucket/key exists with an ETag the local file reproduces
+func (c *Client) sameContent(ctx context.Context, path, bucket, key string) (bool, error) {
+	head, err := c.S3.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		if IsNotFound(err) {
+			return false, nil
+		}
+		return false, &OpError{Op: "Checking object", Bucket: bucket, Key: key, Err: err}
+	}
+
+	etag := aws.ToString(head.ETag)
+	if !VerifiableETag(etag) {
+		return false, nil
+	}
+	// A size mismatch settles it without hashing
+	if info, err := os.Stat(path); err != nil {
+		return false, fmt.Errorf("failed to stat %s: %w", path, err)
+	} else if info.Size() != aws.ToInt64(head.ContentLength) {
+		return false, nil
+	}
+
+	err = CompareETag(path, etag)
+	if errors.Is(err, ErrIntegrityCheckFailed) || errors.Is(err, ErrETagUnverifiable) {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,49 @@
+package aws
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/natevick/stui/internal/security"
+)
+
+// uriScheme is the prefix of every S3 URI
+const uriScheme = "s3://"
+
+// ParseS3URI splits an s3://bucket/key URI into its bucket and key.
+// "s3://bucket", "s3://bucket/" both mean the bucket root (empty key);
+// any other key, including a trailing slash for a prefix, is returned as written.
+func ParseS3URI(s string) (bucket, key string, err error) {
+	rest, ok := strings.CutPrefix(s, uriScheme)
+	if !ok {
+		return "", "", fmt.Errorf("not an s3:// URI: %q", s)
+	}
+
+	bucket, key, _ = strings.Cut(rest, "/")
+	if security.IsAccessPointARN(rest) {
+		// An access point ARN ends in accesspoint/name, so the key starts after the next "/"
+		name, k, _ := strings.Cut(key, "/")
+		bucket, key = bucket+"/"+name, k
+	}
+	if bucket == "" {
+		return "", "", fmt.Errorf("missing bucket in %q", s)
+	}
+	if err := security.ValidBucketName(bucket); err != nil {
+		return "", "", err
+	}
+
+	return bucket, key, nil
+}
+
+// FormatS3URI builds an s3:// URI. An empty key formats as the bucket root, "s3://bucket".
+func FormatS3URI(bucket, key string) string {
+	if key == "" {
+		return uriScheme + bucket
+	}
+	return uriScheme + bucket + "/" + key
+}
+
+// IsS3URI reports whether s uses the s3:// scheme
+func IsS3URI(s string) bool {
+	return strings.HasPrefix(s, uriScheme)
+}
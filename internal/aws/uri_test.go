@@ -0,0 +1,93 @@
+package aws
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseS3URI(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		wantBucket string
+		wantKey    string
+		wantErr    bool
+	}{
+		{"object", "s3://my-bucket/k", "my-bucket", "k", false},
+		{"nested object", "s3://my-bucket/a/b/c", "my-bucket", "a/b/c", false},
+		{"trailing slash root", "s3://my-bucket/", "my-bucket", "", false},
+		{"bucket only", "s3://my-bucket", "my-bucket", "", false},
+		{"prefix", "s3://my-bucket/logs/", "my-bucket", "logs/", false},
+		{"malformed scheme", "s3:/b", "", "", true},
+		{"missing scheme", "b/k", "", "", true},
+		{"missing bucket", "s3:///k", "", "", true},
+		{"invalid bucket", "s3://Bad_Bucket/k", "", "", true},
+		{"access point ARN", "s3://arn:aws:s3:us-west-2:123456789012:accesspoint/my-ap/logs/a.txt",
+			"arn:aws:s3:us-west-2:123456789012:accesspoint/my-ap", "logs/a.txt", false},
+		{"access point ARN root", "s3://arn:aws:s3:us-west-2:123456789012:accesspoint/my-ap",
+			"arn:aws:s3:us-west-2:123456789012:accesspoint/my-ap", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bucket, key, err := ParseS3URI(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseS3URI(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if bucket != tt.wantBucket || key != tt.wantKey {
+				t.Errorf("ParseS3URI(%q) = (%q, %q), want (%q, %q)", tt.input, bucket, key, tt.wantBucket, tt.wantKey)
+			}
+		})
+	}
+}
+
+func TestFormatS3URI(t *testing.T) {
+	tests := []struct {
+		bucket string
+		key    string
+		want   string
+	}{
+		{"my-bucket", "k", "s3://my-bucket/k"},
+		{"my-bucket", "a/b/c", "s3://my-bucket/a/b/c"},
+		{"my-bucket", "logs/", "s3://my-bucket/logs/"},
+		{"my-bucket", "", "s3://my-bucket"},
+	}
+
+	for _, tt := range tests {
+		got := FormatS3URI(tt.bucket, tt.key)
+		if got != tt.want {
+			t.Errorf("FormatS3URI(%q, %q) = %q, want %q", tt.bucket, tt.key, got, tt.want)
+		}
+
+		// Formatting then parsing must round-trip
+		bucket, key, err := ParseS3URI(got)
+		if err != nil || bucket != tt.bucket || key != tt.key {
+			t.Errorf("ParseS3URI(%q) = (%q, %q, %v), want (%q, %q)", got, bucket, key, err, tt.bucket, tt.key)
+		}
+	}
+}
+
+func TestParseS3URIShortBucket(t *testing.T) {
+	// Bucket names are at least 3 characters, so these format but don't parse
+	tests := []struct {
+		input  string
+		bucket string
+		key    string
+	}{
+		{"s3://b/k", "b", "k"},
+		{"s3://b/", "b", ""},
+		{"s3://b", "b", ""},
+	}
+
+	for _, tt := range tests {
+		_, _, err := ParseS3URI(tt.input)
+		if err == nil || !strings.Contains(err.Error(), "3-63 characters") {
+			t.Errorf("ParseS3URI(%q) error = %v, want a bucket name length error", tt.input, err)
+		}
+		if !strings.HasSuffix(tt.input, "/") {
+			if got := FormatS3URI(tt.bucket, tt.key); got != tt.input {
+				t.Errorf("FormatS3URI(%q, %q) = %q, want %q", tt.bucket, tt.key, got, tt.input)
+			}
+		}
+	}
+}
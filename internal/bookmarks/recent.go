@@ -0,0 +1,125 @@
+package bookmarks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/natevick/stui/internal/aws"
+)
+
+// DefaultRecentConcurrency is the number of bookmarks listed in parallel
+const DefaultRecentConcurrency = 4
+
+// ObjectLister lists every object under a prefix
+type ObjectLister interface {
+	ListAllObjects(ctx context.Context, bucket, prefix string) ([]aws.S3Object, error)
+}
+
+// ObjectInfo is an object found under a bookmark
+type ObjectInfo struct {
+	Bookmark Bookmark
+	aws.S3Object
+}
+
+// URI returns the object's s3:// URI
+func (o ObjectInfo) URI() string {
+	return aws.FormatS3URI(o.Bookmark.Bucket, o.Key)
+}
+
+// SkippedBookmarksError reports bookmarks left out of a recent listing because
+// they failed to list, for example by holding more objects than the listing
+// limit allows. The objects from the other bookmarks are returned alongside it.
+type SkippedBookmarksError struct {
+	Errs  []error
+	Total int
+}
+
+func (e *SkippedBookmarksError) Error() string {
+	return fmt.Sprintf("%d of %d bookmarks skipped: %v", len(e.Errs), e.Total, errors.Join(e.Errs...))
+}
+
+func (e *SkippedBookmarksError) Unwrap() []error {
+	return e.Errs
+}
+
+// RecentAcrossBookmarks lists every bookmarked location and returns the limit
+// most recently modified objects, newest first. If only some bookmarks fail to
+// list, the rest are still returned along with a *SkippedBookmarksError; if
+// every bookmark fails, no objects are returned.
+func RecentAcrossBookmarks(ctx context.Context, client ObjectLister, store *Store, limit int) ([]ObjectInfo, error) {
+	marks := store.List()
+	if len(marks) == 0 {
+		return nil, nil
+	}
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results []ObjectInfo
+		errs    []error
+	)
+	sem := make(chan struct{}, DefaultRecentConcurrency)
+
+	for _, b := range marks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+
+			objs, err := client.ListAllObjects(ctx, b.Bucket, b.Prefix)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to list %s: %w", b.Path(), err))
+				return
+			}
+			for _, obj := range objs {
+				if obj.IsPrefix {
+					continue
+				}
+				results = append(results, ObjectInfo{Bookmark: b, S3Object: obj})
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if len(errs) == len(marks) {
+		return nil, errors.Join(errs...)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].LastModified.After(results[j].LastModified)
+	})
+
+	// Overlapping bookmarks (a bucket and one of its prefixes) list the same objects
+	seen := make(map[string]bool, len(results))
+	unique := results[:0]
+	for _, r := range results {
+		if uri := r.URI(); !seen[uri] {
+			seen[uri] = true
+			unique = append(unique, r)
+		}
+	}
+	results = unique
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	if len(errs) > 0 {
+		return results, &SkippedBookmarksError{Errs: errs, Total: len(marks)}
+	}
+	return results, nil
+}
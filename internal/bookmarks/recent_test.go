@@ -0,0 +1,104 @@
+package bookmarks
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/natevick/stui/internal/aws"
+)
+
+// fakeLister serves canned listings keyed by bucket/prefix
+type fakeLister struct {
+	objects map[string][]aws.S3Object
+	errs    map[string]error
+}
+
+func (f fakeLister) ListAllObjects(ctx context.Context, bucket, prefix string) ([]aws.S3Object, error) {
+	loc := bucket + "/" + prefix
+	if err := f.errs[loc]; err != nil {
+		return nil, err
+	}
+	return f.objects[loc], nil
+}
+
+func testStore(marks ...Bookmark) *Store {
+	return &Store{bookmarks: marks}
+}
+
+func TestRecentAcrossBookmarks(t *testing.T) {
+	base := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	at := func(h int) time.Time { return base.Add(time.Duration(h) * time.Hour) }
+
+	lister := fakeLister{
+		objects: map[string][]aws.S3Object{
+			"logs-bucket/app/": {
+				{Key: "app/1.log", LastModified: at(1)},
+				{Key: "app/4.log", LastModified: at(4)},
+				{Key: "app/old/", IsPrefix: true},
+			},
+			"data-bucket/": {
+				{Key: "a.csv", LastModified: at(3)},
+				{Key: "b.csv", LastModified: at(2)},
+				{Key: "c.csv", LastModified: at(5)},
+			},
+		},
+		errs: map[string]error{
+			"broken-bucket/": errors.New("access denied"),
+		},
+	}
+	store := testStore(
+		Bookmark{ID: "1", Bucket: "logs-bucket", Prefix: "app/"},
+		Bookmark{ID: "2", Bucket: "data-bucket"},
+		Bookmark{ID: "3", Bucket: "broken-bucket"},
+	)
+
+	got, err := RecentAcrossBookmarks(context.Background(), lister, store, 3)
+	var skipped *SkippedBookmarksError
+	if !errors.As(err, &skipped) {
+		t.Fatalf("RecentAcrossBookmarks() error = %v, want a SkippedBookmarksError", err)
+	}
+	if len(skipped.Errs) != 1 || skipped.Total != 3 {
+		t.Errorf("skipped %d of %d bookmarks, want 1 of 3", len(skipped.Errs), skipped.Total)
+	}
+
+	want := []string{"s3://data-bucket/c.csv", "s3://logs-bucket/app/4.log", "s3://data-bucket/a.csv"}
+	if len(got) != len(want) {
+		t.Fatalf("got %d objects, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		if got[i].URI() != w {
+			t.Errorf("result[%d] = %s, want %s", i, got[i].URI(), w)
+		}
+	}
+}
+
+func TestRecentAcrossBookmarksOverlapping(t *testing.T) {
+	obj := aws.S3Object{Key: "logs/a.log", LastModified: time.Now()}
+	lister := fakeLister{objects: map[string][]aws.S3Object{
+		"b1/":      {obj},
+		"b1/logs/": {obj},
+	}}
+	store := testStore(Bookmark{ID: "1", Bucket: "b1"}, Bookmark{ID: "2", Bucket: "b1", Prefix: "logs/"})
+
+	got, err := RecentAcrossBookmarks(context.Background(), lister, store, 10)
+	if err != nil {
+		t.Fatalf("RecentAcrossBookmarks() error = %v", err)
+	}
+	if len(got) != 1 {
+		t.Errorf("got %d objects, want the duplicate collapsed to 1", len(got))
+	}
+}
+
+func TestRecentAcrossBookmarksAllFail(t *testing.T) {
+	lister := fakeLister{errs: map[string]error{
+		"b1/": errors.New("boom"),
+		"b2/": errors.New("boom"),
+	}}
+	store := testStore(Bookmark{ID: "1", Bucket: "b1"}, Bookmark{ID: "2", Bucket: "b2"})
+
+	if _, err := RecentAcrossBookmarks(context.Background(), lister, store, 10); err == nil {
+		t.Error("expected an error when every bookmark fails")
+	}
+}
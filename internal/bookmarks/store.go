@@ -2,12 +2,18 @@ package bookmarks
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
+	"github.com/natevick/stui/internal/aws"
+	"github.com/natevick/stui/internal/nav"
 	"github.com/natevick/stui/internal/security"
 )
 
@@ -17,26 +23,25 @@ type Bookmark struct {
 	Name      string    `json:"name"`
 	Bucket    string    `json:"bucket"`
 	Prefix    string    `json:"prefix"`
+	Profile   string    `json:"profile,omitempty"`
+	Region    string    `json:"region,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// ErrBookmarkExists is returned when the location is already bookmarked
+var ErrBookmarkExists = errors.New("location is already bookmarked")
+
 // DisplayName returns the bookmark display name
 func (b Bookmark) DisplayName() string {
 	if b.Name != "" {
 		return b.Name
 	}
-	if b.Prefix != "" {
-		return fmt.Sprintf("s3://%s/%s", b.Bucket, b.Prefix)
-	}
-	return fmt.Sprintf("s3://%s", b.Bucket)
+	return aws.FormatS3URI(b.Bucket, b.Prefix)
 }
 
 // Path returns the full S3 path
 func (b Bookmark) Path() string {
-	if b.Prefix != "" {
-		return fmt.Sprintf("s3://%s/%s", b.Bucket, b.Prefix)
-	}
-	return fmt.Sprintf("s3://%s", b.Bucket)
+	return aws.FormatS3URI(b.Bucket, b.Prefix)
 }
 
 // Store manages bookmark persistence
@@ -108,21 +113,65 @@ func (s *Store) Save() error {
 
 // Add creates a new bookmark
 func (s *Store) Add(name, bucket, prefix string) (Bookmark, error) {
+	return s.add(Bookmark{Name: name, Bucket: bucket, Prefix: prefix})
+}
+
+// AddCurrent bookmarks the current location, remembering the profile and
+// region it was found with. A blank name is generated from bucket/prefix.
+func (s *Store) AddCurrent(state *nav.State, profile, region, name string) (Bookmark, error) {
+	if state == nil || state.AtBucketList() {
+		return Bookmark{}, fmt.Errorf("no bucket is open")
+	}
+	if existing, ok := s.FindByPath(state.Bucket, state.Prefix); ok {
+		return existing, ErrBookmarkExists
+	}
+	if strings.TrimSpace(name) == "" {
+		name = AutoName(state.Bucket, state.Prefix)
+	}
+
+	return s.add(Bookmark{
+		Name:    name,
+		Bucket:  state.Bucket,
+		Prefix:  state.Prefix,
+		Profile: profile,
+		Region:  region,
+	})
+}
+
+// AutoName builds a bookmark name like "bucket/prefix", replacing characters
+// that ValidBookmarkName rejects with "_"
+func AutoName(bucket, prefix string) string {
+	raw := strings.TrimSuffix(bucket+"/"+prefix, "/")
+
+	var b strings.Builder
+	for _, r := range raw {
+		if r < utf8.RuneSelf && (r == '-' || r == '_' || r == '.' || r == '/' || r == ' ' ||
+			unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+
+	name := b.String()
+	if len(name) > security.MaxBookmarkNameLen {
+		name = name[:security.MaxBookmarkNameLen]
+	}
+	return name
+}
+
+// add validates a bookmark, assigns its ID, and persists it
+func (s *Store) add(bookmark Bookmark) (Bookmark, error) {
 	// Validate inputs
-	if err := security.ValidBookmarkName(name); err != nil {
+	if err := security.ValidBookmarkName(bookmark.Name); err != nil {
 		return Bookmark{}, err
 	}
-	if err := security.ValidBucketName(bucket); err != nil {
+	if err := security.ValidBucketName(bookmark.Bucket); err != nil {
 		return Bookmark{}, err
 	}
 
-	bookmark := Bookmark{
-		ID:        uuid.New().String(),
-		Name:      name,
-		Bucket:    bucket,
-		Prefix:    prefix,
-		CreatedAt: time.Now(),
-	}
+	bookmark.ID = uuid.New().String()
+	bookmark.CreatedAt = time.Now()
 
 	s.bookmarks = append(s.bookmarks, bookmark)
 
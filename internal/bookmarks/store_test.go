@@ -1,9 +1,14 @@
 package bookmarks
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/natevick/stui/internal/nav"
+	"github.com/natevick/stui/internal/security"
 )
 
 func TestBookmarkStore(t *testing.T) {
@@ -100,3 +105,71 @@ func TestBookmarkDisplayName(t *testing.T) {
 		})
 	}
 }
+
+func TestAddCurrent(t *testing.T) {
+	store := &Store{
+		path:      filepath.Join(t.TempDir(), "bookmarks.json"),
+		bookmarks: []Bookmark{},
+	}
+
+	state := &nav.State{Bucket: "my-bucket", Prefix: "logs/2024/"}
+	bm, err := store.AddCurrent(state, "dev", "us-west-2", "Logs")
+	if err != nil {
+		t.Fatalf("AddCurrent() error = %v", err)
+	}
+	if bm.Name != "Logs" || bm.Bucket != "my-bucket" || bm.Prefix != "logs/2024/" {
+		t.Errorf("AddCurrent() = %+v", bm)
+	}
+	if bm.Profile != "dev" || bm.Region != "us-west-2" {
+		t.Errorf("profile/region = %q/%q, want dev/us-west-2", bm.Profile, bm.Region)
+	}
+
+	// The same location can't be added twice
+	if _, err := store.AddCurrent(state, "dev", "us-west-2", ""); !errors.Is(err, ErrBookmarkExists) {
+		t.Errorf("duplicate AddCurrent() error = %v, want ErrBookmarkExists", err)
+	}
+
+	// Blank names are generated from the location
+	bm, err = store.AddCurrent(&nav.State{Bucket: "my-bucket"}, "", "", "  ")
+	if err != nil {
+		t.Fatalf("AddCurrent() error = %v", err)
+	}
+	if bm.Name != "my-bucket" {
+		t.Errorf("auto name = %q, want my-bucket", bm.Name)
+	}
+
+	if _, err := store.AddCurrent(&nav.State{}, "", "", ""); err == nil {
+		t.Error("expected error at the bucket list")
+	}
+}
+
+func TestAutoName(t *testing.T) {
+	tests := []struct {
+		name   string
+		bucket string
+		prefix string
+		want   string
+	}{
+		{"bucket root", "my-bucket", "", "my-bucket"},
+		{"prefix", "my-bucket", "logs/2024/", "my-bucket/logs/2024"},
+		{"illegal characters", "my-bucket", "a<b>;c:d/", "my-bucket/a_b__c_d"},
+		{"unicode", "my-bucket", "données/", "my-bucket/donn_es"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AutoName(tt.bucket, tt.prefix)
+			if got != tt.want {
+				t.Errorf("AutoName(%q, %q) = %q, want %q", tt.bucket, tt.prefix, got, tt.want)
+			}
+			if err := security.ValidBookmarkName(got); err != nil {
+				t.Errorf("AutoName result %q is invalid: %v", got, err)
+			}
+		})
+	}
+
+	long := AutoName("my-bucket", strings.Repeat("x", 400))
+	if err := security.ValidBookmarkName(long); err != nil {
+		t.Errorf("long auto name invalid: %v", err)
+	}
+}
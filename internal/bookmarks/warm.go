@@ -0,0 +1,65 @@
+package bookmarks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/natevick/stui/internal/aws"
+)
+
+// PrefixLister lists the objects and folders directly under a prefix
+type PrefixLister interface {
+	ListObjects(ctx context.Context, bucket, prefix string) ([]aws.S3Object, error)
+}
+
+// WarmCache pre-lists every bookmarked location into cache so opening a
+// bookmark is instant. At most concurrency listings run at once. A failing
+// bookmark doesn't stop the others; failures are joined into the returned
+// error for logging. Cancelling ctx stops warming promptly and returns ctx.Err().
+func WarmCache(ctx context.Context, client PrefixLister, store *Store, cache *aws.ListingCache, concurrency int) error {
+	if concurrency <= 0 {
+		concurrency = DefaultRecentConcurrency
+	}
+
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		errs []error
+	)
+	sem := make(chan struct{}, concurrency)
+
+	for _, b := range store.List() {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			objects, err := client.ListObjects(ctx, b.Bucket, b.Prefix)
+			if err != nil {
+				if ctx.Err() == nil {
+					mu.Lock()
+					errs = append(errs, fmt.Errorf("failed to warm %s: %w", b.Path(), err))
+					mu.Unlock()
+				}
+				return
+			}
+			cache.Put(b.Bucket, b.Prefix, objects)
+		}()
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,84 @@
+package bookmarks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/natevick/stui/internal/aws"
+)
+
+// funcLister adapts a function to PrefixLister
+type funcLister func(ctx context.Context, bucket, prefix string) ([]aws.S3Object, error)
+
+func (f funcLister) ListObjects(ctx context.Context, bucket, prefix string) ([]aws.S3Object, error) {
+	return f(ctx, bucket, prefix)
+}
+
+func TestWarmCache(t *testing.T) {
+	store := testStore(
+		Bookmark{ID: "1", Bucket: "logs-bucket", Prefix: "app/"},
+		Bookmark{ID: "2", Bucket: "data-bucket"},
+		Bookmark{ID: "3", Bucket: "broken-bucket"},
+	)
+	lister := funcLister(func(ctx context.Context, bucket, prefix string) ([]aws.S3Object, error) {
+		if bucket == "broken-bucket" {
+			return nil, errors.New("access denied")
+		}
+		return []aws.S3Object{{Key: prefix + "file.txt"}}, nil
+	})
+	cache := aws.NewListingCache(time.Minute)
+
+	err := WarmCache(context.Background(), lister, store, cache, 2)
+	if err == nil {
+		t.Error("expected the failing bookmark to be reported")
+	}
+
+	for _, b := range store.List()[:2] {
+		objects, ok := cache.Get(b.Bucket, b.Prefix)
+		if !ok || len(objects) != 1 || objects[0].Key != b.Prefix+"file.txt" {
+			t.Errorf("cache for %s = %v, %v", b.Path(), objects, ok)
+		}
+	}
+	if _, ok := cache.Get("broken-bucket", ""); ok {
+		t.Error("failed bookmark was cached")
+	}
+}
+
+func TestWarmCacheStopsOnCancel(t *testing.T) {
+	var marks []Bookmark
+	for i := 0; i < 20; i++ {
+		marks = append(marks, Bookmark{ID: fmt.Sprint(i), Bucket: fmt.Sprintf("bucket-%02d", i)})
+	}
+	store := testStore(marks...)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls atomic.Int32
+	lister := funcLister(func(ctx context.Context, bucket, prefix string) ([]aws.S3Object, error) {
+		if calls.Add(1) == 1 {
+			cancel() // the user started navigating
+		}
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		done <- WarmCache(ctx, lister, store, aws.NewListingCache(time.Minute), 1)
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("WarmCache() error = %v, want context.Canceled", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("WarmCache did not stop after cancellation")
+	}
+	if n := calls.Load(); n >= 20 {
+		t.Errorf("listed %d bookmarks after cancellation", n)
+	}
+}
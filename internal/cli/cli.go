@@ -0,0 +1,196 @@
+// Package cli implements stui's non-interactive subcommands (ls, cp, rm, mv,
+// presign, get, put, diff, xcp, ...) on top of the same AWS client the TUI uses.
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/natevick/stui/internal/aws"
+	"github.com/natevick/stui/internal/security"
+)
+
+// Exit codes returned by Run
+const (
+	ExitOK           = 0
+	ExitError        = 1
+	ExitUsage        = 2
+	ExitNotFound     = 3
+	ExitAccessDenied = 4
+)
+
+// Env holds the streams commands read from and write to
+type Env struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// ClientFactory creates the AWS client commands run against, for profile or,
+// when profile is empty, the one stui was started with. It is only called
+// once a command has parsed its arguments.
+type ClientFactory func(ctx context.Context, profile string) (*aws.Client, error)
+
+// command is a subcommand definition
+type command struct {
+	usage   string
+	summary string
+	run     func(ctx context.Context, r *runner, args []string) error
+}
+
+var commands = map[string]command{
+	"ls":          {"ls [s3://bucket[/prefix]]", "List buckets, or objects under a prefix", runLs},
+	"cp":          {"cp [-if-exists fail] [-skip-unchanged] [-storage-class CLASS] SRC DST", "Copy between S3 and local paths (\"-\" for stdin/stdout)", runCp},
+	"mv":          {"mv s3://SRC s3://DST", "Move an object within or between buckets", runMv},
+	"rm":          {"rm [-r [-max-objects N]] s3://bucket/key", "Delete an object, or everything under a prefix with -r", runRm},
+	"presign":     {"presign [-expires 1h] [-signed-at time] s3://bucket/key", "Print a presigned download URL", runPresign},
+	"get":         {"get s3://bucket/key", "Write an object to stdout", runGet},
+	"put":         {"put [-if-exists fail] [-storage-class CLASS] s3://bucket/key", "Upload stdin to an object", runPut},
+	"diff":        {"diff LEFT RIGHT", "Show keys that differ between two prefixes or a local dir and a prefix", runDiff},
+	"audit":       {"audit [-c 8] [-read] s3://bucket[/prefix]", "Check that every object under a prefix can still be read", runAudit},
+	"empty-trash": {"empty-trash [-older-than 720h] s3://bucket", "Permanently delete soft-deleted objects past the trash retention", runEmptyTrash},
+	"xcp":         {"xcp -to PROFILE [-if-exists fail] [-yes] s3://SRC s3://DST", "Copy an object to a bucket only another profile can write to", runXcp},
+	"uploads":     {"uploads [-abort s3://bucket] [-abort-older-than 168h] [s3://bucket[/prefix]]", "List or abort interrupted uploads (journaled, or all of a bucket's)", runUploads},
+}
+
+// usageError marks bad arguments, reported with ExitUsage
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
+
+func usagef(format string, args ...any) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// runner carries what a command needs while it runs
+type runner struct {
+	env       Env
+	newClient ClientFactory
+	client    *aws.Client
+}
+
+// Client creates the AWS client on first use
+func (r *runner) Client(ctx context.Context) (*aws.Client, error) {
+	if r.client == nil {
+		client, err := r.newClient(ctx, "")
+		if err != nil {
+			return nil, err
+		}
+		r.client = client
+	}
+	return r.client, nil
+}
+
+// ProfileClient creates an AWS client for another profile
+func (r *runner) ProfileClient(ctx context.Context, profile string) (*aws.Client, error) {
+	return r.newClient(ctx, profile)
+}
+
+// IsCommand reports whether name is a CLI subcommand
+func IsCommand(name string) bool {
+	_, ok := commands[name]
+	return ok
+}
+
+// Run executes the subcommand named by args[0] and returns the process exit code.
+// Errors are sanitized before being printed to stderr.
+func Run(ctx context.Context, args []string, env Env, newClient ClientFactory) int {
+	if len(args) == 0 {
+		printUsage(env.Stderr)
+		return ExitUsage
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(env.Stderr, "stui: unknown command %q\n\n", args[0])
+		printUsage(env.Stderr)
+		return ExitUsage
+	}
+
+	r := &runner{env: env, newClient: newClient}
+	err := cmd.run(ctx, r, args[1:])
+	if r.client != nil {
+		if profile := r.client.TakeFallbackProfile(); profile != "" {
+			fmt.Fprintf(env.Stderr, "stui %s: access denied for profile %q; read with fallback profile %q\n",
+				args[0], r.client.Profile, profile)
+		}
+	}
+	if err == nil {
+		return ExitOK
+	}
+
+	var usageErr *usageError
+	if errors.As(err, &usageErr) {
+		fmt.Fprintf(env.Stderr, "stui %s: %s\nusage: stui %s\n", args[0], usageErr.msg, cmd.usage)
+		return ExitUsage
+	}
+
+	fmt.Fprintf(env.Stderr, "stui %s: %s\n", args[0], security.SanitizeError(err))
+	return exitCode(err)
+}
+
+// exitCode maps an error to a process exit code
+func exitCode(err error) int {
+	switch {
+	case aws.IsNotFound(err):
+		return ExitNotFound
+	case aws.IsAccessDenied(err):
+		return ExitAccessDenied
+	default:
+		return ExitError
+	}
+}
+
+// printUsage lists the available subcommands
+func printUsage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "usage: stui [flags] <command> [args]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-40s %s\n", commands[name].usage, commands[name].summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run stui with no command to start the TUI.")
+}
+
+// newFlagSet creates a flag set that reports errors as usage errors
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return fs
+}
+
+// parseFlags parses command flags, converting failures into usage errors
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return usagef("%v", err)
+	}
+	return nil
+}
+
+// requireObjectURI parses an s3:// URI that must name an object
+func requireObjectURI(s string) (bucket, key string, err error) {
+	bucket, key, err = aws.ParseS3URI(s)
+	if err != nil {
+		return "", "", usagef("%v", err)
+	}
+	if key == "" || strings.HasSuffix(key, "/") {
+		return "", "", usagef("%q does not name an object", s)
+	}
+	return bucket, key, nil
+}
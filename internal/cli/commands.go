@@ -0,0 +1,631 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/natevick/stui/internal/aws"
+	"github.com/natevick/stui/internal/download"
+	"github.com/natevick/stui/internal/security"
+)
+
+// stdio is the path argument meaning stdin or stdout
+const stdio = "-"
+
+func runLs(ctx context.Context, r *runner, args []string) error {
+	if len(args) > 1 {
+		return usagef("expected at most one URI")
+	}
+
+	if len(args) == 0 {
+		client, err := r.Client(ctx)
+		if err != nil {
+			return err
+		}
+		buckets, err := client.ListAllBuckets(ctx)
+		if err != nil {
+			return err
+		}
+		FormatBuckets(r.env.Stdout, buckets)
+		return nil
+	}
+
+	bucket, prefix, err := aws.ParseS3URI(args[0])
+	if err != nil {
+		return usagef("%v", err)
+	}
+	client, err := r.Client(ctx)
+	if err != nil {
+		return err
+	}
+	objects, err := client.ListObjects(ctx, bucket, prefix)
+	if err != nil {
+		return err
+	}
+	FormatListing(r.env.Stdout, objects)
+	return nil
+}
+
+func runCp(ctx context.Context, r *runner, args []string) error {
+	fs := newFlagSet("cp")
+	ifExists := fs.String("if-exists", "fail", "fail, overwrite, skip, or rename")
+	skipUnchanged := fs.Bool("skip-unchanged", false, "when uploading a folder, skip files whose content matches the object's ETag")
+	storageClass := fs.String("storage-class", "", "storage class for uploads (e.g. GLACIER_IR); default is the bucket's configured class")
+	keyTemplate := fs.String("key-template", "", "when uploading a folder, key files by a template like {date}/{basename}; default is the configured template")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	policy, err := aws.ParseOnExists(*ifExists)
+	if err != nil {
+		return usagef("-if-exists: %v", err)
+	}
+	class := types.StorageClass(*storageClass)
+	if class != "" {
+		if err := aws.ValidStorageClass(class); err != nil {
+			return usagef("-storage-class: %v", err)
+		}
+	}
+	var tmpl *aws.KeyTemplate
+	if *keyTemplate != "" {
+		if tmpl, err = aws.ParseKeyTemplate(*keyTemplate); err != nil {
+			return usagef("-key-template: %v", err)
+		}
+	}
+	if fs.NArg() != 2 {
+		return usagef("expected SRC and DST")
+	}
+	src, dst := fs.Arg(0), fs.Arg(1)
+
+	switch {
+	case aws.IsS3URI(src) && aws.IsS3URI(dst):
+		srcBucket, srcKey, err := requireObjectURI(src)
+		if err != nil {
+			return err
+		}
+		dstBucket, dstKey, err := aws.ParseS3URI(dst)
+		if err != nil {
+			return usagef("%v", err)
+		}
+		dstKey = destinationKey(dstKey, path.Base(srcKey))
+		if policy != aws.OnExistsFail && policy != aws.OnExistsOverwrite {
+			return usagef("-if-exists %s is only supported for uploads", policy)
+		}
+
+		client, err := r.Client(ctx)
+		if err != nil {
+			return err
+		}
+		return client.CopyObject(ctx, srcBucket, srcKey, dstBucket, dstKey, policy == aws.OnExistsOverwrite)
+
+	case aws.IsS3URI(src):
+		bucket, key, err := requireObjectURI(src)
+		if err != nil {
+			return err
+		}
+		client, err := r.Client(ctx)
+		if err != nil {
+			return err
+		}
+		if dst == stdio {
+			return client.DownloadStream(ctx, bucket, key, r.env.Stdout)
+		}
+		localPath, err := localDestination(dst, path.Base(key))
+		if err != nil {
+			return err
+		}
+		err = client.DownloadFile(ctx, bucket, key, localPath, nil)
+		if errors.Is(err, aws.ErrETagUnverifiable) {
+			fmt.Fprintf(r.env.Stderr, "warning: %s downloaded but not verified: %v\n", localPath, err)
+			return nil
+		}
+		return err
+
+	case aws.IsS3URI(dst):
+		bucket, key, err := aws.ParseS3URI(dst)
+		if err != nil {
+			return usagef("%v", err)
+		}
+		client, err := r.Client(ctx)
+		if err != nil {
+			return err
+		}
+		uploader := client.NewUploader(policy)
+		uploader.Options.StorageClass = class
+		if src == stdio {
+			if key == "" || strings.HasSuffix(key, "/") {
+				return usagef("%q does not name an object", dst)
+			}
+			return upload(ctx, r, uploader, bucket, key, r.env.Stdin)
+		}
+
+		if info, err := os.Stat(src); err == nil && info.IsDir() {
+			uploader.SkipUnchanged = *skipUnchanged
+			if tmpl != nil {
+				uploader.KeyTemplate = tmpl
+			}
+			return uploadDir(ctx, r, uploader, src, bucket, key)
+		}
+
+		key = destinationKey(key, filepath.Base(src))
+		return uploadFile(ctx, r, uploader, src, bucket, key)
+
+	default:
+		return usagef("one of SRC or DST must be an s3:// URI")
+	}
+}
+
+func runMv(ctx context.Context, r *runner, args []string) error {
+	if len(args) != 2 || !aws.IsS3URI(args[0]) || !aws.IsS3URI(args[1]) {
+		return usagef("expected two s3:// URIs")
+	}
+	srcBucket, srcKey, err := requireObjectURI(args[0])
+	if err != nil {
+		return err
+	}
+	dstBucket, dstKey, err := aws.ParseS3URI(args[1])
+	if err != nil {
+		return usagef("%v", err)
+	}
+	dstKey = destinationKey(dstKey, path.Base(srcKey))
+
+	client, err := r.Client(ctx)
+	if err != nil {
+		return err
+	}
+	return client.MoveObject(ctx, srcBucket, srcKey, dstBucket, dstKey, true)
+}
+
+func runDiff(ctx context.Context, r *runner, args []string) error {
+	if len(args) != 2 {
+		return usagef("expected two locations")
+	}
+
+	var locations [2]download.Location
+	for i, arg := range args {
+		if !aws.IsS3URI(arg) {
+			locations[i] = download.LocalLocation{Dir: arg}
+			continue
+		}
+		bucket, prefix, err := aws.ParseS3URI(arg)
+		if err != nil {
+			return usagef("%v", err)
+		}
+		client, err := r.Client(ctx)
+		if err != nil {
+			return err
+		}
+		locations[i] = download.S3Location{Client: client, Bucket: bucket, Prefix: prefix}
+	}
+
+	result, err := download.Diff(ctx, locations[0], locations[1])
+	if err != nil {
+		return err
+	}
+	FormatDiff(r.env.Stdout, result)
+	return nil
+}
+
+func runRm(ctx context.Context, r *runner, args []string) error {
+	fs := newFlagSet("rm")
+	recursive := fs.Bool("r", false, "delete everything under the prefix")
+	maxObjects := fs.Int("max-objects", -1, "with -r, override max_recursive_objects for this run (0 for no limit)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usagef("expected one s3:// URI")
+	}
+
+	bucket, key, err := aws.ParseS3URI(fs.Arg(0))
+	if err != nil {
+		return usagef("%v", err)
+	}
+	if !*recursive && (key == "" || strings.HasSuffix(key, "/")) {
+		return usagef("%q is a prefix; use -r to delete everything under it", fs.Arg(0))
+	}
+
+	client, err := r.Client(ctx)
+	if err != nil {
+		return err
+	}
+
+	keys := []string{key}
+	if *recursive {
+		if *maxObjects >= 0 {
+			ctx = aws.WithObjectLimit(ctx, *maxObjects)
+		}
+		objects, err := client.ListAllObjects(ctx, bucket, key)
+		if err != nil {
+			return err
+		}
+		keys = keys[:0]
+		for _, obj := range objects {
+			keys = append(keys, obj.Key)
+		}
+	}
+
+	failures, err := client.DeleteObjects(ctx, bucket, keys)
+	if err != nil {
+		return err
+	}
+
+	failed := make(map[string]bool, len(failures))
+	for _, f := range failures {
+		failed[f.Key] = true
+		fmt.Fprintf(r.env.Stderr, "delete failed: %s: %s\n", aws.FormatS3URI(bucket, f.Key), f.Message)
+	}
+	for _, k := range keys {
+		if !failed[k] {
+			fmt.Fprintf(r.env.Stdout, "delete: %s\n", aws.FormatS3URI(bucket, k))
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("%d of %d deletes failed", len(failures), len(keys))
+	}
+	return nil
+}
+
+func runAudit(ctx context.Context, r *runner, args []string) error {
+	fs := newFlagSet("audit")
+	concurrency := fs.Int("c", aws.DefaultAuditConcurrency, "objects checked in parallel")
+	readByte := fs.Bool("read", false, "also read each object's first byte")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usagef("expected one s3:// URI")
+	}
+	bucket, prefix, err := aws.ParseS3URI(fs.Arg(0))
+	if err != nil {
+		return usagef("%v", err)
+	}
+
+	client, err := r.Client(ctx)
+	if err != nil {
+		return err
+	}
+	results, err := client.Audit(ctx, bucket, prefix, aws.AuditOptions{Concurrency: *concurrency, ReadByte: *readByte})
+	if err != nil {
+		return err
+	}
+
+	checked, failed := 0, 0
+	for res := range results {
+		checked++
+		if res.Err != nil {
+			failed++
+			fmt.Fprintf(r.env.Stderr, "FAIL %s: %v\n", aws.FormatS3URI(bucket, res.Key), res.Err)
+			continue
+		}
+		fmt.Fprintf(r.env.Stdout, "ok   %s\n", aws.FormatS3URI(bucket, res.Key))
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d objects failed the audit", failed, checked)
+	}
+	return nil
+}
+
+func runPresign(ctx context.Context, r *runner, args []string) error {
+	fs := newFlagSet("presign")
+	expires := fs.Duration("expires", time.Hour, "how long the URL stays valid (max 168h)")
+	signedAt := fs.String("signed-at", "", "sign as of this RFC 3339 time instead of now, for reproducible URLs")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	var at time.Time
+	if *signedAt != "" {
+		t, err := time.Parse(time.RFC3339, *signedAt)
+		if err != nil {
+			return usagef("invalid -signed-at %q, expected RFC 3339 such as 2024-03-01T09:30:00Z", *signedAt)
+		}
+		at = t
+	}
+	if fs.NArg() != 1 {
+		return usagef("expected one s3:// URI")
+	}
+
+	bucket, key, err := requireObjectURI(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	client, err := r.Client(ctx)
+	if err != nil {
+		return err
+	}
+	presigned, err := client.PresignGet(ctx, bucket, key, *expires, at)
+	if err != nil {
+		return err
+	}
+	if presigned.Capped {
+		fmt.Fprintf(r.env.Stderr, "warning: credentials expire sooner than requested; URL is valid for %s\n", presigned.Expires)
+	}
+	fmt.Fprintln(r.env.Stdout, presigned.URL)
+	return nil
+}
+
+func runGet(ctx context.Context, r *runner, args []string) error {
+	if len(args) != 1 {
+		return usagef("expected one s3:// URI")
+	}
+	return runCp(ctx, r, []string{args[0], stdio})
+}
+
+func runPut(ctx context.Context, r *runner, args []string) error {
+	fs := newFlagSet("put")
+	ifExists := fs.String("if-exists", "fail", "fail, overwrite, skip, or rename")
+	storageClass := fs.String("storage-class", "", "storage class (e.g. GLACIER_IR); default is the bucket's configured class")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usagef("expected one s3:// URI")
+	}
+	return runCp(ctx, r, []string{"-if-exists", *ifExists, "-storage-class", *storageClass, stdio, fs.Arg(0)})
+}
+
+func runEmptyTrash(ctx context.Context, r *runner, args []string) error {
+	fs := newFlagSet("empty-trash")
+	olderThan := fs.Duration("older-than", -1, "delete trash deleted this long ago (0 for all of it); defaults to trash_retention_hours")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usagef("expected one s3://bucket")
+	}
+	bucket, key, err := aws.ParseS3URI(fs.Arg(0))
+	if err != nil {
+		return usagef("%v", err)
+	}
+	if key != "" {
+		return usagef("empty-trash takes a bucket, not %q", fs.Arg(0))
+	}
+
+	client, err := r.Client(ctx)
+	if err != nil {
+		return err
+	}
+
+	age := *olderThan
+	if age < 0 {
+		if age = client.Options.TrashRetention; age == 0 {
+			return usagef("trash_retention_hours isn't set; pass -older-than")
+		}
+	}
+	n, err := client.EmptyTrash(ctx, bucket, age)
+	fmt.Fprintf(r.env.Stdout, "deleted %d trash objects\n", n)
+	return err
+}
+
+func runUploads(ctx context.Context, r *runner, args []string) error {
+	fs := newFlagSet("uploads")
+	abort := fs.String("abort", "", "abort the interrupted uploads to this s3://bucket")
+	olderThan := fs.Duration("abort-older-than", 0, "abort every incomplete upload in the bucket started this long ago")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return usagef("expected at most one URI")
+	}
+	var abortBucket string
+	if *abort != "" {
+		var key string
+		var err error
+		if abortBucket, key, err = aws.ParseS3URI(*abort); err != nil {
+			return usagef("%v", err)
+		}
+		if key != "" {
+			return usagef("-abort takes a bucket, not %q", *abort)
+		}
+	}
+	var bucket, prefix string
+	if fs.NArg() == 1 {
+		var err error
+		if bucket, prefix, err = aws.ParseS3URI(fs.Arg(0)); err != nil {
+			return usagef("%v", err)
+		}
+	}
+	if *olderThan < 0 || *olderThan > 0 && (bucket == "" || prefix != "") {
+		return usagef("-abort-older-than needs a positive age and an s3://bucket")
+	}
+
+	client, err := r.Client(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Uploads in S3, whoever started them
+	if bucket != "" {
+		if *olderThan > 0 {
+			n, err := client.AbortIncompleteUploads(ctx, bucket, *olderThan)
+			fmt.Fprintf(r.env.Stdout, "aborted %d incomplete uploads\n", n)
+			return err
+		}
+		uploads, err := client.ListIncompleteUploads(ctx, bucket, prefix)
+		if err != nil {
+			return err
+		}
+		FormatIncompleteUploads(r.env.Stdout, uploads)
+		return nil
+	}
+
+	// Uploads this machine journaled
+	uploader := client.NewUploader(aws.OnExistsFail)
+	if uploader.Journal == nil {
+		return fmt.Errorf("uploads aren't journaled")
+	}
+	if abortBucket != "" {
+		aborted, err := uploader.AbortOrphanedUploads(ctx, abortBucket)
+		for _, rec := range aborted {
+			fmt.Fprintf(r.env.Stdout, "aborted: %s\n", aws.FormatS3URI(rec.Bucket, rec.Key))
+		}
+		return err
+	}
+	records, err := uploader.Journal.Records()
+	if err != nil {
+		return err
+	}
+	FormatUploads(r.env.Stdout, records)
+	return nil
+}
+
+// upload sends r through the uploader, reporting skips and renames on stderr
+// runXcp copies an object between accounts: read with the --profile
+// credentials, written with the -to profile's
+func runXcp(ctx context.Context, r *runner, args []string) error {
+	fs := newFlagSet("xcp")
+	to := fs.String("to", "", "profile whose credentials write the destination")
+	ifExists := fs.String("if-exists", "fail", "fail, overwrite, skip, or rename")
+	yes := fs.Bool("yes", false, "copy across regions without asking to confirm the transfer charges")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *to == "" {
+		return usagef("-to is required")
+	}
+	if err := security.ValidProfileName(*to); err != nil {
+		return usagef("-to: %v", err)
+	}
+	policy, err := aws.ParseOnExists(*ifExists)
+	if err != nil {
+		return usagef("-if-exists: %v", err)
+	}
+	if fs.NArg() != 2 || !aws.IsS3URI(fs.Arg(0)) || !aws.IsS3URI(fs.Arg(1)) {
+		return usagef("expected two s3:// URIs")
+	}
+	srcBucket, srcKey, err := requireObjectURI(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	dstBucket, dstKey, err := aws.ParseS3URI(fs.Arg(1))
+	if err != nil {
+		return usagef("%v", err)
+	}
+	dstKey = destinationKey(dstKey, path.Base(srcKey))
+
+	srcClient, err := r.Client(ctx)
+	if err != nil {
+		return err
+	}
+	dstClient, err := r.ProfileClient(ctx, *to)
+	if err != nil {
+		return err
+	}
+	res, err := aws.CrossAccountCopy(ctx,
+		aws.SrcLocation{Client: srcClient, Bucket: srcBucket, Key: srcKey},
+		aws.DstLocation{
+			Client:          dstClient,
+			Bucket:          dstBucket,
+			Key:             dstKey,
+			OnExists:        policy,
+			EgressWarnBytes: dstClient.Options.EgressWarnBytes,
+			EgressConfirmed: *yes,
+		},
+	)
+	var egress *aws.EgressError
+	if errors.As(err, &egress) {
+		return fmt.Errorf("%w; run again with -yes to copy anyway", err)
+	}
+	if err != nil {
+		return err
+	}
+	switch {
+	case res.Skipped:
+		fmt.Fprintf(r.env.Stderr, "skipped: %s already exists\n", aws.FormatS3URI(dstBucket, dstKey))
+	case res.Key != dstKey:
+		fmt.Fprintf(r.env.Stderr, "renamed: %s already exists, copied to %s\n",
+			aws.FormatS3URI(dstBucket, dstKey), aws.FormatS3URI(dstBucket, res.Key))
+	}
+	return nil
+}
+
+func upload(ctx context.Context, r *runner, u *aws.Uploader, bucket, key string, body io.Reader) error {
+	res, err := u.Upload(ctx, bucket, key, body)
+	if err != nil {
+		return err
+	}
+	switch {
+	case res.Skipped:
+		fmt.Fprintf(r.env.Stderr, "skipped: %s already exists\n", aws.FormatS3URI(bucket, key))
+	case res.Key != key:
+		fmt.Fprintf(r.env.Stderr, "renamed: %s already exists, uploaded to %s\n",
+			aws.FormatS3URI(bucket, key), aws.FormatS3URI(bucket, res.Key))
+	}
+	return nil
+}
+
+// uploadFile uploads a local file, resuming an interrupted earlier upload of
+// it when the client journals uploads, and reports what happened on stderr
+func uploadFile(ctx context.Context, r *runner, u *aws.Uploader, src, bucket, key string) error {
+	res, err := u.UploadFile(ctx, src, bucket, key)
+	if err != nil {
+		if u.Journal != nil && ctx.Err() != nil {
+			fmt.Fprintf(r.env.Stderr, "interrupted: run the same command again to resume\n")
+		}
+		return err
+	}
+	switch {
+	case res.Resumed:
+		fmt.Fprintf(r.env.Stderr, "resumed: finished an interrupted upload to %s\n", aws.FormatS3URI(bucket, res.Key))
+	case res.Skipped:
+		fmt.Fprintf(r.env.Stderr, "skipped: %s already exists\n", aws.FormatS3URI(bucket, key))
+	case res.Key != key:
+		fmt.Fprintf(r.env.Stderr, "renamed: %s already exists, uploaded to %s\n",
+			aws.FormatS3URI(bucket, key), aws.FormatS3URI(bucket, res.Key))
+	}
+	return nil
+}
+
+// uploadDir uploads a local folder under key, reporting each file on stdout
+func uploadDir(ctx context.Context, r *runner, u *aws.Uploader, dir, bucket, key string) error {
+	res, err := u.UploadDir(ctx, dir, bucket, key)
+	for _, k := range res.Uploaded {
+		fmt.Fprintf(r.env.Stdout, "upload: %s\n", aws.FormatS3URI(bucket, k))
+	}
+	for _, k := range res.Unchanged {
+		fmt.Fprintf(r.env.Stderr, "unchanged: %s\n", aws.FormatS3URI(bucket, k))
+	}
+	for _, k := range res.Skipped {
+		fmt.Fprintf(r.env.Stderr, "skipped: %s already exists\n", aws.FormatS3URI(bucket, k))
+	}
+	var partial *aws.PartialFailureError
+	if errors.As(err, &partial) {
+		for _, f := range partial.Failed {
+			fmt.Fprintf(r.env.Stderr, "upload failed: %s: %v\n", aws.FormatS3URI(bucket, f.Key), f.Err)
+		}
+	}
+	return err
+}
+
+// destinationKey appends name to a key that is empty or names a folder
+func destinationKey(key, name string) string {
+	if key == "" || strings.HasSuffix(key, "/") {
+		return key + name
+	}
+	return key
+}
+
+// localDestination resolves a local download target, treating existing
+// directories and paths ending in a separator as folders. The object's name
+// is validated so a crafted key can't escape the folder.
+func localDestination(dst, name string) (string, error) {
+	isDir := strings.HasSuffix(dst, string(os.PathSeparator))
+	if info, err := os.Stat(dst); err == nil && info.IsDir() {
+		isDir = true
+	}
+	if isDir {
+		return security.SafePath(dst, name)
+	}
+	return dst, nil
+}
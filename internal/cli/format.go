@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/natevick/stui/internal/aws"
+	"github.com/natevick/stui/internal/download"
+)
+
+// listTimeFormat is used for timestamps in ls output (always UTC)
+const listTimeFormat = "2006-01-02 15:04:05"
+
+// FormatListing writes objects in the same columns as `aws s3 ls`:
+// folders as "PRE name/", objects as "date time size name"
+func FormatListing(w io.Writer, objects []aws.S3Object) {
+	for _, obj := range objects {
+		if obj.IsPrefix {
+			fmt.Fprintf(w, "%19s %10s %s\n", "", "PRE", obj.DisplayName())
+			continue
+		}
+		fmt.Fprintf(w, "%s %10d %s\n", obj.LastModified.UTC().Format(listTimeFormat), obj.Size, obj.DisplayName())
+	}
+}
+
+// FormatDiff writes one key per line marked "<" (left only), ">" (right only) or "~" (changed)
+func FormatDiff(w io.Writer, result download.DiffResult) {
+	for _, key := range result.OnlyLeft {
+		fmt.Fprintf(w, "< %s\n", key)
+	}
+	for _, key := range result.OnlyRight {
+		fmt.Fprintf(w, "> %s\n", key)
+	}
+	for _, key := range result.Changed {
+		fmt.Fprintf(w, "~ %s\n", key)
+	}
+}
+
+// FormatUploads writes one interrupted upload per line: when it started,
+// how many parts reached S3, the destination and the local file
+func FormatUploads(w io.Writer, records []aws.UploadRecord) {
+	for _, rec := range records {
+		total := (rec.Size + rec.PartSize - 1) / rec.PartSize
+		fmt.Fprintf(w, "%s %4d/%-4d %s %s\n", rec.Started.UTC().Format(listTimeFormat),
+			len(rec.Parts), total, aws.FormatS3URI(rec.Bucket, rec.Key), rec.LocalPath)
+	}
+}
+
+// FormatIncompleteUploads writes one multipart upload per line: when it
+// started, its upload ID and key
+func FormatIncompleteUploads(w io.Writer, uploads []aws.IncompleteUpload) {
+	for _, up := range uploads {
+		fmt.Fprintf(w, "%s %s %s\n", up.Initiated.UTC().Format(listTimeFormat), up.UploadID, up.Key)
+	}
+}
+
+// FormatBuckets writes one bucket per line with its creation date
+func FormatBuckets(w io.Writer, buckets []aws.Bucket) {
+	for _, b := range buckets {
+		fmt.Fprintf(w, "%s %s\n", b.CreationDate.UTC().Format(listTimeFormat), b.Name)
+	}
+}
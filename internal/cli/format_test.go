@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/natevick/stui/internal/aws"
+)
+
+func TestFormatListing(t *testing.T) {
+	modified := time.Date(2024, 3, 5, 14, 7, 9, 0, time.UTC)
+	objects := []aws.S3Object{
+		{Key: "logs/2024/", IsPrefix: true},
+		{Key: "logs/app.log", Size: 1234, LastModified: modified},
+		{Key: "logs/empty", Size: 0, LastModified: modified},
+	}
+
+	var buf bytes.Buffer
+	FormatListing(&buf, objects)
+
+	want := "" +
+		"                           PRE 2024/\n" +
+		"2024-03-05 14:07:09       1234 app.log\n" +
+		"2024-03-05 14:07:09          0 empty\n"
+	if buf.String() != want {
+		t.Errorf("FormatListing() =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestFormatUploads(t *testing.T) {
+	records := []aws.UploadRecord{{
+		Bucket:    "b",
+		Key:       "backups/db.tar",
+		LocalPath: "/data/db.tar",
+		Size:      25,
+		PartSize:  10,
+		Parts:     map[int32]string{1: "etag"},
+		Started:   time.Date(2024, 3, 5, 14, 7, 9, 0, time.UTC),
+	}}
+
+	var buf bytes.Buffer
+	FormatUploads(&buf, records)
+
+	want := "2024-03-05 14:07:09    1/3    s3://b/backups/db.tar /data/db.tar\n"
+	if buf.String() != want {
+		t.Errorf("FormatUploads() = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestRunUsageErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"no command", nil, ExitUsage},
+		{"unknown command", []string{"sync"}, ExitUsage},
+		{"cp needs two args", []string{"cp", "s3://b/k"}, ExitUsage},
+		{"rm prefix without -r", []string{"rm", "s3://bucket/logs/"}, ExitUsage},
+		{"cp unknown policy", []string{"cp", "-if-exists", "clobber", "a.txt", "s3://bucket/a.txt"}, ExitUsage},
+		{"s3 copy cannot rename", []string{"cp", "-if-exists", "rename", "s3://bucket/a", "s3://bucket/b"}, ExitUsage},
+		{"invalid bucket", []string{"ls", "s3://Bad_Bucket"}, ExitUsage},
+		{"diff needs two locations", []string{"diff", "./site"}, ExitUsage},
+		{"uploads abort needs a bucket", []string{"uploads", "-abort", "s3://bucket/key"}, ExitUsage},
+		{"uploads age needs a bucket", []string{"uploads", "-abort-older-than", "24h"}, ExitUsage},
+		{"empty-trash takes a bucket", []string{"empty-trash", "s3://bucket/.s3-tui-trash/"}, ExitUsage},
+		{"empty-trash needs a retention", []string{"empty-trash", "s3://bucket"}, ExitUsage},
+		{"xcp needs a destination profile", []string{"xcp", "s3://dev/a.txt", "s3://prod/a.txt"}, ExitUsage},
+		{"xcp takes s3 URIs", []string{"xcp", "-to", "prod", "a.txt", "s3://prod/a.txt"}, ExitUsage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			env := Env{Stdout: &stdout, Stderr: &stderr}
+			newClient := func(ctx context.Context, profile string) (*aws.Client, error) {
+				return &aws.Client{}, nil
+			}
+			if got := Run(context.Background(), tt.args, env, newClient); got != tt.want {
+				t.Errorf("Run(%v) = %d, want %d (stderr: %s)", tt.args, got, tt.want, stderr.String())
+			}
+			if stderr.Len() == 0 {
+				t.Error("expected a message on stderr")
+			}
+		})
+	}
+}
@@ -0,0 +1,363 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/natevick/stui/internal/aws"
+	"github.com/natevick/stui/internal/security"
+)
+
+// Retry modes supported by the AWS SDK retryer
+const (
+	RetryModeStandard = "standard"
+	RetryModeAdaptive = "adaptive"
+)
+
+// Key display modes for the object list
+const (
+	KeyDisplayBasename = "basename"
+	KeyDisplayRelative = "relative"
+	KeyDisplayFull     = "full"
+)
+
+// Limits for configurable values
+const (
+	MinMaxAttempts = 1
+	MaxMaxAttempts = 10
+	MinPageSize    = 1
+	MaxPageSize    = 1000
+)
+
+// Default size guards; zero disables a guard
+const (
+	DefaultMaxPreviewBytes      = 1 << 20 // 1 MiB
+	DefaultMaxAutoDownloadBytes = 1 << 30 // 1 GiB
+	DefaultVerifyMaxBytes       = 5 << 30 // 5 GiB
+	DefaultEgressWarnBytes      = 1 << 30 // 1 GiB
+)
+
+// DefaultMaxRecursiveObjects caps recursive listings; zero disables the cap
+const DefaultMaxRecursiveObjects = 100_000
+
+// Config holds user settings persisted at ~/.config/stui/config.json
+type Config struct {
+	// RetryMode selects the SDK retry strategy ("standard" or "adaptive")
+	RetryMode string `json:"retry_mode"`
+	// MaxAttempts is the maximum number of attempts per AWS request, including the first
+	MaxAttempts int `json:"max_attempts"`
+	// PageSize is the number of keys requested per listing page (clamped to
+	// 1-1000; 0 means the default, 1000)
+	PageSize int `json:"page_size"`
+	// ShowHidden shows keys whose name starts with "." in listings
+	ShowHidden bool `json:"show_hidden"`
+	// KeyDisplay is how much of each key the object list shows ("basename", "relative", or "full")
+	KeyDisplay string `json:"key_display"`
+	// Redaction is how much error messages hide ("minimal", "standard", or "paranoid")
+	Redaction string `json:"redaction"`
+	// MaxPreviewBytes is the largest object previewed without confirmation
+	MaxPreviewBytes int64 `json:"max_preview_bytes"`
+	// MaxAutoDownloadBytes is the largest download started without confirmation
+	MaxAutoDownloadBytes int64 `json:"max_auto_download_bytes"`
+	// VerifyDownloads checks downloaded files against the object's ETag
+	VerifyDownloads bool `json:"verify_downloads"`
+	// VerifyMaxBytes skips verification for larger objects; zero verifies everything
+	VerifyMaxBytes int64 `json:"verify_max_bytes"`
+	// RemoveCorruptDownloads deletes files that fail verification
+	RemoveCorruptDownloads bool `json:"remove_corrupt_downloads"`
+	// AutoRefreshSeconds re-lists the current prefix this often; zero disables
+	AutoRefreshSeconds int `json:"auto_refresh_seconds"`
+	// WarmBookmarkCache pre-lists bookmarked locations at startup so they open instantly
+	WarmBookmarkCache bool `json:"warm_bookmark_cache"`
+	// TransferConcurrency fixes the number of parallel downloads; zero ramps automatically
+	TransferConcurrency int `json:"transfer_concurrency"`
+	// SessionBudgetBytes pauses downloads after this many bytes until resumed; zero disables
+	SessionBudgetBytes int64 `json:"session_budget_bytes"`
+	// SessionBudgetTransfers pauses downloads after this many files until resumed; zero disables
+	SessionBudgetTransfers int64 `json:"session_budget_transfers"`
+	// TempDir holds in-progress downloads; empty uses the system temp directory
+	TempDir string `json:"temp_dir,omitempty"`
+	// PartMaxAgeHours is how old a leftover part file must be before startup removes it; zero keeps them
+	PartMaxAgeHours int `json:"part_max_age_hours"`
+	// TrashRetentionHours is how old soft-deleted objects must be for stui empty-trash to delete them; zero keeps them
+	TrashRetentionHours int `json:"trash_retention_hours"`
+	// HomeRegion is where stui runs (e.g. the EC2 instance's region); empty disables egress warnings
+	HomeRegion string `json:"home_region,omitempty"`
+	// EgressWarnBytes is the smallest cross-region download that asks for confirmation
+	EgressWarnBytes int64 `json:"egress_warn_bytes"`
+	// PingTimeoutSeconds bounds the endpoint check run at startup; zero skips the check
+	PingTimeoutSeconds int `json:"ping_timeout_seconds"`
+	// MaxRecursiveObjects stops prefix downloads, syncs, and recursive deletes that list more objects; zero disables
+	MaxRecursiveObjects int `json:"max_recursive_objects"`
+	// MaxConcurrentLists caps listing requests in flight across the whole app; zero disables
+	MaxConcurrentLists int `json:"max_concurrent_lists"`
+	// ReadOnly disables every operation that changes S3; --read-only sets it for one run
+	ReadOnly bool `json:"read_only"`
+	// ProtectedBuckets are bucket names or glob patterns (e.g. "prod-*") that stui never modifies
+	ProtectedBuckets []string `json:"protected_buckets,omitempty"`
+	// PerBucketStorageClass is the storage class uploads to a bucket get by
+	// default (e.g. {"my-archive": "GLACIER_IR"}); other buckets use STANDARD
+	PerBucketStorageClass map[string]types.StorageClass `json:"per_bucket_storage_class,omitempty"`
+	// UploadKeyTemplate keys folder uploads, e.g. "{date}/{basename}"; empty keeps each file's relative path
+	UploadKeyTemplate string `json:"upload_key_template,omitempty"`
+	// FallbackProfiles are tried in order when the current profile is denied a read; writes never fall back
+	FallbackProfiles []string `json:"fallback_profiles,omitempty"`
+	// AllowedLocalRoots confine downloads, syncs, and exports to these absolute directories; empty allows anywhere
+	AllowedLocalRoots []string `json:"allowed_local_roots,omitempty"`
+
+	path string
+}
+
+// Default returns the default configuration
+func Default() Config {
+	return Config{
+		RetryMode:   RetryModeStandard,
+		MaxAttempts: 3,
+		PageSize:    MaxPageSize,
+		KeyDisplay:  KeyDisplayBasename,
+		Redaction:   security.Standard.String(),
+
+		MaxPreviewBytes:      DefaultMaxPreviewBytes,
+		MaxAutoDownloadBytes: DefaultMaxAutoDownloadBytes,
+		EgressWarnBytes:      DefaultEgressWarnBytes,
+
+		VerifyDownloads:        true,
+		VerifyMaxBytes:         DefaultVerifyMaxBytes,
+		RemoveCorruptDownloads: true,
+
+		MaxRecursiveObjects: DefaultMaxRecursiveObjects,
+		MaxConcurrentLists:  aws.DefaultMaxConcurrentLists,
+
+		PartMaxAgeHours:    int(aws.DefaultPartMaxAge / time.Hour),
+		PingTimeoutSeconds: int(aws.DefaultPingTimeout / time.Second),
+	}
+}
+
+// Dir returns the config directory path, creating it if needed
+func Dir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	configDir := filepath.Join(homeDir, ".config", "stui")
+	if err := os.MkdirAll(configDir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	return configDir, nil
+}
+
+// Load reads the config from the default location.
+// A missing file yields the default configuration.
+func Load() (Config, error) {
+	configDir, err := Dir()
+	if err != nil {
+		return Config{}, err
+	}
+	return LoadFrom(filepath.Join(configDir, "config.json"))
+}
+
+// LoadFrom reads the config from path, filling unset values with defaults
+func LoadFrom(path string) (Config, error) {
+	cfg := Default()
+	cfg.path = path
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return Config{}, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	// Clamped the same way the lister does, so 0 means the default here too
+	cfg.PageSize = aws.ClampPageSize(cfg.PageSize)
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// Save writes the config back to the file it was loaded from
+func (c Config) Save() error {
+	if c.path == "" {
+		return fmt.Errorf("config has no file path")
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	if err := os.WriteFile(c.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+
+	return nil
+}
+
+// Validate checks that all values are within their allowed ranges
+func (c Config) Validate() error {
+	switch c.RetryMode {
+	case RetryModeStandard, RetryModeAdaptive:
+	default:
+		return fmt.Errorf("retry_mode must be %q or %q", RetryModeStandard, RetryModeAdaptive)
+	}
+
+	if c.MaxAttempts < MinMaxAttempts || c.MaxAttempts > MaxMaxAttempts {
+		return fmt.Errorf("max_attempts must be between %d and %d", MinMaxAttempts, MaxMaxAttempts)
+	}
+
+	switch c.KeyDisplay {
+	case KeyDisplayBasename, KeyDisplayRelative, KeyDisplayFull:
+	default:
+		return fmt.Errorf("key_display must be %q, %q, or %q", KeyDisplayBasename, KeyDisplayRelative, KeyDisplayFull)
+	}
+
+	if _, err := security.ParseStrictness(c.Redaction); err != nil {
+		return fmt.Errorf("redaction must be %q, %q, or %q", security.Minimal, security.Standard, security.Paranoid)
+	}
+
+	if c.AutoRefreshSeconds < 0 {
+		return fmt.Errorf("auto_refresh_seconds must not be negative")
+	}
+
+	if c.TransferConcurrency < 0 {
+		return fmt.Errorf("transfer_concurrency must not be negative")
+	}
+
+	if c.SessionBudgetBytes < 0 || c.SessionBudgetTransfers < 0 {
+		return fmt.Errorf("session budgets must not be negative")
+	}
+
+	if c.MaxRecursiveObjects < 0 {
+		return fmt.Errorf("max_recursive_objects must not be negative")
+	}
+
+	if c.MaxConcurrentLists < 0 {
+		return fmt.Errorf("max_concurrent_lists must not be negative")
+	}
+
+	if c.PartMaxAgeHours < 0 {
+		return fmt.Errorf("part_max_age_hours must not be negative")
+	}
+
+	if c.TrashRetentionHours < 0 {
+		return fmt.Errorf("trash_retention_hours must not be negative")
+	}
+
+	if c.HomeRegion != "" && !regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-\d+$`).MatchString(c.HomeRegion) {
+		return fmt.Errorf("home_region %q is not a region name like us-east-1", c.HomeRegion)
+	}
+
+	if c.PingTimeoutSeconds < 0 {
+		return fmt.Errorf("ping_timeout_seconds must not be negative")
+	}
+
+	if c.TempDir != "" {
+		if !filepath.IsAbs(c.TempDir) {
+			return fmt.Errorf("temp_dir must be an absolute path")
+		}
+		if _, err := security.SafePath(c.TempDir, "."); err != nil {
+			return fmt.Errorf("temp_dir: %w", err)
+		}
+	}
+
+	if c.MaxPreviewBytes < 0 || c.MaxAutoDownloadBytes < 0 || c.VerifyMaxBytes < 0 || c.EgressWarnBytes < 0 {
+		return fmt.Errorf("size limits must not be negative")
+	}
+
+	for _, root := range c.AllowedLocalRoots {
+		if !filepath.IsAbs(root) {
+			return fmt.Errorf("allowed_local_roots: %q must be an absolute path", root)
+		}
+		if _, err := security.SafePath(root, "."); err != nil {
+			return fmt.Errorf("allowed_local_roots: %w", err)
+		}
+	}
+
+	for bucket, class := range c.PerBucketStorageClass {
+		if err := aws.ValidStorageClass(class); err != nil {
+			return fmt.Errorf("per_bucket_storage_class: %s: %w", bucket, err)
+		}
+	}
+
+	if c.UploadKeyTemplate != "" {
+		if _, err := aws.ParseKeyTemplate(c.UploadKeyTemplate); err != nil {
+			return fmt.Errorf("upload_key_template: %w", err)
+		}
+	}
+
+	for _, profile := range c.FallbackProfiles {
+		if profile == "" {
+			return fmt.Errorf("fallback_profiles: profile names cannot be empty")
+		}
+		if err := security.ValidProfileName(profile); err != nil {
+			return fmt.Errorf("fallback_profiles: %q: %w", profile, err)
+		}
+	}
+
+	for _, pattern := range c.ProtectedBuckets {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("protected_buckets: invalid pattern %q", pattern)
+		}
+	}
+
+	return nil
+}
+
+// ClientOptions maps the settings onto AWS client options
+func (c Config) ClientOptions() aws.ClientOptions {
+	return aws.ClientOptions{
+		RetryMode:        awssdk.RetryMode(c.RetryMode),
+		MaxAttempts:      c.MaxAttempts,
+		PageSize:         c.PageSize,
+		ProtectedBuckets: c.ProtectedBuckets,
+
+		BucketStorageClasses: c.PerBucketStorageClass,
+		UploadKeyTemplate:    c.UploadKeyTemplate,
+		FallbackProfiles:     c.FallbackProfiles,
+		TrashRetention:       c.TrashRetention(),
+		EgressWarnBytes:      c.EgressWarnBytes,
+
+		MaxRecursiveObjects: c.MaxRecursiveObjects,
+		MaxConcurrentLists:  c.MaxConcurrentLists,
+		ReadOnly:            c.ReadOnly,
+
+		VerifyDownloads:        c.VerifyDownloads,
+		VerifyMaxBytes:         c.VerifyMaxBytes,
+		RemoveCorruptDownloads: c.RemoveCorruptDownloads,
+		DownloadTempDir:        c.TempDir,
+		PingTimeout:            time.Duration(c.PingTimeoutSeconds) * time.Second,
+	}
+}
+
+// PartMaxAge returns how old a leftover part file must be to be swept at startup
+func (c Config) PartMaxAge() time.Duration {
+	return time.Duration(c.PartMaxAgeHours) * time.Hour
+}
+
+// Strictness returns the redaction level for error messages
+func (c Config) Strictness() security.Strictness {
+	level, _ := security.ParseStrictness(c.Redaction)
+	return level
+}
+
+// TrashRetention returns how long soft-deleted objects are kept before the trash is emptied
+func (c Config) TrashRetention() time.Duration {
+	return time.Duration(c.TrashRetentionHours) * time.Hour
+}
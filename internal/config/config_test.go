@@ -0,0 +1,164 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func TestLoadMissingFileUsesDefaults(t *testing.T) {
+	cfg, err := LoadFrom(filepath.Join(t.TempDir(), "config.json"))
+	if err != nil {
+		t.Fatalf("LoadFrom() error = %v", err)
+	}
+
+	def := Default()
+	if cfg.RetryMode != def.RetryMode || cfg.MaxAttempts != def.MaxAttempts {
+		t.Errorf("expected defaults, got %+v", cfg)
+	}
+}
+
+func TestLoadAndSaveRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"retry_mode":"adaptive","max_attempts":7}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadFrom(path)
+	if err != nil {
+		t.Fatalf("LoadFrom() error = %v", err)
+	}
+	if cfg.RetryMode != RetryModeAdaptive || cfg.MaxAttempts != 7 {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+
+	cfg.MaxAttempts = 4
+	cfg.ShowHidden = true
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	reloaded, err := LoadFrom(path)
+	if err != nil {
+		t.Fatalf("LoadFrom() error = %v", err)
+	}
+	if reloaded.MaxAttempts != 4 {
+		t.Errorf("expected saved max_attempts 4, got %d", reloaded.MaxAttempts)
+	}
+	if !reloaded.ShowHidden {
+		t.Error("expected saved show_hidden to persist")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(*Config)
+		wantErr bool
+	}{
+		{"defaults", func(c *Config) {}, false},
+		{"adaptive", func(c *Config) { c.RetryMode = RetryModeAdaptive }, false},
+		{"unknown retry mode", func(c *Config) { c.RetryMode = "aggressive" }, true},
+		{"full key display", func(c *Config) { c.KeyDisplay = KeyDisplayFull }, false},
+		{"unknown key display", func(c *Config) { c.KeyDisplay = "short" }, true},
+		{"paranoid redaction", func(c *Config) { c.Redaction = "paranoid" }, false},
+		{"unknown redaction", func(c *Config) { c.Redaction = "total" }, true},
+		{"max attempts lower bound", func(c *Config) { c.MaxAttempts = 1 }, false},
+		{"max attempts upper bound", func(c *Config) { c.MaxAttempts = 10 }, false},
+		{"max attempts zero", func(c *Config) { c.MaxAttempts = 0 }, true},
+		{"max attempts too high", func(c *Config) { c.MaxAttempts = 11 }, true},
+		{"preview limit disabled", func(c *Config) { c.MaxPreviewBytes = 0 }, false},
+		{"negative download limit", func(c *Config) { c.MaxAutoDownloadBytes = -1 }, true},
+		{"negative transfer concurrency", func(c *Config) { c.TransferConcurrency = -1 }, true},
+		{"custom temp dir", func(c *Config) { c.TempDir = "/var/tmp/stui" }, false},
+		{"relative temp dir", func(c *Config) { c.TempDir = "tmp" }, true},
+		{"temp dir in system directory", func(c *Config) { c.TempDir = "/etc/stui" }, true},
+		{"allowed local roots", func(c *Config) { c.AllowedLocalRoots = []string{"/home/me/Downloads", "/srv/s3-data"} }, false},
+		{"relative allowed local root", func(c *Config) { c.AllowedLocalRoots = []string{"Downloads"} }, true},
+		{"negative max concurrent lists", func(c *Config) { c.MaxConcurrentLists = -1 }, true},
+		{"recursive limit disabled", func(c *Config) { c.MaxRecursiveObjects = 0 }, false},
+		{"negative recursive limit", func(c *Config) { c.MaxRecursiveObjects = -1 }, true},
+		{"negative part age", func(c *Config) { c.PartMaxAgeHours = -1 }, true},
+		{"trash retention", func(c *Config) { c.TrashRetentionHours = 720 }, false},
+		{"negative trash retention", func(c *Config) { c.TrashRetentionHours = -1 }, true},
+		{"home region", func(c *Config) { c.HomeRegion = "eu-central-1" }, false},
+		{"gov cloud home region", func(c *Config) { c.HomeRegion = "us-gov-west-1" }, false},
+		{"invalid home region", func(c *Config) { c.HomeRegion = "US East" }, true},
+		{"negative egress threshold", func(c *Config) { c.EgressWarnBytes = -1 }, true},
+		{"negative ping timeout", func(c *Config) { c.PingTimeoutSeconds = -1 }, true},
+		{"ping disabled", func(c *Config) { c.PingTimeoutSeconds = 0 }, false},
+		{"protected glob", func(c *Config) { c.ProtectedBuckets = []string{"prod-*"} }, false},
+		{"protected bad pattern", func(c *Config) { c.ProtectedBuckets = []string{"prod-[a"} }, true},
+		{"bucket storage class", func(c *Config) { c.PerBucketStorageClass = map[string]types.StorageClass{"archive": "GLACIER_IR"} }, false},
+		{"unknown bucket storage class", func(c *Config) { c.PerBucketStorageClass = map[string]types.StorageClass{"archive": "FROZEN"} }, true},
+		{"upload key template", func(c *Config) { c.UploadKeyTemplate = "{date}/{basename}" }, false},
+		{"unknown key template placeholder", func(c *Config) { c.UploadKeyTemplate = "{year}/{basename}" }, true},
+		{"fallback profiles", func(c *Config) { c.FallbackProfiles = []string{"dev", "prod-read"} }, false},
+		{"invalid fallback profile", func(c *Config) { c.FallbackProfiles = []string{"prod read"} }, true},
+		{"negative session budget", func(c *Config) { c.SessionBudgetBytes = -1 }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			tt.mutate(&cfg)
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsOutOfRange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"max_attempts":25}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadFrom(path); err == nil {
+		t.Error("expected error for out-of-range max_attempts")
+	}
+}
+
+func TestLoadRejectsUnknownStorageClass(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"per_bucket_storage_class":{"archive":"GLACIER-IR"}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadFrom(path)
+	if err == nil || !strings.Contains(err.Error(), "archive") {
+		t.Errorf("LoadFrom() error = %v, want one naming the bucket", err)
+	}
+}
+
+func TestLoadClampsPageSize(t *testing.T) {
+	tests := []struct {
+		json string
+		want int
+	}{
+		{`{}`, 1000},
+		{`{"page_size":250}`, 250},
+		{`{"page_size":5000}`, 1000},
+		{`{"page_size":0}`, 1000},
+		{`{"page_size":-3}`, 1},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "config.json")
+		if err := os.WriteFile(path, []byte(tt.json), 0600); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := LoadFrom(path)
+		if err != nil {
+			t.Fatalf("LoadFrom(%s) error = %v", tt.json, err)
+		}
+		if cfg.PageSize != tt.want {
+			t.Errorf("LoadFrom(%s) page size = %d, want %d", tt.json, cfg.PageSize, tt.want)
+		}
+	}
+}
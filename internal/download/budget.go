@@ -0,0 +1,67 @@
+package download
+
+import "sync"
+
+// BudgetUsage is what a session has spent against its budget
+type BudgetUsage struct {
+	Bytes        int64
+	Transfers    int64
+	MaxBytes     int64
+	MaxTransfers int64
+}
+
+// Exceeded reports whether either limit has been reached
+func (u BudgetUsage) Exceeded() bool {
+	return (u.MaxBytes > 0 && u.Bytes >= u.MaxBytes) ||
+		(u.MaxTransfers > 0 && u.Transfers >= u.MaxTransfers)
+}
+
+// Budget caps the bytes moved and the transfers (files) finished in a
+// session, for metered connections. A TransferQueue given a Budget pauses
+// once a limit is reached and waits for Resume. It is a soft cap: bytes are
+// charged as they arrive, but transfers already running finish, so the
+// byte count can pass its limit by what they still had to move. A zero
+// limit is unlimited.
+type Budget struct {
+	mu    sync.Mutex
+	usage BudgetUsage
+}
+
+// NewBudget creates a budget with the given limits
+func NewBudget(maxBytes, maxTransfers int64) *Budget {
+	return &Budget{usage: BudgetUsage{MaxBytes: maxBytes, MaxTransfers: maxTransfers}}
+}
+
+// AddBytes records n more bytes moved
+func (b *Budget) AddBytes(n int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.usage.Bytes += n
+}
+
+// AddTransfer records one finished transfer
+func (b *Budget) AddTransfer() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.usage.Transfers++
+}
+
+// Usage returns the amount spent so far and the limits
+func (b *Budget) Usage() BudgetUsage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.usage
+}
+
+// Exceeded reports whether either limit has been reached
+func (b *Budget) Exceeded() bool {
+	return b.Usage().Exceeded()
+}
+
+// Reset starts a fresh allowance with the same limits
+func (b *Budget) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.usage.Bytes = 0
+	b.usage.Transfers = 0
+}
@@ -0,0 +1,270 @@
+package download
+
+import (
+	"cmp"
+	"context"
+	"io/fs"
+	"iter"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/natevick/stui/internal/aws"
+	"github.com/natevick/stui/internal/security"
+)
+
+// Entry is a file or object in a Location, keyed relative to its root
+type Entry struct {
+	Key  string
+	Size int64
+	// ETag is the object's ETag; empty for local files until it is needed
+	ETag string
+	// localPath is set for local files so their MD5 can be computed on demand
+	localPath string
+}
+
+// Location is one side of a diff. Entries must be yielded in ascending byte
+// order of Key, which is the order S3 lists keys in.
+type Location interface {
+	Entries(ctx context.Context) iter.Seq2[Entry, error]
+}
+
+// S3Location lists the objects under a prefix
+type S3Location struct {
+	Client *aws.Client
+	Bucket string
+	Prefix string
+}
+
+// Entries streams the prefix one listing page at a time. Directory buckets
+// don't list in key order, so their listing is read in full and sorted.
+func (l S3Location) Entries(ctx context.Context) iter.Seq2[Entry, error] {
+	if security.IsDirectoryBucket(l.Bucket) {
+		return l.sortedEntries(ctx)
+	}
+	return func(yield func(Entry, error) bool) {
+		lister := l.Client.NewLister(l.Bucket, l.Prefix, "")
+		for lister.HasMorePages() {
+			page, err := lister.NextPage(ctx)
+			if err != nil {
+				yield(Entry{}, err)
+				return
+			}
+			for _, obj := range page {
+				if obj.IsPrefix || strings.HasSuffix(obj.Key, "/") {
+					continue
+				}
+				e := Entry{Key: strings.TrimPrefix(obj.Key, l.Prefix), Size: obj.Size, ETag: obj.ETag}
+				if !yield(e, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// sortedEntries lists the whole prefix before yielding it in key order
+func (l S3Location) sortedEntries(ctx context.Context) iter.Seq2[Entry, error] {
+	return func(yield func(Entry, error) bool) {
+		objects, err := l.Client.ListAllObjects(ctx, l.Bucket, l.Prefix)
+		if err != nil {
+			yield(Entry{}, err)
+			return
+		}
+		slices.SortFunc(objects, func(a, b aws.S3Object) int {
+			return cmp.Compare(a.Key, b.Key)
+		})
+		for _, obj := range objects {
+			if obj.IsPrefix || strings.HasSuffix(obj.Key, "/") {
+				continue
+			}
+			if !yield(Entry{Key: strings.TrimPrefix(obj.Key, l.Prefix), Size: obj.Size, ETag: obj.ETag}, nil) {
+				return
+			}
+		}
+	}
+}
+
+// LocalLocation lists the files under a local directory
+type LocalLocation struct {
+	Dir string
+}
+
+// Entries walks the directory, reading one directory at a time. A missing
+// directory has no entries.
+func (l LocalLocation) Entries(ctx context.Context) iter.Seq2[Entry, error] {
+	return func(yield func(Entry, error) bool) {
+		if _, err := os.Stat(l.Dir); os.IsNotExist(err) {
+			return
+		}
+		walkSorted(ctx, l.Dir, "", yield)
+	}
+}
+
+// walkSorted yields the files under dir in S3 key order. Sorting each
+// directory's children with a trailing "/" on subdirectories makes the
+// depth-first walk match byte order of the full relative paths.
+func walkSorted(ctx context.Context, dir, rel string, yield func(Entry, error) bool) bool {
+	if err := ctx.Err(); err != nil {
+		yield(Entry{}, err)
+		return false
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		yield(Entry{}, err)
+		return false
+	}
+
+	sortKey := func(e fs.DirEntry) string {
+		if e.IsDir() {
+			return e.Name() + "/"
+		}
+		return e.Name()
+	}
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int {
+		return cmp.Compare(sortKey(a), sortKey(b))
+	})
+
+	for _, e := range entries {
+		full := filepath.Join(dir, e.Name())
+		key := path.Join(rel, e.Name())
+		if e.IsDir() {
+			if !walkSorted(ctx, full, key, yield) {
+				return false
+			}
+			continue
+		}
+		if !e.Type().IsRegular() {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			yield(Entry{}, err)
+			return false
+		}
+		if !yield(Entry{Key: key, Size: info.Size(), localPath: full}, nil) {
+			return false
+		}
+	}
+	return true
+}
+
+// DiffResult lists the keys that differ between two locations
+type DiffResult struct {
+	OnlyLeft  []string
+	OnlyRight []string
+	// Changed keys exist on both sides with a different size or content hash
+	Changed []string
+}
+
+// Empty reports whether the locations match
+func (r DiffResult) Empty() bool {
+	return len(r.OnlyLeft) == 0 && len(r.OnlyRight) == 0 && len(r.Changed) == 0
+}
+
+// Diff compares two locations by merging their sorted listings, so neither
+// side is held in memory. Files of equal size are compared by MD5 only when
+// both sides have a comparable hash; multipart ETags are compared only with
+// other multipart ETags.
+func Diff(ctx context.Context, left, right Location) (DiffResult, error) {
+	var result DiffResult
+
+	nextLeft, stopLeft := iter.Pull2(left.Entries(ctx))
+	defer stopLeft()
+	nextRight, stopRight := iter.Pull2(right.Entries(ctx))
+	defer stopRight()
+
+	pull := func(next func() (Entry, error, bool)) (*Entry, error) {
+		e, err, ok := next()
+		if !ok {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &e, nil
+	}
+
+	l, err := pull(nextLeft)
+	if err != nil {
+		return result, err
+	}
+	r, err := pull(nextRight)
+	if err != nil {
+		return result, err
+	}
+
+	for l != nil || r != nil {
+		switch {
+		case r == nil || (l != nil && l.Key < r.Key):
+			result.OnlyLeft = append(result.OnlyLeft, l.Key)
+			if l, err = pull(nextLeft); err != nil {
+				return result, err
+			}
+		case l == nil || r.Key < l.Key:
+			result.OnlyRight = append(result.OnlyRight, r.Key)
+			if r, err = pull(nextRight); err != nil {
+				return result, err
+			}
+		default:
+			changed, err := entriesDiffer(*l, *r)
+			if err != nil {
+				return result, err
+			}
+			if changed {
+				result.Changed = append(result.Changed, l.Key)
+			}
+			if l, err = pull(nextLeft); err != nil {
+				return result, err
+			}
+			if r, err = pull(nextRight); err != nil {
+				return result, err
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// entriesDiffer compares two entries with the same key
+func entriesDiffer(a, b Entry) (bool, error) {
+	if a.Size != b.Size {
+		return true, nil
+	}
+
+	// Local files are hashed only when the other side has a plain MD5 ETag
+	var err error
+	if a.ETag, err = localETag(a, b); err != nil {
+		return false, err
+	}
+	if b.ETag, err = localETag(b, a); err != nil {
+		return false, err
+	}
+
+	if a.ETag == "" || b.ETag == "" {
+		return false, nil
+	}
+	if isMultipartETag(a.ETag) != isMultipartETag(b.ETag) {
+		return false, nil
+	}
+	return a.ETag != b.ETag, nil
+}
+
+// localETag returns e's ETag, computing a local file's MD5 if other can be compared with it
+func localETag(e, other Entry) (string, error) {
+	if e.ETag != "" || e.localPath == "" {
+		return e.ETag, nil
+	}
+	if other.localPath == "" && (other.ETag == "" || isMultipartETag(other.ETag)) {
+		return "", nil
+	}
+	return computeFileMD5(e.localPath)
+}
+
+// isMultipartETag reports whether an ETag came from a multipart upload rather than an MD5
+func isMultipartETag(etag string) bool {
+	return strings.Contains(etag, "-")
+}
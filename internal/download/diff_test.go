@@ -0,0 +1,109 @@
+package download
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"iter"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// sliceLocation is a Location backed by sorted entries
+type sliceLocation []Entry
+
+func (s sliceLocation) Entries(ctx context.Context) iter.Seq2[Entry, error] {
+	return func(yield func(Entry, error) bool) {
+		for _, e := range s {
+			if !yield(e, nil) {
+				return
+			}
+		}
+	}
+}
+
+func md5Hex(data string) string {
+	sum := md5.Sum([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestDiff(t *testing.T) {
+	left := sliceLocation{
+		{Key: "a.txt", Size: 5, ETag: md5Hex("hello")},
+		{Key: "b.txt", Size: 5, ETag: md5Hex("hello")},
+		{Key: "c/big.bin", Size: 100, ETag: "aaaa-2"},
+		{Key: "d.txt", Size: 3, ETag: md5Hex("abc")},
+		{Key: "only-left.txt", Size: 1},
+	}
+	right := sliceLocation{
+		{Key: "a.txt", Size: 5, ETag: md5Hex("hello")},
+		{Key: "b.txt", Size: 5, ETag: md5Hex("world")},   // same size, new content
+		{Key: "c/big.bin", Size: 100, ETag: md5Hex("x")}, // multipart vs MD5: not comparable
+		{Key: "d.txt", Size: 4, ETag: md5Hex("abcd")},    // size changed
+		{Key: "e/only-right.txt", Size: 1},
+	}
+
+	got, err := Diff(context.Background(), left, right)
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+	want := DiffResult{
+		OnlyLeft:  []string{"only-left.txt"},
+		OnlyRight: []string{"e/only-right.txt"},
+		Changed:   []string{"b.txt", "d.txt"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDiffLocalAgainstS3(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a-b.txt":      "same",
+		"a/nested.txt": "local edit",
+		"local.txt":    "x",
+	}
+	for name, body := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// "a-b.txt" sorts before "a/nested.txt" in S3 key order
+	remote := sliceLocation{
+		{Key: "a-b.txt", Size: 4, ETag: md5Hex("same")},
+		{Key: "a/nested.txt", Size: 10, ETag: md5Hex("remote old")},
+		{Key: "remote.txt", Size: 1, ETag: md5Hex("y")},
+	}
+
+	got, err := Diff(context.Background(), LocalLocation{Dir: dir}, remote)
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+	want := DiffResult{
+		OnlyLeft:  []string{"local.txt"},
+		OnlyRight: []string{"remote.txt"},
+		Changed:   []string{"a/nested.txt"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDiffMissingLocalDir(t *testing.T) {
+	remote := sliceLocation{{Key: "a.txt", Size: 1}}
+	got, err := Diff(context.Background(), LocalLocation{Dir: filepath.Join(t.TempDir(), "missing")}, remote)
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+	if !reflect.DeepEqual(got.OnlyRight, []string{"a.txt"}) || got.Empty() {
+		t.Errorf("Diff() = %+v, want a.txt only on the right", got)
+	}
+}
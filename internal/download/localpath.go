@@ -0,0 +1,46 @@
+package download
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/natevick/stui/internal/security"
+)
+
+// maxUniqueAttempts bounds the numbered names UniqueLocalPath tries
+const maxUniqueAttempts = 1000
+
+// UniqueLocalPath returns a path for desiredName inside dir that doesn't exist
+// yet. When the name is taken, " (1)", " (2)", ... is inserted before the
+// extension until a free name is found. Every candidate is checked with SafePath.
+func UniqueLocalPath(dir, desiredName string) (string, error) {
+	for n := 0; n <= maxUniqueAttempts; n++ {
+		name := desiredName
+		if n > 0 {
+			name = numberedName(desiredName, n)
+		}
+
+		candidate, err := security.SafePath(dir, name)
+		if err != nil {
+			return "", err
+		}
+		if _, err := os.Lstat(candidate); os.IsNotExist(err) {
+			return candidate, nil
+		} else if err != nil {
+			return "", fmt.Errorf("failed to check local file: %w", err)
+		}
+	}
+	return "", fmt.Errorf("no free name for %s after %d attempts", desiredName, maxUniqueAttempts)
+}
+
+// numberedName inserts " (n)" before the extension: "report.csv" becomes
+// "report (1).csv". Dotfiles like ".env" have no extension.
+func numberedName(name string, n int) string {
+	ext := filepath.Ext(name)
+	if ext == name {
+		ext = ""
+	}
+	return fmt.Sprintf("%s (%d)%s", strings.TrimSuffix(name, ext), n, ext)
+}
@@ -0,0 +1,47 @@
+package download
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUniqueLocalPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing []string
+		desired  string
+		want     string
+	}{
+		{"no collision", nil, "report.csv", "report.csv"},
+		{"single collision", []string{"report.csv"}, "report.csv", "report (1).csv"},
+		{"multiple collisions", []string{"report.csv", "report (1).csv", "report (2).csv"}, "report.csv", "report (3).csv"},
+		{"no extension", []string{"Makefile"}, "Makefile", "Makefile (1)"},
+		{"dotfile", []string{".env"}, ".env", ".env (1)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, name := range tt.existing {
+				if err := os.WriteFile(filepath.Join(dir, name), nil, 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := UniqueLocalPath(dir, tt.desired)
+			if err != nil {
+				t.Fatalf("UniqueLocalPath() error = %v", err)
+			}
+			if want := filepath.Join(dir, tt.want); got != want {
+				t.Errorf("UniqueLocalPath() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestUniqueLocalPathRejectsTraversal(t *testing.T) {
+	if _, err := UniqueLocalPath(t.TempDir(), "../escape.txt"); err == nil {
+		t.Error("expected an error for a name escaping the directory")
+	}
+}
@@ -2,10 +2,10 @@ package download
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
-	"sync/atomic"
 	"time"
 
 	"github.com/natevick/stui/internal/aws"
@@ -21,6 +21,7 @@ const (
 	StatusCompleted
 	StatusFailed
 	StatusCancelled
+	StatusPaused
 )
 
 func (s Status) String() string {
@@ -35,6 +36,8 @@ func (s Status) String() string {
 		return "failed"
 	case StatusCancelled:
 		return "cancelled"
+	case StatusPaused:
+		return "paused"
 	default:
 		return "unknown"
 	}
@@ -63,6 +66,8 @@ type Progress struct {
 	Files           map[string]*FileProgress
 	StartedAt       time.Time
 	Status          Status
+	// Budget is set once the session budget has paused the downloads
+	Budget *BudgetUsage
 }
 
 // PercentComplete returns the overall percentage
@@ -77,6 +82,7 @@ func (p Progress) PercentComplete() float64 {
 type Manager struct {
 	client      *aws.Client
 	workers     int
+	queue       *TransferQueue
 	progress    Progress
 	progressMu  sync.RWMutex
 	cancelFunc  context.CancelFunc
@@ -84,14 +90,13 @@ type Manager struct {
 	onComplete  func(Progress)
 }
 
-// NewManager creates a new download manager
+// NewManager creates a new download manager.
+// A workers value of zero or less ramps concurrency automatically.
 func NewManager(client *aws.Client, workers int) *Manager {
-	if workers <= 0 {
-		workers = 5
-	}
 	return &Manager{
 		client:  client,
 		workers: workers,
+		queue:   NewTransferQueue(QueueOptions{Concurrency: workers}),
 		progress: Progress{
 			Files: make(map[string]*FileProgress),
 		},
@@ -108,6 +113,54 @@ func (m *Manager) SetCompleteCallback(fn func(Progress)) {
 	m.onComplete = fn
 }
 
+// SetBudget caps the bytes and requests downloads may use. When it runs out
+// the downloads pause with StatusPaused until ResumeBudget. Call it before
+// starting any download.
+func (m *Manager) SetBudget(b *Budget) {
+	m.queue = NewTransferQueue(QueueOptions{
+		Concurrency: m.workers,
+		Budget:      b,
+		OnPause:     m.budgetPaused,
+	})
+}
+
+// ResumeBudget gives the budget a fresh allowance and continues paused downloads
+func (m *Manager) ResumeBudget() {
+	m.queue.Resume()
+
+	m.progressMu.Lock()
+	if m.progress.Status == StatusPaused {
+		m.progress.Status = StatusInProgress
+	}
+	m.progress.Budget = nil
+	m.progressMu.Unlock()
+	m.notifyProgress()
+}
+
+// budgetPaused reports a tripped budget through the progress callback
+func (m *Manager) budgetPaused(usage BudgetUsage) {
+	m.progressMu.Lock()
+	if m.progress.Status == StatusInProgress {
+		m.progress.Status = StatusPaused
+	}
+	m.progress.Budget = &usage
+	m.progressMu.Unlock()
+	m.notifyProgress()
+}
+
+// holdIfPaused marks a new download paused when an earlier one used up the
+// budget, so it doesn't look stuck while it waits for ResumeBudget
+func (m *Manager) holdIfPaused() {
+	if !m.queue.Paused() {
+		return
+	}
+	usage := m.queue.budget.Usage()
+	m.progressMu.Lock()
+	m.progress.Status = StatusPaused
+	m.progress.Budget = &usage
+	m.progressMu.Unlock()
+}
+
 // GetProgress returns the current progress
 func (m *Manager) GetProgress() Progress {
 	m.progressMu.RLock()
@@ -122,6 +175,12 @@ func (m *Manager) Cancel() {
 	}
 }
 
+// Shutdown stops starting new files and waits for in-flight ones until ctx ends,
+// then cancels them. Cancelled files keep their .part data for a later resume.
+func (m *Manager) Shutdown(ctx context.Context) ShutdownSummary {
+	return m.queue.Shutdown(ctx)
+}
+
 // DownloadFile downloads a single file
 func (m *Manager) DownloadFile(ctx context.Context, bucket, key, localPath string) error {
 	ctx, m.cancelFunc = context.WithCancel(ctx)
@@ -151,21 +210,41 @@ func (m *Manager) DownloadFile(ctx context.Context, bucket, key, localPath strin
 	}
 	m.progressMu.Unlock()
 
+	m.holdIfPaused()
 	m.notifyProgress()
 
-	err = m.client.DownloadFile(ctx, bucket, key, localPath, func(dp aws.DownloadProgress) {
-		m.progressMu.Lock()
-		m.progress.DownloadedBytes = dp.BytesDownloaded
-		if fp, ok := m.progress.Files[key]; ok {
-			fp.Downloaded = dp.BytesDownloaded
+	// Run through the queue so Shutdown can drain or cancel it
+	job := Job{Bucket: bucket, Key: key, LocalPath: localPath, Size: obj.Size}
+	var dlErr error
+	runErr := m.queue.Run(ctx, []Job{job}, func(ctx context.Context, _ Job) error {
+		dlErr = m.client.DownloadFile(ctx, bucket, key, localPath, func(dp aws.DownloadProgress) {
+			ReportTransferred(ctx, dp.BytesDownloaded)
+			m.progressMu.Lock()
+			m.progress.DownloadedBytes = dp.BytesDownloaded
+			if fp, ok := m.progress.Files[key]; ok {
+				fp.Downloaded = dp.BytesDownloaded
+			}
+			m.progressMu.Unlock()
+			m.notifyProgress()
+		})
+		if errors.Is(dlErr, aws.ErrETagUnverifiable) {
+			return nil
 		}
-		m.progressMu.Unlock()
-		m.notifyProgress()
+		return dlErr
 	})
+	err = dlErr
+	if err == nil {
+		err = runErr
+	}
+	// The file arrived but couldn't be checked: note it rather than fail it
+	var unverified error
+	if errors.Is(err, aws.ErrETagUnverifiable) {
+		unverified, err = err, nil
+	}
 
 	m.progressMu.Lock()
 	if err != nil {
-		if ctx.Err() != nil {
+		if cancelled(ctx, err) {
 			m.progress.Status = StatusCancelled
 			m.progress.Files[key].Status = StatusCancelled
 		} else {
@@ -178,6 +257,7 @@ func (m *Manager) DownloadFile(ctx context.Context, bucket, key, localPath strin
 		m.progress.Status = StatusCompleted
 		m.progress.CompletedFiles = 1
 		m.progress.Files[key].Status = StatusCompleted
+		m.progress.Files[key].Error = unverified
 		m.progress.Files[key].CompletedAt = time.Now()
 	}
 	m.progressMu.Unlock()
@@ -231,24 +311,12 @@ func (m *Manager) DownloadPrefix(ctx context.Context, bucket, prefix, localDir s
 	}
 	m.progressMu.Unlock()
 
+	m.holdIfPaused()
 	m.notifyProgress()
 
 	// Download files using worker pool
 	err = m.downloadWithWorkers(ctx, bucket, objects, prefix, localDir)
-
-	m.progressMu.Lock()
-	if err != nil && ctx.Err() != nil {
-		m.progress.Status = StatusCancelled
-	} else if m.progress.FailedFiles > 0 {
-		m.progress.Status = StatusFailed
-	} else {
-		m.progress.Status = StatusCompleted
-	}
-	m.progressMu.Unlock()
-
-	m.notifyProgress()
-	m.notifyComplete()
-
+	m.finish(ctx, err)
 	return err
 }
 
@@ -305,13 +373,48 @@ func (m *Manager) DownloadMultiple(ctx context.Context, bucket string, objects [
 	}
 	m.progressMu.Unlock()
 
+	m.holdIfPaused()
 	m.notifyProgress()
 
 	// Download files using worker pool
 	err := m.downloadWithWorkers(ctx, bucket, allObjects, prefix, localDir)
+	m.finish(ctx, err)
+	return err
+}
+
+// RetryFailed downloads the files that failed in the last download again
+// and returns how many were retried. Cancelled files are left alone.
+func (m *Manager) RetryFailed(ctx context.Context) (int, error) {
+	n := m.queue.RequeueFailed()
+	if n == 0 {
+		return 0, nil
+	}
+	ctx, m.cancelFunc = context.WithCancel(ctx)
 
 	m.progressMu.Lock()
-	if err != nil && ctx.Err() != nil {
+	for _, fp := range m.progress.Files {
+		if fp.Status == StatusFailed {
+			fp.Status = StatusPending
+			fp.Error = nil
+			fp.Downloaded = 0
+		}
+	}
+	m.progress.FailedFiles = 0
+	m.progress.Status = StatusInProgress
+	m.progressMu.Unlock()
+
+	m.holdIfPaused()
+	m.notifyProgress()
+
+	err := m.queue.RunPending(ctx, m.downloadJob)
+	m.finish(ctx, err)
+	return n, err
+}
+
+// finish records how a download ended and reports it
+func (m *Manager) finish(ctx context.Context, err error) {
+	m.progressMu.Lock()
+	if cancelled(ctx, err) {
 		m.progress.Status = StatusCancelled
 	} else if m.progress.FailedFiles > 0 {
 		m.progress.Status = StatusFailed
@@ -322,118 +425,107 @@ func (m *Manager) DownloadMultiple(ctx context.Context, bucket string, objects [
 
 	m.notifyProgress()
 	m.notifyComplete()
+}
 
-	return err
+// cancelled reports whether a download stopped because it was cancelled or shut down
+func cancelled(ctx context.Context, err error) bool {
+	if err == nil {
+		return false
+	}
+	return ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, ErrQueueClosed)
 }
 
-// downloadWithWorkers downloads files using a worker pool
+// downloadWithWorkers downloads files through the transfer queue
 func (m *Manager) downloadWithWorkers(ctx context.Context, bucket string, objects []aws.S3Object, prefix, localDir string) error {
-	jobs := make(chan aws.S3Object, len(objects))
-	var wg sync.WaitGroup
-	var downloadedBytes int64
-	var completedFiles int32
-	var failedFiles int32
-
-	// Start workers
-	for i := 0; i < m.workers; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for obj := range jobs {
-				select {
-				case <-ctx.Done():
-					return
-				default:
-				}
-
-				// Get the pre-validated local path from FileProgress
-				m.progressMu.Lock()
-				m.progress.CurrentFile = obj.Key
-				var localPath string
-				if fp, ok := m.progress.Files[obj.Key]; ok {
-					localPath = fp.LocalPath
-					fp.Status = StatusInProgress
-					fp.StartedAt = time.Now()
-				}
-				m.progressMu.Unlock()
-
-				if localPath == "" {
-					// Fallback with validation if not in progress map
-					relPath := strings.TrimPrefix(obj.Key, prefix)
-					var err error
-					localPath, err = security.SafePath(localDir, relPath)
-					if err != nil {
-						atomic.AddInt32(&failedFiles, 1)
-						m.progressMu.Lock()
-						if fp, ok := m.progress.Files[obj.Key]; ok {
-							fp.Status = StatusFailed
-							fp.Error = err
-						}
-						m.progress.FailedFiles = int(atomic.LoadInt32(&failedFiles))
-						m.progressMu.Unlock()
-						continue
-					}
-				}
-
-				m.notifyProgress()
-
-				err := m.client.DownloadFile(ctx, bucket, obj.Key, localPath, func(dp aws.DownloadProgress) {
-					m.progressMu.Lock()
-					if fp, ok := m.progress.Files[obj.Key]; ok {
-						fp.Downloaded = dp.BytesDownloaded
-					}
-					// Update total downloaded
-					var total int64
-					for _, fp := range m.progress.Files {
-						total += fp.Downloaded
-					}
-					m.progress.DownloadedBytes = total
-					m.progressMu.Unlock()
-					m.notifyProgress()
-				})
-
-				m.progressMu.Lock()
-				if err != nil {
-					atomic.AddInt32(&failedFiles, 1)
-					if fp, ok := m.progress.Files[obj.Key]; ok {
-						if ctx.Err() != nil {
-							fp.Status = StatusCancelled
-						} else {
-							fp.Status = StatusFailed
-							fp.Error = err
-						}
-					}
-					m.progress.FailedFiles = int(atomic.LoadInt32(&failedFiles))
-				} else {
-					atomic.AddInt64(&downloadedBytes, obj.Size)
-					atomic.AddInt32(&completedFiles, 1)
-					if fp, ok := m.progress.Files[obj.Key]; ok {
-						fp.Status = StatusCompleted
-						fp.Downloaded = obj.Size
-						fp.CompletedAt = time.Now()
-					}
-					m.progress.CompletedFiles = int(atomic.LoadInt32(&completedFiles))
-				}
-				m.progressMu.Unlock()
-				m.notifyProgress()
-			}
-		}()
+	jobs := make([]Job, len(objects))
+	for i, obj := range objects {
+		jobs[i] = Job{Bucket: bucket, Key: obj.Key, Size: obj.Size}
+		// Use the pre-validated local path from FileProgress
+		m.progressMu.RLock()
+		if fp, ok := m.progress.Files[obj.Key]; ok {
+			jobs[i].LocalPath = fp.LocalPath
+		}
+		m.progressMu.RUnlock()
+		if jobs[i].LocalPath == "" {
+			// Fallback with validation if not in progress map; a bad path
+			// fails the job when it runs
+			jobs[i].LocalPath, _ = security.SafePath(localDir, strings.TrimPrefix(obj.Key, prefix))
+		}
 	}
 
-	// Send jobs
-	for _, obj := range objects {
-		select {
-		case <-ctx.Done():
-			close(jobs)
-			wg.Wait()
-			return ctx.Err()
-		case jobs <- obj:
+	return m.queue.Run(ctx, jobs, m.downloadJob)
+}
+
+// downloadJob downloads one file of a download to job.LocalPath, recording
+// its progress and outcome
+func (m *Manager) downloadJob(ctx context.Context, job Job) error {
+	m.progressMu.Lock()
+	m.progress.CurrentFile = job.Key
+	if fp, ok := m.progress.Files[job.Key]; ok {
+		fp.Status = StatusInProgress
+		fp.StartedAt = time.Now()
+	}
+	m.progressMu.Unlock()
+
+	if job.LocalPath == "" {
+		err := fmt.Errorf("unsafe path for key %s", job.Key)
+		m.progressMu.Lock()
+		if fp, ok := m.progress.Files[job.Key]; ok {
+			fp.Status = StatusFailed
+			fp.Error = err
 		}
+		m.progress.FailedFiles++
+		m.progressMu.Unlock()
+		return err
+	}
+
+	m.notifyProgress()
+
+	err := m.client.DownloadFile(ctx, job.Bucket, job.Key, job.LocalPath, func(dp aws.DownloadProgress) {
+		ReportTransferred(ctx, dp.BytesDownloaded)
+		m.progressMu.Lock()
+		if fp, ok := m.progress.Files[job.Key]; ok {
+			fp.Downloaded = dp.BytesDownloaded
+		}
+		// Update total downloaded
+		var total int64
+		for _, fp := range m.progress.Files {
+			total += fp.Downloaded
+		}
+		m.progress.DownloadedBytes = total
+		m.progressMu.Unlock()
+		m.notifyProgress()
+	})
+
+	// The file arrived but couldn't be checked: note it rather than fail it
+	var unverified error
+	if errors.Is(err, aws.ErrETagUnverifiable) {
+		unverified, err = err, nil
 	}
-	close(jobs)
 
-	wg.Wait()
-	return nil
+	m.progressMu.Lock()
+	if err != nil {
+		if fp, ok := m.progress.Files[job.Key]; ok {
+			if ctx.Err() != nil {
+				fp.Status = StatusCancelled
+			} else {
+				fp.Status = StatusFailed
+				fp.Error = err
+			}
+		}
+		m.progress.FailedFiles++
+	} else {
+		if fp, ok := m.progress.Files[job.Key]; ok {
+			fp.Status = StatusCompleted
+			fp.Error = unverified
+			fp.Downloaded = job.Size
+			fp.CompletedAt = time.Now()
+		}
+		m.progress.CompletedFiles++
+	}
+	m.progressMu.Unlock()
+	m.notifyProgress()
+	return err
 }
 
 func (m *Manager) notifyProgress() {
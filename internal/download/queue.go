@@ -0,0 +1,551 @@
+package download
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/natevick/stui/internal/aws"
+)
+
+// Bounds for automatic concurrency
+const (
+	DefaultMinConcurrency = 2
+	DefaultMaxConcurrency = 16
+	DefaultSampleInterval = 2 * time.Second
+)
+
+// rampThreshold is the relative throughput gain needed to add another worker
+const rampThreshold = 0.05
+
+// AutoConcurrency adjusts a worker limit from throughput and throttling feedback.
+// It adds one worker while throughput keeps improving and halves the limit when
+// S3 throttles or requests time out.
+type AutoConcurrency struct {
+	mu    sync.Mutex
+	min   int
+	max   int
+	limit int
+	last  float64 // throughput measured at the previous limit
+}
+
+// NewAutoConcurrency creates a controller that starts at min and never exceeds max
+func NewAutoConcurrency(minLimit, maxLimit int) *AutoConcurrency {
+	if minLimit <= 0 {
+		minLimit = DefaultMinConcurrency
+	}
+	if maxLimit < minLimit {
+		maxLimit = minLimit
+	}
+	return &AutoConcurrency{min: minLimit, max: maxLimit, limit: minLimit}
+}
+
+// Limit returns the current worker limit
+func (a *AutoConcurrency) Limit() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.limit
+}
+
+// ReportThroughput records the aggregate bytes per second seen at the current limit
+func (a *AutoConcurrency) ReportThroughput(bytesPerSec float64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.last == 0 || bytesPerSec >= a.last*(1+rampThreshold) {
+		if a.limit < a.max {
+			a.limit++
+		}
+	}
+	a.last = bytesPerSec
+}
+
+// ReportThrottle halves the limit after a throttling or timeout error
+func (a *AutoConcurrency) ReportThrottle() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.limit = max(a.min, a.limit/2)
+	a.last = 0
+}
+
+// QueueOptions configures a TransferQueue
+type QueueOptions struct {
+	// Concurrency fixes the number of workers; zero enables AutoConcurrency
+	Concurrency int
+	// MinConcurrency and MaxConcurrency bound automatic ramping
+	MinConcurrency int
+	MaxConcurrency int
+	// SampleInterval is how often throughput is fed back in auto mode
+	SampleInterval time.Duration
+	// Budget pauses the queue once its bytes or requests run out; nil is unlimited
+	Budget *Budget
+	// OnPause is called when the budget trips and the queue pauses
+	OnPause func(BudgetUsage)
+}
+
+// TransferQueue runs transfer jobs with either a fixed or an automatic concurrency limit
+type TransferQueue struct {
+	fixed    int
+	auto     *AutoConcurrency
+	interval time.Duration
+	now      func() time.Time
+	budget   *Budget
+	onPause  func(BudgetUsage)
+
+	mu          sync.Mutex
+	cond        *sync.Cond
+	active      int
+	paused      bool
+	sampleBytes int64
+	sampleStart time.Time
+
+	// Shutdown state
+	closed    bool
+	draining  chan struct{}   // closed when Shutdown starts
+	forceCtx  context.Context // cancelled when the grace period ends
+	force     context.CancelFunc
+	runs      sync.WaitGroup
+	completed atomic.Int64
+	failed    atomic.Int64
+	canceled  atomic.Int64
+
+	// items records the jobs handed to Run until a later Run prunes them,
+	// guarded by mu
+	items []*Item
+}
+
+// Item is a job the queue has been given and what became of it
+type Item struct {
+	Job    Job
+	Status Status
+	// Transferred is the number of bytes moved; Job.Size once completed
+	Transferred int64
+	Err         error
+
+	// claimed is set while a run owns the item, so RunPending can't start it twice
+	claimed bool
+}
+
+// ErrQueueClosed is returned by Run once Shutdown has been called
+var ErrQueueClosed = errors.New("transfer queue is shut down")
+
+// NewTransferQueue creates a queue. A manual Concurrency disables ramping.
+func NewTransferQueue(opts QueueOptions) *TransferQueue {
+	q := &TransferQueue{
+		fixed:    opts.Concurrency,
+		interval: opts.SampleInterval,
+		now:      time.Now,
+		budget:   opts.Budget,
+		onPause:  opts.OnPause,
+		// A budget spent under an earlier queue stays spent until Resume
+		paused: opts.Budget != nil && opts.Budget.Exceeded(),
+	}
+	if q.fixed <= 0 {
+		q.fixed = 0
+		minC, maxC := opts.MinConcurrency, opts.MaxConcurrency
+		if minC <= 0 {
+			minC = DefaultMinConcurrency
+		}
+		if maxC <= 0 {
+			maxC = DefaultMaxConcurrency
+		}
+		q.auto = NewAutoConcurrency(minC, maxC)
+	}
+	if q.interval <= 0 {
+		q.interval = DefaultSampleInterval
+	}
+	q.cond = sync.NewCond(&q.mu)
+	q.draining = make(chan struct{})
+	q.forceCtx, q.force = context.WithCancel(context.Background())
+	return q
+}
+
+// Auto reports whether the queue ramps concurrency automatically
+func (q *TransferQueue) Auto() bool {
+	return q.auto != nil
+}
+
+// Limit returns the number of jobs currently allowed to run at once
+func (q *TransferQueue) Limit() int {
+	if q.auto != nil {
+		return q.auto.Limit()
+	}
+	return q.fixed
+}
+
+// maxWorkers is the most goroutines Run ever needs
+func (q *TransferQueue) maxWorkers() int {
+	if q.auto != nil {
+		return q.auto.max
+	}
+	return q.fixed
+}
+
+// Run calls fn for every job, respecting the current limit, and waits for them to finish.
+// It returns ctx.Err() if the context is cancelled before all jobs are started,
+// or ErrQueueClosed if Shutdown stopped it. fn should ReportTransferred as it
+// goes so a job that fails partway is charged for what it moved. Items that
+// finished in earlier runs are dropped, so Items and RequeueFailed only see
+// jobs from the runs since.
+func (q *TransferQueue) Run(ctx context.Context, jobs []Job, fn func(context.Context, Job) error) error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return ErrQueueClosed
+	}
+	q.pruneFinished()
+	items := make([]*Item, len(jobs))
+	for i, job := range jobs {
+		items[i] = &Item{Job: job, Status: StatusPending, claimed: true}
+	}
+	q.items = append(q.items, items...)
+	return q.run(ctx, items, fn)
+}
+
+// RunPending runs every pending item again, such as those put back by
+// RequeueFailed, with the same semantics as Run
+func (q *TransferQueue) RunPending(ctx context.Context, fn func(context.Context, Job) error) error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return ErrQueueClosed
+	}
+	var items []*Item
+	for _, item := range q.items {
+		if item.Status == StatusPending && !item.claimed {
+			item.claimed = true
+			items = append(items, item)
+		}
+	}
+	return q.run(ctx, items, fn)
+}
+
+// RequeueFailed moves every failed item back to pending, clearing its progress
+// and error, and returns how many were requeued. Cancelled items stay as they are.
+func (q *TransferQueue) RequeueFailed() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	n := 0
+	for _, item := range q.items {
+		if item.Status != StatusFailed {
+			continue
+		}
+		item.Status = StatusPending
+		item.Transferred = 0
+		item.Err = nil
+		item.claimed = false
+		n++
+	}
+	// Retried jobs are counted again when they finish
+	q.failed.Add(-int64(n))
+	return n
+}
+
+// pruneFinished drops items that are done with, keeping those still pending
+// or running. It is called with q.mu held.
+func (q *TransferQueue) pruneFinished() {
+	kept := q.items[:0]
+	for _, item := range q.items {
+		switch item.Status {
+		case StatusCompleted, StatusFailed, StatusCancelled:
+			continue
+		}
+		kept = append(kept, item)
+	}
+	// Clear the tail so dropped items can be collected
+	clear(q.items[len(kept):])
+	q.items = kept
+}
+
+// Items returns a snapshot of the items the queue holds, in order
+func (q *TransferQueue) Items() []Item {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	out := make([]Item, len(q.items))
+	for i, item := range q.items {
+		out[i] = *item
+	}
+	return out
+}
+
+// setStatus records an item's outcome
+func (q *TransferQueue) setStatus(item *Item, status Status, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	item.Status = status
+	item.Err = err
+	if status == StatusCompleted {
+		item.Transferred = item.Job.Size
+	}
+}
+
+// run is called with q.mu held and releases it once the run is registered
+func (q *TransferQueue) run(ctx context.Context, items []*Item, fn func(context.Context, Job) error) error {
+	q.runs.Add(1)
+	q.sampleStart = q.now()
+	q.sampleBytes = 0
+	q.mu.Unlock()
+	defer q.runs.Done()
+
+	// Shutdown cancels running jobs once its grace period ends
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stopForced := context.AfterFunc(q.forceCtx, cancel)
+	defer stopForced()
+
+	// Wake workers blocked on the limit when the context ends
+	stop := context.AfterFunc(ctx, func() {
+		q.mu.Lock()
+		q.cond.Broadcast()
+		q.mu.Unlock()
+	})
+	defer stop()
+
+	ch := make(chan *Item)
+	var wg sync.WaitGroup
+	var skipped atomic.Bool
+
+	for i := 0; i < q.maxWorkers(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range ch {
+				if !q.acquire(ctx) {
+					skipped.Store(true)
+					q.canceled.Add(1)
+					q.setStatus(item, StatusCancelled, nil)
+					continue
+				}
+				q.setStatus(item, StatusInProgress, nil)
+				err := fn(context.WithValue(ctx, runningKey{}, running{q, item}), item.Job)
+				q.chargeBudget(item, err)
+				q.release(item.Job, err)
+
+				switch {
+				case err == nil:
+					q.completed.Add(1)
+					q.setStatus(item, StatusCompleted, nil)
+				case ctx.Err() != nil:
+					q.canceled.Add(1)
+					q.setStatus(item, StatusCancelled, err)
+				default:
+					q.failed.Add(1)
+					q.setStatus(item, StatusFailed, err)
+				}
+			}
+		}()
+	}
+
+	var err error
+	sent := 0
+send:
+	for _, item := range items {
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+			break send
+		case <-q.draining:
+			err = ErrQueueClosed
+			break send
+		case ch <- item:
+			sent++
+		}
+	}
+	close(ch)
+	q.canceled.Add(int64(len(items) - sent))
+	for _, item := range items[sent:] {
+		q.setStatus(item, StatusCancelled, nil)
+	}
+
+	wg.Wait()
+	if err == nil && (skipped.Load() || q.forceCtx.Err() != nil) {
+		err = ctx.Err()
+		if q.forceCtx.Err() != nil || err == nil {
+			err = ErrQueueClosed
+		}
+	}
+	return err
+}
+
+// ShutdownSummary reports what happened to the queue's jobs
+type ShutdownSummary struct {
+	Completed int
+	Failed    int
+	// Canceled counts jobs interrupted mid-transfer or never started
+	Canceled int
+	// Forced is set when the grace period ended before running jobs finished
+	Forced bool
+}
+
+// Shutdown stops the queue from starting new jobs and waits for running ones
+// to finish. When ctx ends first, the remaining jobs are cancelled; transfers
+// that honor cancellation leave their partial files in place for a later resume.
+// Shutdown is final: later calls to Run return ErrQueueClosed.
+func (q *TransferQueue) Shutdown(ctx context.Context) ShutdownSummary {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.draining)
+	}
+	q.cond.Broadcast()
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.runs.Wait()
+		close(done)
+	}()
+
+	forced := false
+	select {
+	case <-done:
+	case <-ctx.Done():
+		forced = true
+		q.force()
+		<-done
+	}
+
+	return ShutdownSummary{
+		Completed: int(q.completed.Load()),
+		Failed:    int(q.failed.Load()),
+		Canceled:  int(q.canceled.Load()),
+		Forced:    forced,
+	}
+}
+
+// Paused reports whether the budget has stopped the queue from starting jobs
+func (q *TransferQueue) Paused() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.paused
+}
+
+// Resume gives the budget a fresh allowance and lets a paused queue start
+// the jobs it was holding back
+func (q *TransferQueue) Resume() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.budget != nil {
+		q.budget.Reset()
+	}
+	q.paused = false
+	q.cond.Broadcast()
+}
+
+// runningKey carries the item a job fn is running for, for ReportTransferred
+type runningKey struct{}
+
+type running struct {
+	queue *TransferQueue
+	item  *Item
+}
+
+// ReportTransferred records that the job running under ctx has moved n bytes
+// so far, charging the new bytes to the queue's budget right away so it
+// can pause before the job ends. A smaller n than before means the job
+// started over. It does nothing outside a TransferQueue job.
+func ReportTransferred(ctx context.Context, n int64) {
+	r, ok := ctx.Value(runningKey{}).(running)
+	if !ok {
+		return
+	}
+	q := r.queue
+	q.mu.Lock()
+	delta := max(n-r.item.Transferred, 0)
+	r.item.Transferred = n
+	q.mu.Unlock()
+
+	if q.budget != nil && delta > 0 {
+		q.budget.AddBytes(delta)
+		q.checkBudget()
+	}
+}
+
+// chargeBudget records a finished job against the budget. The bytes it
+// reported were charged as they moved; a job that succeeded is charged the
+// rest of its size too, in case it reported less. It runs before the job's
+// slot is released so no other job can start in between. Jobs already
+// running finish.
+func (q *TransferQueue) chargeBudget(item *Item, err error) {
+	if q.budget == nil {
+		return
+	}
+	if err == nil {
+		q.mu.Lock()
+		rest := max(item.Job.Size-item.Transferred, 0)
+		q.mu.Unlock()
+		q.budget.AddBytes(rest)
+	}
+	q.budget.AddTransfer()
+	q.checkBudget()
+}
+
+// checkBudget pauses the queue the first time the budget is exceeded
+func (q *TransferQueue) checkBudget() {
+	q.mu.Lock()
+	tripped := !q.paused && q.budget.Exceeded()
+	if tripped {
+		q.paused = true
+	}
+	q.mu.Unlock()
+
+	if tripped && q.onPause != nil {
+		q.onPause(q.budget.Usage())
+	}
+}
+
+// acquire blocks until a slot is free and the queue isn't paused, returning
+// false if ctx ends or the queue closes first
+func (q *TransferQueue) acquire(ctx context.Context) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for q.paused || q.active >= q.Limit() {
+		if ctx.Err() != nil || q.closed {
+			return false
+		}
+		q.cond.Wait()
+	}
+	if ctx.Err() != nil || q.closed {
+		return false
+	}
+	q.active++
+	return true
+}
+
+// release frees a slot and feeds the job's outcome back to the controller
+func (q *TransferQueue) release(job Job, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.active--
+	defer q.cond.Broadcast()
+
+	if q.auto == nil {
+		return
+	}
+
+	if err != nil {
+		if aws.IsThrottled(err) {
+			q.auto.ReportThrottle()
+			q.sampleStart = q.now()
+			q.sampleBytes = 0
+		}
+		return
+	}
+
+	q.sampleBytes += job.Size
+	now := q.now()
+	if elapsed := now.Sub(q.sampleStart); elapsed >= q.interval {
+		q.auto.ReportThroughput(float64(q.sampleBytes) / elapsed.Seconds())
+		q.sampleStart = now
+		q.sampleBytes = 0
+	}
+}
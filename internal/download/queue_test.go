@@ -0,0 +1,472 @@
+package download
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/smithy-go"
+)
+
+// fakeClock advances by step every time it is read
+func fakeClock(step time.Duration) func() time.Time {
+	var mu sync.Mutex
+	now := time.Unix(0, 0)
+	return func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		now = now.Add(step)
+		return now
+	}
+}
+
+func TestAutoConcurrencyRampsWhileThroughputImproves(t *testing.T) {
+	a := NewAutoConcurrency(2, 5)
+	if a.Limit() != 2 {
+		t.Fatalf("initial limit = %d, want 2", a.Limit())
+	}
+
+	for i, bps := range []float64{100, 200, 300} {
+		a.ReportThroughput(bps)
+		if want := 3 + i; a.Limit() != want {
+			t.Fatalf("after %v B/s limit = %d, want %d", bps, a.Limit(), want)
+		}
+	}
+
+	// Flat throughput means extra workers aren't helping
+	a.ReportThroughput(301)
+	if a.Limit() != 5 {
+		t.Fatalf("limit = %d, want 5", a.Limit())
+	}
+	a.ReportThroughput(302)
+	if a.Limit() != 5 {
+		t.Errorf("limit changed on flat throughput: %d", a.Limit())
+	}
+
+	// Never exceeds max
+	a.ReportThroughput(10000)
+	if a.Limit() != 5 {
+		t.Errorf("limit = %d, want capped at 5", a.Limit())
+	}
+}
+
+func TestAutoConcurrencyBacksOffOnThrottle(t *testing.T) {
+	a := NewAutoConcurrency(2, 16)
+	for bps := 100.0; a.Limit() < 16; bps *= 2 {
+		a.ReportThroughput(bps)
+	}
+
+	for _, want := range []int{8, 4, 2, 2} {
+		a.ReportThrottle()
+		if a.Limit() != want {
+			t.Fatalf("limit after throttle = %d, want %d", a.Limit(), want)
+		}
+	}
+}
+
+func TestTransferQueueFeedback(t *testing.T) {
+	q := NewTransferQueue(QueueOptions{MinConcurrency: 2, MaxConcurrency: 8, SampleInterval: time.Second})
+	q.now = fakeClock(time.Second)
+	q.sampleStart = q.now()
+	if !q.Auto() {
+		t.Fatal("expected auto mode without a manual concurrency")
+	}
+
+	// Each sample moves more bytes than the last, so the queue ramps up
+	for i := 1; i <= 4; i++ {
+		q.active++
+		q.release(Job{Size: int64(i) * 1000}, nil)
+	}
+	if q.Limit() != 6 {
+		t.Fatalf("limit after improving throughput = %d, want 6", q.Limit())
+	}
+
+	throttled := &smithy.GenericAPIError{Code: "SlowDown", Message: "Please reduce your request rate."}
+	q.active++
+	q.release(Job{Size: 1000}, throttled)
+	if q.Limit() != 3 {
+		t.Fatalf("limit after throttling = %d, want 3", q.Limit())
+	}
+
+	// Ordinary failures don't change the limit
+	q.active++
+	q.release(Job{Size: 1000}, errors.New("access denied"))
+	if q.Limit() != 3 {
+		t.Errorf("limit after non-throttle error = %d, want 3", q.Limit())
+	}
+}
+
+func TestTransferQueueManualOverride(t *testing.T) {
+	q := NewTransferQueue(QueueOptions{Concurrency: 3, SampleInterval: time.Nanosecond})
+	if q.Auto() {
+		t.Fatal("manual concurrency must disable auto mode")
+	}
+
+	jobs := make([]Job, 30)
+	for i := range jobs {
+		jobs[i] = Job{Size: int64(i+1) * 1 << 20}
+	}
+
+	var running, peak, done atomic.Int32
+	err := q.Run(context.Background(), jobs, func(ctx context.Context, job Job) error {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		running.Add(-1)
+		done.Add(1)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if done.Load() != 30 {
+		t.Errorf("ran %d jobs, want 30", done.Load())
+	}
+	if peak.Load() > 3 {
+		t.Errorf("peak concurrency = %d, want at most 3", peak.Load())
+	}
+	if q.Limit() != 3 {
+		t.Errorf("limit = %d, want fixed at 3", q.Limit())
+	}
+}
+
+func TestTransferQueueRunCancelled(t *testing.T) {
+	q := NewTransferQueue(QueueOptions{Concurrency: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var ran atomic.Int32
+	err := q.Run(ctx, make([]Job, 10), func(ctx context.Context, job Job) error {
+		ran.Add(1)
+		cancel()
+		return ctx.Err()
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() error = %v, want context.Canceled", err)
+	}
+	if ran.Load() >= 10 {
+		t.Errorf("ran %d jobs after cancellation", ran.Load())
+	}
+}
+
+func TestTransferQueueShutdownDrains(t *testing.T) {
+	q := NewTransferQueue(QueueOptions{Concurrency: 2})
+
+	started := make(chan struct{}, 4)
+	finish := make(chan struct{})
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- q.Run(context.Background(), make([]Job, 4), func(ctx context.Context, job Job) error {
+			started <- struct{}{}
+			<-finish
+			return nil
+		})
+	}()
+	<-started
+	<-started
+
+	summary := make(chan ShutdownSummary, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		summary <- q.Shutdown(ctx)
+	}()
+
+	// Let the running jobs finish only once the queue has stopped taking work
+	<-q.draining
+	close(finish)
+
+	got := <-summary
+	want := ShutdownSummary{Completed: 2, Canceled: 2}
+	if got != want {
+		t.Errorf("Shutdown() = %+v, want %+v", got, want)
+	}
+	if err := <-runErr; !errors.Is(err, ErrQueueClosed) {
+		t.Errorf("Run() error = %v, want ErrQueueClosed", err)
+	}
+	if err := q.Run(context.Background(), make([]Job, 1), nil); !errors.Is(err, ErrQueueClosed) {
+		t.Errorf("Run() after Shutdown error = %v, want ErrQueueClosed", err)
+	}
+}
+
+func TestTransferQueueShutdownForcesAfterGrace(t *testing.T) {
+	q := NewTransferQueue(QueueOptions{Concurrency: 1})
+	partPath := filepath.Join(t.TempDir(), "big.bin.part")
+
+	started := make(chan struct{})
+	go q.Run(context.Background(), make([]Job, 1), func(ctx context.Context, job Job) error {
+		// Behave like a resumable download: write partial data, stop on cancel
+		if err := os.WriteFile(partPath, []byte("partial"), 0600); err != nil {
+			return err
+		}
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	<-started
+
+	const grace = 50 * time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), grace)
+	defer cancel()
+
+	begin := time.Now()
+	got := q.Shutdown(ctx)
+	if elapsed := time.Since(begin); elapsed < grace {
+		t.Errorf("Shutdown() returned after %v, before the %v grace period", elapsed, grace)
+	}
+
+	want := ShutdownSummary{Canceled: 1, Forced: true}
+	if got != want {
+		t.Errorf("Shutdown() = %+v, want %+v", got, want)
+	}
+	if _, err := os.Stat(partPath); err != nil {
+		t.Errorf("part file removed by forced shutdown: %v", err)
+	}
+}
+
+func TestTransferQueueRequeueFailed(t *testing.T) {
+	// One worker runs the jobs in order, so the last one cancels the run
+	q := NewTransferQueue(QueueOptions{Concurrency: 1})
+	jobs := []Job{{Key: "ok", Size: 10}, {Key: "bad-1", Size: 20}, {Key: "bad-2", Size: 30}, {Key: "cancelled"}}
+	boom := errors.New("boom")
+	ctx, cancel := context.WithCancel(context.Background())
+	q.Run(ctx, jobs, func(ctx context.Context, job Job) error {
+		switch job.Key {
+		case "ok":
+			return nil
+		case "cancelled":
+			// A job interrupted by cancellation is cancelled, not failed
+			cancel()
+			return ctx.Err()
+		}
+		return boom
+	})
+
+	if n := q.RequeueFailed(); n != 2 {
+		t.Fatalf("RequeueFailed() = %d, want 2", n)
+	}
+	want := map[string]Status{
+		"ok":        StatusCompleted,
+		"bad-1":     StatusPending,
+		"bad-2":     StatusPending,
+		"cancelled": StatusCancelled,
+	}
+	for _, item := range q.Items() {
+		if item.Status != want[item.Job.Key] {
+			t.Errorf("%s status = %v, want %v", item.Job.Key, item.Status, want[item.Job.Key])
+		}
+		if item.Status == StatusPending && (item.Err != nil || item.Transferred != 0) {
+			t.Errorf("%s kept progress %d / error %v after requeue", item.Job.Key, item.Transferred, item.Err)
+		}
+	}
+	if n := q.RequeueFailed(); n != 0 {
+		t.Errorf("second RequeueFailed() = %d, want 0", n)
+	}
+
+	var retried []string
+	var mu sync.Mutex
+	err := q.RunPending(context.Background(), func(ctx context.Context, job Job) error {
+		mu.Lock()
+		retried = append(retried, job.Key)
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("RunPending() error = %v", err)
+	}
+	if len(retried) != 2 {
+		t.Errorf("retried %v, want only the two failed jobs", retried)
+	}
+	for _, item := range q.Items() {
+		if item.Job.Key == "bad-2" && (item.Status != StatusCompleted || item.Transferred != 30) {
+			t.Errorf("bad-2 = %+v after retry, want completed with 30 bytes", item)
+		}
+	}
+
+	summary := q.Shutdown(context.Background())
+	if summary != (ShutdownSummary{Completed: 3, Canceled: 1}) {
+		t.Errorf("Shutdown() = %+v, want 3 completed and 1 cancelled", summary)
+	}
+}
+
+func TestTransferQueueRunPrunesFinishedItems(t *testing.T) {
+	q := NewTransferQueue(QueueOptions{Concurrency: 2})
+	boom := errors.New("boom")
+	fail := func(context.Context, Job) error { return boom }
+
+	for run := range 3 {
+		q.Run(context.Background(), []Job{{Key: "a"}, {Key: "b"}}, fail)
+		if n := len(q.Items()); n != 2 {
+			t.Fatalf("run %d: queue holds %d items, want only this run's 2", run, n)
+		}
+	}
+
+	// Failures from earlier runs aren't retried once a new run starts
+	q.Run(context.Background(), []Job{{Key: "c"}}, func(context.Context, Job) error { return nil })
+	if n := q.RequeueFailed(); n != 0 {
+		t.Errorf("RequeueFailed() = %d after a later run, want 0", n)
+	}
+}
+
+func TestTransferQueueBudgetPausesMidBatch(t *testing.T) {
+	paused := make(chan BudgetUsage, 1)
+	q := NewTransferQueue(QueueOptions{
+		Concurrency: 1,
+		Budget:      NewBudget(25, 0),
+		OnPause:     func(u BudgetUsage) { paused <- u },
+	})
+
+	jobs := make([]Job, 5)
+	for i := range jobs {
+		jobs[i] = Job{Key: string(rune('a' + i)), Size: 10}
+	}
+	var ran atomic.Int32
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- q.Run(context.Background(), jobs, func(ctx context.Context, job Job) error {
+			ran.Add(1)
+			return nil
+		})
+	}()
+
+	// The third job crosses 25 bytes; nothing starts after it
+	usage := <-paused
+	if usage.Bytes != 30 || usage.Transfers != 3 {
+		t.Errorf("usage at pause = %+v, want 30 bytes over 3 transfers", usage)
+	}
+	if !q.Paused() {
+		t.Error("expected the queue to report paused")
+	}
+	select {
+	case err := <-runErr:
+		t.Fatalf("Run() returned %v while paused", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if n := ran.Load(); n != 3 {
+		t.Errorf("ran %d jobs before pausing, want 3", n)
+	}
+	counts := map[Status]int{}
+	for _, item := range q.Items() {
+		counts[item.Status]++
+	}
+	if counts[StatusCompleted] != 3 || counts[StatusPending] != 2 {
+		t.Errorf("statuses while paused = %v, want 3 completed and 2 pending", counts)
+	}
+
+	q.Resume()
+	if err := <-runErr; err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if n := ran.Load(); n != 5 {
+		t.Errorf("ran %d jobs after resuming, want 5", n)
+	}
+	if q.Paused() {
+		t.Error("queue still paused after Resume")
+	}
+	if got := q.budget.Usage(); got.Bytes != 20 || got.Transfers != 2 {
+		t.Errorf("usage after resume = %+v, want the fresh allowance to count 20 bytes over 2 transfers", got)
+	}
+}
+
+func TestTransferQueueBudgetCancelWhilePaused(t *testing.T) {
+	q := NewTransferQueue(QueueOptions{Concurrency: 1, Budget: NewBudget(0, 1)})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- q.Run(ctx, make([]Job, 3), func(ctx context.Context, job Job) error { return nil })
+	}()
+	for !q.Paused() {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+
+	if err := <-runErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestTransferQueueBudgetChargesFailedJobsForBytesMoved(t *testing.T) {
+	budget := NewBudget(0, 0)
+	q := NewTransferQueue(QueueOptions{Concurrency: 1, Budget: budget})
+
+	jobs := []Job{{Key: "ok", Size: 10}, {Key: "broken", Size: 100}}
+	q.Run(context.Background(), jobs, func(ctx context.Context, job Job) error {
+		if job.Key == "broken" {
+			ReportTransferred(ctx, 40)
+			return errors.New("connection reset")
+		}
+		ReportTransferred(ctx, 5) // the whole job counts once it succeeds
+		return nil
+	})
+
+	if got := budget.Usage(); got.Bytes != 50 || got.Transfers != 2 {
+		t.Errorf("usage = %+v, want 50 bytes over 2 transfers", got)
+	}
+}
+
+func TestTransferQueueSpentBudgetStartsPaused(t *testing.T) {
+	budget := NewBudget(10, 0)
+	budget.AddBytes(10)
+
+	// A queue taking over a spent budget, as after switching profiles
+	q := NewTransferQueue(QueueOptions{Concurrency: 1, Budget: budget})
+	if !q.Paused() {
+		t.Fatal("queue with a spent budget isn't paused")
+	}
+	var ran atomic.Int32
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	q.Run(ctx, []Job{{Size: 1}}, func(ctx context.Context, job Job) error {
+		ran.Add(1)
+		return nil
+	})
+	if n := ran.Load(); n != 0 {
+		t.Errorf("ran %d jobs on a spent budget, want 0", n)
+	}
+
+	q.Resume()
+	if budget.Exceeded() {
+		t.Error("Resume didn't refill the shared budget")
+	}
+}
+
+func TestTransferQueueBudgetTripsMidTransfer(t *testing.T) {
+	paused := make(chan BudgetUsage, 1)
+	q := NewTransferQueue(QueueOptions{
+		Concurrency: 1,
+		Budget:      NewBudget(100, 0),
+		OnPause:     func(u BudgetUsage) { paused <- u },
+	})
+
+	// One large file passes the limit while still downloading
+	var tripped bool
+	q.Run(context.Background(), []Job{{Key: "big", Size: 1000}}, func(ctx context.Context, job Job) error {
+		for n := int64(50); n <= job.Size; n += 50 {
+			ReportTransferred(ctx, n)
+			if n == 150 {
+				tripped = q.Paused()
+			}
+		}
+		return nil
+	})
+
+	if !tripped {
+		t.Error("budget didn't pause the queue until the transfer ended")
+	}
+	if usage := <-paused; usage.Bytes != 100 || usage.Transfers != 0 {
+		t.Errorf("usage at pause = %+v, want 100 bytes and no finished transfers", usage)
+	}
+	if got := q.budget.Usage(); got.Bytes != 1000 || got.Transfers != 1 {
+		t.Errorf("usage = %+v, want the file charged once: 1000 bytes over 1 transfer", got)
+	}
+}
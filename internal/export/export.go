@@ -0,0 +1,129 @@
+// Package export writes object listings as CSV, JSON, or NDJSON for reports.
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/natevick/stui/internal/aws"
+)
+
+// Format is an export file format
+type Format string
+
+// Supported export formats
+const (
+	CSV    Format = "csv"
+	JSON   Format = "json"
+	NDJSON Format = "ndjson"
+)
+
+// ParseFormat validates a format name
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case CSV, JSON, NDJSON:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unsupported export format %q (use csv, json, or ndjson)", s)
+	}
+}
+
+// FormatForPath picks the format from a file extension, defaulting to CSV
+func FormatForPath(path string) Format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return JSON
+	case ".ndjson", ".jsonl":
+		return NDJSON
+	default:
+		return CSV
+	}
+}
+
+// record is one exported object
+type record struct {
+	Key          string `json:"key"`
+	Size         int64  `json:"size"`
+	SizeHuman    string `json:"size_human"`
+	LastModified string `json:"last_modified"`
+	StorageClass string `json:"storage_class"`
+}
+
+// csvHeader names the CSV columns in record order
+var csvHeader = []string{"key", "size", "size_human", "last_modified", "storage_class"}
+
+// Listing writes the objects in format. Folders are skipped; timestamps are
+// RFC 3339 in UTC.
+func Listing(w io.Writer, objects []aws.S3Object, format Format) error {
+	records := make([]record, 0, len(objects))
+	for _, obj := range objects {
+		if obj.IsPrefix {
+			continue
+		}
+		records = append(records, record{
+			Key:          obj.Key,
+			Size:         obj.Size,
+			SizeHuman:    humanize.Bytes(uint64(obj.Size)),
+			LastModified: obj.LastModified.UTC().Format(time.RFC3339),
+			StorageClass: obj.StorageClass,
+		})
+	}
+
+	switch format {
+	case CSV:
+		return writeCSV(w, records)
+	case JSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	case NDJSON:
+		enc := json.NewEncoder(w)
+		for _, r := range records {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+// writeCSV writes a header row and one row per record
+func writeCSV(w io.Writer, records []record) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, r := range records {
+		row := []string{
+			neutralizeFormula(r.Key),
+			strconv.FormatInt(r.Size, 10),
+			r.SizeHuman,
+			r.LastModified,
+			r.StorageClass,
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// neutralizeFormula stops spreadsheets from evaluating a key as a formula.
+// Object keys are attacker-controllable, so a leading =, +, -, @, tab, or
+// carriage return is escaped with a quote.
+func neutralizeFormula(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
@@ -0,0 +1,131 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/natevick/stui/internal/aws"
+)
+
+var modified = time.Date(2024, 3, 1, 9, 30, 0, 0, time.FixedZone("PST", -8*3600))
+
+func testObjects() []aws.S3Object {
+	return []aws.S3Object{
+		{Key: "reports/", IsPrefix: true},
+		{Key: "data/q1.csv", Size: 1500, LastModified: modified, StorageClass: "STANDARD"},
+		{Key: `data/"north, south".csv`, Size: 0, LastModified: modified, StorageClass: "GLACIER"},
+	}
+}
+
+func TestListingCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Listing(&buf, testObjects(), CSV); err != nil {
+		t.Fatalf("Listing() error = %v", err)
+	}
+	out := buf.String()
+
+	rows, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not valid CSV: %v\n%s", err, out)
+	}
+	want := [][]string{
+		{"key", "size", "size_human", "last_modified", "storage_class"},
+		{"data/q1.csv", "1500", "1.5 kB", "2024-03-01T17:30:00Z", "STANDARD"},
+		{`data/"north, south".csv`, "0", "0 B", "2024-03-01T17:30:00Z", "GLACIER"},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("got %d rows, want %d:\n%s", len(rows), len(want), out)
+	}
+	for i := range want {
+		if strings.Join(rows[i], "|") != strings.Join(want[i], "|") {
+			t.Errorf("row %d = %q, want %q", i, rows[i], want[i])
+		}
+	}
+	// Commas and quotes in keys are quoted, not split into extra columns
+	if !strings.Contains(out, `"data/""north, south"".csv"`) {
+		t.Errorf("key not escaped:\n%s", out)
+	}
+}
+
+func TestListingCSVNeutralizesFormulas(t *testing.T) {
+	var buf bytes.Buffer
+	objs := []aws.S3Object{{Key: "=HYPERLINK(\"http://evil\")", LastModified: modified}}
+	if err := Listing(&buf, objs, CSV); err != nil {
+		t.Fatalf("Listing() error = %v", err)
+	}
+	rows, _ := csv.NewReader(&buf).ReadAll()
+	if got := rows[1][0]; !strings.HasPrefix(got, "'=") {
+		t.Errorf("key = %q, want it prefixed with a quote", got)
+	}
+}
+
+func TestListingJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Listing(&buf, testObjects(), JSON); err != nil {
+		t.Fatalf("Listing() error = %v", err)
+	}
+
+	var got []record
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output is not a JSON array: %v\n%s", err, buf.String())
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d records, want 2 (folders skipped)", len(got))
+	}
+	want := record{Key: `data/"north, south".csv`, Size: 0, SizeHuman: "0 B", LastModified: "2024-03-01T17:30:00Z", StorageClass: "GLACIER"}
+	if got[1] != want {
+		t.Errorf("record = %+v, want %+v", got[1], want)
+	}
+
+	// An empty listing is an empty array, not null
+	buf.Reset()
+	if err := Listing(&buf, nil, JSON); err != nil {
+		t.Fatalf("Listing() error = %v", err)
+	}
+	if strings.TrimSpace(buf.String()) != "[]" {
+		t.Errorf("empty listing = %q, want []", buf.String())
+	}
+}
+
+func TestListingNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Listing(&buf, testObjects(), NDJSON); err != nil {
+		t.Fatalf("Listing() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2:\n%s", len(lines), buf.String())
+	}
+	for _, line := range lines {
+		var r record
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Errorf("line %q is not a JSON object: %v", line, err)
+		}
+	}
+}
+
+func TestFormatForPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want Format
+	}{
+		{"listing.csv", CSV},
+		{"listing.JSON", JSON},
+		{"listing.ndjson", NDJSON},
+		{"listing.jsonl", NDJSON},
+		{"listing", CSV},
+	}
+	for _, tt := range tests {
+		if got := FormatForPath(tt.path); got != tt.want {
+			t.Errorf("FormatForPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Error("ParseFormat(xml) should fail")
+	}
+}
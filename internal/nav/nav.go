@@ -0,0 +1,72 @@
+// Package nav tracks where the user is in the bucket and prefix hierarchy.
+package nav
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/natevick/stui/internal/aws"
+)
+
+// State is the current browsing location. An empty Bucket means the bucket
+// list; an empty Prefix means the bucket root.
+type State struct {
+	Bucket string
+	Prefix string
+}
+
+// AtBucketList reports whether no bucket is open
+func (s State) AtBucketList() bool {
+	return s.Bucket == ""
+}
+
+// URI returns the location as an s3:// URI, or "" at the bucket list
+func (s State) URI() string {
+	if s.AtBucketList() {
+		return ""
+	}
+	return aws.FormatS3URI(s.Bucket, s.Prefix)
+}
+
+// Parent returns the location one level up: "a/b/" goes to "a/", a
+// top-level prefix to the bucket root, and the bucket root to the bucket
+// list. The bucket list is its own parent. Prefixes are treated as folders
+// whether or not they end in "/".
+func (s State) Parent() State {
+	switch {
+	case s.AtBucketList():
+		return s
+	case s.Prefix == "":
+		return State{}
+	}
+	prefix := strings.TrimSuffix(s.Prefix, "/")
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		return State{Bucket: s.Bucket, Prefix: prefix[:i+1]}
+	}
+	return State{Bucket: s.Bucket}
+}
+
+// Ancestors returns the locations above s within its bucket, from the
+// bucket root down to the immediate parent. The bucket root and the bucket
+// list have none.
+func (s State) Ancestors() []State {
+	var ancestors []State
+	for p := s.Parent(); !p.AtBucketList() && p != s; p = p.Parent() {
+		ancestors = append(ancestors, p)
+		s = p
+	}
+	slices.Reverse(ancestors)
+	return ancestors
+}
+
+// Breadcrumb returns the folder names along the prefix, skipping the empty
+// segments a doubled "/" leaves
+func (s State) Breadcrumb() []string {
+	var segments []string
+	for _, part := range strings.Split(s.Prefix, "/") {
+		if part != "" {
+			segments = append(segments, part)
+		}
+	}
+	return segments
+}
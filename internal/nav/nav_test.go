@@ -0,0 +1,82 @@
+package nav
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestStateURI(t *testing.T) {
+	tests := []struct {
+		state State
+		want  string
+	}{
+		{State{}, ""},
+		{State{Bucket: "b"}, "s3://b"},
+		{State{Bucket: "b", Prefix: "logs/2024/"}, "s3://b/logs/2024/"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.state.URI(); got != tt.want {
+			t.Errorf("%+v.URI() = %q, want %q", tt.state, got, tt.want)
+		}
+	}
+}
+
+func TestStateParent(t *testing.T) {
+	tests := []struct {
+		name  string
+		state State
+		want  State
+	}{
+		{"deep prefix", State{Bucket: "b", Prefix: "logs/2024/05/"}, State{Bucket: "b", Prefix: "logs/2024/"}},
+		{"single-level prefix", State{Bucket: "b", Prefix: "logs/"}, State{Bucket: "b"}},
+		{"prefix without trailing slash", State{Bucket: "b", Prefix: "logs/2024"}, State{Bucket: "b", Prefix: "logs/"}},
+		{"single segment without slash", State{Bucket: "b", Prefix: "logs"}, State{Bucket: "b"}},
+		{"empty segment", State{Bucket: "b", Prefix: "a//"}, State{Bucket: "b", Prefix: "a/"}},
+		{"bucket root", State{Bucket: "b"}, State{}},
+		{"bucket list", State{}, State{}},
+	}
+
+	for _, tt := range tests {
+		if got := tt.state.Parent(); got != tt.want {
+			t.Errorf("%s: %+v.Parent() = %+v, want %+v", tt.name, tt.state, got, tt.want)
+		}
+	}
+}
+
+func TestStateAncestors(t *testing.T) {
+	tests := []struct {
+		name  string
+		state State
+		want  []State
+	}{
+		{"deep prefix", State{Bucket: "b", Prefix: "a/b/c/"}, []State{{Bucket: "b"}, {Bucket: "b", Prefix: "a/"}, {Bucket: "b", Prefix: "a/b/"}}},
+		{"without trailing slash", State{Bucket: "b", Prefix: "a/b"}, []State{{Bucket: "b"}, {Bucket: "b", Prefix: "a/"}}},
+		{"top-level prefix", State{Bucket: "b", Prefix: "a/"}, []State{{Bucket: "b"}}},
+		{"bucket root", State{Bucket: "b"}, nil},
+		{"bucket list", State{}, nil},
+	}
+
+	for _, tt := range tests {
+		if got := tt.state.Ancestors(); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: Ancestors() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStateBreadcrumb(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   []string
+	}{
+		{"a/b/c/", []string{"a", "b", "c"}},
+		{"a//b", []string{"a", "b"}},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		if got := (State{Bucket: "b", Prefix: tt.prefix}).Breadcrumb(); !slices.Equal(got, tt.want) {
+			t.Errorf("Breadcrumb(%q) = %q, want %q", tt.prefix, got, tt.want)
+		}
+	}
+}
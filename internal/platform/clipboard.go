@@ -0,0 +1,59 @@
+package platform
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Clipboard receives copied text
+type Clipboard interface {
+	Copy(text string) error
+}
+
+// SystemClipboard copies through the OS clipboard tool: pbcopy on macOS,
+// clip on Windows, and wl-copy, xclip, or xsel elsewhere
+type SystemClipboard struct{}
+
+// errNoClipboardTool is returned when no clipboard tool is installed
+var errNoClipboardTool = errors.New("no clipboard tool found (install wl-copy, xclip, or xsel)")
+
+// copyCommand builds the OS command that reads clipboard text from stdin
+var copyCommand = func() (*exec.Cmd, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("pbcopy"), nil
+	case "windows":
+		return exec.Command("clip"), nil
+	}
+
+	candidates := [][]string{
+		{"xclip", "-selection", "clipboard"},
+		{"xsel", "--clipboard", "--input"},
+	}
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		candidates = append([][]string{{"wl-copy"}}, candidates...)
+	}
+	for _, c := range candidates {
+		if _, err := exec.LookPath(c[0]); err == nil {
+			return exec.Command(c[0], c[1:]...), nil
+		}
+	}
+	return nil, errNoClipboardTool
+}
+
+// Copy replaces the clipboard contents with text
+func (SystemClipboard) Copy(text string) error {
+	cmd, err := copyCommand()
+	if err != nil {
+		return err
+	}
+	cmd.Stdin = strings.NewReader(text)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to copy to clipboard: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package platform
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestSystemClipboardCopy(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "clip")
+	orig := copyCommand
+	defer func() { copyCommand = orig }()
+	copyCommand = func() (*exec.Cmd, error) {
+		return exec.Command("sh", "-c", `cat > "$0"`, out), nil
+	}
+
+	if err := (SystemClipboard{}).Copy("s3://b/a.txt\ns3://b/b.txt"); err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "s3://b/a.txt\ns3://b/b.txt" {
+		t.Errorf("clipboard = %q", got)
+	}
+
+	copyCommand = func() (*exec.Cmd, error) { return nil, errNoClipboardTool }
+	if err := (SystemClipboard{}).Copy("x"); err != errNoClipboardTool {
+		t.Errorf("Copy() without a tool error = %v, want errNoClipboardTool", err)
+	}
+}
@@ -0,0 +1,54 @@
+package platform
+
+import (
+	"fmt"
+	"net/url"
+	"os/exec"
+	"runtime"
+)
+
+// openCommand builds the OS command that opens a URL in the default browser
+var openCommand = func(target string) *exec.Cmd {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", target)
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", target)
+	default:
+		return exec.Command("xdg-open", target)
+	}
+}
+
+// ValidBrowserURL checks that a URL is safe to hand to the OS opener.
+// Only absolute https URLs are allowed so a crafted value can't launch
+// a local file or another protocol handler.
+func ValidBrowserURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+	if u.Scheme != "https" {
+		return fmt.Errorf("refusing to open non-https URL")
+	}
+	if u.Host == "" {
+		return fmt.Errorf("URL has no host")
+	}
+	return nil
+}
+
+// OpenInBrowser opens an https URL in the user's default browser
+func OpenInBrowser(target string) error {
+	if err := ValidBrowserURL(target); err != nil {
+		return err
+	}
+
+	cmd := openCommand(target)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to open browser: %w", err)
+	}
+
+	// Reap the opener without blocking the caller
+	go cmd.Wait()
+
+	return nil
+}
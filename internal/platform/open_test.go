@@ -0,0 +1,45 @@
+package platform
+
+import (
+	"os/exec"
+	"testing"
+)
+
+func TestOpenInBrowser(t *testing.T) {
+	var opened []string
+	orig := openCommand
+	defer func() { openCommand = orig }()
+	openCommand = func(target string) *exec.Cmd {
+		opened = append(opened, target)
+		return exec.Command("true")
+	}
+
+	tests := []struct {
+		name    string
+		url     string
+		wantErr bool
+	}{
+		{"https console url", "https://s3.console.aws.amazon.com/s3/object/b?region=us-east-1", false},
+		{"http rejected", "http://example.com", true},
+		{"file rejected", "file:///etc/passwd", true},
+		{"javascript rejected", "javascript:alert(1)", true},
+		{"relative rejected", "/s3/object/b", true},
+		{"no host rejected", "https:///path", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opened = nil
+			err := OpenInBrowser(tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("OpenInBrowser(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+			}
+			if tt.wantErr && len(opened) != 0 {
+				t.Errorf("opener was invoked for rejected URL %q", tt.url)
+			}
+			if !tt.wantErr && (len(opened) != 1 || opened[0] != tt.url) {
+				t.Errorf("expected opener to receive %q, got %v", tt.url, opened)
+			}
+		})
+	}
+}
@@ -1,10 +1,17 @@
 package security
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
 )
 
 // Input validation constants
@@ -13,6 +20,7 @@ const (
 	MaxProfileNameLen  = 128
 	MaxBucketNameLen   = 63
 	MaxPathLen         = 4096
+	MaxObjectKeyLen    = 1024
 )
 
 // ValidBookmarkName validates a bookmark name
@@ -45,14 +53,64 @@ func ValidProfileName(name string) error {
 	return nil
 }
 
-// ValidBucketName validates an S3 bucket name
+// DirectoryBucketSuffix ends every S3 Express One Zone directory bucket name
+const DirectoryBucketSuffix = "--x-s3"
+
+// IsDirectoryBucket reports whether name is an S3 Express One Zone directory bucket
+func IsDirectoryBucket(name string) bool {
+	return strings.HasSuffix(name, DirectoryBucketSuffix)
+}
+
+// IsAccessPointARN reports whether name is an ARN rather than a bucket name
+func IsAccessPointARN(name string) bool {
+	return arn.IsARN(name)
+}
+
+// ParseARN parses an S3 Access Point ARN such as
+// arn:aws:s3:us-west-2:123456789012:accesspoint/my-ap.
+// Errors don't repeat the ARN, since it contains the account ID.
+func ParseARN(s string) (arn.ARN, error) {
+	a, err := arn.Parse(s)
+	if err != nil {
+		return arn.ARN{}, fmt.Errorf("invalid ARN format")
+	}
+	if a.Service != "s3" {
+		return arn.ARN{}, fmt.Errorf("ARN is not an S3 ARN")
+	}
+	if a.Region == "" || !regexp.MustCompile(`^\d{12}$`).MatchString(a.AccountID) {
+		return arn.ARN{}, fmt.Errorf("access point ARN needs a region and a 12-digit account ID")
+	}
+	name, ok := strings.CutPrefix(a.Resource, "accesspoint/")
+	if !ok {
+		return arn.ARN{}, fmt.Errorf("ARN is not an access point ARN")
+	}
+	if !regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,48}[a-z0-9]$`).MatchString(name) {
+		return arn.ARN{}, fmt.Errorf("invalid access point name format")
+	}
+	return a, nil
+}
+
+// ValidBucketName validates an S3 bucket name. Access Point ARNs are
+// accepted in place of a name and checked with ParseARN; Access Point
+// aliases (my-ap-...-s3alias) already follow bucket naming rules.
 func ValidBucketName(name string) error {
 	if name == "" {
 		return nil // Empty is allowed
 	}
+	if IsAccessPointARN(name) {
+		_, err := ParseARN(name)
+		return err
+	}
 	if len(name) < 3 || len(name) > MaxBucketNameLen {
 		return fmt.Errorf("bucket name must be 3-%d characters", MaxBucketNameLen)
 	}
+	// Directory buckets are base-name--zone-id--x-s3, e.g. logs--usw2-az1--x-s3
+	if IsDirectoryBucket(name) {
+		if !regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?--[a-z0-9]+(-[a-z0-9]+)+--x-s3$`).MatchString(name) {
+			return fmt.Errorf("invalid directory bucket name format")
+		}
+		return nil
+	}
 	// S3 bucket naming rules (simplified)
 	if !regexp.MustCompile(`^[a-z0-9][a-z0-9.-]*[a-z0-9]$`).MatchString(name) {
 		return fmt.Errorf("invalid bucket name format")
@@ -60,6 +118,76 @@ func ValidBucketName(name string) error {
 	return nil
 }
 
+// ValidObjectKey validates an S3 object key
+func ValidObjectKey(key string) error {
+	if len(key) == 0 {
+		return fmt.Errorf("object key cannot be empty")
+	}
+	if len(key) > MaxObjectKeyLen {
+		return fmt.Errorf("object key too long (max %d bytes)", MaxObjectKeyLen)
+	}
+	if !utf8.ValidString(key) {
+		return fmt.Errorf("object key must be valid UTF-8")
+	}
+	for _, r := range key {
+		if unicode.IsControl(r) {
+			return fmt.Errorf("object key contains control characters")
+		}
+	}
+	return nil
+}
+
+// ErrAmbiguousKey is returned for proposed keys with empty, "." or ".."
+// segments. S3 stores them literally, but local paths, URLs and consoles
+// resolve them, so the object ends up addressed as a different key.
+var ErrAmbiguousKey = errors.New(`object key has "//", "./" or "../" segments`)
+
+// NormalizeObjectKey resolves the path-like segments of a key: "//" and a
+// leading "/" collapse, "." segments are dropped and ".." removes the
+// segment before it. A trailing "/" (a folder) is kept. It fails when ".."
+// climbs above the bucket root or nothing is left.
+func NormalizeObjectKey(key string) (string, error) {
+	var segments []string
+	for _, seg := range strings.Split(key, "/") {
+		switch seg {
+		case "", ".":
+		case "..":
+			if len(segments) == 0 {
+				return "", fmt.Errorf("%w: %q climbs above the bucket root", ErrAmbiguousKey, key)
+			}
+			segments = segments[:len(segments)-1]
+		default:
+			segments = append(segments, seg)
+		}
+	}
+	if len(segments) == 0 {
+		return "", fmt.Errorf("%w: %q names no object", ErrAmbiguousKey, key)
+	}
+	normalized := strings.Join(segments, "/")
+	if strings.HasSuffix(key, "/") {
+		normalized += "/"
+	}
+	return normalized, nil
+}
+
+// ValidNewObjectKey validates a key a rename, move or upload is about to
+// create. On top of ValidObjectKey it rejects keys NormalizeObjectKey would
+// change, suggesting the normalized form. Existing keys are never checked
+// this way, so objects that already have such names stay reachable.
+func ValidNewObjectKey(key string) error {
+	if err := ValidObjectKey(key); err != nil {
+		return err
+	}
+	normalized, err := NormalizeObjectKey(key)
+	if err != nil {
+		return err
+	}
+	if normalized != key {
+		return fmt.Errorf("%w: %q (did you mean %q?)", ErrAmbiguousKey, key, normalized)
+	}
+	return nil
+}
+
 // SafePath validates that a path stays within the base directory
 // Returns the cleaned absolute path or an error if path traversal is detected
 func SafePath(baseDir, relativePath string) (string, error) {
@@ -98,31 +226,117 @@ func SafePath(baseDir, relativePath string) (string, error) {
 	return absPath, nil
 }
 
-// SanitizeError removes sensitive information from error messages
+// SafePathAny validates that a path lies within one of several allowed
+// roots. An absolute path must already be inside a root; a relative path is
+// resolved against each root in turn and the first that contains it wins.
+// Each candidate goes through SafePath, so traversal is rejected the same way.
+func SafePathAny(roots []string, relOrAbs string) (string, error) {
+	if len(roots) == 0 {
+		return "", fmt.Errorf("no allowed local roots configured")
+	}
+
+	for _, root := range roots {
+		rel := relOrAbs
+		if filepath.IsAbs(relOrAbs) {
+			absRoot, err := filepath.Abs(root)
+			if err != nil {
+				continue
+			}
+			if rel, err = filepath.Rel(absRoot, relOrAbs); err != nil {
+				continue
+			}
+		}
+		if path, err := SafePath(root, rel); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("path is outside the allowed local roots")
+}
+
+// Patterns SanitizeError redacts
+var (
+	accessKeyPattern = regexp.MustCompile(`(AKIA|ASIA)[A-Z0-9]{16}`)
+	// Secret keys, session tokens and presigned URL signatures given as
+	// name=value or name: value
+	secretPattern    = regexp.MustCompile(`(?i)(aws_secret_access_key|secret_?access_?key|aws_session_token|session_?token|x-amz-security-token|x-amz-signature|x-amz-credential)(["']?\s*[:=]\s*["']?)[^\s"'&,]+`)
+	accountIDPattern = regexp.MustCompile(`\b\d{12}\b`)
+	arnPattern       = regexp.MustCompile(`arn:aws:[^:\s]+:[^:\s]*:[^:\s]*:[^\s]+`)
+	bucketPattern    = regexp.MustCompile(`bucket[:\s]+['"]?([a-z0-9.-]+)['"]?`)
+	usersPathPattern = regexp.MustCompile(`/Users/[^/\s]+`)
+	homePathPattern  = regexp.MustCompile(`/home/[^/\s]+`)
+
+	// Bucket names in S3 URIs, virtual-hosted and path-style endpoints, and
+	// after "bucket" in any case
+	s3URIPattern       = regexp.MustCompile(`s3://[^/\s]+`)
+	virtualHostPattern = regexp.MustCompile(`\b[a-z0-9][a-z0-9.-]*[a-z0-9]\.s3[.-]([a-z0-9-]+\.)?amazonaws\.com`)
+	pathStylePattern   = regexp.MustCompile(`(\bs3[.-]([a-z0-9-]+\.)?amazonaws\.com)/[^/\s?]+`)
+	anyBucketPattern   = regexp.MustCompile(`(?i)(bucket(?:name)?["']?\s*[:=\s]\s*["']?)([a-z0-9][a-z0-9.-]*[a-z0-9])`)
+	base64TokenPattern = regexp.MustCompile(`[A-Za-z0-9+/_-]{20,}={0,2}`)
+)
+
+// SanitizeError removes sensitive information from error messages, as much
+// as the level set with SetStrictness asks for
 func SanitizeError(err error) string {
 	if err == nil {
 		return ""
 	}
+	return sanitize(err.Error(), CurrentStrictness())
+}
 
-	msg := err.Error()
-
-	// Remove potential AWS account IDs (12 digits)
-	msg = regexp.MustCompile(`\b\d{12}\b`).ReplaceAllString(msg, "[account-id]")
+func sanitize(msg string, level Strictness) string {
+	// Secrets and access keys go at every level
+	msg = accessKeyPattern.ReplaceAllString(msg, "[access-key]")
+	msg = secretPattern.ReplaceAllString(msg, "${1}${2}[secret]")
+	if level == Minimal {
+		return msg
+	}
 
-	// Remove potential ARNs
-	msg = regexp.MustCompile(`arn:aws:[^:\s]+:[^:\s]*:[^:\s]*:[^\s]+`).ReplaceAllString(msg, "[arn]")
+	// Remove potential ARNs, then AWS account IDs (12 digits)
+	msg = arnPattern.ReplaceAllString(msg, "[arn]")
+	msg = accountIDPattern.ReplaceAllString(msg, "[account-id]")
 
 	// Remove S3 bucket names in common error patterns
-	msg = regexp.MustCompile(`bucket[:\s]+['"]?([a-z0-9.-]+)['"]?`).ReplaceAllString(msg, "bucket: [bucket]")
-
-	// Remove access key IDs
-	msg = regexp.MustCompile(`AKIA[A-Z0-9]{16}`).ReplaceAllString(msg, "[access-key]")
+	msg = bucketPattern.ReplaceAllString(msg, "bucket: [bucket]")
 
 	// Remove full file paths that might be sensitive
-	msg = regexp.MustCompile(`/Users/[^/\s]+`).ReplaceAllString(msg, "/Users/[user]")
-	msg = regexp.MustCompile(`/home/[^/\s]+`).ReplaceAllString(msg, "/home/[user]")
+	msg = usersPathPattern.ReplaceAllString(msg, "/Users/[user]")
+	msg = homePathPattern.ReplaceAllString(msg, "/home/[user]")
+	if level != Paranoid {
+		return msg
+	}
+
+	msg = s3URIPattern.ReplaceAllString(msg, "s3://[bucket]")
+	msg = virtualHostPattern.ReplaceAllStringFunc(msg, func(host string) string {
+		return "[bucket]" + host[strings.Index(host, ".s3"):]
+	})
+	msg = pathStylePattern.ReplaceAllString(msg, "${1}/[bucket]")
+	msg = anyBucketPattern.ReplaceAllString(msg, "${1}[bucket]")
+	return base64TokenPattern.ReplaceAllStringFunc(msg, func(token string) string {
+		if looksEncoded(token) {
+			return "[token]"
+		}
+		return token
+	})
+}
 
-	return msg
+// looksEncoded reports whether a run of base64 characters is more likely a
+// token than a word or path: it mixes cases and has digits, or is padded
+func looksEncoded(s string) bool {
+	if strings.HasSuffix(s, "=") {
+		return true
+	}
+	var upper, lower, digit bool
+	for _, r := range s {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		}
+	}
+	return upper && lower && digit
 }
 
 // SanitizeErrorGeneric provides a user-friendly error without details
@@ -134,7 +348,25 @@ func SanitizeErrorGeneric(err error, context string) string {
 	// Check for common AWS error types and provide friendly messages
 	errStr := strings.ToLower(err.Error())
 
+	// SDK v2 errors name the operation and HTTP status; lead with those
+	// rather than the request IDs and raw response that follow
+	if op, status, ok := parseOperationError(err.Error()); ok {
+		return fmt.Sprintf("%s: %s failed: %s (%d)", context, op, describeStatus(errStr, status), status)
+	}
+
 	switch {
+	case isKMSError(errStr):
+		return fmt.Sprintf("%s: access denied to the object's encryption key — check KMS permissions", context)
+	case strings.Contains(errStr, "invalidaccesskeyid"):
+		return fmt.Sprintf("%s: %s", context, msgInvalidAccessKey)
+	case strings.Contains(errStr, "signaturedoesnotmatch"):
+		return fmt.Sprintf("%s: %s", context, msgSignatureMismatch)
+	case strings.Contains(errStr, "bucketalreadyownedbyyou"):
+		return fmt.Sprintf("%s: %s", context, msgBucketAlreadyOwned)
+	case strings.Contains(errStr, "bucketalreadyexists"):
+		return fmt.Sprintf("%s: %s", context, msgBucketNameTaken)
+	case strings.Contains(errStr, "illegallocationconstraintexception"):
+		return fmt.Sprintf("%s: %s", context, msgRegionMismatch)
 	case strings.Contains(errStr, "access denied") || strings.Contains(errStr, "accessdenied"):
 		return fmt.Sprintf("%s: access denied - check your permissions", context)
 	case strings.Contains(errStr, "no such bucket") || strings.Contains(errStr, "nosuchbucket"):
@@ -153,3 +385,104 @@ func SanitizeErrorGeneric(err error, context string) string {
 		return fmt.Sprintf("%s: %s", context, SanitizeError(err))
 	}
 }
+
+// Messages for credentials S3 rejected outright. A bad secret key and a
+// skewed clock both break the signature, so the hint names both.
+const (
+	msgInvalidAccessKey  = "access key is invalid — check your profile"
+	msgSignatureMismatch = "request signature invalid — check your secret key or system clock"
+)
+
+// Messages for creating a bucket that can't be. Owning it already is
+// harmless; a name taken by another account never frees up for this one; and
+// S3 refuses a location constraint the endpoint's region doesn't match.
+const (
+	msgBucketAlreadyOwned = "you already own this bucket"
+	msgBucketNameTaken    = "bucket name is taken by another account — choose a different name"
+	msgRegionMismatch     = "region mismatch — your endpoint/region settings conflict with the requested bucket region"
+)
+
+// operationErrorPattern matches the SDK v2 wrapper, e.g. "operation error S3:
+// GetObject, https response error StatusCode: 403, RequestID: ..."
+var operationErrorPattern = regexp.MustCompile(`operation error [\w ]+: (\w+), https response error StatusCode: (\d{3})`)
+
+// parseOperationError extracts the operation name and HTTP status from an
+// SDK v2 error message
+func parseOperationError(msg string) (op string, status int, ok bool) {
+	m := operationErrorPattern.FindStringSubmatch(msg)
+	if m == nil {
+		return "", 0, false
+	}
+	status, err := strconv.Atoi(m[2])
+	if err != nil {
+		return "", 0, false
+	}
+	return m[1], status, true
+}
+
+// describeStatus explains an HTTP status, using the lowercased error message
+// to tell apart failures that share one
+func describeStatus(errStr string, status int) string {
+	switch {
+	case isKMSError(errStr):
+		return "access denied to the object's encryption key"
+	case strings.Contains(errStr, "invalidaccesskeyid"):
+		return msgInvalidAccessKey
+	case strings.Contains(errStr, "signaturedoesnotmatch"):
+		return msgSignatureMismatch
+	case strings.Contains(errStr, "bucketalreadyownedbyyou"):
+		return msgBucketAlreadyOwned
+	case strings.Contains(errStr, "bucketalreadyexists"):
+		return msgBucketNameTaken
+	case strings.Contains(errStr, "illegallocationconstraintexception"):
+		return msgRegionMismatch
+	case strings.Contains(errStr, "expiredtoken") || strings.Contains(errStr, "token has expired"):
+		return "credentials expired - run 'aws sso login'"
+	}
+
+	switch status {
+	case http.StatusMovedPermanently:
+		return "bucket is in another region"
+	case http.StatusForbidden:
+		return "access denied"
+	case http.StatusNotFound:
+		switch {
+		case strings.Contains(errStr, "nosuchbucket"):
+			return "bucket not found"
+		case strings.Contains(errStr, "nosuchkey"):
+			return "object not found"
+		}
+		return "not found"
+	case http.StatusPreconditionFailed:
+		return "object changed since it was read"
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return "throttled - try again shortly"
+	}
+	if status >= 500 {
+		return "S3 service error"
+	}
+	if text := http.StatusText(status); text != "" {
+		return strings.ToLower(text)
+	}
+	return "request failed"
+}
+
+// kmsErrorSignatures identify failures caused by the object's KMS key rather than S3 permissions
+var kmsErrorSignatures = []string{
+	"kms:decrypt",
+	"kms:generatedatakey",
+	"kms.notfoundexception",
+	"kms.disabledexception",
+	"kms.accessdeniedexception",
+	"kms.kmsinvalidstateexception",
+}
+
+// isKMSError reports whether a lowercased error message is a KMS key access failure
+func isKMSError(errStr string) bool {
+	for _, sig := range kmsErrorSignatures {
+		if strings.Contains(errStr, sig) {
+			return true
+		}
+	}
+	return false
+}
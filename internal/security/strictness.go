@@ -0,0 +1,60 @@
+package security
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// Strictness is how much SanitizeError redacts
+type Strictness int32
+
+const (
+	// Standard redacts secrets, account IDs, ARNs, bucket names after
+	// "bucket:", and home directory user names
+	Standard Strictness = iota
+	// Minimal redacts only secrets and access keys, for private local logs
+	Minimal
+	// Paranoid also redacts every bucket name it can recognize and anything
+	// that looks like a base64 token, for text that will be shared
+	Paranoid
+)
+
+// String returns the level's name as ParseStrictness accepts it
+func (s Strictness) String() string {
+	switch s {
+	case Minimal:
+		return "minimal"
+	case Paranoid:
+		return "paranoid"
+	default:
+		return "standard"
+	}
+}
+
+// ParseStrictness parses a level name as written by String.
+// An empty name is Standard.
+func ParseStrictness(name string) (Strictness, error) {
+	switch strings.ToLower(name) {
+	case "", "standard":
+		return Standard, nil
+	case "minimal":
+		return Minimal, nil
+	case "paranoid":
+		return Paranoid, nil
+	}
+	return Standard, fmt.Errorf("unknown redaction level %q", name)
+}
+
+// strictness is the level SanitizeError uses; the zero value is Standard
+var strictness atomic.Int32
+
+// SetStrictness sets how much SanitizeError redacts from now on
+func SetStrictness(s Strictness) {
+	strictness.Store(int32(s))
+}
+
+// CurrentStrictness returns the level SanitizeError uses
+func CurrentStrictness() Strictness {
+	return Strictness(strictness.Load())
+}
@@ -0,0 +1,44 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/natevick/stui/internal/aws"
+	"github.com/natevick/stui/internal/config"
+	"github.com/natevick/stui/internal/views/profiles"
+)
+
+func TestBucketPagesFromAnOlderLoadAreDropped(t *testing.T) {
+	m := New(Config{Profile: "dev", Settings: config.Default()})
+	m.client = &aws.Client{Profile: "dev"}
+
+	// Two reloads; building the commands starts nothing
+	m.loadBuckets()
+	m.loadBuckets()
+
+	updated, _ := m.Update(BucketsLoadedMsg{Buckets: []aws.Bucket{{Name: "current"}}, load: m.bucketLoad})
+	m = updated.(Model)
+	// The first reload's first page arrives last
+	updated, _ = m.Update(BucketsLoadedMsg{Buckets: []aws.Bucket{{Name: "stale"}}, load: m.bucketLoad - 1})
+	m = updated.(Model)
+
+	if got := m.bucketsView.SelectedBucket(); got != "current" {
+		t.Errorf("buckets show %q, want the latest load's", got)
+	}
+}
+
+func TestBucketPagesFromTheOldProfileAreDropped(t *testing.T) {
+	m := New(Config{Profile: "dev", Settings: config.Default()})
+	m.client = &aws.Client{Profile: "dev"}
+	m.loadBuckets()
+	devLoad := m.bucketLoad
+
+	updated, _ := m.Update(profiles.SelectedMsg{Profile: "prod"})
+	m = updated.(Model)
+	updated, _ = m.Update(BucketsLoadedMsg{Buckets: []aws.Bucket{{Name: "dev-bucket"}}, load: devLoad})
+	m = updated.(Model)
+
+	if got := m.bucketsView.SelectedBucket(); got != "" {
+		t.Errorf("buckets show %q from the old profile after switching", got)
+	}
+}
@@ -0,0 +1,27 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/natevick/stui/internal/aws"
+	"github.com/natevick/stui/internal/config"
+)
+
+func TestDownloadPromptSuggestsFreeNameUnderAllowedRoot(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "report.csv"), []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	settings := config.Default()
+	settings.AllowedLocalRoots = []string{root}
+	m := New(Config{Profile: "default", Settings: settings})
+
+	// "./report.csv" is saved under the root, where it is taken
+	m.showDownloadPrompt(aws.S3Object{Key: "reports/report.csv"})
+	if want := "./report (1).csv"; m.promptDefault != want {
+		t.Errorf("promptDefault = %q, want %q", m.promptDefault, want)
+	}
+}
@@ -0,0 +1,175 @@
+package tui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/natevick/stui/internal/aws"
+)
+
+// maxEditLines is the most lines the editor's textarea holds
+const maxEditLines = 9999
+
+// editLoadedMsg carries an object fetched for editing
+type editLoadedMsg struct {
+	buf *aws.EditBuffer
+	err error
+}
+
+// editSavedMsg is sent when an edited object has been written back
+type editSavedMsg struct {
+	key string
+	err error
+}
+
+// loadEdit fetches a small text object of the current bucket for editing
+func (m Model) loadEdit(key string) tea.Cmd {
+	return func() tea.Msg {
+		if m.demoMode {
+			return ErrorMsg{Err: errDemoMode}
+		}
+		if m.client == nil {
+			return nil
+		}
+		buf, err := m.client.EditObject(m.ctx, m.currentBucket, key, 0)
+		return editLoadedMsg{buf: buf, err: err}
+	}
+}
+
+// saveEdit writes the editor's text back over the object it was read from
+func (m Model) saveEdit(buf *aws.EditBuffer, body []byte) tea.Cmd {
+	return func() tea.Msg {
+		if m.client == nil {
+			return nil
+		}
+		return editSavedMsg{key: buf.Key, err: m.client.SaveEdit(m.ctx, buf, body)}
+	}
+}
+
+// editableText reports why text can't go through the editor unchanged: the
+// textarea turns tabs into spaces and CRLF into LF, and caps the line count
+func editableText(body []byte) error {
+	switch {
+	case strings.ContainsRune(string(body), '\t'):
+		return errors.New("it contains tabs, which the editor would turn into spaces")
+	case strings.ContainsRune(string(body), '\r'):
+		return errors.New("it has CRLF line endings, which the editor would change")
+	case strings.Count(string(body), "\n") >= maxEditLines:
+		return fmt.Errorf("it has more than %d lines", maxEditLines)
+	}
+	return nil
+}
+
+// checkEditedText refuses to save a .json object that no longer parses
+func checkEditedText(key string, body []byte) error {
+	if strings.EqualFold(path.Ext(key), ".json") && !json.Valid(body) {
+		return errors.New("not valid JSON")
+	}
+	return nil
+}
+
+// openEditor shows buf in the editor overlay
+func (m *Model) openEditor(buf *aws.EditBuffer) tea.Cmd {
+	ta := textarea.New()
+	ta.MaxHeight = maxEditLines
+	// Blink messages aren't routed to the editor, so keep the cursor solid
+	ta.Cursor.SetMode(cursor.CursorStatic)
+	ta.SetWidth(max(m.width-8, 20))
+	ta.SetHeight(max(m.height-10, 5))
+	ta.SetValue(string(buf.Body))
+	// SetValue leaves the cursor at the end
+	for ta.Line() > 0 {
+		ta.CursorUp()
+	}
+	ta.CursorStart()
+
+	m.editor = ta
+	m.editBuf = buf
+	m.showEditor = true
+	m.showHelp = false
+	m.showErrors = false
+	m.showPreview = false
+	return m.editor.Focus()
+}
+
+// closeEditor hides the editor and drops its buffer
+func (m *Model) closeEditor() {
+	m.showEditor = false
+	m.editSaving = false
+	m.editBuf = nil
+	m.editor.Blur()
+}
+
+// handleEditorKey sends keys to the textarea; Ctrl+S saves and Esc discards
+func (m Model) handleEditorKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		if m.editSaving {
+			return m, nil
+		}
+		m.closeEditor()
+		m.statusMsg = "Edit discarded"
+		return m, nil
+
+	case "ctrl+s":
+		if m.editSaving {
+			return m, nil
+		}
+		body := []byte(m.editor.Value())
+		if err := checkEditedText(m.editBuf.Key, body); err != nil {
+			m.statusMsg = "Not saved: " + err.Error()
+			return m, nil
+		}
+		m.statusMsg = "Saving..."
+		m.editSaving = true
+		return m, m.saveEdit(m.editBuf, body)
+
+	case "ctrl+c":
+		// Don't let a habitual Ctrl+C quit with unsaved edits
+		m.statusMsg = "Press Esc to discard the edit or Ctrl+S to save"
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.editor, cmd = m.editor.Update(msg)
+	return m, cmd
+}
+
+func (m Model) renderWithEditor() string {
+	editorStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ColorPrimary).
+		Padding(1, 2).
+		Width(m.width - 4)
+
+	status := "Ctrl+S save • Esc discard"
+	if m.statusMsg != "" {
+		status = displaySafe(m.statusMsg) + " • " + status
+	}
+
+	panel := editorStyle.Render(lipgloss.JoinVertical(
+		lipgloss.Left,
+		m.styles.Title.Render("Editing "+displaySafe(aws.FormatS3URI(m.editBuf.Bucket, m.editBuf.Key))),
+		"",
+		m.editor.View(),
+		"",
+		m.styles.Dim.Render(status),
+	))
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		panel,
+		lipgloss.WithWhitespaceChars(" "),
+		lipgloss.WithWhitespaceForeground(lipgloss.Color("0")),
+	)
+}
@@ -0,0 +1,88 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/natevick/stui/internal/aws"
+	"github.com/natevick/stui/internal/config"
+)
+
+func TestEditableText(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		ok   bool
+	}{
+		{"yaml", "replicas: 2\nimage: app:1.4\n", true},
+		{"tabs", "{\n\t\"a\": 1\n}", false},
+		{"crlf", "a: 1\r\nb: 2\r\n", false},
+		{"too many lines", strings.Repeat("x\n", maxEditLines), false},
+	}
+	for _, tt := range tests {
+		if err := editableText([]byte(tt.body)); (err == nil) != tt.ok {
+			t.Errorf("%s: editableText() error = %v, want ok=%v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+// openedEditor returns a model editing body as key
+func openedEditor(t *testing.T, key, body string) Model {
+	t.Helper()
+	m := New(Config{Profile: "default", Settings: config.Default()})
+	buf := &aws.EditBuffer{Bucket: "b", Key: key, Body: []byte(body), ETag: "abc"}
+	updated, _ := m.Update(editLoadedMsg{buf: buf})
+	m = updated.(Model)
+	if !m.showEditor {
+		t.Fatalf("editor not shown (status %q)", m.statusMsg)
+	}
+	return m
+}
+
+func TestEditorKeepsEditOnConflict(t *testing.T) {
+	m := openedEditor(t, "app.yaml", "replicas: 2\n")
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("# ")})
+	m = updated.(Model)
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+	m = updated.(Model)
+	if cmd == nil || !m.editSaving {
+		t.Fatal("Ctrl+S didn't start a save")
+	}
+
+	err := &aws.OpError{Op: "Saving object", Bucket: "b", Key: "app.yaml", Err: fmt.Errorf("%w: PreconditionFailed", aws.ErrPreconditionFailed)}
+	updated, _ = m.Update(editSavedMsg{key: "app.yaml", err: err})
+	m = updated.(Model)
+	if !m.showEditor {
+		t.Fatal("a conflict closed the editor and lost the edit")
+	}
+	if got := m.editor.Value(); got != "# replicas: 2\n" {
+		t.Errorf("editor = %q, want the edit kept", got)
+	}
+	if !strings.Contains(m.statusMsg, "changed since you opened it") {
+		t.Errorf("statusMsg = %q, want a conflict notice", m.statusMsg)
+	}
+
+	updated, _ = m.Update(editSavedMsg{key: "app.yaml"})
+	m = updated.(Model)
+	if m.showEditor {
+		t.Error("editor still open after a successful save")
+	}
+}
+
+func TestEditorRefusesInvalidJSON(t *testing.T) {
+	m := openedEditor(t, "settings.json", `{"debug": false}`)
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("{")})
+	m = updated.(Model)
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+	m = updated.(Model)
+	if cmd != nil || m.editSaving {
+		t.Error("Ctrl+S saved invalid JSON")
+	}
+	if !strings.HasPrefix(m.statusMsg, "Not saved") {
+		t.Errorf("statusMsg = %q, want a refusal", m.statusMsg)
+	}
+}
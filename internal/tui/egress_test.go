@@ -0,0 +1,100 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/natevick/stui/internal/aws"
+	"github.com/natevick/stui/internal/config"
+)
+
+func TestEgressCheckPrompt(t *testing.T) {
+	large := []aws.S3Object{{Key: "exports/dump.tar", Size: 3 << 30}}
+	small := []aws.S3Object{{Key: "exports/notes.txt", Size: 1 << 10}}
+
+	tests := []struct {
+		name       string
+		objs       []aws.S3Object
+		region     string
+		wantPrompt string
+	}{
+		{"same region", large, "us-east-1", "download"},
+		{"cross region large", large, "ap-southeast-2", "confirm-egress"},
+		{"cross region small", small, "ap-southeast-2", "download"},
+		{"unknown region", large, "", "download"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := config.Default()
+			settings.HomeRegion = "us-east-1"
+			settings.MaxAutoDownloadBytes = 0
+			m := New(Config{Profile: "default", Settings: settings})
+			m.currentBucket = "b"
+
+			updated, _ := m.Update(egressCheckedMsg{objs: tt.objs, region: tt.region})
+			m = updated.(Model)
+
+			if !m.showPrompt || m.promptType != tt.wantPrompt {
+				t.Fatalf("prompt = %v/%q, want %q", m.showPrompt, m.promptType, tt.wantPrompt)
+			}
+			if tt.wantPrompt == "confirm-egress" {
+				if !strings.Contains(m.promptText, "ap-southeast-2") || !strings.Contains(m.promptText, "data transfer charges") {
+					t.Errorf("prompt %q does not explain the charge", m.promptText)
+				}
+				if len(m.pendingLargeDownload) != 1 {
+					t.Errorf("pendingLargeDownload = %v", m.pendingLargeDownload)
+				}
+			}
+		})
+	}
+}
+
+func TestEgressConfirmationStillChecksSizeLimit(t *testing.T) {
+	settings := config.Default()
+	settings.HomeRegion = "us-east-1"
+	settings.EgressWarnBytes = 1 << 30
+	settings.MaxAutoDownloadBytes = 2 << 30
+	m := New(Config{Profile: "default", Settings: settings})
+	m.currentBucket = "b"
+
+	objs := []aws.S3Object{{Key: "exports/dump.tar", Size: 3 << 30}}
+	updated, _ := m.Update(egressCheckedMsg{objs: objs, region: "ap-southeast-2"})
+	m = updated.(Model)
+	if m.promptType != "confirm-egress" {
+		t.Fatalf("prompt = %q, want confirm-egress", m.promptType)
+	}
+
+	m.promptInput = "y"
+	updated, _ = m.executePromptAction()
+	m = updated.(Model)
+	if !m.showPrompt || m.promptType != "confirm-download" {
+		t.Fatalf("prompt = %v/%q, want confirm-download after accepting the charges", m.showPrompt, m.promptType)
+	}
+
+	m.promptInput = "y"
+	updated, _ = m.executePromptAction()
+	m = updated.(Model)
+	if !m.showPrompt || m.promptType != "download" {
+		t.Errorf("prompt = %v/%q, want download", m.showPrompt, m.promptType)
+	}
+}
+
+func TestNeedsEgressCheck(t *testing.T) {
+	large := []aws.S3Object{{Key: "dump.tar", Size: 3 << 30}}
+
+	m := New(Config{Profile: "default", Settings: config.Default()})
+	if m.needsEgressCheck(large) {
+		t.Error("no check expected without a home region")
+	}
+
+	m.settings.HomeRegion = "us-east-1"
+	if !m.needsEgressCheck(large) {
+		t.Error("expected a check for a large download with a home region")
+	}
+
+	m.settings.EgressWarnBytes = 0
+	if m.needsEgressCheck(large) {
+		t.Error("no check expected when the threshold is disabled")
+	}
+}
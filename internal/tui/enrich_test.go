@@ -0,0 +1,99 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/natevick/stui/internal/aws"
+	"github.com/natevick/stui/internal/config"
+	"github.com/natevick/stui/internal/views/browser"
+)
+
+// headCounter answers HeadObject with a content type, counting the calls
+type headCounter struct {
+	aws.S3API
+	heads atomic.Int32
+}
+
+func (h *headCounter) HeadObject(ctx context.Context, in *s3.HeadObjectInput, _ ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+	h.heads.Add(1)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	contentType := "text/plain"
+	return &s3.HeadObjectOutput{ContentType: &contentType}, nil
+}
+
+func TestEnrichListingIsCapped(t *testing.T) {
+	api := &headCounter{}
+	m := New(Config{Profile: "default", Settings: config.Default()})
+	m.client = &aws.Client{S3: api}
+	m.currentBucket = "b"
+	m.browserView.SetBucket("b")
+
+	objects := make([]aws.S3Object, maxEnrichObjects+100)
+	for i := range objects {
+		objects[i] = aws.S3Object{Key: fmt.Sprintf("file-%04d.txt", i)}
+	}
+	m.browserView.SetObjects(objects)
+	m.browserView.SetMetadataFilter(browser.MetadataFilter{ContentType: "text/*"})
+
+	cmd := m.maybeEnrichListing()
+	if cmd == nil {
+		t.Fatal("a type filter fetched no metadata")
+	}
+	msg, ok := cmd().(listingEnrichedMsg)
+	if !ok {
+		t.Fatalf("enrichment sent %T, want listingEnrichedMsg", msg)
+	}
+	if n := api.heads.Load(); n != maxEnrichObjects {
+		t.Errorf("made %d HEAD requests, want %d", n, maxEnrichObjects)
+	}
+	if msg.remaining != 100 {
+		t.Errorf("remaining = %d, want 100", msg.remaining)
+	}
+
+	updated, _ := m.Update(msg)
+	m = updated.(Model)
+	if got := len(m.browserView.VisibleObjects()); got != maxEnrichObjects {
+		t.Errorf("filter shows %d objects, want the %d fetched", got, maxEnrichObjects)
+	}
+
+	// Applying the filter again fetches the rest
+	cmd = m.maybeEnrichListing()
+	if cmd == nil {
+		t.Fatal("no fetch for the remaining objects")
+	}
+	if msg := cmd().(listingEnrichedMsg); len(msg.objects) != 100 || msg.remaining != 0 {
+		t.Errorf("second fetch = %d objects with %d remaining, want 100 and 0", len(msg.objects), msg.remaining)
+	}
+}
+
+func TestLeavingListingCancelsEnrichment(t *testing.T) {
+	m := New(Config{Profile: "default", Settings: config.Default()})
+	m.client = &aws.Client{S3: &headCounter{}}
+	m.currentBucket = "b"
+	m.browserView.SetBucket("b")
+	m.browserView.SetObjects([]aws.S3Object{{Key: "a.txt"}})
+
+	cmd := m.enrichListing()
+	if cmd == nil {
+		t.Fatal("enrichListing() = nil")
+	}
+	// Opening another folder, here one with a cached listing
+	m.currentPrefix = "logs/"
+	m.listingCache.Put("b", "logs/", []aws.S3Object{{Key: "logs/b.txt"}})
+	m.loadObjects()
+
+	msg := cmd().(listingEnrichedMsg)
+	if msg.objects[0].Enriched {
+		t.Error("metadata was fetched for a listing that was left")
+	}
+	updated, _ := m.Update(msg)
+	if m = updated.(Model); m.errorLog.Len() != 0 {
+		t.Error("a cancelled fetch was logged as an error")
+	}
+}
@@ -0,0 +1,105 @@
+package tui
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/natevick/stui/internal/aws"
+	"github.com/natevick/stui/internal/security"
+)
+
+// DefaultErrorLogSize is the number of errors kept in the error history
+const DefaultErrorLogSize = 50
+
+// LoggedError is a sanitized error recorded in the error history
+type LoggedError struct {
+	Time    time.Time
+	Context string
+	Message string
+}
+
+// ErrorLog is a fixed-size ring buffer of recent errors.
+// Messages are sanitized on the way in so the history can never display a secret.
+type ErrorLog struct {
+	mu      sync.Mutex
+	entries []LoggedError
+	next    int // index of the slot to write next
+	full    bool
+
+	now func() time.Time
+}
+
+// NewErrorLog creates an error log holding up to size entries
+func NewErrorLog(size int) *ErrorLog {
+	if size <= 0 {
+		size = DefaultErrorLogSize
+	}
+	return &ErrorLog{
+		entries: make([]LoggedError, size),
+		now:     time.Now,
+	}
+}
+
+// Add records an error, evicting the oldest entry when the log is full
+func (l *ErrorLog) Add(context string, err error) {
+	if err == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	message := security.SanitizeError(err)
+	var opErr *aws.OpError
+	if errors.As(err, &opErr) {
+		message = opErr.Detail()
+	}
+
+	l.entries[l.next] = LoggedError{
+		Time:    l.now(),
+		Context: context,
+		Message: message,
+	}
+	l.next = (l.next + 1) % len(l.entries)
+	if l.next == 0 {
+		l.full = true
+	}
+}
+
+// Len returns the number of stored errors
+func (l *ErrorLog) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.full {
+		return len(l.entries)
+	}
+	return l.next
+}
+
+// Recent returns the stored errors, newest first
+func (l *ErrorLog) Recent() []LoggedError {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	count := l.next
+	if l.full {
+		count = len(l.entries)
+	}
+
+	recent := make([]LoggedError, 0, count)
+	for i := 1; i <= count; i++ {
+		idx := (l.next - i + len(l.entries)) % len(l.entries)
+		recent = append(recent, l.entries[idx])
+	}
+	return recent
+}
+
+// Clear removes all stored errors
+func (l *ErrorLog) Clear() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = make([]LoggedError, len(l.entries))
+	l.next = 0
+	l.full = false
+}
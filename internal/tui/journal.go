@@ -0,0 +1,167 @@
+package tui
+
+import (
+	"fmt"
+	"path"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/natevick/stui/internal/aws"
+)
+
+// objectsDeletedMsg reports a delete of selected objects
+type objectsDeletedMsg struct {
+	bucket   string
+	keys     []string
+	failures []aws.DeleteFailure
+	err      error
+}
+
+// deletionRestoredMsg reports an undone delete
+type deletionRestoredMsg struct {
+	entry aws.DeletionEntry
+	err   error
+}
+
+// showDeletePrompt asks before deleting the selected objects. Folders are
+// skipped; deleting everything under a prefix is left to `stui rm -r`.
+func (m *Model) showDeletePrompt(objs []aws.S3Object) {
+	m.pendingDeleteKeys = m.pendingDeleteKeys[:0]
+	for _, obj := range objs {
+		if !obj.IsPrefix {
+			m.pendingDeleteKeys = append(m.pendingDeleteKeys, obj.Key)
+		}
+	}
+	if len(m.pendingDeleteKeys) == 0 {
+		m.statusMsg = "Folders can't be deleted here; use stui rm -r"
+		return
+	}
+	reason := fmt.Sprintf("Delete %d objects?", len(m.pendingDeleteKeys))
+	if len(m.pendingDeleteKeys) == 1 {
+		reason = fmt.Sprintf("Delete '%s'?", path.Base(m.pendingDeleteKeys[0]))
+	}
+	m.showConfirmPrompt("confirm-delete", reason+" On a versioned bucket, D can restore it.")
+}
+
+// deleteObjects deletes keys from the current bucket, recording them in the
+// client's journal
+func (m Model) deleteObjects(keys []string) tea.Cmd {
+	bucket := m.currentBucket
+	return func() tea.Msg {
+		if m.demoMode {
+			return ErrorMsg{Err: errDemoMode}
+		}
+		if m.client == nil {
+			return nil
+		}
+		failures, err := m.client.DeleteObjects(m.ctx, bucket, keys)
+		return objectsDeletedMsg{bucket: bucket, keys: keys, failures: failures, err: err}
+	}
+}
+
+// restoreDeletion undoes a delete by removing its delete marker
+func (m Model) restoreDeletion(e aws.DeletionEntry) tea.Cmd {
+	return func() tea.Msg {
+		if m.client == nil {
+			return nil
+		}
+		return deletionRestoredMsg{entry: e, err: m.client.Restore(m.ctx, e)}
+	}
+}
+
+// journalEntries returns this session's deletes, newest first
+func (m Model) journalEntries() []aws.DeletionEntry {
+	if m.client == nil || m.client.Options.Journal == nil {
+		return nil
+	}
+	return m.client.Options.Journal.Entries()
+}
+
+// openJournal shows the deletes made this session
+func (m *Model) openJournal() {
+	m.showJournal = true
+	m.journalCursor = 0
+	m.showHelp = false
+	m.showErrors = false
+}
+
+// handleJournalKey moves through the deletes; Enter restores the one under
+// the cursor and Esc or D closes the list
+func (m Model) handleJournalKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	entries := m.journalEntries()
+	switch msg.String() {
+	case "esc", "D":
+		m.showJournal = false
+	case "up", "k":
+		m.journalCursor = max(m.journalCursor-1, 0)
+	case "down", "j":
+		m.journalCursor = min(m.journalCursor+1, max(len(entries)-1, 0))
+	case "enter":
+		if m.journalCursor >= len(entries) {
+			return m, nil
+		}
+		e := entries[m.journalCursor]
+		if !e.Restorable() {
+			m.statusMsg = "The bucket isn't versioned, so this delete can't be undone"
+			return m, nil
+		}
+		if m.refuseReadOnly("Restoring") {
+			return m, nil
+		}
+		m.statusMsg = "Restoring " + e.Key + "..."
+		return m, m.restoreDeletion(e)
+	}
+	return m, nil
+}
+
+func (m Model) renderWithJournal() string {
+	width := min(m.width-4, 80)
+	journalStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ColorPrimary).
+		Padding(1, 2).
+		Width(width)
+
+	lines := []string{
+		m.styles.Title.Render("Deleted This Session"),
+		"",
+	}
+
+	entries := m.journalEntries()
+	if len(entries) == 0 {
+		lines = append(lines, m.styles.Dim.Render("Nothing deleted this session"))
+	}
+
+	// Leave room for the border, padding, title, and footer, scrolling to
+	// keep the cursor in view
+	visible := max(m.height-10, 1)
+	start := max(m.journalCursor-visible+1, 0)
+	for i := start; i < len(entries) && i < start+visible; i++ {
+		e := entries[i]
+		line := fmt.Sprintf("%s  %s", e.When.Format("15:04:05"), displaySafe(aws.FormatS3URI(e.Bucket, e.Key)))
+		if !e.Restorable() {
+			line += "  (not restorable)"
+		}
+		if i == m.journalCursor {
+			lines = append(lines, m.styles.SelectedItem.Render("> "+line))
+		} else if e.Restorable() {
+			lines = append(lines, "  "+line)
+		} else {
+			lines = append(lines, m.styles.Dim.Render("  "+line))
+		}
+	}
+
+	lines = append(lines, "", m.styles.Dim.Render("Enter restore • Esc or D to close"))
+
+	panel := journalStyle.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		panel,
+		lipgloss.WithWhitespaceChars(" "),
+		lipgloss.WithWhitespaceForeground(lipgloss.Color("0")),
+	)
+}
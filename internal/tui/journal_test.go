@@ -0,0 +1,113 @@
+package tui
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/natevick/stui/internal/aws"
+	"github.com/natevick/stui/internal/config"
+)
+
+// versionedDeleter answers deletes as a versioned bucket would, with a
+// delete marker per key, and records the versions removed by restores.
+// Listings come back empty.
+type versionedDeleter struct {
+	aws.S3API
+	restored []string
+}
+
+func (v *versionedDeleter) ListObjectsV2(ctx context.Context, in *s3.ListObjectsV2Input, _ ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	return &s3.ListObjectsV2Output{}, nil
+}
+
+func (v *versionedDeleter) DeleteObjects(ctx context.Context, in *s3.DeleteObjectsInput, _ ...func(*s3.Options)) (*s3.DeleteObjectsOutput, error) {
+	out := &s3.DeleteObjectsOutput{}
+	marker := true
+	for _, id := range in.Delete.Objects {
+		version := "marker-" + *id.Key
+		out.Deleted = append(out.Deleted, types.DeletedObject{
+			Key:                   id.Key,
+			DeleteMarker:          &marker,
+			DeleteMarkerVersionId: &version,
+		})
+	}
+	return out, nil
+}
+
+func (v *versionedDeleter) DeleteObject(ctx context.Context, in *s3.DeleteObjectInput, _ ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	v.restored = append(v.restored, *in.VersionId)
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+func TestDeleteThenRestoreFromJournal(t *testing.T) {
+	api := &versionedDeleter{}
+	m := New(Config{Profile: "default", Settings: config.Default()})
+	m.client = &aws.Client{S3: api, Options: aws.ClientOptions{Journal: aws.NewDeletionJournal()}}
+	m.currentBucket = "b"
+	m.SetSize(120, 40)
+	m.listingCache.Put("b", "", []aws.S3Object{{Key: "a.txt"}})
+
+	m.showDeletePrompt([]aws.S3Object{{Key: "a.txt"}, {Key: "logs/", IsPrefix: true}})
+	if m.promptType != "confirm-delete" || len(m.pendingDeleteKeys) != 1 {
+		t.Fatalf("prompt = %q with %v pending, want confirm-delete for a.txt", m.promptType, m.pendingDeleteKeys)
+	}
+	m.promptInput = "y"
+	updated, cmd := m.executePromptAction()
+	m = updated.(Model)
+	if cmd == nil {
+		t.Fatal("confirming the delete sent nothing")
+	}
+	updated, cmd = m.Update(cmd())
+	m = updated.(Model)
+	if m.statusMsg != "Deleted 1 objects (D to restore)" {
+		t.Errorf("statusMsg = %q", m.statusMsg)
+	}
+	// The reload lists S3 rather than showing the cached listing with a.txt
+	if _, ok := cmd().(objectsPageMsg); !ok {
+		t.Error("the reload after the delete came from the stale cache")
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("D")})
+	m = updated.(Model)
+	if !m.showJournal {
+		t.Fatal("D did not open the journal")
+	}
+	if !strings.Contains(m.View(), "s3://b/a.txt") {
+		t.Error("the journal doesn't list the deleted object")
+	}
+
+	updated, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if cmd == nil {
+		t.Fatal("Enter did not restore")
+	}
+	updated, _ = m.Update(cmd())
+	m = updated.(Model)
+	if len(api.restored) != 1 || api.restored[0] != "marker-a.txt" {
+		t.Errorf("restored versions %v, want the delete marker", api.restored)
+	}
+	if got := m.client.Options.Journal.Len(); got != 0 {
+		t.Errorf("journal has %d entries after the restore, want 0", got)
+	}
+}
+
+func TestUnversionedDeleteIsNotRestored(t *testing.T) {
+	m := New(Config{Profile: "default", Settings: config.Default()})
+	journal := aws.NewDeletionJournal()
+	journal.Record(aws.DeletionEntry{Bucket: "b", Key: "a.txt"})
+	m.client = &aws.Client{Options: aws.ClientOptions{Journal: journal}}
+	m.openJournal()
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if cmd != nil {
+		t.Error("an unversioned delete was sent for restore")
+	}
+	if m.statusMsg == "" {
+		t.Error("no explanation for the unrestorable delete")
+	}
+}
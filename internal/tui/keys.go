@@ -19,11 +19,11 @@ type KeyMap struct {
 	End      key.Binding
 
 	// View switching
-	Tab         key.Binding
-	ShiftTab    key.Binding
-	Buckets     key.Binding
-	Browser     key.Binding
-	Bookmarks   key.Binding
+	Tab       key.Binding
+	ShiftTab  key.Binding
+	Buckets   key.Binding
+	Browser   key.Binding
+	Bookmarks key.Binding
 
 	// Actions
 	Select      key.Binding
@@ -35,8 +35,11 @@ type KeyMap struct {
 	Cancel      key.Binding
 
 	// App
-	Help key.Binding
-	Quit key.Binding
+	Help     key.Binding
+	Errors   key.Binding
+	Deleted  key.Binding
+	Profiles key.Binding
+	Quit     key.Binding
 }
 
 // DefaultKeyMap returns the default key bindings
@@ -134,6 +137,18 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("?"),
 			key.WithHelp("?", "help"),
 		),
+		Errors: key.NewBinding(
+			key.WithKeys("e"),
+			key.WithHelp("e", "error history"),
+		),
+		Deleted: key.NewBinding(
+			key.WithKeys("D"),
+			key.WithHelp("D", "recently deleted"),
+		),
+		Profiles: key.NewBinding(
+			key.WithKeys("ctrl+p"),
+			key.WithHelp("ctrl+p", "switch profile"),
+		),
 		Quit: key.NewBinding(
 			key.WithKeys("q", "ctrl+c"),
 			key.WithHelp("q", "quit"),
@@ -152,6 +167,6 @@ func (k KeyMap) FullHelp() [][]key.Binding {
 		{k.Up, k.Down, k.Enter, k.Back},
 		{k.Tab, k.Buckets, k.Browser, k.Bookmarks},
 		{k.Download, k.Sync, k.AddBookmark, k.Refresh},
-		{k.Help, k.Quit},
+		{k.Help, k.Errors, k.Deleted, k.Profiles, k.Quit},
 	}
 }
@@ -1,6 +1,7 @@
 package tui
 
 import (
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/natevick/stui/internal/aws"
 	"github.com/natevick/stui/internal/bookmarks"
 	"github.com/natevick/stui/internal/download"
@@ -20,10 +21,21 @@ const (
 
 // Message types for inter-component communication
 
-// BucketsLoadedMsg is sent when buckets are loaded
+// BucketsLoadedMsg is sent when a page of buckets is loaded
 type BucketsLoadedMsg struct {
 	Buckets []aws.Bucket
 	Err     error
+	// Append adds the page to the buckets already shown
+	Append bool
+	// next delivers the following pages while the listing is still streaming
+	next <-chan BucketsLoadedMsg
+	// load is the loadBuckets call the page belongs to
+	load int
+}
+
+// bucketRegionsMsg carries bucket regions looked up after the listing
+type bucketRegionsMsg struct {
+	regions map[string]string
 }
 
 // BucketSelectedMsg is sent when a bucket is selected
@@ -35,8 +47,20 @@ type BucketSelectedMsg struct {
 // ObjectsLoadedMsg is sent when objects are loaded
 type ObjectsLoadedMsg struct {
 	Objects []aws.S3Object
+	Bucket  string
 	Prefix  string
 	Err     error
+	Refresh bool // background refresh; merge into the current listing
+}
+
+// objectsPageMsg carries a page of a listing still streaming in, for the
+// running total size; the ObjectsLoadedMsg that follows has the listing
+type objectsPageMsg struct {
+	bucket  string
+	prefix  string
+	objects []aws.S3Object
+	// next delivers the following pages and then the ObjectsLoadedMsg
+	next <-chan tea.Msg
 }
 
 // NavigatePrefixMsg is sent when navigating to a prefix
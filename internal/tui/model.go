@@ -2,17 +2,29 @@ package tui
 
 import (
 	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
 	"time"
 
+	"github.com/charmbracelet/bubbles/textarea"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/natevick/stui/internal/aws"
 	"github.com/natevick/stui/internal/bookmarks"
+	"github.com/natevick/stui/internal/config"
 	"github.com/natevick/stui/internal/download"
+	"github.com/natevick/stui/internal/export"
+	"github.com/natevick/stui/internal/nav"
+	"github.com/natevick/stui/internal/platform"
 	"github.com/natevick/stui/internal/views/bookmarksview"
 	"github.com/natevick/stui/internal/views/browser"
 	"github.com/natevick/stui/internal/views/buckets"
 	downloadview "github.com/natevick/stui/internal/views/download"
+	"github.com/natevick/stui/internal/views/filebrowser"
 	"github.com/natevick/stui/internal/views/profiles"
+	"github.com/natevick/stui/internal/views/statusbar"
 )
 
 // Model is the root model for the TUI application
@@ -23,30 +35,78 @@ type Model struct {
 	region        string
 	initialBucket string // bucket to start in (from --bucket flag)
 	demoMode      bool   // use mock data
+	readOnly      bool   // refuse every change to S3
+	anonymous     bool   // unsigned requests, for public buckets
+	settings      config.Config
 
 	// Views
-	activeView     ViewType
-	profilesView   profiles.Model
-	bucketsView    buckets.Model
-	browserView    browser.Model
-	downloadView   downloadview.Model
-	bookmarksView  bookmarksview.Model
-	showHelp       bool
+	activeView    ViewType
+	profilesView  profiles.Model
+	bucketsView   buckets.Model
+	browserView   browser.Model
+	downloadView  downloadview.Model
+	bookmarksView bookmarksview.Model
+	statusBar     statusbar.Model
+	showHelp      bool
+	showErrors    bool
+
+	// Deletion journal overlay
+	showJournal   bool
+	journalCursor int
+
+	// Local destination picker, opened from a path prompt
+	showFilePicker bool
+	filePicker     filebrowser.Model
+
+	// Profile quick switcher overlay
+	showSwitcher   bool
+	switcher       profiles.Switcher
+	profileHistory *profiles.ProfileHistory
+
+	// Object preview overlay
+	showPreview    bool
+	previewTitle   string
+	previewContent []byte
+	previewers     *PreviewerRegistry
+
+	// Object editor overlay
+	showEditor bool
+	editor     textarea.Model
+	editBuf    *aws.EditBuffer // object being edited, with the ETag it was read at
+	editSaving bool            // a save is in flight; SaveEdit updates editBuf
 
 	// State
 	currentBucket string
 	currentPrefix string
 	bookmarkStore *bookmarks.Store
 	downloadMgr   *download.Manager
+	budget        *download.Budget // shared by every downloadMgr, so switching profiles doesn't refill it
+	listingCache  *aws.ListingCache
+	clipboard     platform.Clipboard
+	newClient     func(ctx context.Context, profile, region string, opts aws.ClientOptions) (*aws.Client, error)
+
+	warmStarted bool
+	stopWarming context.CancelFunc
+
+	// stopEnrich cancels the metadata fetch for the listing last enriched
+	stopEnrich context.CancelFunc
+
+	lastAutoRefresh time.Time
+
+	// bucketLoad numbers the bucket listing currently being shown
+	bucketLoad int
 
 	// UI
-	styles       Styles
-	keys         KeyMap
-	width        int
-	height       int
-	statusMsg    string
-	errorMsg     string
-	errorTimeout time.Time
+	styles        Styles
+	keys          KeyMap
+	width         int
+	height        int
+	statusMsg     string
+	quitting      bool // waiting for transfers to drain before exiting
+	quitRequested bool // quit pressed during transfers, awaiting confirmation
+	errorMsg      string
+	errorTimeout  time.Time
+	errorLog      *ErrorLog
 
 	// Prompt state
 	showPrompt             bool
@@ -57,6 +117,17 @@ type Model struct {
 	promptCursor           int
 	pendingDownloadObjects []aws.S3Object // for multi-select downloads
 	pendingBookmarkBucket  string         // for bucket bookmarks
+	pendingRenameKey       string         // object being renamed
+	pendingMoveKeys        []string       // objects being moved to another prefix
+	pendingPreviewKey      string         // oversized object awaiting preview confirmation
+	pendingPublicKey       string         // object awaiting public-read confirmation
+	pendingRetentionKey    string         // object whose retention is being set
+	pendingRetention       aws.Retention  // Compliance retention awaiting confirmation
+	pendingLargeDownload   []aws.S3Object // oversized download awaiting confirmation
+	pendingDeleteKeys      []string       // objects awaiting delete confirmation
+
+	// Prefix listings made to size a download, reused by the download
+	sizedPrefixes map[string][]aws.S3Object
 
 	// Context for cancellation
 	ctx    context.Context
@@ -65,10 +136,13 @@ type Model struct {
 
 // Config holds configuration for the TUI
 type Config struct {
-	Profile  string
-	Region   string
-	Bucket   string // Start directly in this bucket
-	DemoMode bool   // Use mock data instead of real AWS
+	Profile   string
+	Region    string
+	Bucket    string // Start directly in this bucket
+	DemoMode  bool   // Use mock data instead of real AWS
+	ReadOnly  bool   // Disable changes to S3 for this run, on top of the read_only setting
+	Anonymous bool   // Send unsigned requests for public buckets; implies ReadOnly
+	Settings  config.Config
 }
 
 // New creates a new TUI model
@@ -79,26 +153,58 @@ func New(cfg Config) Model {
 	activeView := ViewBuckets
 	if cfg.Bucket != "" {
 		activeView = ViewBrowser
-	} else if cfg.Profile == "" && !cfg.DemoMode {
+	} else if cfg.Profile == "" && !cfg.DemoMode && !cfg.Anonymous {
 		// No profile specified, show profile picker
 		activeView = ViewProfiles
 	}
 
+	browserView := browser.New()
+	browserView.SetShowHidden(cfg.Settings.ShowHidden)
+	if mode, err := browser.ParseKeyDisplayMode(cfg.Settings.KeyDisplay); err == nil {
+		browserView.SetKeyDisplay(mode)
+	}
+
+	statusBar := statusbar.New()
+	statusBar.SetProfile(cfg.Profile)
+	statusBar.SetRegion(cfg.Region)
+	readOnly := cfg.ReadOnly || cfg.Settings.ReadOnly || cfg.Anonymous
+	statusBar.SetReadOnly(readOnly)
+	bucketsView := buckets.New()
+	bucketsView.SetHomeRegion(cfg.Settings.HomeRegion)
+
+	var budget *download.Budget
+	if cfg.Settings.SessionBudgetBytes > 0 || cfg.Settings.SessionBudgetTransfers > 0 {
+		budget = download.NewBudget(cfg.Settings.SessionBudgetBytes, cfg.Settings.SessionBudgetTransfers)
+	}
+
 	return Model{
 		profile:       cfg.Profile,
 		region:        cfg.Region,
 		initialBucket: cfg.Bucket,
 		demoMode:      cfg.DemoMode,
+		readOnly:      readOnly,
+		anonymous:     cfg.Anonymous,
+		settings:      cfg.Settings,
 		activeView:    activeView,
 		profilesView:  profiles.New(),
-		bucketsView:   buckets.New(),
-		browserView:   browser.New(),
+		bucketsView:   bucketsView,
+		browserView:   browserView,
 		downloadView:  downloadview.New(),
 		bookmarksView: bookmarksview.New(),
+		statusBar:     statusBar,
+		errorLog:      NewErrorLog(DefaultErrorLogSize),
+		listingCache:  aws.NewListingCache(aws.DefaultListingCacheTTL),
+		budget:        budget,
+		previewers:    DefaultPreviewers(),
+		clipboard:     platform.SystemClipboard{},
+		newClient:     aws.NewClient,
 		styles:        DefaultStyles(),
 		keys:          DefaultKeyMap(),
 		ctx:           ctx,
 		cancel:        cancel,
+
+		// Replaced by the saved history once it loads
+		profileHistory: profiles.NewProfileHistory(""),
 	}
 }
 
@@ -112,11 +218,12 @@ func (m Model) Init() tea.Cmd {
 		)
 	}
 
-	// If no profile specified, load profile picker
-	if m.profile == "" {
+	// If no profile specified, load profile picker; anonymous mode needs none
+	if m.profile == "" && !m.anonymous {
 		return tea.Batch(
 			m.initProfiles(),
 			m.initBookmarks(),
+			m.initProfileHistory(),
 			tea.SetWindowTitle("S3 TUI"),
 		)
 	}
@@ -124,6 +231,7 @@ func (m Model) Init() tea.Cmd {
 	return tea.Batch(
 		m.initAWS(),
 		m.initBookmarks(),
+		m.initProfileHistory(),
 		tea.SetWindowTitle("S3 TUI"),
 	)
 }
@@ -151,7 +259,7 @@ type demoReadyMsg struct{}
 // initAWS initializes the AWS client
 func (m Model) initAWS() tea.Cmd {
 	return func() tea.Msg {
-		client, err := aws.NewClient(m.ctx, m.profile, m.region)
+		client, err := m.newClient(m.ctx, m.profile, m.region, m.clientOptions())
 		if err != nil {
 			return ErrorMsg{Err: err}
 		}
@@ -159,11 +267,65 @@ func (m Model) initAWS() tea.Cmd {
 	}
 }
 
+// clientOptions maps user settings onto AWS client options
+func (m Model) clientOptions() aws.ClientOptions {
+	opts := m.settings.ClientOptions()
+	opts.ReadOnly = m.readOnly
+	opts.Anonymous = m.anonymous
+	return opts
+}
+
 // awsClientReadyMsg is sent when AWS client is ready
 type awsClientReadyMsg struct {
 	client *aws.Client
 }
 
+// loadCredentialExpiry looks up when the active credentials expire
+func (m Model) loadCredentialExpiry() tea.Cmd {
+	return func() tea.Msg {
+		if m.client == nil {
+			return nil
+		}
+		expires, ok := m.client.CredentialExpiry(m.ctx)
+		if !ok {
+			return nil
+		}
+		return credentialExpiryMsg{expires: expires}
+	}
+}
+
+// credentialExpiryMsg is sent when the credential expiry is known
+type credentialExpiryMsg struct {
+	expires time.Time
+}
+
+// pingEndpoint checks the endpoint is reachable, unless the check is
+// disabled. Unsigned requests can't list buckets, so anonymous sessions
+// check the bucket they open instead, and skip the check without one.
+func (m Model) pingEndpoint() tea.Cmd {
+	if m.client == nil || m.settings.PingTimeoutSeconds == 0 {
+		return nil
+	}
+	client, ctx := m.client, m.ctx
+	if m.anonymous {
+		bucket := m.initialBucket
+		if bucket == "" {
+			return nil
+		}
+		return func() tea.Msg {
+			return pingResultMsg{err: client.PingBucket(ctx, bucket)}
+		}
+	}
+	return func() tea.Msg {
+		return pingResultMsg{err: client.Ping(ctx)}
+	}
+}
+
+// pingResultMsg is sent when the startup endpoint check finishes
+type pingResultMsg struct {
+	err error
+}
+
 // initBookmarks initializes the bookmark store
 func (m Model) initBookmarks() tea.Cmd {
 	return func() tea.Msg {
@@ -180,6 +342,26 @@ type bookmarkStoreReadyMsg struct {
 	store *bookmarks.Store
 }
 
+// initProfileHistory loads the profiles used in earlier sessions
+func (m Model) initProfileHistory() tea.Cmd {
+	return func() tea.Msg {
+		dir, err := config.Dir()
+		if err != nil {
+			return ErrorMsg{Err: err}
+		}
+		history, err := profiles.LoadProfileHistory(filepath.Join(dir, "profile_history.json"))
+		if err != nil {
+			return ErrorMsg{Err: err}
+		}
+		return profileHistoryReadyMsg{history: history}
+	}
+}
+
+// profileHistoryReadyMsg is sent when the profile history is loaded
+type profileHistoryReadyMsg struct {
+	history *profiles.ProfileHistory
+}
+
 // SetSize sets the terminal size
 func (m *Model) SetSize(width, height int) {
 	m.width = width
@@ -193,19 +375,89 @@ func (m *Model) SetSize(width, height int) {
 	m.browserView.SetSize(width-2, contentHeight)
 	m.downloadView.SetSize(width-2, contentHeight)
 	m.bookmarksView.SetSize(width-2, contentHeight)
+	if m.showEditor {
+		m.editor.SetWidth(max(width-8, 20))
+		m.editor.SetHeight(max(height-10, 5))
+	}
+	if m.showFilePicker {
+		m.filePicker.SetSize(max(width-8, 20), max(height-10, 5))
+	}
 }
 
-// loadBuckets returns a command to load buckets
-func (m Model) loadBuckets() tea.Cmd {
+// loadBuckets returns a command to load buckets. Accounts with many buckets
+// are shown a page at a time as the listing streams in. Each call starts a
+// new listing; pages from earlier ones are dropped when they arrive.
+func (m *Model) loadBuckets() tea.Cmd {
+	if m.client == nil {
+		return func() tea.Msg { return ErrorMsg{Err: nil} }
+	}
+
+	m.bucketLoad++
+	client, ctx, load := m.client, m.ctx, m.bucketLoad
 	return func() tea.Msg {
-		if m.client == nil {
-			return ErrorMsg{Err: nil}
+		ch := make(chan BucketsLoadedMsg)
+		go func() {
+			defer close(ch)
+			first := true
+			for page, err := range client.BucketPages(ctx) {
+				msg := BucketsLoadedMsg{Buckets: page, Err: err, Append: !first, load: load}
+				first = false
+				select {
+				case ch <- msg:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		return listenForBuckets(ch)()
+	}
+}
+
+// bucketRegionLookups is how many GetBucketLocation calls run at once
+const bucketRegionLookups = 4
+
+// lookupBucketRegions finds the regions ListBuckets didn't report. Failed
+// lookups are left out, so those buckets just show no region.
+func (m Model) lookupBucketRegions(names []string) tea.Cmd {
+	if m.client == nil || m.demoMode || len(names) == 0 {
+		return nil
+	}
+	return func() tea.Msg {
+		var (
+			mu      sync.Mutex
+			wg      sync.WaitGroup
+			regions = make(map[string]string, len(names))
+			sem     = make(chan struct{}, bucketRegionLookups)
+		)
+		for _, name := range names {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func() {
+				defer wg.Done()
+				defer func() { <-sem }()
+				region, err := m.client.GetBucketRegion(m.ctx, name)
+				if err != nil {
+					return
+				}
+				mu.Lock()
+				regions[name] = region
+				mu.Unlock()
+			}()
 		}
-		bucketList, err := m.client.ListBuckets(m.ctx)
-		if err != nil {
-			return BucketsLoadedMsg{Err: err}
+		wg.Wait()
+		return bucketRegionsMsg{regions: regions}
+	}
+}
+
+// listenForBuckets waits for the next page of a streaming bucket listing
+func listenForBuckets(ch <-chan BucketsLoadedMsg) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-ch
+		if !ok {
+			return nil
 		}
-		return BucketsLoadedMsg{Buckets: bucketList}
+		msg.next = ch
+		return msg
 	}
 }
 
@@ -214,18 +466,183 @@ func (m Model) loadObjects() tea.Cmd {
 	if m.demoMode {
 		return m.loadDemoObjects()
 	}
+	// The user is navigating; warming would only compete for bandwidth now,
+	// and metadata for the listing being left is no longer wanted
+	if m.stopWarming != nil {
+		m.stopWarming()
+	}
+	if m.stopEnrich != nil {
+		m.stopEnrich()
+	}
+
+	// Show a cached listing immediately and refresh it in the background
+	if objects, ok := m.listingCache.Get(m.currentBucket, m.currentPrefix); ok {
+		bucket, prefix := m.currentBucket, m.currentPrefix
+		cached := func() tea.Msg {
+			return ObjectsLoadedMsg{Objects: objects, Bucket: bucket, Prefix: prefix}
+		}
+		return tea.Sequence(cached, m.refreshObjects())
+	}
+
+	if m.client == nil || m.currentBucket == "" {
+		return nil
+	}
+
+	// Pages are sent as they arrive so the status bar can show a running total
+	client, ctx, cache := m.client, m.ctx, m.listingCache
+	bucket, prefix := m.currentBucket, m.currentPrefix
+	ch := make(chan tea.Msg)
+	go func() {
+		defer close(ch)
+		send := func(msg tea.Msg) {
+			select {
+			case ch <- msg:
+			case <-ctx.Done():
+			}
+		}
+		objects, err := client.ListObjectsPages(ctx, bucket, prefix, func(page []aws.S3Object) {
+			send(objectsPageMsg{bucket: bucket, prefix: prefix, objects: page, next: ch})
+		})
+		if err != nil {
+			send(ObjectsLoadedMsg{Err: err})
+			return
+		}
+		cache.Put(bucket, prefix, objects)
+		send(ObjectsLoadedMsg{Objects: objects, Bucket: bucket, Prefix: prefix})
+	}()
+	return listenForObjects(ch)
+}
+
+// invalidateListings drops the cached listings that changing keys in bucket
+// made stale, so the reload after a change lists S3 instead of showing the
+// listing from before it
+func (m Model) invalidateListings(bucket string, keys ...string) {
+	for _, key := range keys {
+		m.listingCache.InvalidateKey(bucket, key)
+	}
+}
+
+// listenForObjects waits for the next message of a streaming object listing
+func listenForObjects(ch <-chan tea.Msg) tea.Cmd {
 	return func() tea.Msg {
-		if m.client == nil || m.currentBucket == "" {
+		msg, ok := <-ch
+		if !ok {
 			return nil
 		}
-		objects, err := m.client.ListObjects(m.ctx, m.currentBucket, m.currentPrefix)
-		if err != nil {
-			return ObjectsLoadedMsg{Err: err}
+		return msg
+	}
+}
+
+// refreshObjects re-lists the current prefix in the background
+func (m Model) refreshObjects() tea.Cmd {
+	bucket, prefix := m.currentBucket, m.currentPrefix
+	return func() tea.Msg {
+		if m.client == nil || bucket == "" {
+			return nil
 		}
-		return ObjectsLoadedMsg{Objects: objects, Prefix: m.currentPrefix}
+		objects, err := m.client.ListObjects(m.ctx, bucket, prefix)
+		if err == nil {
+			m.listingCache.Put(bucket, prefix, objects)
+		}
+		return ObjectsLoadedMsg{Objects: objects, Bucket: bucket, Prefix: prefix, Err: err, Refresh: true}
+	}
+}
+
+// listingEnrichedMsg carries HEAD metadata fetched for a listing
+type listingEnrichedMsg struct {
+	bucket  string
+	prefix  string
+	objects []aws.S3Object
+	// remaining is how many objects were left for a later fetch
+	remaining int
+	err       error
+}
+
+// maxEnrichObjects caps the HEAD requests one enrichment makes, so a
+// metadata filter on a huge listing doesn't HEAD every object in it
+const maxEnrichObjects = 500
+
+// enrichListing fetches content types for up to maxEnrichObjects objects in
+// the current listing that don't have them yet, for the metadata filter. It
+// replaces any fetch still running, and leaving the listing cancels it.
+func (m *Model) enrichListing() tea.Cmd {
+	var pending []aws.S3Object
+	remaining := 0
+	for _, obj := range m.browserView.Listing() {
+		if obj.IsPrefix || obj.Enriched {
+			continue
+		}
+		if len(pending) == maxEnrichObjects {
+			remaining++
+			continue
+		}
+		pending = append(pending, obj)
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	if m.demoMode {
+		return func() tea.Msg { return ErrorMsg{Err: errDemoMode} }
+	}
+	if m.client == nil {
+		return nil
+	}
+
+	if m.stopEnrich != nil {
+		m.stopEnrich()
+	}
+	ctx, cancel := context.WithCancel(m.ctx)
+	m.stopEnrich = cancel
+	client, bucket, prefix := m.client, m.currentBucket, m.currentPrefix
+	return func() tea.Msg {
+		defer cancel()
+		err := client.EnrichObjects(ctx, bucket, pending, 0)
+		return listingEnrichedMsg{bucket: bucket, prefix: prefix, objects: pending, remaining: remaining, err: err}
 	}
 }
 
+// maybeWarmCache starts pre-listing bookmarks once both the client and the
+// bookmark store are ready, if enabled. Opening any listing cancels it.
+func (m *Model) maybeWarmCache() tea.Cmd {
+	if !m.settings.WarmBookmarkCache || m.warmStarted || m.demoMode {
+		return nil
+	}
+	if m.client == nil || m.bookmarkStore == nil {
+		return nil
+	}
+	m.warmStarted = true
+
+	ctx, cancel := context.WithCancel(m.ctx)
+	m.stopWarming = cancel
+	client, store, cache := m.client, m.bookmarkStore, m.listingCache
+	return func() tea.Msg {
+		defer cancel()
+		err := bookmarks.WarmCache(ctx, client, store, cache, bookmarks.DefaultRecentConcurrency)
+		return cacheWarmedMsg{err: err}
+	}
+}
+
+// cacheWarmedMsg reports the end of bookmark cache warming
+type cacheWarmedMsg struct {
+	err error
+}
+
+// autoRefreshDue reports whether the current listing should be re-listed.
+// Refreshing pauses while a transfer runs or the user is mid-prompt.
+func (m Model) autoRefreshDue(now time.Time) bool {
+	interval := time.Duration(m.settings.AutoRefreshSeconds) * time.Second
+	if interval <= 0 || m.demoMode {
+		return false
+	}
+	if m.activeView != ViewBrowser || m.currentBucket == "" || m.browserView.Loading() {
+		return false
+	}
+	if m.downloadView.IsActive() || m.showPrompt {
+		return false
+	}
+	return now.Sub(m.lastAutoRefresh) >= interval
+}
+
 // startDownload starts a download operation
 func (m Model) startDownload(key, localPath string, isPrefix bool) tea.Cmd {
 	return func() tea.Msg {
@@ -264,8 +681,9 @@ type downloadStartedMsg struct {
 	progressChan <-chan download.Progress
 }
 
-// startMultiDownload starts downloading multiple objects
-func (m Model) startMultiDownload(objects []aws.S3Object, localDir string) tea.Cmd {
+// startMultiDownload starts downloading multiple objects, laid out under
+// localDir by their keys relative to prefix
+func (m Model) startMultiDownload(objects []aws.S3Object, prefix, localDir string) tea.Cmd {
 	return func() tea.Msg {
 		if m.downloadMgr == nil || m.client == nil {
 			return ErrorMsg{Err: nil}
@@ -282,7 +700,7 @@ func (m Model) startMultiDownload(objects []aws.S3Object, localDir string) tea.C
 
 		go func() {
 			// Convert to aws.S3Object slice for the download manager
-			err := m.downloadMgr.DownloadMultiple(m.ctx, m.currentBucket, objects, m.currentPrefix, localDir)
+			err := m.downloadMgr.DownloadMultiple(m.ctx, m.currentBucket, objects, prefix, localDir)
 			if err != nil {
 				progressChan <- download.Progress{Status: download.StatusFailed}
 			}
@@ -293,6 +711,543 @@ func (m Model) startMultiDownload(objects []aws.S3Object, localDir string) tea.C
 	}
 }
 
+// retryFailedDownloads downloads the files that failed in the last download again
+func (m Model) retryFailedDownloads() tea.Cmd {
+	return func() tea.Msg {
+		if m.downloadMgr == nil || m.client == nil {
+			return ErrorMsg{Err: nil}
+		}
+
+		// Set up progress callback
+		progressChan := make(chan download.Progress, 10)
+		m.downloadMgr.SetProgressCallback(func(p download.Progress) {
+			select {
+			case progressChan <- p:
+			default:
+			}
+		})
+
+		go func() {
+			if _, err := m.downloadMgr.RetryFailed(m.ctx); err != nil {
+				progressChan <- download.Progress{Status: download.StatusFailed}
+			}
+			close(progressChan)
+		}()
+
+		return downloadStartedMsg{progressChan: progressChan}
+	}
+}
+
+// renameObject renames an object within the current bucket
+func (m Model) renameObject(oldKey, newKey string) tea.Cmd {
+	return func() tea.Msg {
+		if m.demoMode {
+			return ErrorMsg{Err: errDemoMode}
+		}
+		if m.client == nil {
+			return ErrorMsg{Err: nil}
+		}
+		err := m.client.RenameObject(m.ctx, m.currentBucket, oldKey, newKey, false)
+		return objectRenamedMsg{oldKey: oldKey, newKey: newKey, err: err}
+	}
+}
+
+// checkPublicAccess reads the Block Public Access settings that apply to the current bucket
+func (m Model) checkPublicAccess(key string) tea.Cmd {
+	return func() tea.Msg {
+		if m.demoMode {
+			return ErrorMsg{Err: errDemoMode}
+		}
+		if m.client == nil {
+			return nil
+		}
+		status, err := m.client.PublicAccessStatus(m.ctx, m.currentBucket)
+		return publicAccessCheckedMsg{key: key, status: status, err: err}
+	}
+}
+
+// sizePrefixes lists the prefixes in a download to learn how much they hold,
+// returning objs with each prefix's Size set to the total under it. The
+// listings are kept so the download doesn't list the prefixes again.
+func (m Model) sizePrefixes(objs []aws.S3Object) tea.Cmd {
+	bucket := m.currentBucket
+	return func() tea.Msg {
+		if m.client == nil {
+			return nil
+		}
+		sized := slices.Clone(objs)
+		listed := make(map[string][]aws.S3Object)
+		for i, obj := range sized {
+			if !obj.IsPrefix {
+				continue
+			}
+			contents, err := m.client.ListAllObjects(m.ctx, bucket, obj.Key)
+			if err != nil {
+				return prefixesSizedMsg{objs: objs, err: err}
+			}
+			sized[i].Size = downloadSize(contents)
+			listed[obj.Key] = contents
+		}
+		return prefixesSizedMsg{objs: sized, listed: listed}
+	}
+}
+
+// expandSizedPrefixes replaces the prefixes in objs that sizePrefixes
+// listed with what they hold. The listings are used once.
+func (m *Model) expandSizedPrefixes(objs []aws.S3Object) []aws.S3Object {
+	listed := m.sizedPrefixes
+	m.sizedPrefixes = nil
+	var expanded []aws.S3Object
+	for _, obj := range objs {
+		if contents, ok := listed[obj.Key]; ok && obj.IsPrefix {
+			expanded = append(expanded, contents...)
+		} else {
+			expanded = append(expanded, obj)
+		}
+	}
+	return expanded
+}
+
+// checkEgress looks up the current bucket's region before a large download.
+// A failed lookup leaves the region unknown, which never warns.
+func (m Model) checkEgress(objs []aws.S3Object) tea.Cmd {
+	return func() tea.Msg {
+		if m.client == nil {
+			return nil
+		}
+		region, _ := m.client.GetBucketRegion(m.ctx, m.currentBucket)
+		return egressCheckedMsg{objs: objs, region: region}
+	}
+}
+
+// exportListing writes objects to a new file at path, in the format its
+// extension names. An existing file is never overwritten.
+func (m Model) exportListing(path string, objects []aws.S3Object) tea.Cmd {
+	return func() tea.Msg {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err != nil {
+			return listingExportedMsg{path: path, err: err}
+		}
+		err = export.Listing(f, objects, export.FormatForPath(path))
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(path)
+			return listingExportedMsg{path: path, err: err}
+		}
+		count := 0
+		for _, obj := range objects {
+			if !obj.IsPrefix {
+				count++
+			}
+		}
+		return listingExportedMsg{path: path, count: count}
+	}
+}
+
+// makePublic sets a public-read ACL on an object in the current bucket
+func (m Model) makePublic(key string) tea.Cmd {
+	return func() tea.Msg {
+		if m.demoMode {
+			return ErrorMsg{Err: errDemoMode}
+		}
+		if m.client == nil {
+			return nil
+		}
+		return objectPublicMsg{key: key, err: m.client.SetPublicRead(m.ctx, m.currentBucket, key)}
+	}
+}
+
+// loadRetention reads an object's Object Lock retention
+func (m Model) loadRetention(key string) tea.Cmd {
+	return func() tea.Msg {
+		if m.demoMode {
+			return ErrorMsg{Err: errDemoMode}
+		}
+		if m.client == nil {
+			return nil
+		}
+		retention, err := m.client.GetObjectRetention(m.ctx, m.currentBucket, key, "")
+		return retentionLoadedMsg{key: key, retention: retention, err: err}
+	}
+}
+
+// setRetention locks an object in the current bucket until r.Until
+func (m Model) setRetention(key string, r aws.Retention) tea.Cmd {
+	return func() tea.Msg {
+		if m.demoMode {
+			return ErrorMsg{Err: errDemoMode}
+		}
+		if m.client == nil {
+			return nil
+		}
+		err := m.client.SetObjectRetention(m.ctx, m.currentBucket, key, r.Mode, r.Until)
+		return retentionSetMsg{key: key, retention: r, err: err}
+	}
+}
+
+// loadBucketPolicy fetches a bucket's policy for the preview overlay
+func (m Model) loadBucketPolicy(bucket string) tea.Cmd {
+	return func() tea.Msg {
+		if m.demoMode {
+			return ErrorMsg{Err: errDemoMode}
+		}
+		if m.client == nil {
+			return nil
+		}
+		policy, err := m.client.GetBucketPolicy(m.ctx, bucket)
+		return bucketPolicyMsg{bucket: bucket, policy: policy, err: err}
+	}
+}
+
+// loadBucketCORS fetches a bucket's CORS rules for the preview overlay
+func (m Model) loadBucketCORS(bucket string) tea.Cmd {
+	return func() tea.Msg {
+		if m.demoMode {
+			return ErrorMsg{Err: errDemoMode}
+		}
+		if m.client == nil {
+			return nil
+		}
+		rules, err := m.client.GetBucketCORS(m.ctx, bucket)
+		return bucketCORSMsg{bucket: bucket, rules: rules, err: err}
+	}
+}
+
+// loadObjectDetails reads an object's HEAD details for the preview overlay
+func (m Model) loadObjectDetails(key string) tea.Cmd {
+	return func() tea.Msg {
+		if m.demoMode {
+			return ErrorMsg{Err: errDemoMode}
+		}
+		if m.client == nil {
+			return nil
+		}
+		obj, err := m.client.GetObjectMetadata(m.ctx, m.currentBucket, key)
+		return objectDetailsMsg{obj: obj, err: err}
+	}
+}
+
+// openInConsole opens a key of the current bucket in the AWS console,
+// using the bucket's own region so the console doesn't redirect
+func (m Model) openInConsole(key string) tea.Cmd {
+	return func() tea.Msg {
+		if m.demoMode {
+			return ErrorMsg{Err: errDemoMode}
+		}
+		if m.client == nil || m.currentBucket == "" {
+			return nil
+		}
+		region := m.client.Region
+		if r, err := m.client.GetBucketRegion(m.ctx, m.currentBucket); err == nil && r != "" {
+			region = r
+		}
+		if err := platform.OpenInBrowser(aws.ConsoleURL(region, m.currentBucket, key)); err != nil {
+			return ErrorMsg{Err: err}
+		}
+		return nil
+	}
+}
+
+// copyURIs copies the s3:// URIs of the selected keys, or of obj when
+// nothing is selected
+func (m Model) copyURIs(obj aws.S3Object) tea.Cmd {
+	// Snapshot the selection; it may change before the command runs
+	sel := browser.NewSelection()
+	for _, key := range m.browserView.Selection().Keys() {
+		sel.Toggle(key)
+	}
+	if sel.Len() == 0 && obj.Key != "" {
+		sel.Toggle(obj.Key)
+	}
+	bucket, clip := m.currentBucket, m.clipboard
+	return func() tea.Msg {
+		if sel.Len() == 0 {
+			return nil
+		}
+		if sel.Len() == 1 {
+			_, err := browser.CopyS3URI(bucket, sel.Keys()[0], clip)
+			return urisCopiedMsg{count: 1, err: err}
+		}
+		return urisCopiedMsg{count: sel.Len(), err: browser.CopySelectionURIs(sel, bucket, clip)}
+	}
+}
+
+// copyConsoleURL copies the AWS console link for a key of the current
+// bucket, using the bucket's own region like openInConsole
+func (m Model) copyConsoleURL(key string) tea.Cmd {
+	bucket, clip := m.currentBucket, m.clipboard
+	return func() tea.Msg {
+		if m.client == nil || bucket == "" {
+			return nil
+		}
+		region := m.client.Region
+		if r, err := m.client.GetBucketRegion(m.ctx, bucket); err == nil && r != "" {
+			region = r
+		}
+		link, err := browser.CopyConsoleURL(region, bucket, key, clip)
+		return linkCopiedMsg{link: link, err: err}
+	}
+}
+
+// presignedLinkTTL is how long a presigned link copied from the browser works
+const presignedLinkTTL = time.Hour
+
+// copyPresignedURL presigns a download link for an object of the current
+// bucket and copies it to the clipboard
+func (m Model) copyPresignedURL(key string) tea.Cmd {
+	bucket, clip := m.currentBucket, m.clipboard
+	return func() tea.Msg {
+		if m.demoMode {
+			return presignedURLCopiedMsg{key: key, err: errDemoMode}
+		}
+		if m.client == nil || bucket == "" {
+			return nil
+		}
+		presigned, err := m.client.PresignGetInfo(m.ctx, bucket, key, presignedLinkTTL)
+		if err == nil {
+			err = clip.Copy(presigned.URL)
+		}
+		return presignedURLCopiedMsg{key: key, expiresAt: presigned.ExpiresAt, err: err}
+	}
+}
+
+// formatExpiry shows an expiry time as a local clock time, with the date
+// when it isn't today
+func formatExpiry(t, now time.Time) string {
+	t, now = t.Local(), now.Local()
+	if y, m, d := t.Date(); y == now.Year() && m == now.Month() && d == now.Day() {
+		return t.Format("15:04")
+	}
+	return t.Format("Jan 2 15:04")
+}
+
+// previewObject fetches an object for the preview panel. Unless confirmed,
+// objects over the configured preview limit come back as a SizeLimitError.
+func (m Model) previewObject(key string, confirmed bool) tea.Cmd {
+	return func() tea.Msg {
+		if m.demoMode {
+			return ErrorMsg{Err: errDemoMode}
+		}
+		if m.client == nil {
+			return nil
+		}
+		data, err := m.client.PreviewObject(m.ctx, m.currentBucket, key, m.settings.MaxPreviewBytes, confirmed)
+		return objectPreviewMsg{key: key, data: data, err: err}
+	}
+}
+
+// navState returns the current browsing location
+func (m Model) navState() nav.State {
+	return nav.State{Bucket: m.currentBucket, Prefix: m.currentPrefix}
+}
+
+// shutdownGrace is how long quitting waits for in-flight transfers to finish
+const shutdownGrace = 5 * time.Second
+
+// CanQuitCleanly reports whether quitting now would not interrupt a transfer
+func (m Model) CanQuitCleanly() bool {
+	return !m.downloadView.IsActive()
+}
+
+// QuitRequested reports whether a quit is waiting on the user's confirmation
+func (m Model) QuitRequested() bool {
+	return m.quitRequested
+}
+
+// quit exits, first giving running transfers a moment to finish
+func (m Model) quit() (tea.Model, tea.Cmd) {
+	m.quitRequested = false
+	if !m.CanQuitCleanly() && m.downloadMgr != nil && !m.quitting {
+		m.quitting = true
+		m.statusMsg = "Finishing transfers before exit... (q again to quit now)"
+		return m, m.shutdownTransfers()
+	}
+	m.cancel()
+	return m, tea.Quit
+}
+
+// shutdownTransfers drains the download queue, cancelling whatever is still
+// running after shutdownGrace, and then quits
+func (m Model) shutdownTransfers() tea.Cmd {
+	mgr := m.downloadMgr
+	cancel := m.cancel
+	return func() tea.Msg {
+		ctx, stop := context.WithTimeout(context.Background(), shutdownGrace)
+		defer stop()
+		mgr.Shutdown(ctx)
+		cancel()
+		return tea.Quit()
+	}
+}
+
+// recentObjectLimit is how many objects the recently modified panel shows
+const recentObjectLimit = 50
+
+// loadRecent lists every bookmark and collects the newest objects across them
+func (m Model) loadRecent() tea.Cmd {
+	return func() tea.Msg {
+		if m.demoMode {
+			return ErrorMsg{Err: errDemoMode}
+		}
+		if m.client == nil || m.bookmarkStore == nil {
+			return nil
+		}
+		objects, err := bookmarks.RecentAcrossBookmarks(m.ctx, m.client, m.bookmarkStore, recentObjectLimit)
+		return recentLoadedMsg{objects: objects, err: err}
+	}
+}
+
+// recentLoadedMsg carries the newest objects across bookmarks
+type recentLoadedMsg struct {
+	objects []bookmarks.ObjectInfo
+	err     error
+}
+
+// objectPreviewMsg carries the result of a preview fetch
+type objectPreviewMsg struct {
+	key  string
+	data []byte
+	err  error
+}
+
+// renamePrefix moves every object under oldPrefix to newPrefix
+func (m Model) renamePrefix(oldPrefix, newPrefix string) tea.Cmd {
+	return func() tea.Msg {
+		if m.demoMode {
+			return ErrorMsg{Err: errDemoMode}
+		}
+		if m.client == nil {
+			return nil
+		}
+		results, err := m.client.RenamePrefix(m.ctx, m.currentBucket, oldPrefix, newPrefix)
+		return objectRenamedMsg{oldKey: oldPrefix, newKey: newPrefix, moved: len(results), err: err}
+	}
+}
+
+// moveSelection moves the given objects under destPrefix
+func (m Model) moveSelection(keys []string, destPrefix string) tea.Cmd {
+	return func() tea.Msg {
+		if m.demoMode {
+			return ErrorMsg{Err: errDemoMode}
+		}
+		if m.client == nil {
+			return nil
+		}
+		results, err := m.client.MoveSelection(m.ctx, m.currentBucket, keys, destPrefix, aws.OnExistsFail)
+		return selectionMovedMsg{destPrefix: destPrefix, results: results, err: err}
+	}
+}
+
+// selectionMovedMsg reports a move of selected objects to another prefix
+type selectionMovedMsg struct {
+	destPrefix string
+	results    []aws.ObjectResult
+	err        error
+}
+
+// prefixesSizedMsg carries a download whose prefixes have been sized
+type prefixesSizedMsg struct {
+	objs []aws.S3Object
+	// listed holds each prefix's contents, by prefix
+	listed map[string][]aws.S3Object
+	err    error
+}
+
+// egressCheckedMsg carries the bucket region for a large download
+type egressCheckedMsg struct {
+	objs   []aws.S3Object
+	region string
+}
+
+// publicAccessCheckedMsg carries the Block Public Access settings for a make-public request
+type publicAccessCheckedMsg struct {
+	key    string
+	status aws.PABStatus
+	err    error
+}
+
+// urisCopiedMsg is sent when s3:// URIs have been copied to the clipboard
+type urisCopiedMsg struct {
+	count int
+	err   error
+}
+
+// presignedURLCopiedMsg is sent when a presigned link has been copied to the clipboard
+type presignedURLCopiedMsg struct {
+	key       string
+	expiresAt time.Time
+	err       error
+}
+
+// linkCopiedMsg is sent when a console link has been copied to the clipboard
+type linkCopiedMsg struct {
+	link string
+	err  error
+}
+
+// listingExportedMsg is sent when an export file has been written
+type listingExportedMsg struct {
+	path  string
+	count int
+	err   error
+}
+
+// retentionLoadedMsg carries an object's retention, nil when none is set
+type retentionLoadedMsg struct {
+	key       string
+	retention *aws.Retention
+	err       error
+}
+
+// retentionSetMsg is sent when setting retention finishes
+type retentionSetMsg struct {
+	key       string
+	retention aws.Retention
+	err       error
+}
+
+// objectPublicMsg is sent when setting a public-read ACL finishes
+type objectPublicMsg struct {
+	key string
+	err error
+}
+
+// bucketPolicyMsg carries a formatted bucket policy
+type bucketPolicyMsg struct {
+	bucket string
+	policy string
+	err    error
+}
+
+// bucketCORSMsg carries a bucket's CORS rules
+type bucketCORSMsg struct {
+	bucket string
+	rules  []aws.CORSRule
+	err    error
+}
+
+// objectDetailsMsg carries an object's HEAD details
+type objectDetailsMsg struct {
+	obj *aws.S3Object
+	err error
+}
+
+// objectRenamedMsg is sent when a rename finishes
+type objectRenamedMsg struct {
+	oldKey string
+	newKey string
+	moved  int // objects attempted, for prefix renames
+	err    error
+}
+
+// errDemoMode is reported for operations that need a real AWS connection
+var errDemoMode = errors.New("not available in demo mode")
+
+// errAnonymousBuckets explains the empty bucket list when browsing anonymously
+var errAnonymousBuckets = errors.New("listing buckets needs credentials; start with --bucket to open a public bucket")
+
 // tickCmd returns a command that ticks periodically
 func tickCmd() tea.Cmd {
 	return tea.Tick(100*time.Millisecond, func(t time.Time) tea.Msg {
@@ -303,6 +1258,7 @@ func tickCmd() tea.Cmd {
 // Demo mode mock data
 
 func (m Model) loadDemoBuckets() tea.Cmd {
+	load := m.bucketLoad
 	return func() tea.Msg {
 		buckets := []aws.Bucket{
 			{Name: "demo-bucket-1", CreationDate: time.Now().AddDate(0, -6, 0)},
@@ -311,7 +1267,7 @@ func (m Model) loadDemoBuckets() tea.Cmd {
 			{Name: "demo-logs", CreationDate: time.Now().AddDate(0, -1, 0)},
 			{Name: "demo-backups", CreationDate: time.Now().AddDate(-2, 0, 0)},
 		}
-		return BucketsLoadedMsg{Buckets: buckets}
+		return BucketsLoadedMsg{Buckets: buckets, load: load}
 	}
 }
 
@@ -341,4 +1297,3 @@ func (m Model) loadDemoObjects() tea.Cmd {
 		return ObjectsLoadedMsg{Objects: objects, Prefix: m.currentPrefix}
 	}
 }
-
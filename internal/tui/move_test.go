@@ -0,0 +1,54 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/natevick/stui/internal/aws"
+	"github.com/natevick/stui/internal/config"
+)
+
+func TestMovePromptSkipsFolders(t *testing.T) {
+	m := New(Config{Profile: "default", Settings: config.Default()})
+	m.currentPrefix = "inbox/"
+
+	m.showMovePrompt([]aws.S3Object{
+		{Key: "inbox/a.txt"},
+		{Key: "inbox/sub/", IsPrefix: true},
+		{Key: "inbox/b.txt"},
+	})
+	if !m.showPrompt || m.promptType != "move" || m.promptDefault != "inbox/" {
+		t.Fatalf("prompt %q shown=%v default %q, want a move prompt at inbox/", m.promptType, m.showPrompt, m.promptDefault)
+	}
+	if strings.Join(m.pendingMoveKeys, ",") != "inbox/a.txt,inbox/b.txt" {
+		t.Errorf("pendingMoveKeys = %v, want only the objects", m.pendingMoveKeys)
+	}
+
+	m = New(Config{Profile: "default", Settings: config.Default()})
+	m.showMovePrompt([]aws.S3Object{{Key: "inbox/sub/", IsPrefix: true}})
+	if m.showPrompt {
+		t.Error("prompted to move a folder")
+	}
+}
+
+func TestSelectionMovedPartialFailure(t *testing.T) {
+	m := New(Config{Profile: "default", Settings: config.Default()})
+	results := []aws.ObjectResult{
+		{Key: "a.txt", NewKey: "dst/a.txt"},
+		{Key: "b.txt", NewKey: "dst/b.txt", Err: aws.ErrObjectExists},
+	}
+	err := &aws.PartialFailureError{Failed: results[1:], Total: 2}
+
+	updated, _ := m.Update(selectionMovedMsg{destPrefix: "dst/", results: results, err: err})
+	m = updated.(Model)
+	if !strings.Contains(m.errorMsg, "moved 1 of 2 objects") {
+		t.Errorf("errorMsg = %q, want a partial move summary", m.errorMsg)
+	}
+	logged := false
+	for _, e := range m.errorLog.Recent() {
+		logged = logged || e.Context == "Moving b.txt"
+	}
+	if !logged {
+		t.Errorf("error log = %+v, want an entry for b.txt", m.errorLog.Recent())
+	}
+}
@@ -0,0 +1,133 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/natevick/stui/internal/security"
+	"github.com/natevick/stui/internal/views/filebrowser"
+)
+
+// destinationPrompts are the prompts that ask for a local path, where Ctrl+O
+// browses for it
+var destinationPrompts = map[string]bool{
+	"download":       true,
+	"multi-download": true,
+	"sync":           true,
+	"export":         true,
+}
+
+// openFilePicker browses for the prompt's path, starting in the folder it
+// names. With allowed_local_roots set, the picker stays inside the root that
+// folder is in, or the first root when it is in none.
+func (m *Model) openFilePicker() {
+	start := m.promptInput
+	if info, err := os.Stat(start); err != nil || !info.IsDir() {
+		start = filepath.Dir(start)
+	}
+
+	root := ""
+	if roots := m.settings.AllowedLocalRoots; len(roots) > 0 {
+		root = roots[0]
+		inRoot := false
+		for _, r := range roots {
+			if p, err := security.SafePathAny([]string{r}, start); err == nil {
+				root, start, inRoot = r, p, true
+				break
+			}
+		}
+		if !inRoot {
+			start = root
+		}
+	}
+
+	picker, err := filebrowser.New(root)
+	if err != nil {
+		m.showError(err, "")
+		return
+	}
+	picker.SetSize(max(m.width-8, 20), max(m.height-10, 5))
+	if err := picker.Open(start); err != nil {
+		// The typed folder may not exist yet; start from the root instead
+		fallback := root
+		if fallback == "" {
+			fallback = "."
+		}
+		if err := picker.Open(fallback); err != nil {
+			m.showError(err, "")
+			return
+		}
+	}
+	m.filePicker = picker
+	m.showFilePicker = true
+}
+
+// promptWantsFile reports whether the prompt saves to a file rather than
+// into a folder
+func (m Model) promptWantsFile() bool {
+	if m.promptType == "export" {
+		return true
+	}
+	if m.promptType == "download" {
+		obj, _ := m.browserView.SelectedObject()
+		return !obj.IsPrefix
+	}
+	return false
+}
+
+// handleFilePickerKey navigates the picker. Choosing a file or folder puts
+// its path in the prompt to be confirmed; Esc returns to the prompt as it was.
+func (m Model) handleFilePickerKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.Type == tea.KeyEsc && !m.filePicker.Filtering() {
+		m.showFilePicker = false
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.filePicker, cmd = m.filePicker.Update(msg)
+
+	action, path := m.filePicker.ConsumeAction()
+	switch action {
+	case filebrowser.ActionChooseDir:
+		if m.promptWantsFile() {
+			// Keep the suggested name, saved in the chosen folder
+			path = filepath.Join(path, filepath.Base(m.promptInput))
+		}
+	case filebrowser.ActionChoose:
+		if !m.promptWantsFile() {
+			path = filepath.Dir(path)
+		}
+	default:
+		return m, cmd
+	}
+	m.promptInput = path
+	m.promptCursor = len(path)
+	m.showFilePicker = false
+	return m, cmd
+}
+
+func (m Model) renderWithFilePicker() string {
+	pickerStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ColorPrimary).
+		Padding(0, 1)
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		m.styles.Title.Render(m.promptText),
+		m.filePicker.View(),
+		m.styles.Dim.Render("Enter open folder / choose file • Space choose this folder • . hidden files • Esc back"),
+	)
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		pickerStyle.Render(content),
+		lipgloss.WithWhitespaceChars(" "),
+		lipgloss.WithWhitespaceForeground(lipgloss.Color("0")),
+	)
+}
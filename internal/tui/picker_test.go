@@ -0,0 +1,75 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/natevick/stui/internal/aws"
+	"github.com/natevick/stui/internal/config"
+)
+
+func TestFilePickerFillsDestinationPrompt(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(root, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	settings := config.Default()
+	settings.AllowedLocalRoots = []string{root}
+	m := New(Config{Profile: "default", Settings: settings})
+	m.SetSize(120, 40)
+	m.showMultiDownloadPrompt([]aws.S3Object{{Key: "a"}, {Key: "b"}})
+	m.promptInput = filepath.Join(root, "missing")
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlO})
+	m = updated.(Model)
+	if !m.showFilePicker || m.filePicker.Dir() != root {
+		t.Fatalf("picker open = %v in %q, want open in %q", m.showFilePicker, m.filePicker.Dir(), root)
+	}
+
+	// The picker can't leave the allowed root
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	m = updated.(Model)
+	if m.filePicker.Dir() != root {
+		t.Errorf("picker left the root for %q", m.filePicker.Dir())
+	}
+
+	// Enter opens sub/, Space chooses it
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")})
+	m = updated.(Model)
+	if m.showFilePicker || !m.showPrompt {
+		t.Fatal("choosing a folder should return to the prompt")
+	}
+	if want := filepath.Join(root, "sub"); m.promptInput != want {
+		t.Errorf("promptInput = %q, want %q", m.promptInput, want)
+	}
+}
+
+func TestFilePickerKeepsExportName(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := New(Config{Profile: "default", Settings: config.Default()})
+	m.SetSize(120, 40)
+	m.showPrompt = true
+	m.promptType = "export"
+	m.promptInput = "./listing.csv"
+
+	m.openFilePicker()
+	if err := m.filePicker.Open(dir); err != nil {
+		t.Fatal(err)
+	}
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")})
+	m = updated.(Model)
+	if want := filepath.Join(dir, "listing.csv"); m.promptInput != want {
+		t.Errorf("promptInput = %q, want %q", m.promptInput, want)
+	}
+}
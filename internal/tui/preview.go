@@ -0,0 +1,110 @@
+package tui
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/dustin/go-humanize"
+	"github.com/natevick/stui/internal/aws"
+	"github.com/natevick/stui/internal/bookmarks"
+)
+
+// previewText renders object contents for the preview panel. Binary data is
+// summarized rather than dumped, and control characters are stripped so the
+// object can't drive the terminal.
+func previewText(data []byte, maxLines int) string {
+	if !utf8.Valid(data) || bytes.IndexByte(data, 0) >= 0 {
+		return fmt.Sprintf("Binary content (%s)", humanize.Bytes(uint64(len(data))))
+	}
+
+	text := strings.ReplaceAll(displaySafe(string(data)), "\t", "    ")
+
+	lines := strings.Split(text, "\n")
+	if maxLines > 0 && len(lines) > maxLines {
+		more := len(lines) - maxLines
+		lines = append(lines[:maxLines], fmt.Sprintf("... %d more lines", more))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// displaySafe strips control characters other than newlines and tabs
+func displaySafe(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' || !unicode.IsControl(r) {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// formatCORS renders CORS rules as a readable list, one block per rule
+func formatCORS(rules []aws.CORSRule) string {
+	if len(rules) == 0 {
+		return "No CORS configuration."
+	}
+
+	var b strings.Builder
+	for i, r := range rules {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		title := fmt.Sprintf("Rule %d", i+1)
+		if r.ID != "" {
+			title += " (" + r.ID + ")"
+		}
+		b.WriteString(title + "\n")
+		fmt.Fprintf(&b, "  Origins:  %s\n", strings.Join(r.AllowedOrigins, ", "))
+		fmt.Fprintf(&b, "  Methods:  %s\n", strings.Join(r.AllowedMethods, ", "))
+		if len(r.AllowedHeaders) > 0 {
+			fmt.Fprintf(&b, "  Headers:  %s\n", strings.Join(r.AllowedHeaders, ", "))
+		}
+		if len(r.ExposeHeaders) > 0 {
+			fmt.Fprintf(&b, "  Exposes:  %s\n", strings.Join(r.ExposeHeaders, ", "))
+		}
+		if r.MaxAgeSeconds > 0 {
+			fmt.Fprintf(&b, "  Max age:  %ds\n", r.MaxAgeSeconds)
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// formatObjectDetails renders an object's HEAD details, one field per line
+func formatObjectDetails(obj aws.S3Object) string {
+	encryption := obj.ServerSideEncryption
+	if encryption == "" {
+		encryption = "none"
+	}
+	replication := obj.ReplicationStatus
+	if replication == "" {
+		replication = "none"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Size:          %s (%d bytes)\n", humanize.Bytes(uint64(obj.Size)), obj.Size)
+	fmt.Fprintf(&b, "Modified:      %s\n", obj.LastModified.Local().Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(&b, "ETag:          %s\n", obj.ETag)
+	fmt.Fprintf(&b, "Content type:  %s\n", obj.ContentType)
+	fmt.Fprintf(&b, "Storage class: %s\n", obj.StorageClass)
+	fmt.Fprintf(&b, "Encryption:    %s\n", encryption)
+	fmt.Fprintf(&b, "Replication:   %s", replication)
+	return b.String()
+}
+
+// formatRecent renders the recently modified panel, one object per line
+func formatRecent(objects []bookmarks.ObjectInfo) string {
+	if len(objects) == 0 {
+		return "No objects found under your bookmarks."
+	}
+
+	var b strings.Builder
+	for _, o := range objects {
+		fmt.Fprintf(&b, "%s  %9s  %s\n",
+			o.LastModified.Local().Format("2006-01-02 15:04"),
+			humanize.Bytes(uint64(o.Size)),
+			o.URI())
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,128 @@
+package tui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/natevick/stui/internal/aws"
+	"github.com/natevick/stui/internal/config"
+)
+
+func TestPreviewText(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		maxLines int
+		want     string
+	}{
+		{"plain text", []byte("hello\nworld"), 10, "hello\nworld"},
+		{"truncated", []byte("a\nb\nc\nd"), 2, "a\nb\n... 2 more lines"},
+		{"binary", []byte{0x00, 0x01, 0x02}, 10, "Binary content (3 B)"},
+		{"invalid utf8", []byte{0xff, 0xfe}, 10, "Binary content (2 B)"},
+		{"escape sequences stripped", []byte("red\x1b[31mtext"), 10, "red[31mtext"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := previewText(tt.data, tt.maxLines)
+			if got != tt.want {
+				t.Errorf("previewText() = %q, want %q", got, tt.want)
+			}
+			if strings.ContainsRune(got, 0x1b) {
+				t.Error("preview contains an escape character")
+			}
+		})
+	}
+}
+
+func TestFormatObjectDetailsReplication(t *testing.T) {
+	tests := []struct {
+		status string
+		want   string
+	}{
+		{"PENDING", "Replication:   PENDING"},
+		{"REPLICA", "Replication:   REPLICA"},
+		{"", "Replication:   none"},
+	}
+
+	for _, tt := range tests {
+		got := formatObjectDetails(aws.S3Object{Key: "a.txt", ReplicationStatus: tt.status})
+		if !strings.Contains(got, tt.want) {
+			t.Errorf("formatObjectDetails(%q) = %q, want a line %q", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestDownloadWithPrefixIsSizedFirst(t *testing.T) {
+	selection := []aws.S3Object{
+		{Key: "exports/notes.txt", Size: 1 << 10},
+		{Key: "exports/archive/", IsPrefix: true},
+	}
+
+	m := New(Config{Profile: "default", Settings: config.Default()})
+	if !m.needsSizing(selection) {
+		t.Fatal("expected a selection with a prefix to be sized")
+	}
+	if m.needsSizing(selection[:1]) {
+		t.Error("objects alone don't need sizing")
+	}
+
+	// The prefix holds more than the limit
+	sized := []aws.S3Object{selection[0], selection[1]}
+	sized[1].Size = 2 << 30
+	updated, _ := m.Update(prefixesSizedMsg{objs: sized})
+	m = updated.(Model)
+	if !m.showPrompt || m.promptType != "confirm-download" {
+		t.Fatalf("prompt = %v/%q, want confirm-download", m.showPrompt, m.promptType)
+	}
+	if !strings.Contains(m.promptText, "2.1 GB") {
+		t.Errorf("prompt %q doesn't count the prefix's contents", m.promptText)
+	}
+
+	// A prefix that couldn't be listed still asks
+	m = New(Config{Profile: "default", Settings: config.Default()})
+	updated, _ = m.Update(prefixesSizedMsg{objs: selection, err: errors.New("access denied")})
+	m = updated.(Model)
+	if !m.showPrompt || m.promptType != "confirm-download" || len(m.pendingLargeDownload) != 2 {
+		t.Errorf("prompt = %v/%q with %d pending, want confirm-download for both", m.showPrompt, m.promptType, len(m.pendingLargeDownload))
+	}
+
+	// A small prefix goes straight to the download prompt
+	m = New(Config{Profile: "default", Settings: config.Default()})
+	sized[1].Size = 1 << 20
+	updated, _ = m.Update(prefixesSizedMsg{objs: sized})
+	m = updated.(Model)
+	if !m.showPrompt || m.promptType != "multi-download" {
+		t.Errorf("prompt = %v/%q, want multi-download", m.showPrompt, m.promptType)
+	}
+}
+
+func TestDownloadReusesSizingListing(t *testing.T) {
+	m := New(Config{Profile: "default", Settings: config.Default()})
+	m.currentPrefix = "exports/"
+	contents := []aws.S3Object{
+		{Key: "exports/archive/a.csv", Size: 10},
+		{Key: "exports/archive/b.csv", Size: 20},
+	}
+	selection := []aws.S3Object{
+		{Key: "exports/notes.txt", Size: 1 << 10},
+		{Key: "exports/archive/", IsPrefix: true, Size: 30},
+	}
+	updated, _ := m.Update(prefixesSizedMsg{objs: selection, listed: map[string][]aws.S3Object{"exports/archive/": contents}})
+	m = updated.(Model)
+
+	got := m.expandSizedPrefixes(m.pendingDownloadObjects)
+	want := []string{"exports/notes.txt", "exports/archive/a.csv", "exports/archive/b.csv"}
+	if len(got) != len(want) {
+		t.Fatalf("expanded to %v, want %v", got, want)
+	}
+	for i, obj := range got {
+		if obj.Key != want[i] || obj.IsPrefix {
+			t.Errorf("expanded[%d] = %+v, want %s", i, obj, want[i])
+		}
+	}
+	if m.sizedPrefixes != nil {
+		t.Error("a listing must be used once")
+	}
+}
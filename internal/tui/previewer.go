@@ -0,0 +1,215 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"mime"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Previewer turns fetched object contents into lines for the preview panel.
+// The lines are still made display-safe and cut to the panel afterwards.
+type Previewer interface {
+	// Name identifies the previewer, e.g. "json"
+	Name() string
+	// Lines renders data; an error falls back to the registry's default
+	Lines(data []byte) ([]string, error)
+}
+
+// PreviewerRegistry picks a Previewer for an object by its content type,
+// then by its key's extension, then falls back to a default
+type PreviewerRegistry struct {
+	byType   map[string]Previewer // media type without parameters, lowercased
+	byExt    map[string]Previewer // extension with the dot, lowercased
+	fallback Previewer
+}
+
+// NewPreviewerRegistry creates a registry that uses fallback for anything
+// not registered
+func NewPreviewerRegistry(fallback Previewer) *PreviewerRegistry {
+	return &PreviewerRegistry{
+		byType:   make(map[string]Previewer),
+		byExt:    make(map[string]Previewer),
+		fallback: fallback,
+	}
+}
+
+// DefaultPreviewers returns a registry with the built-in previewers: pretty
+// JSON, CSV and TSV as aligned columns, plain text, and a passthrough default
+func DefaultPreviewers() *PreviewerRegistry {
+	r := NewPreviewerRegistry(passthroughPreviewer{})
+	for _, t := range []string{"text/plain", "text/markdown", "text/x-log"} {
+		r.RegisterType(t, textPreviewer{})
+	}
+	for _, ext := range []string{".txt", ".log", ".md"} {
+		r.RegisterExtension(ext, textPreviewer{})
+	}
+	for _, t := range []string{"application/json", "text/json", "application/x-ndjson"} {
+		r.RegisterType(t, jsonPreviewer{})
+	}
+	r.RegisterExtension(".json", jsonPreviewer{})
+	r.RegisterType("text/csv", csvPreviewer{comma: ','})
+	r.RegisterType("application/csv", csvPreviewer{comma: ','})
+	r.RegisterExtension(".csv", csvPreviewer{comma: ','})
+	r.RegisterType("text/tab-separated-values", csvPreviewer{comma: '\t'})
+	r.RegisterExtension(".tsv", csvPreviewer{comma: '\t'})
+	return r
+}
+
+// RegisterType makes p the previewer for a content type such as
+// "application/json"; parameters like "; charset=utf-8" are ignored
+func (r *PreviewerRegistry) RegisterType(contentType string, p Previewer) {
+	r.byType[mediaType(contentType)] = p
+}
+
+// RegisterExtension makes p the previewer for keys ending in ext, e.g. ".csv"
+func (r *PreviewerRegistry) RegisterExtension(ext string, p Previewer) {
+	r.byExt[strings.ToLower(ext)] = p
+}
+
+// For returns the previewer for an object. Generic content types such as
+// application/octet-stream say nothing, so the extension decides for those.
+func (r *PreviewerRegistry) For(contentType, key string) Previewer {
+	if p, ok := r.byType[mediaType(contentType)]; ok {
+		return p
+	}
+	if p, ok := r.byExt[strings.ToLower(path.Ext(key))]; ok {
+		return p
+	}
+	return r.fallback
+}
+
+// Render renders data with the object's previewer, using the default if
+// that fails or the data isn't text. It returns the previewer that was used.
+func (r *PreviewerRegistry) Render(contentType, key string, data []byte) (Previewer, []string) {
+	if utf8.Valid(data) && bytes.IndexByte(data, 0) < 0 {
+		p := r.For(contentType, key)
+		if lines, err := p.Lines(data); err == nil {
+			return p, lines
+		}
+	}
+	lines, _ := r.fallback.Lines(data)
+	return r.fallback, lines
+}
+
+// mediaType lowercases a content type and drops its parameters
+func mediaType(contentType string) string {
+	if t, _, err := mime.ParseMediaType(contentType); err == nil {
+		return t
+	}
+	return strings.ToLower(strings.TrimSpace(contentType))
+}
+
+// passthroughPreviewer shows the contents as they are
+type passthroughPreviewer struct{}
+
+func (passthroughPreviewer) Name() string { return "raw" }
+
+func (passthroughPreviewer) Lines(data []byte) ([]string, error) {
+	return strings.Split(string(data), "\n"), nil
+}
+
+// textPreviewer shows text with Windows line endings normalized
+type textPreviewer struct{}
+
+func (textPreviewer) Name() string { return "text" }
+
+func (textPreviewer) Lines(data []byte) ([]string, error) {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n"), nil
+}
+
+// jsonPreviewer pretty-prints JSON documents, one after another for NDJSON
+type jsonPreviewer struct{}
+
+func (jsonPreviewer) Name() string { return "json" }
+
+func (jsonPreviewer) Lines(data []byte) ([]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	var out bytes.Buffer
+	for dec.More() {
+		var doc json.RawMessage
+		if err := dec.Decode(&doc); err != nil {
+			return nil, err
+		}
+		if out.Len() > 0 {
+			out.WriteByte('\n')
+		}
+		if err := json.Indent(&out, doc, "", "  "); err != nil {
+			return nil, err
+		}
+	}
+	return strings.Split(out.String(), "\n"), nil
+}
+
+// maxCSVColumnWidth caps a column so one long field doesn't push the rest
+// off the panel
+const maxCSVColumnWidth = 40
+
+// csvPreviewer lines up delimited rows in columns
+type csvPreviewer struct {
+	comma rune
+}
+
+func (p csvPreviewer) Name() string {
+	if p.comma == '\t' {
+		return "tsv"
+	}
+	return "csv"
+}
+
+func (p csvPreviewer) Lines(data []byte) ([]string, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = p.comma
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var widths []int
+	for _, row := range rows {
+		for i, field := range row {
+			row[i] = truncateField(displaySafe(field), maxCSVColumnWidth)
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], lipgloss.Width(row[i]))
+		}
+	}
+
+	lines := make([]string, len(rows))
+	for r, row := range rows {
+		var sb strings.Builder
+		for i, field := range row {
+			sb.WriteString(field)
+			if i < len(row)-1 {
+				sb.WriteString(strings.Repeat(" ", widths[i]-lipgloss.Width(field)+2))
+			}
+		}
+		lines[r] = sb.String()
+	}
+	return lines, nil
+}
+
+// truncateField keeps a field to one line of at most width cells
+func truncateField(s string, width int) string {
+	s = strings.NewReplacer("\n", " ", "\t", " ").Replace(s)
+	if lipgloss.Width(s) <= width {
+		return s
+	}
+	var sb strings.Builder
+	for _, r := range s {
+		if lipgloss.Width(sb.String()+string(r)) > width-1 {
+			break
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String() + "…"
+}
@@ -0,0 +1,65 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPreviewerRegistryFor(t *testing.T) {
+	r := DefaultPreviewers()
+	tests := []struct {
+		name        string
+		contentType string
+		key         string
+		want        string
+	}{
+		{"json content type", "application/json", "data", "json"},
+		{"json content type with charset", "application/json; charset=utf-8", "data.bin", "json"},
+		{"csv extension", "", "reports/q1.csv", "csv"},
+		{"csv extension any case", "", "reports/Q1.CSV", "csv"},
+		{"generic content type uses the extension", "application/octet-stream", "reports/q1.csv", "csv"},
+		{"content type beats extension", "text/csv", "export.json", "csv"},
+		{"unknown type", "application/x-unknown", "blob", "raw"},
+		{"nothing known", "", "README", "raw"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.For(tt.contentType, tt.key).Name(); got != tt.want {
+				t.Errorf("For(%q, %q) = %s, want %s", tt.contentType, tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPreviewerRegistryRender(t *testing.T) {
+	r := DefaultPreviewers()
+
+	p, lines := r.Render("application/json", "a", []byte(`{"name":"app","replicas":2}`))
+	if p.Name() != "json" {
+		t.Fatalf("Render() used %s, want json", p.Name())
+	}
+	if want := "{\n  \"name\": \"app\",\n  \"replicas\": 2\n}"; strings.Join(lines, "\n") != want {
+		t.Errorf("JSON lines = %q, want %q", lines, want)
+	}
+
+	p, lines = r.Render("", "people.csv", []byte("name,age\nalexandra,31\nbo,4\n"))
+	if p.Name() != "csv" {
+		t.Fatalf("Render() used %s, want csv", p.Name())
+	}
+	want := []string{
+		"name       age",
+		"alexandra  31",
+		"bo         4",
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("CSV lines = %q, want %q", lines, want)
+	}
+
+	// Broken JSON and binary data fall back to the default
+	if p, lines := r.Render("application/json", "a", []byte(`{"name":`)); p.Name() != "raw" || lines[0] != `{"name":` {
+		t.Errorf("Render(broken JSON) = %s %q, want the raw text", p.Name(), lines)
+	}
+	if p, _ := r.Render("text/csv", "a.csv", []byte{0x00, 0xff}); p.Name() != "raw" {
+		t.Errorf("Render(binary) used %s, want raw", p.Name())
+	}
+}
@@ -0,0 +1,41 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/natevick/stui/internal/aws"
+	"github.com/natevick/stui/internal/config"
+)
+
+func TestMakePublicPromptWarns(t *testing.T) {
+	tests := []struct {
+		name   string
+		status aws.PABStatus
+		want   string
+	}{
+		{"exposed", aws.PABStatus{}, "readable by anyone"},
+		{"blocked by bucket", aws.PABStatus{Bucket: &aws.PublicAccessBlock{BlockPublicACLs: true}}, "will fail"},
+		{"ignored by account", aws.PABStatus{Account: &aws.PublicAccessBlock{IgnorePublicACLs: true}}, "stay private"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New(Config{Profile: "default", Settings: config.Default()})
+			m.currentBucket = "b"
+
+			updated, _ := m.Update(publicAccessCheckedMsg{key: "docs/report.pdf", status: tt.status})
+			m = updated.(Model)
+
+			if !m.showPrompt || m.promptType != "confirm-public" {
+				t.Fatalf("prompt = %v/%q, want confirm-public", m.showPrompt, m.promptType)
+			}
+			if !strings.Contains(m.promptText, tt.want) {
+				t.Errorf("prompt %q does not mention %q", m.promptText, tt.want)
+			}
+			if m.pendingPublicKey != "docs/report.pdf" {
+				t.Errorf("pendingPublicKey = %q", m.pendingPublicKey)
+			}
+		})
+	}
+}
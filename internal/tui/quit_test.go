@@ -0,0 +1,77 @@
+package tui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/natevick/stui/internal/config"
+	"github.com/natevick/stui/internal/download"
+)
+
+var quitKey = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")}
+
+func isQuit(cmd tea.Cmd) bool {
+	if cmd == nil {
+		return false
+	}
+	_, ok := cmd().(tea.QuitMsg)
+	return ok
+}
+
+func TestQuitWithoutTransfers(t *testing.T) {
+	m := New(Config{Profile: "default", Settings: config.Default()})
+	if !m.CanQuitCleanly() {
+		t.Fatal("CanQuitCleanly() = false with no transfers")
+	}
+
+	updated, cmd := m.Update(quitKey)
+	m = updated.(Model)
+	if !isQuit(cmd) {
+		t.Error("q did not quit with no active transfers")
+	}
+	if m.showPrompt || m.QuitRequested() {
+		t.Error("quit asked for confirmation with no active transfers")
+	}
+}
+
+func TestQuitWithActiveTransfersNeedsConfirmation(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     []tea.KeyMsg
+		wantQuit bool
+	}{
+		{"confirmed", []tea.KeyMsg{{Type: tea.KeyRunes, Runes: []rune("y")}, {Type: tea.KeyEnter}}, true},
+		{"declined", []tea.KeyMsg{{Type: tea.KeyRunes, Runes: []rune("n")}, {Type: tea.KeyEnter}}, false},
+		{"cancelled", []tea.KeyMsg{{Type: tea.KeyEsc}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New(Config{Profile: "default", Settings: config.Default()})
+			m.downloadView.SetProgress(download.Progress{TotalFiles: 1, Status: download.StatusInProgress})
+			if m.CanQuitCleanly() {
+				t.Fatal("CanQuitCleanly() = true with an active transfer")
+			}
+
+			updated, cmd := m.Update(quitKey)
+			m = updated.(Model)
+			if isQuit(cmd) {
+				t.Fatal("q quit without confirmation during a transfer")
+			}
+			if !m.QuitRequested() || !m.showPrompt || m.promptType != "confirm-quit" {
+				t.Fatalf("QuitRequested() = %v, prompt = %v/%q, want confirm-quit", m.QuitRequested(), m.showPrompt, m.promptType)
+			}
+
+			for _, k := range tt.keys {
+				updated, cmd = m.Update(k)
+				m = updated.(Model)
+			}
+			if got := isQuit(cmd); got != tt.wantQuit {
+				t.Errorf("quit = %v, want %v", got, tt.wantQuit)
+			}
+			if m.QuitRequested() {
+				t.Error("QuitRequested() still set after answering")
+			}
+		})
+	}
+}
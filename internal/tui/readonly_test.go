@@ -0,0 +1,128 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/natevick/stui/internal/aws"
+	"github.com/natevick/stui/internal/config"
+)
+
+func TestReadOnlyMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		flag     bool
+		setting  bool
+		readOnly bool
+	}{
+		{"off", false, false, false},
+		{"flag", true, false, true},
+		{"setting", false, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := config.Default()
+			settings.ReadOnly = tt.setting
+			m := New(Config{Profile: "default", ReadOnly: tt.flag, Settings: settings})
+
+			if got := m.clientOptions().ReadOnly; got != tt.readOnly {
+				t.Errorf("client ReadOnly = %v, want %v", got, tt.readOnly)
+			}
+			if got := m.refuseReadOnly("Renaming"); got != tt.readOnly {
+				t.Errorf("refuseReadOnly() = %v, want %v", got, tt.readOnly)
+			}
+			if tt.readOnly && !strings.Contains(m.statusMsg, "read-only") {
+				t.Errorf("statusMsg = %q, want a read-only notice", m.statusMsg)
+			}
+		})
+	}
+}
+
+func TestReadOnlyRetentionIsShownNotPrompted(t *testing.T) {
+	m := New(Config{Profile: "default", ReadOnly: true, Settings: config.Default()})
+
+	updated, _ := m.Update(retentionLoadedMsg{key: "docs/a.txt", retention: &aws.Retention{Mode: aws.RetentionGovernance}})
+	m = updated.(Model)
+	if m.showPrompt {
+		t.Errorf("prompt %q shown in read-only mode", m.promptType)
+	}
+	if !strings.Contains(m.statusMsg, "a.txt") {
+		t.Errorf("statusMsg = %q, want the current retention", m.statusMsg)
+	}
+}
+
+func TestAnonymousModeIsReadOnly(t *testing.T) {
+	m := New(Config{Anonymous: true, Bucket: "public-data", Settings: config.Default()})
+
+	opts := m.clientOptions()
+	if !opts.Anonymous || !opts.ReadOnly {
+		t.Errorf("client options Anonymous=%v ReadOnly=%v, want both", opts.Anonymous, opts.ReadOnly)
+	}
+	if m.activeView == ViewProfiles {
+		t.Error("anonymous mode opened the profile picker")
+	}
+	if !m.refuseReadOnly("Renaming") {
+		t.Error("refuseReadOnly() allowed a change in anonymous mode")
+	}
+}
+
+// pingRecorder records the requests a connection check makes
+type pingRecorder struct {
+	aws.S3API
+	calls []string
+}
+
+func (p *pingRecorder) ListBuckets(ctx context.Context, in *s3.ListBucketsInput, _ ...func(*s3.Options)) (*s3.ListBucketsOutput, error) {
+	p.calls = append(p.calls, "ListBuckets")
+	return &s3.ListBucketsOutput{}, nil
+}
+
+func (p *pingRecorder) HeadBucket(ctx context.Context, in *s3.HeadBucketInput, _ ...func(*s3.Options)) (*s3.HeadBucketOutput, error) {
+	p.calls = append(p.calls, "HeadBucket "+*in.Bucket)
+	return &s3.HeadBucketOutput{}, nil
+}
+
+func TestAnonymousPingChecksTheBucket(t *testing.T) {
+	// Unsigned ListBuckets always fails, so there's nothing to check without a bucket
+	m := New(Config{Anonymous: true, Settings: config.Default()})
+	m.client = &aws.Client{S3: &pingRecorder{}}
+	if cmd := m.pingEndpoint(); cmd != nil {
+		t.Error("anonymous session without a bucket ran a connection check")
+	}
+
+	rec := &pingRecorder{}
+	m = New(Config{Anonymous: true, Bucket: "public-data", Settings: config.Default()})
+	m.client = &aws.Client{S3: rec}
+	cmd := m.pingEndpoint()
+	if cmd == nil {
+		t.Fatal("anonymous session with a bucket skipped the connection check")
+	}
+	if msg, ok := cmd().(pingResultMsg); !ok || msg.err != nil {
+		t.Errorf("ping = %+v, want a successful pingResultMsg", msg)
+	}
+	if want := []string{"HeadBucket public-data"}; !slices.Equal(rec.calls, want) {
+		t.Errorf("ping made %v, want %v", rec.calls, want)
+	}
+}
+
+func TestPingDeniedStaysConnected(t *testing.T) {
+	m := New(Config{Profile: "dev", Settings: config.Default()})
+	denied := &aws.OpError{Op: "Connection check", Err: fmt.Errorf("%w: AccessDenied", aws.ErrCheckDenied)}
+
+	updated, _ := m.Update(pingResultMsg{err: denied})
+	m = updated.(Model)
+	if got := m.statusBar.Render(200); !strings.HasPrefix(got, "●") || strings.Contains(got, "⚠") {
+		t.Error("a refused connection check marked the session disconnected")
+	}
+	if !strings.Contains(m.statusMsg, "not allowed to list buckets") {
+		t.Errorf("statusMsg = %q, want a note about the refused check", m.statusMsg)
+	}
+	if m.errorLog.Len() != 0 {
+		t.Error("a refused connection check was logged as an error")
+	}
+}
@@ -0,0 +1,28 @@
+package tui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/natevick/stui/internal/bookmarks"
+	"github.com/natevick/stui/internal/config"
+)
+
+func TestRecentLoadedReportsSkippedBookmarks(t *testing.T) {
+	m := New(Config{Profile: "default", Settings: config.Default()})
+
+	skipped := &bookmarks.SkippedBookmarksError{Errs: []error{errors.New("failed to list big-bucket/: too many objects")}, Total: 3}
+	updated, _ := m.Update(recentLoadedMsg{objects: []bookmarks.ObjectInfo{}, err: skipped})
+	m = updated.(Model)
+
+	if !m.showPreview {
+		t.Error("the objects from the other bookmarks should still be shown")
+	}
+	if !strings.Contains(m.statusMsg, "1 of 3 bookmarks skipped") {
+		t.Errorf("statusMsg = %q, want the skipped count", m.statusMsg)
+	}
+	if m.errorLog.Len() != 1 || !strings.Contains(m.errorLog.Recent()[0].Message, "big-bucket") {
+		t.Errorf("error log = %+v, want the skipped bookmark", m.errorLog.Recent())
+	}
+}
@@ -0,0 +1,74 @@
+package tui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/natevick/stui/internal/aws"
+	"github.com/natevick/stui/internal/config"
+	"github.com/natevick/stui/internal/download"
+)
+
+func refreshModel() Model {
+	settings := config.Default()
+	settings.AutoRefreshSeconds = 30
+
+	m := New(Config{Profile: "default", Settings: settings})
+	m.activeView = ViewBrowser
+	m.currentBucket = "b"
+	m.browserView.SetBucket("b")
+	return m
+}
+
+func TestAutoRefreshDue(t *testing.T) {
+	now := time.Now()
+
+	m := refreshModel()
+	if !m.autoRefreshDue(now) {
+		t.Error("expected refresh to be due on first tick")
+	}
+
+	m.lastAutoRefresh = now.Add(-10 * time.Second)
+	if m.autoRefreshDue(now) {
+		t.Error("refresh should wait for the interval")
+	}
+
+	m.settings.AutoRefreshSeconds = 0
+	if m.autoRefreshDue(now) {
+		t.Error("refresh should be off by default")
+	}
+}
+
+func TestAutoRefreshPausedDuringTransfer(t *testing.T) {
+	m := refreshModel()
+	m.downloadView.SetProgress(download.Progress{Status: download.StatusInProgress})
+
+	if m.autoRefreshDue(time.Now()) {
+		t.Error("refresh should be suppressed while a transfer is running")
+	}
+
+	m.downloadView.SetProgress(download.Progress{Status: download.StatusCompleted})
+	if !m.autoRefreshDue(time.Now()) {
+		t.Error("refresh should resume once the transfer finishes")
+	}
+}
+
+func TestRefreshFromAnotherBucketIsIgnored(t *testing.T) {
+	m := refreshModel()
+	m.currentBucket = "other"
+	m.browserView.SetBucket("other")
+	m.browserView.SetObjects([]aws.S3Object{{Key: "other.txt"}})
+
+	// A refresh of b's root lands after the user opened other's root
+	updated, _ := m.Update(ObjectsLoadedMsg{Objects: []aws.S3Object{{Key: "b.txt"}}, Bucket: "b", Refresh: true})
+	m = updated.(Model)
+	if got := m.browserView.VisibleObjects(); len(got) != 1 || got[0].Key != "other.txt" {
+		t.Errorf("listing = %v, want only other.txt", got)
+	}
+
+	updated, _ = m.Update(ObjectsLoadedMsg{Objects: []aws.S3Object{{Key: "other.txt"}, {Key: "new.txt"}}, Bucket: "other", Refresh: true})
+	m = updated.(Model)
+	if got := m.browserView.VisibleObjects(); len(got) != 2 {
+		t.Errorf("listing = %v, want the refresh of the current bucket merged", got)
+	}
+}
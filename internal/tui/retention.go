@@ -0,0 +1,54 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/natevick/stui/internal/aws"
+)
+
+// formatRetention renders retention as "COMPLIANCE until 2030-01-02"
+func formatRetention(r aws.Retention) string {
+	return fmt.Sprintf("%s until %s", r.Mode, r.Until.UTC().Format(time.DateOnly))
+}
+
+// showRetentionPrompt shows an object's current retention and asks for a new one
+func (m *Model) showRetentionPrompt(key string, current *aws.Retention) {
+	status := "No retention"
+	m.promptDefault = "governance "
+	if current != nil {
+		status = formatRetention(*current)
+		m.promptDefault = fmt.Sprintf("%s %s", strings.ToLower(string(current.Mode)), current.Until.UTC().Format(time.DateOnly))
+	}
+	m.showPrompt = true
+	m.promptType = "retention"
+	m.promptInput = m.promptDefault
+	m.promptCursor = len(m.promptInput)
+	m.promptText = fmt.Sprintf("'%s': %s. Set retention (governance|compliance YYYY-MM-DD):", filepath.Base(key), status)
+	m.pendingRetentionKey = key
+}
+
+// parseRetentionInput parses "governance 2030-01-02" or "compliance 2030-01-02T15:04:05Z".
+// A bare date means midnight UTC at the start of that day.
+func parseRetentionInput(input string) (aws.Retention, error) {
+	fields := strings.Fields(input)
+	if len(fields) != 2 {
+		return aws.Retention{}, fmt.Errorf("expected a mode and a date, e.g. governance 2030-01-02")
+	}
+	mode, err := aws.ParseRetentionMode(fields[0])
+	if err != nil {
+		return aws.Retention{}, err
+	}
+	until, err := time.Parse(time.DateOnly, fields[1])
+	if err != nil {
+		if until, err = time.Parse(time.RFC3339, fields[1]); err != nil {
+			return aws.Retention{}, fmt.Errorf("invalid date %q: use YYYY-MM-DD", fields[1])
+		}
+	}
+	if !until.After(time.Now()) {
+		return aws.Retention{}, fmt.Errorf("retention date %s is not in the future", fields[1])
+	}
+	return aws.Retention{Mode: mode, Until: until}, nil
+}
@@ -0,0 +1,62 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/natevick/stui/internal/aws"
+	"github.com/natevick/stui/internal/config"
+)
+
+func TestParseRetentionInput(t *testing.T) {
+	next := time.Now().AddDate(1, 0, 0).UTC().Format(time.DateOnly)
+
+	tests := []struct {
+		input    string
+		wantMode aws.RetentionMode
+		wantErr  bool
+	}{
+		{"governance " + next, aws.RetentionGovernance, false},
+		{"Compliance " + next, aws.RetentionCompliance, false},
+		{"governance 2001-01-01", "", true},
+		{"governance", "", true},
+		{"legal-hold " + next, "", true},
+		{"governance next-week", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseRetentionInput(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseRetentionInput(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got.Mode != tt.wantMode {
+			t.Errorf("parseRetentionInput(%q) mode = %q, want %q", tt.input, got.Mode, tt.wantMode)
+		}
+	}
+}
+
+func TestComplianceRetentionNeedsConfirmation(t *testing.T) {
+	m := New(Config{Profile: "default", Settings: config.Default()})
+	m.currentBucket = "vault"
+
+	updated, _ := m.Update(retentionLoadedMsg{key: "ledger.csv"})
+	m = updated.(Model)
+	if !m.showPrompt || m.promptType != "retention" || !strings.Contains(m.promptText, "No retention") {
+		t.Fatalf("prompt = %v/%q %q, want the retention prompt", m.showPrompt, m.promptType, m.promptText)
+	}
+
+	m.promptInput = "compliance " + time.Now().AddDate(1, 0, 0).Format(time.DateOnly)
+	updated, cmd := m.executePromptAction()
+	m = updated.(Model)
+	if cmd != nil {
+		t.Error("compliance retention was set without confirmation")
+	}
+	if m.promptType != "confirm-retention" || !strings.Contains(m.promptText, "can't be shortened") {
+		t.Errorf("prompt = %q %q, want the compliance warning", m.promptType, m.promptText)
+	}
+	if m.pendingRetentionKey != "ledger.csv" || m.pendingRetention.Mode != aws.RetentionCompliance {
+		t.Errorf("pending = %q %+v", m.pendingRetentionKey, m.pendingRetention)
+	}
+}
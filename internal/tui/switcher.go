@@ -0,0 +1,120 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/natevick/stui/internal/aws"
+	"github.com/natevick/stui/internal/security"
+	"github.com/natevick/stui/internal/views/profiles"
+)
+
+// openSwitcher shows the profile quick switcher: the most used profiles
+// first, then the rest of those configured
+func (m *Model) openSwitcher() {
+	if m.demoMode || m.anonymous {
+		m.statusMsg = "Profiles can't be switched in this mode"
+		return
+	}
+	names := m.profileHistory.Ranked()
+	if configured, err := aws.ListProfiles(); err == nil {
+		for _, p := range configured {
+			names = append(names, p.Name)
+		}
+	}
+	m.switcher = profiles.NewSwitcher(names, m.profile)
+	m.showSwitcher = true
+	m.showHelp = false
+	m.showErrors = false
+}
+
+// handleSwitcherKey sends keys to the switcher; Esc or Ctrl+P closes it
+func (m Model) handleSwitcherKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "ctrl+p":
+		m.showSwitcher = false
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.switcher, cmd = m.switcher.Update(msg)
+	return m, cmd
+}
+
+// switchProfile drops the current client and everything read with it, and
+// connects again with profile
+func (m Model) switchProfile(profile string) (tea.Model, tea.Cmd) {
+	m.showSwitcher = false
+	if err := security.ValidProfileName(profile); err != nil {
+		m.showError(err, "Switching profile")
+		return m, nil
+	}
+	if m.client != nil && profile == m.profile {
+		m.statusMsg = fmt.Sprintf("Already using profile %s", profile)
+		return m, nil
+	}
+	// Transfers hold the old client; don't pull it out from under them
+	if !m.CanQuitCleanly() {
+		m.statusMsg = "Finish or cancel transfers before switching profiles"
+		return m, nil
+	}
+
+	if m.client != nil {
+		if m.stopWarming != nil {
+			m.stopWarming()
+			m.stopWarming = nil
+		}
+		if m.stopEnrich != nil {
+			m.stopEnrich()
+			m.stopEnrich = nil
+		}
+		m.warmStarted = false
+		m.client = nil
+		m.downloadMgr = nil
+		m.bucketLoad++ // the old profile's buckets may still be streaming in
+		m.listingCache = aws.NewListingCache(aws.DefaultListingCacheTTL)
+		m.initialBucket = ""
+		m.currentBucket = ""
+		m.currentPrefix = ""
+		m.browserView.SetBucket("")
+		m.statusBar.SetConnected(false)
+		m.statusBar.SetProblem("")
+		m.statusBar.SetCredentialExpiry(time.Time{})
+		m.statusMsg = fmt.Sprintf("Switching to profile %s...", profile)
+	}
+
+	m.profile = profile
+	m.statusBar.SetProfile(profile)
+	m.activeView = ViewBuckets
+	m.bucketsView.SetLoading(true)
+	return m, m.initAWS()
+}
+
+// recordProfile counts the current profile in the switcher's history
+func (m *Model) recordProfile() {
+	if m.profile == "" || m.anonymous || m.demoMode {
+		return
+	}
+	if err := m.profileHistory.Record(m.profile); err != nil {
+		m.errorLog.Add("Saving profile history", err)
+	}
+}
+
+func (m Model) renderWithSwitcher() string {
+	switcherStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ColorPrimary).
+		Padding(1, 2).
+		Width(min(50, m.width-4))
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		switcherStyle.Render(m.switcher.View()),
+		lipgloss.WithWhitespaceChars(" "),
+		lipgloss.WithWhitespaceForeground(lipgloss.Color("0")),
+	)
+}
@@ -0,0 +1,119 @@
+package tui
+
+import (
+	"context"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/natevick/stui/internal/aws"
+	"github.com/natevick/stui/internal/config"
+	"github.com/natevick/stui/internal/views/profiles"
+)
+
+func TestSwitchProfileRebuildsClient(t *testing.T) {
+	m := New(Config{Profile: "dev", Settings: config.Default()})
+	var built []string
+	m.newClient = func(ctx context.Context, profile, region string, opts aws.ClientOptions) (*aws.Client, error) {
+		built = append(built, profile)
+		return &aws.Client{Profile: profile}, nil
+	}
+	m.client = &aws.Client{Profile: "dev"}
+	m.currentBucket = "dev-bucket"
+	m.currentPrefix = "logs/"
+	m.listingCache.Put("dev-bucket", "logs/", []aws.S3Object{{Key: "logs/a"}})
+	m.showSwitcher = true
+
+	updated, cmd := m.Update(profiles.SelectedMsg{Profile: "prod"})
+	m = updated.(Model)
+	if m.client != nil {
+		t.Error("old client kept after switching profile")
+	}
+	if m.profile != "prod" || m.showSwitcher {
+		t.Errorf("profile = %q, switcher shown = %v; want prod and closed", m.profile, m.showSwitcher)
+	}
+	if m.currentBucket != "" || m.currentPrefix != "" || m.listingCache.Len() != 0 {
+		t.Error("location or cached listings from the old profile kept")
+	}
+	if cmd == nil {
+		t.Fatal("switching returned no command")
+	}
+
+	msg := cmd()
+	ready, ok := msg.(awsClientReadyMsg)
+	if !ok {
+		t.Fatalf("switch command sent %T, want awsClientReadyMsg", msg)
+	}
+	if len(built) != 1 || built[0] != "prod" {
+		t.Errorf("clients built for %v, want [prod]", built)
+	}
+
+	updated, _ = m.Update(ready)
+	m = updated.(Model)
+	if m.client == nil || m.client.Profile != "prod" {
+		t.Errorf("client = %+v, want one for prod", m.client)
+	}
+	if got := m.profileHistory.Ranked(); len(got) != 1 || got[0] != "prod" {
+		t.Errorf("profile history = %v, want [prod]", got)
+	}
+}
+
+func TestSwitchToCurrentProfileKeepsClient(t *testing.T) {
+	m := New(Config{Profile: "dev", Settings: config.Default()})
+	client := &aws.Client{Profile: "dev"}
+	m.client = client
+
+	updated, cmd := m.Update(profiles.SelectedMsg{Profile: "dev"})
+	m = updated.(Model)
+	if m.client != client || cmd != nil {
+		t.Error("switching to the current profile rebuilt the client")
+	}
+}
+
+func TestSwitcherKey(t *testing.T) {
+	m := New(Config{Profile: "dev", Settings: config.Default()})
+	m.profileHistory = profiles.NewProfileHistory("")
+	for _, p := range []string{"prod", "prod", "dev"} {
+		if err := m.profileHistory.Record(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlP})
+	m = updated.(Model)
+	if !m.showSwitcher {
+		t.Fatal("ctrl+p didn't open the switcher")
+	}
+	if got := m.switcher.Profiles(); len(got) < 2 || got[0] != "prod" || got[1] != "dev" {
+		t.Errorf("switcher offers %v, want prod then dev first", got)
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = updated.(Model)
+	if m.showSwitcher {
+		t.Error("esc didn't close the switcher")
+	}
+}
+
+func TestSwitchProfileKeepsSpentBudget(t *testing.T) {
+	settings := config.Default()
+	settings.SessionBudgetBytes = 100
+	m := New(Config{Profile: "dev", Settings: settings})
+	m.newClient = func(ctx context.Context, profile, region string, opts aws.ClientOptions) (*aws.Client, error) {
+		return &aws.Client{Profile: profile}, nil
+	}
+	m.client = &aws.Client{Profile: "dev"}
+	budget := m.budget
+	budget.AddBytes(100)
+
+	updated, cmd := m.Update(profiles.SelectedMsg{Profile: "prod"})
+	m = updated.(Model)
+	updated, _ = m.Update(cmd())
+	m = updated.(Model)
+
+	if m.downloadMgr == nil {
+		t.Fatal("no download manager for the new profile")
+	}
+	if m.budget != budget || !m.budget.Exceeded() {
+		t.Error("switching profiles refilled the session budget")
+	}
+}
@@ -1,14 +1,20 @@
 package tui
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"path"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/dustin/go-humanize"
 	"github.com/natevick/stui/internal/aws"
+	"github.com/natevick/stui/internal/bookmarks"
 	"github.com/natevick/stui/internal/download"
 	"github.com/natevick/stui/internal/security"
 	"github.com/natevick/stui/internal/views/bookmarksview"
@@ -27,19 +33,51 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case tea.KeyMsg:
+		// The file picker sits over the prompt it fills in
+		if m.showFilePicker {
+			return m.handleFilePickerKey(msg)
+		}
 		// Handle prompt input first
 		if m.showPrompt {
 			return m.handlePromptKey(msg)
 		}
+		if m.showEditor {
+			return m.handleEditorKey(msg)
+		}
+		if m.showSwitcher {
+			return m.handleSwitcherKey(msg)
+		}
+		if m.showJournal {
+			return m.handleJournalKey(msg)
+		}
 
 		// Global key handling
 		switch {
 		case key.Matches(msg, m.keys.Quit):
-			m.cancel()
-			return m, tea.Quit
+			// Ask before interrupting transfers; a quit while draining skips the wait
+			if !m.CanQuitCleanly() && !m.quitting {
+				m.quitRequested = true
+				m.showConfirmPrompt("confirm-quit", "Transfers are still running and quitting will stop them.")
+				return m, nil
+			}
+			return m.quit()
 
 		case key.Matches(msg, m.keys.Help):
 			m.showHelp = !m.showHelp
+			m.showErrors = false
+			return m, nil
+
+		case key.Matches(msg, m.keys.Errors):
+			m.showErrors = !m.showErrors
+			m.showHelp = false
+			return m, nil
+
+		case key.Matches(msg, m.keys.Deleted):
+			m.openJournal()
+			return m, nil
+
+		case key.Matches(msg, m.keys.Profiles):
+			m.openSwitcher()
 			return m, nil
 
 		case key.Matches(msg, m.keys.Tab), key.Matches(msg, m.keys.Right):
@@ -74,6 +112,17 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.showHelp = false
 				return m, nil
 			}
+			// Close error history if open
+			if m.showErrors {
+				m.showErrors = false
+				return m, nil
+			}
+			// Close preview if open
+			if m.showPreview {
+				m.showPreview = false
+				m.previewContent = nil
+				return m, nil
+			}
 
 		case key.Matches(msg, m.keys.Refresh):
 			return m.handleRefresh()
@@ -81,58 +130,187 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case demoReadyMsg:
 		// Load mock data for demo mode
+		m.statusBar.SetConnected(true)
 		return m, m.loadDemoBuckets()
 
 	case profilesReadyMsg:
 		// Load available profiles
 		if err := m.profilesView.LoadProfiles(); err != nil {
-			m.errorMsg = security.SanitizeErrorGeneric(err, "Failed to load profiles")
-			m.errorTimeout = time.Now().Add(5 * time.Second)
+			m.showError(err, "Failed to load profiles")
 		}
 		return m, nil
 
 	case profiles.SelectedMsg:
-		// Profile was selected, initialize AWS with it
-		m.profile = msg.Profile
-		m.activeView = ViewBuckets
-		m.bucketsView.SetLoading(true)
-		return m, m.initAWS()
+		// Profile was picked or switched to; (re)initialize AWS with it
+		return m.switchProfile(msg.Profile)
+
+	case profileHistoryReadyMsg:
+		m.profileHistory = msg.history
+		// A client that connected first was counted in the placeholder history
+		if m.client != nil {
+			m.recordProfile()
+		}
+		return m, nil
 
 	case awsClientReadyMsg:
 		m.client = msg.client
-		m.downloadMgr = download.NewManager(m.client, 5)
+		m.recordProfile()
+		m.downloadMgr = download.NewManager(m.client, m.settings.TransferConcurrency)
+		if m.budget != nil {
+			m.downloadMgr.SetBudget(m.budget)
+		}
+		m.statusBar.SetConnected(true)
+		m.statusBar.SetRegion(m.client.Region)
+
+		// Listing buckets needs credentials, and there are none to expire
+		if m.anonymous {
+			m.bucketsView.SetError(errAnonymousBuckets)
+			if m.initialBucket == "" {
+				return m, nil
+			}
+			m.currentBucket = m.initialBucket
+			m.browserView.SetBucket(m.initialBucket)
+			m.browserView.SetLoading(true)
+			return m, tea.Batch(m.loadObjects(), m.pingEndpoint())
+		}
 
 		// If a bucket was specified on command line, go directly to it
 		if m.initialBucket != "" {
 			m.currentBucket = m.initialBucket
 			m.browserView.SetBucket(m.initialBucket)
 			m.browserView.SetLoading(true)
-			return m, tea.Batch(m.loadBuckets(), m.loadObjects())
+			loadBuckets := m.loadBuckets()
+			return m, tea.Batch(loadBuckets, m.loadObjects(), m.loadCredentialExpiry(), m.pingEndpoint())
+		}
+		loadBuckets := m.loadBuckets()
+		return m, tea.Batch(loadBuckets, m.loadCredentialExpiry(), m.maybeWarmCache(), m.pingEndpoint())
+
+	case credentialExpiryMsg:
+		m.statusBar.SetCredentialExpiry(msg.expires)
+		return m, nil
+
+	case pingResultMsg:
+		if errors.Is(msg.err, aws.ErrCheckDenied) {
+			m.statusBar.SetConnected(true)
+			m.statusBar.SetProblem("")
+			m.statusMsg = "Connected, but not allowed to list buckets"
+			if m.anonymous {
+				m.statusMsg = "Connected, but the bucket doesn't allow anonymous access"
+			}
+			return m, nil
+		}
+		if msg.err != nil {
+			m.statusBar.SetConnected(false)
+			m.statusBar.SetProblem(msg.err.Error())
+			m.errorLog.Add("Connection check", msg.err)
+		} else {
+			m.statusBar.SetConnected(true)
+			m.statusBar.SetProblem("")
 		}
-		return m, m.loadBuckets()
+		return m, nil
 
 	case bookmarkStoreReadyMsg:
 		m.bookmarkStore = msg.store
 		m.bookmarksView.SetStore(m.bookmarkStore)
+		return m, m.maybeWarmCache()
+
+	case cacheWarmedMsg:
+		// Warming is best-effort: record failures without interrupting the user
+		if msg.err != nil && !errors.Is(msg.err, context.Canceled) {
+			if joined, ok := msg.err.(interface{ Unwrap() []error }); ok {
+				for _, err := range joined.Unwrap() {
+					m.errorLog.Add("Warming bookmark cache", err)
+				}
+			} else {
+				m.errorLog.Add("Warming bookmark cache", msg.err)
+			}
+		}
 		return m, nil
 
 	case BucketsLoadedMsg:
-		if msg.Err != nil {
+		// A reload or profile switch starts a new stream; drain older ones
+		// without showing them, whichever page arrives first
+		if msg.load != m.bucketLoad {
+			if msg.next == nil {
+				return m, nil
+			}
+			return m, listenForBuckets(msg.next)
+		}
+		switch {
+		case msg.Err != nil:
 			m.bucketsView.SetError(msg.Err)
-			m.errorMsg = security.SanitizeErrorGeneric(msg.Err, "Loading buckets")
-			m.errorTimeout = time.Now().Add(5 * time.Second)
-		} else {
+			m.showError(msg.Err, "Loading buckets")
+			return m, nil
+		case msg.Append:
+			m.bucketsView.AppendBuckets(msg.Buckets)
+		default:
 			m.bucketsView.SetBuckets(msg.Buckets)
+			m.noteFallback()
 		}
-		return m, nil
+		cmds := []tea.Cmd{m.lookupBucketRegions(buckets.MissingRegions(msg.Buckets))}
+		if msg.next != nil {
+			cmds = append(cmds, listenForBuckets(msg.next))
+		}
+		return m, tea.Batch(cmds...)
+
+	case bucketRegionsMsg:
+		return m, m.bucketsView.SetBucketRegions(msg.regions)
+
+	case objectsPageMsg:
+		// Pages of a listing we've since left are drained but not counted
+		if msg.bucket == m.currentBucket && msg.prefix == m.currentPrefix && m.browserView.Loading() {
+			m.browserView.AddListingPage(msg.objects)
+		}
+		return m, listenForObjects(msg.next)
 
 	case ObjectsLoadedMsg:
+		if msg.Refresh {
+			// Ignore stale refreshes from a location we've since left, and
+			// don't interrupt the user with errors from a background listing
+			if msg.Err != nil {
+				m.errorLog.Add("Auto-refresh", msg.Err)
+			} else if msg.Bucket == m.currentBucket && msg.Prefix == m.currentPrefix && !m.browserView.Loading() {
+				m.browserView.MergeObjects(msg.Objects)
+				cmd := m.maybeEnrichListing()
+				return m, cmd
+			}
+			return m, nil
+		}
 		if msg.Err != nil {
 			m.browserView.SetError(msg.Err)
-			m.errorMsg = security.SanitizeErrorGeneric(msg.Err, "Loading objects")
-			m.errorTimeout = time.Now().Add(5 * time.Second)
-		} else {
-			m.browserView.SetObjects(msg.Objects)
+			m.showError(msg.Err, "Loading objects")
+			return m, nil
+		}
+		m.browserView.SetObjects(msg.Objects)
+		m.noteFallback()
+		cmd := m.maybeEnrichListing()
+		return m, cmd
+
+	case listingEnrichedMsg:
+		// A cancelled fetch was for a listing the user has left
+		if msg.err != nil && !errors.Is(msg.err, context.Canceled) {
+			m.errorLog.Add("Fetching metadata", msg.err)
+		}
+		// Drop results for a listing we've since left
+		if msg.bucket != m.currentBucket || msg.prefix != m.currentPrefix {
+			return m, nil
+		}
+		enriched := make(map[string]aws.S3Object, len(msg.objects))
+		for _, obj := range msg.objects {
+			if obj.Enriched {
+				enriched[obj.Key] = obj
+			}
+		}
+		objects := m.browserView.Listing()
+		for i, obj := range objects {
+			if e, ok := enriched[obj.Key]; ok && e.ETag == obj.ETag {
+				objects[i] = e
+			}
+		}
+		m.browserView.MergeObjects(objects)
+		if msg.remaining > 0 {
+			m.statusMsg = fmt.Sprintf("Fetched metadata for %d objects; %d more are fetched when the filter is applied again",
+				len(msg.objects), msg.remaining)
 		}
 		return m, nil
 
@@ -155,12 +333,344 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return m, nil
 		}
+		if msg.progress.Status == download.StatusPaused {
+			m.statusMsg = "Transfer budget reached; downloads paused (Enter in Downloads resumes)"
+		}
 		return m, m.listenForProgress(msg.progressChan)
 
+	case objectPreviewMsg:
+		var limitErr *aws.SizeLimitError
+		if errors.As(msg.err, &limitErr) {
+			m.showConfirmPrompt("confirm-preview", fmt.Sprintf("'%s' is %s, over the %s preview limit.",
+				filepath.Base(msg.key), humanize.Bytes(uint64(limitErr.Size)), humanize.Bytes(uint64(limitErr.Limit))))
+			m.pendingPreviewKey = msg.key
+			return m, nil
+		}
+		if msg.err != nil {
+			m.showError(msg.err, "Previewing object")
+			return m, nil
+		}
+		m.showPreview = true
+		m.showHelp = false
+		m.showErrors = false
+		// The content type is known once the listing has been enriched
+		var contentType string
+		if obj, ok := m.browserView.SelectedObject(); ok && obj.Key == msg.key {
+			contentType = obj.ContentType
+		}
+		previewer, lines := m.previewers.Render(contentType, msg.key, msg.data)
+		m.previewTitle = msg.key + " (" + previewer.Name() + ")"
+		m.previewContent = []byte(strings.Join(lines, "\n"))
+		m.noteFallback()
+		return m, nil
+
+	case recentLoadedMsg:
+		var skipped *bookmarks.SkippedBookmarksError
+		if errors.As(msg.err, &skipped) {
+			for _, err := range skipped.Errs {
+				m.errorLog.Add("Loading recent objects", err)
+			}
+			m.statusMsg = fmt.Sprintf("%d of %d bookmarks skipped (see error log)", len(skipped.Errs), skipped.Total)
+		} else if msg.err != nil {
+			m.showError(msg.err, "Loading recent objects")
+			return m, nil
+		} else {
+			m.statusMsg = ""
+		}
+		m.showPreview = true
+		m.showHelp = false
+		m.showErrors = false
+		m.previewTitle = "Recently modified"
+		m.previewContent = []byte(formatRecent(msg.objects))
+		return m, nil
+
+	case prefixesSizedMsg:
+		m.statusMsg = ""
+		if msg.err != nil {
+			// Without a size, the limit can't be checked; ask instead
+			m.errorLog.Add("Sizing download", msg.err)
+			m.showConfirmPrompt("confirm-download", "The size of this download couldn't be determined.")
+			m.pendingLargeDownload = msg.objs
+			return m, nil
+		}
+		m.sizedPrefixes = msg.listed
+		return m, m.prepareDownload(msg.objs)
+
+	case egressCheckedMsg:
+		m.statusMsg = ""
+		if warning := m.egressWarning(msg.objs, msg.region); warning != "" {
+			m.showConfirmPrompt("confirm-egress", warning)
+			m.pendingLargeDownload = msg.objs
+		} else {
+			m.confirmDownload(msg.objs)
+		}
+		return m, nil
+
+	case publicAccessCheckedMsg:
+		m.statusMsg = ""
+		if msg.err != nil {
+			m.showError(msg.err, "Checking public access settings")
+			return m, nil
+		}
+		m.showConfirmPrompt("confirm-public", fmt.Sprintf("Make '%s' public? %s",
+			filepath.Base(msg.key), msg.status.PublicACLWarning()))
+		m.pendingPublicKey = msg.key
+		return m, nil
+
+	case objectPublicMsg:
+		if msg.err != nil {
+			if errors.Is(msg.err, aws.ErrProtectedBucket) {
+				m.showError(fmt.Errorf("bucket %s is protected", m.currentBucket), "")
+			} else {
+				m.showError(msg.err, "Setting object ACL")
+			}
+			return m, nil
+		}
+		m.statusMsg = "Public-read ACL set on " + aws.FormatS3URI(m.currentBucket, msg.key)
+		return m, nil
+
+	case retentionLoadedMsg:
+		m.statusMsg = ""
+		if msg.err != nil {
+			m.showError(msg.err, "Reading retention")
+			return m, nil
+		}
+		if m.readOnly {
+			// Show the retention without offering to change it
+			status := "No retention"
+			if msg.retention != nil {
+				status = formatRetention(*msg.retention)
+			}
+			m.statusMsg = fmt.Sprintf("'%s': %s", filepath.Base(msg.key), status)
+			return m, nil
+		}
+		m.showRetentionPrompt(msg.key, msg.retention)
+		return m, nil
+
+	case retentionSetMsg:
+		if msg.err != nil {
+			if errors.Is(msg.err, aws.ErrProtectedBucket) {
+				m.showError(fmt.Errorf("bucket %s is protected", m.currentBucket), "")
+			} else {
+				m.showError(msg.err, "Setting retention")
+			}
+			return m, nil
+		}
+		m.statusMsg = fmt.Sprintf("Retention set on %s: %s", aws.FormatS3URI(m.currentBucket, msg.key), formatRetention(msg.retention))
+		return m, nil
+
+	case urisCopiedMsg:
+		if msg.err != nil {
+			m.showError(msg.err, "Copying to clipboard")
+			return m, nil
+		}
+		if msg.count == 1 {
+			m.statusMsg = "Copied 1 URI to the clipboard"
+		} else {
+			m.statusMsg = fmt.Sprintf("Copied %d URIs to the clipboard", msg.count)
+		}
+		return m, nil
+
+	case linkCopiedMsg:
+		if msg.err != nil {
+			m.showError(msg.err, "Copying to clipboard")
+			return m, nil
+		}
+		m.statusMsg = "Copied " + msg.link
+		return m, nil
+
+	case presignedURLCopiedMsg:
+		if msg.err != nil {
+			m.showError(msg.err, "Copying presigned link")
+			return m, nil
+		}
+		// The URL carries a signature, so it goes to the clipboard only
+		m.statusMsg = fmt.Sprintf("Copied presigned link to %s (expires %s)", path.Base(msg.key), formatExpiry(msg.expiresAt, time.Now()))
+		return m, nil
+
+	case listingExportedMsg:
+		if msg.err != nil {
+			m.showError(msg.err, "Exporting listing")
+			return m, nil
+		}
+		m.statusMsg = fmt.Sprintf("Exported %d objects to %s", msg.count, msg.path)
+		return m, nil
+
+	case bucketPolicyMsg:
+		if msg.err != nil {
+			m.showError(msg.err, "Reading bucket policy")
+			return m, nil
+		}
+		policy := msg.policy
+		if policy == "" {
+			policy = "No bucket policy."
+		}
+		m.showPreview = true
+		m.showHelp = false
+		m.showErrors = false
+		m.previewTitle = "Bucket policy: " + msg.bucket
+		m.previewContent = []byte(policy)
+		return m, nil
+
+	case objectDetailsMsg:
+		if msg.err != nil {
+			m.showError(msg.err, "Reading object details")
+			return m, nil
+		}
+		m.showPreview = true
+		m.showHelp = false
+		m.showErrors = false
+		m.previewTitle = "Details: " + msg.obj.Key
+		m.previewContent = []byte(formatObjectDetails(*msg.obj))
+		return m, nil
+
+	case editLoadedMsg:
+		if msg.err != nil {
+			m.showError(msg.err, "Opening object for editing")
+			return m, nil
+		}
+		if err := editableText(msg.buf.Body); err != nil {
+			m.statusMsg = fmt.Sprintf("Can't edit '%s': %v", path.Base(msg.buf.Key), err)
+			return m, nil
+		}
+		m.statusMsg = ""
+		return m, m.openEditor(msg.buf)
+
+	case editSavedMsg:
+		m.editSaving = false
+		if msg.err != nil {
+			if errors.Is(msg.err, aws.ErrPreconditionFailed) {
+				// Keep the edit on screen so it can be copied before reopening
+				m.statusMsg = "Not saved: the object changed since you opened it. Esc and reopen to edit the new version"
+				m.errorLog.Add("Saving "+msg.key, msg.err)
+				return m, nil
+			}
+			m.showError(msg.err, "Saving object")
+			return m, nil
+		}
+		m.closeEditor()
+		m.statusMsg = "Saved " + msg.key
+		m.invalidateListings(m.currentBucket, msg.key)
+		m.browserView.SetLoading(true)
+		return m, m.loadObjects()
+
+	case bucketCORSMsg:
+		if msg.err != nil {
+			m.showError(msg.err, "Reading CORS configuration")
+			return m, nil
+		}
+		m.showPreview = true
+		m.showHelp = false
+		m.showErrors = false
+		m.previewTitle = "CORS: " + msg.bucket
+		m.previewContent = []byte(formatCORS(msg.rules))
+		return m, nil
+
+	case objectRenamedMsg:
+		if msg.err != nil {
+			if errors.Is(msg.err, aws.ErrObjectExists) {
+				m.showError(fmt.Errorf("'%s' already exists", msg.newKey), "")
+			} else if errors.Is(msg.err, aws.ErrProtectedBucket) {
+				m.showError(fmt.Errorf("bucket %s is protected", m.currentBucket), "")
+			} else {
+				m.showError(msg.err, "Renaming object")
+			}
+
+			// Some objects of a prefix rename may have moved; show the current state
+			var partial *aws.PartialFailureError
+			if errors.As(msg.err, &partial) {
+				for _, r := range partial.Failed {
+					m.errorLog.Add("Renaming "+r.Key, r.Err)
+				}
+				m.invalidateListings(m.currentBucket, msg.oldKey, msg.newKey)
+				m.browserView.SetLoading(true)
+				return m, m.loadObjects()
+			}
+			return m, nil
+		}
+		if strings.HasSuffix(msg.oldKey, "/") {
+			m.statusMsg = fmt.Sprintf("Moved %d objects to %s", msg.moved, msg.newKey)
+		} else {
+			m.statusMsg = "Renamed to " + msg.newKey
+		}
+		m.invalidateListings(m.currentBucket, msg.oldKey, msg.newKey)
+		m.browserView.SetLoading(true)
+		return m, m.loadObjects()
+
+	case selectionMovedMsg:
+		moved := 0
+		for _, r := range msg.results {
+			if r.Err == nil && r.NewKey != "" {
+				moved++
+				m.invalidateListings(m.currentBucket, r.Key, r.NewKey)
+			}
+		}
+		var partial *aws.PartialFailureError
+		switch {
+		case errors.As(msg.err, &partial):
+			for _, r := range partial.Failed {
+				m.errorLog.Add("Moving "+r.Key, r.Err)
+			}
+			m.showError(fmt.Errorf("moved %d of %d objects; %d failed (see error log)", moved, partial.Total, len(partial.Failed)), "")
+		case errors.Is(msg.err, aws.ErrProtectedBucket):
+			m.showError(fmt.Errorf("bucket %s is protected", m.currentBucket), "")
+		case msg.err != nil:
+			m.showError(msg.err, "Moving objects")
+		default:
+			m.statusMsg = fmt.Sprintf("Moved %d objects to %s", moved, aws.FormatS3URI(m.currentBucket, msg.destPrefix))
+		}
+		if moved == 0 {
+			return m, nil
+		}
+		m.browserView.ClearSelection()
+		m.browserView.SetLoading(true)
+		return m, m.loadObjects()
+
+	case objectsDeletedMsg:
+		for _, f := range msg.failures {
+			m.errorLog.Add("Deleting "+f.Key, errors.New(f.Message))
+		}
+		deleted := len(msg.keys) - len(msg.failures)
+		switch {
+		case errors.Is(msg.err, aws.ErrProtectedBucket):
+			m.showError(fmt.Errorf("bucket %s is protected", msg.bucket), "")
+			return m, nil
+		case msg.err != nil:
+			m.showError(msg.err, "Deleting objects")
+			return m, nil
+		case len(msg.failures) > 0:
+			m.showError(fmt.Errorf("deleted %d of %d objects; %d failed (see error log)", deleted, len(msg.keys), len(msg.failures)), "")
+		default:
+			m.statusMsg = fmt.Sprintf("Deleted %d objects (D to restore)", deleted)
+		}
+		if deleted == 0 {
+			return m, nil
+		}
+		m.invalidateListings(msg.bucket, msg.keys...)
+		if msg.bucket != m.currentBucket {
+			return m, nil
+		}
+		m.browserView.ClearSelection()
+		m.browserView.SetLoading(true)
+		return m, m.loadObjects()
+
+	case deletionRestoredMsg:
+		if msg.err != nil {
+			m.showError(msg.err, "Restoring "+msg.entry.Key)
+			return m, nil
+		}
+		m.statusMsg = "Restored " + aws.FormatS3URI(msg.entry.Bucket, msg.entry.Key)
+		m.journalCursor = min(m.journalCursor, max(len(m.journalEntries())-1, 0))
+		m.invalidateListings(msg.entry.Bucket, msg.entry.Key)
+		if msg.entry.Bucket != m.currentBucket {
+			return m, nil
+		}
+		m.browserView.SetLoading(true)
+		return m, m.loadObjects()
+
 	case ErrorMsg:
 		if msg.Err != nil {
-			m.errorMsg = security.SanitizeError(msg.Err)
-			m.errorTimeout = time.Now().Add(5 * time.Second)
+			m.showError(msg.Err, "")
 		}
 		return m, nil
 
@@ -169,6 +679,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.errorMsg != "" && time.Now().After(m.errorTimeout) {
 			m.errorMsg = ""
 		}
+		if now := time.Now(); m.autoRefreshDue(now) {
+			m.lastAutoRefresh = now
+			return m, tea.Batch(tickCmd(), m.refreshObjects())
+		}
 		return m, tickCmd()
 	}
 
@@ -197,6 +711,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		case buckets.ActionBookmark:
 			m.showBucketBookmarkPrompt(bucket)
+
+		case buckets.ActionPolicy:
+			cmds = append(cmds, m.loadBucketPolicy(bucket))
+
+		case buckets.ActionCORS:
+			cmds = append(cmds, m.loadBucketCORS(bucket))
 		}
 
 	case ViewBrowser:
@@ -212,21 +732,122 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.browserView.SetLoading(true)
 			cmds = append(cmds, m.loadObjects())
 
+		case browser.ActionBucketList:
+			m.activeView = ViewBuckets
+
 		case browser.ActionDownload:
-			if len(objs) > 0 {
-				m.showMultiDownloadPrompt(objs)
+			if len(objs) == 0 {
+				objs = []aws.S3Object{obj}
+			}
+			m.sizedPrefixes = nil
+			if m.needsSizing(objs) {
+				m.statusMsg = "Sizing download..."
+				cmds = append(cmds, m.sizePrefixes(objs))
 			} else {
-				m.showDownloadPrompt(obj)
+				cmds = append(cmds, m.prepareDownload(objs))
 			}
 
+		case browser.ActionPreview:
+			cmds = append(cmds, m.previewObject(obj.Key, false))
+
+		case browser.ActionDetails:
+			cmds = append(cmds, m.loadObjectDetails(obj.Key))
+
+		case browser.ActionEdit:
+			if m.refuseReadOnly("Editing objects") {
+				break
+			}
+			m.statusMsg = "Opening " + obj.Key + "..."
+			cmds = append(cmds, m.loadEdit(obj.Key))
+
+		case browser.ActionMakePublic:
+			if m.refuseReadOnly("Making objects public") {
+				break
+			}
+			m.statusMsg = "Checking Block Public Access..."
+			cmds = append(cmds, m.checkPublicAccess(obj.Key))
+
+		case browser.ActionExport:
+			m.showExportPrompt()
+
+		case browser.ActionRetention:
+			m.statusMsg = "Reading retention..."
+			cmds = append(cmds, m.loadRetention(obj.Key))
+
 		case browser.ActionSync:
 			m.showSyncPrompt()
 
 		case browser.ActionBookmark:
 			m.showBookmarkPrompt()
+
+		case browser.ActionQuickBookmark:
+			m.quickBookmark()
+
+		case browser.ActionRename:
+			if m.refuseReadOnly("Renaming") {
+				break
+			}
+			m.showRenamePrompt(obj)
+
+		case browser.ActionDelete:
+			if m.refuseReadOnly("Deleting") {
+				break
+			}
+			if len(objs) == 0 {
+				objs = []aws.S3Object{obj}
+			}
+			m.showDeletePrompt(objs)
+
+		case browser.ActionMoveSelection:
+			if m.refuseReadOnly("Moving") {
+				break
+			}
+			if len(objs) == 0 {
+				objs = []aws.S3Object{obj}
+			}
+			m.showMovePrompt(objs)
+
+		case browser.ActionJump:
+			m.showJumpPrompt()
+
+		case browser.ActionOpenConsole:
+			cmds = append(cmds, m.openInConsole(obj.Key))
+
+		case browser.ActionCopyURIs:
+			cmds = append(cmds, m.copyURIs(obj))
+
+		case browser.ActionCopyConsoleURL:
+			cmds = append(cmds, m.copyConsoleURL(obj.Key))
+
+		case browser.ActionCopyPresignedURL:
+			cmds = append(cmds, m.copyPresignedURL(obj.Key))
+
+		case browser.ActionToggleHidden:
+			m.toggleHidden()
+
+		case browser.ActionCycleKeyDisplay:
+			m.cycleKeyDisplay()
+
+		case browser.ActionMetadataFilter:
+			m.showMetadataFilterPrompt()
 		}
 
 	case ViewDownload:
+		if msg, ok := msg.(tea.KeyMsg); ok && key.Matches(msg, m.keys.Enter) && m.downloadView.Paused() {
+			if m.downloadMgr != nil {
+				m.downloadMgr.ResumeBudget()
+				m.statusMsg = "Transfer budget reset; resuming downloads"
+			}
+			return m, nil
+		}
+		if msg, ok := msg.(tea.KeyMsg); ok && msg.String() == "R" && !m.downloadView.IsActive() {
+			if n := m.downloadView.Progress().FailedFiles; n > 0 {
+				m.statusMsg = fmt.Sprintf("Retrying %d failed files...", n)
+				cmd := m.retryFailedDownloads()
+				return m, cmd
+			}
+			return m, nil
+		}
 		var cmd tea.Cmd
 		m.downloadView, cmd = m.downloadView.Update(msg)
 		cmds = append(cmds, cmd)
@@ -244,17 +865,20 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.currentBucket = bookmark.Bucket
 				m.currentPrefix = bookmark.Prefix
 				m.browserView.SetBucket(bookmark.Bucket)
-				m.browserView.SetPrefix(bookmark.Prefix)
+				m.browserView.OpenPrefix(bookmark.Prefix)
 				m.browserView.SetLoading(true)
 				m.activeView = ViewBrowser
 				cmds = append(cmds, m.loadObjects())
 			}
 
+		case bookmarksview.ActionRecent:
+			m.statusMsg = "Loading recent objects..."
+			cmds = append(cmds, m.loadRecent())
+
 		case bookmarksview.ActionDelete:
 			if m.bookmarkStore != nil {
 				if err := m.bookmarkStore.Remove(id); err != nil {
-					m.errorMsg = security.SanitizeErrorGeneric(err, "Removing bookmark")
-					m.errorTimeout = time.Now().Add(5 * time.Second)
+					m.showError(err, "Removing bookmark")
 				} else {
 					m.bookmarksView.Refresh()
 					m.statusMsg = "Bookmark removed"
@@ -266,6 +890,30 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// noteFallback tells the user when a read only worked with a fallback profile
+func (m *Model) noteFallback() {
+	if m.client == nil {
+		return
+	}
+	if profile := m.client.TakeFallbackProfile(); profile != "" {
+		m.statusMsg = fmt.Sprintf("Access denied for profile %s; read with fallback profile %s", m.client.Profile, profile)
+	}
+}
+
+// showError displays a sanitized error in the status bar and records it in the error history
+func (m *Model) showError(err error, context string) {
+	if err == nil {
+		return
+	}
+	m.errorLog.Add(context, err)
+	if context == "" {
+		m.errorMsg = security.SanitizeError(err)
+	} else {
+		m.errorMsg = security.SanitizeErrorGeneric(err, context)
+	}
+	m.errorTimeout = time.Now().Add(5 * time.Second)
+}
+
 func (m *Model) nextView() {
 	switch m.activeView {
 	case ViewBuckets:
@@ -296,7 +944,8 @@ func (m Model) handleRefresh() (tea.Model, tea.Cmd) {
 	switch m.activeView {
 	case ViewBuckets:
 		m.bucketsView.SetLoading(true)
-		return m, m.loadBuckets()
+		cmd := m.loadBuckets()
+		return m, cmd
 	case ViewBrowser:
 		m.browserView.SetLoading(true)
 		return m, m.loadObjects()
@@ -308,10 +957,102 @@ func (m Model) handleRefresh() (tea.Model, tea.Cmd) {
 
 // Prompt handling
 
+// showDownloadPromptFor asks where to save one object or several
+func (m *Model) showDownloadPromptFor(objs []aws.S3Object) {
+	if len(objs) == 1 {
+		m.showDownloadPrompt(objs[0])
+	} else {
+		m.showMultiDownloadPrompt(objs)
+	}
+}
+
+// downloadSize totals the sizes of objects. A prefix counts as zero until
+// sizePrefixes has filled in what it holds.
+func downloadSize(objs []aws.S3Object) int64 {
+	var total int64
+	for _, obj := range objs {
+		total += obj.Size
+	}
+	return total
+}
+
+// needsSizing reports whether a download includes prefixes that must be
+// listed before the size limit or egress warning can be checked
+func (m Model) needsSizing(objs []aws.S3Object) bool {
+	if m.demoMode || (m.settings.MaxAutoDownloadBytes <= 0 && m.settings.EgressWarnBytes <= 0) {
+		return false
+	}
+	return slices.ContainsFunc(objs, func(obj aws.S3Object) bool { return obj.IsPrefix })
+}
+
+// prepareDownload checks a download's region when it's large enough to warn
+// about, then asks to confirm it
+func (m *Model) prepareDownload(objs []aws.S3Object) tea.Cmd {
+	if m.needsEgressCheck(objs) {
+		m.statusMsg = "Checking bucket region..."
+		return m.checkEgress(objs)
+	}
+	m.confirmDownload(objs)
+	return nil
+}
+
+// confirmDownload asks for the download path, first asking for confirmation
+// when the download is over the size limit
+func (m *Model) confirmDownload(objs []aws.S3Object) {
+	if total := downloadSize(objs); aws.CheckSize(total, m.settings.MaxAutoDownloadBytes) != nil {
+		m.showConfirmPrompt("confirm-download", fmt.Sprintf("This download is %s, over the %s limit.",
+			humanize.Bytes(uint64(total)), humanize.Bytes(uint64(m.settings.MaxAutoDownloadBytes))))
+		m.pendingLargeDownload = objs
+		return
+	}
+	m.showDownloadPromptFor(objs)
+}
+
+// needsEgressCheck reports whether a download is large enough that the
+// bucket's region should be compared with the home region
+func (m Model) needsEgressCheck(objs []aws.S3Object) bool {
+	return !m.demoMode && m.settings.HomeRegion != "" && m.settings.EgressWarnBytes > 0 &&
+		downloadSize(objs) >= m.settings.EgressWarnBytes
+}
+
+// egressWarning describes the transfer charges of downloading objs from a
+// bucket in region, or returns "" if there is nothing to warn about
+func (m Model) egressWarning(objs []aws.S3Object, region string) string {
+	total := downloadSize(objs)
+	if m.settings.EgressWarnBytes <= 0 || total < m.settings.EgressWarnBytes {
+		return ""
+	}
+	if !aws.WouldIncurEgress(aws.Location{Region: region}, aws.Location{Region: m.settings.HomeRegion}) {
+		return ""
+	}
+	return fmt.Sprintf("The bucket is in %s but your home region is %s; transferring %s across regions incurs data transfer charges.",
+		region, m.settings.HomeRegion, humanize.Bytes(uint64(total)))
+}
+
+// showConfirmPrompt asks the user to type y before continuing
+func (m *Model) showConfirmPrompt(promptType, reason string) {
+	m.showPrompt = true
+	m.promptType = promptType
+	m.promptDefault = ""
+	m.promptInput = ""
+	m.promptCursor = 0
+	m.promptText = reason + " Type y to continue:"
+}
+
 func (m *Model) showDownloadPrompt(obj aws.S3Object) {
 	m.showPrompt = true
 	m.promptType = "download"
 	m.promptDefault = m.browserView.DefaultDownloadPath(obj)
+	if !obj.IsPrefix {
+		// Suggest a free name rather than overwriting an earlier download,
+		// looking where the suggestion will be saved (under the first allowed
+		// root when allowed_local_roots is set)
+		if target, err := m.localPath(m.promptDefault); err == nil {
+			if p, err := download.UniqueLocalPath(filepath.Dir(target), filepath.Base(target)); err == nil {
+				m.promptDefault = strings.TrimSuffix(m.promptDefault, filepath.Base(target)) + filepath.Base(p)
+			}
+		}
+	}
 	m.promptInput = m.promptDefault
 	m.promptCursor = len(m.promptInput)
 
@@ -351,6 +1092,16 @@ func (m *Model) showSyncPrompt() {
 	m.promptText = fmt.Sprintf("Sync '%s' to local directory:", m.currentPrefix)
 }
 
+// showExportPrompt asks where to write the current listing; the extension picks the format
+func (m *Model) showExportPrompt() {
+	m.showPrompt = true
+	m.promptType = "export"
+	m.promptDefault = "listing.csv"
+	m.promptInput = m.promptDefault
+	m.promptCursor = len(m.promptInput)
+	m.promptText = "Export listing to (.csv, .json, .ndjson):"
+}
+
 func (m *Model) showBookmarkPrompt() {
 	m.showPrompt = true
 	m.promptType = "bookmark"
@@ -370,6 +1121,135 @@ func (m *Model) showBookmarkPrompt() {
 	m.promptText = "Bookmark name:"
 }
 
+// quickBookmark bookmarks the current location without prompting for a name
+func (m *Model) quickBookmark() {
+	if m.bookmarkStore == nil {
+		return
+	}
+	region := m.region
+	if m.client != nil {
+		region = m.client.Region
+	}
+
+	state := m.navState()
+	bm, err := m.bookmarkStore.AddCurrent(&state, m.profile, region, "")
+	switch {
+	case errors.Is(err, bookmarks.ErrBookmarkExists):
+		m.statusMsg = fmt.Sprintf("Already bookmarked as '%s'", bm.DisplayName())
+	case err != nil:
+		m.showError(err, "Adding bookmark")
+	default:
+		m.statusMsg = fmt.Sprintf("Bookmarked as '%s'", bm.Name)
+		m.bookmarksView.Refresh()
+	}
+}
+
+// refuseReadOnly reports whether read-only mode blocks action, telling the
+// user so when it does
+func (m *Model) refuseReadOnly(action string) bool {
+	if !m.readOnly {
+		return false
+	}
+	m.statusMsg = action + " is disabled in read-only mode"
+	return true
+}
+
+// toggleHidden flips dotfile visibility and persists the choice
+func (m *Model) toggleHidden() {
+	m.settings.ShowHidden = !m.settings.ShowHidden
+	m.browserView.SetShowHidden(m.settings.ShowHidden)
+
+	if m.settings.ShowHidden {
+		m.statusMsg = "Showing hidden files"
+	} else {
+		m.statusMsg = "Hiding hidden files"
+	}
+
+	if err := m.settings.Save(); err != nil {
+		m.showError(err, "Saving settings")
+	}
+}
+
+// cycleKeyDisplay switches to the next key display mode and persists it
+func (m *Model) cycleKeyDisplay() {
+	mode := m.browserView.KeyDisplay().Next()
+	m.browserView.SetKeyDisplay(mode)
+	m.settings.KeyDisplay = mode.String()
+	m.statusMsg = fmt.Sprintf("Showing %s keys", mode)
+
+	if err := m.settings.Save(); err != nil {
+		m.showError(err, "Saving settings")
+	}
+}
+
+func (m *Model) showRenamePrompt(obj aws.S3Object) {
+	m.showPrompt = true
+	m.promptType = "rename"
+	m.promptDefault = obj.Key
+	m.promptInput = m.promptDefault
+	m.promptCursor = len(m.promptInput)
+	m.promptText = fmt.Sprintf("Rename '%s' to:", obj.DisplayName())
+	m.pendingRenameKey = obj.Key
+}
+
+func (m *Model) showMovePrompt(objs []aws.S3Object) {
+	m.pendingMoveKeys = m.pendingMoveKeys[:0]
+	for _, obj := range objs {
+		if !obj.IsPrefix {
+			m.pendingMoveKeys = append(m.pendingMoveKeys, obj.Key)
+		}
+	}
+	if len(m.pendingMoveKeys) == 0 {
+		m.statusMsg = "Folders can't be moved this way; rename them instead"
+		return
+	}
+	m.showPrompt = true
+	m.promptType = "move"
+	m.promptDefault = m.currentPrefix
+	m.promptInput = m.promptDefault
+	m.promptCursor = len(m.promptInput)
+	if len(m.pendingMoveKeys) == 1 {
+		m.promptText = fmt.Sprintf("Move '%s' to prefix:", path.Base(m.pendingMoveKeys[0]))
+	} else {
+		m.promptText = fmt.Sprintf("Move %d objects to prefix:", len(m.pendingMoveKeys))
+	}
+}
+
+func (m *Model) showJumpPrompt() {
+	m.showPrompt = true
+	m.promptType = "jump"
+	m.promptDefault = m.browserView.LastJump()
+	m.promptInput = m.promptDefault
+	m.promptCursor = len(m.promptInput)
+	m.promptText = "Jump to key starting with:"
+}
+
+// localPath cleans a path typed into a prompt and, when allowed_local_roots
+// is set, checks that it lies within one of them
+func (m Model) localPath(input string) (string, error) {
+	if len(m.settings.AllowedLocalRoots) == 0 {
+		return filepath.Clean(input), nil
+	}
+	return security.SafePathAny(m.settings.AllowedLocalRoots, input)
+}
+
+func (m *Model) showMetadataFilterPrompt() {
+	m.showPrompt = true
+	m.promptType = "metafilter"
+	m.promptDefault = m.browserView.MetadataFilter().String()
+	m.promptInput = m.promptDefault
+	m.promptCursor = len(m.promptInput)
+	m.promptText = "Filter (type:image/* size>1MB size<1GB after:2024-01-01 before:2024-12-31, empty clears):"
+}
+
+// maybeEnrichListing fetches the metadata the active filter needs, if any
+func (m *Model) maybeEnrichListing() tea.Cmd {
+	if m.demoMode || !m.browserView.MetadataFilter().NeedsEnrichment() {
+		return nil
+	}
+	return m.enrichListing()
+}
+
 func (m *Model) showBucketBookmarkPrompt(bucket string) {
 	m.showPrompt = true
 	m.promptType = "bucket-bookmark"
@@ -385,11 +1265,18 @@ func (m Model) handlePromptKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case tea.KeyEsc:
 		m.showPrompt = false
 		m.promptInput = ""
+		m.quitRequested = false
 		return m, nil
 
 	case tea.KeyEnter:
 		return m.executePromptAction()
 
+	case tea.KeyCtrlO:
+		if destinationPrompts[m.promptType] {
+			m.openFilePicker()
+		}
+		return m, nil
+
 	case tea.KeyBackspace:
 		if len(m.promptInput) > 0 && m.promptCursor > 0 {
 			m.promptInput = m.promptInput[:m.promptCursor-1] + m.promptInput[m.promptCursor:]
@@ -437,41 +1324,56 @@ func (m Model) executePromptAction() (tea.Model, tea.Cmd) {
 	m.showPrompt = false
 	input := m.promptInput
 	m.promptInput = ""
+	quitRequested := m.quitRequested
+	m.quitRequested = false
 
-	if input == "" {
+	// An empty filter clears it; every other prompt treats empty as cancel
+	if input == "" && m.promptType != "metafilter" {
 		return m, nil
 	}
 
 	switch m.promptType {
 	case "download":
 		obj, _ := m.browserView.SelectedObject()
-		localPath := input
-
-		// Make path absolute if relative
-		if !filepath.IsAbs(localPath) {
-			localPath = filepath.Clean(localPath)
+		localPath, err := m.localPath(input)
+		if err != nil {
+			m.showError(err, "")
+			return m, nil
 		}
 
 		m.activeView = ViewDownload
 		m.browserView.ClearSelection()
+		if _, ok := m.sizedPrefixes[obj.Key]; ok && obj.IsPrefix {
+			return m, m.startMultiDownload(m.expandSizedPrefixes([]aws.S3Object{obj}), obj.Key, localPath)
+		}
 		return m, m.startDownload(obj.Key, localPath, obj.IsPrefix)
 
 	case "multi-download":
-		localPath := input
-		if !filepath.IsAbs(localPath) {
-			localPath = filepath.Clean(localPath)
+		localPath, err := m.localPath(input)
+		if err != nil {
+			m.showError(err, "")
+			return m, nil
 		}
 
-		objs := m.pendingDownloadObjects
+		objs := m.expandSizedPrefixes(m.pendingDownloadObjects)
 		m.pendingDownloadObjects = nil
 		m.activeView = ViewDownload
 		m.browserView.ClearSelection()
-		return m, m.startMultiDownload(objs, localPath)
+		return m, m.startMultiDownload(objs, m.currentPrefix, localPath)
+
+	case "export":
+		localPath, err := m.localPath(input)
+		if err != nil {
+			m.showError(err, "")
+			return m, nil
+		}
+		return m, m.exportListing(localPath, m.browserView.VisibleObjects())
 
 	case "sync":
-		localPath := input
-		if !filepath.IsAbs(localPath) {
-			localPath = filepath.Clean(localPath)
+		localPath, err := m.localPath(input)
+		if err != nil {
+			m.showError(err, "")
+			return m, nil
 		}
 
 		m.activeView = ViewDownload
@@ -504,8 +1406,7 @@ func (m Model) executePromptAction() (tea.Model, tea.Cmd) {
 		if m.bookmarkStore != nil {
 			_, err := m.bookmarkStore.Add(input, m.currentBucket, m.currentPrefix)
 			if err != nil {
-				m.errorMsg = security.SanitizeErrorGeneric(err, "Adding bookmark")
-				m.errorTimeout = time.Now().Add(5 * time.Second)
+				m.showError(err, "Adding bookmark")
 			} else {
 				m.statusMsg = "Bookmark added"
 				m.bookmarksView.Refresh()
@@ -516,19 +1417,133 @@ func (m Model) executePromptAction() (tea.Model, tea.Cmd) {
 		if m.bookmarkStore != nil && m.pendingBookmarkBucket != "" {
 			_, err := m.bookmarkStore.Add(input, m.pendingBookmarkBucket, "")
 			if err != nil {
-				m.errorMsg = security.SanitizeErrorGeneric(err, "Adding bookmark")
-				m.errorTimeout = time.Now().Add(5 * time.Second)
+				m.showError(err, "Adding bookmark")
 			} else {
 				m.statusMsg = "Bookmark added"
 				m.bookmarksView.Refresh()
 			}
 		}
 		m.pendingBookmarkBucket = ""
+
+	case "metafilter":
+		f, err := browser.ParseMetadataFilter(input)
+		if err != nil {
+			m.showError(err, "")
+			return m, nil
+		}
+		m.browserView.SetMetadataFilter(f)
+		if !f.Active() {
+			m.statusMsg = "Metadata filter cleared"
+			return m, nil
+		}
+		m.statusMsg = "Filtering by " + f.String()
+		if f.NeedsEnrichment() {
+			cmd := m.enrichListing()
+			return m, cmd
+		}
+
+	case "jump":
+		if !m.browserView.Jump(input) {
+			m.statusMsg = fmt.Sprintf("No key starts with '%s'", input)
+		}
+
+	case "retention":
+		key := m.pendingRetentionKey
+		m.pendingRetentionKey = ""
+		r, err := parseRetentionInput(input)
+		if err != nil {
+			m.showError(err, "")
+			return m, nil
+		}
+		if r.Mode == aws.RetentionCompliance {
+			m.showConfirmPrompt("confirm-retention", fmt.Sprintf("Lock '%s' until %s? %s",
+				filepath.Base(key), r.Until.Format(time.DateOnly), aws.ComplianceWarning))
+			m.pendingRetentionKey = key
+			m.pendingRetention = r
+			return m, nil
+		}
+		return m, m.setRetention(key, r)
+
+	case "confirm-quit":
+		if isYes(input) && quitRequested {
+			return m.quit()
+		}
+
+	case "confirm-retention":
+		key, r := m.pendingRetentionKey, m.pendingRetention
+		m.pendingRetentionKey = ""
+		m.pendingRetention = aws.Retention{}
+		if isYes(input) && key != "" {
+			return m, m.setRetention(key, r)
+		}
+
+	case "confirm-public":
+		key := m.pendingPublicKey
+		m.pendingPublicKey = ""
+		if isYes(input) && key != "" {
+			return m, m.makePublic(key)
+		}
+
+	case "confirm-preview":
+		key := m.pendingPreviewKey
+		m.pendingPreviewKey = ""
+		if isYes(input) && key != "" {
+			return m, m.previewObject(key, true)
+		}
+
+	case "confirm-delete":
+		keys := m.pendingDeleteKeys
+		m.pendingDeleteKeys = nil
+		if isYes(input) && len(keys) > 0 {
+			m.statusMsg = fmt.Sprintf("Deleting %d objects...", len(keys))
+			return m, m.deleteObjects(keys)
+		}
+
+	case "confirm-egress":
+		objs := m.pendingLargeDownload
+		m.pendingLargeDownload = nil
+		if isYes(input) && len(objs) > 0 {
+			// Accepting the charges doesn't lift the size limit
+			m.confirmDownload(objs)
+		}
+
+	case "confirm-download":
+		objs := m.pendingLargeDownload
+		m.pendingLargeDownload = nil
+		if isYes(input) && len(objs) > 0 {
+			m.showDownloadPromptFor(objs)
+		}
+
+	case "move":
+		keys := m.pendingMoveKeys
+		m.pendingMoveKeys = nil
+		if len(keys) == 0 {
+			return m, nil
+		}
+		m.statusMsg = fmt.Sprintf("Moving %d objects...", len(keys))
+		return m, m.moveSelection(keys, input)
+
+	case "rename":
+		oldKey := m.pendingRenameKey
+		m.pendingRenameKey = ""
+		if oldKey == "" || input == oldKey {
+			return m, nil
+		}
+		if strings.HasSuffix(oldKey, "/") {
+			return m, m.renamePrefix(oldKey, input)
+		}
+		return m, m.renameObject(oldKey, input)
 	}
 
 	return m, nil
 }
 
+// isYes reports whether a confirmation prompt was answered affirmatively
+func isYes(input string) bool {
+	input = strings.ToLower(strings.TrimSpace(input))
+	return input == "y" || input == "yes"
+}
+
 // downloadProgressTickMsg is sent for progress updates
 type downloadProgressTickMsg struct {
 	progress     download.Progress
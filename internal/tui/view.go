@@ -5,6 +5,8 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/dustin/go-humanize"
+	"github.com/natevick/stui/internal/aws"
 )
 
 // View renders the TUI
@@ -23,16 +25,46 @@ func (m Model) View() string {
 	content := m.renderContent()
 	sb.WriteString(content)
 
+	// File picker overlay, over the prompt it fills in
+	if m.showFilePicker {
+		return m.renderWithFilePicker()
+	}
+
 	// Prompt overlay
 	if m.showPrompt {
 		return m.renderWithPrompt(sb.String())
 	}
 
+	// Object editor overlay
+	if m.showEditor {
+		return m.renderWithEditor()
+	}
+
+	// Profile switcher overlay
+	if m.showSwitcher {
+		return m.renderWithSwitcher()
+	}
+
+	// Deletion journal overlay
+	if m.showJournal {
+		return m.renderWithJournal()
+	}
+
 	// Help overlay
 	if m.showHelp {
 		return m.renderWithHelp(sb.String())
 	}
 
+	// Error history overlay
+	if m.showErrors {
+		return m.renderWithErrors(sb.String())
+	}
+
+	// Object preview overlay
+	if m.showPreview {
+		return m.renderWithPreview(sb.String())
+	}
+
 	// Status bar
 	sb.WriteString("\n")
 	sb.WriteString(m.renderStatusBar())
@@ -86,29 +118,26 @@ func (m Model) renderHeader() string {
 	// Title
 	title := m.styles.Title.Render("S3 TUI")
 
-	// Profile info
-	profile := m.styles.Dim.Render(fmt.Sprintf("Profile: %s", m.profileDisplay()))
-
-	// Combine title, tabs, and profile
-	header := lipgloss.JoinHorizontal(
-		lipgloss.Top,
-		title,
-		"  ",
-		tabLine,
-		"  ",
-		profile,
-	)
+	// Connection, location, and transfer state in the remaining space
+	status := m.statusBar
+	status.SetLocation(m.currentBucket, m.currentPrefix)
+	if m.activeView == ViewBrowser {
+		status.SetPrefixSize(m.browserView.TotalSize(), m.browserView.Loading())
+	}
+	status.SetTransfer(m.downloadView.Progress())
+	statusWidth := m.width - lipgloss.Width(title) - lipgloss.Width(tabLine) - 10
+
+	// Combine title, tabs, and status. Render treats a width of 0 or less as
+	// unlimited, so a terminal too narrow for any status leaves it out.
+	parts := []string{title, "  ", tabLine}
+	if statusWidth > 0 {
+		parts = append(parts, "  ", m.styles.Dim.Render(status.Render(statusWidth)))
+	}
+	header := lipgloss.JoinHorizontal(lipgloss.Top, parts...)
 
 	return m.styles.Header.Width(m.width - 2).Render(header)
 }
 
-func (m Model) profileDisplay() string {
-	if m.profile != "" {
-		return m.profile
-	}
-	return "default"
-}
-
 func (m Model) renderContent() string {
 	// Calculate content area
 	contentHeight := m.height - 6 // header + status bar
@@ -181,7 +210,7 @@ func (m Model) renderContextualHelp() string {
 		}
 		return m.styles.Dim.Render("←→ switch tabs")
 	case ViewBookmarks:
-		return m.styles.Dim.Render("↑↓ navigate • enter go to • x delete • ←→ tabs")
+		return m.styles.Dim.Render("↑↓ navigate • enter go to • m recent • x delete • ←→ tabs")
 	default:
 		return ""
 	}
@@ -204,13 +233,18 @@ func (m Model) renderWithPrompt(base string) string {
 		input = input + cursor
 	}
 
+	hint := "Enter to confirm • Esc to cancel"
+	if destinationPrompts[m.promptType] {
+		hint = "Enter to confirm • Ctrl+O to browse • Esc to cancel"
+	}
+
 	promptContent := lipgloss.JoinVertical(
 		lipgloss.Left,
 		m.styles.Title.Render(m.promptText),
 		"",
 		m.styles.PromptInput.Render(input),
 		"",
-		m.styles.Dim.Render("Enter to confirm • Esc to cancel"),
+		m.styles.Dim.Render(hint),
 	)
 
 	prompt := promptStyle.Render(promptContent)
@@ -234,6 +268,14 @@ func (m Model) renderWithHelp(base string) string {
 		Padding(1, 2).
 		Width(60)
 
+	// Actions that change S3 are greyed out in read-only mode
+	mutating := func(line string) string {
+		if m.readOnly {
+			return m.styles.Dim.Render(line)
+		}
+		return line
+	}
+
 	helpContent := lipgloss.JoinVertical(
 		lipgloss.Left,
 		m.styles.Title.Render("Keyboard Shortcuts"),
@@ -242,6 +284,7 @@ func (m Model) renderWithHelp(base string) string {
 		"  ↑/k, ↓/j    Move up/down",
 		"  Enter       Open folder",
 		"  Backspace   Go back",
+		"  u           Up to parent folder (bucket list from root)",
 		"  PgUp/PgDn   Page up/down",
 		"",
 		m.styles.Subtitle.Render("Views"),
@@ -255,11 +298,35 @@ func (m Model) renderWithHelp(base string) string {
 		"  d           Download selected (or current)",
 		"  s           Sync prefix to local",
 		"  b           Add bookmark",
+		"  B           Bookmark here (auto-named)",
+		mutating("  R           Rename object or folder"),
+		mutating("  M           Move selection (or current) to another prefix"),
+		mutating("  x           Delete selection (or current object)"),
+		"  o           Open in AWS console",
+		"  y           Copy s3:// URIs of selection (or current)",
+		"  Y           Copy AWS console link of current item",
+		"  U           Copy presigned download link (1h), showing its expiry",
+		"  .           Show/hide hidden files",
+		"  K           Cycle key display (basename, relative, full)",
+		"  F           Filter by content type, size, modified date",
+		"  p           Preview object",
+		"  i           Object details (type, encryption, replication)",
+		mutating("  w           Edit a small text object (Ctrl+S saves)"),
+		mutating("  P           Make object public (checks Block Public Access)"),
+		"              On Buckets: view bucket policy",
+		"  E           Export listing (.csv, .json, .ndjson)",
+		"  L           View/set Object Lock retention",
+		"  C           View bucket CORS rules (Buckets tab)",
+		"  m           Newest objects across bookmarks",
 		"  r           Refresh",
 		"  /           Filter list",
+		"  :           Jump to key (n for next match)",
 		"",
 		m.styles.Subtitle.Render("General"),
 		"  ?           Toggle this help",
+		"  e           Toggle error history",
+		"  D           Deleted this session (Enter restores)",
+		"  Ctrl+P      Switch profile (most used first)",
 		"  Esc         Cancel / Close",
 		"  q           Quit",
 		"",
@@ -280,3 +347,98 @@ func (m Model) renderWithHelp(base string) string {
 	)
 }
 
+func (m Model) renderWithErrors(base string) string {
+	width := 80
+	if m.width-4 < width {
+		width = m.width - 4
+	}
+	errorsStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ColorError).
+		Padding(1, 2).
+		Width(width)
+
+	lines := []string{
+		m.styles.Title.Render("Recent Errors"),
+		"",
+	}
+
+	recent := m.errorLog.Recent()
+	if len(recent) == 0 {
+		lines = append(lines, m.styles.Dim.Render("No errors this session"))
+	}
+
+	// Leave room for the border, padding, title, and footer
+	maxEntries := (m.height - 10) / 2
+	for i, entry := range recent {
+		if i >= maxEntries {
+			lines = append(lines, m.styles.Dim.Render(fmt.Sprintf("... and %d older", len(recent)-i)))
+			break
+		}
+		heading := entry.Time.Format("15:04:05")
+		if entry.Context != "" {
+			heading += "  " + entry.Context
+		}
+		lines = append(lines,
+			m.styles.Dim.Render(heading),
+			m.styles.Error.Render("  "+entry.Message),
+		)
+	}
+
+	if m.client != nil {
+		lines = append(lines, "", m.styles.Dim.Render(formatMetrics(m.client.Options.Metrics.Snapshot())))
+	}
+
+	lines = append(lines, "", m.styles.Dim.Render("Press Esc or e to close"))
+
+	panel := errorsStyle.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		panel,
+		lipgloss.WithWhitespaceChars(" "),
+		lipgloss.WithWhitespaceForeground(lipgloss.Color("0")),
+	)
+}
+
+func (m Model) renderWithPreview(base string) string {
+	width := m.width - 4
+	previewStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ColorPrimary).
+		Padding(1, 2).
+		Width(width)
+
+	// Leave room for the border, padding, title, and footer
+	maxLines := m.height - 10
+	content := previewText(m.previewContent, maxLines)
+
+	panel := previewStyle.Render(lipgloss.JoinVertical(
+		lipgloss.Left,
+		m.styles.Title.Render(displaySafe(m.previewTitle)),
+		"",
+		content,
+		"",
+		m.styles.Dim.Render("Press Esc to close"),
+	))
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		panel,
+		lipgloss.WithWhitespaceChars(" "),
+		lipgloss.WithWhitespaceForeground(lipgloss.Color("0")),
+	)
+}
+
+// formatMetrics summarizes the session's S3 traffic for the error panel
+func formatMetrics(s aws.MetricsSnapshot) string {
+	return fmt.Sprintf("Session: %d requests • %d retries • %d errors • ↓ %s • ↑ %s",
+		s.TotalRequests(), s.Retries, s.TotalErrors(),
+		humanize.Bytes(uint64(s.BytesDown)), humanize.Bytes(uint64(s.BytesUp)))
+}
@@ -0,0 +1,24 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/natevick/stui/internal/config"
+)
+
+func TestHeaderDropsStatusWhenNarrow(t *testing.T) {
+	m := New(Config{Profile: "default", Settings: config.Default()})
+	m.currentBucket = "a-bucket-with-a-rather-long-name"
+
+	m.SetSize(200, 40)
+	if !strings.Contains(m.renderHeader(), m.currentBucket) {
+		t.Fatal("a wide header should show the bucket")
+	}
+
+	// Too narrow for any status: it used to render in full and wrap
+	m.SetSize(40, 40)
+	if header := m.renderHeader(); strings.Contains(header, "rather-long") {
+		t.Errorf("narrow header still shows the status:\n%s", header)
+	}
+}
@@ -27,6 +27,7 @@ const (
 	ActionNone Action = iota
 	ActionSelect
 	ActionDelete
+	ActionRecent
 )
 
 // Model is the bookmarks view model
@@ -126,6 +127,10 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 				return m, nil
 			}
 
+		case key.Matches(msg, key.NewBinding(key.WithKeys("m"))):
+			m.action = ActionRecent
+			return m, nil
+
 		case key.Matches(msg, key.NewBinding(key.WithKeys("x", "delete"))):
 			if item, ok := m.list.SelectedItem().(Item); ok {
 				m.action = ActionDelete
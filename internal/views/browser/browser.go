@@ -3,6 +3,7 @@ package browser
 import (
 	"fmt"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/key"
@@ -11,22 +12,29 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/dustin/go-humanize"
 	"github.com/natevick/stui/internal/aws"
+	"github.com/natevick/stui/internal/nav"
+	"github.com/natevick/stui/internal/security"
 )
 
 // Item represents an S3 object in the list
 type Item struct {
 	object   aws.S3Object
+	name     string // key as rendered in the current KeyDisplayMode
 	selected bool
+	widths   []int // column widths from TableLayout; nil before the size is known
 }
 
 func (i Item) Title() string {
-	name := i.object.DisplayName()
+	name := i.name
 	var icon string
 	if i.selected {
 		icon = "✓ "
 	} else {
 		icon = "  "
 	}
+	if i.widths != nil {
+		name = renderRow(objectCells(i.object, name), ObjectColumns, i.widths)
+	}
 	if i.object.IsPrefix {
 		return icon + "📁 " + name
 	}
@@ -44,7 +52,7 @@ func (i Item) Description() string {
 }
 
 func (i Item) FilterValue() string {
-	return i.object.DisplayName()
+	return i.name
 }
 
 // Action represents an action to take
@@ -57,22 +65,69 @@ const (
 	ActionDownload
 	ActionSync
 	ActionBookmark
+	ActionQuickBookmark
+	ActionRename
+	ActionJump
+	ActionOpenConsole
+	ActionToggleHidden
+	ActionPreview
+	ActionMakePublic
+	ActionExport
+	ActionRetention
+	ActionBucketList
+	ActionCopyURIs
+	ActionCopyConsoleURL
+	ActionCopyPresignedURL
+	ActionCycleKeyDisplay
+	ActionMetadataFilter
+	ActionMoveSelection
+	ActionDetails
+	ActionEdit
+	ActionDelete
 )
 
+// LargeListingThreshold is the most entries shown through the list widget;
+// larger listings are drawn a window at a time from a RowList
+const LargeListingThreshold = 10_000
+
 // Model is the browser view model
 type Model struct {
 	list    list.Model
 	bucket  string
 	prefix  string
-	history []string // prefix history for back navigation
-	objects []aws.S3Object
-	loading bool
-	err     error
+	history []string       // prefix history for back navigation
+	all     []aws.S3Object // unfiltered listing
+	objects []aws.S3Object // entries passing the visibility and metadata filters
+	total   int64          // bytes directly under the prefix, running while a listing streams in
+	filter  VisibilityFilter
+	meta    MetadataFilter
+	display KeyDisplayMode
 	width   int
 	height  int
 
+	// delegate styles the rows of a large listing like the list's items
+	delegate list.DefaultDelegate
+
+	// rows backs the listing. Above LargeListingThreshold entries it is
+	// drawn directly, with its own cursor and "/" filter, and the list
+	// widget is left empty.
+	rows       *RowList
+	large      bool
+	cursor     int    // position among the rows' matches, when large
+	offset     int    // first row drawn, when large
+	typing     bool   // the "/" filter is being typed, when large
+	filterText string // the "/" filter as typed, when large
+
+	// What the listing area shows instead of the list, when not populated
+	state        ListState
+	errMsg       string // sanitized, set in StateError
+	placeholders Placeholders
+
 	// Multi-select
-	selected map[string]bool // map of Key -> selected
+	selected *Selection
+
+	// Last jump-to-key query, repeated with "n"
+	lastJump string
 
 	// Pending action
 	action          Action
@@ -83,6 +138,9 @@ type Model struct {
 // New creates a new browser view
 func New() Model {
 	delegate := list.NewDefaultDelegate()
+	// Each object is one table row; size and date are columns of the title
+	delegate.ShowDescription = false
+	delegate.SetSpacing(0)
 	delegate.Styles.SelectedTitle = delegate.Styles.SelectedTitle.
 		Foreground(lipgloss.Color("255")).
 		Background(lipgloss.Color("39")).
@@ -102,17 +160,28 @@ func New() Model {
 		Padding(0, 1)
 
 	return Model{
-		list:     l,
-		history:  []string{},
-		selected: make(map[string]bool),
+		list:         l,
+		delegate:     delegate,
+		rows:         NewRowList(nil, nil),
+		history:      []string{},
+		selected:     NewSelection(),
+		filter:       NewVisibilityFilter(false),
+		placeholders: DefaultPlaceholders(),
 	}
 }
 
 // SetSize sets the view size
 func (m *Model) SetSize(width, height int) {
+	resized := width != m.width
 	m.width = width
 	m.height = height
 	m.list.SetSize(width, height-2) // Reserve space for path
+	if m.large {
+		// Rows are laid out as they are drawn
+		m.scrollToCursor()
+	} else if resized && len(m.objects) > 0 {
+		m.refreshListItems()
+	}
 }
 
 // SetBucket sets the current bucket
@@ -120,38 +189,248 @@ func (m *Model) SetBucket(bucket string) {
 	m.bucket = bucket
 	m.prefix = ""
 	m.history = []string{}
-	m.selected = make(map[string]bool) // Clear selection
+	m.selected.Clear()
+	m.total = 0
 	m.updateTitle()
 }
 
 // SetPrefix sets the current prefix
 func (m *Model) SetPrefix(prefix string) {
 	m.prefix = prefix
+	m.total = 0
 	m.updateTitle()
 }
 
+// OpenPrefix jumps straight to a deep prefix, filling the back history with
+// every folder above it so backspace walks up through them as if the user
+// had navigated down
+func (m *Model) OpenPrefix(prefix string) {
+	m.history = m.history[:0]
+	for _, s := range (nav.State{Bucket: m.bucket, Prefix: prefix}).Ancestors() {
+		m.history = append(m.history, s.Prefix)
+	}
+	m.SetPrefix(prefix)
+}
+
+// History returns the prefixes backspace returns to, most recent last
+func (m Model) History() []string {
+	return slices.Clone(m.history)
+}
+
+// AddListingPage counts a page of a listing that is still streaming in
+// toward TotalSize. The page isn't shown; SetObjects delivers the listing.
+func (m *Model) AddListingPage(page []aws.S3Object) {
+	m.total += totalSize(page)
+}
+
+// TotalSize returns the bytes in objects directly under the prefix;
+// subfolders aren't counted. While a listing streams in, it is the total of
+// the pages so far.
+func (m Model) TotalSize() int64 {
+	return m.total
+}
+
+// totalSize adds up the sizes of objects, skipping folders
+func totalSize(objects []aws.S3Object) int64 {
+	var total int64
+	for _, obj := range objects {
+		if !obj.IsPrefix {
+			total += obj.Size
+		}
+	}
+	return total
+}
+
 // SetObjects updates the object list
 func (m *Model) SetObjects(objects []aws.S3Object) {
-	m.objects = objects
-	m.loading = false
-	m.selected = make(map[string]bool) // Clear selection when navigating
+	m.all = objects
+	m.total = totalSize(objects)
+	m.applyFilters()
+	m.settle()
+	m.selected.Clear() // Clear selection when navigating
+
+	// A new listing starts unfiltered
+	m.typing, m.filterText = false, ""
+	m.rows.SetFilter("")
+	m.cursor, m.offset = 0, 0
+	m.populate()
+}
 
-	items := make([]list.Item, len(objects))
-	for i, obj := range objects {
-		items[i] = Item{object: obj, selected: false}
+// MergeObjects replaces the listing with a fresh copy of the same prefix,
+// keeping selections for keys that still exist and the cursor on the same key
+func (m *Model) MergeObjects(objects []aws.S3Object) {
+	var cursorKey string
+	if item, ok := m.currentItem(); ok {
+		cursorKey = item.object.Key
 	}
-	m.list.SetItems(items)
+	idx := m.index()
+
+	m.all = carryEnrichment(m.all, objects)
+	m.total = totalSize(m.all)
+	m.applyFilters()
+	m.settle()
+
+	present := make(map[string]bool, len(m.objects))
+	for _, obj := range m.objects {
+		present[obj.Key] = true
+	}
+	for _, key := range m.selected.Keys() {
+		if !present[key] {
+			m.selected.Remove(key)
+		}
+	}
+
+	m.populate()
+	if pos := m.position(cursorKey); pos >= 0 {
+		idx = pos
+	}
+	if idx >= m.count() {
+		idx = m.count() - 1
+	}
+	if idx >= 0 {
+		m.selectIndex(idx)
+	}
+}
+
+// SetShowHidden toggles dotfile visibility and re-filters the listing
+func (m *Model) SetShowHidden(show bool) {
+	m.filter.ShowHidden = show
+	m.refilter()
+}
+
+// SetMetadataFilter narrows the listing by object metadata; the zero
+// filter shows everything
+func (m *Model) SetMetadataFilter(f MetadataFilter) {
+	m.meta = f
+	m.updateTitle()
+	m.refilter()
 }
 
-// SetError sets an error state
+// MetadataFilter returns the active metadata filter
+func (m Model) MetadataFilter() MetadataFilter {
+	return m.meta
+}
+
+// Listing returns a copy of the unfiltered listing
+func (m Model) Listing() []aws.S3Object {
+	return slices.Clone(m.all)
+}
+
+// applyFilters recomputes the visible entries from the full listing
+func (m *Model) applyFilters() {
+	m.objects = m.meta.Apply(m.filter.Apply(m.all))
+}
+
+// refilter re-applies the filters to the current listing, dropping
+// selections that are no longer visible
+func (m *Model) refilter() {
+	m.applyFilters()
+	if m.state == StateEmpty || m.state == StatePopulated {
+		m.settle()
+	}
+
+	visible := make(map[string]bool, len(m.objects))
+	for _, obj := range m.objects {
+		visible[obj.Key] = true
+	}
+	for _, key := range m.selected.Keys() {
+		if !visible[key] {
+			m.selected.Remove(key)
+		}
+	}
+	m.refreshListItems()
+}
+
+// carryEnrichment copies HEAD metadata from a previous listing onto
+// unchanged objects in a fresh one, so a refresh doesn't lose it
+func carryEnrichment(old, fresh []aws.S3Object) []aws.S3Object {
+	enriched := make(map[string]aws.S3Object)
+	for _, obj := range old {
+		if obj.Enriched {
+			enriched[obj.Key] = obj
+		}
+	}
+	if len(enriched) == 0 {
+		return fresh
+	}
+	for i, obj := range fresh {
+		prev, ok := enriched[obj.Key]
+		if ok && !obj.Enriched && prev.ETag == obj.ETag {
+			fresh[i].ContentType = prev.ContentType
+			fresh[i].Metadata = prev.Metadata
+			fresh[i].Enriched = true
+		}
+	}
+	return fresh
+}
+
+// SetKeyDisplay changes how keys are rendered
+func (m *Model) SetKeyDisplay(mode KeyDisplayMode) {
+	m.display = mode
+	m.refreshListItems()
+}
+
+// KeyDisplay returns how keys are rendered
+func (m Model) KeyDisplay() KeyDisplayMode {
+	return m.display
+}
+
+// ShowHidden reports whether hidden entries are shown
+func (m Model) ShowHidden() bool {
+	return m.filter.ShowHidden
+}
+
+// SetError puts the view in StateError with a sanitized message.
+// A nil error leaves the listing as it is.
 func (m *Model) SetError(err error) {
-	m.err = err
-	m.loading = false
+	if err == nil {
+		m.settle()
+		return
+	}
+	m.state = StateError
+	m.errMsg = security.SanitizeError(err)
 }
 
-// SetLoading sets the loading state
+// SetLoading enters StateLoading, or leaves it for Empty or Populated.
+// Entering it restarts TotalSize for the listing to come.
 func (m *Model) SetLoading(loading bool) {
-	m.loading = loading
+	if loading {
+		m.state = StateLoading
+		m.errMsg = ""
+		m.total = 0
+	} else if m.state == StateLoading {
+		m.settle()
+	}
+}
+
+// settle picks Empty or Populated from the visible entries
+func (m *Model) settle() {
+	m.errMsg = ""
+	if len(m.objects) == 0 {
+		m.state = StateEmpty
+	} else {
+		m.state = StatePopulated
+	}
+}
+
+// Loading reports whether a listing is being loaded
+func (m Model) Loading() bool {
+	return m.state == StateLoading
+}
+
+// State returns what the listing area is showing
+func (m Model) State() ListState {
+	return m.state
+}
+
+// ErrorMessage returns the sanitized error shown in StateError, or ""
+func (m Model) ErrorMessage() string {
+	return m.errMsg
+}
+
+// SetPlaceholders replaces the text shown for loading, empty, and failed listings
+func (m *Model) SetPlaceholders(p Placeholders) {
+	m.placeholders = p
 }
 
 // Bucket returns the current bucket
@@ -164,9 +443,14 @@ func (m Model) Prefix() string {
 	return m.prefix
 }
 
+// VisibleObjects returns the listing as shown, after hidden-file and metadata filtering
+func (m Model) VisibleObjects() []aws.S3Object {
+	return m.objects
+}
+
 // SelectedObject returns the currently selected object
 func (m Model) SelectedObject() (aws.S3Object, bool) {
-	if item, ok := m.list.SelectedItem().(Item); ok {
+	if item, ok := m.currentItem(); ok {
 		return item.object, true
 	}
 	return aws.S3Object{}, false
@@ -177,8 +461,10 @@ func (m *Model) updateTitle() {
 		m.list.Title = "Objects"
 		return
 	}
-	path := fmt.Sprintf("s3://%s/%s", m.bucket, m.prefix)
-	m.list.Title = path
+	m.list.Title = aws.FormatS3URI(m.bucket, m.prefix)
+	if m.meta.Active() {
+		m.list.Title += " [" + m.meta.String() + "]"
+	}
 }
 
 // Update handles messages
@@ -187,22 +473,29 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		// Don't handle keys if filtering
-		if m.list.FilterState() == list.Filtering {
+		if m.large {
+			if m.updateRows(msg) {
+				return m, nil
+			}
+		} else if m.list.FilterState() == list.Filtering {
+			// Don't handle keys if filtering
 			break
 		}
 
 		switch {
 		case key.Matches(msg, key.NewBinding(key.WithKeys(" "))):
 			// Toggle selection with spacebar
-			if item, ok := m.list.SelectedItem().(Item); ok {
+			if item, ok := m.currentItem(); ok {
 				m.toggleSelection(item.object.Key)
-				m.refreshListItems()
+				// Rows are marked as they are drawn
+				if !m.large {
+					m.refreshListItems()
+				}
 			}
 			return m, nil
 
 		case key.Matches(msg, key.NewBinding(key.WithKeys("enter"))):
-			if item, ok := m.list.SelectedItem().(Item); ok {
+			if item, ok := m.currentItem(); ok {
 				if item.object.IsPrefix {
 					// Navigate into prefix
 					m.history = append(m.history, m.prefix)
@@ -229,18 +522,55 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 				return m, nil
 			}
 
+		case key.Matches(msg, key.NewBinding(key.WithKeys("u"))):
+			// Go up one level, regardless of how we got here
+			parent := nav.State{Bucket: m.bucket, Prefix: m.prefix}.Parent()
+			if parent.AtBucketList() {
+				m.action = ActionBucketList
+				return m, nil
+			}
+			m.history = append(m.history, m.prefix)
+			m.prefix = parent.Prefix
+			m.action = ActionNavigate
+			m.updateTitle()
+			return m, nil
+
 		case key.Matches(msg, key.NewBinding(key.WithKeys("d"))):
 			// Download selected items, or current item if none selected
 			selectedObjs := m.GetSelectedObjects()
 			if len(selectedObjs) > 0 {
 				m.selectedObjects = selectedObjs
 				m.action = ActionDownload
-			} else if item, ok := m.list.SelectedItem().(Item); ok {
+			} else if item, ok := m.currentItem(); ok {
 				m.selectedObject = item.object
 				m.action = ActionDownload
 			}
 			return m, nil
 
+		case key.Matches(msg, key.NewBinding(key.WithKeys("M"))):
+			// Move selected objects, or the current one if none selected
+			selectedObjs := m.GetSelectedObjects()
+			if len(selectedObjs) > 0 {
+				m.selectedObjects = selectedObjs
+				m.action = ActionMoveSelection
+			} else if item, ok := m.currentItem(); ok && !item.object.IsPrefix {
+				m.selectedObject = item.object
+				m.action = ActionMoveSelection
+			}
+			return m, nil
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("x"))):
+			// Delete selected objects, or the current one if none selected
+			selectedObjs := m.GetSelectedObjects()
+			if len(selectedObjs) > 0 {
+				m.selectedObjects = selectedObjs
+				m.action = ActionDelete
+			} else if item, ok := m.currentItem(); ok && !item.object.IsPrefix {
+				m.selectedObject = item.object
+				m.action = ActionDelete
+			}
+			return m, nil
+
 		case key.Matches(msg, key.NewBinding(key.WithKeys("s"))):
 			m.action = ActionSync
 			return m, nil
@@ -248,6 +578,119 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 		case key.Matches(msg, key.NewBinding(key.WithKeys("b"))):
 			m.action = ActionBookmark
 			return m, nil
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("B"))):
+			m.action = ActionQuickBookmark
+			return m, nil
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("R"))):
+			// Rename the current object or folder
+			if item, ok := m.currentItem(); ok {
+				m.selectedObject = item.object
+				m.action = ActionRename
+			}
+			return m, nil
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("o"))):
+			// Open the current item (or the current prefix) in the AWS console
+			if item, ok := m.currentItem(); ok {
+				m.selectedObject = item.object
+			} else {
+				m.selectedObject = aws.S3Object{Key: m.prefix, IsPrefix: true}
+			}
+			m.action = ActionOpenConsole
+			return m, nil
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("p"))):
+			if item, ok := m.currentItem(); ok && !item.object.IsPrefix {
+				m.selectedObject = item.object
+				m.action = ActionPreview
+			}
+			return m, nil
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("i"))):
+			// Show the current object's HEAD details
+			if item, ok := m.currentItem(); ok && !item.object.IsPrefix {
+				m.selectedObject = item.object
+				m.action = ActionDetails
+			}
+			return m, nil
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("w"))):
+			// Edit the current object in place
+			if item, ok := m.currentItem(); ok && !item.object.IsPrefix {
+				m.selectedObject = item.object
+				m.action = ActionEdit
+			}
+			return m, nil
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("P"))):
+			// Make the current object publicly readable
+			if item, ok := m.currentItem(); ok && !item.object.IsPrefix {
+				m.selectedObject = item.object
+				m.action = ActionMakePublic
+			}
+			return m, nil
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("L"))):
+			// View or set Object Lock retention on the current object
+			if item, ok := m.currentItem(); ok && !item.object.IsPrefix {
+				m.selectedObject = item.object
+				m.action = ActionRetention
+			}
+			return m, nil
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("y"))):
+			// Copy s3:// URIs of the selection, or of the current item
+			if item, ok := m.currentItem(); ok {
+				m.selectedObject = item.object
+			}
+			m.action = ActionCopyURIs
+			return m, nil
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("Y"))):
+			// Copy the AWS console link of the current item (or the current prefix)
+			if item, ok := m.currentItem(); ok {
+				m.selectedObject = item.object
+			} else {
+				m.selectedObject = aws.S3Object{Key: m.prefix, IsPrefix: true}
+			}
+			m.action = ActionCopyConsoleURL
+			return m, nil
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("U"))):
+			// Copy a presigned download link of the current object
+			if item, ok := m.currentItem(); ok && !item.object.IsPrefix {
+				m.selectedObject = item.object
+				m.action = ActionCopyPresignedURL
+			}
+			return m, nil
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("E"))):
+			m.action = ActionExport
+			return m, nil
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("."))):
+			m.action = ActionToggleHidden
+			return m, nil
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("K"))):
+			m.action = ActionCycleKeyDisplay
+			return m, nil
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("F"))):
+			m.action = ActionMetadataFilter
+			return m, nil
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys(":"))):
+			m.action = ActionJump
+			return m, nil
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("n"))):
+			if m.lastJump != "" {
+				m.Jump(m.lastJump)
+			}
+			return m, nil
 		}
 	}
 
@@ -256,31 +699,178 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 	return m, cmd
 }
 
+// Jump moves the cursor to the next visible item whose name starts with query.
+// Repeating the previous query cycles through the matches.
+func (m *Model) Jump(query string) bool {
+	var names []string
+	if m.large {
+		names = make([]string, m.rows.Len())
+		for i := range names {
+			obj, _ := m.rows.Object(i)
+			names[i] = obj.DisplayName()
+		}
+	} else {
+		visible := m.list.VisibleItems()
+		names = make([]string, len(visible))
+		for i, li := range visible {
+			if item, ok := li.(Item); ok {
+				names[i] = item.object.DisplayName()
+			}
+		}
+	}
+
+	var idx int
+	var found bool
+	if query == m.lastJump {
+		idx, found = JumpNext(names, query, m.index())
+	} else {
+		idx, found = JumpTo(names, query)
+	}
+	m.lastJump = query
+	if found {
+		m.selectIndex(idx)
+	}
+	return found
+}
+
+// LastJump returns the most recent jump-to-key query
+func (m Model) LastJump() string {
+	return m.lastJump
+}
+
 // toggleSelection toggles the selection state of an object
 func (m *Model) toggleSelection(key string) {
-	if m.selected[key] {
-		delete(m.selected, key)
-	} else {
-		m.selected[key] = true
+	m.selected.Toggle(key)
+}
+
+// newItem wraps obj for the list, rendering its key for the current prefix
+func (m Model) newItem(obj aws.S3Object) Item {
+	item := Item{
+		object:   obj,
+		name:     m.display.Display(obj, m.prefix),
+		selected: m.selected.Has(obj.Key),
+	}
+	item.widths = m.columnWidths()
+	return item
+}
+
+// columnWidths returns the table layout for the current width, or nil
+// before the size is known
+func (m Model) columnWidths() []int {
+	if m.width <= 0 {
+		return nil
 	}
+	return TableLayout(m.width-itemChrome, ObjectColumns)
 }
 
 // refreshListItems updates the list items with current selection state
 func (m *Model) refreshListItems() {
-	idx := m.list.Index()
+	idx := m.index()
+	m.populate()
+	m.selectIndex(idx) // Preserve cursor position
+}
+
+// populate renders m.objects' names and hands them to the list widget,
+// or, for a large listing, to the rows alone so nothing is built per entry
+// until it is drawn
+func (m *Model) populate() {
+	names := make([]string, len(m.objects))
+	for i, obj := range m.objects {
+		names[i] = m.display.Display(obj, m.prefix)
+	}
+	m.rows.Reset(m.objects, names)
+
+	wasLarge := m.large
+	m.large = len(m.objects) > LargeListingThreshold
+	if m.large {
+		if !wasLarge {
+			m.list.ResetFilter()
+			m.list.SetItems(nil)
+		}
+		m.scrollToCursor()
+		return
+	}
+	if wasLarge {
+		m.typing, m.filterText = false, ""
+		m.rows.SetFilter("")
+	}
+
+	widths := m.columnWidths()
 	items := make([]list.Item, len(m.objects))
 	for i, obj := range m.objects {
-		items[i] = Item{object: obj, selected: m.selected[obj.Key]}
+		items[i] = Item{object: obj, name: names[i], selected: m.selected.Has(obj.Key), widths: widths}
 	}
 	m.list.SetItems(items)
-	m.list.Select(idx) // Preserve cursor position
+}
+
+// currentItem returns the entry under the cursor
+func (m Model) currentItem() (Item, bool) {
+	if !m.large {
+		item, ok := m.list.SelectedItem().(Item)
+		return item, ok
+	}
+	obj, ok := m.rows.Object(m.cursor)
+	if !ok {
+		return Item{}, false
+	}
+	return Item{object: obj, name: m.rows.Name(m.cursor), selected: m.selected.Has(obj.Key), widths: m.columnWidths()}, true
+}
+
+// index returns the cursor position among the entries shown
+func (m Model) index() int {
+	if m.large {
+		return m.cursor
+	}
+	return m.list.Index()
+}
+
+// selectIndex moves the cursor to position i among the entries shown
+func (m *Model) selectIndex(i int) {
+	if !m.large {
+		m.list.Select(i)
+		return
+	}
+	m.cursor = max(0, min(i, m.rows.Len()-1))
+	m.scrollToCursor()
+}
+
+// count returns the number of entries shown
+func (m Model) count() int {
+	if m.large {
+		return m.rows.Len()
+	}
+	return len(m.list.Items())
+}
+
+// position returns where the entry with key is among those shown, or -1
+func (m Model) position(key string) int {
+	if m.large {
+		return m.rows.Position(key)
+	}
+	for i, obj := range m.objects {
+		if obj.Key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// VisibleWindow returns up to height entries from offset, marked if
+// selected, building only those rows. Large listings are drawn this way,
+// with the "/" filter applied.
+func (m Model) VisibleWindow(offset, height int) []Row {
+	rows := m.rows.VisibleWindow(offset, height)
+	for i := range rows {
+		rows[i].Selected = m.selected.Has(rows[i].Object.Key)
+	}
+	return rows
 }
 
 // GetSelectedObjects returns all selected objects
 func (m Model) GetSelectedObjects() []aws.S3Object {
 	var objs []aws.S3Object
 	for _, obj := range m.objects {
-		if m.selected[obj.Key] {
+		if m.selected.Has(obj.Key) {
 			objs = append(objs, obj)
 		}
 	}
@@ -289,12 +879,17 @@ func (m Model) GetSelectedObjects() []aws.S3Object {
 
 // SelectionCount returns the number of selected items
 func (m Model) SelectionCount() int {
-	return len(m.selected)
+	return m.selected.Len()
+}
+
+// Selection returns the selected keys
+func (m Model) Selection() *Selection {
+	return m.selected
 }
 
 // ClearSelection clears all selections
 func (m *Model) ClearSelection() {
-	m.selected = make(map[string]bool)
+	m.selected.Clear()
 	m.refreshListItems()
 }
 
@@ -304,11 +899,10 @@ func (m Model) View() string {
 		return m.renderNoBucket()
 	}
 
-	if m.loading {
+	switch m.state {
+	case StateLoading:
 		return m.renderLoading()
-	}
-
-	if m.err != nil {
+	case StateError:
 		return m.renderError()
 	}
 
@@ -319,8 +913,17 @@ func (m Model) View() string {
 	sb.WriteString(path)
 	sb.WriteString("\n\n")
 
+	if m.state == StateEmpty {
+		sb.WriteString(m.renderEmpty())
+		return sb.String()
+	}
+
 	// List
-	sb.WriteString(m.list.View())
+	if m.large {
+		sb.WriteString(m.renderRows())
+	} else {
+		sb.WriteString(m.list.View())
+	}
 
 	return sb.String()
 }
@@ -329,24 +932,11 @@ func (m Model) renderPath() string {
 	style := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("240"))
 
-	var path string
-	if m.prefix == "" {
-		path = fmt.Sprintf("📦 %s", m.bucket)
-	} else {
-		// Build breadcrumb
-		parts := strings.Split(strings.TrimSuffix(m.prefix, "/"), "/")
-		var breadcrumbs []string
-		breadcrumbs = append(breadcrumbs, "📦 "+m.bucket)
-		for _, part := range parts {
-			if part != "" {
-				breadcrumbs = append(breadcrumbs, part)
-			}
-		}
-		path = strings.Join(breadcrumbs, " / ")
-	}
+	breadcrumbs := append([]string{"📦 " + m.bucket}, nav.State{Bucket: m.bucket, Prefix: m.prefix}.Breadcrumb()...)
+	path := strings.Join(breadcrumbs, " / ")
 
 	// Show selection count
-	if count := len(m.selected); count > 0 {
+	if count := m.selected.Len(); count > 0 {
 		selStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("213")).Bold(true)
 		path += selStyle.Render(fmt.Sprintf("  [%d selected]", count))
 	}
@@ -370,7 +960,17 @@ func (m Model) renderLoading() string {
 		Height(m.height).
 		Align(lipgloss.Center, lipgloss.Center)
 
-	return style.Render("Loading objects...")
+	return style.Render(m.placeholder())
+}
+
+func (m Model) renderEmpty() string {
+	style := lipgloss.NewStyle().
+		Width(m.width).
+		Height(max(m.height-2, 1)).
+		Align(lipgloss.Center, lipgloss.Center).
+		Foreground(lipgloss.Color("240"))
+
+	return style.Render(m.placeholder())
 }
 
 func (m Model) renderError() string {
@@ -380,7 +980,7 @@ func (m Model) renderError() string {
 		Align(lipgloss.Center, lipgloss.Center).
 		Foreground(lipgloss.Color("196"))
 
-	return style.Render(fmt.Sprintf("Error: %v", m.err))
+	return style.Render(m.placeholder())
 }
 
 // Action returns the pending action
@@ -0,0 +1,114 @@
+package browser
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/natevick/stui/internal/aws"
+)
+
+func TestMergeObjectsPreservesSelectionAndCursor(t *testing.T) {
+	m := New()
+	m.SetSize(80, 40)
+	m.SetBucket("b")
+	m.SetObjects([]aws.S3Object{{Key: "a.txt"}, {Key: "c.txt"}, {Key: "d.txt"}})
+
+	// Select c.txt and d.txt, leave the cursor on d.txt
+	m.list.Select(1)
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")})
+	m.list.Select(2)
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")})
+	if m.SelectionCount() != 2 {
+		t.Fatalf("selection count = %d, want 2", m.SelectionCount())
+	}
+
+	// Someone uploads b.txt and deletes c.txt
+	m.MergeObjects([]aws.S3Object{{Key: "a.txt"}, {Key: "b.txt"}, {Key: "d.txt"}})
+
+	selected := m.GetSelectedObjects()
+	if len(selected) != 1 || selected[0].Key != "d.txt" {
+		t.Errorf("selected = %v, want only d.txt", keysOf(selected))
+	}
+	if obj, _ := m.SelectedObject(); obj.Key != "d.txt" {
+		t.Errorf("cursor on %q, want d.txt", obj.Key)
+	}
+	if got := len(m.list.Items()); got != 3 {
+		t.Errorf("items = %d, want 3", got)
+	}
+}
+
+func TestGoToParent(t *testing.T) {
+	m := New()
+	m.SetSize(80, 40)
+	m.SetBucket("b")
+	m.prefix = "logs/2024/"
+
+	up := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("u")}
+	for _, want := range []string{"logs/", ""} {
+		m, _ = m.Update(up)
+		if action, _, _ := m.ConsumeAction(); action != ActionNavigate || m.Prefix() != want {
+			t.Fatalf("after u: action %v prefix %q, want navigate to %q", action, m.Prefix(), want)
+		}
+	}
+
+	m, _ = m.Update(up)
+	if action, _, _ := m.ConsumeAction(); action != ActionBucketList {
+		t.Errorf("u at bucket root: action %v, want ActionBucketList", action)
+	}
+}
+
+func TestOpenPrefixBuildsBreadcrumbAndHistory(t *testing.T) {
+	m := New()
+	m.SetSize(80, 40)
+	m.SetBucket("b")
+	m.OpenPrefix("team/reports/2024/")
+
+	if got, want := m.History(), []string{"", "team/", "team/reports/"}; !slices.Equal(got, want) {
+		t.Errorf("History() = %q, want %q", got, want)
+	}
+	if got := m.renderPath(); !strings.Contains(got, "📦 b / team / reports / 2024") {
+		t.Errorf("breadcrumb = %q", got)
+	}
+
+	back := tea.KeyMsg{Type: tea.KeyBackspace}
+	for _, want := range []string{"team/reports/", "team/", ""} {
+		m, _ = m.Update(back)
+		if action, _, _ := m.ConsumeAction(); action != ActionBack || m.Prefix() != want {
+			t.Fatalf("after backspace: action %v prefix %q, want back to %q", action, m.Prefix(), want)
+		}
+	}
+}
+
+func TestTotalSizeStreamsAndResets(t *testing.T) {
+	m := New()
+	m.SetBucket("b")
+	m.SetPrefix("logs/")
+	m.SetLoading(true)
+
+	m.AddListingPage([]aws.S3Object{{Key: "logs/a", Size: 100}, {Key: "logs/2024/", IsPrefix: true}})
+	if got := m.TotalSize(); got != 100 {
+		t.Fatalf("TotalSize() after one page = %d, want 100", got)
+	}
+	m.AddListingPage([]aws.S3Object{{Key: "logs/b", Size: 50}})
+	if got := m.TotalSize(); got != 150 {
+		t.Fatalf("TotalSize() after two pages = %d, want 150", got)
+	}
+
+	// The complete listing is authoritative
+	m.SetObjects([]aws.S3Object{{Key: "logs/a", Size: 100}, {Key: "logs/b", Size: 50}, {Key: "logs/2024/", IsPrefix: true}})
+	if got := m.TotalSize(); got != 150 {
+		t.Errorf("TotalSize() after SetObjects = %d, want 150", got)
+	}
+
+	m.SetPrefix("logs/2024/")
+	if got := m.TotalSize(); got != 0 {
+		t.Errorf("TotalSize() on a new prefix = %d, want 0", got)
+	}
+	m.SetLoading(true)
+	m.AddListingPage([]aws.S3Object{{Key: "logs/2024/c", Size: 7}})
+	if got := m.TotalSize(); got != 7 {
+		t.Errorf("TotalSize() streaming the new prefix = %d, want 7", got)
+	}
+}
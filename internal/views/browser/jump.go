@@ -0,0 +1,30 @@
+package browser
+
+import "strings"
+
+// JumpTo returns the index of the first key that starts with query,
+// ignoring case. found is false if nothing matches or query is empty.
+func JumpTo(keys []string, query string) (index int, found bool) {
+	return JumpNext(keys, query, -1)
+}
+
+// JumpNext returns the index of the next key after the given index that
+// starts with query, wrapping around to the top. Calling it repeatedly with
+// the previous result cycles through all matches.
+func JumpNext(keys []string, query string, after int) (index int, found bool) {
+	if query == "" || len(keys) == 0 {
+		return 0, false
+	}
+	query = strings.ToLower(query)
+
+	for i := 1; i <= len(keys); i++ {
+		idx := (after + i) % len(keys)
+		if idx < 0 {
+			idx += len(keys)
+		}
+		if strings.HasPrefix(strings.ToLower(keys[idx]), query) {
+			return idx, true
+		}
+	}
+	return 0, false
+}
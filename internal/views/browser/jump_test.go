@@ -0,0 +1,64 @@
+package browser
+
+import "testing"
+
+func TestJumpTo(t *testing.T) {
+	keys := []string{"alpha/", "Beta.txt", "beta-2.txt", "gamma.csv"}
+
+	tests := []struct {
+		name      string
+		query     string
+		wantIndex int
+		wantFound bool
+	}{
+		{"exact prefix", "gam", 3, true},
+		{"case insensitive", "BETA", 1, true},
+		{"first of several", "b", 1, true},
+		{"no match", "zeta", 0, false},
+		{"empty query", "", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idx, found := JumpTo(keys, tt.query)
+			if idx != tt.wantIndex || found != tt.wantFound {
+				t.Errorf("JumpTo(%q) = (%d, %v), want (%d, %v)", tt.query, idx, found, tt.wantIndex, tt.wantFound)
+			}
+		})
+	}
+}
+
+func TestJumpNextCycles(t *testing.T) {
+	keys := []string{"log-1", "data", "log-2", "other", "LOG-3"}
+
+	idx, found := JumpTo(keys, "log")
+	if !found || idx != 0 {
+		t.Fatalf("JumpTo = (%d, %v), want (0, true)", idx, found)
+	}
+
+	var visited []int
+	for i := 0; i < 4; i++ {
+		idx, found = JumpNext(keys, "log", idx)
+		if !found {
+			t.Fatal("expected a match while cycling")
+		}
+		visited = append(visited, idx)
+	}
+
+	want := []int{2, 4, 0, 2}
+	for i := range want {
+		if visited[i] != want[i] {
+			t.Errorf("cycle = %v, want %v", visited, want)
+			break
+		}
+	}
+}
+
+func TestJumpNextSingleMatch(t *testing.T) {
+	keys := []string{"a", "b", "c"}
+
+	idx, found := JumpNext(keys, "b", 1)
+	if !found || idx != 1 {
+		t.Errorf("JumpNext with a single match = (%d, %v), want (1, true)", idx, found)
+	}
+}
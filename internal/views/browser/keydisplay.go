@@ -0,0 +1,65 @@
+package browser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/natevick/stui/internal/aws"
+)
+
+// KeyDisplayMode controls how much of each key the listing shows
+type KeyDisplayMode int
+
+const (
+	// KeyDisplayBasename shows only the last path segment
+	KeyDisplayBasename KeyDisplayMode = iota
+	// KeyDisplayRelative shows the key with the current prefix stripped
+	KeyDisplayRelative
+	// KeyDisplayFull shows the whole key
+	KeyDisplayFull
+)
+
+func (d KeyDisplayMode) String() string {
+	switch d {
+	case KeyDisplayRelative:
+		return "relative"
+	case KeyDisplayFull:
+		return "full"
+	default:
+		return "basename"
+	}
+}
+
+// ParseKeyDisplayMode parses a mode name as written by String.
+// An empty name is KeyDisplayBasename.
+func ParseKeyDisplayMode(s string) (KeyDisplayMode, error) {
+	switch strings.ToLower(s) {
+	case "", "basename":
+		return KeyDisplayBasename, nil
+	case "relative":
+		return KeyDisplayRelative, nil
+	case "full":
+		return KeyDisplayFull, nil
+	}
+	return KeyDisplayBasename, fmt.Errorf("unknown key display mode %q", s)
+}
+
+// Next returns the mode after d, wrapping around
+func (d KeyDisplayMode) Next() KeyDisplayMode {
+	return (d + 1) % (KeyDisplayFull + 1)
+}
+
+// Display returns the text shown for obj while browsing prefix
+func (d KeyDisplayMode) Display(obj aws.S3Object, prefix string) string {
+	switch d {
+	case KeyDisplayFull:
+		return obj.Key
+	case KeyDisplayRelative:
+		if rel, ok := strings.CutPrefix(obj.Key, prefix); ok && rel != "" {
+			return rel
+		}
+		return obj.Key
+	default:
+		return obj.DisplayName()
+	}
+}
@@ -0,0 +1,81 @@
+package browser
+
+import (
+	"testing"
+
+	"github.com/natevick/stui/internal/aws"
+)
+
+func TestKeyDisplayModes(t *testing.T) {
+	const prefix = "logs/2024/01/"
+	tests := []struct {
+		name string
+		obj  aws.S3Object
+		mode KeyDisplayMode
+		want string
+	}{
+		{"full file", aws.S3Object{Key: "logs/2024/01/app.log"}, KeyDisplayFull, "logs/2024/01/app.log"},
+		{"relative file", aws.S3Object{Key: "logs/2024/01/app.log"}, KeyDisplayRelative, "app.log"},
+		{"basename file", aws.S3Object{Key: "logs/2024/01/app.log"}, KeyDisplayBasename, "app.log"},
+
+		// A key nested below the prefix, as returned by a flat listing
+		{"full nested", aws.S3Object{Key: "logs/2024/01/web/access.log"}, KeyDisplayFull, "logs/2024/01/web/access.log"},
+		{"relative nested", aws.S3Object{Key: "logs/2024/01/web/access.log"}, KeyDisplayRelative, "web/access.log"},
+		{"basename nested", aws.S3Object{Key: "logs/2024/01/web/access.log"}, KeyDisplayBasename, "access.log"},
+
+		{"full folder", aws.S3Object{Key: "logs/2024/01/web/", IsPrefix: true}, KeyDisplayFull, "logs/2024/01/web/"},
+		{"relative folder", aws.S3Object{Key: "logs/2024/01/web/", IsPrefix: true}, KeyDisplayRelative, "web/"},
+		{"basename folder", aws.S3Object{Key: "logs/2024/01/web/", IsPrefix: true}, KeyDisplayBasename, "web/"},
+
+		// Keys outside the prefix are shown in full rather than mangled
+		{"relative outside prefix", aws.S3Object{Key: "other/app.log"}, KeyDisplayRelative, "other/app.log"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.mode.Display(tt.obj, prefix); got != tt.want {
+				t.Errorf("%s.Display(%q) = %q, want %q", tt.mode, tt.obj.Key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseKeyDisplayMode(t *testing.T) {
+	for _, mode := range []KeyDisplayMode{KeyDisplayBasename, KeyDisplayRelative, KeyDisplayFull} {
+		got, err := ParseKeyDisplayMode(mode.String())
+		if err != nil || got != mode {
+			t.Errorf("ParseKeyDisplayMode(%q) = %v, %v", mode.String(), got, err)
+		}
+	}
+	if got, err := ParseKeyDisplayMode(""); err != nil || got != KeyDisplayBasename {
+		t.Errorf("ParseKeyDisplayMode(\"\") = %v, %v, want basename", got, err)
+	}
+	if _, err := ParseKeyDisplayMode("short"); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+	if got := KeyDisplayFull.Next(); got != KeyDisplayBasename {
+		t.Errorf("KeyDisplayFull.Next() = %v, want basename", got)
+	}
+}
+
+func TestBrowserKeyDisplay(t *testing.T) {
+	m := New()
+	m.SetBucket("b")
+	m.SetPrefix("logs/2024/")
+	m.SetObjects([]aws.S3Object{{Key: "logs/2024/app.log"}})
+
+	name := func() string {
+		return m.list.Items()[0].(Item).name
+	}
+	if got := name(); got != "app.log" {
+		t.Errorf("default name = %q, want app.log", got)
+	}
+
+	m.SetKeyDisplay(KeyDisplayFull)
+	if got := name(); got != "logs/2024/app.log" {
+		t.Errorf("full name = %q, want logs/2024/app.log", got)
+	}
+	if got := m.list.Items()[0].FilterValue(); got != "logs/2024/app.log" {
+		t.Errorf("filter value = %q, want the displayed key", got)
+	}
+}
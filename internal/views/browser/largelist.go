@@ -0,0 +1,124 @@
+package browser
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/dustin/go-humanize"
+)
+
+// rowsChrome is the lines a large listing spends above its rows: the title
+// or filter line, the status line, and a blank line
+const rowsChrome = 3
+
+// pageSize returns how many rows of a large listing fit
+func (m Model) pageSize() int {
+	// The path and its blank line come first, as for the list widget
+	return max(1, m.height-2-rowsChrome)
+}
+
+// scrollToCursor keeps the cursor inside the window of rows drawn
+func (m *Model) scrollToCursor() {
+	page := m.pageSize()
+	m.cursor = max(0, min(m.cursor, m.rows.Len()-1))
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	} else if m.cursor >= m.offset+page {
+		m.offset = m.cursor - page + 1
+	}
+	m.offset = max(0, min(m.offset, m.rows.Len()-page))
+}
+
+// setRowFilter applies the "/" filter as typed, from the top of the matches
+func (m *Model) setRowFilter(text string) {
+	m.filterText = text
+	m.rows.SetFilter(text)
+	m.cursor, m.offset = 0, 0
+}
+
+// updateRows handles the keys a large listing draws itself for: moving the
+// cursor and typing the "/" filter. It reports whether the key was used;
+// other keys act on the entry under the cursor as usual.
+func (m *Model) updateRows(msg tea.KeyMsg) bool {
+	if m.typing {
+		switch msg.Type {
+		case tea.KeyEnter:
+			m.typing = false
+		case tea.KeyEsc:
+			m.typing = false
+			m.setRowFilter("")
+		case tea.KeyBackspace:
+			if m.filterText == "" {
+				m.typing = false
+				break
+			}
+			runes := []rune(m.filterText)
+			m.setRowFilter(string(runes[:len(runes)-1]))
+		case tea.KeyRunes, tea.KeySpace:
+			m.setRowFilter(m.filterText + string(msg.Runes))
+		}
+		// Everything typed belongs to the filter
+		return true
+	}
+
+	switch msg.String() {
+	case "/":
+		m.typing = true
+	case "esc":
+		if m.filterText == "" {
+			return false
+		}
+		m.setRowFilter("")
+	case "up", "k":
+		m.cursor--
+	case "down", "j":
+		m.cursor++
+	case "pgup":
+		m.cursor -= m.pageSize()
+	case "pgdown":
+		m.cursor += m.pageSize()
+	case "home", "g":
+		m.cursor = 0
+	case "end", "G":
+		m.cursor = m.rows.Len() - 1
+	default:
+		return false
+	}
+	m.scrollToCursor()
+	return true
+}
+
+// renderRows draws the window of a large listing around the cursor
+func (m Model) renderRows() string {
+	lines := make([]string, 0, rowsChrome+m.pageSize())
+
+	if m.typing {
+		lines = append(lines, m.list.Styles.TitleBar.Render("Filter: "+m.filterText+"█"))
+	} else {
+		lines = append(lines, m.list.Styles.TitleBar.Render(m.list.Styles.Title.Render(m.list.Title)))
+	}
+
+	shown, total := m.rows.Len(), m.rows.Total()
+	var status string
+	switch {
+	case shown == 0:
+		status = m.list.Styles.StatusEmpty.Render("Nothing matched")
+	case m.filterText != "":
+		status = fmt.Sprintf("“%s” %s of %s items", m.filterText, humanize.Comma(int64(shown)), humanize.Comma(int64(total)))
+	default:
+		status = humanize.Comma(int64(total)) + " items"
+	}
+	lines = append(lines, m.list.Styles.StatusBar.Render(status), "")
+
+	widths := m.columnWidths()
+	for i, row := range m.VisibleWindow(m.offset, m.pageSize()) {
+		item := Item{object: row.Object, name: row.Name, selected: row.Selected, widths: widths}
+		style := m.delegate.Styles.NormalTitle
+		if m.offset+i == m.cursor {
+			style = m.delegate.Styles.SelectedTitle
+		}
+		lines = append(lines, style.Render(item.Title()))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,104 @@
+package browser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/natevick/stui/internal/aws"
+)
+
+func largeModel(t *testing.T, n int) Model {
+	t.Helper()
+	objects := make([]aws.S3Object, n)
+	for i := range objects {
+		objects[i] = aws.S3Object{Key: fmt.Sprintf("file-%06d.log", i), Size: 1024}
+	}
+	m := New()
+	m.SetSize(80, 30)
+	m.SetBucket("b")
+	m.SetObjects(objects)
+	if !m.large {
+		t.Fatalf("%d objects did not switch to the large listing", n)
+	}
+	return m
+}
+
+func typeKeys(m Model, keys ...string) Model {
+	for _, k := range keys {
+		var msg tea.KeyMsg
+		switch k {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		case "backspace":
+			msg = tea.KeyMsg{Type: tea.KeyBackspace}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		}
+		m, _ = m.Update(msg)
+	}
+	return m
+}
+
+func TestLargeListingSkipsListItems(t *testing.T) {
+	m := largeModel(t, LargeListingThreshold+1)
+	if got := len(m.list.Items()); got != 0 {
+		t.Errorf("list widget holds %d items, want none", got)
+	}
+	if got := strings.Count(m.View(), ".log"); got != m.pageSize() {
+		t.Errorf("view draws %d rows, want a page of %d", got, m.pageSize())
+	}
+}
+
+func TestLargeListingCursorScrollsWindow(t *testing.T) {
+	m := largeModel(t, 50_000)
+
+	m = typeKeys(m, "G")
+	if obj, _ := m.SelectedObject(); obj.Key != "file-049999.log" {
+		t.Errorf("cursor on %q after end, want the last object", obj.Key)
+	}
+	if want := 50_000 - m.pageSize(); m.offset != want {
+		t.Errorf("offset = %d, want %d", m.offset, want)
+	}
+
+	m = typeKeys(m, "g", "j", "j")
+	if obj, _ := m.SelectedObject(); obj.Key != "file-000002.log" || m.offset != 0 {
+		t.Errorf("cursor on %q at offset %d, want file-000002.log at 0", obj.Key, m.offset)
+	}
+
+	// Selection shows in the window
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")})
+	rows := m.VisibleWindow(0, 3)
+	if len(rows) != 3 || rows[1].Selected || !rows[2].Selected {
+		t.Errorf("window = %+v, want only the third row selected", rows)
+	}
+}
+
+func TestLargeListingFilter(t *testing.T) {
+	m := largeModel(t, 50_000)
+
+	m = typeKeys(m, append([]string{"/"}, strings.Split("FILE-0042", "")...)...)
+	if !m.typing || m.rows.Len() != 100 {
+		t.Fatalf("typing = %v with %d matches, want 100 while typing", m.typing, m.rows.Len())
+	}
+	if view := m.View(); !strings.Contains(view, "Filter: FILE-0042") {
+		t.Errorf("view does not show the filter being typed:\n%s", view)
+	}
+
+	m = typeKeys(m, "backspace", "enter")
+	if m.typing || m.rows.Len() != 1_000 {
+		t.Fatalf("typing = %v with %d matches, want 1000 after enter", m.typing, m.rows.Len())
+	}
+	m = typeKeys(m, "j")
+	if obj, _ := m.SelectedObject(); obj.Key != "file-004001.log" {
+		t.Errorf("cursor on %q, want the second match", obj.Key)
+	}
+
+	m = typeKeys(m, "esc")
+	if m.rows.Len() != 50_000 || m.filterText != "" {
+		t.Errorf("esc left %d rows under %q, want the whole listing", m.rows.Len(), m.filterText)
+	}
+}
@@ -0,0 +1,130 @@
+package browser
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/dustin/go-humanize"
+	"github.com/natevick/stui/internal/aws"
+	"github.com/natevick/stui/internal/views/widgets"
+)
+
+// columnGap is the space between table columns
+const columnGap = 2
+
+// itemChrome is the width each row spends outside the table: the list's
+// left padding plus the selection mark and file icon
+const itemChrome = 7
+
+// Column describes one column of the object table
+type Column struct {
+	Name string
+	// Width is the column's natural width, or the minimum for a Flex column
+	Width int
+	// Priority decides which columns go first when the terminal is too
+	// narrow: the highest is dropped first, and 0 is never dropped
+	Priority int
+	// Flex marks the column that takes the leftover space and is truncated
+	// with an ellipsis when space is tight
+	Flex bool
+	// Right aligns the column's values to the right
+	Right bool
+}
+
+// Object table columns, in display order
+const (
+	ColKey = iota
+	ColSize
+	ColModified
+	ColStorageClass
+)
+
+// ObjectColumns are the columns of the object listing
+var ObjectColumns = []Column{
+	ColKey:          {Name: "Key", Width: 16, Flex: true},
+	ColSize:         {Name: "Size", Width: 8, Right: true},
+	ColModified:     {Name: "Modified", Width: 16, Priority: 1},
+	ColStorageClass: {Name: "Class", Width: 12, Priority: 2},
+}
+
+// TableLayout computes the width of each column for a table width cells
+// wide. Low-priority columns are dropped (width 0) until the rest fit with
+// the flexible column at its minimum; the flexible column then takes
+// whatever space is left.
+func TableLayout(width int, cols []Column) []int {
+	widths := make([]int, len(cols))
+	kept := make([]bool, len(cols))
+	for i := range cols {
+		kept[i] = true
+	}
+
+	needed := func() int {
+		total, n := 0, 0
+		for i, col := range cols {
+			if kept[i] {
+				total += col.Width
+				n++
+			}
+		}
+		return total + max(n-1, 0)*columnGap
+	}
+	for needed() > width {
+		drop := -1
+		for i, col := range cols {
+			if kept[i] && col.Priority > 0 && (drop < 0 || col.Priority > cols[drop].Priority) {
+				drop = i
+			}
+		}
+		if drop < 0 {
+			break
+		}
+		kept[drop] = false
+	}
+
+	spare := width - needed()
+	for i, col := range cols {
+		if !kept[i] {
+			continue
+		}
+		widths[i] = col.Width
+		if col.Flex {
+			widths[i] = max(col.Width+spare, 1)
+			spare = 0
+		}
+	}
+	return widths
+}
+
+// renderRow lays out an object's cells in the widths from TableLayout,
+// skipping dropped columns
+func renderRow(cells []string, cols []Column, widths []int) string {
+	var parts []string
+	for i, cell := range cells {
+		w := widths[i]
+		if w == 0 {
+			continue
+		}
+		cell = widgets.Truncate(cell, w)
+		pad := strings.Repeat(" ", max(w-lipgloss.Width(cell), 0))
+		if cols[i].Right {
+			cell = pad + cell
+		} else {
+			cell += pad
+		}
+		parts = append(parts, cell)
+	}
+	return strings.TrimRight(strings.Join(parts, strings.Repeat(" ", columnGap)), " ")
+}
+
+// objectCells returns the values of obj for each of ObjectColumns
+func objectCells(obj aws.S3Object, name string) []string {
+	if obj.IsPrefix {
+		return []string{ColKey: name, ColSize: "", ColModified: "", ColStorageClass: ""}
+	}
+	return []string{
+		ColKey:          name,
+		ColSize:         humanize.Bytes(uint64(obj.Size)),
+		ColModified:     obj.LastModified.Format("2006-01-02 15:04"),
+		ColStorageClass: obj.StorageClass,
+	}
+}
@@ -0,0 +1,56 @@
+package browser
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/natevick/stui/internal/aws"
+)
+
+func TestTableLayout(t *testing.T) {
+	tests := []struct {
+		name  string
+		width int
+		want  []int
+	}{
+		// 16+8+16+12 plus three gaps is 58
+		{"wide keeps all columns", 100, []int{58, 8, 16, 12}},
+		{"exact fit", 58, []int{16, 8, 16, 12}},
+		{"medium drops storage class", 50, []int{22, 8, 16, 0}},
+		{"narrow keeps key and size", 30, []int{20, 8, 0, 0}},
+		{"tiny still shows key", 12, []int{2, 8, 0, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := TableLayout(tt.width, ObjectColumns)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("TableLayout(%d) = %v, want %v", tt.width, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderRowFitsWidth(t *testing.T) {
+	obj := aws.S3Object{
+		Key:          "reports/2024/a-very-long-quarterly-report-name.parquet",
+		Size:         123456789,
+		LastModified: time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC),
+		StorageClass: "INTELLIGENT_TIERING",
+	}
+	for _, width := range []int{100, 50, 30} {
+		widths := TableLayout(width, ObjectColumns)
+		row := renderRow(objectCells(obj, obj.Key), ObjectColumns, widths)
+		if got := lipgloss.Width(row); got > width {
+			t.Errorf("width %d: row is %d cells: %q", width, got, row)
+		}
+		if !strings.Contains(row, "…") {
+			t.Errorf("width %d: long key not truncated: %q", width, row)
+		}
+		if strings.Contains(row, "2024-03-01") != (widths[ColModified] > 0) {
+			t.Errorf("width %d: date shown doesn't match layout: %q", width, row)
+		}
+	}
+}
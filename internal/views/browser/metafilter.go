@@ -0,0 +1,174 @@
+package browser
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/natevick/stui/internal/aws"
+)
+
+// MetadataFilter narrows a listing by object metadata. Unset fields match
+// everything; every set field must match (AND). Folders never match an
+// active filter, since they have no metadata.
+type MetadataFilter struct {
+	// ContentType is a path.Match glob such as "image/*"
+	ContentType string
+	// MinSize and MaxSize bound the size in bytes; zero means no bound
+	MinSize int64
+	MaxSize int64
+	// ModifiedAfter and ModifiedBefore bound LastModified; zero means no bound
+	ModifiedAfter  time.Time
+	ModifiedBefore time.Time
+}
+
+// Active reports whether any predicate is set
+func (f MetadataFilter) Active() bool {
+	return f != MetadataFilter{}
+}
+
+// NeedsEnrichment reports whether the filter reads fields that listings
+// don't return, so objects must go through EnrichObjects first
+func (f MetadataFilter) NeedsEnrichment() bool {
+	return f.ContentType != ""
+}
+
+// Match reports whether obj satisfies every predicate. Objects that haven't
+// been enriched don't match a content-type filter.
+func (f MetadataFilter) Match(obj aws.S3Object) bool {
+	if !f.Active() {
+		return true
+	}
+	if obj.IsPrefix {
+		return false
+	}
+	if f.ContentType != "" {
+		if !obj.Enriched {
+			return false
+		}
+		// Ignore parameters such as "; charset=utf-8"
+		// Media types are case-insensitive; the pattern is already lowercase
+		contentType, _, _ := strings.Cut(obj.ContentType, ";")
+		if ok, _ := path.Match(f.ContentType, strings.ToLower(strings.TrimSpace(contentType))); !ok {
+			return false
+		}
+	}
+	if f.MinSize > 0 && obj.Size < f.MinSize {
+		return false
+	}
+	if f.MaxSize > 0 && obj.Size > f.MaxSize {
+		return false
+	}
+	if !f.ModifiedAfter.IsZero() && !obj.LastModified.After(f.ModifiedAfter) {
+		return false
+	}
+	if !f.ModifiedBefore.IsZero() && !obj.LastModified.Before(f.ModifiedBefore) {
+		return false
+	}
+	return true
+}
+
+// Apply returns the objects that match
+func (f MetadataFilter) Apply(objects []aws.S3Object) []aws.S3Object {
+	if !f.Active() {
+		return objects
+	}
+	matched := make([]aws.S3Object, 0, len(objects))
+	for _, obj := range objects {
+		if f.Match(obj) {
+			matched = append(matched, obj)
+		}
+	}
+	return matched
+}
+
+// String formats the filter in the syntax ParseMetadataFilter reads
+func (f MetadataFilter) String() string {
+	var terms []string
+	if f.ContentType != "" {
+		terms = append(terms, "type:"+f.ContentType)
+	}
+	if f.MinSize > 0 {
+		terms = append(terms, "size>="+exactBytes(f.MinSize))
+	}
+	if f.MaxSize > 0 {
+		terms = append(terms, "size<="+exactBytes(f.MaxSize))
+	}
+	if !f.ModifiedAfter.IsZero() {
+		terms = append(terms, "after:"+f.ModifiedAfter.UTC().Format(time.DateOnly))
+	}
+	if !f.ModifiedBefore.IsZero() {
+		terms = append(terms, "before:"+f.ModifiedBefore.UTC().Format(time.DateOnly))
+	}
+	return strings.Join(terms, " ")
+}
+
+// exactBytes formats n in the largest unit that represents it exactly, so
+// the text parses back to the same value
+func exactBytes(n int64) string {
+	units := []struct {
+		size int64
+		name string
+	}{{1 << 30, "GiB"}, {1 << 20, "MiB"}, {1 << 10, "KiB"}, {1e9, "GB"}, {1e6, "MB"}, {1e3, "KB"}}
+	for _, u := range units {
+		if n%u.size == 0 {
+			return fmt.Sprintf("%d%s", n/u.size, u.name)
+		}
+	}
+	return fmt.Sprintf("%dB", n)
+}
+
+// ParseMetadataFilter parses space-separated terms:
+//
+//	type:image/*        content type glob
+//	size>=1MB size<=1GiB size bounds (">" and "<" work the same)
+//	after:2024-01-31    modified after this date (UTC)
+//	before:2024-06-30   modified before this date (UTC)
+//
+// An empty string is the inactive filter.
+func ParseMetadataFilter(s string) (MetadataFilter, error) {
+	var f MetadataFilter
+	for _, term := range strings.Fields(s) {
+		switch {
+		case strings.HasPrefix(term, "type:"):
+			pattern := strings.TrimPrefix(term, "type:")
+			if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+				return MetadataFilter{}, fmt.Errorf("invalid content type pattern %q", pattern)
+			}
+			f.ContentType = strings.ToLower(pattern)
+
+		case strings.HasPrefix(term, "size>"), strings.HasPrefix(term, "size<"):
+			op := term[4]
+			n, err := humanize.ParseBytes(strings.TrimLeft(term[5:], "="))
+			if err != nil {
+				return MetadataFilter{}, fmt.Errorf("invalid size in %q", term)
+			}
+			if op == '>' {
+				f.MinSize = int64(n)
+			} else {
+				f.MaxSize = int64(n)
+			}
+
+		case strings.HasPrefix(term, "after:"), strings.HasPrefix(term, "before:"):
+			name, value, _ := strings.Cut(term, ":")
+			t, err := time.Parse(time.DateOnly, value)
+			if err != nil {
+				return MetadataFilter{}, fmt.Errorf("invalid date in %q, expected YYYY-MM-DD", term)
+			}
+			if name == "after" {
+				f.ModifiedAfter = t
+			} else {
+				f.ModifiedBefore = t
+			}
+
+		default:
+			return MetadataFilter{}, fmt.Errorf("unknown filter term %q", term)
+		}
+	}
+	if f.MaxSize > 0 && f.MinSize > f.MaxSize {
+		return MetadataFilter{}, fmt.Errorf("minimum size is larger than maximum size")
+	}
+	return f, nil
+}
@@ -0,0 +1,135 @@
+package browser
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/natevick/stui/internal/aws"
+)
+
+func metadataFixture() []aws.S3Object {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 12, 0, 0, 0, time.UTC) }
+	return []aws.S3Object{
+		{Key: "photos/", IsPrefix: true},
+		{Key: "cat.jpg", Size: 2 << 20, LastModified: day(5), ContentType: "image/jpeg", Enriched: true},
+		{Key: "dog.png", Size: 200 << 10, LastModified: day(20), ContentType: "Image/PNG", Enriched: true}, // media types ignore case
+		{Key: "notes.txt", Size: 3 << 20, LastModified: day(10), ContentType: "text/plain; charset=utf-8", Enriched: true},
+		{Key: "huge.jpg", Size: 50 << 20, LastModified: day(15), ContentType: "image/jpeg", Enriched: true},
+		{Key: "unknown.bin", Size: 2 << 20, LastModified: day(10)},
+	}
+}
+
+func TestMetadataFilterApply(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter MetadataFilter
+		want   []string
+	}{
+		{
+			"zero filter matches everything",
+			MetadataFilter{},
+			[]string{"photos/", "cat.jpg", "dog.png", "notes.txt", "huge.jpg", "unknown.bin"},
+		},
+		{
+			"content type glob",
+			MetadataFilter{ContentType: "image/*"},
+			[]string{"cat.jpg", "dog.png", "huge.jpg"},
+		},
+		{
+			"content type ignores parameters",
+			MetadataFilter{ContentType: "text/plain"},
+			[]string{"notes.txt"},
+		},
+		{
+			"size range",
+			MetadataFilter{MinSize: 1 << 20, MaxSize: 10 << 20},
+			[]string{"cat.jpg", "notes.txt", "unknown.bin"},
+		},
+		{
+			"modified window",
+			MetadataFilter{
+				ModifiedAfter:  time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC),
+				ModifiedBefore: time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC),
+			},
+			[]string{"notes.txt", "huge.jpg", "unknown.bin"},
+		},
+		{
+			"combined filters intersect",
+			MetadataFilter{ContentType: "image/*", MinSize: 1 << 20, MaxSize: 10 << 20},
+			[]string{"cat.jpg"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := keysOf(tt.filter.Apply(metadataFixture()))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Apply() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMetadataFilter(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    MetadataFilter
+		wantErr bool
+	}{
+		{"", MetadataFilter{}, false},
+		{"type:image/*", MetadataFilter{ContentType: "image/*"}, false},
+		{"size>1MB size<=2MiB", MetadataFilter{MinSize: 1_000_000, MaxSize: 2 << 20}, false},
+		{
+			"after:2024-01-01 before:2024-02-01",
+			MetadataFilter{
+				ModifiedAfter:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				ModifiedBefore: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+			},
+			false,
+		},
+		{"type:[", MetadataFilter{}, true},
+		{"size>lots", MetadataFilter{}, true},
+		{"after:yesterday", MetadataFilter{}, true},
+		{"size>2MB size<1MB", MetadataFilter{}, true},
+		{"owner:me", MetadataFilter{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseMetadataFilter(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseMetadataFilter(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseMetadataFilter(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+			if err == nil {
+				round, err := ParseMetadataFilter(got.String())
+				if err != nil || round != got {
+					t.Errorf("round trip of %q = %+v, %v", got.String(), round, err)
+				}
+			}
+		})
+	}
+}
+
+func TestSetMetadataFilterPlaceholder(t *testing.T) {
+	m := New()
+	m.SetBucket("bucket")
+	m.SetObjects(metadataFixture())
+	m.SetMetadataFilter(MetadataFilter{ContentType: "video/*"})
+
+	if m.State() != StateEmpty {
+		t.Fatalf("State() = %v, want empty", m.State())
+	}
+	want := "Nothing here matches type:video/* — press F to change the filter"
+	if got := m.placeholder(); got != want {
+		t.Errorf("placeholder() = %q, want %q", got, want)
+	}
+
+	m.SetMetadataFilter(MetadataFilter{})
+	if got := len(m.VisibleObjects()); got != 6 {
+		t.Errorf("after clearing, %d visible objects, want 6", got)
+	}
+}
@@ -0,0 +1,159 @@
+package browser
+
+import (
+	"strings"
+
+	"github.com/natevick/stui/internal/aws"
+)
+
+// Row is one entry of a listing, materialized for display
+type Row struct {
+	Object   aws.S3Object
+	Name     string // key as rendered in the current KeyDisplayMode
+	Selected bool
+}
+
+// RowList holds a listing without building anything per entry until it is
+// shown: VisibleWindow materializes only the rows asked for, and SetFilter
+// narrows the previous matches in place when the query grows, so typing a
+// filter over a huge listing doesn't copy it on every keystroke.
+type RowList struct {
+	objects []aws.S3Object
+	names   []string
+	folded  []string // names lowercased once, for case-insensitive filtering
+
+	query    string // lowercased
+	filtered bool
+	matches  []int // indexes into objects matching query, in order
+}
+
+// NewRowList creates a list of objects shown under names, which must be as
+// long as objects
+func NewRowList(objects []aws.S3Object, names []string) *RowList {
+	r := &RowList{}
+	r.Reset(objects, names)
+	return r
+}
+
+// Reset replaces the listing, keeping the filter and reusing the match buffer
+func (r *RowList) Reset(objects []aws.S3Object, names []string) {
+	r.objects = objects
+	r.names = names
+	if cap(r.folded) >= len(names) {
+		r.folded = r.folded[:len(names)]
+	} else {
+		r.folded = make([]string, len(names))
+	}
+	for i, name := range names {
+		r.folded[i] = strings.ToLower(name)
+	}
+	if r.filtered {
+		r.rescan()
+	}
+}
+
+// Total returns the number of entries, ignoring the filter
+func (r *RowList) Total() int {
+	return len(r.objects)
+}
+
+// Len returns the number of entries matching the filter
+func (r *RowList) Len() int {
+	if r.filtered {
+		return len(r.matches)
+	}
+	return len(r.objects)
+}
+
+// index maps a position among the matches to an index into objects
+func (r *RowList) index(i int) int {
+	if r.filtered {
+		return r.matches[i]
+	}
+	return i
+}
+
+// Object returns the entry at position i among the matches
+func (r *RowList) Object(i int) (aws.S3Object, bool) {
+	if i < 0 || i >= r.Len() {
+		return aws.S3Object{}, false
+	}
+	return r.objects[r.index(i)], true
+}
+
+// Name returns the rendered name of the entry at position i among the matches
+func (r *RowList) Name(i int) string {
+	if i < 0 || i >= r.Len() {
+		return ""
+	}
+	return r.names[r.index(i)]
+}
+
+// Position returns where the entry with key is among the matches, or -1
+func (r *RowList) Position(key string) int {
+	for i := range r.Len() {
+		if r.objects[r.index(i)].Key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// Filter returns the current filter query, lowercased
+func (r *RowList) Filter() string {
+	return r.query
+}
+
+// SetFilter keeps only entries whose name contains query, ignoring case; an
+// empty query shows everything. A query that contains the previous one can
+// only match fewer entries, so those are narrowed in place; any other query
+// rescans into the same buffer.
+func (r *RowList) SetFilter(query string) {
+	query = strings.ToLower(query)
+	switch {
+	case query == "":
+		r.query, r.filtered = "", false
+		r.matches = r.matches[:0]
+	case r.filtered && strings.Contains(query, r.query):
+		r.query = query
+		kept := r.matches[:0]
+		for _, i := range r.matches {
+			if strings.Contains(r.folded[i], query) {
+				kept = append(kept, i)
+			}
+		}
+		r.matches = kept
+	default:
+		r.query, r.filtered = query, true
+		r.rescan()
+	}
+}
+
+// rescan matches every entry against the query
+func (r *RowList) rescan() {
+	r.matches = r.matches[:0]
+	for i, name := range r.folded {
+		if strings.Contains(name, r.query) {
+			r.matches = append(r.matches, i)
+		}
+	}
+}
+
+// VisibleWindow materializes up to height rows starting at offset among the
+// matches. The offset is clamped so the window stays full where the list
+// allows: past the end it shows the last height rows.
+func (r *RowList) VisibleWindow(offset, height int) []Row {
+	n := r.Len()
+	if height <= 0 || n == 0 {
+		return nil
+	}
+	offset = max(0, min(offset, n-height))
+	end := min(offset+height, n)
+
+	rows := make([]Row, 0, end-offset)
+	for i := offset; i < end; i++ {
+		idx := r.index(i)
+		rows = append(rows, Row{Object: r.objects[idx], Name: r.names[idx]})
+	}
+	return rows
+}
@@ -0,0 +1,147 @@
+package browser
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/natevick/stui/internal/aws"
+)
+
+// largeRows returns a RowList of n objects named file-000000.log and so on,
+// with every tenth under reports/
+func largeRows(n int) *RowList {
+	objects := make([]aws.S3Object, n)
+	names := make([]string, n)
+	for i := range objects {
+		name := fmt.Sprintf("file-%06d.log", i)
+		if i%10 == 0 {
+			name = fmt.Sprintf("reports/Q%06d.csv", i)
+		}
+		objects[i] = aws.S3Object{Key: name, Size: int64(i)}
+		names[i] = name
+	}
+	return NewRowList(objects, names)
+}
+
+func TestRowListVisibleWindow(t *testing.T) {
+	const n = 100_000
+	r := largeRows(n)
+
+	tests := []struct {
+		name          string
+		offset        int
+		height        int
+		first, length int
+	}{
+		{"top", 0, 20, 0, 20},
+		{"middle", 50_000, 20, 50_000, 20},
+		{"bottom", n - 20, 20, n - 20, 20},
+		{"past the end shows the last page", n + 5, 20, n - 20, 20},
+		{"negative offset", -3, 20, 0, 20},
+		{"taller than the list", 0, n + 10, 0, n},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := r.VisibleWindow(tt.offset, tt.height)
+			if len(rows) != tt.length {
+				t.Fatalf("got %d rows, want %d", len(rows), tt.length)
+			}
+			for i, row := range rows {
+				want, _ := r.Object(tt.first + i)
+				if row.Object.Key != want.Key || row.Name != want.Key {
+					t.Fatalf("row %d = %q, want %q", i, row.Object.Key, want.Key)
+				}
+			}
+		})
+	}
+
+	if rows := r.VisibleWindow(0, 0); rows != nil {
+		t.Errorf("zero height window = %d rows, want none", len(rows))
+	}
+}
+
+func TestRowListFilteredWindow(t *testing.T) {
+	r := largeRows(100_000)
+	r.SetFilter("REPORTS/")
+	if got := r.Len(); got != 10_000 {
+		t.Fatalf("Len() = %d, want 10000 matches", got)
+	}
+
+	rows := r.VisibleWindow(9_995, 10)
+	if len(rows) != 10 {
+		t.Fatalf("got %d rows, want 10", len(rows))
+	}
+	if first, last := rows[0].Object.Key, rows[9].Object.Key; first != "reports/Q099900.csv" || last != "reports/Q099990.csv" {
+		t.Errorf("window = %s .. %s, want reports/Q099900.csv .. reports/Q099990.csv", first, last)
+	}
+	if pos := r.Position("reports/Q000020.csv"); pos != 2 {
+		t.Errorf("Position() = %d, want 2", pos)
+	}
+	if pos := r.Position("file-000001.log"); pos != -1 {
+		t.Errorf("Position() of a filtered-out key = %d, want -1", pos)
+	}
+}
+
+func TestRowListIncrementalFilter(t *testing.T) {
+	r := largeRows(1_000)
+
+	// Each narrowing only drops matches
+	for _, q := range []string{"r", "re", "rep", "reports/q0001"} {
+		r.SetFilter(q)
+	}
+	if got := r.Len(); got != 10 {
+		t.Errorf("Len() after narrowing = %d, want 10", got)
+	}
+
+	// Widening rescans
+	r.SetFilter("reports/")
+	if got := r.Len(); got != 100 {
+		t.Errorf("Len() after widening = %d, want 100", got)
+	}
+	r.SetFilter("")
+	if got := r.Len(); got != 1_000 {
+		t.Errorf("Len() after clearing = %d, want 1000", got)
+	}
+	r.SetFilter("no such key")
+	if got := r.Len(); got != 0 {
+		t.Errorf("Len() with no matches = %d, want 0", got)
+	}
+}
+
+func TestRowListFilterDoesNotAllocatePerKeystroke(t *testing.T) {
+	r := largeRows(100_000)
+	// Typing "reports/q05", then deleting back to nothing
+	var keystrokes []string
+	query := "reports/q05"
+	for i := 1; i <= len(query); i++ {
+		keystrokes = append(keystrokes, query[:i])
+	}
+	for i := len(query) - 1; i >= 0; i-- {
+		keystrokes = append(keystrokes, query[:i])
+	}
+
+	// The first pass grows the match buffer once
+	for _, q := range keystrokes {
+		r.SetFilter(q)
+	}
+	allocs := testing.AllocsPerRun(5, func() {
+		for _, q := range keystrokes {
+			r.SetFilter(q)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("filtering allocated %.0f times over %d keystrokes, want 0", allocs, len(keystrokes))
+	}
+}
+
+func TestRowListResetKeepsFilter(t *testing.T) {
+	r := largeRows(100)
+	r.SetFilter("reports/")
+	r.Reset([]aws.S3Object{{Key: "reports/a.csv"}, {Key: "b.log"}}, []string{"reports/a.csv", "b.log"})
+	if r.Len() != 1 || r.Total() != 2 {
+		t.Errorf("Len() = %d, Total() = %d, want 1 of 2", r.Len(), r.Total())
+	}
+	if obj, _ := r.Object(0); obj.Key != "reports/a.csv" {
+		t.Errorf("Object(0) = %q, want reports/a.csv", obj.Key)
+	}
+}
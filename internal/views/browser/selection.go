@@ -0,0 +1,85 @@
+package browser
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/natevick/stui/internal/aws"
+	"github.com/natevick/stui/internal/platform"
+)
+
+// Selection is the set of keys picked for a batch action
+type Selection struct {
+	keys map[string]bool
+}
+
+// NewSelection creates an empty selection
+func NewSelection() *Selection {
+	return &Selection{keys: make(map[string]bool)}
+}
+
+// Has reports whether key is selected
+func (s *Selection) Has(key string) bool {
+	return s.keys[key]
+}
+
+// Toggle selects key, or deselects it if it was selected
+func (s *Selection) Toggle(key string) {
+	if s.keys[key] {
+		delete(s.keys, key)
+	} else {
+		s.keys[key] = true
+	}
+}
+
+// Remove deselects key
+func (s *Selection) Remove(key string) {
+	delete(s.keys, key)
+}
+
+// Len returns the number of selected keys
+func (s *Selection) Len() int {
+	return len(s.keys)
+}
+
+// Clear deselects everything
+func (s *Selection) Clear() {
+	clear(s.keys)
+}
+
+// Keys returns the selected keys in sorted order
+func (s *Selection) Keys() []string {
+	keys := make([]string, 0, len(s.keys))
+	for k := range s.keys {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// CopySelectionURIs copies the s3:// URI of every selected key, one per
+// line and in key order. An empty selection leaves the clipboard untouched.
+func CopySelectionURIs(sel *Selection, bucket string, clip platform.Clipboard) error {
+	if sel == nil || sel.Len() == 0 {
+		return nil
+	}
+	keys := sel.Keys()
+	uris := make([]string, len(keys))
+	for i, key := range keys {
+		uris[i] = aws.FormatS3URI(bucket, key)
+	}
+	return clip.Copy(strings.Join(uris, "\n"))
+}
+
+// CopyS3URI copies the s3:// URI of one key and returns what was copied
+func CopyS3URI(bucket, key string, clip platform.Clipboard) (string, error) {
+	uri := aws.FormatS3URI(bucket, key)
+	return uri, clip.Copy(uri)
+}
+
+// CopyConsoleURL copies the AWS console link for one key and returns what
+// was copied. region picks the partition's console as well as the region.
+func CopyConsoleURL(region, bucket, key string, clip platform.Clipboard) (string, error) {
+	link := aws.ConsoleURL(region, bucket, key)
+	return link, clip.Copy(link)
+}
@@ -0,0 +1,75 @@
+package browser
+
+import "testing"
+
+// recordingClipboard remembers every Copy call
+type recordingClipboard struct {
+	copies []string
+}
+
+func (c *recordingClipboard) Copy(text string) error {
+	c.copies = append(c.copies, text)
+	return nil
+}
+
+func TestCopySelectionURIs(t *testing.T) {
+	sel := NewSelection()
+	for _, key := range []string{"logs/b.txt", "logs/a b.txt", "logs/archive/"} {
+		sel.Toggle(key)
+	}
+
+	clip := &recordingClipboard{}
+	if err := CopySelectionURIs(sel, "my-bucket", clip); err != nil {
+		t.Fatalf("CopySelectionURIs() error = %v", err)
+	}
+	want := "s3://my-bucket/logs/a b.txt\ns3://my-bucket/logs/archive/\ns3://my-bucket/logs/b.txt"
+	if len(clip.copies) != 1 || clip.copies[0] != want {
+		t.Errorf("clipboard got %q, want %q", clip.copies, want)
+	}
+}
+
+func TestCopySelectionURIsEmpty(t *testing.T) {
+	clip := &recordingClipboard{}
+	if err := CopySelectionURIs(NewSelection(), "my-bucket", clip); err != nil {
+		t.Fatalf("CopySelectionURIs() error = %v", err)
+	}
+	if len(clip.copies) != 0 {
+		t.Errorf("empty selection copied %q", clip.copies)
+	}
+}
+
+func TestCopyS3URI(t *testing.T) {
+	clip := &recordingClipboard{}
+	got, err := CopyS3URI("my-bucket", "logs/app log.txt", clip)
+	if err != nil {
+		t.Fatalf("CopyS3URI() error = %v", err)
+	}
+	want := "s3://my-bucket/logs/app log.txt"
+	if got != want || len(clip.copies) != 1 || clip.copies[0] != want {
+		t.Errorf("CopyS3URI() = %q, clipboard %q, want %q", got, clip.copies, want)
+	}
+}
+
+func TestCopyConsoleURL(t *testing.T) {
+	tests := []struct {
+		region string
+		key    string
+		want   string
+	}{
+		{"eu-west-1", "logs/app.txt", "https://s3.console.aws.amazon.com/s3/object/my-bucket?prefix=logs%2Fapp.txt&region=eu-west-1"},
+		{"eu-west-1", "logs/", "https://s3.console.aws.amazon.com/s3/buckets/my-bucket?prefix=logs%2F&region=eu-west-1"},
+		{"us-gov-west-1", "data.csv", "https://console.amazonaws-us-gov.com/s3/object/my-bucket?prefix=data.csv&region=us-gov-west-1"},
+		{"cn-north-1", "data.csv", "https://console.amazonaws.cn/s3/object/my-bucket?prefix=data.csv&region=cn-north-1"},
+	}
+
+	for _, tt := range tests {
+		clip := &recordingClipboard{}
+		got, err := CopyConsoleURL(tt.region, "my-bucket", tt.key, clip)
+		if err != nil {
+			t.Fatalf("CopyConsoleURL(%q, %q) error = %v", tt.region, tt.key, err)
+		}
+		if got != tt.want || len(clip.copies) != 1 || clip.copies[0] != tt.want {
+			t.Errorf("CopyConsoleURL(%q, %q) = %q, clipboard %q, want %q", tt.region, tt.key, got, clip.copies, tt.want)
+		}
+	}
+}
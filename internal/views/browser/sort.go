@@ -0,0 +1,76 @@
+package browser
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/natevick/stui/internal/aws"
+)
+
+// SortKey is what a listing is ordered by
+type SortKey int
+
+const (
+	// SortByName orders by key
+	SortByName SortKey = iota
+	// SortBySize orders by size; folders count as zero
+	SortBySize
+	// SortByModified orders by last-modified time; folders count as the zero time
+	SortByModified
+)
+
+func (k SortKey) String() string {
+	switch k {
+	case SortBySize:
+		return "size"
+	case SortByModified:
+		return "modified"
+	default:
+		return "name"
+	}
+}
+
+// SortOptions configures SortObjects
+type SortOptions struct {
+	Key        SortKey
+	Descending bool
+	// FoldersFirst puts every folder ahead of every object, whatever the
+	// key and direction, and sorts each group on its own
+	FoldersFirst bool
+}
+
+// DefaultSortOptions sorts by name, ascending, with folders first
+func DefaultSortOptions() SortOptions {
+	return SortOptions{Key: SortByName, FoldersFirst: true}
+}
+
+// SortObjects sorts objects in place. Entries that tie on the key are
+// ordered by key, ascending, so the result doesn't depend on input order.
+func SortObjects(objects []aws.S3Object, opts SortOptions) {
+	slices.SortStableFunc(objects, func(a, b aws.S3Object) int {
+		if opts.FoldersFirst && a.IsPrefix != b.IsPrefix {
+			if a.IsPrefix {
+				return -1
+			}
+			return 1
+		}
+
+		var c int
+		switch opts.Key {
+		case SortBySize:
+			c = cmp.Compare(a.Size, b.Size)
+		case SortByModified:
+			c = a.LastModified.Compare(b.LastModified)
+		default:
+			c = strings.Compare(a.Key, b.Key)
+		}
+		if opts.Descending {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+		return strings.Compare(a.Key, b.Key)
+	})
+}
@@ -0,0 +1,63 @@
+package browser
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/natevick/stui/internal/aws"
+)
+
+func sortFixture() []aws.S3Object {
+	return []aws.S3Object{
+		{Key: "medium.bin", Size: 500},
+		{Key: "zeta/", IsPrefix: true},
+		{Key: "big.bin", Size: 9000},
+		{Key: "alpha/", IsPrefix: true},
+		{Key: "empty.txt", Size: 0},
+		{Key: "small.bin", Size: 10},
+	}
+}
+
+func TestSortObjects(t *testing.T) {
+	tests := []struct {
+		name string
+		opts SortOptions
+		want []string
+	}{
+		{
+			"size descending, folders first",
+			SortOptions{Key: SortBySize, Descending: true, FoldersFirst: true},
+			[]string{"alpha/", "zeta/", "big.bin", "medium.bin", "small.bin", "empty.txt"},
+		},
+		{
+			"size descending, folders interleaved as zero",
+			SortOptions{Key: SortBySize, Descending: true},
+			[]string{"big.bin", "medium.bin", "small.bin", "alpha/", "empty.txt", "zeta/"},
+		},
+		{
+			"size ascending, folders first",
+			SortOptions{Key: SortBySize, FoldersFirst: true},
+			[]string{"alpha/", "zeta/", "empty.txt", "small.bin", "medium.bin", "big.bin"},
+		},
+		{
+			"name descending, folders first",
+			SortOptions{Key: SortByName, Descending: true, FoldersFirst: true},
+			[]string{"zeta/", "alpha/", "small.bin", "medium.bin", "empty.txt", "big.bin"},
+		},
+		{
+			"default options",
+			DefaultSortOptions(),
+			[]string{"alpha/", "zeta/", "big.bin", "empty.txt", "medium.bin", "small.bin"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects := sortFixture()
+			SortObjects(objects, tt.opts)
+			if got := keysOf(objects); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SortObjects() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
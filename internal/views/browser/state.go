@@ -0,0 +1,81 @@
+package browser
+
+import "fmt"
+
+// ListState is what the listing area is showing
+type ListState int
+
+const (
+	// StateEmpty is a finished listing with nothing visible in it
+	StateEmpty ListState = iota
+	// StateLoading is a listing in flight
+	StateLoading
+	// StateError is a failed listing; ErrorMessage says why
+	StateError
+	// StatePopulated is a finished listing with visible entries
+	StatePopulated
+)
+
+func (s ListState) String() string {
+	switch s {
+	case StateEmpty:
+		return "empty"
+	case StateLoading:
+		return "loading"
+	case StateError:
+		return "error"
+	case StatePopulated:
+		return "populated"
+	default:
+		return "unknown"
+	}
+}
+
+// Placeholders is the text shown in place of the list in each non-populated state
+type Placeholders struct {
+	Loading string
+	// Empty is shown for a prefix with no entries at all
+	Empty string
+	// EmptyBucket is shown at the root of a bucket with no entries
+	EmptyBucket string
+	// AllHidden is shown when every entry is hidden; %d is the hidden count
+	AllHidden string
+	// NoMatches is shown when the metadata filter hides every entry; %s is the filter
+	NoMatches string
+	// Error prefixes the sanitized error message
+	Error string
+}
+
+// DefaultPlaceholders returns the built-in placeholder text
+func DefaultPlaceholders() Placeholders {
+	return Placeholders{
+		Loading:     "Loading objects...",
+		Empty:       "This prefix is empty",
+		EmptyBucket: "This bucket is empty",
+		AllHidden:   "Only hidden entries here (%d) — press . to show them",
+		NoMatches:   "Nothing here matches %s — press F to change the filter",
+		Error:       "Error: ",
+	}
+}
+
+// placeholder returns the text for the current non-populated state
+func (m Model) placeholder() string {
+	switch m.state {
+	case StateLoading:
+		return m.placeholders.Loading
+	case StateError:
+		return m.placeholders.Error + m.errMsg
+	}
+	if m.meta.Active() && len(m.all) > 0 {
+		if len(m.filter.Apply(m.all)) > 0 {
+			return fmt.Sprintf(m.placeholders.NoMatches, m.meta)
+		}
+	}
+	if hidden := len(m.all) - len(m.objects); hidden > 0 {
+		return fmt.Sprintf(m.placeholders.AllHidden, hidden)
+	}
+	if m.prefix == "" {
+		return m.placeholders.EmptyBucket
+	}
+	return m.placeholders.Empty
+}
@@ -0,0 +1,86 @@
+package browser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/natevick/stui/internal/aws"
+)
+
+func TestListStateTransitions(t *testing.T) {
+	tests := []struct {
+		name        string
+		finish      func(m *Model)
+		want        ListState
+		placeholder string
+	}{
+		{
+			name:        "loading to empty",
+			finish:      func(m *Model) { m.SetObjects(nil) },
+			want:        StateEmpty,
+			placeholder: "This prefix is empty",
+		},
+		{
+			name:        "loading to empty with only hidden entries",
+			finish:      func(m *Model) { m.SetObjects([]aws.S3Object{{Key: "logs/.keep"}}) },
+			want:        StateEmpty,
+			placeholder: "press . to show",
+		},
+		{
+			name: "loading to error",
+			finish: func(m *Model) {
+				m.SetError(errors.New("AccessDenied for arn:aws:iam::123456789012:role/reader"))
+			},
+			want:        StateError,
+			placeholder: "[arn]",
+		},
+		{
+			name:   "loading to populated",
+			finish: func(m *Model) { m.SetObjects([]aws.S3Object{{Key: "logs/a.txt"}}) },
+			want:   StatePopulated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New()
+			m.SetSize(80, 40)
+			m.SetBucket("b")
+			m.SetPrefix("logs/")
+			m.SetLoading(true)
+			if m.State() != StateLoading || !m.Loading() {
+				t.Fatalf("state = %v, want loading", m.State())
+			}
+
+			tt.finish(&m)
+			if m.State() != tt.want {
+				t.Fatalf("state = %v, want %v", m.State(), tt.want)
+			}
+			if tt.placeholder != "" && !strings.Contains(m.View(), tt.placeholder) {
+				t.Errorf("view does not show %q:\n%s", tt.placeholder, m.View())
+			}
+		})
+	}
+}
+
+func TestErrorStateIsSanitizedAndClears(t *testing.T) {
+	m := New()
+	m.SetBucket("b")
+	m.SetLoading(true)
+	m.SetError(errors.New("denied for account 123456789012"))
+
+	if m.State() != StateError {
+		t.Fatalf("state = %v, want error", m.State())
+	}
+	if msg := m.ErrorMessage(); strings.Contains(msg, "123456789012") || !strings.Contains(msg, "[account-id]") {
+		t.Errorf("ErrorMessage() = %q, want the account ID redacted", msg)
+	}
+
+	// The next successful listing replaces the error
+	m.SetLoading(true)
+	m.SetObjects([]aws.S3Object{{Key: "a.txt"}})
+	if m.State() != StatePopulated || m.ErrorMessage() != "" {
+		t.Errorf("after reload state = %v, message %q", m.State(), m.ErrorMessage())
+	}
+}
@@ -0,0 +1,57 @@
+package browser
+
+import (
+	"strings"
+
+	"github.com/natevick/stui/internal/aws"
+)
+
+// InternalPrefixes are prefixes stui uses for its own bookkeeping.
+// They are hidden even when hidden files are shown.
+var InternalPrefixes = []string{aws.TrashPrefix}
+
+// VisibilityFilter decides which listing entries are shown
+type VisibilityFilter struct {
+	// ShowHidden shows entries whose name starts with "."
+	ShowHidden bool
+	// AlwaysHidden lists key prefixes that are never shown
+	AlwaysHidden []string
+}
+
+// NewVisibilityFilter creates a filter that always hides InternalPrefixes
+func NewVisibilityFilter(showHidden bool) VisibilityFilter {
+	return VisibilityFilter{
+		ShowHidden:   showHidden,
+		AlwaysHidden: InternalPrefixes,
+	}
+}
+
+// IsHidden reports whether the last path segment of key starts with "."
+func IsHidden(key string) bool {
+	name := strings.TrimSuffix(key, "/")
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return strings.HasPrefix(name, ".")
+}
+
+// Visible reports whether an entry passes the filter
+func (f VisibilityFilter) Visible(obj aws.S3Object) bool {
+	for _, prefix := range f.AlwaysHidden {
+		if strings.HasPrefix(obj.Key, prefix) {
+			return false
+		}
+	}
+	return f.ShowHidden || !IsHidden(obj.Key)
+}
+
+// Apply returns the entries that pass the filter
+func (f VisibilityFilter) Apply(objects []aws.S3Object) []aws.S3Object {
+	visible := make([]aws.S3Object, 0, len(objects))
+	for _, obj := range objects {
+		if f.Visible(obj) {
+			visible = append(visible, obj)
+		}
+	}
+	return visible
+}
@@ -0,0 +1,91 @@
+package browser
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/natevick/stui/internal/aws"
+)
+
+func visibilityFixture() []aws.S3Object {
+	return []aws.S3Object{
+		{Key: ".env"},
+		{Key: ".github/", IsPrefix: true},
+		{Key: ".s3-tui-trash/", IsPrefix: true},
+		{Key: ".s3-tui-trash/old.txt"},
+		{Key: "docs/", IsPrefix: true},
+		{Key: "docs/.hidden.txt"},
+		{Key: "docs/readme.md"},
+		{Key: "not.hidden"},
+	}
+}
+
+func keysOf(objects []aws.S3Object) []string {
+	keys := make([]string, len(objects))
+	for i, obj := range objects {
+		keys[i] = obj.Key
+	}
+	return keys
+}
+
+func TestVisibilityFilter(t *testing.T) {
+	tests := []struct {
+		name       string
+		showHidden bool
+		want       []string
+	}{
+		{
+			"hidden entries filtered",
+			false,
+			[]string{"docs/", "docs/readme.md", "not.hidden"},
+		},
+		{
+			"hidden entries shown, trash still hidden",
+			true,
+			[]string{".env", ".github/", "docs/", "docs/.hidden.txt", "docs/readme.md", "not.hidden"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := keysOf(NewVisibilityFilter(tt.showHidden).Apply(visibilityFixture()))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Apply() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsHidden(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{".env", true},
+		{".github/", true},
+		{"a/b/.cache", true},
+		{"a/.b/c.txt", false},
+		{"file.txt", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsHidden(tt.key); got != tt.want {
+			t.Errorf("IsHidden(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestBrowserToggleHidden(t *testing.T) {
+	m := New()
+	m.SetBucket("b")
+	m.SetObjects(visibilityFixture())
+	if got := len(m.list.Items()); got != 3 {
+		t.Fatalf("items with hidden filtered = %d, want 3", got)
+	}
+
+	m.SetShowHidden(true)
+	if got := len(m.list.Items()); got != 6 {
+		t.Errorf("items with hidden shown = %d, want 6", got)
+	}
+}
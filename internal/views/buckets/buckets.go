@@ -14,12 +14,33 @@ import (
 // Item represents a bucket in the list
 type Item struct {
 	bucket aws.Bucket
+	// home is the configured home region, empty when unset
+	home string
 }
 
-func (i Item) Title() string       { return i.bucket.Name }
-func (i Item) Description() string { return fmt.Sprintf("Created: %s", i.bucket.CreationDate.Format("2006-01-02")) }
+func (i Item) Title() string { return i.bucket.Name }
+func (i Item) Description() string {
+	desc := fmt.Sprintf("Created: %s", i.bucket.CreationDate.Format("2006-01-02"))
+	switch {
+	case i.bucket.Region == "":
+		return desc
+	case !i.InHomeRegion():
+		return fmt.Sprintf("%s • ⚠ %s (not %s)", desc, i.bucket.Region, i.home)
+	default:
+		return fmt.Sprintf("%s • %s", desc, i.bucket.Region)
+	}
+}
 func (i Item) FilterValue() string { return i.bucket.Name }
 
+// Region returns the bucket's region, empty until it's known
+func (i Item) Region() string { return i.bucket.Region }
+
+// InHomeRegion reports whether the bucket is in the home region. It is true
+// when either region is unknown, so only a confirmed difference is flagged.
+func (i Item) InHomeRegion() bool {
+	return i.home == "" || i.bucket.Region == "" || i.bucket.Region == i.home
+}
+
 // Action represents an action to take
 type Action int
 
@@ -27,12 +48,15 @@ const (
 	ActionNone Action = iota
 	ActionSelect
 	ActionBookmark
+	ActionPolicy
+	ActionCORS
 )
 
 // Model is the buckets view model
 type Model struct {
 	list           list.Model
 	buckets        []aws.Bucket
+	homeRegion     string
 	loading        bool
 	err            error
 	width          int
@@ -83,11 +107,47 @@ func (m *Model) SetBuckets(buckets []aws.Bucket) {
 
 	items := make([]list.Item, len(buckets))
 	for i, b := range buckets {
-		items[i] = Item{bucket: b}
+		items[i] = Item{bucket: b, home: m.homeRegion}
 	}
 	m.list.SetItems(items)
 }
 
+// SetHomeRegion sets the region buckets are compared against; empty turns
+// the flag off
+func (m *Model) SetHomeRegion(region string) {
+	m.homeRegion = region
+	m.SetBuckets(m.buckets)
+}
+
+// SetBucketRegions fills in regions looked up after the listing, keyed by
+// bucket name. The returned command re-applies an active filter.
+func (m *Model) SetBucketRegions(regions map[string]string) tea.Cmd {
+	items := make([]list.Item, len(m.buckets))
+	for i, b := range m.buckets {
+		if region := regions[b.Name]; region != "" {
+			m.buckets[i].Region = region
+		}
+		items[i] = Item{bucket: m.buckets[i], home: m.homeRegion}
+	}
+	return m.list.SetItems(items)
+}
+
+// MissingRegions returns the buckets whose region isn't known yet
+func MissingRegions(buckets []aws.Bucket) []string {
+	var names []string
+	for _, b := range buckets {
+		if b.Region == "" {
+			names = append(names, b.Name)
+		}
+	}
+	return names
+}
+
+// AppendBuckets adds another page of buckets to the list
+func (m *Model) AppendBuckets(buckets []aws.Bucket) {
+	m.SetBuckets(append(m.buckets, buckets...))
+}
+
 // SetError sets an error state
 func (m *Model) SetError(err error) {
 	m.err = err
@@ -132,6 +192,20 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 				m.action = ActionBookmark
 				return m, nil
 			}
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("P"))):
+			if item, ok := m.list.SelectedItem().(Item); ok {
+				m.selectedBucket = item.bucket.Name
+				m.action = ActionPolicy
+				return m, nil
+			}
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("C"))):
+			if item, ok := m.list.SelectedItem().(Item); ok {
+				m.selectedBucket = item.bucket.Name
+				m.action = ActionCORS
+				return m, nil
+			}
 		}
 	}
 
@@ -0,0 +1,60 @@
+package buckets
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/natevick/stui/internal/aws"
+)
+
+func items(m Model) []Item {
+	var out []Item
+	for _, it := range m.list.Items() {
+		out = append(out, it.(Item))
+	}
+	return out
+}
+
+func TestSetBucketRegionsPopulatesRegions(t *testing.T) {
+	m := New()
+	m.SetHomeRegion("us-east-1")
+	m.SetBuckets([]aws.Bucket{{Name: "logs"}, {Name: "assets", Region: "us-east-1"}})
+
+	if got := MissingRegions(m.buckets); len(got) != 1 || got[0] != "logs" {
+		t.Fatalf("MissingRegions() = %v, want [logs]", got)
+	}
+
+	m.SetBucketRegions(map[string]string{"logs": "us-east-1"})
+	for _, item := range items(m) {
+		if item.Region() != "us-east-1" {
+			t.Errorf("%s region = %q, want us-east-1", item.Title(), item.Region())
+		}
+		if !item.InHomeRegion() {
+			t.Errorf("%s flagged outside its home region", item.Title())
+		}
+	}
+	if got := MissingRegions(m.buckets); len(got) != 0 {
+		t.Errorf("MissingRegions() after lookup = %v, want none", got)
+	}
+}
+
+func TestInHomeRegionFalseForDifferentRegion(t *testing.T) {
+	m := New()
+	m.SetHomeRegion("us-east-1")
+	m.SetBuckets([]aws.Bucket{{Name: "eu-data"}})
+	m.SetBucketRegions(map[string]string{"eu-data": "eu-west-1"})
+
+	item := items(m)[0]
+	if item.InHomeRegion() {
+		t.Error("InHomeRegion() = true for a bucket in eu-west-1, want false")
+	}
+	if desc := item.Description(); !strings.Contains(desc, "⚠ eu-west-1") {
+		t.Errorf("Description() = %q, want the region flagged", desc)
+	}
+
+	// Without a home region nothing is flagged
+	m.SetHomeRegion("")
+	if item := items(m)[0]; !item.InHomeRegion() || strings.Contains(item.Description(), "⚠") {
+		t.Errorf("bucket flagged with no home region: %q", item.Description())
+	}
+}
@@ -42,7 +42,18 @@ func (m *Model) SetSize(width, height int) {
 // SetProgress updates the download progress
 func (m *Model) SetProgress(p download.Progress) {
 	m.progress = p
-	m.active = p.Status == download.StatusInProgress || p.Status == download.StatusPending
+	m.active = p.Status == download.StatusInProgress || p.Status == download.StatusPending ||
+		p.Status == download.StatusPaused
+}
+
+// Paused returns true if the session budget has paused the download
+func (m Model) Paused() bool {
+	return m.progress.Status == download.StatusPaused
+}
+
+// Progress returns the most recent download progress
+func (m Model) Progress() download.Progress {
+	return m.progress
 }
 
 // IsActive returns true if a download is in progress
@@ -89,6 +100,12 @@ func (m Model) View() string {
 		sb.WriteString(statusStyle.Foreground(lipgloss.Color("196")).Render("✗ Download failed"))
 	case download.StatusCancelled:
 		sb.WriteString(statusStyle.Foreground(lipgloss.Color("240")).Render("⊘ Download cancelled"))
+	case download.StatusPaused:
+		sb.WriteString(statusStyle.Foreground(lipgloss.Color("214")).Render("⏸ Paused: transfer budget reached"))
+		if b := m.progress.Budget; b != nil {
+			sb.WriteString("\n")
+			sb.WriteString(statusStyle.Foreground(lipgloss.Color("240")).Render(budgetSummary(*b)))
+		}
 	}
 	sb.WriteString("\n\n")
 
@@ -182,8 +199,12 @@ func (m Model) View() string {
 		Foreground(lipgloss.Color("240")).
 		Padding(0, 1)
 
-	if m.active {
+	if m.Paused() {
+		sb.WriteString(helpStyle.Render("Press Enter to resume with a fresh budget, Esc to cancel"))
+	} else if m.active {
 		sb.WriteString(helpStyle.Render("Press Esc to cancel"))
+	} else if m.progress.FailedFiles > 0 {
+		sb.WriteString(helpStyle.Render(fmt.Sprintf("Press R to retry %d failed files, 1 to go to Buckets, 2 to go to Browser", m.progress.FailedFiles)))
 	} else {
 		sb.WriteString(helpStyle.Render("Press 1 to go to Buckets, 2 to go to Browser"))
 	}
@@ -201,6 +222,18 @@ func (m Model) renderNoDownload() string {
 	return style.Render("No downloads in progress\n\nPress 'd' on a file or folder in the Browser to download")
 }
 
+// budgetSummary describes what the session has spent against its limits
+func budgetSummary(u download.BudgetUsage) string {
+	var parts []string
+	if u.MaxBytes > 0 {
+		parts = append(parts, fmt.Sprintf("%s of %s", humanize.Bytes(uint64(u.Bytes)), humanize.Bytes(uint64(u.MaxBytes))))
+	}
+	if u.MaxTransfers > 0 {
+		parts = append(parts, fmt.Sprintf("%d of %d transfers", u.Transfers, u.MaxTransfers))
+	}
+	return strings.Join(parts, "  •  ")
+}
+
 func truncatePath(path string, maxLen int) string {
 	if len(path) <= maxLen {
 		return path
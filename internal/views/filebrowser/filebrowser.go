@@ -0,0 +1,290 @@
+package filebrowser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/dustin/go-humanize"
+	"github.com/natevick/stui/internal/security"
+)
+
+// Entry is a file or directory in the current listing
+type Entry struct {
+	Name  string
+	Size  int64
+	IsDir bool
+}
+
+// Item represents a local entry in the list
+type Item struct {
+	entry Entry
+}
+
+func (i Item) Title() string {
+	if i.entry.IsDir {
+		return "📁 " + i.entry.Name + "/"
+	}
+	return "📄 " + i.entry.Name
+}
+
+func (i Item) Description() string {
+	if i.entry.IsDir {
+		return "folder"
+	}
+	return humanize.Bytes(uint64(i.entry.Size))
+}
+
+func (i Item) FilterValue() string { return i.entry.Name }
+
+// Action represents an action to take
+type Action int
+
+const (
+	ActionNone Action = iota
+	ActionChoose
+	// ActionChooseDir picks the directory being shown
+	ActionChooseDir
+)
+
+// Model is the local file picker view model
+type Model struct {
+	list       list.Model
+	root       string
+	dir        string
+	entries    []Entry
+	showHidden bool
+	err        error
+	action     Action
+	chosen     string
+	width      int
+	height     int
+}
+
+// New creates a file picker confined to root. An empty root allows
+// browsing anywhere.
+func New(root string) (Model, error) {
+	delegate := list.NewDefaultDelegate()
+	delegate.Styles.SelectedTitle = delegate.Styles.SelectedTitle.
+		Foreground(lipgloss.Color("255")).
+		Background(lipgloss.Color("39")).
+		Bold(true)
+	delegate.Styles.SelectedDesc = delegate.Styles.SelectedDesc.
+		Foreground(lipgloss.Color("252")).
+		Background(lipgloss.Color("39"))
+
+	l := list.New([]list.Item{}, delegate, 0, 0)
+	l.SetShowStatusBar(true)
+	l.SetFilteringEnabled(true)
+	l.SetShowHelp(false)
+	l.Styles.Title = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("39")).
+		Padding(0, 1)
+
+	m := Model{list: l}
+	if root != "" {
+		resolved, err := resolve(root)
+		if err != nil {
+			return m, fmt.Errorf("invalid root directory: %w", err)
+		}
+		m.root = resolved
+	}
+	return m, nil
+}
+
+// resolve makes dir absolute and follows symlinks, so a link inside the
+// root can't be used to step outside it
+func resolve(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(abs)
+}
+
+// Open lists dir, which must be inside the root when one is set
+func (m *Model) Open(dir string) error {
+	target, err := resolve(dir)
+	if err != nil {
+		return fmt.Errorf("failed to open directory: %w", err)
+	}
+	if m.root != "" {
+		rel, err := filepath.Rel(m.root, target)
+		if err != nil {
+			return fmt.Errorf("failed to open directory: %w", err)
+		}
+		if target, err = security.SafePath(m.root, rel); err != nil {
+			return err
+		}
+	}
+
+	dirEntries, err := os.ReadDir(target)
+	if err != nil {
+		return fmt.Errorf("failed to read directory: %w", err)
+	}
+	entries := make([]Entry, 0, len(dirEntries))
+	for _, de := range dirEntries {
+		info, err := de.Info()
+		if err != nil {
+			continue // Removed since ReadDir
+		}
+		entries = append(entries, Entry{
+			Name:  de.Name(),
+			Size:  info.Size(),
+			IsDir: info.IsDir(),
+		})
+	}
+	// Folders first, then files, each by name
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].IsDir != entries[j].IsDir {
+			return entries[i].IsDir
+		}
+		return entries[i].Name < entries[j].Name
+	})
+
+	m.dir = target
+	m.entries = entries
+	m.list.Title = target
+	m.refreshItems()
+	m.list.Select(0)
+	return nil
+}
+
+// Enter opens the named subdirectory of the current directory
+func (m *Model) Enter(name string) error {
+	return m.Open(filepath.Join(m.dir, name))
+}
+
+// Up opens the parent of the current directory
+func (m *Model) Up() error {
+	return m.Open(filepath.Dir(m.dir))
+}
+
+// Dir returns the directory being shown
+func (m Model) Dir() string {
+	return m.dir
+}
+
+// Root returns the directory the picker is confined to, or "" if none
+func (m Model) Root() string {
+	return m.root
+}
+
+// Err returns the last navigation error, if any
+func (m Model) Err() error {
+	return m.err
+}
+
+// Filtering reports whether a filter is being typed, so keys like Esc belong
+// to the list
+func (m Model) Filtering() bool {
+	return m.list.FilterState() == list.Filtering
+}
+
+// ShowHidden reports whether dotfiles are listed
+func (m Model) ShowHidden() bool {
+	return m.showHidden
+}
+
+// ToggleHidden shows or hides dotfiles
+func (m *Model) ToggleHidden() {
+	m.showHidden = !m.showHidden
+	m.refreshItems()
+}
+
+// VisibleEntries returns the entries as shown, after dotfile filtering
+func (m Model) VisibleEntries() []Entry {
+	visible := make([]Entry, 0, len(m.entries))
+	for _, e := range m.entries {
+		if m.showHidden || !strings.HasPrefix(e.Name, ".") {
+			visible = append(visible, e)
+		}
+	}
+	return visible
+}
+
+func (m *Model) refreshItems() {
+	visible := m.VisibleEntries()
+	items := make([]list.Item, len(visible))
+	for i, e := range visible {
+		items[i] = Item{entry: e}
+	}
+	m.list.SetItems(items)
+}
+
+// SetSize sets the view size
+func (m *Model) SetSize(width, height int) {
+	m.width = width
+	m.height = height
+	// Leave a line for navigation errors
+	m.list.SetSize(width, height-1)
+}
+
+// ConsumeAction clears and returns the action and the chosen path
+func (m *Model) ConsumeAction() (Action, string) {
+	action, chosen := m.action, m.chosen
+	m.action = ActionNone
+	m.chosen = ""
+	return action, chosen
+}
+
+// Update handles messages
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		// Don't handle keys if filtering
+		if m.list.FilterState() == list.Filtering {
+			break
+		}
+
+		switch {
+		case key.Matches(msg, key.NewBinding(key.WithKeys("enter", "l", "right"))):
+			item, ok := m.list.SelectedItem().(Item)
+			if !ok {
+				return m, nil
+			}
+			if item.entry.IsDir {
+				m.err = m.Enter(item.entry.Name)
+				return m, nil
+			}
+			m.action = ActionChoose
+			m.chosen = filepath.Join(m.dir, item.entry.Name)
+			return m, nil
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("backspace", "h", "left"))):
+			m.err = m.Up()
+			return m, nil
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("."))):
+			m.ToggleHidden()
+			return m, nil
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys(" "))):
+			m.action = ActionChooseDir
+			m.chosen = m.dir
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.list, cmd = m.list.Update(msg)
+	return m, cmd
+}
+
+// View renders the view
+func (m Model) View() string {
+	status := ""
+	if m.err != nil {
+		status = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("196")).
+			Render(security.SanitizeError(m.err))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, m.list.View(), status)
+}
@@ -0,0 +1,152 @@
+package filebrowser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// newTree creates root/{a.txt,.env,sub/b.txt} and returns root, symlinks resolved
+func newTree(t *testing.T) string {
+	t.Helper()
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(root, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"a.txt":     "hello",
+		".env":      "SECRET=1",
+		"sub/b.txt": "world!",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func names(entries []Entry) []string {
+	out := make([]string, len(entries))
+	for i, e := range entries {
+		out[i] = e.Name
+	}
+	return out
+}
+
+func TestNavigateIntoSubdir(t *testing.T) {
+	root := newTree(t)
+	m, err := New(root)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	m.SetSize(80, 40)
+	if err := m.Open(root); err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	// Folders sort first, so Enter on the first item opens sub/
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.Err() != nil {
+		t.Fatalf("entering sub: %v", m.Err())
+	}
+	if want := filepath.Join(root, "sub"); m.Dir() != want {
+		t.Fatalf("Dir() = %q, want %q", m.Dir(), want)
+	}
+	entries := m.VisibleEntries()
+	if len(entries) != 1 || entries[0].Name != "b.txt" || entries[0].Size != 6 {
+		t.Fatalf("entries = %+v, want b.txt (6 bytes)", entries)
+	}
+
+	// Enter on a file chooses it
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	action, path := m.ConsumeAction()
+	if action != ActionChoose || path != filepath.Join(root, "sub", "b.txt") {
+		t.Errorf("ConsumeAction() = %v, %q; want ActionChoose on sub/b.txt", action, path)
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	if m.Dir() != root {
+		t.Errorf("after backspace Dir() = %q, want %q", m.Dir(), root)
+	}
+
+	// Space chooses the folder being shown
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")})
+	action, path = m.ConsumeAction()
+	if action != ActionChooseDir || path != root {
+		t.Errorf("ConsumeAction() = %v, %q; want ActionChooseDir on the root", action, path)
+	}
+}
+
+func TestEscapeAboveRootBlocked(t *testing.T) {
+	root := newTree(t)
+	m, err := New(root)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := m.Open(root); err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	if err := m.Up(); err == nil {
+		t.Error("Up() from the root succeeded, want an error")
+	}
+	if err := m.Enter(".."); err == nil {
+		t.Error(`Enter("..") from the root succeeded, want an error`)
+	}
+	if m.Dir() != root {
+		t.Errorf("Dir() = %q after blocked escapes, want %q", m.Dir(), root)
+	}
+
+	// A symlink pointing outside the root is refused too
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(root, "out")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+	if err := m.Enter("out"); err == nil {
+		t.Error(`Enter("out") followed a symlink out of the root`)
+	}
+
+	// Without a root, going up is allowed
+	free, _ := New("")
+	if err := free.Open(root); err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if err := free.Up(); err != nil || free.Dir() != filepath.Dir(root) {
+		t.Errorf("unconfined Up() = %v, Dir() = %q", err, free.Dir())
+	}
+}
+
+func TestToggleHidden(t *testing.T) {
+	root := newTree(t)
+	m, _ := New(root)
+	m.SetSize(80, 40)
+	if err := m.Open(root); err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	if got := names(m.VisibleEntries()); len(got) != 2 || got[0] != "sub" || got[1] != "a.txt" {
+		t.Fatalf("entries = %v, want [sub a.txt]", got)
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(".")})
+	if !m.ShowHidden() {
+		t.Fatal("ShowHidden() = false after pressing .")
+	}
+	if got := names(m.VisibleEntries()); len(got) != 3 || got[1] != ".env" {
+		t.Errorf("entries = %v, want .env shown", got)
+	}
+	if got := len(m.list.Items()); got != 3 {
+		t.Errorf("list items = %d, want 3", got)
+	}
+
+	m.ToggleHidden()
+	if got := names(m.VisibleEntries()); len(got) != 2 {
+		t.Errorf("entries = %v, want .env hidden again", got)
+	}
+}
@@ -0,0 +1,138 @@
+package profiles
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/natevick/stui/internal/security"
+)
+
+// maxHistoryProfiles is how many profiles the history remembers; the
+// lowest ranked are forgotten first
+const maxHistoryProfiles = 20
+
+// historyEntry is how often and how recently a profile was used
+type historyEntry struct {
+	Count    int       `json:"count"`
+	LastUsed time.Time `json:"last_used"`
+}
+
+// ProfileHistory records which AWS profiles were switched to, and ranks
+// them for the quick switcher
+type ProfileHistory struct {
+	path    string // empty keeps the history in memory only
+	entries map[string]historyEntry
+	now     func() time.Time
+}
+
+// NewProfileHistory creates an empty history saved to path by Record;
+// an empty path keeps it in memory
+func NewProfileHistory(path string) *ProfileHistory {
+	return &ProfileHistory{
+		path:    path,
+		entries: make(map[string]historyEntry),
+		now:     time.Now,
+	}
+}
+
+// LoadProfileHistory reads the history saved at path. A missing file
+// yields an empty history.
+func LoadProfileHistory(path string) (*ProfileHistory, error) {
+	h := NewProfileHistory(path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return h, nil
+		}
+		return nil, fmt.Errorf("failed to read profile history: %w", err)
+	}
+	if err := json.Unmarshal(data, &h.entries); err != nil {
+		return nil, fmt.Errorf("failed to parse profile history: %w", err)
+	}
+	if h.entries == nil {
+		h.entries = make(map[string]historyEntry)
+	}
+	return h, nil
+}
+
+// Record counts a switch to profile and saves the history
+func (h *ProfileHistory) Record(profile string) error {
+	if err := security.ValidProfileName(profile); err != nil {
+		return err
+	}
+	e := h.entries[profile]
+	e.Count++
+	e.LastUsed = h.now()
+	h.entries[profile] = e
+
+	// Forget the lowest ranked, but never the profile just used
+	ranked := h.Ranked()
+	for i := len(ranked) - 1; i >= 0 && len(h.entries) > maxHistoryProfiles; i-- {
+		if ranked[i] != profile {
+			delete(h.entries, ranked[i])
+		}
+	}
+	return h.save()
+}
+
+// Ranked returns the recorded profiles, best first. A use counts for more
+// the more recent it is, so a profile used often last month falls behind
+// one used a few times today. Names that aren't valid profile names, as in
+// a hand-edited history file, are left out.
+func (h *ProfileHistory) Ranked() []string {
+	now := h.now()
+	names := make([]string, 0, len(h.entries))
+	scores := make(map[string]float64, len(h.entries))
+	for name, e := range h.entries {
+		if security.ValidProfileName(name) != nil {
+			continue
+		}
+		names = append(names, name)
+		scores[name] = float64(e.Count) * recencyWeight(now.Sub(e.LastUsed))
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := names[i], names[j]
+		if scores[a] != scores[b] {
+			return scores[a] > scores[b]
+		}
+		if !h.entries[a].LastUsed.Equal(h.entries[b].LastUsed) {
+			return h.entries[a].LastUsed.After(h.entries[b].LastUsed)
+		}
+		return a < b
+	})
+	return names
+}
+
+// recencyWeight scales a profile's use count by how long ago it was last used
+func recencyWeight(age time.Duration) float64 {
+	switch {
+	case age < 4*time.Hour:
+		return 8
+	case age < 24*time.Hour:
+		return 4
+	case age < 7*24*time.Hour:
+		return 2
+	case age < 30*24*time.Hour:
+		return 1
+	default:
+		return 0.5
+	}
+}
+
+// save writes the history to its path, if it has one
+func (h *ProfileHistory) save() error {
+	if h.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(h.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode profile history: %w", err)
+	}
+	if err := os.WriteFile(h.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write profile history: %w", err)
+	}
+	return nil
+}
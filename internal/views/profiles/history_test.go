@@ -0,0 +1,91 @@
+package profiles
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestProfileHistoryRanked(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	h := NewProfileHistory("")
+	h.now = func() time.Time { return now }
+	h.entries = map[string]historyEntry{
+		// Used a lot, but not for over a month
+		"legacy": {Count: 30, LastUsed: now.Add(-60 * 24 * time.Hour)},
+		// Used a few times this morning
+		"dev": {Count: 3, LastUsed: now.Add(-time.Hour)},
+		// Used daily this week
+		"prod": {Count: 10, LastUsed: now.Add(-2 * 24 * time.Hour)},
+		// Ties with dev on score, but less recent
+		"sandbox":   {Count: 6, LastUsed: now.Add(-5 * time.Hour)},
+		"bad name!": {Count: 100, LastUsed: now},
+	}
+
+	want := []string{"dev", "sandbox", "prod", "legacy"}
+	if got := h.Ranked(); !slices.Equal(got, want) {
+		t.Errorf("Ranked() = %v, want %v", got, want)
+	}
+}
+
+func TestProfileHistoryRecord(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "profile_history.json")
+	h := NewProfileHistory(path)
+	h.now = func() time.Time { return now }
+
+	for _, p := range []string{"dev", "prod", "prod"} {
+		if err := h.Record(p); err != nil {
+			t.Fatalf("Record(%q) error = %v", p, err)
+		}
+	}
+	if err := h.Record("../etc"); err == nil {
+		t.Error("Record(\"../etc\") succeeded, want a validation error")
+	}
+
+	loaded, err := LoadProfileHistory(path)
+	if err != nil {
+		t.Fatalf("LoadProfileHistory() error = %v", err)
+	}
+	loaded.now = h.now
+	if got, want := loaded.Ranked(), []string{"prod", "dev"}; !slices.Equal(got, want) {
+		t.Errorf("loaded Ranked() = %v, want %v", got, want)
+	}
+	if got := loaded.entries["prod"].Count; got != 2 {
+		t.Errorf("prod count = %d, want 2", got)
+	}
+}
+
+func TestProfileHistoryForgetsLowestRanked(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	h := NewProfileHistory("")
+	h.now = func() time.Time { return now }
+	for i := range maxHistoryProfiles {
+		h.entries[string(rune('a'+i))] = historyEntry{Count: 5, LastUsed: now.Add(-time.Hour)}
+	}
+	h.entries["stale"] = historyEntry{Count: 1, LastUsed: now.Add(-90 * 24 * time.Hour)}
+
+	if err := h.Record("new"); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	if len(h.entries) != maxHistoryProfiles {
+		t.Errorf("history holds %d profiles, want %d", len(h.entries), maxHistoryProfiles)
+	}
+	if _, ok := h.entries["stale"]; ok {
+		t.Error("stale profile kept, want it forgotten first")
+	}
+	if _, ok := h.entries["new"]; !ok {
+		t.Error("newly recorded profile forgotten")
+	}
+}
+
+func TestLoadProfileHistoryMissing(t *testing.T) {
+	h, err := LoadProfileHistory(filepath.Join(t.TempDir(), "none.json"))
+	if err != nil {
+		t.Fatalf("LoadProfileHistory() error = %v", err)
+	}
+	if got := h.Ranked(); len(got) != 0 {
+		t.Errorf("Ranked() = %v, want empty", got)
+	}
+}
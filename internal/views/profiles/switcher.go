@@ -0,0 +1,104 @@
+package profiles
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/natevick/stui/internal/security"
+)
+
+// maxSwitcherProfiles is how many profiles the quick switcher offers
+const maxSwitcherProfiles = 9
+
+// Switcher is a quick profile switcher: the most used profiles, each a
+// number key away. Choosing one sends SelectedMsg.
+type Switcher struct {
+	profiles []string
+	current  string
+	cursor   int
+}
+
+// NewSwitcher creates a switcher offering profiles in order, marking current
+func NewSwitcher(profiles []string, current string) Switcher {
+	s := Switcher{current: current}
+	seen := make(map[string]bool)
+	for _, name := range profiles {
+		if seen[name] || security.ValidProfileName(name) != nil {
+			continue
+		}
+		seen[name] = true
+		s.profiles = append(s.profiles, name)
+		if len(s.profiles) == maxSwitcherProfiles {
+			break
+		}
+	}
+	return s
+}
+
+// Profiles returns the profiles offered, in order
+func (s Switcher) Profiles() []string {
+	return s.profiles
+}
+
+// Update moves the cursor and picks a profile with enter or its number
+func (s Switcher) Update(msg tea.Msg) (Switcher, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok || len(s.profiles) == 0 {
+		return s, nil
+	}
+
+	switch k := keyMsg.String(); k {
+	case "up", "k":
+		if s.cursor > 0 {
+			s.cursor--
+		}
+	case "down", "j":
+		if s.cursor < len(s.profiles)-1 {
+			s.cursor++
+		}
+	case "enter":
+		return s, s.choose(s.cursor)
+	default:
+		if len(k) == 1 && k[0] >= '1' && k[0] <= '9' {
+			if i := int(k[0] - '1'); i < len(s.profiles) {
+				s.cursor = i
+				return s, s.choose(i)
+			}
+		}
+	}
+	return s, nil
+}
+
+// choose selects the profile at index i
+func (s Switcher) choose(i int) tea.Cmd {
+	profile := s.profiles[i]
+	return func() tea.Msg {
+		return SelectedMsg{Profile: profile}
+	}
+}
+
+// View renders the switcher's lines, for the caller to frame
+func (s Switcher) View() string {
+	title := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("39")).Render("Switch Profile")
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	selected := lipgloss.NewStyle().Foreground(lipgloss.Color("255")).Background(lipgloss.Color("39")).Bold(true)
+
+	lines := []string{title, ""}
+	if len(s.profiles) == 0 {
+		lines = append(lines, dim.Render("No profiles used yet"))
+	}
+	for i, name := range s.profiles {
+		line := fmt.Sprintf("%d  %s", i+1, name)
+		if name == s.current {
+			line += "  (current)"
+		}
+		if i == s.cursor {
+			line = selected.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, "", dim.Render("1-9 or enter to switch • esc to close"))
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,38 @@
+package profiles
+
+import (
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestSwitcherProfiles(t *testing.T) {
+	s := NewSwitcher([]string{"dev", "prod", "dev", "bad name!", "sandbox"}, "prod")
+	if got, want := s.Profiles(), []string{"dev", "prod", "sandbox"}; !slices.Equal(got, want) {
+		t.Errorf("Profiles() = %v, want %v", got, want)
+	}
+}
+
+func TestSwitcherSelect(t *testing.T) {
+	s := NewSwitcher([]string{"dev", "prod", "sandbox"}, "dev")
+
+	_, cmd := s.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("2")})
+	if cmd == nil {
+		t.Fatal("number key returned no command")
+	}
+	if msg, ok := cmd().(SelectedMsg); !ok || msg.Profile != "prod" {
+		t.Errorf("number key sent %#v, want SelectedMsg for prod", cmd())
+	}
+
+	s, _ = s.Update(tea.KeyMsg{Type: tea.KeyDown})
+	s, _ = s.Update(tea.KeyMsg{Type: tea.KeyDown})
+	_, cmd = s.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if msg, ok := cmd().(SelectedMsg); !ok || msg.Profile != "sandbox" {
+		t.Errorf("enter sent %#v, want SelectedMsg for sandbox", cmd())
+	}
+
+	if _, cmd := s.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("9")}); cmd != nil {
+		t.Error("number key past the list returned a command")
+	}
+}
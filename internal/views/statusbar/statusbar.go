@@ -0,0 +1,284 @@
+package statusbar
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/dustin/go-humanize"
+	"github.com/natevick/stui/internal/aws"
+	"github.com/natevick/stui/internal/download"
+)
+
+const (
+	separator = " │ "
+	ellipsis  = "…"
+)
+
+// Model aggregates connection, location, and transfer state into a single line.
+// All values are expected to be sanitized by the caller; control characters are
+// stripped as a last line of defense so nothing can corrupt the terminal.
+type Model struct {
+	connected  bool
+	profile    string
+	region     string
+	credExpiry time.Time
+	problem    string
+	bucket     string
+	prefix     string
+	prefixSize int64
+	sizing     bool
+	transfer   download.Progress
+	readOnly   bool
+	width      int
+
+	now func() time.Time
+}
+
+// New creates a new status bar
+func New() Model {
+	return Model{now: time.Now}
+}
+
+// SetSize sets the available width
+func (m *Model) SetSize(width int) {
+	m.width = width
+}
+
+// SetConnected sets whether an AWS client is ready
+func (m *Model) SetConnected(connected bool) {
+	m.connected = connected
+}
+
+// SetProfile sets the active profile name
+func (m *Model) SetProfile(profile string) {
+	m.profile = profile
+}
+
+// SetRegion sets the active region
+func (m *Model) SetRegion(region string) {
+	m.region = region
+}
+
+// SetCredentialExpiry sets when the current credentials expire (zero if they don't)
+func (m *Model) SetCredentialExpiry(t time.Time) {
+	m.credExpiry = t
+}
+
+// SetProblem sets a connection problem to show next to the profile; empty clears it
+func (m *Model) SetProblem(problem string) {
+	m.problem = problem
+}
+
+// SetReadOnly sets whether changes to S3 are disabled
+func (m *Model) SetReadOnly(readOnly bool) {
+	m.readOnly = readOnly
+}
+
+// SetLocation sets the current bucket and prefix
+func (m *Model) SetLocation(bucket, prefix string) {
+	m.bucket = bucket
+	m.prefix = prefix
+}
+
+// SetPrefixSize sets the total size of the current prefix's objects; partial
+// marks a running total while the listing is still loading. Zero hides it.
+func (m *Model) SetPrefixSize(size int64, partial bool) {
+	m.prefixSize = size
+	m.sizing = partial
+}
+
+// SetTransfer sets the aggregate transfer progress
+func (m *Model) SetTransfer(p download.Progress) {
+	m.transfer = p
+}
+
+// View renders the status line, truncated to the configured width
+func (m Model) View() string {
+	return m.Render(m.width)
+}
+
+// Render renders the status line for the given width. The location segment is
+// shortened first (keeping its tail, which is the most specific part), then the
+// whole line is cut at the right edge if it still doesn't fit.
+func (m Model) Render(width int) string {
+	head := m.headSegments()
+	location := m.locationSegment()
+	tail := m.transferSegment()
+
+	segments := append([]string{}, head...)
+	if location != "" {
+		segments = append(segments, location)
+	}
+	if tail != "" {
+		segments = append(segments, tail)
+	}
+	line := strings.Join(segments, separator)
+	if width <= 0 || lipgloss.Width(line) <= width {
+		return line
+	}
+
+	// Shrink the location to whatever room is left
+	if location != "" {
+		fixed := append([]string{}, head...)
+		if tail != "" {
+			fixed = append(fixed, tail)
+		}
+		room := width - lipgloss.Width(strings.Join(fixed, separator)) - lipgloss.Width(separator)
+		if room > lipgloss.Width(ellipsis) {
+			location = truncateLeft(location, room)
+			segments = append(append([]string{}, head...), location)
+			if tail != "" {
+				segments = append(segments, tail)
+			}
+			line = strings.Join(segments, separator)
+			if lipgloss.Width(line) <= width {
+				return line
+			}
+		}
+	}
+
+	return truncateRight(line, width)
+}
+
+func (m Model) headSegments() []string {
+	var segments []string
+
+	if m.connected {
+		segments = append(segments, "● "+m.profileDisplay())
+	} else {
+		segments = append(segments, "○ "+m.profileDisplay())
+	}
+
+	if m.region != "" {
+		segments = append(segments, clean(m.region))
+	}
+
+	if m.readOnly {
+		segments = append(segments, "read-only")
+	}
+
+	if m.problem != "" {
+		segments = append(segments, "⚠ "+clean(m.problem))
+	}
+
+	if !m.credExpiry.IsZero() {
+		remaining := m.credExpiry.Sub(m.now())
+		if remaining <= 0 {
+			segments = append(segments, "creds expired")
+		} else {
+			segments = append(segments, "creds "+formatCountdown(remaining))
+		}
+	}
+
+	return segments
+}
+
+func (m Model) profileDisplay() string {
+	if m.profile == "" {
+		return "default"
+	}
+	return clean(m.profile)
+}
+
+func (m Model) locationSegment() string {
+	if m.bucket == "" {
+		return ""
+	}
+	location := clean(aws.FormatS3URI(m.bucket, m.prefix))
+	// Appended so that shortening the location from the left keeps it
+	if m.prefixSize > 0 {
+		location += " · " + humanize.Bytes(uint64(m.prefixSize))
+		if m.sizing {
+			location += ellipsis
+		}
+	}
+	return location
+}
+
+func (m Model) transferSegment() string {
+	p := m.transfer
+	if p.TotalFiles == 0 {
+		return ""
+	}
+	switch p.Status {
+	case download.StatusInProgress, download.StatusPending:
+		return fmt.Sprintf("⏬ %d/%d %.0f%%", p.CompletedFiles, p.TotalFiles, p.PercentComplete())
+	case download.StatusFailed:
+		return fmt.Sprintf("⏬ %d failed", p.FailedFiles)
+	default:
+		return ""
+	}
+}
+
+// formatCountdown renders a duration as a compact countdown (e.g. 1h05m, 42m, 30s)
+func formatCountdown(d time.Duration) string {
+	switch {
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	default:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	}
+}
+
+// clean strips control characters so values can't inject terminal escapes
+func clean(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// truncateRight cuts s to fit width cells, ending with an ellipsis.
+// Works on runes so multibyte characters are never split.
+func truncateRight(s string, width int) string {
+	if lipgloss.Width(s) <= width {
+		return s
+	}
+	limit := width - lipgloss.Width(ellipsis)
+	if limit <= 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	used := 0
+	for _, r := range s {
+		w := lipgloss.Width(string(r))
+		if used+w > limit {
+			break
+		}
+		sb.WriteRune(r)
+		used += w
+	}
+	return sb.String() + ellipsis
+}
+
+// truncateLeft cuts s to fit width cells, keeping the tail and prefixing an ellipsis
+func truncateLeft(s string, width int) string {
+	if lipgloss.Width(s) <= width {
+		return s
+	}
+	limit := width - lipgloss.Width(ellipsis)
+	if limit <= 0 {
+		return ""
+	}
+
+	runes := []rune(s)
+	used := 0
+	start := len(runes)
+	for i := len(runes) - 1; i >= 0; i-- {
+		w := lipgloss.Width(string(runes[i]))
+		if used+w > limit {
+			break
+		}
+		used += w
+		start = i
+	}
+	return ellipsis + string(runes[start:])
+}
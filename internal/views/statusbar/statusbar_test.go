@@ -0,0 +1,146 @@
+package statusbar
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/natevick/stui/internal/download"
+)
+
+func newTestModel() Model {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	m := New()
+	m.now = func() time.Time { return now }
+	m.SetConnected(true)
+	m.SetProfile("dev-admin")
+	m.SetRegion("us-west-2")
+	m.SetCredentialExpiry(now.Add(42 * time.Minute))
+	m.SetLocation("my-bucket", "logs/2024/")
+	m.SetTransfer(download.Progress{
+		TotalFiles:      4,
+		CompletedFiles:  1,
+		TotalBytes:      100,
+		DownloadedBytes: 50,
+		Status:          download.StatusInProgress,
+	})
+	return m
+}
+
+func TestRenderWide(t *testing.T) {
+	m := newTestModel()
+
+	got := m.Render(200)
+	want := "● dev-admin │ us-west-2 │ creds 42m │ s3://my-bucket/logs/2024/ │ ⏬ 1/4 50%"
+	if got != want {
+		t.Errorf("Render(200) = %q, want %q", got, want)
+	}
+}
+
+func TestRenderTruncatesLocationFirst(t *testing.T) {
+	m := newTestModel()
+	m.SetLocation("my-bucket", "a/very/deep/prefix/that/goes/on/")
+
+	full := m.Render(0)
+	width := lipgloss.Width(full) - 10
+
+	got := m.Render(width)
+	if w := lipgloss.Width(got); w > width {
+		t.Errorf("rendered width %d exceeds %d: %q", w, width, got)
+	}
+	if !strings.Contains(got, "…") {
+		t.Errorf("expected ellipsis in %q", got)
+	}
+	// Profile and transfer survive; only the location is shortened
+	if !strings.HasPrefix(got, "● dev-admin") || !strings.HasSuffix(got, "⏬ 1/4 50%") {
+		t.Errorf("expected profile and transfer to be kept, got %q", got)
+	}
+	if !strings.Contains(got, "on/") {
+		t.Errorf("expected the tail of the prefix to be kept, got %q", got)
+	}
+}
+
+func TestRenderNarrowMultibyte(t *testing.T) {
+	m := newTestModel()
+	m.SetLocation("my-bucket", "日本語/データ/ファイル/")
+
+	for width := 1; width <= 40; width++ {
+		got := m.Render(width)
+		if !utf8.ValidString(got) {
+			t.Fatalf("Render(%d) produced invalid UTF-8: %q", width, got)
+		}
+		if w := lipgloss.Width(got); w > width {
+			t.Errorf("Render(%d) width = %d: %q", width, w, got)
+		}
+	}
+}
+
+func TestRenderStripsControlCharacters(t *testing.T) {
+	m := newTestModel()
+	m.SetProfile("evil\x1b[31mprofile")
+
+	got := m.Render(200)
+	if strings.ContainsRune(got, '\x1b') {
+		t.Errorf("expected control characters to be stripped, got %q", got)
+	}
+}
+
+func TestRenderExpiredCredentials(t *testing.T) {
+	m := newTestModel()
+	m.SetCredentialExpiry(m.now().Add(-time.Minute))
+
+	if got := m.Render(200); !strings.Contains(got, "creds expired") {
+		t.Errorf("expected expired credentials notice, got %q", got)
+	}
+}
+
+func TestRenderProblem(t *testing.T) {
+	m := newTestModel()
+	m.SetConnected(false)
+	m.SetProblem("Connection check: connection error - check your network")
+
+	got := m.Render(200)
+	if !strings.HasPrefix(got, "○ dev-admin") || !strings.Contains(got, "⚠ Connection check: connection error") {
+		t.Errorf("expected disconnected profile and problem, got %q", got)
+	}
+
+	m.SetProblem("")
+	if got := m.Render(200); strings.Contains(got, "⚠") {
+		t.Errorf("expected problem to be cleared, got %q", got)
+	}
+}
+
+func TestRenderReadOnly(t *testing.T) {
+	m := newTestModel()
+	m.SetReadOnly(true)
+
+	got := m.Render(200)
+	if !strings.HasPrefix(got, "● dev-admin │ us-west-2 │ read-only │ ") {
+		t.Errorf("expected read-only marker after the region, got %q", got)
+	}
+}
+
+func TestRenderPrefixSize(t *testing.T) {
+	m := newTestModel()
+	m.SetTransfer(download.Progress{})
+
+	m.SetPrefixSize(1500, true)
+	if got, want := m.Render(200), "s3://my-bucket/logs/2024/ · 1.5 kB…"; !strings.HasSuffix(got, want) {
+		t.Errorf("Render() while listing = %q, want suffix %q", got, want)
+	}
+	m.SetPrefixSize(2500, false)
+	if got, want := m.Render(200), "s3://my-bucket/logs/2024/ · 2.5 kB"; !strings.HasSuffix(got, want) {
+		t.Errorf("Render() when listed = %q, want suffix %q", got, want)
+	}
+
+	// Shortening the location keeps the size
+	if got := m.Render(60); !strings.HasSuffix(got, "· 2.5 kB") {
+		t.Errorf("Render(60) = %q, want the size kept", got)
+	}
+	m.SetPrefixSize(0, false)
+	if got := m.Render(200); strings.Contains(got, "·") {
+		t.Errorf("Render() with no size = %q", got)
+	}
+}
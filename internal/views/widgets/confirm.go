@@ -0,0 +1,102 @@
+package widgets
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// TypedConfirm guards a destructive action behind typing a word, such as
+// the bucket's name. It only collects input; the caller decides what Enter
+// and Esc do.
+type TypedConfirm struct {
+	required  string
+	input     []rune
+	trimSpace bool
+}
+
+// NewTypedConfirm creates a confirmation that needs required typed exactly
+func NewTypedConfirm(required string) TypedConfirm {
+	return TypedConfirm{required: required}
+}
+
+// SetTrimSpace makes leading and trailing whitespace in the input ignored
+func (c *TypedConfirm) SetTrimSpace(trim bool) {
+	c.trimSpace = trim
+}
+
+// Required returns the text that must be typed
+func (c TypedConfirm) Required() string {
+	return c.required
+}
+
+// Input returns what has been typed so far
+func (c TypedConfirm) Input() string {
+	return string(c.input)
+}
+
+// SetInput replaces the typed text
+func (c *TypedConfirm) SetInput(s string) {
+	c.input = []rune(clean(s))
+}
+
+// Reset clears the typed text
+func (c *TypedConfirm) Reset() {
+	c.input = nil
+}
+
+// typed is the input as compared, trimmed if SetTrimSpace is on
+func (c TypedConfirm) typed() string {
+	if c.trimSpace {
+		return strings.TrimSpace(string(c.input))
+	}
+	return string(c.input)
+}
+
+// Confirmed reports whether the input matches the required text exactly,
+// case included. An empty required text is never confirmed.
+func (c TypedConfirm) Confirmed() bool {
+	return c.required != "" && c.typed() == c.required
+}
+
+// Progress returns how much of the required text has been typed correctly,
+// from 0 to 1. Only the matching start of the input counts, so a typo stops
+// progress until it is deleted, and extra input past the end lowers it; 1
+// means Confirmed.
+func (c TypedConfirm) Progress() float64 {
+	want := []rune(c.required)
+	if len(want) == 0 {
+		return 0
+	}
+	got := []rune(c.typed())
+	matched := 0
+	for matched < len(want) && matched < len(got) && got[matched] == want[matched] {
+		matched++
+	}
+	return float64(matched) / float64(max(len(want), len(got)))
+}
+
+// Update edits the input on typing, Backspace, and Ctrl+U (clear)
+func (c TypedConfirm) Update(msg tea.Msg) (TypedConfirm, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return c, nil
+	}
+	switch key.Type {
+	case tea.KeyRunes, tea.KeySpace:
+		c.input = append(c.input, []rune(clean(string(key.Runes)))...)
+	case tea.KeyBackspace:
+		if len(c.input) > 0 {
+			c.input = c.input[:len(c.input)-1]
+		}
+	case tea.KeyCtrlU:
+		c.input = nil
+	}
+	return c, nil
+}
+
+// View shows the instruction and the input with a cursor
+func (c TypedConfirm) View() string {
+	return fmt.Sprintf("Type %q to confirm: %s█", clean(c.required), string(c.input))
+}
@@ -0,0 +1,97 @@
+package widgets
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func typeText(c TypedConfirm, s string) TypedConfirm {
+	for _, r := range s {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}}
+		if r == ' ' {
+			msg = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{r}}
+		}
+		c, _ = c.Update(msg)
+	}
+	return c
+}
+
+func TestTypedConfirmExactMatch(t *testing.T) {
+	c := NewTypedConfirm("prod-data")
+	if c.Confirmed() || c.Progress() != 0 {
+		t.Fatalf("fresh confirm: Confirmed() = %v, Progress() = %v", c.Confirmed(), c.Progress())
+	}
+
+	c = typeText(c, "prod-")
+	if c.Confirmed() {
+		t.Error("Confirmed() = true halfway through")
+	}
+	if got, want := c.Progress(), 5.0/9; got != want {
+		t.Errorf("Progress() = %v, want %v", got, want)
+	}
+
+	c = typeText(c, "data")
+	if !c.Confirmed() || c.Progress() != 1 {
+		t.Errorf("after typing it all: Confirmed() = %v, Progress() = %v", c.Confirmed(), c.Progress())
+	}
+
+	c, _ = c.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	if c.Confirmed() || c.Input() != "prod-dat" {
+		t.Errorf("after Backspace: Confirmed() = %v, Input() = %q", c.Confirmed(), c.Input())
+	}
+	c, _ = c.Update(tea.KeyMsg{Type: tea.KeyCtrlU})
+	if c.Input() != "" {
+		t.Errorf("after Ctrl+U: Input() = %q, want empty", c.Input())
+	}
+}
+
+func TestTypedConfirmNearMatch(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		wantProgress float64
+	}{
+		{"different case", "Prod-data", 0},
+		{"typo", "prod-dota", 6.0 / 9},
+		{"too long", "prod-data-2", 9.0 / 11},
+		{"prefix", "prod", 4.0 / 9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewTypedConfirm("prod-data")
+			c.SetInput(tt.input)
+			if c.Confirmed() {
+				t.Errorf("Confirmed() = true for %q", tt.input)
+			}
+			if got := c.Progress(); got != tt.wantProgress {
+				t.Errorf("Progress() = %v, want %v", got, tt.wantProgress)
+			}
+		})
+	}
+
+	if c := NewTypedConfirm(""); c.Confirmed() {
+		t.Error("an empty required text was confirmed")
+	}
+}
+
+func TestTypedConfirmTrimSpace(t *testing.T) {
+	c := typeText(NewTypedConfirm("delete"), " delete ")
+	if c.Confirmed() {
+		t.Error("Confirmed() = true with surrounding spaces while trimming is off")
+	}
+
+	c.SetTrimSpace(true)
+	if !c.Confirmed() || c.Progress() != 1 {
+		t.Errorf("with trimming: Confirmed() = %v, Progress() = %v", c.Confirmed(), c.Progress())
+	}
+	if c.Input() != " delete " {
+		t.Errorf("Input() = %q, want the text as typed", c.Input())
+	}
+
+	// Only the ends are trimmed
+	c.SetInput("del ete")
+	if c.Confirmed() {
+		t.Error("Confirmed() = true with a space inside the word")
+	}
+}
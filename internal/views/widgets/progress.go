@@ -0,0 +1,174 @@
+// Package widgets holds small rendering components shared by the views.
+package widgets
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// SpinnerFrames are the frames an indeterminate ProgressModel cycles through
+var SpinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// SpinnerInterval is how often TickCmd advances the spinner
+const SpinnerInterval = 100 * time.Millisecond
+
+// minBarWidth is the narrowest bar worth drawing; below it the label is cut first
+const minBarWidth = 10
+
+const ellipsis = "…"
+
+// lastID hands out IDs so each ProgressModel only reacts to its own ticks
+var lastID atomic.Int64
+
+// TickMsg advances the spinner of the ProgressModel with the matching ID
+type TickMsg struct {
+	ID int64
+}
+
+// ProgressModel shows progress as a percentage bar (determinate) or a
+// spinner (indeterminate), with a label
+type ProgressModel struct {
+	id          int64
+	label       string
+	percent     float64
+	determinate bool
+	frame       int
+}
+
+// NewProgress creates an indeterminate progress model
+func NewProgress(label string) ProgressModel {
+	return ProgressModel{id: lastID.Add(1), label: label}
+}
+
+// SetLabel changes the label
+func (p *ProgressModel) SetLabel(label string) {
+	p.label = label
+}
+
+// SetPercent switches to determinate mode; pct is a fraction from 0 to 1
+func (p *ProgressModel) SetPercent(pct float64) {
+	p.determinate = true
+	p.percent = min(max(pct, 0), 1)
+}
+
+// SetIndeterminate switches to spinner mode
+func (p *ProgressModel) SetIndeterminate() {
+	p.determinate = false
+}
+
+// Determinate reports whether a percentage is shown
+func (p ProgressModel) Determinate() bool {
+	return p.determinate
+}
+
+// Percent returns the completed fraction, from 0 to 1
+func (p ProgressModel) Percent() float64 {
+	return p.percent
+}
+
+// Tick advances the spinner one frame
+func (p *ProgressModel) Tick() {
+	p.frame = (p.frame + 1) % len(SpinnerFrames)
+}
+
+// TickCmd schedules the next spinner frame
+func (p ProgressModel) TickCmd() tea.Cmd {
+	id := p.id
+	return tea.Tick(SpinnerInterval, func(time.Time) tea.Msg {
+		return TickMsg{ID: id}
+	})
+}
+
+// Update advances the spinner on its own TickMsg and schedules the next one.
+// Ticks stop once the model is determinate.
+func (p ProgressModel) Update(msg tea.Msg) (ProgressModel, tea.Cmd) {
+	if tick, ok := msg.(TickMsg); ok && tick.ID == p.id && !p.determinate {
+		p.Tick()
+		return p, p.TickCmd()
+	}
+	return p, nil
+}
+
+// Render draws the model in at most width cells. The label is shortened
+// before the bar, and the bar before the percentage.
+func (p ProgressModel) Render(width int) string {
+	if width <= 0 {
+		return ""
+	}
+	label := clean(p.label)
+
+	if !p.determinate {
+		line := SpinnerFrames[p.frame]
+		if label != "" {
+			line += " " + label
+		}
+		return Truncate(line, width)
+	}
+
+	pct := fmt.Sprintf("%3.0f%%", p.percent*100)
+	room := width - lipgloss.Width(pct) - 1 // space before the percentage
+	if room < 1 {
+		return Truncate(strings.TrimSpace(pct), width)
+	}
+
+	if label != "" {
+		labelRoom := room - minBarWidth - 1
+		if labelRoom < 1 {
+			label = ""
+		} else {
+			label = Truncate(label, labelRoom)
+			room -= lipgloss.Width(label) + 1
+		}
+	}
+
+	bar := renderBar(p.percent, room)
+	if label == "" {
+		return bar + " " + pct
+	}
+	return label + " " + bar + " " + pct
+}
+
+// renderBar draws a bar width cells wide with a filled fraction of pct
+func renderBar(pct float64, width int) string {
+	filled := int(pct * float64(width))
+	return strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+}
+
+// Truncate cuts s to fit width cells, ending with an ellipsis. It works on
+// runes and their display width, so wide characters are never split.
+func Truncate(s string, width int) string {
+	if lipgloss.Width(s) <= width {
+		return s
+	}
+	limit := width - lipgloss.Width(ellipsis)
+	if limit <= 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	used := 0
+	for _, r := range s {
+		w := lipgloss.Width(string(r))
+		if used+w > limit {
+			break
+		}
+		sb.WriteRune(r)
+		used += w
+	}
+	return sb.String() + ellipsis
+}
+
+// clean drops control characters and newlines so a label can't break the layout
+func clean(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, s)
+}
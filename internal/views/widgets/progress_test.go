@@ -0,0 +1,136 @@
+package widgets
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestRenderDeterminate(t *testing.T) {
+	tests := []struct {
+		pct        float64
+		wantFilled int
+		wantPct    string
+	}{
+		{0, 0, "  0%"},
+		{0.25, 5, " 25%"},
+		{0.5, 10, " 50%"},
+		{1, 20, "100%"},
+		{1.5, 20, "100%"}, // clamped
+	}
+
+	for _, tt := range tests {
+		p := NewProgress("")
+		p.SetPercent(tt.pct)
+		// 20-cell bar, a space, and the 4-cell percentage
+		got := p.Render(25)
+
+		if !strings.HasSuffix(got, tt.wantPct) {
+			t.Errorf("Render at %v = %q, want suffix %q", tt.pct, got, tt.wantPct)
+		}
+		if n := strings.Count(got, "█"); n != tt.wantFilled {
+			t.Errorf("Render at %v has %d filled cells, want %d: %q", tt.pct, n, tt.wantFilled, got)
+		}
+		if w := lipgloss.Width(got); w != 25 {
+			t.Errorf("Render at %v is %d cells wide, want 25", tt.pct, w)
+		}
+	}
+}
+
+func TestRenderDeterminateWithLabel(t *testing.T) {
+	p := NewProgress("Uploading")
+	p.SetPercent(0.5)
+	got := p.Render(40)
+	if !strings.HasPrefix(got, "Uploading ") || !strings.HasSuffix(got, " 50%") {
+		t.Errorf("Render = %q, want label, bar, and percentage", got)
+	}
+	if w := lipgloss.Width(got); w != 40 {
+		t.Errorf("Render is %d cells wide, want 40", w)
+	}
+}
+
+func TestIndeterminateFramesAdvance(t *testing.T) {
+	p := NewProgress("Listing")
+	first := p.Render(40)
+	if !strings.HasPrefix(first, SpinnerFrames[0]) || !strings.HasSuffix(first, "Listing") {
+		t.Fatalf("Render = %q, want spinner frame and label", first)
+	}
+
+	p, cmd := p.Update(TickMsg{ID: p.id})
+	if cmd == nil {
+		t.Error("Update did not schedule the next tick")
+	}
+	if got := p.Render(40); !strings.HasPrefix(got, SpinnerFrames[1]) {
+		t.Errorf("after a tick Render = %q, want frame %q", got, SpinnerFrames[1])
+	}
+
+	// Another model's tick is ignored
+	other := NewProgress("")
+	if p2, cmd := p.Update(TickMsg{ID: other.id}); cmd != nil || p2.frame != p.frame {
+		t.Error("model advanced on another model's tick")
+	}
+
+	// Frames wrap around
+	for range len(SpinnerFrames) - 1 {
+		p.Tick()
+	}
+	if p.frame != 0 {
+		t.Errorf("frame = %d after a full cycle, want 0", p.frame)
+	}
+
+	// Determinate models stop ticking
+	p.SetPercent(0.1)
+	if _, cmd := p.Update(TickMsg{ID: p.id}); cmd != nil {
+		t.Error("determinate model kept ticking")
+	}
+}
+
+func TestRenderTruncatesMultibyteLabels(t *testing.T) {
+	label := "日本語のファイルをアップロード中"
+
+	for _, width := range []int{8, 15, 24, 30} {
+		p := NewProgress(label)
+		for _, determinate := range []bool{false, true} {
+			if determinate {
+				p.SetPercent(0.3)
+			}
+			got := p.Render(width)
+			if w := lipgloss.Width(got); w > width {
+				t.Errorf("width %d determinate=%v: %q is %d cells", width, determinate, got, w)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("width %d: %q split a multibyte character", width, got)
+			}
+			if determinate && !strings.HasSuffix(got, "30%") {
+				t.Errorf("width %d: %q dropped the percentage", width, got)
+			}
+		}
+	}
+
+	// Wide enough for the whole label: nothing is cut
+	p := NewProgress(label)
+	if got := p.Render(60); !strings.Contains(got, label) {
+		t.Errorf("Render(60) = %q, want the full label", got)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		in    string
+		width int
+		want  string
+	}{
+		{"short", 10, "short"},
+		{"exactly10!", 10, "exactly10!"},
+		{"longer than ten", 10, "longer th…"},
+		{"日本語のキー", 7, "日本語…"},
+		{"abc", 1, ""},
+	}
+	for _, tt := range tests {
+		if got := Truncate(tt.in, tt.width); got != tt.want {
+			t.Errorf("Truncate(%q, %d) = %q, want %q", tt.in, tt.width, got, tt.want)
+		}
+	}
+}